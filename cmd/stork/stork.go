@@ -4,10 +4,21 @@ import (
 	"flag"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/libopenstorage/stork/drivers/volume"
+	"github.com/libopenstorage/stork/drivers/volume/breaker"
+	_ "github.com/libopenstorage/stork/drivers/volume/csi"
+	"github.com/libopenstorage/stork/drivers/volume/external"
+	_ "github.com/libopenstorage/stork/drivers/volume/gce"
+	_ "github.com/libopenstorage/stork/drivers/volume/hostpath"
+	_ "github.com/libopenstorage/stork/drivers/volume/linstor"
+	"github.com/libopenstorage/stork/drivers/volume/multi"
 	_ "github.com/libopenstorage/stork/drivers/volume/portworx"
+	_ "github.com/libopenstorage/stork/drivers/volume/rbd"
+	storkclientset "github.com/libopenstorage/stork/pkg/client/clientset/versioned"
 	"github.com/libopenstorage/stork/pkg/cluster"
 	"github.com/libopenstorage/stork/pkg/clusterdomains"
 	"github.com/libopenstorage/stork/pkg/controller"
@@ -22,6 +33,7 @@ import (
 	"github.com/libopenstorage/stork/pkg/schedule"
 	"github.com/libopenstorage/stork/pkg/snapshot"
 	"github.com/libopenstorage/stork/pkg/version"
+	"github.com/libopenstorage/stork/pkg/webhookadmission"
 	log "github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
 	api_v1 "k8s.io/api/core/v1"
@@ -37,12 +49,14 @@ import (
 )
 
 const (
-	defaultLockObjectName      = "stork"
-	defaultLockObjectNamespace = "kube-system"
-	eventComponentName         = "stork"
+	defaultLockObjectName       = "stork"
+	defaultLockObjectNamespace  = "kube-system"
+	eventComponentName          = "stork"
+	defaultDriverDegradedPeriod = 30
 )
 
 var ext *extender.Extender
+var webhookController *webhookadmission.Controller
 
 func main() {
 	// Parse empty flags to suppress warnings from the snapshotter which uses
@@ -69,7 +83,23 @@ func main() {
 		},
 		cli.StringFlag{
 			Name:  "driver,d",
-			Usage: "Storage driver name",
+			Usage: "Storage driver name. Multiple drivers can be used with a comma separated list, in which case the first driver is used for snapshots, migrations and cluster domains",
+		},
+		cli.StringFlag{
+			Name:  "driver-socket",
+			Usage: "Unix socket where an out-of-tree driver plugin is serving the driver plugin service. The plugin is used as the first driver from the driver option",
+		},
+		cli.Int64Flag{
+			Name:  "driver-timeout",
+			Usage: "Time in seconds after which calls to the storage driver time out (default: 0, disabled)",
+		},
+		cli.Int64Flag{
+			Name:  "driver-failure-threshold",
+			Usage: "Number of consecutive failed or timed out calls after which the storage driver is marked degraded and calls to it fail immediately (default: 0, disabled)",
+		},
+		cli.Int64Flag{
+			Name:  "driver-degraded-period",
+			Usage: "Time in seconds for which calls fail immediately once the storage driver is marked degraded (default: 30)",
 		},
 		cli.BoolTFlag{
 			Name:  "leader-elect",
@@ -89,10 +119,36 @@ func main() {
 			Name:  "snapshotter",
 			Usage: "Enable snapshotter (default: true)",
 		},
+		cli.Int64Flag{
+			Name:  "snapshot-gc-grace-period",
+			Usage: "The time in minutes after which snapshots, including CSI snapshots, created by schedules with the Delete reclaim policy are deleted once their schedule or PVC no longer exists (default: 0, disabled)",
+		},
 		cli.BoolTFlag{
 			Name:  "extender",
 			Usage: "Enable scheduler extender for hyperconvergence (default: true)",
 		},
+		cli.Int64Flag{
+			Name:  "extender-cache-ttl",
+			Usage: "Time in seconds for which the extender caches node and volume info from the storage driver. Nothing is cached if set to 0 (default: 0)",
+		},
+		cli.StringFlag{
+			Name:  "extender-scores-configmap",
+			Usage: "Name of the config map used to configure the scores for node, rack, zone and region locality in the extender",
+		},
+		cli.StringFlag{
+			Name:  "extender-scores-configmap-namespace",
+			Value: "kube-system",
+			Usage: "Namespace of the config map used to configure the scores in the extender",
+		},
+		cli.BoolFlag{
+			Name:  "extender-storageclass-opt-in",
+			Usage: "Only consider volumes from storage classes with the stork.libopenstorage.org/extender-enabled annotation set to true in the extender (default: false)",
+		},
+		cli.StringFlag{
+			Name:  "extender-degradation-policy",
+			Value: extender.DegradationPolicyFailOpen,
+			Usage: "Policy used by the extender to schedule pods when the storage driver can't be reached. One of fail-open, fail-closed or cached",
+		},
 		cli.BoolTFlag{
 			Name:  "health-monitor",
 			Usage: "Enable health monitoring of the storage driver (default: true)",
@@ -101,6 +157,58 @@ func main() {
 			Name:  "health-monitor-interval",
 			Usage: "The interval in seconds to monitor the health of the storage driver (default: 120, min: 30)",
 		},
+		cli.Int64Flag{
+			Name:  "health-monitor-grace-period",
+			Usage: "The time in seconds for which the storage on a node needs to be offline before pods using it are deleted (default: 0)",
+		},
+		cli.Int64Flag{
+			Name:  "health-monitor-eviction-backoff",
+			Usage: "The minimum time in seconds before a pod with the same name is deleted again by the health monitor (default: 0)",
+		},
+		cli.BoolFlag{
+			Name:  "health-monitor-use-eviction-api",
+			Usage: "Use the eviction API to delete pods from nodes with offline storage so that PodDisruptionBudgets are honored (default: false)",
+		},
+		cli.BoolFlag{
+			Name:  "health-monitor-force-detach",
+			Usage: "Force detach ReadWriteOnce volumes from nodes with offline storage once the pods using them have been deleted (default: false)",
+		},
+		cli.BoolFlag{
+			Name:  "health-monitor-shared-volume-failover",
+			Usage: "Move shared volumes off nodes with offline storage serving them and restart the pods on other nodes using them (default: false)",
+		},
+		cli.BoolTFlag{
+			Name:  "health-monitor-node-storage-status",
+			Usage: "Publish the status of the storage on each node as a NodeStorageStatus object (default: true)",
+		},
+		cli.Int64Flag{
+			Name:  "health-monitor-cordon-threshold",
+			Usage: "The time in seconds for which the storage on a node needs to be offline before the node is cordoned and tainted. Nodes aren't cordoned if 0 (default: 0)",
+		},
+		cli.Int64Flag{
+			Name:  "health-monitor-stuck-attachment-threshold",
+			Usage: "The time in seconds after which volume attachments that haven't been attached or detached are reported as stuck. Volume attachments aren't checked if 0 (default: 0)",
+		},
+		cli.BoolFlag{
+			Name:  "health-monitor-remediate-stuck-attachments",
+			Usage: "Force detach volumes for stuck volume attachments if they are attached to a node where the storage isn't online (default: false)",
+		},
+		cli.StringSliceFlag{
+			Name:  "health-monitor-namespace",
+			Usage: "Namespace in which pods should be failed over by the health monitor. Can be specified multiple times (default: all namespaces)",
+		},
+		cli.StringFlag{
+			Name:  "health-monitor-pod-selector",
+			Usage: "Label selector for pods that should be failed over by the health monitor (default: all pods)",
+		},
+		cli.BoolFlag{
+			Name:  "health-monitor-dry-run",
+			Usage: "Only report pods that would be deleted by the health monitor with events and metrics without deleting them (default: false)",
+		},
+		cli.Int64Flag{
+			Name:  "health-monitor-eviction-timeout",
+			Usage: "The time in seconds after which pods that couldn't be evicted are force deleted when using the eviction API. Pods are never force deleted if 0 (default: 0)",
+		},
 		cli.BoolTFlag{
 			Name:  "migration-controller",
 			Usage: "Start the migration controller (default: true)",
@@ -109,10 +217,27 @@ func main() {
 			Name:  "app-initializer",
 			Usage: "EXPERIMENTAL: Enable application initializer to update scheduler name automatically (default: false)",
 		},
+		cli.BoolFlag{
+			Name:  "app-initializer-inject-affinity",
+			Usage: "Add preferred node affinity for the nodes and zones with the volume data to deployments updated by the application initializer (default: false)",
+		},
 		cli.StringFlag{
-			Name:  "migration-admin-namespace",
+			Name:  "app-initializer-skip-configmap",
+			Usage: "Name of the config map with the namespaces and owners whose applications shouldn't be updated by the application initializer",
+		},
+		cli.StringFlag{
+			Name:  "app-initializer-skip-configmap-namespace",
+			Usage: "Namespace of the config map with the namespaces and owners whose applications shouldn't be updated by the application initializer (default: kube-system)",
+			Value: "kube-system",
+		},
+		cli.StringFlag{
+			Name:  "admin-namespace",
 			Usage: "Namespace to be used by a cluster admin which can migrate all other namespaces (default: none)",
 		},
+		cli.StringFlag{
+			Name:  "migration-admin-namespace",
+			Usage: "DEPRECATED: Use admin-namespace instead. Namespace to be used by a cluster admin which can migrate all other namespaces (default: none)",
+		},
 		cli.BoolFlag{
 			Name:  "storage-cluster-controller",
 			Usage: "Start the storage cluster controller (default: false)",
@@ -121,10 +246,58 @@ func main() {
 			Name:  "cluster-domain-controllers",
 			Usage: "Start the cluster domain controllers (default: true)",
 		},
+		cli.StringFlag{
+			Name:  "cluster-domain",
+			Usage: "Name of the cluster domain in which stork is running. Required for automatic failover of cluster domains (default: none)",
+		},
+		cli.BoolFlag{
+			Name:  "cluster-domain-update-require-confirmation",
+			Usage: "Require ClusterDomainUpdates that deactivate a cluster domain to be confirmed after their impact is reported in the status (default: false)",
+		},
+		cli.StringFlag{
+			Name:  "cluster-domain-witness-url",
+			Usage: "URL of the witness used to automatically deactivate unreachable cluster domains and activate the local cluster domain. Automatic failover is disabled if not set (default: none)",
+		},
+		cli.Int64Flag{
+			Name:  "cluster-domain-failover-timeout",
+			Usage: "Time in seconds for which the witness shouldn't have received a heartbeat from a cluster domain before it is deactivated (default: 60)",
+		},
+		cli.Int64Flag{
+			Name:  "cluster-domain-witness-interval",
+			Usage: "Interval in seconds at which heartbeats are sent to the cluster domain witness (default: 10)",
+		},
 		cli.BoolTFlag{
 			Name:  "pvc-watcher",
 			Usage: "Start the controller to monitor PVC creation and deletions (default: true)",
 		},
+		cli.BoolFlag{
+			Name:  "webhook-controller",
+			Usage: "Start the admission webhook to validate stork resources when they are created or updated (default: false)",
+		},
+		cli.StringFlag{
+			Name:  "webhook-service-name",
+			Usage: "Name of the service through which the API server reaches the admission webhook (default: stork-service)",
+			Value: "stork-service",
+		},
+		cli.StringFlag{
+			Name:  "webhook-service-namespace",
+			Usage: "Namespace of the service for the admission webhook (default: kube-system)",
+			Value: "kube-system",
+		},
+		cli.StringFlag{
+			Name:  "webhook-service-account",
+			Usage: "Name of the service account stork runs as in the webhook service namespace. Objects created by it aren't checked for access to cluster rules (default: stork-account)",
+			Value: "stork-account",
+		},
+		cli.BoolFlag{
+			Name:  "webhook-mutate-apps",
+			Usage: "Update the scheduler name in the pod templates of deployments and statefulsets using volumes from the driver when they are created, using the admission webhook. Honors the app-initializer options (default: false)",
+		},
+		cli.Int64Flag{
+			Name:  "webhook-health-check-interval",
+			Usage: "The interval in seconds at which the admission webhook checks for ready stork endpoints. Requests are only rejected when the webhook can't be reached while stork is healthy. Set to 0 to always reject them (default: 30)",
+			Value: 30,
+		},
 	}
 
 	if err := app.Run(os.Args); err != nil {
@@ -145,12 +318,44 @@ func run(c *cli.Context) {
 		log.SetLevel(log.DebugLevel)
 	}
 
-	d, err := volume.Get(driverName)
-	if err != nil {
-		log.Fatalf("Error getting Stork Driver %v: %v", driverName, err)
+	driverNames := strings.Split(driverName, ",")
+	for i := range driverNames {
+		driverNames[i] = strings.TrimSpace(driverNames[i])
+	}
+	if driverSocket := c.String("driver-socket"); driverSocket != "" {
+		if err := external.Register(driverNames[0], driverSocket); err != nil {
+			log.Fatalf("Error registering driver plugin %v: %v", driverNames[0], err)
+		}
+	}
+
+	degradedPeriod := c.Int64("driver-degraded-period")
+	if degradedPeriod <= 0 {
+		degradedPeriod = defaultDriverDegradedPeriod
+	}
+	breakerConfig := breaker.Config{
+		Timeout:          time.Duration(c.Int64("driver-timeout")) * time.Second,
+		FailureThreshold: int(c.Int64("driver-failure-threshold")),
+		DegradedPeriod:   time.Duration(degradedPeriod) * time.Second,
+	}
+	// Each driver is wrapped separately so that the metrics and circuit
+	// breaker are per driver when multiple drivers are used
+	drivers := make([]volume.Driver, 0, len(driverNames))
+	for _, name := range driverNames {
+		d, err := volume.Get(name)
+		if err != nil {
+			log.Fatalf("Error getting Stork Driver %v: %v", name, err)
+		}
+		drivers = append(drivers, breaker.New(d, breakerConfig))
+	}
+	d := drivers[0]
+	if len(drivers) > 1 {
+		var err error
+		if d, err = multi.New(drivers); err != nil {
+			log.Fatalf("Error getting Stork Driver %v: %v", driverName, err)
+		}
 	}
 
-	if err = d.Init(nil); err != nil {
+	if err := d.Init(nil); err != nil {
 		log.Fatalf("Error initializing Stork Driver %v: %v", driverName, err)
 	}
 
@@ -164,6 +369,14 @@ func run(c *cli.Context) {
 		log.Fatalf("Error getting client, %v", err)
 	}
 
+	storkClient, err := storkclientset.NewForConfig(config)
+	if err != nil {
+		log.Fatalf("Error getting stork client, %v", err)
+	}
+	// Cluster rules are resolved by the controllers and the webhook
+	rule.SetStorkClient(storkClient)
+	rule.SetKubeClient(k8sClient)
+
 	eventBroadcaster := record.NewBroadcaster()
 	eventBroadcaster.StartRecordingToSink(&core_v1.EventSinkImpl{Interface: core_v1.New(k8sClient.CoreV1().RESTClient()).Events("")})
 	recorder := eventBroadcaster.NewRecorder(legacyscheme.Scheme, api_v1.EventSource{Component: eventComponentName})
@@ -172,6 +385,13 @@ func run(c *cli.Context) {
 		ext = &extender.Extender{
 			Driver:   d,
 			Recorder: recorder,
+			CacheTTL: time.Duration(c.Int64("extender-cache-ttl")) * time.Second,
+
+			ScoresConfigMapName:      c.String("extender-scores-configmap"),
+			ScoresConfigMapNamespace: c.String("extender-scores-configmap-namespace"),
+			StorageClassOptIn:        c.Bool("extender-storageclass-opt-in"),
+			DegradationPolicy:        c.String("extender-degradation-policy"),
+			KubeClient:               k8sClient,
 		}
 
 		if err = ext.Start(); err != nil {
@@ -179,8 +399,31 @@ func run(c *cli.Context) {
 		}
 	}
 
+	// The webhook is served by all the replicas since the service can route
+	// requests to any of them
+	if c.Bool("webhook-controller") {
+		webhookController = &webhookadmission.Controller{
+			KubeClient:             k8sClient,
+			ServiceName:            c.String("webhook-service-name"),
+			ServiceNamespace:       c.String("webhook-service-namespace"),
+			ServiceAccount:         c.String("webhook-service-account"),
+			HealthCheckIntervalSec: c.Int64("webhook-health-check-interval"),
+		}
+		if c.Bool("webhook-mutate-apps") {
+			webhookController.AppInitializer = &initializer.Initializer{
+				Driver:                 d,
+				SkipConfigMapName:      c.String("app-initializer-skip-configmap"),
+				SkipConfigMapNamespace: c.String("app-initializer-skip-configmap-namespace"),
+				InjectAffinity:         c.Bool("app-initializer-inject-affinity"),
+			}
+		}
+		if err = webhookController.Start(); err != nil {
+			log.Fatalf("Error starting webhook controller: %v", err)
+		}
+	}
+
 	runFunc := func(_ <-chan struct{}) {
-		runStork(d, recorder, c)
+		runStork(d, recorder, k8sClient, storkClient, c)
 	}
 
 	if c.BoolT("leader-elect") {
@@ -235,7 +478,13 @@ func run(c *cli.Context) {
 	}
 }
 
-func runStork(d volume.Driver, recorder record.EventRecorder, c *cli.Context) {
+func runStork(
+	d volume.Driver,
+	recorder record.EventRecorder,
+	k8sClient clientset.Interface,
+	storkClient storkclientset.Interface,
+	c *cli.Context,
+) {
 	if err := controller.Init(); err != nil {
 		log.Fatalf("Error initializing controller: %v", err)
 	}
@@ -244,8 +493,16 @@ func runStork(d volume.Driver, recorder record.EventRecorder, c *cli.Context) {
 		log.Fatalf("Error initializing rule: %v", err)
 	}
 
+	ruleCleanup := &rule.CleanupController{}
+	if err := ruleCleanup.Start(); err != nil {
+		log.Fatalf("Error starting rule cleanup controller: %v", err)
+	}
+
 	initializer := &initializer.Initializer{
-		Driver: d,
+		Driver:                 d,
+		SkipConfigMapName:      c.String("app-initializer-skip-configmap"),
+		SkipConfigMapNamespace: c.String("app-initializer-skip-configmap-namespace"),
+		InjectAffinity:         c.Bool("app-initializer-inject-affinity"),
 	}
 	if c.Bool("app-initializer") {
 		if err := initializer.Start(); err != nil {
@@ -254,8 +511,27 @@ func runStork(d volume.Driver, recorder record.EventRecorder, c *cli.Context) {
 	}
 
 	monitor := &monitor.Monitor{
-		Driver:      d,
-		IntervalSec: c.Int64("health-monitor-interval"),
+		Driver:                      d,
+		IntervalSec:                 c.Int64("health-monitor-interval"),
+		GracePeriodSec:              c.Int64("health-monitor-grace-period"),
+		EvictionBackoffSec:          c.Int64("health-monitor-eviction-backoff"),
+		UseEvictionAPI:              c.Bool("health-monitor-use-eviction-api"),
+		EvictionTimeoutSec:          c.Int64("health-monitor-eviction-timeout"),
+		KubeClient:                  k8sClient,
+		ForceDetachVolumes:          c.Bool("health-monitor-force-detach"),
+		FailoverSharedVolumes:       c.Bool("health-monitor-shared-volume-failover"),
+		CordonThresholdSec:          c.Int64("health-monitor-cordon-threshold"),
+		StuckAttachmentThresholdSec: c.Int64("health-monitor-stuck-attachment-threshold"),
+		RemediateStuckAttachments:   c.Bool("health-monitor-remediate-stuck-attachments"),
+		Namespaces:                  c.StringSlice("health-monitor-namespace"),
+		PodSelector:                 c.String("health-monitor-pod-selector"),
+		DryRun:                      c.Bool("health-monitor-dry-run"),
+		PublishNodeStorageStatus:    c.BoolT("health-monitor-node-storage-status"),
+		StorkClient:                 storkClient,
+		Recorder:                    recorder,
+	}
+	if ext != nil {
+		monitor.NodeStatusChangeHandler = ext.InvalidateCache
 	}
 
 	if c.Bool("health-monitor") {
@@ -268,9 +544,15 @@ func runStork(d volume.Driver, recorder record.EventRecorder, c *cli.Context) {
 		log.Fatalf("Error initializing schedule: %v", err)
 	}
 
+	adminNamespace := c.String("admin-namespace")
+	if adminNamespace == "" {
+		adminNamespace = c.String("migration-admin-namespace")
+	}
+
 	snapshot := &snapshot.Snapshot{
-		Driver:   d,
-		Recorder: recorder,
+		Driver:        d,
+		Recorder:      recorder,
+		GCGracePeriod: time.Duration(c.Int64("snapshot-gc-grace-period")) * time.Minute,
 	}
 	if c.Bool("snapshotter") {
 		if err := snapshot.Start(); err != nil {
@@ -303,13 +585,12 @@ func runStork(d volume.Driver, recorder record.EventRecorder, c *cli.Context) {
 	}
 
 	if c.Bool("migration-controller") {
-		migrationAdminNamespace := c.String("migration-admin-namespace")
 		migration := migration.Migration{
 			Driver:            d,
 			Recorder:          recorder,
 			ResourceCollector: resourceCollector,
 		}
-		if err := migration.Init(migrationAdminNamespace); err != nil {
+		if err := migration.Init(adminNamespace); err != nil {
 			log.Fatalf("Error initializing migration: %v", err)
 		}
 	}
@@ -323,10 +604,16 @@ func runStork(d volume.Driver, recorder record.EventRecorder, c *cli.Context) {
 		}
 	}
 
+	var clusterDomains *clusterdomains.ClusterDomains
 	if c.Bool("cluster-domain-controllers") {
-		clusterDomains := clusterdomains.ClusterDomains{
-			Driver:   d,
-			Recorder: recorder,
+		clusterDomains = &clusterdomains.ClusterDomains{
+			Driver:              d,
+			Recorder:            recorder,
+			LocalDomain:         c.String("cluster-domain"),
+			RequireConfirmation: c.Bool("cluster-domain-update-require-confirmation"),
+			WitnessURL:          c.String("cluster-domain-witness-url"),
+			FailoverTimeout:     time.Duration(c.Int64("cluster-domain-failover-timeout")) * time.Second,
+			WitnessInterval:     time.Duration(c.Int64("cluster-domain-witness-interval")) * time.Second,
 		}
 		if err := clusterDomains.Init(); err != nil {
 			log.Fatalf("Error initializing cluster domain controllers: %v", err)
@@ -364,6 +651,19 @@ func runStork(d volume.Driver, recorder record.EventRecorder, c *cli.Context) {
 				log.Warnf("Error stopping app-initializer: %v", err)
 			}
 		}
+		if clusterDomains != nil {
+			if err := clusterDomains.Stop(); err != nil {
+				log.Warnf("Error stopping cluster domain controllers: %v", err)
+			}
+		}
+		if webhookController != nil {
+			if err := webhookController.Stop(); err != nil {
+				log.Warnf("Error stopping webhook controller: %v", err)
+			}
+		}
+		if err := ruleCleanup.Stop(); err != nil {
+			log.Warnf("Error stopping rule cleanup controller: %v", err)
+		}
 		if err := d.Stop(); err != nil {
 			log.Warnf("Error stopping driver: %v", err)
 		}
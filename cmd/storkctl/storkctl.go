@@ -1,7 +1,10 @@
 package main
 
 import (
+	"fmt"
 	"os"
+	"os/exec"
+	"syscall"
 
 	_ "github.com/libopenstorage/stork/drivers/volume/portworx"
 	"github.com/libopenstorage/stork/pkg/storkctl"
@@ -10,7 +13,22 @@ import (
 
 func main() {
 	factory := storkctl.NewFactory()
-	if err := storkctl.NewCommand(factory, os.Stdin, os.Stdout, os.Stdout).Execute(); err != nil {
+	cmd := storkctl.NewCommand(factory, os.Stdin, os.Stdout, os.Stdout)
+	found, err := storkctl.RunPlugin(cmd, os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+	if found {
+		if err != nil {
+			// Exit with the same code as the plugin
+			if exitErr, ok := err.(*exec.ExitError); ok {
+				if status, ok := exitErr.Sys().(syscall.WaitStatus); ok {
+					os.Exit(status.ExitStatus())
+				}
+			}
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if err := cmd.Execute(); err != nil {
 		os.Exit(1)
 	}
 }
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/libopenstorage/stork/pkg/clusterdomains/witness"
+	"github.com/libopenstorage/stork/pkg/version"
+	"github.com/sirupsen/logrus"
+)
+
+const defaultPort = 9010
+
+func main() {
+	port := flag.Int("port", defaultPort, "Port on which the witness is served")
+	flag.Parse()
+
+	logrus.Infof("Running cluster domain witness %v on port %v", version.Version, *port)
+	server := witness.NewServer()
+	if err := server.Start(*port); err != nil {
+		logrus.Fatalf("Error starting witness: %v", err)
+	}
+
+	signalChan := make(chan os.Signal, 1)
+	signal.Notify(signalChan, syscall.SIGINT, syscall.SIGTERM)
+	<-signalChan
+	logrus.Infof("Shutdown signal received, exiting...")
+	if err := server.Stop(); err != nil {
+		logrus.Warnf("Error stopping witness: %v", err)
+	}
+}
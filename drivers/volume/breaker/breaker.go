@@ -0,0 +1,160 @@
+package breaker
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	storkvolume "github.com/libopenstorage/stork/drivers/volume"
+	"github.com/libopenstorage/stork/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrDriverTimeout is returned when a call to the driver doesn't return
+// within the timeout
+type ErrDriverTimeout struct {
+	// Call is the name of the driver method
+	Call string
+	// Timeout for the call
+	Timeout time.Duration
+}
+
+func (e *ErrDriverTimeout) Error() string {
+	return fmt.Sprintf("call to %v on storage driver timed out after %v", e.Call, e.Timeout)
+}
+
+// ErrDriverDegraded is returned without calling the driver while it is
+// marked degraded after repeated failures
+type ErrDriverDegraded struct {
+	// Call is the name of the driver method
+	Call string
+	// Failures is the number of consecutive failed calls
+	Failures int
+}
+
+func (e *ErrDriverDegraded) Error() string {
+	return fmt.Sprintf("not calling %v on storage driver since it is degraded after %v consecutive failures",
+		e.Call, e.Failures)
+}
+
+// Config is the configuration for the timeouts and circuit breaker
+type Config struct {
+	// Timeout for each call to the driver. Calls aren't timed out if set to 0
+	Timeout time.Duration
+	// FailureThreshold is the number of consecutive failed calls after which
+	// the driver is marked degraded. The driver is never marked degraded if
+	// set to 0
+	FailureThreshold int
+	// DegradedPeriod is the time for which calls fail immediately once the
+	// driver is marked degraded, before calls are tried again
+	DegradedPeriod time.Duration
+}
+
+// guarded is a volume driver that wraps the calls to another driver with a
+// timeout and a circuit breaker, and records the metrics for the calls. Calls
+// that time out keep running in the background since the drivers can't be
+// interrupted, but the caller isn't blocked on them.
+type guarded struct {
+	storkvolume.Driver
+	name   string
+	config Config
+
+	sync.Mutex
+	failures   int
+	degradedAt time.Time
+}
+
+// New returns a driver that wraps the calls to the driver with the timeout
+// and circuit breaker from the config. With an empty config the calls are
+// only instrumented with metrics.
+func New(d storkvolume.Driver, config Config) storkvolume.Driver {
+	return &guarded{
+		Driver: d,
+		name:   d.String(),
+		config: config,
+	}
+}
+
+// isFailure returns true if the error should be counted as a failure of the
+// driver. Errors for unsupported operations, missing objects and pending
+// PVCs are expected and don't count.
+func isFailure(err error) bool {
+	switch err.(type) {
+	case nil, *errors.ErrNotSupported, *errors.ErrNotImplemented, *errors.ErrNotFound, *storkvolume.ErrPVCPending:
+		return false
+	}
+	return true
+}
+
+// allow returns an error if the driver is degraded and the degraded period
+// hasn't expired
+func (g *guarded) allow(call string) error {
+	g.Lock()
+	defer g.Unlock()
+	if g.config.FailureThreshold == 0 || g.failures < g.config.FailureThreshold ||
+		time.Since(g.degradedAt) >= g.config.DegradedPeriod {
+		return nil
+	}
+	return &ErrDriverDegraded{
+		Call:     call,
+		Failures: g.failures,
+	}
+}
+
+// record records the result of a call. The driver is marked degraded once the
+// number of consecutive failures reaches the threshold, and again for every
+// failure after the degraded period expires until a call succeeds.
+func (g *guarded) record(err error) {
+	g.Lock()
+	defer g.Unlock()
+	if !isFailure(err) {
+		if g.config.FailureThreshold != 0 && g.failures >= g.config.FailureThreshold {
+			logrus.Infof("Storage driver %v recovered after %v consecutive failures", g.name, g.failures)
+			driverDegraded.WithLabelValues(g.name).Set(0)
+		}
+		g.failures = 0
+		return
+	}
+	g.failures++
+	if g.config.FailureThreshold != 0 && g.failures >= g.config.FailureThreshold {
+		if g.failures == g.config.FailureThreshold {
+			logrus.Warnf("Marking storage driver %v degraded for %v after %v consecutive failures, last error: %v",
+				g.name, g.config.DegradedPeriod, g.failures, err)
+		}
+		g.degradedAt = time.Now()
+		driverDegraded.WithLabelValues(g.name).Set(1)
+	}
+}
+
+// call runs the function calling the driver method with the timeout and
+// circuit breaker, and records the metrics for the call. The results of the
+// method should only be used by the function if no error is returned.
+func (g *guarded) call(call string, fn func() error) error {
+	if err := g.allow(call); err != nil {
+		rejectedCalls.WithLabelValues(g.name, call).Inc()
+		return err
+	}
+
+	start := time.Now()
+	var err error
+	if g.config.Timeout == 0 {
+		err = fn()
+	} else {
+		done := make(chan error, 1)
+		go func() {
+			done <- fn()
+		}()
+		select {
+		case err = <-done:
+		case <-time.After(g.config.Timeout):
+			err = &ErrDriverTimeout{
+				Call:    call,
+				Timeout: g.config.Timeout,
+			}
+		}
+	}
+
+	observeCall(g.name, call, start, err)
+	g.record(err)
+	return err
+}
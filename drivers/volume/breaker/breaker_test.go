@@ -0,0 +1,141 @@
+// +build unittest
+
+package breaker
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	storkvolume "github.com/libopenstorage/stork/drivers/volume"
+	"github.com/libopenstorage/stork/drivers/volume/mock"
+	"github.com/libopenstorage/stork/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+)
+
+// flakyDriver is a mock driver where getting the nodes can be made slow or
+// fail
+type flakyDriver struct {
+	*mock.Driver
+	delay time.Duration
+	err   error
+}
+
+func (d *flakyDriver) GetNodes() ([]*storkvolume.NodeInfo, error) {
+	time.Sleep(d.delay)
+	if d.err != nil {
+		return nil, d.err
+	}
+	return d.Driver.GetNodes()
+}
+
+func newTestDriver(t *testing.T) *flakyDriver {
+	d := &flakyDriver{Driver: &mock.Driver{}}
+	require.NoError(t, d.CreateCluster(3, &v1.NodeList{}), "Error creating mock cluster")
+	return d
+}
+
+func TestTimeout(t *testing.T) {
+	flaky := newTestDriver(t)
+	d := New(flaky, Config{Timeout: 100 * time.Millisecond})
+
+	nodes, err := d.GetNodes()
+	require.NoError(t, err, "Error getting nodes")
+	require.Len(t, nodes, 3)
+
+	flaky.delay = time.Second
+	start := time.Now()
+	_, err = d.GetNodes()
+	require.Error(t, err)
+	require.IsType(t, &ErrDriverTimeout{}, err)
+	require.True(t, time.Since(start) < flaky.delay, "Call wasn't timed out")
+	require.Equal(t, "call to GetNodes on storage driver timed out after 100ms", err.Error())
+}
+
+func TestDegraded(t *testing.T) {
+	flaky := newTestDriver(t)
+	d := New(flaky, Config{FailureThreshold: 2, DegradedPeriod: 200 * time.Millisecond})
+
+	// Expected errors don't count as failures
+	flaky.err = &errors.ErrNotSupported{}
+	for i := 0; i < 3; i++ {
+		_, err := d.GetNodes()
+		require.IsType(t, &errors.ErrNotSupported{}, err)
+	}
+
+	flaky.err = fmt.Errorf("driver unavailable")
+	for i := 0; i < 2; i++ {
+		_, err := d.GetNodes()
+		require.Error(t, err)
+		require.Equal(t, "driver unavailable", err.Error())
+	}
+
+	// Calls fail immediately while the driver is degraded, even once it has
+	// recovered
+	flaky.err = nil
+	_, err := d.GetNodes()
+	require.Error(t, err)
+	require.IsType(t, &ErrDriverDegraded{}, err)
+	require.Equal(t, "not calling GetNodes on storage driver since it is degraded after 2 consecutive failures", err.Error())
+
+	// The driver is called again after the degraded period and marked
+	// degraded again if it still fails
+	time.Sleep(200 * time.Millisecond)
+	flaky.err = fmt.Errorf("driver unavailable")
+	_, err = d.GetNodes()
+	require.Error(t, err)
+	require.Equal(t, "driver unavailable", err.Error())
+	_, err = d.GetNodes()
+	require.IsType(t, &ErrDriverDegraded{}, err)
+
+	// A successful call after the degraded period resets the failures
+	time.Sleep(200 * time.Millisecond)
+	flaky.err = nil
+	nodes, err := d.GetNodes()
+	require.NoError(t, err, "Error getting nodes")
+	require.Len(t, nodes, 3)
+
+	flaky.err = fmt.Errorf("driver unavailable")
+	_, err = d.GetNodes()
+	require.Equal(t, "driver unavailable", err.Error())
+	_, err = d.GetNodes()
+	require.Equal(t, "driver unavailable", err.Error())
+	_, err = d.GetNodes()
+	require.IsType(t, &ErrDriverDegraded{}, err)
+}
+
+func getCounterValue(t *testing.T, counter prometheus.Counter) float64 {
+	metric := &dto.Metric{}
+	require.NoError(t, counter.Write(metric), "Error reading counter")
+	return metric.GetCounter().GetValue()
+}
+
+func getHistogramCount(t *testing.T, observer prometheus.Observer) uint64 {
+	metric := &dto.Metric{}
+	require.NoError(t, observer.(prometheus.Metric).Write(metric), "Error reading histogram")
+	return metric.GetHistogram().GetSampleCount()
+}
+
+func TestMetrics(t *testing.T) {
+	flaky := newTestDriver(t)
+	d := New(flaky, Config{})
+	name := flaky.String()
+	calls := getHistogramCount(t, callDuration.WithLabelValues(name, "GetNodes"))
+	failures := getCounterValue(t, callFailures.WithLabelValues(name, "GetNodes", failureReasonError))
+
+	_, err := d.GetNodes()
+	require.NoError(t, err, "Error getting nodes")
+	flaky.err = &errors.ErrNotSupported{}
+	_, err = d.GetNodes()
+	require.Error(t, err)
+	flaky.err = fmt.Errorf("driver unavailable")
+	_, err = d.GetNodes()
+	require.Error(t, err)
+
+	// All calls are timed, but only unexpected errors are failures
+	require.Equal(t, calls+3, getHistogramCount(t, callDuration.WithLabelValues(name, "GetNodes")))
+	require.Equal(t, failures+1, getCounterValue(t, callFailures.WithLabelValues(name, "GetNodes", failureReasonError)))
+}
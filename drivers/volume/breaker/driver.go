@@ -0,0 +1,235 @@
+package breaker
+
+import (
+	snapv1 "github.com/kubernetes-incubator/external-storage/snapshot/pkg/apis/crd/v1"
+	storkvolume "github.com/libopenstorage/stork/drivers/volume"
+	stork_crd "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// The methods that don't return errors, along with Init and Stop, aren't
+// wrapped and call the driver directly
+
+func (g *guarded) InspectVolume(volumeID string) (*storkvolume.Info, error) {
+	var info *storkvolume.Info
+	if err := g.call("InspectVolume", func() (err error) {
+		info, err = g.Driver.InspectVolume(volumeID)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+func (g *guarded) GetNodes() ([]*storkvolume.NodeInfo, error) {
+	var nodes []*storkvolume.NodeInfo
+	if err := g.call("GetNodes", func() (err error) {
+		nodes, err = g.Driver.GetNodes()
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return nodes, nil
+}
+
+func (g *guarded) GetPodVolumes(podSpec *v1.PodSpec, namespace string) ([]*storkvolume.Info, error) {
+	var volumes []*storkvolume.Info
+	if err := g.call("GetPodVolumes", func() (err error) {
+		volumes, err = g.Driver.GetPodVolumes(podSpec, namespace)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return volumes, nil
+}
+
+func (g *guarded) GetVolumeClaimTemplates(templates []v1.PersistentVolumeClaim) ([]v1.PersistentVolumeClaim, error) {
+	var driverTemplates []v1.PersistentVolumeClaim
+	if err := g.call("GetVolumeClaimTemplates", func() (err error) {
+		driverTemplates, err = g.Driver.GetVolumeClaimTemplates(templates)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return driverTemplates, nil
+}
+
+func (g *guarded) GetSnapshotType(snap *snapv1.VolumeSnapshot) (string, error) {
+	var snapType string
+	if err := g.call("GetSnapshotType", func() (err error) {
+		snapType, err = g.Driver.GetSnapshotType(snap)
+		return err
+	}); err != nil {
+		return "", err
+	}
+	return snapType, nil
+}
+
+func (g *guarded) GetClusterID() (string, error) {
+	var clusterID string
+	if err := g.call("GetClusterID", func() (err error) {
+		clusterID, err = g.Driver.GetClusterID()
+		return err
+	}); err != nil {
+		return "", err
+	}
+	return clusterID, nil
+}
+
+func (g *guarded) CreateGroupSnapshot(snap *stork_crd.GroupVolumeSnapshot) (*storkvolume.GroupSnapshotCreateResponse, error) {
+	var response *storkvolume.GroupSnapshotCreateResponse
+	if err := g.call("CreateGroupSnapshot", func() (err error) {
+		response, err = g.Driver.CreateGroupSnapshot(snap)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func (g *guarded) GetGroupSnapshotStatus(snap *stork_crd.GroupVolumeSnapshot) (*storkvolume.GroupSnapshotCreateResponse, error) {
+	var response *storkvolume.GroupSnapshotCreateResponse
+	if err := g.call("GetGroupSnapshotStatus", func() (err error) {
+		response, err = g.Driver.GetGroupSnapshotStatus(snap)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func (g *guarded) DeleteGroupSnapshot(snap *stork_crd.GroupVolumeSnapshot) error {
+	return g.call("DeleteGroupSnapshot", func() error {
+		return g.Driver.DeleteGroupSnapshot(snap)
+	})
+}
+
+func (g *guarded) CreatePair(pair *stork_crd.ClusterPair) (string, error) {
+	var remoteID string
+	if err := g.call("CreatePair", func() (err error) {
+		remoteID, err = g.Driver.CreatePair(pair)
+		return err
+	}); err != nil {
+		return "", err
+	}
+	return remoteID, nil
+}
+
+func (g *guarded) DeletePair(pair *stork_crd.ClusterPair) error {
+	return g.call("DeletePair", func() error {
+		return g.Driver.DeletePair(pair)
+	})
+}
+
+func (g *guarded) StartMigration(migration *stork_crd.Migration) ([]*stork_crd.VolumeInfo, error) {
+	var volumes []*stork_crd.VolumeInfo
+	if err := g.call("StartMigration", func() (err error) {
+		volumes, err = g.Driver.StartMigration(migration)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return volumes, nil
+}
+
+func (g *guarded) GetMigrationStatus(migration *stork_crd.Migration) ([]*stork_crd.VolumeInfo, error) {
+	var volumes []*stork_crd.VolumeInfo
+	if err := g.call("GetMigrationStatus", func() (err error) {
+		volumes, err = g.Driver.GetMigrationStatus(migration)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return volumes, nil
+}
+
+func (g *guarded) CancelMigration(migration *stork_crd.Migration) error {
+	return g.call("CancelMigration", func() error {
+		return g.Driver.CancelMigration(migration)
+	})
+}
+
+func (g *guarded) UpdateMigratedPersistentVolumeSpec(object runtime.Unstructured) (runtime.Unstructured, error) {
+	var updated runtime.Unstructured
+	if err := g.call("UpdateMigratedPersistentVolumeSpec", func() (err error) {
+		updated, err = g.Driver.UpdateMigratedPersistentVolumeSpec(object)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+func (g *guarded) GetClusterDomains() (*stork_crd.ClusterDomains, error) {
+	var clusterDomains *stork_crd.ClusterDomains
+	if err := g.call("GetClusterDomains", func() (err error) {
+		clusterDomains, err = g.Driver.GetClusterDomains()
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return clusterDomains, nil
+}
+
+func (g *guarded) ActivateClusterDomain(update *stork_crd.ClusterDomainUpdate) error {
+	return g.call("ActivateClusterDomain", func() error {
+		return g.Driver.ActivateClusterDomain(update)
+	})
+}
+
+func (g *guarded) DeactivateClusterDomain(update *stork_crd.ClusterDomainUpdate) error {
+	return g.call("DeactivateClusterDomain", func() error {
+		return g.Driver.DeactivateClusterDomain(update)
+	})
+}
+
+func (g *guarded) StartVolumeSnapshotRestore(restore *stork_crd.VolumeSnapshotRestore) error {
+	return g.call("StartVolumeSnapshotRestore", func() error {
+		return g.Driver.StartVolumeSnapshotRestore(restore)
+	})
+}
+
+func (g *guarded) GetVolumeSnapshotRestoreStatus(restore *stork_crd.VolumeSnapshotRestore) error {
+	return g.call("GetVolumeSnapshotRestoreStatus", func() error {
+		return g.Driver.GetVolumeSnapshotRestoreStatus(restore)
+	})
+}
+
+func (g *guarded) GetSharedVolumeCoordinator(volumeInfo *storkvolume.Info) (string, error) {
+	var coordinator string
+	if err := g.call("GetSharedVolumeCoordinator", func() (err error) {
+		coordinator, err = g.Driver.GetSharedVolumeCoordinator(volumeInfo)
+		return err
+	}); err != nil {
+		return "", err
+	}
+	return coordinator, nil
+}
+
+func (g *guarded) GetVolumeAttachedNode(volumeInfo *storkvolume.Info) (string, error) {
+	var attachedNode string
+	if err := g.call("GetVolumeAttachedNode", func() (err error) {
+		attachedNode, err = g.Driver.GetVolumeAttachedNode(volumeInfo)
+		return err
+	}); err != nil {
+		return "", err
+	}
+	return attachedNode, nil
+}
+
+func (g *guarded) ForceDetachVolume(volumeInfo *storkvolume.Info) error {
+	return g.call("ForceDetachVolume", func() error {
+		return g.Driver.ForceDetachVolume(volumeInfo)
+	})
+}
+
+func (g *guarded) PreProvisionRestoreVolume(
+	snapshotData *snapv1.VolumeSnapshotData,
+	pvc *v1.PersistentVolumeClaim,
+	parameters map[string]string,
+) error {
+	return g.call("PreProvisionRestoreVolume", func() error {
+		return g.Driver.PreProvisionRestoreVolume(snapshotData, pvc, parameters)
+	})
+}
@@ -0,0 +1,59 @@
+package breaker
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	failureReasonError   = "error"
+	failureReasonTimeout = "timeout"
+)
+
+var (
+	callDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "stork_driver_call_duration_seconds",
+			Help:    "Time taken by calls to the storage driver, including the calls that failed or timed out",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"driver", "call"},
+	)
+	callFailures = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "stork_driver_call_failures_total",
+			Help: "Number of calls to the storage driver that failed or timed out",
+		},
+		[]string{"driver", "call", "reason"},
+	)
+	rejectedCalls = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "stork_driver_rejected_calls_total",
+			Help: "Number of calls to the storage driver that failed immediately since the driver was degraded",
+		},
+		[]string{"driver", "call"},
+	)
+	driverDegraded = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "stork_driver_degraded",
+			Help: "Set to 1 while the storage driver is marked degraded after repeated failures",
+		},
+		[]string{"driver"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(callDuration, callFailures, rejectedCalls, driverDegraded)
+}
+
+// observeCall records the time taken by a driver call that was started at the
+// given time, and the reason if it failed
+func observeCall(driver string, call string, start time.Time, err error) {
+	callDuration.WithLabelValues(driver, call).Observe(time.Since(start).Seconds())
+	if _, ok := err.(*ErrDriverTimeout); ok {
+		callFailures.WithLabelValues(driver, call, failureReasonTimeout).Inc()
+	} else if isFailure(err) {
+		callFailures.WithLabelValues(driver, call, failureReasonError).Inc()
+	}
+}
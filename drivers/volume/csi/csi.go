@@ -0,0 +1,338 @@
+package csi
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	snapv1 "github.com/kubernetes-incubator/external-storage/snapshot/pkg/apis/crd/v1"
+	snapshotVolume "github.com/kubernetes-incubator/external-storage/snapshot/pkg/volume"
+	storkvolume "github.com/libopenstorage/stork/drivers/volume"
+	"github.com/libopenstorage/stork/pkg/errors"
+	"github.com/portworx/sched-ops/k8s"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	k8shelper "k8s.io/kubernetes/pkg/apis/core/v1/helper"
+	kubeletapis "k8s.io/kubernetes/pkg/kubelet/apis"
+)
+
+const (
+	// driverName is the name of the CSI driver
+	driverName = "csi"
+	// csiDriverNames is the environment variable with the comma separated
+	// list of CSI drivers whose volumes are owned by the driver. Volumes from
+	// all CSI drivers are owned if it isn't set.
+	csiDriverNames = "CSI_DRIVER_NAMES"
+	// pvcProvisionerAnnotation is the annotation on PVCs with the provisioner
+	// name
+	pvcProvisionerAnnotation = "volume.beta.kubernetes.io/storage-provisioner"
+	// nodeIDAnnotation is the annotation on nodes with the IDs for the CSI
+	// drivers that are registered on the node, encoded as a json map
+	nodeIDAnnotation = "csi.volume.kubernetes.io/nodeid"
+)
+
+// csi is a generic driver for volumes from any CSI driver. The nodes where a
+// volume can be accessed are derived from the topology in the node affinity
+// of the PV, and from the VolumeAttachment for volumes that aren't limited to
+// a topology. The storage IDs of the nodes are the node names.
+type csi struct {
+	storkvolume.ClusterPairNotSupported
+	storkvolume.MigrationNotSupported
+	storkvolume.GroupSnapshotNotSupported
+	storkvolume.ClusterDomainsNotSupported
+	storkvolume.SnapshotRestoreNotSupported
+	storkvolume.SharedVolumeNotSupported
+	storkvolume.RestoreProvisionNotSupported
+	client      kubernetes.Interface
+	driverNames map[string]bool
+}
+
+func (c *csi) String() string {
+	return driverName
+}
+
+func (c *csi) Init(_ interface{}) error {
+	if c.client == nil {
+		config, err := rest.InClusterConfig()
+		if err != nil {
+			return fmt.Errorf("error getting cluster config: %v", err)
+		}
+		client, err := kubernetes.NewForConfig(config)
+		if err != nil {
+			return fmt.Errorf("error getting client: %v", err)
+		}
+		c.client = client
+	}
+	c.driverNames = make(map[string]bool)
+	for _, name := range strings.Split(os.Getenv(csiDriverNames), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			c.driverNames[name] = true
+		}
+	}
+	return nil
+}
+
+func (c *csi) Stop() error {
+	return nil
+}
+
+// Capabilities returns no capabilities since the plugin interfaces need
+// vendor specific support
+func (c *csi) Capabilities() map[storkvolume.Capability]bool {
+	return map[storkvolume.Capability]bool{}
+}
+
+// ownsDriver returns true if volumes from the CSI driver are owned by the
+// driver
+func (c *csi) ownsDriver(name string) bool {
+	return len(c.driverNames) == 0 || c.driverNames[name]
+}
+
+// InspectVolume returns the info for the CSI PV with the volumeID as the name
+func (c *csi) InspectVolume(volumeID string) (*storkvolume.Info, error) {
+	pv, err := k8s.Instance().GetPersistentVolume(volumeID)
+	if err != nil {
+		return nil, err
+	}
+	if pv.Spec.CSI == nil || !c.ownsDriver(pv.Spec.CSI.Driver) {
+		return nil, &errors.ErrNotFound{
+			ID:   volumeID,
+			Type: "Volume",
+		}
+	}
+
+	info := &storkvolume.Info{
+		VolumeID:        pv.Name,
+		VolumeName:      pv.Name,
+		Labels:          pv.Labels,
+		VolumeSourceRef: pv,
+	}
+	if storage, ok := pv.Spec.Capacity[v1.ResourceStorage]; ok {
+		info.Size = uint64(storage.Value()) / (1024 * 1024 * 1024)
+	}
+	for _, accessMode := range pv.Spec.AccessModes {
+		if accessMode == v1.ReadWriteMany {
+			info.Shared = true
+		}
+	}
+
+	if pv.Spec.NodeAffinity != nil && pv.Spec.NodeAffinity.Required != nil {
+		nodes, err := k8s.Instance().GetNodes()
+		if err != nil {
+			return nil, err
+		}
+		for _, node := range nodes.Items {
+			if k8shelper.MatchNodeSelectorTerms(
+				pv.Spec.NodeAffinity.Required.NodeSelectorTerms,
+				labels.Set(node.Labels),
+				fields.Set{"metadata.name": node.Name}) {
+				info.DataNodes = append(info.DataNodes, node.Name)
+			}
+		}
+		return info, nil
+	}
+
+	// Without a topology the data is assumed to be local to the node where
+	// the volume is attached, so that pods using it are placed there
+	attachedNode, err := c.GetVolumeAttachedNode(info)
+	if err != nil {
+		return nil, err
+	}
+	if attachedNode != "" {
+		info.DataNodes = []string{attachedNode}
+	}
+	return info, nil
+}
+
+// GetNodes returns the nodes in the cluster. Nodes that aren't ready, or
+// where none of the CSI drivers owned by the driver are registered, are
+// offline.
+func (c *csi) GetNodes() ([]*storkvolume.NodeInfo, error) {
+	nodes, err := k8s.Instance().GetNodes()
+	if err != nil {
+		return nil, err
+	}
+
+	var nodeInfos []*storkvolume.NodeInfo
+	for _, node := range nodes.Items {
+		nodeInfo := &storkvolume.NodeInfo{
+			StorageID:   node.Name,
+			SchedulerID: node.Name,
+			Hostname:    strings.ToLower(node.Name),
+			Zone:        node.Labels[kubeletapis.LabelZoneFailureDomain],
+			Region:      node.Labels[kubeletapis.LabelZoneRegion],
+			Status:      storkvolume.NodeOffline,
+		}
+		for _, address := range node.Status.Addresses {
+			switch address.Type {
+			case v1.NodeHostName:
+				nodeInfo.Hostname = strings.ToLower(address.Address)
+			case v1.NodeInternalIP:
+				nodeInfo.IPs = append(nodeInfo.IPs, address.Address)
+			}
+		}
+		if isNodeReady(&node) && c.isDriverRegistered(&node) {
+			nodeInfo.Status = storkvolume.NodeOnline
+		}
+		nodeInfos = append(nodeInfos, nodeInfo)
+	}
+	return nodeInfos, nil
+}
+
+func isNodeReady(node *v1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == v1.NodeReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// isDriverRegistered returns true if one of the CSI drivers owned by the
+// driver is registered on the node. Always true if the drivers aren't
+// configured.
+func (c *csi) isDriverRegistered(node *v1.Node) bool {
+	if len(c.driverNames) == 0 {
+		return true
+	}
+	nodeIDs := make(map[string]string)
+	if annotation, ok := node.Annotations[nodeIDAnnotation]; ok {
+		if err := json.Unmarshal([]byte(annotation), &nodeIDs); err != nil {
+			logrus.Warnf("Error parsing CSI node IDs for node %v: %v", node.Name, err)
+			return false
+		}
+	}
+	for name := range nodeIDs {
+		if c.driverNames[name] {
+			return true
+		}
+	}
+	return false
+}
+
+// OwnsPVC returns true if the PVC is provisioned by one of the CSI drivers
+// owned by the driver. Pending PVCs can only be checked if the drivers are
+// configured, since the provisioner can't be identified as a CSI driver
+// otherwise.
+func (c *csi) OwnsPVC(pvc *v1.PersistentVolumeClaim) bool {
+	if pvc.Spec.VolumeName == "" {
+		if len(c.driverNames) == 0 {
+			return false
+		}
+		provisioner := pvc.Annotations[pvcProvisionerAnnotation]
+		if provisioner == "" {
+			storageClassName := k8shelper.GetPersistentVolumeClaimClass(pvc)
+			if storageClassName == "" {
+				return false
+			}
+			storageClass, err := k8s.Instance().GetStorageClass(storageClassName)
+			if err != nil {
+				logrus.Warnf("Error getting storageclass %v for pvc %v: %v", storageClassName, pvc.Name, err)
+				return false
+			}
+			provisioner = storageClass.Provisioner
+		}
+		return c.driverNames[provisioner]
+	}
+
+	pv, err := k8s.Instance().GetPersistentVolume(pvc.Spec.VolumeName)
+	if err != nil {
+		logrus.Warnf("Error getting pv %v for pvc %v: %v", pvc.Spec.VolumeName, pvc.Name, err)
+		return false
+	}
+	return pv.Spec.CSI != nil && c.ownsDriver(pv.Spec.CSI.Driver)
+}
+
+func (c *csi) GetPodVolumes(podSpec *v1.PodSpec, namespace string) ([]*storkvolume.Info, error) {
+	var volumes []*storkvolume.Info
+	for _, volume := range podSpec.Volumes {
+		if volume.PersistentVolumeClaim == nil {
+			continue
+		}
+		pvc, err := k8s.Instance().GetPersistentVolumeClaim(
+			volume.PersistentVolumeClaim.ClaimName,
+			namespace)
+		if err != nil {
+			return nil, err
+		}
+
+		if !c.OwnsPVC(pvc) {
+			continue
+		}
+
+		if pvc.Status.Phase == v1.ClaimPending {
+			return nil, &storkvolume.ErrPVCPending{
+				Name: volume.PersistentVolumeClaim.ClaimName,
+			}
+		}
+
+		volumeInfo, err := c.InspectVolume(pvc.Spec.VolumeName)
+		if err != nil {
+			return nil, err
+		}
+		volumes = append(volumes, volumeInfo)
+	}
+	return volumes, nil
+}
+
+func (c *csi) GetVolumeClaimTemplates(templates []v1.PersistentVolumeClaim) (
+	[]v1.PersistentVolumeClaim, error) {
+	var csiTemplates []v1.PersistentVolumeClaim
+	for _, t := range templates {
+		if c.OwnsPVC(&t) {
+			csiTemplates = append(csiTemplates, t)
+		}
+	}
+	return csiTemplates, nil
+}
+
+// GetVolumeAttachedNode returns the node from the VolumeAttachment for the
+// volume
+func (c *csi) GetVolumeAttachedNode(volumeInfo *storkvolume.Info) (string, error) {
+	attachments, err := c.client.StorageV1beta1().VolumeAttachments().List(meta.ListOptions{})
+	if err != nil {
+		return "", err
+	}
+	for _, attachment := range attachments.Items {
+		if attachment.Spec.Source.PersistentVolumeName != nil &&
+			*attachment.Spec.Source.PersistentVolumeName == volumeInfo.VolumeID &&
+			attachment.Status.Attached {
+			return attachment.Spec.NodeName, nil
+		}
+	}
+	return "", nil
+}
+
+// ForceDetachVolume returns ErrNotSupported since CSI volumes can only be
+// detached by the attacher once the pods using them are deleted
+func (c *csi) ForceDetachVolume(volumeInfo *storkvolume.Info) error {
+	return &errors.ErrNotSupported{
+		Feature: "ForceDetachVolume",
+		Reason:  "CSI volumes are detached by the CSI attacher",
+	}
+}
+
+// GetSnapshotPlugin returns nil since snapshots need vendor specific support
+func (c *csi) GetSnapshotPlugin() snapshotVolume.Plugin {
+	return nil
+}
+
+func (c *csi) GetSnapshotType(snap *snapv1.VolumeSnapshot) (string, error) {
+	return "", &errors.ErrNotSupported{}
+}
+
+func (c *csi) GetClusterID() (string, error) {
+	return "", &errors.ErrNotSupported{}
+}
+
+func init() {
+	if err := storkvolume.Register(driverName, &csi{}); err != nil {
+		logrus.Panicf("Error registering csi volume driver: %v", err)
+	}
+}
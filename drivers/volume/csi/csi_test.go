@@ -0,0 +1,204 @@
+// +build unittest
+
+package csi
+
+import (
+	"os"
+	"testing"
+
+	storkvolume "github.com/libopenstorage/stork/drivers/volume"
+	"github.com/portworx/sched-ops/k8s"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	storagev1beta1 "k8s.io/api/storage/v1beta1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakekube "k8s.io/client-go/kubernetes/fake"
+	kubeletapis "k8s.io/kubernetes/pkg/kubelet/apis"
+)
+
+const testCSIDriver = "csi.example.com"
+
+func newTestNode(name string, zone string, ready bool, drivers string) *v1.Node {
+	status := v1.ConditionFalse
+	if ready {
+		status = v1.ConditionTrue
+	}
+	return &v1.Node{
+		ObjectMeta: meta.ObjectMeta{
+			Name:        name,
+			Labels:      map[string]string{kubeletapis.LabelZoneFailureDomain: zone},
+			Annotations: map[string]string{nodeIDAnnotation: drivers},
+		},
+		Status: v1.NodeStatus{
+			Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: status}},
+			Addresses: []v1.NodeAddress{
+				{Type: v1.NodeHostName, Address: name},
+				{Type: v1.NodeInternalIP, Address: "10.0.0." + name[len(name)-1:]},
+			},
+		},
+	}
+}
+
+func newTestPV(name string, driver string, zone string) *v1.PersistentVolume {
+	pv := &v1.PersistentVolume{
+		ObjectMeta: meta.ObjectMeta{Name: name},
+		Spec: v1.PersistentVolumeSpec{
+			Capacity:    v1.ResourceList{v1.ResourceStorage: resource.MustParse("10Gi")},
+			AccessModes: []v1.PersistentVolumeAccessMode{v1.ReadWriteOnce},
+			PersistentVolumeSource: v1.PersistentVolumeSource{
+				CSI: &v1.CSIPersistentVolumeSource{Driver: driver, VolumeHandle: name},
+			},
+		},
+	}
+	if zone != "" {
+		pv.Spec.NodeAffinity = &v1.VolumeNodeAffinity{
+			Required: &v1.NodeSelector{
+				NodeSelectorTerms: []v1.NodeSelectorTerm{{
+					MatchExpressions: []v1.NodeSelectorRequirement{{
+						Key:      "topology." + testCSIDriver + "/zone",
+						Operator: v1.NodeSelectorOpIn,
+						Values:   []string{zone},
+					}},
+				}},
+			},
+		}
+	}
+	return pv
+}
+
+func newTestPVC(name string, volumeName string, storageClass string) *v1.PersistentVolumeClaim {
+	phase := v1.ClaimBound
+	if volumeName == "" {
+		phase = v1.ClaimPending
+	}
+	return &v1.PersistentVolumeClaim{
+		ObjectMeta: meta.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: v1.PersistentVolumeClaimSpec{
+			VolumeName:       volumeName,
+			StorageClassName: &storageClass,
+		},
+		Status: v1.PersistentVolumeClaimStatus{Phase: phase},
+	}
+}
+
+func setupTestDriver(t *testing.T, driverNames string) *csi {
+	node1 := newTestNode("node1", "zone1", true, `{"`+testCSIDriver+`":"id1"}`)
+	node1.Labels["topology."+testCSIDriver+"/zone"] = "zone1"
+	node2 := newTestNode("node2", "zone1", true, `{"other.csi.com":"id2"}`)
+	node2.Labels["topology."+testCSIDriver+"/zone"] = "zone1"
+	node3 := newTestNode("node3", "zone2", false, `{"`+testCSIDriver+`":"id3"}`)
+	node3.Labels["topology."+testCSIDriver+"/zone"] = "zone2"
+	pvName := "pv2"
+	objects := []runtime.Object{
+		node1, node2, node3,
+		newTestPV("pv1", testCSIDriver, "zone1"),
+		newTestPV("pv2", testCSIDriver, ""),
+		newTestPV("pv3", "other.csi.com", ""),
+		newTestPVC("pvc1", "pv1", "csi-sc"),
+		newTestPVC("pvc2", "pv2", "csi-sc"),
+		newTestPVC("pvc3", "pv3", "other-sc"),
+		newTestPVC("pending", "", "csi-sc"),
+		&storagev1.StorageClass{
+			ObjectMeta:  meta.ObjectMeta{Name: "csi-sc"},
+			Provisioner: testCSIDriver,
+		},
+		&storagev1beta1.VolumeAttachment{
+			ObjectMeta: meta.ObjectMeta{Name: "attachment1"},
+			Spec: storagev1beta1.VolumeAttachmentSpec{
+				Attacher: testCSIDriver,
+				Source:   storagev1beta1.VolumeAttachmentSource{PersistentVolumeName: &pvName},
+				NodeName: "node2",
+			},
+			Status: storagev1beta1.VolumeAttachmentStatus{Attached: true},
+		},
+	}
+	client := fakekube.NewSimpleClientset(objects...)
+	k8s.Instance().SetClient(client, nil, nil, nil, nil, nil)
+
+	require.NoError(t, os.Setenv(csiDriverNames, driverNames), "Error setting driver names")
+	d := &csi{client: client}
+	require.NoError(t, d.Init(nil), "Error initializing driver")
+	return d
+}
+
+func TestCSIGetNodes(t *testing.T) {
+	defer os.Unsetenv(csiDriverNames)
+	d := setupTestDriver(t, testCSIDriver)
+	nodes, err := d.GetNodes()
+	require.NoError(t, err, "Error getting nodes")
+	require.Len(t, nodes, 3)
+	require.Equal(t, &storkvolume.NodeInfo{
+		StorageID:   "node1",
+		SchedulerID: "node1",
+		Hostname:    "node1",
+		IPs:         []string{"10.0.0.1"},
+		Zone:        "zone1",
+		Status:      storkvolume.NodeOnline,
+	}, nodes[0])
+	// The CSI driver isn't registered on node2 and node3 isn't ready
+	require.Equal(t, storkvolume.NodeOffline, nodes[1].Status)
+	require.Equal(t, storkvolume.NodeOffline, nodes[2].Status)
+
+	d = setupTestDriver(t, "")
+	nodes, err = d.GetNodes()
+	require.NoError(t, err, "Error getting nodes")
+	require.Equal(t, storkvolume.NodeOnline, nodes[1].Status)
+	require.Equal(t, storkvolume.NodeOffline, nodes[2].Status)
+}
+
+func TestCSIGetPodVolumes(t *testing.T) {
+	defer os.Unsetenv(csiDriverNames)
+	d := setupTestDriver(t, testCSIDriver)
+	podSpec := &v1.PodSpec{}
+	for _, claim := range []string{"pvc1", "pvc2", "pvc3"} {
+		podSpec.Volumes = append(podSpec.Volumes, v1.Volume{
+			VolumeSource: v1.VolumeSource{
+				PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: claim},
+			},
+		})
+	}
+	volumes, err := d.GetPodVolumes(podSpec, "default")
+	require.NoError(t, err, "Error getting pod volumes")
+	require.Len(t, volumes, 2)
+	// The nodes in the topology of the PV are used for the volume with a
+	// topology, and the attached node for the one without
+	require.Equal(t, "pv1", volumes[0].VolumeID)
+	require.Equal(t, []string{"node1", "node2"}, volumes[0].DataNodes)
+	require.Equal(t, uint64(10), volumes[0].Size)
+	require.Equal(t, "pv2", volumes[1].VolumeID)
+	require.Equal(t, []string{"node2"}, volumes[1].DataNodes)
+
+	podSpec.Volumes = append(podSpec.Volumes, v1.Volume{
+		VolumeSource: v1.VolumeSource{
+			PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: "pending"},
+		},
+	})
+	_, err = d.GetPodVolumes(podSpec, "default")
+	require.Equal(t, &storkvolume.ErrPVCPending{Name: "pending"}, err)
+
+	// Volumes from all CSI drivers are owned if the drivers aren't configured
+	d = setupTestDriver(t, "")
+	volumes, err = d.GetPodVolumes(&v1.PodSpec{Volumes: podSpec.Volumes[:3]}, "default")
+	require.NoError(t, err, "Error getting pod volumes")
+	require.Len(t, volumes, 3)
+	require.Nil(t, volumes[2].DataNodes)
+}
+
+func TestCSIVolumeAttachment(t *testing.T) {
+	defer os.Unsetenv(csiDriverNames)
+	d := setupTestDriver(t, testCSIDriver)
+	attachedNode, err := d.GetVolumeAttachedNode(&storkvolume.Info{VolumeID: "pv2"})
+	require.NoError(t, err, "Error getting attached node")
+	require.Equal(t, "node2", attachedNode)
+	attachedNode, err = d.GetVolumeAttachedNode(&storkvolume.Info{VolumeID: "pv1"})
+	require.NoError(t, err, "Error getting attached node")
+	require.Equal(t, "", attachedNode)
+	require.Error(t, d.ForceDetachVolume(&storkvolume.Info{VolumeID: "pv2"}))
+
+	_, err = d.InspectVolume("pv3")
+	require.Error(t, err)
+	require.Equal(t, "Volume with UID/Name: pv3 not found", err.Error())
+}
@@ -0,0 +1,297 @@
+package external
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	snapv1 "github.com/kubernetes-incubator/external-storage/snapshot/pkg/apis/crd/v1"
+	snapshotVolume "github.com/kubernetes-incubator/external-storage/snapshot/pkg/volume"
+	"github.com/libopenstorage/openstorage/pkg/grpcserver"
+	storkvolume "github.com/libopenstorage/stork/drivers/volume"
+	stork_crd "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	// callTimeout is the timeout for each call to the plugin
+	callTimeout = 2 * time.Minute
+)
+
+// driver is a volume driver that is implemented out of tree by a plugin
+// serving the driver plugin service on a unix socket
+type driver struct {
+	name         string
+	socket       string
+	conn         *grpc.ClientConn
+	capabilities map[storkvolume.Capability]bool
+}
+
+// Register registers a volume driver with the name that calls the plugin
+// serving the driver plugin service on the unix socket. The plugin is
+// connected to when the driver is initialized.
+func Register(name string, socket string) error {
+	return storkvolume.Register(name, &driver{
+		name:   name,
+		socket: socket,
+	})
+}
+
+func (d *driver) String() string {
+	return d.name
+}
+
+// Init connects to the plugin and gets the capabilities supported by it
+func (d *driver) Init(_ interface{}) error {
+	conn, err := grpcserver.Connect("unix://"+d.socket, []grpc.DialOption{grpc.WithInsecure()})
+	if err != nil {
+		return fmt.Errorf("error connecting to driver plugin at %v: %v", d.socket, err)
+	}
+	d.conn = conn
+
+	response := &CapabilitiesResponse{}
+	if err := d.call(methodCapabilities, &Empty{}, response); err != nil {
+		return fmt.Errorf("error getting capabilities from driver plugin: %v", err)
+	}
+	d.capabilities = response.Capabilities
+	if d.capabilities == nil {
+		d.capabilities = make(map[storkvolume.Capability]bool)
+	}
+	// The snapshot plugin runs in stork, so snapshots can't be served by the
+	// plugin
+	d.capabilities[storkvolume.CapabilitySnapshots] = false
+	d.capabilities[storkvolume.CapabilityCloudSnapshots] = false
+	logrus.Infof("Connected to driver plugin %v at %v with capabilities %v", d.name, d.socket, d.capabilities)
+	return nil
+}
+
+func (d *driver) Stop() error {
+	if d.conn == nil {
+		return nil
+	}
+	return d.conn.Close()
+}
+
+// call calls the method on the plugin and converts the status returned by it
+// to the errors returned by the drivers
+func (d *driver) call(method string, request interface{}, response interface{}) error {
+	if d.conn == nil {
+		return fmt.Errorf("driver plugin %v hasn't been initialized", d.name)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+	defer cancel()
+	if err := d.conn.Invoke(ctx, "/"+ServiceName+"/"+method, request, response,
+		grpc.CallContentSubtype(codecName)); err != nil {
+		return fromStatus(method, err)
+	}
+	return nil
+}
+
+func (d *driver) Capabilities() map[storkvolume.Capability]bool {
+	return d.capabilities
+}
+
+func (d *driver) InspectVolume(volumeID string) (*storkvolume.Info, error) {
+	response := &VolumeResponse{}
+	if err := d.call(methodInspectVolume, &VolumeIDRequest{VolumeID: volumeID}, response); err != nil {
+		return nil, err
+	}
+	return fromVolumeInfo(response.Volume), nil
+}
+
+func (d *driver) GetNodes() ([]*storkvolume.NodeInfo, error) {
+	response := &NodesResponse{}
+	if err := d.call(methodGetNodes, &Empty{}, response); err != nil {
+		return nil, err
+	}
+	return fromNodeInfos(response.Nodes), nil
+}
+
+func (d *driver) GetPodVolumes(podSpec *v1.PodSpec, namespace string) ([]*storkvolume.Info, error) {
+	response := &VolumesResponse{}
+	request := &PodVolumesRequest{
+		PodSpec:   podSpec,
+		Namespace: namespace,
+	}
+	if err := d.call(methodGetPodVolumes, request, response); err != nil {
+		return nil, err
+	}
+	return fromVolumeInfos(response.Volumes), nil
+}
+
+func (d *driver) GetVolumeClaimTemplates(templates []v1.PersistentVolumeClaim) ([]v1.PersistentVolumeClaim, error) {
+	response := &ClaimTemplatesMessage{}
+	if err := d.call(methodGetVolumeClaimTemplates, &ClaimTemplatesMessage{Templates: templates}, response); err != nil {
+		return nil, err
+	}
+	return response.Templates, nil
+}
+
+// OwnsPVC returns false if the plugin can't be reached since the driver
+// interface doesn't return errors for it
+func (d *driver) OwnsPVC(pvc *v1.PersistentVolumeClaim) bool {
+	response := &OwnsPVCResponse{}
+	if err := d.call(methodOwnsPVC, &PVCRequest{PVC: pvc}, response); err != nil {
+		logrus.Errorf("Error checking if driver plugin %v owns PVC %v/%v: %v", d.name, pvc.Namespace, pvc.Name, err)
+		return false
+	}
+	return response.Owns
+}
+
+// GetSnapshotPlugin returns nil since the snapshot plugin can't be served by
+// the plugin
+func (d *driver) GetSnapshotPlugin() snapshotVolume.Plugin {
+	return nil
+}
+
+func (d *driver) GetSnapshotType(snap *snapv1.VolumeSnapshot) (string, error) {
+	response := &SnapshotTypeResponse{}
+	if err := d.call(methodGetSnapshotType, &SnapshotRequest{Snapshot: snap}, response); err != nil {
+		return "", err
+	}
+	return response.Type, nil
+}
+
+func (d *driver) GetClusterID() (string, error) {
+	response := &ClusterIDResponse{}
+	if err := d.call(methodGetClusterID, &Empty{}, response); err != nil {
+		return "", err
+	}
+	return response.ClusterID, nil
+}
+
+func (d *driver) CreateGroupSnapshot(snap *stork_crd.GroupVolumeSnapshot) (*storkvolume.GroupSnapshotCreateResponse, error) {
+	response := &GroupSnapshotResponse{}
+	if err := d.call(methodCreateGroupSnapshot, &GroupSnapshotRequest{GroupSnapshot: snap}, response); err != nil {
+		return nil, err
+	}
+	return &storkvolume.GroupSnapshotCreateResponse{Snapshots: response.Snapshots}, nil
+}
+
+func (d *driver) GetGroupSnapshotStatus(snap *stork_crd.GroupVolumeSnapshot) (*storkvolume.GroupSnapshotCreateResponse, error) {
+	response := &GroupSnapshotResponse{}
+	if err := d.call(methodGetGroupSnapshotStatus, &GroupSnapshotRequest{GroupSnapshot: snap}, response); err != nil {
+		return nil, err
+	}
+	return &storkvolume.GroupSnapshotCreateResponse{Snapshots: response.Snapshots}, nil
+}
+
+func (d *driver) DeleteGroupSnapshot(snap *stork_crd.GroupVolumeSnapshot) error {
+	return d.call(methodDeleteGroupSnapshot, &GroupSnapshotRequest{GroupSnapshot: snap}, &Empty{})
+}
+
+func (d *driver) CreatePair(pair *stork_crd.ClusterPair) (string, error) {
+	response := &CreatePairResponse{}
+	if err := d.call(methodCreatePair, &ClusterPairRequest{ClusterPair: pair}, response); err != nil {
+		return "", err
+	}
+	return response.RemoteID, nil
+}
+
+func (d *driver) DeletePair(pair *stork_crd.ClusterPair) error {
+	return d.call(methodDeletePair, &ClusterPairRequest{ClusterPair: pair}, &Empty{})
+}
+
+func (d *driver) StartMigration(migration *stork_crd.Migration) ([]*stork_crd.VolumeInfo, error) {
+	response := &MigrationVolumesResponse{}
+	if err := d.call(methodStartMigration, &MigrationRequest{Migration: migration}, response); err != nil {
+		return nil, err
+	}
+	return response.Volumes, nil
+}
+
+func (d *driver) GetMigrationStatus(migration *stork_crd.Migration) ([]*stork_crd.VolumeInfo, error) {
+	response := &MigrationVolumesResponse{}
+	if err := d.call(methodGetMigrationStatus, &MigrationRequest{Migration: migration}, response); err != nil {
+		return nil, err
+	}
+	return response.Volumes, nil
+}
+
+func (d *driver) CancelMigration(migration *stork_crd.Migration) error {
+	return d.call(methodCancelMigration, &MigrationRequest{Migration: migration}, &Empty{})
+}
+
+// UpdateMigratedPersistentVolumeSpec updates the object in place with the
+// object returned by the plugin, since the callers don't use the returned
+// object
+func (d *driver) UpdateMigratedPersistentVolumeSpec(object runtime.Unstructured) (runtime.Unstructured, error) {
+	response := &ObjectMessage{}
+	if err := d.call(methodUpdateMigratedPersistentVolumeSpec,
+		&ObjectMessage{Object: object.UnstructuredContent()}, response); err != nil {
+		return nil, err
+	}
+	object.SetUnstructuredContent(response.Object)
+	return object, nil
+}
+
+func (d *driver) GetClusterDomains() (*stork_crd.ClusterDomains, error) {
+	response := &ClusterDomainsResponse{}
+	if err := d.call(methodGetClusterDomains, &Empty{}, response); err != nil {
+		return nil, err
+	}
+	return response.ClusterDomains, nil
+}
+
+func (d *driver) ActivateClusterDomain(update *stork_crd.ClusterDomainUpdate) error {
+	return d.call(methodActivateClusterDomain, &ClusterDomainUpdateRequest{ClusterDomainUpdate: update}, &Empty{})
+}
+
+func (d *driver) DeactivateClusterDomain(update *stork_crd.ClusterDomainUpdate) error {
+	return d.call(methodDeactivateClusterDomain, &ClusterDomainUpdateRequest{ClusterDomainUpdate: update}, &Empty{})
+}
+
+func (d *driver) StartVolumeSnapshotRestore(restore *stork_crd.VolumeSnapshotRestore) error {
+	return d.callSnapshotRestore(methodStartVolumeSnapshotRestore, restore)
+}
+
+func (d *driver) GetVolumeSnapshotRestoreStatus(restore *stork_crd.VolumeSnapshotRestore) error {
+	return d.callSnapshotRestore(methodGetVolumeSnapshotRestoreStatus, restore)
+}
+
+// callSnapshotRestore calls the snapshot restore method and updates the
+// status of the restore with the status returned by the plugin
+func (d *driver) callSnapshotRestore(method string, restore *stork_crd.VolumeSnapshotRestore) error {
+	response := &SnapshotRestoreMessage{}
+	if err := d.call(method, &SnapshotRestoreMessage{Restore: restore}, response); err != nil {
+		return err
+	}
+	if response.Restore != nil {
+		restore.Status = response.Restore.Status
+	}
+	return nil
+}
+
+func (d *driver) GetSharedVolumeCoordinator(volume *storkvolume.Info) (string, error) {
+	response := &NodeIDResponse{}
+	if err := d.call(methodGetSharedVolumeCoordinator, &VolumeRequest{Volume: toVolumeInfo(volume)}, response); err != nil {
+		return "", err
+	}
+	return response.StorageID, nil
+}
+
+func (d *driver) GetVolumeAttachedNode(volume *storkvolume.Info) (string, error) {
+	response := &NodeIDResponse{}
+	if err := d.call(methodGetVolumeAttachedNode, &VolumeRequest{Volume: toVolumeInfo(volume)}, response); err != nil {
+		return "", err
+	}
+	return response.StorageID, nil
+}
+
+func (d *driver) ForceDetachVolume(volume *storkvolume.Info) error {
+	return d.call(methodForceDetachVolume, &VolumeRequest{Volume: toVolumeInfo(volume)}, &Empty{})
+}
+
+// PreProvisionRestoreVolume returns ErrNotSupported since snapshots can't be
+// served by plugins
+func (d *driver) PreProvisionRestoreVolume(
+	*snapv1.VolumeSnapshotData,
+	*v1.PersistentVolumeClaim,
+	map[string]string,
+) error {
+	return &errors.ErrNotSupported{}
+}
@@ -0,0 +1,178 @@
+// +build unittest
+
+package external
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	storkvolume "github.com/libopenstorage/stork/drivers/volume"
+	"github.com/libopenstorage/stork/drivers/volume/mock"
+	"github.com/libopenstorage/stork/pkg/errors"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestExternalDriver(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stork-driver")
+	require.NoError(t, err, "Error creating socket dir")
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "driver.sock")
+
+	mockDriver := &mock.Driver{}
+	require.NoError(t, mockDriver.CreateCluster(3, &v1.NodeList{}), "Error creating mock cluster")
+	require.NoError(t, mockDriver.ProvisionVolume("vol1", []int{0, 2}, 10), "Error provisioning volume")
+	require.NoError(t, mockDriver.AttachVolume("vol1", 2), "Error attaching volume")
+	go func() {
+		if err := Serve(socket, mockDriver); err != nil {
+			t.Logf("Error serving driver plugin: %v", err)
+		}
+	}()
+
+	require.NoError(t, Register("test", socket), "Error registering driver plugin")
+	d, err := storkvolume.Get("test")
+	require.NoError(t, err, "Error getting driver plugin")
+	require.NoError(t, d.Init(nil), "Error initializing driver plugin")
+	defer d.Stop()
+	require.Equal(t, "test", d.String())
+	require.Equal(t, map[storkvolume.Capability]bool{
+		storkvolume.CapabilitySnapshots:      false,
+		storkvolume.CapabilityCloudSnapshots: false,
+	}, d.Capabilities())
+
+	nodes, err := d.GetNodes()
+	require.NoError(t, err, "Error getting nodes")
+	require.Len(t, nodes, 3)
+	require.Equal(t, "node1", nodes[0].StorageID)
+	require.Equal(t, storkvolume.NodeOnline, nodes[0].Status)
+
+	volume, err := d.InspectVolume("vol1")
+	require.NoError(t, err, "Error inspecting volume")
+	require.Equal(t, []string{"node1", "node3"}, volume.DataNodes)
+	require.Equal(t, uint64(10), volume.Size)
+
+	attachedNode, err := d.GetVolumeAttachedNode(volume)
+	require.NoError(t, err, "Error getting attached node")
+	require.Equal(t, "node3", attachedNode)
+	require.NoError(t, d.ForceDetachVolume(volume), "Error detaching volume")
+	attachedNode, err = d.GetVolumeAttachedNode(volume)
+	require.NoError(t, err, "Error getting attached node")
+	require.Equal(t, "", attachedNode)
+
+	pvc := mockDriver.NewPVC("vol1")
+	podVolumes, err := d.GetPodVolumes(&v1.PodSpec{
+		Volumes: []v1.Volume{
+			{
+				VolumeSource: v1.VolumeSource{
+					PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: pvc.Name},
+				},
+			},
+		},
+	}, "default")
+	require.NoError(t, err, "Error getting pod volumes")
+	require.Len(t, podVolumes, 1)
+	require.Equal(t, "vol1", podVolumes[0].VolumeID)
+	require.False(t, d.OwnsPVC(pvc))
+
+	// Errors from the driver are returned with the same types
+	_, err = d.InspectVolume("missing")
+	require.Error(t, err)
+	require.Equal(t, "volume with UID/Name: missing not found", err.Error())
+
+	_, err = d.StartMigration(nil)
+	require.Error(t, err)
+	_, ok := err.(*errors.ErrNotSupported)
+	require.True(t, ok, "Expected ErrNotSupported, got %v", err)
+
+	_, err = d.UpdateMigratedPersistentVolumeSpec(&unstructured.Unstructured{Object: map[string]interface{}{}})
+	_, ok = err.(*errors.ErrNotSupported)
+	require.True(t, ok, "Expected ErrNotSupported, got %v", err)
+
+	_, err = d.GetSnapshotType(nil)
+	_, ok = err.(*errors.ErrNotSupported)
+	require.True(t, ok, "Expected ErrNotSupported, got %v", err)
+
+	mockDriver.SetInterfaceError(&storkvolume.ErrPVCPending{Name: "pvc1"})
+	_, err = d.GetNodes()
+	require.Equal(t, &storkvolume.ErrPVCPending{Name: "pvc1"}, err)
+
+	mockDriver.SetInterfaceError(fmt.Errorf("driver error"))
+	_, err = d.GetNodes()
+	require.Error(t, err)
+	require.Equal(t, "driver error", err.Error())
+}
+
+func TestExternalDriverNotInitialized(t *testing.T) {
+	d := &driver{name: "test"}
+	_, err := d.GetNodes()
+	require.Error(t, err)
+	require.Equal(t, "driver plugin test hasn't been initialized", err.Error())
+	require.NoError(t, d.Stop())
+}
+
+// TestProtocolMatchesProto checks that the Go types used for the messages are
+// the JSON encoding of the messages in driver.proto
+func TestProtocolMatchesProto(t *testing.T) {
+	goMessages := make(map[string]reflect.Type)
+	for _, message := range []interface{}{
+		Empty{}, VolumeInfo{}, StoragePoolInfo{}, NodeInfo{}, CapabilitiesResponse{},
+		VolumeIDRequest{}, VolumeRequest{}, VolumeResponse{}, VolumesResponse{},
+		NodesResponse{}, NodeIDResponse{}, PodVolumesRequest{}, ClaimTemplatesMessage{},
+		PVCRequest{}, OwnsPVCResponse{}, SnapshotRequest{}, SnapshotTypeResponse{},
+		ClusterIDResponse{}, GroupSnapshotRequest{}, GroupSnapshotResponse{},
+		ClusterPairRequest{}, CreatePairResponse{}, MigrationRequest{},
+		MigrationVolumesResponse{}, ObjectMessage{}, ClusterDomainsResponse{},
+		ClusterDomainUpdateRequest{}, SnapshotRestoreMessage{},
+	} {
+		goMessages[reflect.TypeOf(message).Name()] = reflect.TypeOf(message)
+	}
+
+	contents, err := ioutil.ReadFile("driver.proto")
+	require.NoError(t, err, "Error reading driver.proto")
+	proto := string(contents)
+
+	rpcs := regexp.MustCompile(`rpc (\w+)\((\w+)\) returns \((\w+)\);`).FindAllStringSubmatch(proto, -1)
+	require.Len(t, rpcs, len(methods))
+	for i, rpc := range rpcs {
+		require.Equal(t, methods[i].name, rpc[1])
+		require.Equal(t, rpc[2], reflect.TypeOf(methods[i].newRequest()).Elem().Name(), "Request for %v", rpc[1])
+		require.Contains(t, goMessages, rpc[3], "Response for %v", rpc[1])
+	}
+
+	messages := regexp.MustCompile(`(?s)\nmessage (\w+) \{\n(.*?)\}`).FindAllStringSubmatch(proto, -1)
+	require.Len(t, messages, len(goMessages))
+	field := regexp.MustCompile(`(?m)^\s*(?:repeated )?([\w.]+|map<[\w, ]+>) (\w+) = \d+;`)
+	for _, message := range messages {
+		goType, ok := goMessages[message[1]]
+		require.True(t, ok, "Go type not found for message %v", message[1])
+
+		protoFields := make(map[string]string)
+		for _, f := range field.FindAllStringSubmatch(message[2], -1) {
+			// The proto3 JSON mapping uses the lowerCamelCase field names
+			parts := strings.Split(f[2], "_")
+			for i := 1; i < len(parts); i++ {
+				parts[i] = strings.Title(parts[i])
+			}
+			protoFields[strings.Join(parts, "")] = f[1]
+		}
+		goFields := make(map[string]string)
+		for i := 0; i < goType.NumField(); i++ {
+			tag := strings.Split(goType.Field(i).Tag.Get("json"), ",")
+			options := strings.Join(tag[1:], ",")
+			goFields[tag[0]] = options
+			// 64 bit integers are encoded as strings
+			protoType, ok := protoFields[tag[0]]
+			require.True(t, ok, "Field %v of %v not found in driver.proto", tag[0], message[1])
+			require.Equal(t, protoType == "uint64", options == "string",
+				"Encoding of field %v of %v doesn't match driver.proto", tag[0], message[1])
+		}
+		require.Len(t, goFields, len(protoFields), "Fields of %v don't match driver.proto", message[1])
+	}
+}
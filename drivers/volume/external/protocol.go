@@ -0,0 +1,423 @@
+package external
+
+import (
+	"encoding/json"
+	"fmt"
+
+	snapv1 "github.com/kubernetes-incubator/external-storage/snapshot/pkg/apis/crd/v1"
+	storkvolume "github.com/libopenstorage/stork/drivers/volume"
+	stork_crd "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/status"
+	v1 "k8s.io/api/core/v1"
+)
+
+// The driver plugin protocol is the gRPC service defined in driver.proto,
+// served on a unix socket. The messages are encoded with the proto3 JSON
+// mapping, with the content-subtype set to json, so that plugins can be
+// written in any language without sharing generated code with stork. The Go
+// types below are the JSON encoding of the messages in driver.proto and must be
+// kept in sync with it.
+//
+// Errors are returned as gRPC status codes:
+//   - Unimplemented if the plugin doesn't support the method
+//   - FailedPrecondition with the name of the PVC as the message if the PVC
+//     hasn't been bound yet
+//   - Any other code with a message for other errors
+const (
+	// ServiceName is the name of the gRPC service served by driver plugins
+	ServiceName = "stork.volume.v1.Driver"
+	// codecName is the content-subtype for the json encoded messages
+	codecName = "json"
+)
+
+// Methods of the driver plugin service
+const (
+	methodCapabilities                       = "Capabilities"
+	methodInspectVolume                      = "InspectVolume"
+	methodGetNodes                           = "GetNodes"
+	methodGetPodVolumes                      = "GetPodVolumes"
+	methodGetVolumeClaimTemplates            = "GetVolumeClaimTemplates"
+	methodOwnsPVC                            = "OwnsPVC"
+	methodGetSnapshotType                    = "GetSnapshotType"
+	methodGetClusterID                       = "GetClusterID"
+	methodCreateGroupSnapshot                = "CreateGroupSnapshot"
+	methodGetGroupSnapshotStatus             = "GetGroupSnapshotStatus"
+	methodDeleteGroupSnapshot                = "DeleteGroupSnapshot"
+	methodCreatePair                         = "CreatePair"
+	methodDeletePair                         = "DeletePair"
+	methodStartMigration                     = "StartMigration"
+	methodGetMigrationStatus                 = "GetMigrationStatus"
+	methodCancelMigration                    = "CancelMigration"
+	methodUpdateMigratedPersistentVolumeSpec = "UpdateMigratedPersistentVolumeSpec"
+	methodGetClusterDomains                  = "GetClusterDomains"
+	methodActivateClusterDomain              = "ActivateClusterDomain"
+	methodDeactivateClusterDomain            = "DeactivateClusterDomain"
+	methodStartVolumeSnapshotRestore         = "StartVolumeSnapshotRestore"
+	methodGetVolumeSnapshotRestoreStatus     = "GetVolumeSnapshotRestoreStatus"
+	methodGetSharedVolumeCoordinator         = "GetSharedVolumeCoordinator"
+	methodGetVolumeAttachedNode              = "GetVolumeAttachedNode"
+	methodForceDetachVolume                  = "ForceDetachVolume"
+)
+
+// Empty is used for methods without parameters or return values
+type Empty struct{}
+
+// VolumeInfo is the information about a volume
+type VolumeInfo struct {
+	VolumeID        string            `json:"volumeId"`
+	VolumeName      string            `json:"volumeName"`
+	DataNodes       []string          `json:"dataNodes"`
+	Size            uint64            `json:"size,string"`
+	ParentID        string            `json:"parentId"`
+	Labels          map[string]string `json:"labels"`
+	Shared          bool              `json:"shared"`
+	VolumeSourceRef interface{}       `json:"volumeSourceRef"`
+	Driver          string            `json:"driver"`
+}
+
+// StoragePoolInfo is the information about a storage pool on a node
+type StoragePoolInfo struct {
+	ID            string `json:"id"`
+	TotalCapacity uint64 `json:"totalCapacity,string"`
+	FreeCapacity  uint64 `json:"freeCapacity,string"`
+}
+
+// NodeInfo is the information about a storage node
+type NodeInfo struct {
+	StorageID     string             `json:"storageId"`
+	SchedulerID   string             `json:"schedulerId"`
+	Hostname      string             `json:"hostname"`
+	IPs           []string           `json:"ips"`
+	Rack          string             `json:"rack"`
+	Zone          string             `json:"zone"`
+	Region        string             `json:"region"`
+	Status        string             `json:"status"`
+	TotalCapacity uint64             `json:"totalCapacity,string"`
+	FreeCapacity  uint64             `json:"freeCapacity,string"`
+	Pools         []*StoragePoolInfo `json:"pools"`
+	Driver        string             `json:"driver"`
+}
+
+// CapabilitiesResponse is the response for Capabilities
+type CapabilitiesResponse struct {
+	Capabilities map[storkvolume.Capability]bool `json:"capabilities"`
+}
+
+// VolumeIDRequest is the request for InspectVolume
+type VolumeIDRequest struct {
+	VolumeID string `json:"volumeId"`
+}
+
+// VolumeRequest is the request for the methods that take a volume
+type VolumeRequest struct {
+	Volume *VolumeInfo `json:"volume"`
+}
+
+// VolumeResponse is the response for InspectVolume
+type VolumeResponse struct {
+	Volume *VolumeInfo `json:"volume"`
+}
+
+// VolumesResponse is the response for GetPodVolumes
+type VolumesResponse struct {
+	Volumes []*VolumeInfo `json:"volumes"`
+}
+
+// NodesResponse is the response for GetNodes
+type NodesResponse struct {
+	Nodes []*NodeInfo `json:"nodes"`
+}
+
+// NodeIDResponse is the response for the methods that return the storage ID
+// of a node
+type NodeIDResponse struct {
+	StorageID string `json:"storageId"`
+}
+
+// PodVolumesRequest is the request for GetPodVolumes
+type PodVolumesRequest struct {
+	PodSpec   *v1.PodSpec `json:"podSpec"`
+	Namespace string      `json:"namespace"`
+}
+
+// ClaimTemplatesMessage is the request and response for
+// GetVolumeClaimTemplates
+type ClaimTemplatesMessage struct {
+	Templates []v1.PersistentVolumeClaim `json:"templates"`
+}
+
+// PVCRequest is the request for OwnsPVC
+type PVCRequest struct {
+	PVC *v1.PersistentVolumeClaim `json:"pvc"`
+}
+
+// OwnsPVCResponse is the response for OwnsPVC
+type OwnsPVCResponse struct {
+	Owns bool `json:"owns"`
+}
+
+// SnapshotRequest is the request for GetSnapshotType
+type SnapshotRequest struct {
+	Snapshot *snapv1.VolumeSnapshot `json:"snapshot"`
+}
+
+// SnapshotTypeResponse is the response for GetSnapshotType
+type SnapshotTypeResponse struct {
+	Type string `json:"type"`
+}
+
+// ClusterIDResponse is the response for GetClusterID
+type ClusterIDResponse struct {
+	ClusterID string `json:"clusterId"`
+}
+
+// GroupSnapshotRequest is the request for the group snapshot methods
+type GroupSnapshotRequest struct {
+	GroupSnapshot *stork_crd.GroupVolumeSnapshot `json:"groupSnapshot"`
+}
+
+// GroupSnapshotResponse is the response for CreateGroupSnapshot and
+// GetGroupSnapshotStatus
+type GroupSnapshotResponse struct {
+	Snapshots []*stork_crd.VolumeSnapshotStatus `json:"snapshots"`
+}
+
+// ClusterPairRequest is the request for the cluster pair methods
+type ClusterPairRequest struct {
+	ClusterPair *stork_crd.ClusterPair `json:"clusterPair"`
+}
+
+// CreatePairResponse is the response for CreatePair
+type CreatePairResponse struct {
+	RemoteID string `json:"remoteId"`
+}
+
+// MigrationRequest is the request for the migration methods
+type MigrationRequest struct {
+	Migration *stork_crd.Migration `json:"migration"`
+}
+
+// MigrationVolumesResponse is the response for StartMigration and
+// GetMigrationStatus
+type MigrationVolumesResponse struct {
+	Volumes []*stork_crd.VolumeInfo `json:"volumes"`
+}
+
+// ObjectMessage is the request and response for
+// UpdateMigratedPersistentVolumeSpec
+type ObjectMessage struct {
+	Object map[string]interface{} `json:"object"`
+}
+
+// ClusterDomainsResponse is the response for GetClusterDomains
+type ClusterDomainsResponse struct {
+	ClusterDomains *stork_crd.ClusterDomains `json:"clusterDomains"`
+}
+
+// ClusterDomainUpdateRequest is the request for ActivateClusterDomain and
+// DeactivateClusterDomain
+type ClusterDomainUpdateRequest struct {
+	ClusterDomainUpdate *stork_crd.ClusterDomainUpdate `json:"clusterDomainUpdate"`
+}
+
+// SnapshotRestoreMessage is the request and response for the snapshot restore
+// methods. The status of the restore in the response replaces the status in
+// stork.
+type SnapshotRestoreMessage struct {
+	Restore *stork_crd.VolumeSnapshotRestore `json:"restore"`
+}
+
+// toVolumeInfo converts the volume info from a driver to the message sent to
+// or from the plugin
+func toVolumeInfo(info *storkvolume.Info) *VolumeInfo {
+	if info == nil {
+		return nil
+	}
+	return &VolumeInfo{
+		VolumeID:        info.VolumeID,
+		VolumeName:      info.VolumeName,
+		DataNodes:       info.DataNodes,
+		Size:            info.Size,
+		ParentID:        info.ParentID,
+		Labels:          info.Labels,
+		Shared:          info.Shared,
+		VolumeSourceRef: info.VolumeSourceRef,
+		Driver:          info.Driver,
+	}
+}
+
+// fromVolumeInfo converts the volume info in a message to the volume info
+// returned by the drivers
+func fromVolumeInfo(info *VolumeInfo) *storkvolume.Info {
+	if info == nil {
+		return nil
+	}
+	return &storkvolume.Info{
+		VolumeID:        info.VolumeID,
+		VolumeName:      info.VolumeName,
+		DataNodes:       info.DataNodes,
+		Size:            info.Size,
+		ParentID:        info.ParentID,
+		Labels:          info.Labels,
+		Shared:          info.Shared,
+		VolumeSourceRef: info.VolumeSourceRef,
+		Driver:          info.Driver,
+	}
+}
+
+func toVolumeInfos(infos []*storkvolume.Info) []*VolumeInfo {
+	if infos == nil {
+		return nil
+	}
+	volumes := make([]*VolumeInfo, 0, len(infos))
+	for _, info := range infos {
+		volumes = append(volumes, toVolumeInfo(info))
+	}
+	return volumes
+}
+
+func fromVolumeInfos(infos []*VolumeInfo) []*storkvolume.Info {
+	if infos == nil {
+		return nil
+	}
+	volumes := make([]*storkvolume.Info, 0, len(infos))
+	for _, info := range infos {
+		volumes = append(volumes, fromVolumeInfo(info))
+	}
+	return volumes
+}
+
+// toNodeInfos converts the node info from a driver to the message sent by the
+// plugin
+func toNodeInfos(infos []*storkvolume.NodeInfo) []*NodeInfo {
+	if infos == nil {
+		return nil
+	}
+	nodes := make([]*NodeInfo, 0, len(infos))
+	for _, info := range infos {
+		if info == nil {
+			nodes = append(nodes, nil)
+			continue
+		}
+		node := &NodeInfo{
+			StorageID:     info.StorageID,
+			SchedulerID:   info.SchedulerID,
+			Hostname:      info.Hostname,
+			IPs:           info.IPs,
+			Rack:          info.Rack,
+			Zone:          info.Zone,
+			Region:        info.Region,
+			Status:        string(info.Status),
+			TotalCapacity: info.TotalCapacity,
+			FreeCapacity:  info.FreeCapacity,
+			Driver:        info.Driver,
+		}
+		for _, pool := range info.Pools {
+			if pool != nil {
+				node.Pools = append(node.Pools, &StoragePoolInfo{
+					ID:            pool.ID,
+					TotalCapacity: pool.TotalCapacity,
+					FreeCapacity:  pool.FreeCapacity,
+				})
+			}
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+// fromNodeInfos converts the node info in a message to the node info returned
+// by the drivers
+func fromNodeInfos(infos []*NodeInfo) []*storkvolume.NodeInfo {
+	if infos == nil {
+		return nil
+	}
+	nodes := make([]*storkvolume.NodeInfo, 0, len(infos))
+	for _, info := range infos {
+		if info == nil {
+			nodes = append(nodes, nil)
+			continue
+		}
+		node := &storkvolume.NodeInfo{
+			StorageID:     info.StorageID,
+			SchedulerID:   info.SchedulerID,
+			Hostname:      info.Hostname,
+			IPs:           info.IPs,
+			Rack:          info.Rack,
+			Zone:          info.Zone,
+			Region:        info.Region,
+			Status:        storkvolume.NodeStatus(info.Status),
+			TotalCapacity: info.TotalCapacity,
+			FreeCapacity:  info.FreeCapacity,
+			Driver:        info.Driver,
+		}
+		for _, pool := range info.Pools {
+			if pool != nil {
+				node.Pools = append(node.Pools, &storkvolume.StoragePoolInfo{
+					ID:            pool.ID,
+					TotalCapacity: pool.TotalCapacity,
+					FreeCapacity:  pool.FreeCapacity,
+				})
+			}
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+// jsonCodec encodes the messages for the driver plugin service as json
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (jsonCodec) Name() string {
+	return codecName
+}
+
+// toStatus converts an error from a driver to the status returned by the
+// plugin
+func toStatus(err error) error {
+	switch e := err.(type) {
+	case *errors.ErrNotSupported, *errors.ErrNotImplemented:
+		return status.Error(codes.Unimplemented, err.Error())
+	case *storkvolume.ErrPVCPending:
+		return status.Error(codes.FailedPrecondition, e.Name)
+	}
+	return status.Error(codes.Unknown, err.Error())
+}
+
+// fromStatus converts the status returned by the plugin for a method to the
+// errors returned by the drivers
+func fromStatus(method string, err error) error {
+	s, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	switch s.Code() {
+	case codes.Unimplemented:
+		return &errors.ErrNotSupported{
+			Feature: method,
+			Reason:  s.Message(),
+		}
+	case codes.FailedPrecondition:
+		return &storkvolume.ErrPVCPending{
+			Name: s.Message(),
+		}
+	case codes.Unknown:
+		return fmt.Errorf("%v", s.Message())
+	}
+	return fmt.Errorf("error calling %v on driver plugin: %v", method, err)
+}
+
+func init() {
+	encoding.RegisterCodec(jsonCodec{})
+}
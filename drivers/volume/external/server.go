@@ -0,0 +1,297 @@
+package external
+
+import (
+	"context"
+	"net"
+	"os"
+
+	storkvolume "github.com/libopenstorage/stork/drivers/volume"
+	"google.golang.org/grpc"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// method is a method of the driver plugin service along with the function to
+// call it on a driver
+type method struct {
+	name       string
+	newRequest func() interface{}
+	call       func(d storkvolume.Driver, request interface{}) (interface{}, error)
+}
+
+var methods = []method{
+	{
+		name:       methodCapabilities,
+		newRequest: func() interface{} { return &Empty{} },
+		call: func(d storkvolume.Driver, _ interface{}) (interface{}, error) {
+			return &CapabilitiesResponse{Capabilities: d.Capabilities()}, nil
+		},
+	},
+	{
+		name:       methodInspectVolume,
+		newRequest: func() interface{} { return &VolumeIDRequest{} },
+		call: func(d storkvolume.Driver, request interface{}) (interface{}, error) {
+			volume, err := d.InspectVolume(request.(*VolumeIDRequest).VolumeID)
+			return &VolumeResponse{Volume: toVolumeInfo(volume)}, err
+		},
+	},
+	{
+		name:       methodGetNodes,
+		newRequest: func() interface{} { return &Empty{} },
+		call: func(d storkvolume.Driver, _ interface{}) (interface{}, error) {
+			nodes, err := d.GetNodes()
+			return &NodesResponse{Nodes: toNodeInfos(nodes)}, err
+		},
+	},
+	{
+		name:       methodGetPodVolumes,
+		newRequest: func() interface{} { return &PodVolumesRequest{} },
+		call: func(d storkvolume.Driver, request interface{}) (interface{}, error) {
+			r := request.(*PodVolumesRequest)
+			volumes, err := d.GetPodVolumes(r.PodSpec, r.Namespace)
+			return &VolumesResponse{Volumes: toVolumeInfos(volumes)}, err
+		},
+	},
+	{
+		name:       methodGetVolumeClaimTemplates,
+		newRequest: func() interface{} { return &ClaimTemplatesMessage{} },
+		call: func(d storkvolume.Driver, request interface{}) (interface{}, error) {
+			templates, err := d.GetVolumeClaimTemplates(request.(*ClaimTemplatesMessage).Templates)
+			return &ClaimTemplatesMessage{Templates: templates}, err
+		},
+	},
+	{
+		name:       methodOwnsPVC,
+		newRequest: func() interface{} { return &PVCRequest{} },
+		call: func(d storkvolume.Driver, request interface{}) (interface{}, error) {
+			return &OwnsPVCResponse{Owns: d.OwnsPVC(request.(*PVCRequest).PVC)}, nil
+		},
+	},
+	{
+		name:       methodGetSnapshotType,
+		newRequest: func() interface{} { return &SnapshotRequest{} },
+		call: func(d storkvolume.Driver, request interface{}) (interface{}, error) {
+			snapType, err := d.GetSnapshotType(request.(*SnapshotRequest).Snapshot)
+			return &SnapshotTypeResponse{Type: snapType}, err
+		},
+	},
+	{
+		name:       methodGetClusterID,
+		newRequest: func() interface{} { return &Empty{} },
+		call: func(d storkvolume.Driver, _ interface{}) (interface{}, error) {
+			clusterID, err := d.GetClusterID()
+			return &ClusterIDResponse{ClusterID: clusterID}, err
+		},
+	},
+	{
+		name:       methodCreateGroupSnapshot,
+		newRequest: func() interface{} { return &GroupSnapshotRequest{} },
+		call: func(d storkvolume.Driver, request interface{}) (interface{}, error) {
+			response, err := d.CreateGroupSnapshot(request.(*GroupSnapshotRequest).GroupSnapshot)
+			return toGroupSnapshotResponse(response), err
+		},
+	},
+	{
+		name:       methodGetGroupSnapshotStatus,
+		newRequest: func() interface{} { return &GroupSnapshotRequest{} },
+		call: func(d storkvolume.Driver, request interface{}) (interface{}, error) {
+			response, err := d.GetGroupSnapshotStatus(request.(*GroupSnapshotRequest).GroupSnapshot)
+			return toGroupSnapshotResponse(response), err
+		},
+	},
+	{
+		name:       methodDeleteGroupSnapshot,
+		newRequest: func() interface{} { return &GroupSnapshotRequest{} },
+		call: func(d storkvolume.Driver, request interface{}) (interface{}, error) {
+			return &Empty{}, d.DeleteGroupSnapshot(request.(*GroupSnapshotRequest).GroupSnapshot)
+		},
+	},
+	{
+		name:       methodCreatePair,
+		newRequest: func() interface{} { return &ClusterPairRequest{} },
+		call: func(d storkvolume.Driver, request interface{}) (interface{}, error) {
+			remoteID, err := d.CreatePair(request.(*ClusterPairRequest).ClusterPair)
+			return &CreatePairResponse{RemoteID: remoteID}, err
+		},
+	},
+	{
+		name:       methodDeletePair,
+		newRequest: func() interface{} { return &ClusterPairRequest{} },
+		call: func(d storkvolume.Driver, request interface{}) (interface{}, error) {
+			return &Empty{}, d.DeletePair(request.(*ClusterPairRequest).ClusterPair)
+		},
+	},
+	{
+		name:       methodStartMigration,
+		newRequest: func() interface{} { return &MigrationRequest{} },
+		call: func(d storkvolume.Driver, request interface{}) (interface{}, error) {
+			volumes, err := d.StartMigration(request.(*MigrationRequest).Migration)
+			return &MigrationVolumesResponse{Volumes: volumes}, err
+		},
+	},
+	{
+		name:       methodGetMigrationStatus,
+		newRequest: func() interface{} { return &MigrationRequest{} },
+		call: func(d storkvolume.Driver, request interface{}) (interface{}, error) {
+			volumes, err := d.GetMigrationStatus(request.(*MigrationRequest).Migration)
+			return &MigrationVolumesResponse{Volumes: volumes}, err
+		},
+	},
+	{
+		name:       methodCancelMigration,
+		newRequest: func() interface{} { return &MigrationRequest{} },
+		call: func(d storkvolume.Driver, request interface{}) (interface{}, error) {
+			return &Empty{}, d.CancelMigration(request.(*MigrationRequest).Migration)
+		},
+	},
+	{
+		name:       methodUpdateMigratedPersistentVolumeSpec,
+		newRequest: func() interface{} { return &ObjectMessage{} },
+		call: func(d storkvolume.Driver, request interface{}) (interface{}, error) {
+			object, err := d.UpdateMigratedPersistentVolumeSpec(
+				&unstructured.Unstructured{Object: request.(*ObjectMessage).Object})
+			if err != nil {
+				return nil, err
+			}
+			return &ObjectMessage{Object: object.UnstructuredContent()}, nil
+		},
+	},
+	{
+		name:       methodGetClusterDomains,
+		newRequest: func() interface{} { return &Empty{} },
+		call: func(d storkvolume.Driver, _ interface{}) (interface{}, error) {
+			clusterDomains, err := d.GetClusterDomains()
+			return &ClusterDomainsResponse{ClusterDomains: clusterDomains}, err
+		},
+	},
+	{
+		name:       methodActivateClusterDomain,
+		newRequest: func() interface{} { return &ClusterDomainUpdateRequest{} },
+		call: func(d storkvolume.Driver, request interface{}) (interface{}, error) {
+			return &Empty{}, d.ActivateClusterDomain(request.(*ClusterDomainUpdateRequest).ClusterDomainUpdate)
+		},
+	},
+	{
+		name:       methodDeactivateClusterDomain,
+		newRequest: func() interface{} { return &ClusterDomainUpdateRequest{} },
+		call: func(d storkvolume.Driver, request interface{}) (interface{}, error) {
+			return &Empty{}, d.DeactivateClusterDomain(request.(*ClusterDomainUpdateRequest).ClusterDomainUpdate)
+		},
+	},
+	{
+		name:       methodStartVolumeSnapshotRestore,
+		newRequest: func() interface{} { return &SnapshotRestoreMessage{} },
+		call: func(d storkvolume.Driver, request interface{}) (interface{}, error) {
+			r := request.(*SnapshotRestoreMessage)
+			return r, d.StartVolumeSnapshotRestore(r.Restore)
+		},
+	},
+	{
+		name:       methodGetVolumeSnapshotRestoreStatus,
+		newRequest: func() interface{} { return &SnapshotRestoreMessage{} },
+		call: func(d storkvolume.Driver, request interface{}) (interface{}, error) {
+			r := request.(*SnapshotRestoreMessage)
+			return r, d.GetVolumeSnapshotRestoreStatus(r.Restore)
+		},
+	},
+	{
+		name:       methodGetSharedVolumeCoordinator,
+		newRequest: func() interface{} { return &VolumeRequest{} },
+		call: func(d storkvolume.Driver, request interface{}) (interface{}, error) {
+			storageID, err := d.GetSharedVolumeCoordinator(fromVolumeInfo(request.(*VolumeRequest).Volume))
+			return &NodeIDResponse{StorageID: storageID}, err
+		},
+	},
+	{
+		name:       methodGetVolumeAttachedNode,
+		newRequest: func() interface{} { return &VolumeRequest{} },
+		call: func(d storkvolume.Driver, request interface{}) (interface{}, error) {
+			storageID, err := d.GetVolumeAttachedNode(fromVolumeInfo(request.(*VolumeRequest).Volume))
+			return &NodeIDResponse{StorageID: storageID}, err
+		},
+	},
+	{
+		name:       methodForceDetachVolume,
+		newRequest: func() interface{} { return &VolumeRequest{} },
+		call: func(d storkvolume.Driver, request interface{}) (interface{}, error) {
+			return &Empty{}, d.ForceDetachVolume(fromVolumeInfo(request.(*VolumeRequest).Volume))
+		},
+	},
+}
+
+// toGroupSnapshotResponse converts the group snapshot response from a driver
+// to the message sent by the plugin
+func toGroupSnapshotResponse(response *storkvolume.GroupSnapshotCreateResponse) *GroupSnapshotResponse {
+	if response == nil {
+		return &GroupSnapshotResponse{}
+	}
+	return &GroupSnapshotResponse{Snapshots: response.Snapshots}
+}
+
+// handler returns the gRPC handler that decodes the request for the method
+// and calls it on the driver
+func (m method) handler() func(interface{}, context.Context, func(interface{}) error, grpc.UnaryServerInterceptor) (interface{}, error) {
+	return func(
+		srv interface{},
+		ctx context.Context,
+		dec func(interface{}) error,
+		interceptor grpc.UnaryServerInterceptor,
+	) (interface{}, error) {
+		request := m.newRequest()
+		if err := dec(request); err != nil {
+			return nil, err
+		}
+		handle := func(_ context.Context, request interface{}) (interface{}, error) {
+			response, err := m.call(srv.(storkvolume.Driver), request)
+			if err != nil {
+				return nil, toStatus(err)
+			}
+			return response, nil
+		}
+		if interceptor == nil {
+			return handle(ctx, request)
+		}
+		info := &grpc.UnaryServerInfo{
+			Server:     srv,
+			FullMethod: "/" + ServiceName + "/" + m.name,
+		}
+		return interceptor(ctx, request, info, handle)
+	}
+}
+
+// serviceDesc returns the description of the driver plugin service
+func serviceDesc() *grpc.ServiceDesc {
+	desc := &grpc.ServiceDesc{
+		ServiceName: ServiceName,
+		HandlerType: (*storkvolume.Driver)(nil),
+		Streams:     []grpc.StreamDesc{},
+	}
+	for _, m := range methods {
+		desc.Methods = append(desc.Methods, grpc.MethodDesc{
+			MethodName: m.name,
+			Handler:    m.handler(),
+		})
+	}
+	return desc
+}
+
+// RegisterServer registers the driver plugin service for the driver with the
+// gRPC server. Used by out-of-tree drivers written in Go to serve their
+// implementation of the volume driver interface.
+func RegisterServer(server *grpc.Server, d storkvolume.Driver) {
+	server.RegisterService(serviceDesc(), d)
+}
+
+// Serve serves the driver plugin service for the driver on the unix socket
+// until the listener is closed. An existing socket at the path is removed.
+func Serve(socket string, d storkvolume.Driver) error {
+	if err := os.Remove(socket); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		return err
+	}
+	server := grpc.NewServer()
+	RegisterServer(server, d)
+	return server.Serve(listener)
+}
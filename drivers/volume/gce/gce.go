@@ -0,0 +1,331 @@
+package gce
+
+import (
+	"sort"
+	"strings"
+
+	snapv1 "github.com/kubernetes-incubator/external-storage/snapshot/pkg/apis/crd/v1"
+	snapshotVolume "github.com/kubernetes-incubator/external-storage/snapshot/pkg/volume"
+	storkvolume "github.com/libopenstorage/stork/drivers/volume"
+	"github.com/libopenstorage/stork/pkg/errors"
+	"github.com/portworx/sched-ops/k8s"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	k8shelper "k8s.io/kubernetes/pkg/apis/core/v1/helper"
+	kubeletapis "k8s.io/kubernetes/pkg/kubelet/apis"
+)
+
+const (
+	// driverName is the name of the GCE PD driver
+	driverName = "gce"
+	// provisionerName is the name of the in-tree GCE PD provisioner
+	provisionerName = "kubernetes.io/gce-pd"
+	// csiDriverName is the name of the GCE PD CSI driver
+	csiDriverName = "pd.csi.storage.gke.io"
+	// pvcProvisionerAnnotation is the annotation on PVCs with the provisioner
+	// name
+	pvcProvisionerAnnotation = "volume.beta.kubernetes.io/storage-provisioner"
+
+	// gkeZoneLabel is the topology key used by the GCE PD CSI driver for the
+	// zones of nodes and volumes
+	gkeZoneLabel = "topology.gke.io/zone"
+	// topologyZoneLabel is the GA zone label for nodes and volumes
+	topologyZoneLabel = "topology.kubernetes.io/zone"
+	// zonesSeparator separates the replica zones of regional PDs in the zone
+	// label of in-tree PVs
+	zonesSeparator = "__"
+	// regionsPathElement is in the volume handle of regional PDs from the CSI
+	// driver, which is projects/<project>/regions/<region>/disks/<name>
+	regionsPathElement = "regions"
+)
+
+// gce is the driver for GCE persistent disks provisioned by the in-tree
+// provisioner or the GCE PD CSI driver. A PD can be attached to any node in
+// the zones where it has replicas, so the data nodes of a volume are the
+// nodes in its replica zones. Regional PDs are replicated to two zones of a
+// region. The storage IDs of the nodes are the node names.
+type gce struct {
+	storkvolume.ClusterPairNotSupported
+	storkvolume.MigrationNotSupported
+	storkvolume.GroupSnapshotNotSupported
+	storkvolume.ClusterDomainsNotSupported
+	storkvolume.SnapshotRestoreNotSupported
+	storkvolume.SharedVolumeNotSupported
+	storkvolume.AttachmentNotSupported
+	storkvolume.RestoreProvisionNotSupported
+}
+
+func (g *gce) String() string {
+	return driverName
+}
+
+func (g *gce) Init(_ interface{}) error {
+	return nil
+}
+
+func (g *gce) Stop() error {
+	return nil
+}
+
+// Capabilities returns no capabilities since PD snapshots need the GCE
+// compute API
+func (g *gce) Capabilities() map[storkvolume.Capability]bool {
+	return map[storkvolume.Capability]bool{}
+}
+
+func isGCEProvisioner(name string) bool {
+	return name == provisionerName || name == csiDriverName
+}
+
+func isGCEPV(pv *v1.PersistentVolume) bool {
+	return pv.Spec.GCEPersistentDisk != nil ||
+		(pv.Spec.CSI != nil && pv.Spec.CSI.Driver == csiDriverName)
+}
+
+// getNodeZone returns the zone of the node from the zone labels
+func getNodeZone(node *v1.Node) string {
+	for _, label := range []string{kubeletapis.LabelZoneFailureDomain, topologyZoneLabel, gkeZoneLabel} {
+		if zone := node.Labels[label]; zone != "" {
+			return zone
+		}
+	}
+	return ""
+}
+
+// getReplicaZones returns the zones where the PD has replicas, from the zone
+// label and the node affinity of the PV. The zone label of in-tree regional
+// PDs has both zones separated by "__".
+func getReplicaZones(pv *v1.PersistentVolume) []string {
+	zones := make(map[string]bool)
+	for _, label := range []string{kubeletapis.LabelZoneFailureDomain, topologyZoneLabel} {
+		if value := pv.Labels[label]; value != "" {
+			for _, zone := range strings.Split(value, zonesSeparator) {
+				zones[zone] = true
+			}
+		}
+	}
+	if pv.Spec.NodeAffinity != nil && pv.Spec.NodeAffinity.Required != nil {
+		for _, term := range pv.Spec.NodeAffinity.Required.NodeSelectorTerms {
+			for _, expression := range term.MatchExpressions {
+				switch expression.Key {
+				case kubeletapis.LabelZoneFailureDomain, topologyZoneLabel, gkeZoneLabel:
+				default:
+					continue
+				}
+				if expression.Operator != v1.NodeSelectorOpIn {
+					continue
+				}
+				for _, zone := range expression.Values {
+					zones[zone] = true
+				}
+			}
+		}
+	}
+
+	replicaZones := make([]string, 0, len(zones))
+	for zone := range zones {
+		replicaZones = append(replicaZones, zone)
+	}
+	sort.Strings(replicaZones)
+	return replicaZones
+}
+
+// getDiskName returns the name of the PD for the PV
+func getDiskName(pv *v1.PersistentVolume) string {
+	if pv.Spec.GCEPersistentDisk != nil {
+		return pv.Spec.GCEPersistentDisk.PDName
+	}
+	parts := strings.Split(pv.Spec.CSI.VolumeHandle, "/")
+	return parts[len(parts)-1]
+}
+
+// isRegional returns true if the PD is a regional PD, from the volume handle
+// for the CSI driver or from the number of replica zones
+func isRegional(pv *v1.PersistentVolume, zones []string) bool {
+	if pv.Spec.CSI != nil {
+		parts := strings.Split(pv.Spec.CSI.VolumeHandle, "/")
+		if len(parts) == 6 {
+			return parts[2] == regionsPathElement
+		}
+	}
+	return len(zones) > 1
+}
+
+// InspectVolume returns the info for the PD with the volumeID as the name of
+// the PV. The data nodes are the nodes in the replica zones of the PD, so
+// pods using regional PDs are placed in either of the two zones.
+func (g *gce) InspectVolume(volumeID string) (*storkvolume.Info, error) {
+	pv, err := k8s.Instance().GetPersistentVolume(volumeID)
+	if err != nil {
+		return nil, err
+	}
+	if !isGCEPV(pv) {
+		return nil, &errors.ErrNotFound{
+			ID:   volumeID,
+			Type: "Volume",
+		}
+	}
+
+	info := &storkvolume.Info{
+		VolumeID:        pv.Name,
+		VolumeName:      getDiskName(pv),
+		Labels:          pv.Labels,
+		VolumeSourceRef: pv,
+	}
+	if storage, ok := pv.Spec.Capacity[v1.ResourceStorage]; ok {
+		info.Size = uint64(storage.Value()) / (1024 * 1024 * 1024)
+	}
+
+	zones := getReplicaZones(pv)
+	if isRegional(pv, zones) && len(zones) != 2 {
+		logrus.Warnf("Expected 2 replica zones for regional PD %v, found %v", info.VolumeName, zones)
+	}
+	replicaZones := make(map[string]bool)
+	for _, zone := range zones {
+		replicaZones[zone] = true
+	}
+	nodes, err := k8s.Instance().GetNodes()
+	if err != nil {
+		return nil, err
+	}
+	for _, node := range nodes.Items {
+		if replicaZones[getNodeZone(&node)] {
+			info.DataNodes = append(info.DataNodes, node.Name)
+		}
+	}
+	return info, nil
+}
+
+// GetNodes returns the Kubernetes nodes. Nodes are online if they are ready,
+// since PDs can be attached to any node in their replica zones.
+func (g *gce) GetNodes() ([]*storkvolume.NodeInfo, error) {
+	nodes, err := k8s.Instance().GetNodes()
+	if err != nil {
+		return nil, err
+	}
+
+	var nodeInfos []*storkvolume.NodeInfo
+	for _, node := range nodes.Items {
+		nodeInfo := &storkvolume.NodeInfo{
+			StorageID:   node.Name,
+			SchedulerID: node.Name,
+			Hostname:    strings.ToLower(node.Name),
+			Zone:        getNodeZone(&node),
+			Region:      node.Labels[kubeletapis.LabelZoneRegion],
+			Status:      storkvolume.NodeOffline,
+		}
+		for _, address := range node.Status.Addresses {
+			switch address.Type {
+			case v1.NodeHostName:
+				nodeInfo.Hostname = strings.ToLower(address.Address)
+			case v1.NodeInternalIP:
+				nodeInfo.IPs = append(nodeInfo.IPs, address.Address)
+			}
+		}
+		if isNodeReady(&node) {
+			nodeInfo.Status = storkvolume.NodeOnline
+		}
+		nodeInfos = append(nodeInfos, nodeInfo)
+	}
+	return nodeInfos, nil
+}
+
+func isNodeReady(node *v1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == v1.NodeReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// OwnsPVC returns true if the PVC is provisioned by the in-tree GCE PD
+// provisioner or the GCE PD CSI driver
+func (g *gce) OwnsPVC(pvc *v1.PersistentVolumeClaim) bool {
+	if pvc.Spec.VolumeName == "" {
+		provisioner := pvc.Annotations[pvcProvisionerAnnotation]
+		if provisioner == "" {
+			storageClassName := k8shelper.GetPersistentVolumeClaimClass(pvc)
+			if storageClassName == "" {
+				return false
+			}
+			storageClass, err := k8s.Instance().GetStorageClass(storageClassName)
+			if err != nil {
+				logrus.Warnf("Error getting storageclass %v for pvc %v: %v", storageClassName, pvc.Name, err)
+				return false
+			}
+			provisioner = storageClass.Provisioner
+		}
+		return isGCEProvisioner(provisioner)
+	}
+
+	pv, err := k8s.Instance().GetPersistentVolume(pvc.Spec.VolumeName)
+	if err != nil {
+		logrus.Warnf("Error getting pv %v for pvc %v: %v", pvc.Spec.VolumeName, pvc.Name, err)
+		return false
+	}
+	return isGCEPV(pv)
+}
+
+func (g *gce) GetPodVolumes(podSpec *v1.PodSpec, namespace string) ([]*storkvolume.Info, error) {
+	var volumes []*storkvolume.Info
+	for _, volume := range podSpec.Volumes {
+		if volume.PersistentVolumeClaim == nil {
+			continue
+		}
+		pvc, err := k8s.Instance().GetPersistentVolumeClaim(
+			volume.PersistentVolumeClaim.ClaimName,
+			namespace)
+		if err != nil {
+			return nil, err
+		}
+
+		if !g.OwnsPVC(pvc) {
+			continue
+		}
+
+		if pvc.Status.Phase == v1.ClaimPending {
+			return nil, &storkvolume.ErrPVCPending{
+				Name: volume.PersistentVolumeClaim.ClaimName,
+			}
+		}
+
+		volumeInfo, err := g.InspectVolume(pvc.Spec.VolumeName)
+		if err != nil {
+			return nil, err
+		}
+		volumes = append(volumes, volumeInfo)
+	}
+	return volumes, nil
+}
+
+func (g *gce) GetVolumeClaimTemplates(templates []v1.PersistentVolumeClaim) (
+	[]v1.PersistentVolumeClaim, error) {
+	var gceTemplates []v1.PersistentVolumeClaim
+	for _, t := range templates {
+		if g.OwnsPVC(&t) {
+			gceTemplates = append(gceTemplates, t)
+		}
+	}
+	return gceTemplates, nil
+}
+
+// GetSnapshotPlugin returns nil since the snapshotter in this tree has no
+// data source for PD snapshots
+func (g *gce) GetSnapshotPlugin() snapshotVolume.Plugin {
+	return nil
+}
+
+func (g *gce) GetSnapshotType(snap *snapv1.VolumeSnapshot) (string, error) {
+	return "", &errors.ErrNotSupported{}
+}
+
+func (g *gce) GetClusterID() (string, error) {
+	return "", &errors.ErrNotSupported{}
+}
+
+func init() {
+	if err := storkvolume.Register(driverName, &gce{}); err != nil {
+		logrus.Panicf("Error registering gce volume driver: %v", err)
+	}
+}
@@ -0,0 +1,222 @@
+// +build unittest
+
+package gce
+
+import (
+	"testing"
+
+	storkvolume "github.com/libopenstorage/stork/drivers/volume"
+	"github.com/portworx/sched-ops/k8s"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakekube "k8s.io/client-go/kubernetes/fake"
+	kubeletapis "k8s.io/kubernetes/pkg/kubelet/apis"
+)
+
+func newTestNode(name string, zoneLabel string, zone string, ready bool) *v1.Node {
+	status := v1.ConditionFalse
+	if ready {
+		status = v1.ConditionTrue
+	}
+	return &v1.Node{
+		ObjectMeta: meta.ObjectMeta{
+			Name: name,
+			Labels: map[string]string{
+				zoneLabel:                   zone,
+				kubeletapis.LabelZoneRegion: "us-central1",
+			},
+		},
+		Status: v1.NodeStatus{
+			Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: status}},
+			Addresses: []v1.NodeAddress{
+				{Type: v1.NodeHostName, Address: name},
+				{Type: v1.NodeInternalIP, Address: "10.0.0.1"},
+			},
+		},
+	}
+}
+
+// newInTreePV returns a PV for a PD from the in-tree provisioner with the
+// zones in the zone label
+func newInTreePV(name string, zones string) *v1.PersistentVolume {
+	return &v1.PersistentVolume{
+		ObjectMeta: meta.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{kubeletapis.LabelZoneFailureDomain: zones},
+		},
+		Spec: v1.PersistentVolumeSpec{
+			Capacity: v1.ResourceList{v1.ResourceStorage: resource.MustParse("20Gi")},
+			PersistentVolumeSource: v1.PersistentVolumeSource{
+				GCEPersistentDisk: &v1.GCEPersistentDiskVolumeSource{PDName: "disk-" + name},
+			},
+		},
+	}
+}
+
+// newCSIPV returns a PV for a PD from the CSI driver with the zones in the
+// node affinity
+func newCSIPV(name string, driver string, handle string, zones ...string) *v1.PersistentVolume {
+	return &v1.PersistentVolume{
+		ObjectMeta: meta.ObjectMeta{Name: name},
+		Spec: v1.PersistentVolumeSpec{
+			PersistentVolumeSource: v1.PersistentVolumeSource{
+				CSI: &v1.CSIPersistentVolumeSource{
+					Driver:       driver,
+					VolumeHandle: handle,
+				},
+			},
+			NodeAffinity: &v1.VolumeNodeAffinity{
+				Required: &v1.NodeSelector{
+					NodeSelectorTerms: []v1.NodeSelectorTerm{{
+						MatchExpressions: []v1.NodeSelectorRequirement{{
+							Key:      gkeZoneLabel,
+							Operator: v1.NodeSelectorOpIn,
+							Values:   zones,
+						}},
+					}},
+				},
+			},
+		},
+	}
+}
+
+func newTestPVC(name string, volumeName string, storageClass string) *v1.PersistentVolumeClaim {
+	phase := v1.ClaimBound
+	if volumeName == "" {
+		phase = v1.ClaimPending
+	}
+	return &v1.PersistentVolumeClaim{
+		ObjectMeta: meta.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: v1.PersistentVolumeClaimSpec{
+			VolumeName:       volumeName,
+			StorageClassName: &storageClass,
+		},
+		Status: v1.PersistentVolumeClaimStatus{Phase: phase},
+	}
+}
+
+func setupTestDriver(t *testing.T) *gce {
+	client := fakekube.NewSimpleClientset(
+		newTestNode("node1", kubeletapis.LabelZoneFailureDomain, "us-central1-a", true),
+		newTestNode("node2", gkeZoneLabel, "us-central1-b", true),
+		newTestNode("node3", kubeletapis.LabelZoneFailureDomain, "us-central1-c", false),
+		newInTreePV("zonal", "us-central1-a"),
+		newInTreePV("regional", "us-central1-a__us-central1-c"),
+		newCSIPV("csi-regional", csiDriverName,
+			"projects/project1/regions/us-central1/disks/pvc-1", "us-central1-b", "us-central1-c"),
+		newCSIPV("other", "other.csi.com", "vol1", "us-central1-a"),
+		newTestPVC("zonal", "zonal", "standard"),
+		newTestPVC("regional", "regional", "standard"),
+		newTestPVC("csi-regional", "csi-regional", "regional-pd"),
+		newTestPVC("other", "other", "other-sc"),
+		newTestPVC("pending", "", "regional-pd"),
+		&storagev1.StorageClass{
+			ObjectMeta:  meta.ObjectMeta{Name: "regional-pd"},
+			Provisioner: csiDriverName,
+		},
+		&storagev1.StorageClass{
+			ObjectMeta:  meta.ObjectMeta{Name: "other-sc"},
+			Provisioner: "other.csi.com",
+		},
+	)
+	k8s.Instance().SetClient(client, nil, nil, nil, nil, nil)
+
+	d := &gce{}
+	require.NoError(t, d.Init(nil), "Error initializing driver")
+	return d
+}
+
+func TestGCEGetNodes(t *testing.T) {
+	d := setupTestDriver(t)
+
+	nodes, err := d.GetNodes()
+	require.NoError(t, err, "Error getting nodes")
+	require.Len(t, nodes, 3)
+	require.Equal(t, &storkvolume.NodeInfo{
+		StorageID:   "node1",
+		SchedulerID: "node1",
+		Hostname:    "node1",
+		IPs:         []string{"10.0.0.1"},
+		Zone:        "us-central1-a",
+		Region:      "us-central1",
+		Status:      storkvolume.NodeOnline,
+	}, nodes[0])
+	// The zone is read from the label used by the CSI driver too
+	require.Equal(t, "us-central1-b", nodes[1].Zone)
+	require.Equal(t, storkvolume.NodeOffline, nodes[2].Status)
+}
+
+func TestGCEInspectVolume(t *testing.T) {
+	d := setupTestDriver(t)
+
+	info, err := d.InspectVolume("zonal")
+	require.NoError(t, err, "Error inspecting volume")
+	require.Equal(t, "disk-zonal", info.VolumeName)
+	require.Equal(t, []string{"node1"}, info.DataNodes)
+	require.Equal(t, uint64(20), info.Size)
+
+	// Both replica zones of regional PDs have the data
+	info, err = d.InspectVolume("regional")
+	require.NoError(t, err, "Error inspecting volume")
+	require.Equal(t, []string{"node1", "node3"}, info.DataNodes)
+
+	info, err = d.InspectVolume("csi-regional")
+	require.NoError(t, err, "Error inspecting volume")
+	require.Equal(t, "pvc-1", info.VolumeName)
+	require.Equal(t, []string{"node2", "node3"}, info.DataNodes)
+
+	_, err = d.InspectVolume("other")
+	require.Error(t, err)
+	require.Equal(t, "Volume with UID/Name: other not found", err.Error())
+}
+
+func TestGCERegional(t *testing.T) {
+	zonal := newInTreePV("zonal", "us-central1-a")
+	require.False(t, isRegional(zonal, getReplicaZones(zonal)))
+	regional := newInTreePV("regional", "us-central1-a__us-central1-c")
+	require.Equal(t, []string{"us-central1-a", "us-central1-c"}, getReplicaZones(regional))
+	require.True(t, isRegional(regional, getReplicaZones(regional)))
+
+	csiZonal := newCSIPV("csi-zonal", csiDriverName, "projects/project1/zones/us-central1-a/disks/pvc-2", "us-central1-a")
+	require.False(t, isRegional(csiZonal, getReplicaZones(csiZonal)))
+	csiRegional := newCSIPV("csi-regional", csiDriverName,
+		"projects/project1/regions/us-central1/disks/pvc-1", "us-central1-b", "us-central1-c")
+	require.True(t, isRegional(csiRegional, getReplicaZones(csiRegional)))
+}
+
+func TestGCEGetPodVolumes(t *testing.T) {
+	d := setupTestDriver(t)
+
+	podSpec := &v1.PodSpec{}
+	for _, claim := range []string{"regional", "csi-regional", "other"} {
+		podSpec.Volumes = append(podSpec.Volumes, v1.Volume{
+			VolumeSource: v1.VolumeSource{
+				PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: claim},
+			},
+		})
+	}
+	volumes, err := d.GetPodVolumes(podSpec, "default")
+	require.NoError(t, err, "Error getting pod volumes")
+	require.Len(t, volumes, 2)
+	require.Equal(t, "regional", volumes[0].VolumeID)
+	require.Equal(t, "csi-regional", volumes[1].VolumeID)
+
+	podSpec.Volumes = append(podSpec.Volumes, v1.Volume{
+		VolumeSource: v1.VolumeSource{
+			PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: "pending"},
+		},
+	})
+	_, err = d.GetPodVolumes(podSpec, "default")
+	require.Equal(t, &storkvolume.ErrPVCPending{Name: "pending"}, err)
+
+	templates, err := d.GetVolumeClaimTemplates([]v1.PersistentVolumeClaim{
+		*newTestPVC("template1", "", "regional-pd"),
+		*newTestPVC("template2", "", "other-sc"),
+	})
+	require.NoError(t, err, "Error getting volume claim templates")
+	require.Len(t, templates, 1)
+	require.Equal(t, "template1", templates[0].Name)
+}
@@ -0,0 +1,288 @@
+package hostpath
+
+import (
+	"os"
+	"strings"
+
+	snapv1 "github.com/kubernetes-incubator/external-storage/snapshot/pkg/apis/crd/v1"
+	snapshotVolume "github.com/kubernetes-incubator/external-storage/snapshot/pkg/volume"
+	storkvolume "github.com/libopenstorage/stork/drivers/volume"
+	"github.com/libopenstorage/stork/pkg/errors"
+	"github.com/portworx/sched-ops/k8s"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	k8shelper "k8s.io/kubernetes/pkg/apis/core/v1/helper"
+	kubeletapis "k8s.io/kubernetes/pkg/kubelet/apis"
+)
+
+const (
+	// driverName is the name of the hostPath driver. It needs to match the
+	// volume type used by the snapshotter for hostPath PVs so that the
+	// snapshot plugin is used for them.
+	driverName = "hostPath"
+	// localPathProvisioner is the name of the local-path provisioner, which
+	// provisions hostPath PVs
+	localPathProvisioner = "rancher.io/local-path"
+	// pvcProvisionerAnnotation is the annotation on PVCs with the provisioner
+	// name
+	pvcProvisionerAnnotation = "volume.beta.kubernetes.io/storage-provisioner"
+
+	// resticRepositoryEnv is the environment variable with the restic
+	// repository where the snapshots are stored. Snapshots are disabled if it
+	// isn't set.
+	resticRepositoryEnv = "RESTIC_REPOSITORY"
+	// resticSecretEnv is the environment variable with the name of the secret
+	// with the password and credentials for the restic repository, passed as
+	// environment variables to the data mover jobs
+	resticSecretEnv = "RESTIC_SECRET"
+	// resticImageEnv is the environment variable with the image used for the
+	// data mover jobs
+	resticImageEnv = "RESTIC_IMAGE"
+	// namespaceEnv is the environment variable with the namespace where the
+	// data mover jobs are run, along with the secret for the repository
+	namespaceEnv = "DATAMOVER_NAMESPACE"
+	// restorePathEnv is the environment variable with the directory on the
+	// nodes under which volumes are restored
+	restorePathEnv = "DATAMOVER_RESTORE_PATH"
+
+	defaultResticImage = "restic/restic:0.9.5"
+	defaultNamespace   = "kube-system"
+	defaultRestorePath = "/opt/local-path-provisioner"
+)
+
+// hostPath is the driver for hostPath PVs, including the ones provisioned by
+// the local-path provisioner. The data nodes of a volume are the nodes
+// matching the node affinity of the PV. Snapshots are taken by running restic
+// in a job on the node with the data, which moves the data to a restic
+// repository, for clusters without snapshot-capable storage.
+type hostPath struct {
+	storkvolume.ClusterPairNotSupported
+	storkvolume.MigrationNotSupported
+	storkvolume.GroupSnapshotNotSupported
+	storkvolume.ClusterDomainsNotSupported
+	storkvolume.SnapshotRestoreNotSupported
+	storkvolume.SharedVolumeNotSupported
+	storkvolume.RestoreProvisionNotSupported
+	storkvolume.AttachmentNotSupported
+	repository  string
+	secret      string
+	image       string
+	namespace   string
+	restorePath string
+}
+
+func getEnv(name string, defaultValue string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func (h *hostPath) String() string {
+	return driverName
+}
+
+func (h *hostPath) Init(_ interface{}) error {
+	h.repository = os.Getenv(resticRepositoryEnv)
+	h.secret = os.Getenv(resticSecretEnv)
+	h.image = getEnv(resticImageEnv, defaultResticImage)
+	h.namespace = getEnv(namespaceEnv, defaultNamespace)
+	h.restorePath = getEnv(restorePathEnv, defaultRestorePath)
+	if h.repository == "" {
+		logrus.Infof("Snapshots of hostPath volumes are disabled since %v isn't set", resticRepositoryEnv)
+	}
+	return nil
+}
+
+func (h *hostPath) Stop() error {
+	return nil
+}
+
+// Capabilities returns the snapshot capability if the restic repository is
+// configured
+func (h *hostPath) Capabilities() map[storkvolume.Capability]bool {
+	return map[storkvolume.Capability]bool{
+		storkvolume.CapabilitySnapshots: h.repository != "",
+	}
+}
+
+// InspectVolume returns the info for the hostPath PV with the volumeID as the
+// name
+func (h *hostPath) InspectVolume(volumeID string) (*storkvolume.Info, error) {
+	pv, err := k8s.Instance().GetPersistentVolume(volumeID)
+	if err != nil {
+		return nil, err
+	}
+	if pv.Spec.HostPath == nil {
+		return nil, &errors.ErrNotFound{
+			ID:   volumeID,
+			Type: "Volume",
+		}
+	}
+
+	info := &storkvolume.Info{
+		VolumeID:        pv.Name,
+		VolumeName:      pv.Name,
+		Labels:          pv.Labels,
+		VolumeSourceRef: pv,
+	}
+	if storage, ok := pv.Spec.Capacity[v1.ResourceStorage]; ok {
+		info.Size = uint64(storage.Value()) / (1024 * 1024 * 1024)
+	}
+	info.DataNodes, err = getDataNodes(pv)
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// getDataNodes returns the nodes matching the node affinity of the PV. The
+// data for hostPath PVs without a node affinity could be on any node.
+func getDataNodes(pv *v1.PersistentVolume) ([]string, error) {
+	if pv.Spec.NodeAffinity == nil || pv.Spec.NodeAffinity.Required == nil {
+		return nil, nil
+	}
+	nodes, err := k8s.Instance().GetNodes()
+	if err != nil {
+		return nil, err
+	}
+	var dataNodes []string
+	for _, node := range nodes.Items {
+		if k8shelper.MatchNodeSelectorTerms(
+			pv.Spec.NodeAffinity.Required.NodeSelectorTerms,
+			labels.Set(node.Labels),
+			fields.Set{"metadata.name": node.Name}) {
+			dataNodes = append(dataNodes, node.Name)
+		}
+	}
+	return dataNodes, nil
+}
+
+// GetNodes returns the nodes in the cluster. Nodes that aren't ready are
+// offline.
+func (h *hostPath) GetNodes() ([]*storkvolume.NodeInfo, error) {
+	nodes, err := k8s.Instance().GetNodes()
+	if err != nil {
+		return nil, err
+	}
+
+	var nodeInfos []*storkvolume.NodeInfo
+	for _, node := range nodes.Items {
+		nodeInfo := &storkvolume.NodeInfo{
+			StorageID:   node.Name,
+			SchedulerID: node.Name,
+			Hostname:    strings.ToLower(node.Name),
+			Zone:        node.Labels[kubeletapis.LabelZoneFailureDomain],
+			Region:      node.Labels[kubeletapis.LabelZoneRegion],
+			Status:      storkvolume.NodeOffline,
+		}
+		for _, address := range node.Status.Addresses {
+			switch address.Type {
+			case v1.NodeHostName:
+				nodeInfo.Hostname = strings.ToLower(address.Address)
+			case v1.NodeInternalIP:
+				nodeInfo.IPs = append(nodeInfo.IPs, address.Address)
+			}
+		}
+		for _, condition := range node.Status.Conditions {
+			if condition.Type == v1.NodeReady && condition.Status == v1.ConditionTrue {
+				nodeInfo.Status = storkvolume.NodeOnline
+			}
+		}
+		nodeInfos = append(nodeInfos, nodeInfo)
+	}
+	return nodeInfos, nil
+}
+
+// OwnsPVC returns true if the PVC is bound to a hostPath PV, or is pending
+// and provisioned by the local-path provisioner
+func (h *hostPath) OwnsPVC(pvc *v1.PersistentVolumeClaim) bool {
+	if pvc.Spec.VolumeName == "" {
+		provisioner := pvc.Annotations[pvcProvisionerAnnotation]
+		if provisioner == "" {
+			storageClassName := k8shelper.GetPersistentVolumeClaimClass(pvc)
+			if storageClassName == "" {
+				return false
+			}
+			storageClass, err := k8s.Instance().GetStorageClass(storageClassName)
+			if err != nil {
+				logrus.Warnf("Error getting storageclass %v for pvc %v: %v", storageClassName, pvc.Name, err)
+				return false
+			}
+			provisioner = storageClass.Provisioner
+		}
+		return provisioner == localPathProvisioner
+	}
+
+	pv, err := k8s.Instance().GetPersistentVolume(pvc.Spec.VolumeName)
+	if err != nil {
+		logrus.Warnf("Error getting pv %v for pvc %v: %v", pvc.Spec.VolumeName, pvc.Name, err)
+		return false
+	}
+	return pv.Spec.HostPath != nil
+}
+
+func (h *hostPath) GetPodVolumes(podSpec *v1.PodSpec, namespace string) ([]*storkvolume.Info, error) {
+	var volumes []*storkvolume.Info
+	for _, volume := range podSpec.Volumes {
+		if volume.PersistentVolumeClaim == nil {
+			continue
+		}
+		pvc, err := k8s.Instance().GetPersistentVolumeClaim(
+			volume.PersistentVolumeClaim.ClaimName,
+			namespace)
+		if err != nil {
+			return nil, err
+		}
+
+		if !h.OwnsPVC(pvc) {
+			continue
+		}
+
+		if pvc.Status.Phase == v1.ClaimPending {
+			return nil, &storkvolume.ErrPVCPending{
+				Name: volume.PersistentVolumeClaim.ClaimName,
+			}
+		}
+
+		volumeInfo, err := h.InspectVolume(pvc.Spec.VolumeName)
+		if err != nil {
+			return nil, err
+		}
+		volumes = append(volumes, volumeInfo)
+	}
+	return volumes, nil
+}
+
+func (h *hostPath) GetVolumeClaimTemplates(templates []v1.PersistentVolumeClaim) (
+	[]v1.PersistentVolumeClaim, error) {
+	var hostPathTemplates []v1.PersistentVolumeClaim
+	for _, t := range templates {
+		if h.OwnsPVC(&t) {
+			hostPathTemplates = append(hostPathTemplates, t)
+		}
+	}
+	return hostPathTemplates, nil
+}
+
+func (h *hostPath) GetSnapshotPlugin() snapshotVolume.Plugin {
+	return h
+}
+
+// GetSnapshotType returns restic since all snapshots are stored in the restic
+// repository
+func (h *hostPath) GetSnapshotType(snap *snapv1.VolumeSnapshot) (string, error) {
+	return "restic", nil
+}
+
+func (h *hostPath) GetClusterID() (string, error) {
+	return "", &errors.ErrNotSupported{}
+}
+
+func init() {
+	if err := storkvolume.Register(driverName, &hostPath{}); err != nil {
+		logrus.Panicf("Error registering hostPath volume driver: %v", err)
+	}
+}
@@ -0,0 +1,181 @@
+// +build unittest
+
+package hostpath
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	snapv1 "github.com/kubernetes-incubator/external-storage/snapshot/pkg/apis/crd/v1"
+	storkvolume "github.com/libopenstorage/stork/drivers/volume"
+	"github.com/portworx/sched-ops/k8s"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakekube "k8s.io/client-go/kubernetes/fake"
+	core "k8s.io/client-go/testing"
+	kubeletapis "k8s.io/kubernetes/pkg/kubelet/apis"
+)
+
+func newTestNode(name string) *v1.Node {
+	return &v1.Node{
+		ObjectMeta: meta.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{kubeletapis.LabelHostname: name},
+		},
+		Status: v1.NodeStatus{
+			Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionTrue}},
+		},
+	}
+}
+
+func newTestPV(name string, node string) *v1.PersistentVolume {
+	pv := &v1.PersistentVolume{
+		ObjectMeta: meta.ObjectMeta{Name: name},
+		Spec: v1.PersistentVolumeSpec{
+			Capacity: v1.ResourceList{v1.ResourceStorage: resource.MustParse("2Gi")},
+			PersistentVolumeSource: v1.PersistentVolumeSource{
+				HostPath: &v1.HostPathVolumeSource{Path: "/opt/local-path-provisioner/" + name},
+			},
+		},
+	}
+	if node != "" {
+		pv.Spec.NodeAffinity = &v1.VolumeNodeAffinity{
+			Required: &v1.NodeSelector{
+				NodeSelectorTerms: []v1.NodeSelectorTerm{{
+					MatchExpressions: []v1.NodeSelectorRequirement{{
+						Key:      kubeletapis.LabelHostname,
+						Operator: v1.NodeSelectorOpIn,
+						Values:   []string{node},
+					}},
+				}},
+			},
+		}
+	}
+	return pv
+}
+
+// setup creates a fake cluster where all jobs have completed successfully when
+// they are checked, and returns the driver with the jobs that were created
+func setup(t *testing.T) (*hostPath, *[]*batchv1.Job) {
+	pvc := &v1.PersistentVolumeClaim{
+		ObjectMeta: meta.ObjectMeta{Name: "data", Namespace: "default"},
+		Spec:       v1.PersistentVolumeClaimSpec{VolumeName: "pvc-1"},
+		Status:     v1.PersistentVolumeClaimStatus{Phase: v1.ClaimBound},
+	}
+	fakeKubeClient := fakekube.NewSimpleClientset(
+		newTestNode("node1"), newTestNode("node2"),
+		newTestPV("pvc-1", "node2"), newTestPV("pvc-2", ""), pvc)
+
+	jobs := &[]*batchv1.Job{}
+	running := make(map[string]*batchv1.Job)
+	fakeKubeClient.PrependReactor("create", "jobs", func(action core.Action) (bool, runtime.Object, error) {
+		job := action.(core.CreateAction).GetObject().(*batchv1.Job)
+		*jobs = append(*jobs, job)
+		running[job.Name] = job
+		return false, nil, nil
+	})
+	fakeKubeClient.PrependReactor("get", "jobs", func(action core.Action) (bool, runtime.Object, error) {
+		job, ok := running[action.(core.GetAction).GetName()]
+		if !ok {
+			return false, nil, nil
+		}
+		job = job.DeepCopy()
+		job.Status.Succeeded = 1
+		return true, job, nil
+	})
+	fakeKubeClient.PrependReactor("delete", "jobs", func(action core.Action) (bool, runtime.Object, error) {
+		delete(running, action.(core.DeleteAction).GetName())
+		return false, nil, nil
+	})
+	k8s.Instance().SetClient(fakeKubeClient, nil, nil, nil, nil, nil)
+
+	require.NoError(t, os.Setenv(resticRepositoryEnv, "s3:s3.amazonaws.com/bucket"))
+	defer os.Unsetenv(resticRepositoryEnv)
+	h := &hostPath{}
+	require.NoError(t, h.Init(nil), "Error initializing driver")
+	return h, jobs
+}
+
+func TestInspectVolume(t *testing.T) {
+	h, _ := setup(t)
+	require.True(t, h.Capabilities()[storkvolume.CapabilitySnapshots])
+
+	info, err := h.InspectVolume("pvc-1")
+	require.NoError(t, err, "Error inspecting volume")
+	require.Equal(t, []string{"node2"}, info.DataNodes)
+	require.Equal(t, uint64(2), info.Size)
+
+	info, err = h.InspectVolume("pvc-2")
+	require.NoError(t, err, "Error inspecting volume")
+	require.Empty(t, info.DataNodes)
+
+	require.True(t, h.OwnsPVC(&v1.PersistentVolumeClaim{Spec: v1.PersistentVolumeClaimSpec{VolumeName: "pvc-1"}}))
+}
+
+func TestSnapshotCreate(t *testing.T) {
+	h, jobs := setup(t)
+	pv, err := k8s.Instance().GetPersistentVolume("pvc-1")
+	require.NoError(t, err, "Error getting PV")
+	snap := &snapv1.VolumeSnapshot{
+		Metadata: meta.ObjectMeta{Name: "snap", Namespace: "default", UID: "1234"},
+		Spec:     snapv1.VolumeSnapshotSpec{PersistentVolumeClaimName: "data"},
+	}
+
+	source, _, err := h.SnapshotCreate(snap, pv, nil)
+	require.NoError(t, err, "Error creating snapshot")
+	require.Equal(t, "1234", source.HostPath.Path)
+	require.Len(t, *jobs, 1)
+	job := (*jobs)[0]
+	require.Equal(t, "stork-backup-1234", job.Name)
+	require.Equal(t, defaultNamespace, job.Namespace)
+	require.Equal(t, "node2", job.Spec.Template.Spec.NodeName)
+	require.Equal(t, pv.Spec.HostPath.Path, job.Spec.Template.Spec.Volumes[0].HostPath.Path)
+	require.True(t, job.Spec.Template.Spec.Containers[0].VolumeMounts[0].ReadOnly)
+	require.True(t, strings.HasSuffix(job.Spec.Template.Spec.Containers[0].Command[2], "--tag 1234 /data"))
+
+	// The job is deleted once it completes
+	_, err = k8s.Instance().GetJob(job.Name, job.Namespace)
+	require.Error(t, err)
+
+	// Snapshots can't be taken if the node with the data isn't known
+	pv, err = k8s.Instance().GetPersistentVolume("pvc-2")
+	require.NoError(t, err, "Error getting PV")
+	_, _, err = h.SnapshotCreate(snap, pv, nil)
+	require.Error(t, err)
+	require.Len(t, *jobs, 1)
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	h, jobs := setup(t)
+	snapshotData := &snapv1.VolumeSnapshotData{
+		Spec: snapv1.VolumeSnapshotDataSpec{
+			VolumeSnapshotDataSource: snapv1.VolumeSnapshotDataSource{
+				HostPath: &snapv1.HostPathVolumeSnapshotSource{Path: "1234"},
+			},
+			PersistentVolumeRef: &v1.ObjectReference{Name: "pvc-1"},
+		},
+	}
+	pvc := &v1.PersistentVolumeClaim{
+		ObjectMeta: meta.ObjectMeta{Name: "restore", Namespace: "default"},
+	}
+
+	// Restored to the node of the source volume by default
+	source, labels, err := h.SnapshotRestore(snapshotData, pvc, "pvc-3", nil)
+	require.NoError(t, err, "Error restoring snapshot")
+	require.Equal(t, "/opt/local-path-provisioner/pvc-3_default_restore", source.HostPath.Path)
+	require.Equal(t, map[string]string{kubeletapis.LabelHostname: "node2"}, labels)
+	require.Len(t, *jobs, 1)
+	require.Equal(t, "stork-restore-pvc-3", (*jobs)[0].Name)
+	require.Equal(t, "node2", (*jobs)[0].Spec.Template.Spec.NodeName)
+	require.Equal(t, source.HostPath.Path, (*jobs)[0].Spec.Template.Spec.Volumes[0].HostPath.Path)
+
+	_, labels, err = h.SnapshotRestore(snapshotData, pvc, "pvc-4", map[string]string{restoreNodeParameter: "node1"})
+	require.NoError(t, err, "Error restoring snapshot")
+	require.Equal(t, map[string]string{kubeletapis.LabelHostname: "node1"}, labels)
+	require.Equal(t, "node1", (*jobs)[1].Spec.Template.Spec.NodeName)
+}
@@ -0,0 +1,293 @@
+package hostpath
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	snapv1 "github.com/kubernetes-incubator/external-storage/snapshot/pkg/apis/crd/v1"
+	"github.com/libopenstorage/stork/pkg/errors"
+	storklog "github.com/libopenstorage/stork/pkg/log"
+	"github.com/libopenstorage/stork/pkg/snapshot"
+	"github.com/portworx/sched-ops/k8s"
+	"github.com/sirupsen/logrus"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeletapis "k8s.io/kubernetes/pkg/kubelet/apis"
+)
+
+const (
+	// restoreNodeParameter is the storage class parameter with the node where
+	// volumes are restored. The node of the source volume is used if it isn't
+	// set.
+	restoreNodeParameter = "restoreNode"
+	// dataMountPath is the path where the volume is mounted in the data mover
+	// jobs. The snapshots are taken with this path so that they can be
+	// restored to any volume mounted at the same path.
+	dataMountPath = "/data"
+	// resticHost is the host set for the snapshots in the restic repository
+	resticHost = "stork"
+	// jobTimeout is the maximum time for which a data mover job can run
+	jobTimeout = 2 * time.Hour
+)
+
+func getReadySnapshotConditions() *[]snapv1.VolumeSnapshotCondition {
+	return &[]snapv1.VolumeSnapshotCondition{
+		{
+			Type:               snapv1.VolumeSnapshotConditionReady,
+			Status:             v1.ConditionTrue,
+			Message:            "Snapshot created successfully and it is ready",
+			LastTransitionTime: metav1.Now(),
+		},
+	}
+}
+
+func getErrorSnapshotConditions(err error) *[]snapv1.VolumeSnapshotCondition {
+	return &[]snapv1.VolumeSnapshotCondition{
+		{
+			Type:               snapv1.VolumeSnapshotConditionError,
+			Status:             v1.ConditionTrue,
+			Message:            fmt.Sprintf("snapshot failed due to err: %v", err),
+			LastTransitionTime: metav1.Now(),
+		},
+	}
+}
+
+// newJob returns a job that runs the command with the restic image on the
+// node, with the host path mounted at the data mount path if it is set
+func (h *hostPath) newJob(
+	name string,
+	node string,
+	path string,
+	pathType v1.HostPathType,
+	readOnly bool,
+	command string,
+) *batchv1.Job {
+	backoffLimit := int32(0)
+	container := v1.Container{
+		Name:    "restic",
+		Image:   h.image,
+		Command: []string{"/bin/sh", "-c", command},
+		Env: []v1.EnvVar{
+			{Name: resticRepositoryEnv, Value: h.repository},
+		},
+	}
+	var volumes []v1.Volume
+	if path != "" {
+		container.VolumeMounts = []v1.VolumeMount{
+			{Name: "data", MountPath: dataMountPath, ReadOnly: readOnly},
+		}
+		volumes = []v1.Volume{{
+			Name: "data",
+			VolumeSource: v1.VolumeSource{
+				HostPath: &v1.HostPathVolumeSource{Path: path, Type: &pathType},
+			},
+		}}
+	}
+	if h.secret != "" {
+		container.EnvFrom = []v1.EnvFromSource{{
+			SecretRef: &v1.SecretEnvSource{
+				LocalObjectReference: v1.LocalObjectReference{Name: h.secret},
+			},
+		}}
+	}
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: h.namespace,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: v1.PodTemplateSpec{
+				Spec: v1.PodSpec{
+					RestartPolicy: v1.RestartPolicyNever,
+					NodeName:      node,
+					Containers:    []v1.Container{container},
+					Volumes:       volumes,
+				},
+			},
+		},
+	}
+}
+
+// runJob runs the job and waits for it to complete. The job is deleted once
+// it completes so that it can be run again if it failed. An existing job with
+// the same name is waited on instead, for eg if stork restarted while it was
+// running.
+func runJob(job *batchv1.Job) error {
+	if _, err := k8s.Instance().CreateJob(job); err != nil && !k8serrors.IsAlreadyExists(err) {
+		return fmt.Errorf("error creating job %v: %v", job.Name, err)
+	}
+	jobErr := k8s.Instance().ValidateJob(job.Name, job.Namespace, jobTimeout)
+	if err := k8s.Instance().DeleteJob(job.Name, job.Namespace); err != nil && !k8serrors.IsNotFound(err) {
+		logrus.Warnf("Error deleting job %v: %v", job.Name, err)
+	}
+	if jobErr != nil {
+		return fmt.Errorf("job %v failed: %v", job.Name, jobErr)
+	}
+	return nil
+}
+
+// getSourceNode returns the node with the data for the PV. Only PVs with the
+// data on a single node can be snapshotted.
+func getSourceNode(pv *v1.PersistentVolume) (string, error) {
+	dataNodes, err := getDataNodes(pv)
+	if err != nil {
+		return "", err
+	}
+	if len(dataNodes) != 1 {
+		return "", fmt.Errorf("node with the data for PV %v can't be found from its node affinity", pv.Name)
+	}
+	return dataNodes[0], nil
+}
+
+// SnapshotCreate backs up the volume to the restic repository by running a
+// job on the node with the data. The snapshot is tagged with the UID of the
+// VolumeSnapshot.
+func (h *hostPath) SnapshotCreate(
+	snap *snapv1.VolumeSnapshot,
+	pv *v1.PersistentVolume,
+	tags *map[string]string,
+) (*snapv1.VolumeSnapshotDataSource, *[]snapv1.VolumeSnapshotCondition, error) {
+	if pv == nil || pv.Spec.HostPath == nil {
+		err := fmt.Errorf("invalid PV: %v", pv)
+		return nil, getErrorSnapshotConditions(err), err
+	}
+	node, err := getSourceNode(pv)
+	if err != nil {
+		return nil, getErrorSnapshotConditions(err), err
+	}
+
+	pvc, err := k8s.Instance().GetPersistentVolumeClaim(snap.Spec.PersistentVolumeClaimName, snap.Metadata.Namespace)
+	if err != nil {
+		return nil, getErrorSnapshotConditions(err), err
+	}
+	pvcs := []v1.PersistentVolumeClaim{*pvc}
+	backgroundCommandTermChan, err := snapshot.ExecutePreSnapRule(snap, pvcs)
+	defer func() {
+		if backgroundCommandTermChan != nil {
+			backgroundCommandTermChan <- true // regardless of what happens, always terminate commands
+		}
+	}()
+	if err != nil {
+		err = fmt.Errorf("failed to run pre-snap rule due to: %v", err)
+		storklog.SnapshotLog(snap).Error(err)
+		return nil, getErrorSnapshotConditions(err), err
+	}
+
+	tag := string(snap.Metadata.UID)
+	storklog.SnapshotLog(snap).Infof("Backing up volume %v on node %v to restic repository", pv.Name, node)
+	job := h.newJob("stork-backup-"+tag, node, pv.Spec.HostPath.Path, v1.HostPathDirectory, true,
+		fmt.Sprintf("(restic snapshots > /dev/null 2>&1 || restic init) && restic backup --host %v --tag %v %v",
+			resticHost, tag, dataMountPath))
+	if err := runJob(job); err != nil {
+		return nil, getErrorSnapshotConditions(err), err
+	}
+
+	if err := snapshot.ExecutePostSnapRule(pvcs, snap); err != nil {
+		err = fmt.Errorf("failed to run post-snap rule due to: %v", err)
+		storklog.SnapshotLog(snap).Error(err)
+		return nil, getErrorSnapshotConditions(err), err
+	}
+
+	return &snapv1.VolumeSnapshotDataSource{
+		HostPath: &snapv1.HostPathVolumeSnapshotSource{
+			Path: tag,
+		},
+	}, getReadySnapshotConditions(), nil
+}
+
+// SnapshotDelete removes the snapshot from the restic repository
+func (h *hostPath) SnapshotDelete(snapDataSrc *snapv1.VolumeSnapshotDataSource, _ *v1.PersistentVolume) error {
+	if snapDataSrc == nil || snapDataSrc.HostPath == nil {
+		return fmt.Errorf("invalid snapshot source %v", snapDataSrc)
+	}
+	tag := snapDataSrc.HostPath.Path
+	job := h.newJob("stork-forget-"+tag, "", "", "", true,
+		fmt.Sprintf("restic forget --host %v --tag %v --prune", resticHost, tag))
+	return runJob(job)
+}
+
+// SnapshotRestore restores the snapshot to a new directory on the restore
+// node by running a job on the node. The node is added as a label so that the
+// PV is pinned to it.
+func (h *hostPath) SnapshotRestore(
+	snapshotData *snapv1.VolumeSnapshotData,
+	pvc *v1.PersistentVolumeClaim,
+	pvName string,
+	parameters map[string]string,
+) (*v1.PersistentVolumeSource, map[string]string, error) {
+	if snapshotData == nil || snapshotData.Spec.HostPath == nil {
+		return nil, nil, fmt.Errorf("invalid Snapshot spec")
+	}
+	if pvc == nil {
+		return nil, nil, fmt.Errorf("invalid PVC spec")
+	}
+
+	node := parameters[restoreNodeParameter]
+	if node == "" {
+		if snapshotData.Spec.PersistentVolumeRef == nil {
+			return nil, nil, fmt.Errorf("%v parameter is required since the source volume isn't known", restoreNodeParameter)
+		}
+		sourcePV, err := k8s.Instance().GetPersistentVolume(snapshotData.Spec.PersistentVolumeRef.Name)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%v parameter is required since the source volume can't be found: %v",
+				restoreNodeParameter, err)
+		}
+		if node, err = getSourceNode(sourcePV); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Same naming as the local-path provisioner
+	path := filepath.Join(h.restorePath, pvName+"_"+pvc.Namespace+"_"+pvc.Name)
+	tag := snapshotData.Spec.HostPath.Path
+	logrus.Infof("Restoring snapshot %v to %v on node %v for PVC %v/%v", tag, path, node, pvc.Namespace, pvc.Name)
+	job := h.newJob("stork-restore-"+pvName, node, path, v1.HostPathDirectoryOrCreate, false,
+		fmt.Sprintf("restic restore latest --host %v --tag %v --target /", resticHost, tag))
+	if err := runJob(job); err != nil {
+		return nil, nil, err
+	}
+
+	hostPathType := v1.HostPathDirectoryOrCreate
+	return &v1.PersistentVolumeSource{
+		HostPath: &v1.HostPathVolumeSource{
+			Path: path,
+			Type: &hostPathType,
+		},
+	}, map[string]string{
+		kubeletapis.LabelHostname: node,
+	}, nil
+}
+
+// DescribeSnapshot returns the snapshot as ready since snapshots are
+// completed when they are created
+func (h *hostPath) DescribeSnapshot(snapshotData *snapv1.VolumeSnapshotData) (*[]snapv1.VolumeSnapshotCondition, bool, error) {
+	if snapshotData == nil || snapshotData.Spec.HostPath == nil {
+		err := fmt.Errorf("invalid VolumeSnapshotDataSource: %v", snapshotData)
+		return getErrorSnapshotConditions(err), false, nil
+	}
+	return getReadySnapshotConditions(), true, nil
+}
+
+// FindSnapshot returns ErrNotImplemented
+func (h *hostPath) FindSnapshot(tags *map[string]string) (*snapv1.VolumeSnapshotDataSource, *[]snapv1.VolumeSnapshotCondition, error) {
+	return nil, nil, &errors.ErrNotImplemented{}
+}
+
+// VolumeDelete deletes the directory of a restored volume by running a job on
+// its node
+func (h *hostPath) VolumeDelete(pv *v1.PersistentVolume) error {
+	if pv == nil || pv.Spec.HostPath == nil {
+		return fmt.Errorf("invalid PV: %v", pv)
+	}
+	node := pv.Labels[kubeletapis.LabelHostname]
+	if node == "" {
+		return fmt.Errorf("node for PV %v not found in its labels", pv.Name)
+	}
+	job := h.newJob("stork-cleanup-"+pv.Name, node, filepath.Dir(pv.Spec.HostPath.Path), v1.HostPathDirectory, false,
+		fmt.Sprintf("rm -rf %v", filepath.Join(dataMountPath, filepath.Base(pv.Spec.HostPath.Path))))
+	return runJob(job)
+}
@@ -0,0 +1,142 @@
+package linstor
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/libopenstorage/stork/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	nodeConnectionOnline = "ONLINE"
+	nodeTypeController   = "CONTROLLER"
+	resourceFlagDiskless = "DISKLESS"
+
+	requestTimeout = 30 * time.Second
+)
+
+// node is a node from the Linstor API
+type node struct {
+	Name             string         `json:"name"`
+	Type             string         `json:"type"`
+	ConnectionStatus string         `json:"connection_status"`
+	NetInterfaces    []netInterface `json:"net_interfaces"`
+}
+
+type netInterface struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+}
+
+// replica is a resource from the Linstor API, ie the replica of a resource
+// definition on a node. Diskless replicas access the data over the network
+// from the diskful replicas.
+type replica struct {
+	Name     string         `json:"name"`
+	NodeName string         `json:"node_name"`
+	Flags    []string       `json:"flags"`
+	State    *resourceState `json:"state"`
+}
+
+type resourceState struct {
+	InUse bool `json:"in_use"`
+}
+
+func (r *replica) isDiskless() bool {
+	for _, flag := range r.Flags {
+		if flag == resourceFlagDiskless {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *replica) isInUse() bool {
+	return r.State != nil && r.State.InUse
+}
+
+// apiCallError is returned by the Linstor API for failed requests
+type apiCallError struct {
+	Message string `json:"message"`
+}
+
+// client is a client for the REST API of the Linstor controller
+type client struct {
+	endpoint   string
+	httpClient *http.Client
+}
+
+func newClient(endpoint string) *client {
+	return &client{
+		endpoint:   strings.TrimSuffix(endpoint, "/"),
+		httpClient: &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// get decodes the response for the path into out. Returns ErrNotFound with
+// the id and type if the object doesn't exist.
+func (c *client) get(path string, id string, objectType string, out interface{}) error {
+	resp, err := c.httpClient.Get(c.endpoint + path)
+	if err != nil {
+		return fmt.Errorf("error calling Linstor API: %v", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			logrus.Warnf("Error closing response from Linstor API: %v", err)
+		}
+	}()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading response from Linstor API: %v", err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return &errors.ErrNotFound{
+			ID:   id,
+			Type: objectType,
+		}
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErrors []apiCallError
+		if err := json.Unmarshal(body, &apiErrors); err == nil && len(apiErrors) > 0 {
+			return fmt.Errorf("error from Linstor API for %v: %v", path, apiErrors[0].Message)
+		}
+		return fmt.Errorf("error from Linstor API for %v: %v", path, resp.Status)
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("error parsing response from Linstor API for %v: %v", path, err)
+	}
+	return nil
+}
+
+func (c *client) getNodes() ([]node, error) {
+	var nodes []node
+	if err := c.get("/v1/nodes", "", "Node", &nodes); err != nil {
+		return nil, err
+	}
+	return nodes, nil
+}
+
+// getResources returns the replicas of the resource definition. A resource
+// definition that doesn't exist has no replicas, so ErrNotFound is also
+// returned if there aren't any.
+func (c *client) getResources(resourceName string) ([]replica, error) {
+	var resources []replica
+	path := "/v1/resource-definitions/" + url.PathEscape(resourceName) + "/resources"
+	if err := c.get(path, resourceName, "Volume", &resources); err != nil {
+		return nil, err
+	}
+	if len(resources) == 0 {
+		return nil, &errors.ErrNotFound{
+			ID:   resourceName,
+			Type: "Volume",
+		}
+	}
+	return resources, nil
+}
@@ -0,0 +1,251 @@
+package linstor
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	snapv1 "github.com/kubernetes-incubator/external-storage/snapshot/pkg/apis/crd/v1"
+	snapshotVolume "github.com/kubernetes-incubator/external-storage/snapshot/pkg/volume"
+	storkvolume "github.com/libopenstorage/stork/drivers/volume"
+	"github.com/libopenstorage/stork/pkg/errors"
+	"github.com/portworx/sched-ops/k8s"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	k8shelper "k8s.io/kubernetes/pkg/apis/core/v1/helper"
+)
+
+const (
+	// driverName is the name of the Linstor driver
+	driverName = "linstor"
+	// controllersEnv is the environment variable with the comma separated list
+	// of endpoints for the Linstor controller, same as the one used by the
+	// Linstor CSI driver. The first endpoint is used.
+	controllersEnv = "LS_CONTROLLERS"
+	// defaultEndpoint is the endpoint for the Linstor controller if it isn't
+	// configured
+	defaultEndpoint = "http://localhost:3370"
+	// pvcProvisionerAnnotation is the annotation on PVCs with the provisioner
+	// name
+	pvcProvisionerAnnotation = "volume.beta.kubernetes.io/storage-provisioner"
+)
+
+// provisioners are the names of the Linstor CSI driver. The volume handles of
+// the PVs are the names of the resource definitions in Linstor.
+var provisioners = map[string]bool{
+	"linstor.csi.linbit.com": true,
+	"io.drbd.linstor-csi":    true,
+}
+
+// linstor is the driver for volumes provisioned by the Linstor CSI driver.
+// The data nodes of a volume are the nodes with diskful DRBD replicas, so
+// that pods are placed where they can read locally.
+type linstor struct {
+	storkvolume.ClusterPairNotSupported
+	storkvolume.MigrationNotSupported
+	storkvolume.GroupSnapshotNotSupported
+	storkvolume.ClusterDomainsNotSupported
+	storkvolume.SnapshotRestoreNotSupported
+	storkvolume.SharedVolumeNotSupported
+	storkvolume.RestoreProvisionNotSupported
+	client *client
+}
+
+func (l *linstor) String() string {
+	return driverName
+}
+
+func (l *linstor) Init(_ interface{}) error {
+	endpoint := defaultEndpoint
+	if controllers := os.Getenv(controllersEnv); controllers != "" {
+		endpoint = strings.TrimSpace(strings.Split(controllers, ",")[0])
+	}
+	l.client = newClient(endpoint)
+	if _, err := l.client.getNodes(); err != nil {
+		return fmt.Errorf("error connecting to Linstor controller at %v: %v", endpoint, err)
+	}
+	return nil
+}
+
+func (l *linstor) Stop() error {
+	return nil
+}
+
+// Capabilities returns no capabilities since snapshots of Linstor volumes
+// can't be stored by the snapshotter
+func (l *linstor) Capabilities() map[storkvolume.Capability]bool {
+	return map[storkvolume.Capability]bool{}
+}
+
+// InspectVolume returns the info for the Linstor resource with the volumeID
+// as the name
+func (l *linstor) InspectVolume(volumeID string) (*storkvolume.Info, error) {
+	resources, err := l.client.getResources(volumeID)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &storkvolume.Info{
+		VolumeID:   volumeID,
+		VolumeName: volumeID,
+	}
+	for _, r := range resources {
+		if !r.isDiskless() {
+			info.DataNodes = append(info.DataNodes, r.NodeName)
+		}
+	}
+	return info, nil
+}
+
+// GetNodes returns the satellite nodes from Linstor. The storage IDs are the
+// Linstor node names, which are usually the hostnames.
+func (l *linstor) GetNodes() ([]*storkvolume.NodeInfo, error) {
+	nodes, err := l.client.getNodes()
+	if err != nil {
+		return nil, err
+	}
+
+	var nodeInfos []*storkvolume.NodeInfo
+	for _, n := range nodes {
+		if n.Type == nodeTypeController {
+			continue
+		}
+		nodeInfo := &storkvolume.NodeInfo{
+			StorageID: n.Name,
+			Hostname:  strings.ToLower(n.Name),
+			Status:    storkvolume.NodeOffline,
+		}
+		for _, netInterface := range n.NetInterfaces {
+			nodeInfo.IPs = append(nodeInfo.IPs, netInterface.Address)
+		}
+		if n.ConnectionStatus == nodeConnectionOnline {
+			nodeInfo.Status = storkvolume.NodeOnline
+		}
+		nodeInfos = append(nodeInfos, nodeInfo)
+	}
+	return nodeInfos, nil
+}
+
+// OwnsPVC returns true if the PVC is provisioned by the Linstor CSI driver
+func (l *linstor) OwnsPVC(pvc *v1.PersistentVolumeClaim) bool {
+	if pvc.Spec.VolumeName == "" {
+		provisioner := pvc.Annotations[pvcProvisionerAnnotation]
+		if provisioner == "" {
+			storageClassName := k8shelper.GetPersistentVolumeClaimClass(pvc)
+			if storageClassName == "" {
+				return false
+			}
+			storageClass, err := k8s.Instance().GetStorageClass(storageClassName)
+			if err != nil {
+				logrus.Warnf("Error getting storageclass %v for pvc %v: %v", storageClassName, pvc.Name, err)
+				return false
+			}
+			provisioner = storageClass.Provisioner
+		}
+		return provisioners[provisioner]
+	}
+
+	pv, err := k8s.Instance().GetPersistentVolume(pvc.Spec.VolumeName)
+	if err != nil {
+		logrus.Warnf("Error getting pv %v for pvc %v: %v", pvc.Spec.VolumeName, pvc.Name, err)
+		return false
+	}
+	return pv.Spec.CSI != nil && provisioners[pv.Spec.CSI.Driver]
+}
+
+func (l *linstor) GetPodVolumes(podSpec *v1.PodSpec, namespace string) ([]*storkvolume.Info, error) {
+	var volumes []*storkvolume.Info
+	for _, volume := range podSpec.Volumes {
+		if volume.PersistentVolumeClaim == nil {
+			continue
+		}
+		pvc, err := k8s.Instance().GetPersistentVolumeClaim(
+			volume.PersistentVolumeClaim.ClaimName,
+			namespace)
+		if err != nil {
+			return nil, err
+		}
+
+		if !l.OwnsPVC(pvc) {
+			continue
+		}
+
+		if pvc.Status.Phase == v1.ClaimPending {
+			return nil, &storkvolume.ErrPVCPending{
+				Name: volume.PersistentVolumeClaim.ClaimName,
+			}
+		}
+
+		pv, err := k8s.Instance().GetPersistentVolume(pvc.Spec.VolumeName)
+		if err != nil {
+			return nil, err
+		}
+		volumeInfo, err := l.InspectVolume(pv.Spec.CSI.VolumeHandle)
+		if err != nil {
+			return nil, err
+		}
+		volumeInfo.VolumeName = pv.Name
+		volumeInfo.Labels = pv.Labels
+		volumeInfo.VolumeSourceRef = pv
+		if storage, ok := pv.Spec.Capacity[v1.ResourceStorage]; ok {
+			volumeInfo.Size = uint64(storage.Value()) / (1024 * 1024 * 1024)
+		}
+		volumes = append(volumes, volumeInfo)
+	}
+	return volumes, nil
+}
+
+func (l *linstor) GetVolumeClaimTemplates(templates []v1.PersistentVolumeClaim) (
+	[]v1.PersistentVolumeClaim, error) {
+	var linstorTemplates []v1.PersistentVolumeClaim
+	for _, t := range templates {
+		if l.OwnsPVC(&t) {
+			linstorTemplates = append(linstorTemplates, t)
+		}
+	}
+	return linstorTemplates, nil
+}
+
+// GetVolumeAttachedNode returns the node where the DRBD resource is in use,
+// ie promoted to primary
+func (l *linstor) GetVolumeAttachedNode(volumeInfo *storkvolume.Info) (string, error) {
+	resources, err := l.client.getResources(volumeInfo.VolumeID)
+	if err != nil {
+		return "", err
+	}
+	for _, r := range resources {
+		if r.isInUse() {
+			return r.NodeName, nil
+		}
+	}
+	return "", nil
+}
+
+// ForceDetachVolume returns ErrNotSupported since Linstor volumes are
+// detached by the CSI attacher once the pods using them are deleted
+func (l *linstor) ForceDetachVolume(volumeInfo *storkvolume.Info) error {
+	return &errors.ErrNotSupported{
+		Feature: "ForceDetachVolume",
+		Reason:  "Linstor volumes are detached by the CSI attacher",
+	}
+}
+
+// GetSnapshotPlugin returns nil since the snapshotter has no data source for
+// Linstor snapshots
+func (l *linstor) GetSnapshotPlugin() snapshotVolume.Plugin {
+	return nil
+}
+
+func (l *linstor) GetSnapshotType(snap *snapv1.VolumeSnapshot) (string, error) {
+	return "", &errors.ErrNotSupported{}
+}
+
+func (l *linstor) GetClusterID() (string, error) {
+	return "", &errors.ErrNotSupported{}
+}
+
+func init() {
+	if err := storkvolume.Register(driverName, &linstor{}); err != nil {
+		logrus.Panicf("Error registering linstor volume driver: %v", err)
+	}
+}
@@ -0,0 +1,177 @@
+// +build unittest
+
+package linstor
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	storkvolume "github.com/libopenstorage/stork/drivers/volume"
+	"github.com/portworx/sched-ops/k8s"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakekube "k8s.io/client-go/kubernetes/fake"
+)
+
+const (
+	testNodes = `[
+		{"name": "controller", "type": "CONTROLLER", "connection_status": "ONLINE"},
+		{"name": "Node1", "type": "SATELLITE", "connection_status": "ONLINE",
+		 "net_interfaces": [{"name": "default", "address": "10.0.0.1"}]},
+		{"name": "node2", "type": "SATELLITE", "connection_status": "ONLINE",
+		 "net_interfaces": [{"name": "default", "address": "10.0.0.2"}]},
+		{"name": "node3", "type": "SATELLITE", "connection_status": "OFFLINE",
+		 "net_interfaces": [{"name": "default", "address": "10.0.0.3"}]}
+	]`
+	testResources = `[
+		{"name": "pvc-1", "node_name": "Node1", "flags": [], "state": {"in_use": false}},
+		{"name": "pvc-1", "node_name": "node2", "flags": ["DISKLESS"], "state": {"in_use": true}},
+		{"name": "pvc-1", "node_name": "node3"}
+	]`
+)
+
+func newTestServer() *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/nodes", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, testNodes)
+	})
+	mux.HandleFunc("/v1/resource-definitions/pvc-1/resources", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, testResources)
+	})
+	mux.HandleFunc("/v1/resource-definitions/pvc-2/resources", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, `[{"message": "Controller unavailable"}]`)
+	})
+	return httptest.NewServer(mux)
+}
+
+func newTestPV(name string, driver string, handle string) *v1.PersistentVolume {
+	return &v1.PersistentVolume{
+		ObjectMeta: meta.ObjectMeta{Name: name},
+		Spec: v1.PersistentVolumeSpec{
+			Capacity: v1.ResourceList{v1.ResourceStorage: resource.MustParse("10Gi")},
+			PersistentVolumeSource: v1.PersistentVolumeSource{
+				CSI: &v1.CSIPersistentVolumeSource{Driver: driver, VolumeHandle: handle},
+			},
+		},
+	}
+}
+
+func newTestPVC(name string, volumeName string, storageClass string) *v1.PersistentVolumeClaim {
+	phase := v1.ClaimBound
+	if volumeName == "" {
+		phase = v1.ClaimPending
+	}
+	return &v1.PersistentVolumeClaim{
+		ObjectMeta: meta.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: v1.PersistentVolumeClaimSpec{
+			VolumeName:       volumeName,
+			StorageClassName: &storageClass,
+		},
+		Status: v1.PersistentVolumeClaimStatus{Phase: phase},
+	}
+}
+
+func setupTestDriver(t *testing.T, server *httptest.Server) *linstor {
+	client := fakekube.NewSimpleClientset(
+		newTestPV("pv1", "linstor.csi.linbit.com", "pvc-1"),
+		newTestPV("pv2", "other.csi.com", "pvc-2"),
+		newTestPVC("pvc1", "pv1", "linstor-sc"),
+		newTestPVC("pvc2", "pv2", "other-sc"),
+		newTestPVC("pending", "", "linstor-sc"),
+		&storagev1.StorageClass{
+			ObjectMeta:  meta.ObjectMeta{Name: "linstor-sc"},
+			Provisioner: "linstor.csi.linbit.com",
+		},
+	)
+	k8s.Instance().SetClient(client, nil, nil, nil, nil, nil)
+
+	require.NoError(t, os.Setenv(controllersEnv, server.URL+",http://unused:3370"), "Error setting controllers")
+	d := &linstor{}
+	require.NoError(t, d.Init(nil), "Error initializing driver")
+	return d
+}
+
+func TestLinstorInit(t *testing.T) {
+	defer os.Unsetenv(controllersEnv)
+	require.NoError(t, os.Setenv(controllersEnv, "http://127.0.0.1:1"), "Error setting controllers")
+	d := &linstor{}
+	require.Error(t, d.Init(nil))
+}
+
+func TestLinstorGetNodes(t *testing.T) {
+	defer os.Unsetenv(controllersEnv)
+	server := newTestServer()
+	defer server.Close()
+	d := setupTestDriver(t, server)
+
+	nodes, err := d.GetNodes()
+	require.NoError(t, err, "Error getting nodes")
+	require.Len(t, nodes, 3)
+	require.Equal(t, &storkvolume.NodeInfo{
+		StorageID: "Node1",
+		Hostname:  "node1",
+		IPs:       []string{"10.0.0.1"},
+		Status:    storkvolume.NodeOnline,
+	}, nodes[0])
+	require.Equal(t, storkvolume.NodeOnline, nodes[1].Status)
+	require.Equal(t, storkvolume.NodeOffline, nodes[2].Status)
+}
+
+func TestLinstorGetPodVolumes(t *testing.T) {
+	defer os.Unsetenv(controllersEnv)
+	server := newTestServer()
+	defer server.Close()
+	d := setupTestDriver(t, server)
+
+	podSpec := &v1.PodSpec{}
+	for _, claim := range []string{"pvc1", "pvc2"} {
+		podSpec.Volumes = append(podSpec.Volumes, v1.Volume{
+			VolumeSource: v1.VolumeSource{
+				PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: claim},
+			},
+		})
+	}
+	volumes, err := d.GetPodVolumes(podSpec, "default")
+	require.NoError(t, err, "Error getting pod volumes")
+	require.Len(t, volumes, 1)
+	// Only the nodes with diskful replicas have the data
+	require.Equal(t, "pvc-1", volumes[0].VolumeID)
+	require.Equal(t, "pv1", volumes[0].VolumeName)
+	require.Equal(t, []string{"Node1", "node3"}, volumes[0].DataNodes)
+	require.Equal(t, uint64(10), volumes[0].Size)
+
+	podSpec.Volumes = append(podSpec.Volumes, v1.Volume{
+		VolumeSource: v1.VolumeSource{
+			PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: "pending"},
+		},
+	})
+	_, err = d.GetPodVolumes(podSpec, "default")
+	require.Equal(t, &storkvolume.ErrPVCPending{Name: "pending"}, err)
+}
+
+func TestLinstorInspectVolume(t *testing.T) {
+	defer os.Unsetenv(controllersEnv)
+	server := newTestServer()
+	defer server.Close()
+	d := setupTestDriver(t, server)
+
+	attachedNode, err := d.GetVolumeAttachedNode(&storkvolume.Info{VolumeID: "pvc-1"})
+	require.NoError(t, err, "Error getting attached node")
+	require.Equal(t, "node2", attachedNode)
+	require.Error(t, d.ForceDetachVolume(&storkvolume.Info{VolumeID: "pvc-1"}))
+
+	_, err = d.InspectVolume("pvc-3")
+	require.Error(t, err)
+	require.Equal(t, "Volume with UID/Name: pvc-3 not found", err.Error())
+
+	_, err = d.InspectVolume("pvc-2")
+	require.Error(t, err)
+	require.Equal(t, "error from Linstor API for /v1/resource-definitions/pvc-2/resources: Controller unavailable", err.Error())
+}
@@ -35,9 +35,13 @@ type Driver struct {
 	storkvolume.MigrationNotSupported
 	storkvolume.GroupSnapshotNotSupported
 	storkvolume.ClusterDomainsNotSupported
+	storkvolume.SnapshotRestoreNotSupported
+	storkvolume.RestoreProvisionNotSupported
 	nodes          []*storkvolume.NodeInfo
 	volumes        map[string]*storkvolume.Info
 	pvcs           map[string]*v1.PersistentVolumeClaim
+	coordinators   map[string]string
+	attachments    map[string]string
 	interfaceError error
 	clusterID      string
 }
@@ -57,6 +61,12 @@ func (m Driver) Stop() error {
 	return nil
 }
 
+// Capabilities Returns the capabilities for the mock driver. The plugin
+// interfaces aren't supported by the mock driver
+func (m Driver) Capabilities() map[storkvolume.Capability]bool {
+	return map[storkvolume.Capability]bool{}
+}
+
 // CreateCluster Creates a cluster with specified number of nodes
 func (m *Driver) CreateCluster(numNodes int, nodes *v1.NodeList) error {
 	if len(m.nodes) > 0 {
@@ -97,6 +107,8 @@ func (m *Driver) CreateCluster(numNodes int, nodes *v1.NodeList) error {
 	}
 	m.volumes = make(map[string]*storkvolume.Info)
 	m.pvcs = make(map[string]*v1.PersistentVolumeClaim)
+	m.coordinators = make(map[string]string)
+	m.attachments = make(map[string]string)
 	m.interfaceError = nil
 	m.clusterID = "stork-test-" + uuid.New()
 	return nil
@@ -151,6 +163,39 @@ func (m *Driver) ProvisionVolume(
 	return nil
 }
 
+// SetSharedVolumeCoordinator Mark a volume as shared and set the node that is
+// serving it
+func (m *Driver) SetSharedVolumeCoordinator(
+	volumeName string,
+	nodeIndex int,
+) error {
+	volume, ok := m.volumes[volumeName]
+	if !ok {
+		return fmt.Errorf("volume %v not found", volumeName)
+	}
+	if len(m.nodes) <= nodeIndex {
+		return fmt.Errorf("node %v not found", nodeIndex)
+	}
+	volume.Shared = true
+	m.coordinators[volumeName] = m.nodes[nodeIndex].StorageID
+	return nil
+}
+
+// AttachVolume Set the node where a volume is attached
+func (m *Driver) AttachVolume(
+	volumeName string,
+	nodeIndex int,
+) error {
+	if _, ok := m.volumes[volumeName]; !ok {
+		return fmt.Errorf("volume %v not found", volumeName)
+	}
+	if len(m.nodes) <= nodeIndex {
+		return fmt.Errorf("node %v not found", nodeIndex)
+	}
+	m.attachments[volumeName] = m.nodes[nodeIndex].StorageID
+	return nil
+}
+
 // UpdateNodeStatus Update status for a node
 func (m *Driver) UpdateNodeStatus(
 	nodeIndex int,
@@ -163,6 +208,20 @@ func (m *Driver) UpdateNodeStatus(
 	return nil
 }
 
+// UpdateNodeCapacity Update the total and free capacity for a node
+func (m *Driver) UpdateNodeCapacity(
+	nodeIndex int,
+	totalCapacity uint64,
+	freeCapacity uint64,
+) error {
+	if len(m.nodes) <= nodeIndex {
+		return fmt.Errorf("node %v not found", nodeIndex)
+	}
+	m.nodes[nodeIndex].TotalCapacity = totalCapacity
+	m.nodes[nodeIndex].FreeCapacity = freeCapacity
+	return nil
+}
+
 // SetInterfaceError to the specified error. Used for negative testing
 func (m *Driver) SetInterfaceError(err error) {
 	m.interfaceError = err
@@ -231,6 +290,33 @@ func (m Driver) GetPodVolumes(podSpec *v1.PodSpec, namespace string) ([]*storkvo
 	return volumes, nil
 }
 
+// GetSharedVolumeCoordinator Get the node serving a shared volume
+func (m Driver) GetSharedVolumeCoordinator(volumeInfo *storkvolume.Info) (string, error) {
+	if m.interfaceError != nil {
+		return "", m.interfaceError
+	}
+	return m.coordinators[volumeInfo.VolumeID], nil
+}
+
+// GetVolumeAttachedNode Get the node where a volume is attached
+func (m Driver) GetVolumeAttachedNode(volumeInfo *storkvolume.Info) (string, error) {
+	if m.interfaceError != nil {
+		return "", m.interfaceError
+	}
+	return m.attachments[volumeInfo.VolumeID], nil
+}
+
+// ForceDetachVolume Detach a volume from the node where it is attached. Shared
+// volumes are also no longer served by the node.
+func (m Driver) ForceDetachVolume(volumeInfo *storkvolume.Info) error {
+	if m.interfaceError != nil {
+		return m.interfaceError
+	}
+	delete(m.attachments, volumeInfo.VolumeID)
+	delete(m.coordinators, volumeInfo.VolumeID)
+	return nil
+}
+
 // OwnsPVC returns false since mock driver doesn't own any PVCs
 func (m *Driver) OwnsPVC(pvc *v1.PersistentVolumeClaim) bool {
 	return false
@@ -241,10 +327,20 @@ func (m *Driver) GetSnapshotPlugin() snapshotVolume.Plugin {
 	return nil
 }
 
-// GetVolumeClaimTemplates Not implemented for mock driver
-func (m *Driver) GetVolumeClaimTemplates([]v1.PersistentVolumeClaim) (
+// GetVolumeClaimTemplates Returns the templates using the mock driver
+// storage class
+func (m *Driver) GetVolumeClaimTemplates(templates []v1.PersistentVolumeClaim) (
 	[]v1.PersistentVolumeClaim, error) {
-	return nil, &errors.ErrNotImplemented{}
+	if m.interfaceError != nil {
+		return nil, m.interfaceError
+	}
+	var mockTemplates []v1.PersistentVolumeClaim
+	for _, template := range templates {
+		if k8shelper.GetPersistentVolumeClaimClass(&template) == mockStorageClassName {
+			mockTemplates = append(mockTemplates, template)
+		}
+	}
+	return mockTemplates, nil
 }
 
 // GetSnapshotType Not implemented for mock driver
@@ -0,0 +1,527 @@
+package multi
+
+import (
+	"fmt"
+	"strings"
+
+	snapv1 "github.com/kubernetes-incubator/external-storage/snapshot/pkg/apis/crd/v1"
+	snapshotVolume "github.com/kubernetes-incubator/external-storage/snapshot/pkg/volume"
+	storkvolume "github.com/libopenstorage/stork/drivers/volume"
+	stork_crd "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/errors"
+	"github.com/libopenstorage/stork/pkg/k8sutils"
+	"github.com/portworx/sched-ops/k8s"
+	v1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// multi is a volume driver that uses multiple drivers in the same cluster.
+// PVCs are routed to the driver that owns them, and the volumes and nodes
+// returned by each driver are tagged with the name of the driver so that the
+// extender and health monitor only match volumes with nodes from the same
+// driver. Snapshots, group snapshots, migrations and restores are routed to
+// the driver that owns the PVCs, while cluster pairs and cluster domains are
+// served by the first driver.
+type multi struct {
+	drivers []storkvolume.Driver
+	byName  map[string]storkvolume.Driver
+}
+
+// New returns a driver that uses all the drivers. The first driver is the
+// primary driver which serves the plugin interfaces that can't be routed by
+// PVC.
+func New(drivers []storkvolume.Driver) (storkvolume.Driver, error) {
+	if len(drivers) == 0 {
+		return nil, fmt.Errorf("at least one driver needs to be provided")
+	}
+	m := &multi{
+		drivers: drivers,
+		byName:  make(map[string]storkvolume.Driver),
+	}
+	for _, d := range drivers {
+		if _, present := m.byName[d.String()]; present {
+			return nil, fmt.Errorf("driver %v provided more than once", d.String())
+		}
+		m.byName[d.String()] = d
+	}
+	return m, nil
+}
+
+func (m *multi) primary() storkvolume.Driver {
+	return m.drivers[0]
+}
+
+// Drivers returns the drivers used by the multi driver
+func (m *multi) Drivers() []storkvolume.Driver {
+	return m.drivers
+}
+
+// pvcDriver returns the driver that owns the PVC. The primary driver is
+// returned if the PVC doesn't exist anymore, since operations like
+// migrations could still be in progress for it.
+func (m *multi) pvcDriver(name string, namespace string) (storkvolume.Driver, error) {
+	pvc, err := k8s.Instance().GetPersistentVolumeClaim(name, namespace)
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			return m.primary(), nil
+		}
+		return nil, err
+	}
+	return storkvolume.GetPVCDriver(m, pvc)
+}
+
+// selectorDrivers returns the drivers that own the PVCs matching the labels
+// in the namespaces, in the order the drivers were provided
+func (m *multi) selectorDrivers(namespaces []string, labels map[string]string) ([]storkvolume.Driver, error) {
+	owners := make(map[string]bool)
+	for _, namespace := range namespaces {
+		pvcList, err := k8s.Instance().GetPersistentVolumeClaims(namespace, labels)
+		if err != nil {
+			return nil, err
+		}
+		for _, pvc := range pvcList.Items {
+			if d, err := storkvolume.GetPVCDriver(m, &pvc); err == nil {
+				owners[d.String()] = true
+			}
+		}
+	}
+	drivers := make([]storkvolume.Driver, 0)
+	for _, d := range m.drivers {
+		if owners[d.String()] {
+			drivers = append(drivers, d)
+		}
+	}
+	return drivers, nil
+}
+
+// groupSnapshotDriver returns the driver that owns the PVCs for the group
+// snapshot. All the PVCs need to be owned by the same driver since the
+// snapshots have to be consistent.
+func (m *multi) groupSnapshotDriver(snap *stork_crd.GroupVolumeSnapshot) (storkvolume.Driver, error) {
+	drivers, err := m.selectorDrivers(k8sutils.GetGroupSnapshotNamespaces(snap), snap.Spec.PVCSelector.MatchLabels)
+	if err != nil {
+		return nil, err
+	}
+	switch len(drivers) {
+	case 0:
+		return m.primary(), nil
+	case 1:
+		return drivers[0], nil
+	default:
+		return nil, fmt.Errorf("PVCs for group snapshot %v are owned by multiple drivers: %v",
+			snap.Name, strings.Join(driverNames(drivers), ","))
+	}
+}
+
+func driverNames(drivers []storkvolume.Driver) []string {
+	names := make([]string, 0, len(drivers))
+	for _, d := range drivers {
+		names = append(names, d.String())
+	}
+	return names
+}
+
+// volumeDriver returns the driver that returned the volume
+func (m *multi) volumeDriver(volumeInfo *storkvolume.Info) (storkvolume.Driver, error) {
+	if volumeInfo.Driver == "" {
+		return m.primary(), nil
+	}
+	d, ok := m.byName[volumeInfo.Driver]
+	if !ok {
+		return nil, &errors.ErrNotFound{
+			ID:   volumeInfo.Driver,
+			Type: "VolumeDriver",
+		}
+	}
+	return d, nil
+}
+
+// tagVolume returns a copy of the volume tagged with the name of the driver,
+// since drivers could return the volumes they keep internally
+func tagVolume(d storkvolume.Driver, volumeInfo *storkvolume.Info) *storkvolume.Info {
+	tagged := *volumeInfo
+	tagged.Driver = d.String()
+	return &tagged
+}
+
+func (m *multi) String() string {
+	return strings.Join(driverNames(m.drivers), ",")
+}
+
+func (m *multi) Init(config interface{}) error {
+	for _, d := range m.drivers {
+		if err := d.Init(config); err != nil {
+			return fmt.Errorf("error initializing driver %v: %v", d.String(), err)
+		}
+	}
+	return nil
+}
+
+func (m *multi) Stop() error {
+	var lastErr error
+	for _, d := range m.drivers {
+		if err := d.Stop(); err != nil {
+			lastErr = fmt.Errorf("error stopping driver %v: %v", d.String(), err)
+		}
+	}
+	return lastErr
+}
+
+// Capabilities returns the capabilities supported by any of the drivers. The
+// capability is checked again for the driver that an operation is routed to.
+func (m *multi) Capabilities() map[storkvolume.Capability]bool {
+	capabilities := make(map[storkvolume.Capability]bool)
+	for _, d := range m.drivers {
+		for capability, supported := range d.Capabilities() {
+			if supported {
+				capabilities[capability] = true
+			}
+		}
+	}
+	return capabilities
+}
+
+// InspectVolume returns the volume from the first driver that has it
+func (m *multi) InspectVolume(volumeID string) (*storkvolume.Info, error) {
+	var lastErr error
+	for _, d := range m.drivers {
+		volumeInfo, err := d.InspectVolume(volumeID)
+		if err == nil {
+			return tagVolume(d, volumeInfo), nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// GetNodes returns the nodes from all the drivers, so a Kubernetes node can
+// have one node from each driver. An error is returned if any of the drivers
+// fails, so that the health monitor doesn't treat the nodes from a driver
+// that can't be reached as offline.
+func (m *multi) GetNodes() ([]*storkvolume.NodeInfo, error) {
+	nodes, errs := m.GetAvailableNodes()
+	for _, d := range m.drivers {
+		if err, failed := errs[d.String()]; failed {
+			return nil, fmt.Errorf("error getting nodes from driver %v: %v", d.String(), err)
+		}
+	}
+	return nodes, nil
+}
+
+// GetAvailableNodes returns the nodes from the drivers that could be reached
+// and the errors from the other drivers
+func (m *multi) GetAvailableNodes() ([]*storkvolume.NodeInfo, map[string]error) {
+	var nodes []*storkvolume.NodeInfo
+	errs := make(map[string]error)
+	for _, d := range m.drivers {
+		driverNodes, err := d.GetNodes()
+		if err != nil {
+			errs[d.String()] = err
+			continue
+		}
+		for _, node := range driverNodes {
+			tagged := *node
+			tagged.Driver = d.String()
+			nodes = append(nodes, &tagged)
+		}
+	}
+	return nodes, errs
+}
+
+// GetPodVolumes returns the volumes for the pod from all the drivers. An error
+// is returned if any of the drivers fails.
+func (m *multi) GetPodVolumes(podSpec *v1.PodSpec, namespace string) ([]*storkvolume.Info, error) {
+	volumes, errs := m.GetAvailablePodVolumes(podSpec, namespace)
+	for _, d := range m.drivers {
+		if err, failed := errs[d.String()]; failed {
+			return nil, err
+		}
+	}
+	return volumes, nil
+}
+
+// GetAvailablePodVolumes returns the volumes for the pod from the drivers that
+// could be reached and the errors from the other drivers
+func (m *multi) GetAvailablePodVolumes(podSpec *v1.PodSpec, namespace string) ([]*storkvolume.Info, map[string]error) {
+	var volumes []*storkvolume.Info
+	errs := make(map[string]error)
+	for _, d := range m.drivers {
+		driverVolumes, err := d.GetPodVolumes(podSpec, namespace)
+		if err != nil {
+			errs[d.String()] = err
+			continue
+		}
+		for _, volumeInfo := range driverVolumes {
+			volumes = append(volumes, tagVolume(d, volumeInfo))
+		}
+	}
+	return volumes, errs
+}
+
+func (m *multi) GetVolumeClaimTemplates(templates []v1.PersistentVolumeClaim) ([]v1.PersistentVolumeClaim, error) {
+	var ownedTemplates []v1.PersistentVolumeClaim
+	for _, t := range templates {
+		if m.OwnsPVC(&t) {
+			ownedTemplates = append(ownedTemplates, t)
+		}
+	}
+	return ownedTemplates, nil
+}
+
+func (m *multi) OwnsPVC(pvc *v1.PersistentVolumeClaim) bool {
+	for _, d := range m.drivers {
+		if d.OwnsPVC(pvc) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *multi) GetSnapshotPlugin() snapshotVolume.Plugin {
+	return m.primary().GetSnapshotPlugin()
+}
+
+func (m *multi) GetSnapshotType(snap *snapv1.VolumeSnapshot) (string, error) {
+	d, err := m.pvcDriver(snap.Spec.PersistentVolumeClaimName, snap.Metadata.Namespace)
+	if err != nil {
+		return "", err
+	}
+	return d.GetSnapshotType(snap)
+}
+
+func (m *multi) GetClusterID() (string, error) {
+	return m.primary().GetClusterID()
+}
+
+func (m *multi) CreateGroupSnapshot(snap *stork_crd.GroupVolumeSnapshot) (*storkvolume.GroupSnapshotCreateResponse, error) {
+	d, err := m.groupSnapshotDriver(snap)
+	if err != nil {
+		return nil, err
+	}
+	return d.CreateGroupSnapshot(snap)
+}
+
+func (m *multi) GetGroupSnapshotStatus(snap *stork_crd.GroupVolumeSnapshot) (*storkvolume.GroupSnapshotCreateResponse, error) {
+	d, err := m.groupSnapshotDriver(snap)
+	if err != nil {
+		return nil, err
+	}
+	return d.GetGroupSnapshotStatus(snap)
+}
+
+func (m *multi) DeleteGroupSnapshot(snap *stork_crd.GroupVolumeSnapshot) error {
+	d, err := m.groupSnapshotDriver(snap)
+	if err != nil {
+		return err
+	}
+	return d.DeleteGroupSnapshot(snap)
+}
+
+func (m *multi) CreatePair(pair *stork_crd.ClusterPair) (string, error) {
+	return m.primary().CreatePair(pair)
+}
+
+func (m *multi) DeletePair(pair *stork_crd.ClusterPair) error {
+	return m.primary().DeletePair(pair)
+}
+
+// StartMigration starts the migration with each of the drivers that own PVCs
+// being migrated
+func (m *multi) StartMigration(migration *stork_crd.Migration) ([]*stork_crd.VolumeInfo, error) {
+	drivers, err := m.selectorDrivers(migration.Spec.Namespaces, migration.Spec.Selectors)
+	if err != nil {
+		return nil, fmt.Errorf("error getting list of volumes to migrate: %v", err)
+	}
+	if len(drivers) == 0 {
+		return m.primary().StartMigration(migration)
+	}
+	volumeInfos := make([]*stork_crd.VolumeInfo, 0)
+	for _, d := range drivers {
+		if err := storkvolume.CheckCapability(d, storkvolume.CapabilityMigration); err != nil {
+			return nil, err
+		}
+		driverVolumeInfos, err := d.StartMigration(migration)
+		if err != nil {
+			return nil, err
+		}
+		volumeInfos = append(volumeInfos, driverVolumeInfos...)
+	}
+	return volumeInfos, nil
+}
+
+// migrationsByDriver returns a copy of the migration for each driver with
+// only the volumes owned by that driver in the status
+func (m *multi) migrationsByDriver(migration *stork_crd.Migration) ([]storkvolume.Driver, []*stork_crd.Migration, error) {
+	volumes := make(map[string][]*stork_crd.VolumeInfo)
+	for _, volumeInfo := range migration.Status.Volumes {
+		d, err := m.pvcDriver(volumeInfo.PersistentVolumeClaim, volumeInfo.Namespace)
+		if err != nil {
+			return nil, nil, err
+		}
+		volumes[d.String()] = append(volumes[d.String()], volumeInfo)
+	}
+	drivers := make([]storkvolume.Driver, 0)
+	migrations := make([]*stork_crd.Migration, 0)
+	for _, d := range m.drivers {
+		if _, ok := volumes[d.String()]; !ok {
+			continue
+		}
+		driverMigration := *migration
+		driverMigration.Status.Volumes = volumes[d.String()]
+		drivers = append(drivers, d)
+		migrations = append(migrations, &driverMigration)
+	}
+	return drivers, migrations, nil
+}
+
+func (m *multi) GetMigrationStatus(migration *stork_crd.Migration) ([]*stork_crd.VolumeInfo, error) {
+	drivers, migrations, err := m.migrationsByDriver(migration)
+	if err != nil {
+		return nil, err
+	}
+	volumeInfos := make([]*stork_crd.VolumeInfo, 0)
+	for i, d := range drivers {
+		driverVolumeInfos, err := d.GetMigrationStatus(migrations[i])
+		if err != nil {
+			return nil, err
+		}
+		volumeInfos = append(volumeInfos, driverVolumeInfos...)
+	}
+	return volumeInfos, nil
+}
+
+func (m *multi) CancelMigration(migration *stork_crd.Migration) error {
+	drivers, migrations, err := m.migrationsByDriver(migration)
+	if err != nil {
+		return err
+	}
+	for i, d := range drivers {
+		if err := d.CancelMigration(migrations[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UpdateMigratedPersistentVolumeSpec updates the PV with the first driver
+// that supports migrations and recognizes the volume source of the PV
+func (m *multi) UpdateMigratedPersistentVolumeSpec(object runtime.Unstructured) (runtime.Unstructured, error) {
+	var lastErr error
+	for _, d := range m.drivers {
+		if storkvolume.CheckCapability(d, storkvolume.CapabilityMigration) != nil {
+			continue
+		}
+		updated, err := d.UpdateMigratedPersistentVolumeSpec(object)
+		if err == nil {
+			return updated, nil
+		}
+		lastErr = err
+	}
+	if lastErr == nil {
+		return m.primary().UpdateMigratedPersistentVolumeSpec(object)
+	}
+	return nil, lastErr
+}
+
+func (m *multi) GetClusterDomains() (*stork_crd.ClusterDomains, error) {
+	return m.primary().GetClusterDomains()
+}
+
+func (m *multi) ActivateClusterDomain(update *stork_crd.ClusterDomainUpdate) error {
+	return m.primary().ActivateClusterDomain(update)
+}
+
+func (m *multi) DeactivateClusterDomain(update *stork_crd.ClusterDomainUpdate) error {
+	return m.primary().DeactivateClusterDomain(update)
+}
+
+// restoresByDriver returns a copy of the restore for each driver with only
+// the volumes owned by that driver in the status
+func (m *multi) restoresByDriver(restore *stork_crd.VolumeSnapshotRestore) ([]storkvolume.Driver, []*stork_crd.VolumeSnapshotRestore, error) {
+	volumes := make(map[string][]*stork_crd.RestoreVolumeInfo)
+	for _, volumeInfo := range restore.Status.Volumes {
+		d, err := m.pvcDriver(volumeInfo.PersistentVolumeClaim, volumeInfo.Namespace)
+		if err != nil {
+			return nil, nil, err
+		}
+		volumes[d.String()] = append(volumes[d.String()], volumeInfo)
+	}
+	drivers := make([]storkvolume.Driver, 0)
+	restores := make([]*stork_crd.VolumeSnapshotRestore, 0)
+	for _, d := range m.drivers {
+		if _, ok := volumes[d.String()]; !ok {
+			continue
+		}
+		driverRestore := *restore
+		driverRestore.Status.Volumes = volumes[d.String()]
+		drivers = append(drivers, d)
+		restores = append(restores, &driverRestore)
+	}
+	return drivers, restores, nil
+}
+
+func (m *multi) StartVolumeSnapshotRestore(restore *stork_crd.VolumeSnapshotRestore) error {
+	drivers, restores, err := m.restoresByDriver(restore)
+	if err != nil {
+		return err
+	}
+	for i, d := range drivers {
+		if err := storkvolume.CheckCapability(d, storkvolume.CapabilitySnapshotRestore); err != nil {
+			return err
+		}
+		if err := d.StartVolumeSnapshotRestore(restores[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *multi) GetVolumeSnapshotRestoreStatus(restore *stork_crd.VolumeSnapshotRestore) error {
+	drivers, restores, err := m.restoresByDriver(restore)
+	if err != nil {
+		return err
+	}
+	for i, d := range drivers {
+		if err := d.GetVolumeSnapshotRestoreStatus(restores[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *multi) GetSharedVolumeCoordinator(volumeInfo *storkvolume.Info) (string, error) {
+	d, err := m.volumeDriver(volumeInfo)
+	if err != nil {
+		return "", err
+	}
+	return d.GetSharedVolumeCoordinator(volumeInfo)
+}
+
+func (m *multi) GetVolumeAttachedNode(volumeInfo *storkvolume.Info) (string, error) {
+	d, err := m.volumeDriver(volumeInfo)
+	if err != nil {
+		return "", err
+	}
+	return d.GetVolumeAttachedNode(volumeInfo)
+}
+
+func (m *multi) ForceDetachVolume(volumeInfo *storkvolume.Info) error {
+	d, err := m.volumeDriver(volumeInfo)
+	if err != nil {
+		return err
+	}
+	return d.ForceDetachVolume(volumeInfo)
+}
+
+func (m *multi) PreProvisionRestoreVolume(
+	snapshotData *snapv1.VolumeSnapshotData,
+	pvc *v1.PersistentVolumeClaim,
+	parameters map[string]string,
+) error {
+	// The snapshot is restored by the driver that created it
+	if d, ok := m.byName[snapv1.GetSupportedVolumeFromSnapshotDataSpec(&snapshotData.Spec)]; ok {
+		return d.PreProvisionRestoreVolume(snapshotData, pvc, parameters)
+	}
+	return m.primary().PreProvisionRestoreVolume(snapshotData, pvc, parameters)
+}
@@ -0,0 +1,227 @@
+// +build unittest
+
+package multi
+
+import (
+	"fmt"
+	"testing"
+
+	storkvolume "github.com/libopenstorage/stork/drivers/volume"
+	"github.com/libopenstorage/stork/drivers/volume/mock"
+	stork_crd "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/portworx/sched-ops/k8s"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakekube "k8s.io/client-go/kubernetes/fake"
+)
+
+// namedDriver is a mock driver with a different name so that multiple mock
+// drivers can be used together. PVCs from other drivers are skipped when
+// getting pod volumes like the real drivers.
+type namedDriver struct {
+	*mock.Driver
+	name         string
+	claims       map[string]bool
+	capabilities map[storkvolume.Capability]bool
+	restored     []string
+}
+
+func (d *namedDriver) String() string {
+	return d.name
+}
+
+func (d *namedDriver) OwnsPVC(pvc *v1.PersistentVolumeClaim) bool {
+	return d.claims[pvc.Name]
+}
+
+func (d *namedDriver) Capabilities() map[storkvolume.Capability]bool {
+	return d.capabilities
+}
+
+func (d *namedDriver) StartVolumeSnapshotRestore(restore *stork_crd.VolumeSnapshotRestore) error {
+	for _, volumeInfo := range restore.Status.Volumes {
+		d.restored = append(d.restored, volumeInfo.PersistentVolumeClaim)
+	}
+	return nil
+}
+
+func (d *namedDriver) GetPodVolumes(podSpec *v1.PodSpec, namespace string) ([]*storkvolume.Info, error) {
+	ownedSpec := &v1.PodSpec{}
+	for _, volume := range podSpec.Volumes {
+		if volume.PersistentVolumeClaim != nil && d.claims[volume.PersistentVolumeClaim.ClaimName] {
+			ownedSpec.Volumes = append(ownedSpec.Volumes, volume)
+		}
+	}
+	return d.Driver.GetPodVolumes(ownedSpec, namespace)
+}
+
+func newTestDriver(t *testing.T, name string, numNodes int, volumeName string) *namedDriver {
+	d := &namedDriver{
+		Driver:       &mock.Driver{},
+		name:         name,
+		claims:       map[string]bool{volumeName: true},
+		capabilities: map[storkvolume.Capability]bool{},
+	}
+	require.NoError(t, d.CreateCluster(numNodes, &v1.NodeList{}), "Error creating mock cluster")
+	require.NoError(t, d.ProvisionVolume(volumeName, []int{0}, 1), "Error provisioning volume")
+	require.NoError(t, d.AttachVolume(volumeName, numNodes-1), "Error attaching volume")
+	d.NewPVC(volumeName)
+	return d
+}
+
+func TestMultiDriver(t *testing.T) {
+	_, err := New(nil)
+	require.Error(t, err)
+
+	driver1 := newTestDriver(t, "driver1", 2, "vol1")
+	driver2 := newTestDriver(t, "driver2", 3, "vol2")
+	_, err = New([]storkvolume.Driver{driver1, driver1})
+	require.Error(t, err)
+	require.Equal(t, "driver driver1 provided more than once", err.Error())
+
+	d, err := New([]storkvolume.Driver{driver1, driver2})
+	require.NoError(t, err, "Error creating multi driver")
+	require.NoError(t, d.Init(nil), "Error initializing multi driver")
+	require.Equal(t, "driver1,driver2", d.String())
+
+	nodes, err := d.GetNodes()
+	require.NoError(t, err, "Error getting nodes")
+	require.Len(t, nodes, 5)
+	require.Equal(t, "driver1", nodes[0].Driver)
+	require.Equal(t, "driver2", nodes[4].Driver)
+
+	podSpec := &v1.PodSpec{}
+	for _, claim := range []string{"vol1", "vol2"} {
+		podSpec.Volumes = append(podSpec.Volumes, v1.Volume{
+			VolumeSource: v1.VolumeSource{
+				PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: claim},
+			},
+		})
+	}
+	volumes, err := d.GetPodVolumes(podSpec, "default")
+	require.NoError(t, err, "Error getting pod volumes")
+	require.Len(t, volumes, 2)
+	require.Equal(t, "vol1", volumes[0].VolumeID)
+	require.Equal(t, "driver1", volumes[0].Driver)
+	require.Equal(t, "vol2", volumes[1].VolumeID)
+	require.Equal(t, "driver2", volumes[1].Driver)
+
+	volume, err := d.InspectVolume("vol2")
+	require.NoError(t, err, "Error inspecting volume")
+	require.Equal(t, "driver2", volume.Driver)
+
+	// The attached node comes from the driver for the volume
+	attachedNode, err := d.GetVolumeAttachedNode(volume)
+	require.NoError(t, err, "Error getting attached node")
+	require.Equal(t, "node3", attachedNode)
+	volume, err = d.InspectVolume("vol1")
+	require.NoError(t, err, "Error inspecting volume")
+	require.Equal(t, "driver1", volume.Driver)
+	attachedNode, err = d.GetVolumeAttachedNode(volume)
+	require.NoError(t, err, "Error getting attached node")
+	require.Equal(t, "node2", attachedNode)
+
+	volume.Driver = "missing"
+	_, err = d.GetVolumeAttachedNode(volume)
+	require.Error(t, err)
+
+	_, err = d.InspectVolume("missing")
+	require.Error(t, err)
+
+	require.Equal(t, driver1.Capabilities(), d.Capabilities())
+	require.NoError(t, d.Stop(), "Error stopping multi driver")
+}
+
+// The info from the drivers that can be reached is returned by the partial
+// interfaces, while the full interfaces fail if any of the drivers fails
+func TestMultiDriverPartial(t *testing.T) {
+	driver1 := newTestDriver(t, "driver1", 2, "vol1")
+	driver2 := newTestDriver(t, "driver2", 3, "vol2")
+	d, err := New([]storkvolume.Driver{driver1, driver2})
+	require.NoError(t, err, "Error creating multi driver")
+	group, ok := d.(storkvolume.PartialDriverGroup)
+	require.True(t, ok, "Multi driver should return partial results")
+
+	podSpec := &v1.PodSpec{}
+	for _, claim := range []string{"vol1", "vol2"} {
+		podSpec.Volumes = append(podSpec.Volumes, v1.Volume{
+			VolumeSource: v1.VolumeSource{
+				PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: claim},
+			},
+		})
+	}
+
+	driver2.SetInterfaceError(fmt.Errorf("driver2 error"))
+	nodes, errs := group.GetAvailableNodes()
+	require.Len(t, nodes, 2)
+	require.Equal(t, "driver1", nodes[0].Driver)
+	require.Len(t, errs, 1)
+	require.EqualError(t, errs["driver2"], "driver2 error")
+	_, err = d.GetNodes()
+	require.EqualError(t, err, "error getting nodes from driver driver2: driver2 error")
+
+	volumes, errs := group.GetAvailablePodVolumes(podSpec, "default")
+	require.Len(t, volumes, 1)
+	require.Equal(t, "vol1", volumes[0].VolumeID)
+	require.Equal(t, "driver1", volumes[0].Driver)
+	require.Len(t, errs, 1)
+	_, err = d.GetPodVolumes(podSpec, "default")
+	require.EqualError(t, err, "driver2 error")
+
+	driver2.SetInterfaceError(nil)
+	nodes, errs = group.GetAvailableNodes()
+	require.Len(t, nodes, 5)
+	require.Empty(t, errs)
+}
+
+func TestMultiDriverRouting(t *testing.T) {
+	kube := fakekube.NewSimpleClientset()
+	k8s.Instance().SetClient(kube, nil, nil, nil, nil, nil)
+	for _, name := range []string{"vol1", "vol2"} {
+		_, err := kube.CoreV1().PersistentVolumeClaims("default").Create(&v1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		})
+		require.NoError(t, err, "Error creating PVC")
+	}
+
+	driver1 := newTestDriver(t, "driver1", 2, "vol1")
+	driver2 := newTestDriver(t, "driver2", 3, "vol2")
+	driver2.capabilities = map[storkvolume.Capability]bool{
+		storkvolume.CapabilitySnapshots:       true,
+		storkvolume.CapabilitySnapshotRestore: true,
+	}
+	d, err := New([]storkvolume.Driver{driver1, driver2})
+	require.NoError(t, err, "Error creating multi driver")
+
+	// Capabilities from all the drivers are supported and the snapshot plugin
+	// is registered under the name of the driver that supports it
+	require.True(t, d.Capabilities()[storkvolume.CapabilitySnapshotRestore])
+	require.Equal(t, []storkvolume.Driver{driver1, driver2}, storkvolume.GetDrivers(d))
+	plugins := storkvolume.GetSnapshotPlugins(d)
+	require.Len(t, plugins, 1)
+	_, ok := plugins["driver2"]
+	require.True(t, ok, "Snapshot plugin not registered for driver2")
+
+	pvc, err := k8s.Instance().GetPersistentVolumeClaim("vol2", "default")
+	require.NoError(t, err, "Error getting PVC")
+	owner, err := storkvolume.GetPVCDriver(d, pvc)
+	require.NoError(t, err, "Error getting driver for PVC")
+	require.Equal(t, "driver2", owner.String())
+
+	restore := &stork_crd.VolumeSnapshotRestore{}
+	restore.Status.Volumes = []*stork_crd.RestoreVolumeInfo{
+		{PersistentVolumeClaim: "vol2", Namespace: "default"},
+	}
+	require.NoError(t, d.StartVolumeSnapshotRestore(restore), "Error starting restore")
+	require.Equal(t, []string{"vol2"}, driver2.restored)
+	require.Empty(t, driver1.restored)
+
+	// The restore fails if a volume belongs to a driver that doesn't
+	// support restores
+	restore.Status.Volumes = append(restore.Status.Volumes,
+		&stork_crd.RestoreVolumeInfo{PersistentVolumeClaim: "vol1", Namespace: "default"})
+	err = d.StartVolumeSnapshotRestore(restore)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "driver1")
+}
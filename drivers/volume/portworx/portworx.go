@@ -23,6 +23,7 @@ import (
 	clusterclient "github.com/libopenstorage/openstorage/api/client/cluster"
 	volumeclient "github.com/libopenstorage/openstorage/api/client/volume"
 	ost_errors "github.com/libopenstorage/openstorage/api/errors"
+	"github.com/libopenstorage/openstorage/api/spec"
 	"github.com/libopenstorage/openstorage/cluster"
 	"github.com/libopenstorage/openstorage/pkg/auth"
 	auth_secrets "github.com/libopenstorage/openstorage/pkg/auth/secrets"
@@ -124,6 +125,10 @@ const (
 	pxSdkPort      = "px-sdk"
 	pxEnableTLS    = "PX_ENABLE_TLS"
 	pxSharedSecret = "PX_SHARED_SECRET"
+
+	// pxForceDetachOption Option to detach a volume even if the node where
+	// it is attached can't be reached
+	pxForceDetachOption = "FORCE_DETACH"
 )
 
 type cloudSnapStatus struct {
@@ -390,6 +395,9 @@ func (p *portworx) inspectVolume(volDriver volume.VolumeDriver, volumeID string)
 	if vols[0].Source != nil {
 		info.ParentID = vols[0].Source.Parent
 	}
+	if vols[0].Spec != nil {
+		info.Shared = vols[0].Spec.Shared || vols[0].Spec.Sharedv4
+	}
 
 	if len(vols[0].Locator.GetVolumeLabels()) > 0 {
 		info.Labels = vols[0].Locator.GetVolumeLabels()
@@ -483,6 +491,18 @@ func (p *portworx) GetNodes() ([]*storkvolume.NodeInfo, error) {
 		}
 		nodeInfo.IPs = append(nodeInfo.IPs, n.MgmtIp)
 		nodeInfo.IPs = append(nodeInfo.IPs, n.DataIp)
+		for _, pool := range n.Pools {
+			poolInfo := &storkvolume.StoragePoolInfo{
+				ID:            strconv.Itoa(int(pool.ID)),
+				TotalCapacity: pool.TotalSize,
+			}
+			if pool.TotalSize > pool.Used {
+				poolInfo.FreeCapacity = pool.TotalSize - pool.Used
+			}
+			nodeInfo.TotalCapacity += poolInfo.TotalCapacity
+			nodeInfo.FreeCapacity += poolInfo.FreeCapacity
+			nodeInfo.Pools = append(nodeInfo.Pools, poolInfo)
+		}
 
 		labels, err := p.getNodeLabels(nodeInfo)
 		if err == nil {
@@ -504,6 +524,47 @@ func (p *portworx) GetNodes() ([]*storkvolume.NodeInfo, error) {
 	return nodes, nil
 }
 
+func (p *portworx) GetSharedVolumeCoordinator(volumeInfo *storkvolume.Info) (string, error) {
+	if !volumeInfo.Shared {
+		return "", nil
+	}
+	return p.GetVolumeAttachedNode(volumeInfo)
+}
+
+func (p *portworx) GetVolumeAttachedNode(volumeInfo *storkvolume.Info) (string, error) {
+	vol, ok := volumeInfo.VolumeSourceRef.(*api.Volume)
+	if !ok {
+		return "", fmt.Errorf("invalid source for volume %v", volumeInfo.VolumeName)
+	}
+	if vol.AttachedOn == "" {
+		return "", nil
+	}
+
+	// The volume could be attached using the ID or one of the IPs of the node
+	cluster, err := p.clusterManager.Enumerate()
+	if err != nil {
+		return "", &ErrFailedToGetNodes{
+			Cause: err.Error(),
+		}
+	}
+	for _, n := range cluster.Nodes {
+		if n.Id == vol.AttachedOn || n.MgmtIp == vol.AttachedOn || n.DataIp == vol.AttachedOn {
+			return n.Id, nil
+		}
+	}
+	return "", nil
+}
+
+func (p *portworx) ForceDetachVolume(volumeInfo *storkvolume.Info) error {
+	volDriver, err := p.getAdminVolDriver()
+	if err != nil {
+		return err
+	}
+	return volDriver.Detach(volumeInfo.VolumeID, map[string]string{
+		pxForceDetachOption: "true",
+	})
+}
+
 func (p *portworx) GetClusterID() (string, error) {
 	cluster, err := p.clusterManager.Enumerate()
 	if err != nil {
@@ -519,6 +580,17 @@ func (p *portworx) GetClusterID() (string, error) {
 	return cluster.Id, nil
 }
 
+func (p *portworx) Capabilities() map[storkvolume.Capability]bool {
+	return map[storkvolume.Capability]bool{
+		storkvolume.CapabilitySnapshots:       true,
+		storkvolume.CapabilityCloudSnapshots:  true,
+		storkvolume.CapabilityGroupSnapshots:  true,
+		storkvolume.CapabilityMigration:       true,
+		storkvolume.CapabilityClusterDomains:  true,
+		storkvolume.CapabilitySnapshotRestore: true,
+	}
+}
+
 func (p *portworx) OwnsPVC(pvc *v1.PersistentVolumeClaim) bool {
 
 	provisioner := ""
@@ -715,6 +787,9 @@ func (p *portworx) addCloudsnapInfo(
 	request *api.CloudBackupCreateRequest,
 	snap *crdv1.VolumeSnapshot,
 ) {
+	if full, exists := snap.Metadata.Annotations[snapshotcontrollers.SnapshotFullAnnotation]; exists && full == "true" {
+		request.Full = true
+	}
 	if scheduleName, exists := snap.Metadata.Labels[snapshotcontrollers.SnapshotScheduleNameLabel]; exists {
 		if policyType, exists := snap.Metadata.Labels[snapshotcontrollers.SnapshotSchedulePolicyTypeLabel]; exists {
 			request.Labels[cloudBackupExternalManagerLabel] = "Stork-" + scheduleName + "-" + snap.Metadata.Namespace + "-" + policyType
@@ -1016,6 +1091,10 @@ func (p *portworx) SnapshotRestore(
 		restoreVolumeID = restoreVolumeName
 	}
 
+	if err := p.applyRestoreParameters(volDriver, restoreVolumeID, pvc); err != nil {
+		return nil, nil, err
+	}
+
 	// create PV from restored volume
 	vols, err := volDriver.Inspect([]string{restoreVolumeID})
 	if err != nil {
@@ -1044,6 +1123,75 @@ func (p *portworx) SnapshotRestore(
 	return pv, labels, nil
 }
 
+// getRestoreVolumeSpec returns the spec to update a restored volume with the
+// restore parameters. Only the parameters that can be updated on an existing
+// volume are supported.
+func getRestoreVolumeSpec(parameters map[string]string) (*api.VolumeSpec, error) {
+	parsed, _, _, err := spec.NewSpecHandler().SpecFromOpts(parameters)
+	if err != nil {
+		return nil, fmt.Errorf("invalid restore parameters: %v", err)
+	}
+	update := &api.VolumeSpec{}
+	for key, value := range parameters {
+		switch key {
+		case api.SpecHaLevel:
+			if parsed.HaLevel < 1 || parsed.HaLevel > 3 {
+				return nil, fmt.Errorf("invalid value %q for restore parameter %v", value, key)
+			}
+			update.HaLevel = parsed.HaLevel
+		case api.SpecIoProfile:
+			update.IoProfile = parsed.IoProfile
+		case api.SpecPriority:
+			update.Cos = parsed.Cos
+		default:
+			return nil, &errors.ErrNotSupported{
+				Feature: "Restore parameter " + key,
+				Reason:  fmt.Sprintf("only %v, %v and %v can be set for restored volumes", api.SpecHaLevel, api.SpecIoProfile, api.SpecPriority),
+			}
+		}
+	}
+	return update, nil
+}
+
+// PreProvisionRestoreVolume validates the restore parameters for the PVC.
+// Portworx restores snapshots by cloning them or by restoring the cloud backup
+// to a new volume, so the volume can't be created before the restore. The
+// parameters are applied to the restored volume before its PV is created
+// instead.
+func (p *portworx) PreProvisionRestoreVolume(
+	snapshotData *crdv1.VolumeSnapshotData,
+	pvc *v1.PersistentVolumeClaim,
+	parameters map[string]string,
+) error {
+	if snapshotData == nil || snapshotData.Spec.PortworxSnapshot == nil {
+		return fmt.Errorf("invalid Snapshot spec")
+	}
+	_, err := getRestoreVolumeSpec(parameters)
+	return err
+}
+
+// applyRestoreParameters updates the restored volume with the restore
+// parameters set for the PVC, if any
+func (p *portworx) applyRestoreParameters(
+	volDriver volume.VolumeDriver,
+	volumeID string,
+	pvc *v1.PersistentVolumeClaim,
+) error {
+	parameters, err := snapshotcontrollers.GetRestoreParameters(pvc)
+	if err != nil || len(parameters) == 0 {
+		return err
+	}
+	update, err := getRestoreVolumeSpec(parameters)
+	if err != nil {
+		return err
+	}
+	logrus.Infof("Updating restored volume %v for PVC %v/%v with restore parameters %v", volumeID, pvc.Namespace, pvc.Name, parameters)
+	if err := volDriver.Set(volumeID, nil, update); err != nil {
+		return fmt.Errorf("error updating restored volume %v with restore parameters: %v", volumeID, err)
+	}
+	return nil
+}
+
 func (p *portworx) DescribeSnapshot(snapshotData *crdv1.VolumeSnapshotData) (*[]crdv1.VolumeSnapshotCondition, bool /* isCompleted */, error) {
 	var err error
 	if snapshotData == nil || snapshotData.Spec.PortworxSnapshot == nil {
@@ -1624,7 +1772,7 @@ func (p *portworx) CreateGroupSnapshot(snap *stork_crd.GroupVolumeSnapshot) (
 		return nil, err
 	}
 
-	volNames, err := k8sutils.GetVolumeNamesFromLabelSelector(snap.Namespace, snap.Spec.PVCSelector.MatchLabels)
+	volNames, err := k8sutils.GetVolumeNamesFromLabelSelector(k8sutils.GetGroupSnapshotNamespaces(snap), snap.Spec.PVCSelector.MatchLabels)
 	if err != nil {
 		return nil, err
 	}
@@ -1759,6 +1907,48 @@ func (p *portworx) DeactivateClusterDomain(cdu *stork_crd.ClusterDomainUpdate) e
 	return err
 }
 
+func (p *portworx) StartVolumeSnapshotRestore(snapRestore *stork_crd.VolumeSnapshotRestore) error {
+	volDriver, err := p.getUserVolDriver(snapRestore.Annotations)
+	if err != nil {
+		return err
+	}
+
+	for _, volumeInfo := range snapRestore.Status.Volumes {
+		snapshotData, err := k8s.Instance().GetSnapshotData(volumeInfo.SnapshotData)
+		if err != nil {
+			return fmt.Errorf("error getting snapshot data for %v: %v", volumeInfo.Snapshot, err)
+		}
+		if snapshotData.Spec.PortworxSnapshot == nil {
+			return fmt.Errorf("snapshot %v was not created by portworx", volumeInfo.Snapshot)
+		}
+		switch snapshotData.Spec.PortworxSnapshot.SnapshotType {
+		case "", crdv1.PortworxSnapshotTypeLocal:
+		default:
+			return &errors.ErrNotSupported{
+				Feature: "In-place restore",
+				Reason:  "Only supported for local snapshots",
+			}
+		}
+
+		log.VolumeSnapshotRestoreLog(snapRestore).Infof("Restoring volume %v from snapshot %v",
+			volumeInfo.Volume, snapshotData.Spec.PortworxSnapshot.SnapshotID)
+		if err := volDriver.Restore(volumeInfo.Volume, snapshotData.Spec.PortworxSnapshot.SnapshotID); err != nil {
+			volumeInfo.Status = stork_crd.VolumeSnapshotRestoreStatusFailed
+			volumeInfo.Reason = fmt.Sprintf("Error restoring volume: %v", err)
+			continue
+		}
+		volumeInfo.Status = stork_crd.VolumeSnapshotRestoreStatusSuccessful
+		volumeInfo.Reason = "Volume restore completed successfully"
+	}
+	return nil
+}
+
+func (p *portworx) GetVolumeSnapshotRestoreStatus(snapRestore *stork_crd.VolumeSnapshotRestore) error {
+	// The local restore is synchronous so the status would already have been
+	// updated when the restore was started
+	return nil
+}
+
 func (p *portworx) createGroupLocalSnapFromPVCs(groupSnap *stork_crd.GroupVolumeSnapshot, volNames []string, options map[string]string) (
 	*storkvolume.GroupSnapshotCreateResponse, error) {
 	volDriver, err := p.getUserVolDriver(groupSnap.Annotations)
@@ -0,0 +1,214 @@
+package rbd
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/libopenstorage/stork/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// apiMediaType is the versioned media type accepted by the Ceph dashboard
+	// API
+	apiMediaType = "application/vnd.ceph.api.v1.0+json"
+
+	requestTimeout = 30 * time.Second
+)
+
+// errUnauthorized is returned if the token for the API has expired
+var errUnauthorized = fmt.Errorf("unauthorized for Ceph dashboard API")
+
+// osd is an OSD from the Ceph dashboard API
+type osd struct {
+	ID   int     `json:"osd"`
+	Up   int     `json:"up"`
+	In   int     `json:"in"`
+	Host osdHost `json:"host"`
+}
+
+// osdHost is the CRUSH host of an OSD. Rook names the hosts after the nodes
+// running the OSDs.
+type osdHost struct {
+	Name string `json:"name"`
+}
+
+func (o *osd) isAvailable() bool {
+	return o.Up == 1 && o.In == 1
+}
+
+// image is an RBD image from the Ceph dashboard API
+type image struct {
+	Name     string `json:"name"`
+	PoolName string `json:"pool_name"`
+	Size     uint64 `json:"size"`
+}
+
+type monitorStatus struct {
+	MonStatus struct {
+		MonMap struct {
+			FSID string `json:"fsid"`
+		} `json:"monmap"`
+	} `json:"mon_status"`
+}
+
+// apiError is returned by the Ceph dashboard API for failed requests
+type apiError struct {
+	Detail string `json:"detail"`
+}
+
+// client is a client for the REST API of the Ceph dashboard
+type client struct {
+	endpoint   string
+	username   string
+	password   string
+	httpClient *http.Client
+	lock       sync.Mutex
+	token      string
+}
+
+func newClient(endpoint, username, password, caFile string, insecure bool) (*client, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: insecure}
+	if caFile != "" {
+		ca, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading CA file %v: %v", caFile, err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificates found in CA file %v", caFile)
+		}
+	}
+	return &client{
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		username: username,
+		password: password,
+		httpClient: &http.Client{
+			Timeout:   requestTimeout,
+			Transport: &http.Transport{TLSClientConfig: tlsConfig},
+		},
+	}, nil
+}
+
+// login gets a new token for the API
+func (c *client) login() error {
+	request, err := json.Marshal(map[string]string{
+		"username": c.username,
+		"password": c.password,
+	})
+	if err != nil {
+		return err
+	}
+	var response struct {
+		Token string `json:"token"`
+	}
+	if err := c.do(http.MethodPost, "/api/auth", request, "", "", &response); err != nil {
+		return err
+	}
+	c.lock.Lock()
+	c.token = response.Token
+	c.lock.Unlock()
+	return nil
+}
+
+// get decodes the response for the path into out. Returns ErrNotFound with
+// the id and type if the object doesn't exist. The token is renewed once if
+// it has expired.
+func (c *client) get(path string, id string, objectType string, out interface{}) error {
+	err := c.do(http.MethodGet, path, nil, id, objectType, out)
+	if err != errUnauthorized {
+		return err
+	}
+	if err := c.login(); err != nil {
+		return fmt.Errorf("error logging in to Ceph dashboard API: %v", err)
+	}
+	return c.do(http.MethodGet, path, nil, id, objectType, out)
+}
+
+func (c *client) do(method string, path string, body []byte, id string, objectType string, out interface{}) error {
+	req, err := http.NewRequest(method, c.endpoint+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", apiMediaType)
+	req.Header.Set("Content-Type", "application/json")
+	c.lock.Lock()
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	c.lock.Unlock()
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error calling Ceph dashboard API: %v", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			logrus.Warnf("Error closing response from Ceph dashboard API: %v", err)
+		}
+	}()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading response from Ceph dashboard API: %v", err)
+	}
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+	case http.StatusUnauthorized:
+		if path != "/api/auth" {
+			return errUnauthorized
+		}
+		return fmt.Errorf("invalid credentials for Ceph dashboard API")
+	case http.StatusNotFound:
+		return &errors.ErrNotFound{
+			ID:   id,
+			Type: objectType,
+		}
+	default:
+		var apiErr apiError
+		if err := json.Unmarshal(respBody, &apiErr); err == nil && apiErr.Detail != "" {
+			return fmt.Errorf("error from Ceph dashboard API for %v: %v", path, apiErr.Detail)
+		}
+		return fmt.Errorf("error from Ceph dashboard API for %v: %v", path, resp.Status)
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("error parsing response from Ceph dashboard API for %v: %v", path, err)
+	}
+	return nil
+}
+
+func (c *client) getOSDs() ([]osd, error) {
+	var osds []osd
+	if err := c.get("/api/osd", "", "OSD", &osds); err != nil {
+		return nil, err
+	}
+	return osds, nil
+}
+
+// getImage returns the RBD image in the pool
+func (c *client) getImage(pool string, name string) (*image, error) {
+	spec := pool + "/" + name
+	var img image
+	if err := c.get("/api/block/image/"+url.PathEscape(spec), spec, "Volume", &img); err != nil {
+		return nil, err
+	}
+	return &img, nil
+}
+
+// getFSID returns the fsid of the Ceph cluster
+func (c *client) getFSID() (string, error) {
+	var status monitorStatus
+	if err := c.get("/api/monitor", "", "Monitor", &status); err != nil {
+		return "", err
+	}
+	return status.MonStatus.MonMap.FSID, nil
+}
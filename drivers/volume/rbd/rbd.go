@@ -0,0 +1,386 @@
+package rbd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	snapv1 "github.com/kubernetes-incubator/external-storage/snapshot/pkg/apis/crd/v1"
+	snapshotVolume "github.com/kubernetes-incubator/external-storage/snapshot/pkg/volume"
+	storkvolume "github.com/libopenstorage/stork/drivers/volume"
+	"github.com/libopenstorage/stork/pkg/errors"
+	"github.com/portworx/sched-ops/k8s"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	k8shelper "k8s.io/kubernetes/pkg/apis/core/v1/helper"
+	kubeletapis "k8s.io/kubernetes/pkg/kubelet/apis"
+)
+
+const (
+	// driverName is the name of the RBD driver
+	driverName = "rbd"
+	// dashboardURLEnv is the environment variable with the URL of the Ceph
+	// dashboard. Defaults to the dashboard service created by Rook.
+	dashboardURLEnv = "CEPH_DASHBOARD_URL"
+	// dashboardUsernameEnv is the environment variable with the user for the
+	// Ceph dashboard. Defaults to admin.
+	dashboardUsernameEnv = "CEPH_DASHBOARD_USERNAME"
+	// dashboardPasswordEnv is the environment variable with the password for
+	// the Ceph dashboard. Read from the secret created by Rook if it isn't
+	// set.
+	dashboardPasswordEnv = "CEPH_DASHBOARD_PASSWORD"
+	// dashboardCAFileEnv is the environment variable with the path to the CA
+	// certificates used to verify the Ceph dashboard
+	dashboardCAFileEnv = "CEPH_DASHBOARD_CA_FILE"
+	// dashboardInsecureEnv is the environment variable to skip verifying the
+	// certificate of the Ceph dashboard, which is self-signed by default
+	dashboardInsecureEnv = "CEPH_DASHBOARD_INSECURE"
+	// rookNamespaceEnv is the environment variable with the namespace of the
+	// Rook cluster. Defaults to rook-ceph.
+	rookNamespaceEnv = "ROOK_NAMESPACE"
+
+	defaultRookNamespace    = "rook-ceph"
+	defaultDashboardUser    = "admin"
+	dashboardPasswordSecret = "rook-ceph-dashboard-password"
+	dashboardPasswordKey    = "password"
+
+	// pvcProvisionerAnnotation is the annotation on PVCs with the provisioner
+	// name
+	pvcProvisionerAnnotation = "volume.beta.kubernetes.io/storage-provisioner"
+	// nodeIDAnnotation is the annotation on nodes with the IDs for the CSI
+	// drivers that are registered on the node, encoded as a json map
+	nodeIDAnnotation = "csi.volume.kubernetes.io/nodeid"
+	// rackLabel is the label used by Rook for the rack of a node
+	rackLabel = "topology.rook.io/rack"
+
+	// csiDriverName is the name of the Ceph CSI RBD driver. Rook prefixes it
+	// with the namespace of the cluster.
+	csiDriverName = "rbd.csi.ceph.com"
+	// poolAttribute and imageNameAttribute are the volume attributes of the
+	// PVs with the pool and name of the RBD image
+	poolAttribute      = "pool"
+	imageNameAttribute = "imageName"
+	// imagePrefix is the prefix for the names of images created by the Ceph
+	// CSI driver, followed by the UUID at the end of the volume handle
+	imagePrefix = "csi-vol-"
+	uuidLength  = 36
+)
+
+// rbd is the driver for RBD volumes provisioned by the Ceph CSI driver in
+// clusters managed by Rook. The data of an RBD image is striped across the
+// OSDs of its pool, so the data nodes of a volume are the nodes running OSDs
+// that are up and in. The storage IDs of the nodes are the node names.
+type rbd struct {
+	storkvolume.ClusterPairNotSupported
+	storkvolume.MigrationNotSupported
+	storkvolume.GroupSnapshotNotSupported
+	storkvolume.ClusterDomainsNotSupported
+	storkvolume.SnapshotRestoreNotSupported
+	storkvolume.SharedVolumeNotSupported
+	storkvolume.AttachmentNotSupported
+	storkvolume.RestoreProvisionNotSupported
+	client *client
+}
+
+func (r *rbd) String() string {
+	return driverName
+}
+
+func (r *rbd) Init(_ interface{}) error {
+	namespace := os.Getenv(rookNamespaceEnv)
+	if namespace == "" {
+		namespace = defaultRookNamespace
+	}
+	endpoint := os.Getenv(dashboardURLEnv)
+	if endpoint == "" {
+		endpoint = "https://rook-ceph-mgr-dashboard." + namespace + ".svc:8443"
+	}
+	username := os.Getenv(dashboardUsernameEnv)
+	if username == "" {
+		username = defaultDashboardUser
+	}
+	password := os.Getenv(dashboardPasswordEnv)
+	if password == "" {
+		secret, err := k8s.Instance().GetSecret(dashboardPasswordSecret, namespace)
+		if err != nil {
+			return fmt.Errorf("error getting password for Ceph dashboard: %v", err)
+		}
+		password = string(secret.Data[dashboardPasswordKey])
+	}
+	insecure := false
+	if value := os.Getenv(dashboardInsecureEnv); value != "" {
+		var err error
+		if insecure, err = strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("invalid value %v for %v: %v", value, dashboardInsecureEnv, err)
+		}
+	}
+
+	client, err := newClient(endpoint, username, password, os.Getenv(dashboardCAFileEnv), insecure)
+	if err != nil {
+		return err
+	}
+	r.client = client
+	if _, err := r.client.getOSDs(); err != nil {
+		return fmt.Errorf("error connecting to Ceph dashboard at %v: %v", endpoint, err)
+	}
+	return nil
+}
+
+func (r *rbd) Stop() error {
+	return nil
+}
+
+// Capabilities returns no capabilities since snapshots of RBD volumes can't
+// be stored by the snapshotter
+func (r *rbd) Capabilities() map[storkvolume.Capability]bool {
+	return map[storkvolume.Capability]bool{}
+}
+
+// isRBDDriver returns true for the names of the Ceph CSI RBD driver
+func isRBDDriver(name string) bool {
+	return name == csiDriverName || strings.HasSuffix(name, "."+csiDriverName)
+}
+
+// InspectVolume returns the info for the RBD image with the volumeID as
+// <pool>/<image>
+func (r *rbd) InspectVolume(volumeID string) (*storkvolume.Info, error) {
+	parts := strings.SplitN(volumeID, "/", 2)
+	if len(parts) != 2 {
+		return nil, &errors.ErrNotFound{
+			ID:   volumeID,
+			Type: "Volume",
+		}
+	}
+	img, err := r.client.getImage(parts[0], parts[1])
+	if err != nil {
+		return nil, err
+	}
+	dataNodes, err := r.getOSDNodes()
+	if err != nil {
+		return nil, err
+	}
+	return &storkvolume.Info{
+		VolumeID:   volumeID,
+		VolumeName: img.Name,
+		DataNodes:  dataNodes,
+		Size:       img.Size / (1024 * 1024 * 1024),
+	}, nil
+}
+
+// getOSDNodes returns the names of the nodes running OSDs that are up and in.
+// Rook names the CRUSH hosts after the hostname label of the nodes, with
+// dots replaced by dashes.
+func (r *rbd) getOSDNodes() ([]string, error) {
+	osds, err := r.client.getOSDs()
+	if err != nil {
+		return nil, err
+	}
+	hosts := make(map[string]bool)
+	for _, o := range osds {
+		if o.isAvailable() {
+			hosts[crushHostName(o.Host.Name)] = true
+		}
+	}
+
+	nodes, err := k8s.Instance().GetNodes()
+	if err != nil {
+		return nil, err
+	}
+	var osdNodes []string
+	for _, node := range nodes.Items {
+		if hosts[crushHostName(node.Name)] || hosts[crushHostName(node.Labels[kubeletapis.LabelHostname])] {
+			osdNodes = append(osdNodes, node.Name)
+		}
+	}
+	return osdNodes, nil
+}
+
+func crushHostName(name string) string {
+	return strings.ToLower(strings.Replace(name, ".", "-", -1))
+}
+
+// GetNodes returns the Kubernetes nodes. Nodes are online if they are ready
+// and the Ceph CSI RBD driver is registered on them, since any of them can
+// access the data of the volumes over the network.
+func (r *rbd) GetNodes() ([]*storkvolume.NodeInfo, error) {
+	nodes, err := k8s.Instance().GetNodes()
+	if err != nil {
+		return nil, err
+	}
+
+	var nodeInfos []*storkvolume.NodeInfo
+	for _, node := range nodes.Items {
+		nodeInfo := &storkvolume.NodeInfo{
+			StorageID:   node.Name,
+			SchedulerID: node.Name,
+			Hostname:    strings.ToLower(node.Name),
+			Rack:        node.Labels[rackLabel],
+			Zone:        node.Labels[kubeletapis.LabelZoneFailureDomain],
+			Region:      node.Labels[kubeletapis.LabelZoneRegion],
+			Status:      storkvolume.NodeOffline,
+		}
+		for _, address := range node.Status.Addresses {
+			switch address.Type {
+			case v1.NodeHostName:
+				nodeInfo.Hostname = strings.ToLower(address.Address)
+			case v1.NodeInternalIP:
+				nodeInfo.IPs = append(nodeInfo.IPs, address.Address)
+			}
+		}
+		if isNodeReady(&node) && isDriverRegistered(&node) {
+			nodeInfo.Status = storkvolume.NodeOnline
+		}
+		nodeInfos = append(nodeInfos, nodeInfo)
+	}
+	return nodeInfos, nil
+}
+
+func isNodeReady(node *v1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == v1.NodeReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// isDriverRegistered returns true if the Ceph CSI RBD driver is registered on
+// the node
+func isDriverRegistered(node *v1.Node) bool {
+	nodeIDs := make(map[string]string)
+	if annotation, ok := node.Annotations[nodeIDAnnotation]; ok {
+		if err := json.Unmarshal([]byte(annotation), &nodeIDs); err != nil {
+			logrus.Warnf("Error parsing CSI node IDs for node %v: %v", node.Name, err)
+			return false
+		}
+	}
+	for name := range nodeIDs {
+		if isRBDDriver(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// OwnsPVC returns true if the PVC is provisioned by the Ceph CSI RBD driver
+func (r *rbd) OwnsPVC(pvc *v1.PersistentVolumeClaim) bool {
+	if pvc.Spec.VolumeName == "" {
+		provisioner := pvc.Annotations[pvcProvisionerAnnotation]
+		if provisioner == "" {
+			storageClassName := k8shelper.GetPersistentVolumeClaimClass(pvc)
+			if storageClassName == "" {
+				return false
+			}
+			storageClass, err := k8s.Instance().GetStorageClass(storageClassName)
+			if err != nil {
+				logrus.Warnf("Error getting storageclass %v for pvc %v: %v", storageClassName, pvc.Name, err)
+				return false
+			}
+			provisioner = storageClass.Provisioner
+		}
+		return isRBDDriver(provisioner)
+	}
+
+	pv, err := k8s.Instance().GetPersistentVolume(pvc.Spec.VolumeName)
+	if err != nil {
+		logrus.Warnf("Error getting pv %v for pvc %v: %v", pvc.Spec.VolumeName, pvc.Name, err)
+		return false
+	}
+	return pv.Spec.CSI != nil && isRBDDriver(pv.Spec.CSI.Driver)
+}
+
+// getVolumeID returns <pool>/<image> for the PV. The image name is only set
+// in the volume attributes by newer versions of the Ceph CSI driver, so it is
+// derived from the volume handle otherwise.
+func getVolumeID(pv *v1.PersistentVolume) (string, error) {
+	csi := pv.Spec.CSI
+	pool := csi.VolumeAttributes[poolAttribute]
+	if pool == "" {
+		return "", fmt.Errorf("pool not found in volume attributes of PV %v", pv.Name)
+	}
+	imageName := csi.VolumeAttributes[imageNameAttribute]
+	if imageName == "" {
+		if len(csi.VolumeHandle) < uuidLength {
+			return "", fmt.Errorf("invalid volume handle %v for PV %v", csi.VolumeHandle, pv.Name)
+		}
+		imageName = imagePrefix + csi.VolumeHandle[len(csi.VolumeHandle)-uuidLength:]
+	}
+	return pool + "/" + imageName, nil
+}
+
+func (r *rbd) GetPodVolumes(podSpec *v1.PodSpec, namespace string) ([]*storkvolume.Info, error) {
+	var volumes []*storkvolume.Info
+	for _, volume := range podSpec.Volumes {
+		if volume.PersistentVolumeClaim == nil {
+			continue
+		}
+		pvc, err := k8s.Instance().GetPersistentVolumeClaim(
+			volume.PersistentVolumeClaim.ClaimName,
+			namespace)
+		if err != nil {
+			return nil, err
+		}
+
+		if !r.OwnsPVC(pvc) {
+			continue
+		}
+
+		if pvc.Status.Phase == v1.ClaimPending {
+			return nil, &storkvolume.ErrPVCPending{
+				Name: volume.PersistentVolumeClaim.ClaimName,
+			}
+		}
+
+		pv, err := k8s.Instance().GetPersistentVolume(pvc.Spec.VolumeName)
+		if err != nil {
+			return nil, err
+		}
+		volumeID, err := getVolumeID(pv)
+		if err != nil {
+			return nil, err
+		}
+		volumeInfo, err := r.InspectVolume(volumeID)
+		if err != nil {
+			return nil, err
+		}
+		volumeInfo.VolumeName = pv.Name
+		volumeInfo.Labels = pv.Labels
+		volumeInfo.VolumeSourceRef = pv
+		volumes = append(volumes, volumeInfo)
+	}
+	return volumes, nil
+}
+
+func (r *rbd) GetVolumeClaimTemplates(templates []v1.PersistentVolumeClaim) (
+	[]v1.PersistentVolumeClaim, error) {
+	var rbdTemplates []v1.PersistentVolumeClaim
+	for _, t := range templates {
+		if r.OwnsPVC(&t) {
+			rbdTemplates = append(rbdTemplates, t)
+		}
+	}
+	return rbdTemplates, nil
+}
+
+// GetSnapshotPlugin returns nil since the snapshotter has no data source for
+// RBD snapshots
+func (r *rbd) GetSnapshotPlugin() snapshotVolume.Plugin {
+	return nil
+}
+
+func (r *rbd) GetSnapshotType(snap *snapv1.VolumeSnapshot) (string, error) {
+	return "", &errors.ErrNotSupported{}
+}
+
+// GetClusterID returns the fsid of the Ceph cluster
+func (r *rbd) GetClusterID() (string, error) {
+	return r.client.getFSID()
+}
+
+func init() {
+	if err := storkvolume.Register(driverName, &rbd{}); err != nil {
+		logrus.Panicf("Error registering rbd volume driver: %v", err)
+	}
+}
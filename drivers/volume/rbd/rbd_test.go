@@ -0,0 +1,251 @@
+// +build unittest
+
+package rbd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	storkvolume "github.com/libopenstorage/stork/drivers/volume"
+	"github.com/portworx/sched-ops/k8s"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakekube "k8s.io/client-go/kubernetes/fake"
+)
+
+const (
+	testToken = "token1"
+	testOSDs  = `[
+		{"osd": 0, "up": 1, "in": 1, "host": {"name": "node1-example-com"}},
+		{"osd": 1, "up": 1, "in": 1, "host": {"name": "node2"}},
+		{"osd": 2, "up": 0, "in": 1, "host": {"name": "node3"}}
+	]`
+	testImage   = `{"name": "csi-vol-0cc7a3ae-86ac-11e9-9a4d-0a580ae9402d", "pool_name": "replicapool", "size": 10737418240}`
+	testMonitor = `{"mon_status": {"monmap": {"fsid": "fsid-1"}}}`
+	testHandle  = "0001-0009-rook-ceph-0000000000000001-0cc7a3ae-86ac-11e9-9a4d-0a580ae9402d"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/auth", func(w http.ResponseWriter, r *http.Request) {
+		var credentials map[string]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&credentials), "Error decoding credentials")
+		if credentials["username"] != "admin" || credentials["password"] != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"token": "%v"}`, testToken)
+	})
+	authorized := func(handler http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer "+testToken {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			require.Equal(t, apiMediaType, r.Header.Get("Accept"))
+			handler(w, r)
+		}
+	}
+	mux.HandleFunc("/api/osd", authorized(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, testOSDs)
+	}))
+	mux.HandleFunc("/api/block/image/replicapool/csi-vol-0cc7a3ae-86ac-11e9-9a4d-0a580ae9402d",
+		authorized(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, testImage)
+		}))
+	mux.HandleFunc("/api/block/image/replicapool/failed", authorized(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, `{"detail": "RBD error", "code": "2"}`)
+	}))
+	mux.HandleFunc("/api/monitor", authorized(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, testMonitor)
+	}))
+	return httptest.NewServer(mux)
+}
+
+func newTestNode(name string, hostname string, ready bool, drivers string) *v1.Node {
+	status := v1.ConditionFalse
+	if ready {
+		status = v1.ConditionTrue
+	}
+	return &v1.Node{
+		ObjectMeta: meta.ObjectMeta{
+			Name:        name,
+			Labels:      map[string]string{"kubernetes.io/hostname": hostname, rackLabel: "rack1"},
+			Annotations: map[string]string{nodeIDAnnotation: drivers},
+		},
+		Status: v1.NodeStatus{
+			Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: status}},
+			Addresses: []v1.NodeAddress{
+				{Type: v1.NodeHostName, Address: hostname},
+				{Type: v1.NodeInternalIP, Address: "10.0.0.1"},
+			},
+		},
+	}
+}
+
+func newTestPV(name string, driver string, attributes map[string]string) *v1.PersistentVolume {
+	return &v1.PersistentVolume{
+		ObjectMeta: meta.ObjectMeta{Name: name},
+		Spec: v1.PersistentVolumeSpec{
+			PersistentVolumeSource: v1.PersistentVolumeSource{
+				CSI: &v1.CSIPersistentVolumeSource{
+					Driver:           driver,
+					VolumeHandle:     testHandle,
+					VolumeAttributes: attributes,
+				},
+			},
+		},
+	}
+}
+
+func newTestPVC(name string, volumeName string, storageClass string) *v1.PersistentVolumeClaim {
+	phase := v1.ClaimBound
+	if volumeName == "" {
+		phase = v1.ClaimPending
+	}
+	return &v1.PersistentVolumeClaim{
+		ObjectMeta: meta.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: v1.PersistentVolumeClaimSpec{
+			VolumeName:       volumeName,
+			StorageClassName: &storageClass,
+		},
+		Status: v1.PersistentVolumeClaimStatus{Phase: phase},
+	}
+}
+
+func setupTestDriver(t *testing.T, server *httptest.Server) *rbd {
+	client := fakekube.NewSimpleClientset(
+		newTestNode("node1", "node1.example.com", true, `{"rook-ceph.rbd.csi.ceph.com": "node1"}`),
+		newTestNode("node2", "node2", true, `{"other.csi.com": "node2"}`),
+		newTestNode("node3", "node3", true, `{"rook-ceph.rbd.csi.ceph.com": "node3"}`),
+		newTestNode("node4", "node4", false, `{"rook-ceph.rbd.csi.ceph.com": "node4"}`),
+		newTestPV("pv1", "rook-ceph.rbd.csi.ceph.com", map[string]string{poolAttribute: "replicapool"}),
+		newTestPV("pv2", "other.csi.com", nil),
+		newTestPVC("pvc1", "pv1", "rbd-sc"),
+		newTestPVC("pvc2", "pv2", "other-sc"),
+		newTestPVC("pending", "", "rbd-sc"),
+		&storagev1.StorageClass{
+			ObjectMeta:  meta.ObjectMeta{Name: "rbd-sc"},
+			Provisioner: "rook-ceph.rbd.csi.ceph.com",
+		},
+		&v1.Secret{
+			ObjectMeta: meta.ObjectMeta{Name: dashboardPasswordSecret, Namespace: defaultRookNamespace},
+			Data:       map[string][]byte{dashboardPasswordKey: []byte("secret")},
+		},
+	)
+	k8s.Instance().SetClient(client, nil, nil, nil, nil, nil)
+
+	require.NoError(t, os.Setenv(dashboardURLEnv, server.URL), "Error setting dashboard URL")
+	d := &rbd{}
+	require.NoError(t, d.Init(nil), "Error initializing driver")
+	return d
+}
+
+func TestRBDInit(t *testing.T) {
+	defer os.Unsetenv(dashboardURLEnv)
+	defer os.Unsetenv(dashboardPasswordEnv)
+	server := newTestServer(t)
+	defer server.Close()
+	setupTestDriver(t, server)
+
+	require.NoError(t, os.Setenv(dashboardPasswordEnv, "invalid"), "Error setting password")
+	d := &rbd{}
+	err := d.Init(nil)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid credentials for Ceph dashboard API")
+
+	require.NoError(t, os.Setenv(dashboardURLEnv, "http://127.0.0.1:1"), "Error setting dashboard URL")
+	require.Error(t, d.Init(nil))
+}
+
+func TestRBDGetNodes(t *testing.T) {
+	defer os.Unsetenv(dashboardURLEnv)
+	server := newTestServer(t)
+	defer server.Close()
+	d := setupTestDriver(t, server)
+
+	nodes, err := d.GetNodes()
+	require.NoError(t, err, "Error getting nodes")
+	require.Len(t, nodes, 4)
+	require.Equal(t, &storkvolume.NodeInfo{
+		StorageID:   "node1",
+		SchedulerID: "node1",
+		Hostname:    "node1.example.com",
+		IPs:         []string{"10.0.0.1"},
+		Rack:        "rack1",
+		Status:      storkvolume.NodeOnline,
+	}, nodes[0])
+	// Nodes without the RBD driver or that aren't ready are offline
+	require.Equal(t, storkvolume.NodeOffline, nodes[1].Status)
+	require.Equal(t, storkvolume.NodeOnline, nodes[2].Status)
+	require.Equal(t, storkvolume.NodeOffline, nodes[3].Status)
+
+	clusterID, err := d.GetClusterID()
+	require.NoError(t, err, "Error getting cluster ID")
+	require.Equal(t, "fsid-1", clusterID)
+}
+
+func TestRBDGetPodVolumes(t *testing.T) {
+	defer os.Unsetenv(dashboardURLEnv)
+	server := newTestServer(t)
+	defer server.Close()
+	d := setupTestDriver(t, server)
+
+	podSpec := &v1.PodSpec{}
+	for _, claim := range []string{"pvc1", "pvc2"} {
+		podSpec.Volumes = append(podSpec.Volumes, v1.Volume{
+			VolumeSource: v1.VolumeSource{
+				PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: claim},
+			},
+		})
+	}
+	volumes, err := d.GetPodVolumes(podSpec, "default")
+	require.NoError(t, err, "Error getting pod volumes")
+	require.Len(t, volumes, 1)
+	// Only the nodes with OSDs that are up and in have the data
+	require.Equal(t, "replicapool/csi-vol-0cc7a3ae-86ac-11e9-9a4d-0a580ae9402d", volumes[0].VolumeID)
+	require.Equal(t, "pv1", volumes[0].VolumeName)
+	require.Equal(t, []string{"node1", "node2"}, volumes[0].DataNodes)
+	require.Equal(t, uint64(10), volumes[0].Size)
+
+	podSpec.Volumes = append(podSpec.Volumes, v1.Volume{
+		VolumeSource: v1.VolumeSource{
+			PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: "pending"},
+		},
+	})
+	_, err = d.GetPodVolumes(podSpec, "default")
+	require.Equal(t, &storkvolume.ErrPVCPending{Name: "pending"}, err)
+}
+
+func TestRBDInspectVolume(t *testing.T) {
+	defer os.Unsetenv(dashboardURLEnv)
+	server := newTestServer(t)
+	defer server.Close()
+	d := setupTestDriver(t, server)
+
+	_, err := d.InspectVolume("replicapool/missing")
+	require.Error(t, err)
+	require.Equal(t, "Volume with UID/Name: replicapool/missing not found", err.Error())
+
+	_, err = d.InspectVolume("missing")
+	require.Error(t, err)
+	require.Equal(t, "Volume with UID/Name: missing not found", err.Error())
+
+	_, err = d.InspectVolume("replicapool/failed")
+	require.Error(t, err)
+	require.Equal(t, "error from Ceph dashboard API for /api/block/image/replicapool%2Ffailed: RBD error", err.Error())
+
+	// The token is renewed when it expires
+	d.client.token = "expired"
+	info, err := d.InspectVolume("replicapool/csi-vol-0cc7a3ae-86ac-11e9-9a4d-0a580ae9402d")
+	require.NoError(t, err, "Error inspecting volume")
+	require.Equal(t, "csi-vol-0cc7a3ae-86ac-11e9-9a4d-0a580ae9402d", info.VolumeName)
+}
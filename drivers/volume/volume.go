@@ -1,6 +1,7 @@
 package volume
 
 import (
+	"fmt"
 	"net"
 	"strings"
 
@@ -58,6 +59,10 @@ type Driver interface {
 	// GetClusterID returns the clusterID for the driver
 	GetClusterID() (string, error)
 
+	// Capabilities returns the optional operations that are supported by the
+	// driver
+	Capabilities() map[Capability]bool
+
 	// GroupSnapshotPluginInterface Interface for group snapshots
 	GroupSnapshotPluginInterface
 	// ClusterPairPluginInterface Interface to pair clusters
@@ -66,6 +71,112 @@ type Driver interface {
 	MigratePluginInterface
 	// ClusterDomainsPluginInterface Interface to manage cluster domains
 	ClusterDomainsPluginInterface
+	// SnapshotRestorePluginInterface Interface to restore volumes in-place
+	// from snapshots
+	SnapshotRestorePluginInterface
+	// SharedVolumePluginInterface Interface to get information about shared
+	// volumes
+	SharedVolumePluginInterface
+	// AttachmentPluginInterface Interface to manage the attachment of volumes
+	// to nodes
+	AttachmentPluginInterface
+	// RestoreProvisionPluginInterface Interface to provision the volumes that
+	// snapshots are restored to
+	RestoreProvisionPluginInterface
+}
+
+// Capability is an optional operation that can be supported by a driver
+type Capability string
+
+const (
+	// CapabilitySnapshots is the capability to create snapshots using the
+	// snapshot plugin
+	CapabilitySnapshots Capability = "Snapshots"
+	// CapabilityCloudSnapshots is the capability to back up snapshots to an
+	// objectstore in the cloud
+	CapabilityCloudSnapshots Capability = "CloudSnapshots"
+	// CapabilityGroupSnapshots is the capability to create consistent
+	// snapshots of a group of volumes
+	CapabilityGroupSnapshots Capability = "GroupSnapshots"
+	// CapabilityMigration is the capability to pair clusters and migrate
+	// volumes between them
+	CapabilityMigration Capability = "Migration"
+	// CapabilityClusterDomains is the capability to manage cluster domains
+	// for metro DR
+	CapabilityClusterDomains Capability = "ClusterDomains"
+	// CapabilitySnapshotRestore is the capability to restore volumes in-place
+	// from snapshots
+	CapabilitySnapshotRestore Capability = "SnapshotRestore"
+)
+
+// CheckCapability returns ErrNotSupported if the driver doesn't support the
+// capability. Used by the controllers to fail before using the plugin
+// interfaces for the capability.
+func CheckCapability(d Driver, capability Capability) error {
+	if d.Capabilities()[capability] {
+		return nil
+	}
+	return &errors.ErrNotSupported{
+		Feature: string(capability),
+		Reason:  fmt.Sprintf("volume driver %v doesn't support it", d.String()),
+	}
+}
+
+// DriverGroup is implemented by drivers that are made up of multiple drivers
+type DriverGroup interface {
+	// Drivers returns the drivers in the group
+	Drivers() []Driver
+}
+
+// PartialDriverGroup is implemented by groups of drivers that can return the
+// nodes and volumes from the drivers that could be reached when some of the
+// drivers fail. The errors are keyed by the name of the driver.
+type PartialDriverGroup interface {
+	DriverGroup
+	// GetAvailableNodes returns the nodes from the drivers that could be
+	// reached and the errors from the other drivers
+	GetAvailableNodes() ([]*NodeInfo, map[string]error)
+	// GetAvailablePodVolumes returns the volumes for the pod from the drivers
+	// that could be reached and the errors from the other drivers
+	GetAvailablePodVolumes(podSpec *v1.PodSpec, namespace string) ([]*Info, map[string]error)
+}
+
+// GetDrivers returns the drivers that the driver is made up of, or the driver
+// itself if it isn't a group of drivers
+func GetDrivers(d Driver) []Driver {
+	if group, ok := d.(DriverGroup); ok {
+		return group.Drivers()
+	}
+	return []Driver{d}
+}
+
+// GetPVCDriver returns the driver that owns the PVC. For a group of drivers
+// this is the driver from the group that owns the PVC.
+func GetPVCDriver(d Driver, pvc *v1.PersistentVolumeClaim) (Driver, error) {
+	for _, driver := range GetDrivers(d) {
+		if driver.OwnsPVC(pvc) {
+			return driver, nil
+		}
+	}
+	return nil, &errors.ErrNotFound{
+		ID:   pvc.Namespace + "/" + pvc.Name,
+		Type: "VolumeDriver for PVC",
+	}
+}
+
+// GetSnapshotPlugins returns the snapshot plugins for the drivers that
+// support snapshots, keyed by the name of each driver so that snapshots are
+// handled by the plugin for the volume type of the PV
+func GetSnapshotPlugins(d Driver) map[string]snapshotVolume.Plugin {
+	plugins := make(map[string]snapshotVolume.Plugin)
+	for _, driver := range GetDrivers(d) {
+		if err := CheckCapability(driver, CapabilitySnapshots); err != nil {
+			logrus.Warnf("Not registering snapshot plugin: %v", err)
+			continue
+		}
+		plugins[driver.String()] = driver.GetSnapshotPlugin()
+	}
+	return plugins
 }
 
 // GroupSnapshotCreateResponse is the response for the group snapshot operation
@@ -116,6 +227,49 @@ type ClusterDomainsPluginInterface interface {
 	DeactivateClusterDomain(*stork_crd.ClusterDomainUpdate) error
 }
 
+// SnapshotRestorePluginInterface Interface to restore volumes in-place from
+// snapshots
+type SnapshotRestorePluginInterface interface {
+	// StartVolumeSnapshotRestore starts the in-place restore of the volumes
+	// specified in the status of the restore spec
+	StartVolumeSnapshotRestore(*stork_crd.VolumeSnapshotRestore) error
+	// GetVolumeSnapshotRestoreStatus updates the status of the volumes being
+	// restored in the status of the restore spec
+	GetVolumeSnapshotRestoreStatus(*stork_crd.VolumeSnapshotRestore) error
+}
+
+// SharedVolumePluginInterface Interface to get information about shared
+// (ReadWriteMany) volumes
+type SharedVolumePluginInterface interface {
+	// GetSharedVolumeCoordinator returns the storage ID of the node that is
+	// serving the shared volume to the pods using it. Returns an empty string
+	// if the volume isn't shared or isn't being served from any node
+	GetSharedVolumeCoordinator(*Info) (string, error)
+}
+
+// AttachmentPluginInterface Interface to manage the attachment of volumes to
+// nodes
+type AttachmentPluginInterface interface {
+	// GetVolumeAttachedNode returns the storage ID of the node where the
+	// volume is attached. Returns an empty string if the volume isn't attached
+	GetVolumeAttachedNode(*Info) (string, error)
+	// ForceDetachVolume detaches the volume from the node where it is
+	// attached, even if the node can't be reached
+	ForceDetachVolume(*Info) error
+}
+
+// RestoreProvisionPluginInterface Interface to provision the volumes that
+// snapshots are restored to with parameters set for the restore
+type RestoreProvisionPluginInterface interface {
+	// PreProvisionRestoreVolume is called before the snapshot is restored to a
+	// new volume for the PVC when restore parameters are set for it, for eg
+	// the replication factor, IO profile or encryption. The driver should
+	// provision the volume with those parameters instead of the settings of
+	// the source volume, or return an error if they can't be applied so that
+	// the restore fails before any data is restored.
+	PreProvisionRestoreVolume(*snapv1.VolumeSnapshotData, *v1.PersistentVolumeClaim, map[string]string) error
+}
+
 // Info Information about a volume
 type Info struct {
 	// VolumeID is a unique identifier for the volume
@@ -130,8 +284,14 @@ type Info struct {
 	ParentID string
 	// Labels are user applied labels on the volume
 	Labels map[string]string
+	// Shared is true if the volume can be accessed from multiple nodes at the
+	// same time
+	Shared bool
 	// VolumeSourceRef is a optional reference to the source of the volume
 	VolumeSourceRef interface{}
+	// Driver is the name of the driver for the volume. Only set when
+	// multiple drivers are used
+	Driver string
 }
 
 // NodeStatus Status of driver on a node
@@ -165,6 +325,28 @@ type NodeInfo struct {
 	Region string
 	// Status of the node
 	Status NodeStatus
+	// TotalCapacity is the total capacity in bytes of the storage pools on
+	// the node. Set to 0 if the driver doesn't report capacity
+	TotalCapacity uint64
+	// FreeCapacity is the capacity in bytes that is available in the storage
+	// pools on the node
+	FreeCapacity uint64
+	// Pools are the storage pools on the node. Empty if the driver doesn't
+	// report pools
+	Pools []*StoragePoolInfo
+	// Driver is the name of the driver running on the node. Only set when
+	// multiple drivers are used
+	Driver string
+}
+
+// StoragePoolInfo Information about a storage pool on a node
+type StoragePoolInfo struct {
+	// ID of the pool on the node
+	ID string
+	// TotalCapacity is the total capacity of the pool in bytes
+	TotalCapacity uint64
+	// FreeCapacity is the capacity in bytes that is available in the pool
+	FreeCapacity uint64
 }
 
 var (
@@ -265,6 +447,56 @@ func (c *ClusterDomainsNotSupported) DeactivateClusterDomain(*stork_crd.ClusterD
 	return &errors.ErrNotSupported{}
 }
 
+// SnapshotRestoreNotSupported to be used by drivers that don't support
+// in-place restore of volumes
+type SnapshotRestoreNotSupported struct{}
+
+// StartVolumeSnapshotRestore returns ErrNotSupported
+func (s *SnapshotRestoreNotSupported) StartVolumeSnapshotRestore(*stork_crd.VolumeSnapshotRestore) error {
+	return &errors.ErrNotSupported{}
+}
+
+// GetVolumeSnapshotRestoreStatus returns ErrNotSupported
+func (s *SnapshotRestoreNotSupported) GetVolumeSnapshotRestoreStatus(*stork_crd.VolumeSnapshotRestore) error {
+	return &errors.ErrNotSupported{}
+}
+
+// SharedVolumeNotSupported to be used by drivers that don't support shared
+// volumes
+type SharedVolumeNotSupported struct{}
+
+// GetSharedVolumeCoordinator returns ErrNotSupported
+func (s *SharedVolumeNotSupported) GetSharedVolumeCoordinator(*Info) (string, error) {
+	return "", &errors.ErrNotSupported{}
+}
+
+// AttachmentNotSupported to be used by drivers that don't support managing
+// the attachment of volumes
+type AttachmentNotSupported struct{}
+
+// GetVolumeAttachedNode returns ErrNotSupported
+func (a *AttachmentNotSupported) GetVolumeAttachedNode(*Info) (string, error) {
+	return "", &errors.ErrNotSupported{}
+}
+
+// ForceDetachVolume returns ErrNotSupported
+func (a *AttachmentNotSupported) ForceDetachVolume(*Info) error {
+	return &errors.ErrNotSupported{}
+}
+
+// RestoreProvisionNotSupported to be used by drivers that don't support
+// setting parameters for the volumes that snapshots are restored to
+type RestoreProvisionNotSupported struct{}
+
+// PreProvisionRestoreVolume returns ErrNotSupported
+func (r *RestoreProvisionNotSupported) PreProvisionRestoreVolume(
+	*snapv1.VolumeSnapshotData,
+	*v1.PersistentVolumeClaim,
+	map[string]string,
+) error {
+	return &errors.ErrNotSupported{}
+}
+
 // IsNodeMatch There are a couple of things that need to be checked to see if the driver
 // node matched the k8s node since different k8s installs set the node name,
 // hostname and IPs differently
@@ -18,6 +18,45 @@ const (
 type ClusterDomains struct {
 	Active   []string `json:"active"`
 	Inactive []string `json:"inactive"`
+	// ClusterDomainInfos provides the state and sync status of each cluster
+	// domain
+	ClusterDomainInfos []ClusterDomainInfo `json:"clusterDomainInfos,omitempty"`
+}
+
+// ClusterDomainActiveState is the state of a cluster domain
+type ClusterDomainActiveState string
+
+const (
+	// ClusterDomainActive is the state when a cluster domain is active
+	ClusterDomainActive ClusterDomainActiveState = "Active"
+	// ClusterDomainInactive is the state when a cluster domain is inactive
+	ClusterDomainInactive ClusterDomainActiveState = "Inactive"
+)
+
+// ClusterDomainSyncStatus is the status of the replication of data to a
+// cluster domain
+type ClusterDomainSyncStatus string
+
+const (
+	// ClusterDomainSyncStatusInSync is the status when the data in the
+	// cluster domain is in sync with the other cluster domains
+	ClusterDomainSyncStatusInSync ClusterDomainSyncStatus = "InSync"
+	// ClusterDomainSyncStatusNotInSync is the status when the data in the
+	// cluster domain isn't in sync with the other cluster domains
+	ClusterDomainSyncStatusNotInSync ClusterDomainSyncStatus = "NotInSync"
+	// ClusterDomainSyncStatusUnknown is the status when the storage driver
+	// doesn't report the sync status of the cluster domain
+	ClusterDomainSyncStatusUnknown ClusterDomainSyncStatus = "Unknown"
+)
+
+// ClusterDomainInfo provides the state and sync status of a cluster domain
+type ClusterDomainInfo struct {
+	Name       string                   `json:"name"`
+	State      ClusterDomainActiveState `json:"state"`
+	SyncStatus ClusterDomainSyncStatus  `json:"syncStatus"`
+	// LastTransitionTime is the time at which the state or the sync status
+	// of the cluster domain last changed
+	LastTransitionTime meta.Time `json:"lastTransitionTime,omitempty"`
 }
 
 // +genclient
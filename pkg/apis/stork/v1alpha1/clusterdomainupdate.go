@@ -17,6 +17,24 @@ const (
 type ClusterDomainUpdateSpec struct {
 	ClusterDomain string `json:"clusterdomain"`
 	Active        bool   `json:"active"`
+	// DryRun if set, the impact of deactivating the cluster domain is
+	// reported in the status without deactivating it
+	DryRun bool `json:"dryRun,omitempty"`
+	// Confirmed needs to be set to deactivate a cluster domain when stork
+	// requires deactivations to be confirmed. The impact is reported in the
+	// status until then
+	Confirmed bool `json:"confirmed,omitempty"`
+	// PostExecRule is the name of a rule that is run once the cluster domain
+	// has been activated or deactivated, for eg to fail over DNS or update
+	// load balancers. The rule can run commands in pods or run Jobs
+	PostExecRule string `json:"postExecRule,omitempty"`
+	// RuleNamespace is the namespace of the post exec rule, in which the
+	// pods for its commands are selected and its Jobs are run
+	RuleNamespace string `json:"ruleNamespace,omitempty"`
+	// RuleParameters are the parameters passed to the post exec rule. The
+	// clusterDomain and action (activate or deactivate) parameters are set
+	// by stork
+	RuleParameters map[string]string `json:"ruleParameters,omitempty"`
 }
 
 // +genclient
@@ -35,6 +53,31 @@ type ClusterDomainUpdate struct {
 type ClusterDomainUpdateStatus struct {
 	Status ClusterDomainUpdateStatusType `json:"status"`
 	Reason string                        `json:"reason"`
+	// Impact is the impact of deactivating the cluster domain on the
+	// applications in this cluster
+	Impact *ClusterDomainUpdateImpact `json:"impact,omitempty"`
+	// RuleResults are the results of running the post exec rule
+	RuleResults []*RuleExecutionResult `json:"ruleResults,omitempty"`
+}
+
+// ClusterDomainUpdateImpact is the impact of deactivating a cluster domain.
+// Each list is limited to the first 100 items, with the total number of items
+// in the counts.
+type ClusterDomainUpdateImpact struct {
+	// Namespaces with pods that will be impacted
+	Namespaces []string `json:"namespaces,omitempty"`
+	// PVCs used by the pods that will be impacted, as namespace/name
+	PVCs    []string `json:"pvcs,omitempty"`
+	NumPVCs int      `json:"numPVCs"`
+	// Pods running in the cluster domain that use volumes from the storage
+	// driver, as namespace/name
+	Pods    []string `json:"pods,omitempty"`
+	NumPods int      `json:"numPods"`
+	// AppsToActivate are the applications in this cluster, as
+	// kind/namespace/name, that were migrated from another cluster and will
+	// need to be activated if this cluster domain takes over
+	AppsToActivate    []string `json:"appsToActivate,omitempty"`
+	NumAppsToActivate int      `json:"numAppsToActivate"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -54,6 +97,10 @@ const (
 	ClusterDomainUpdateStatusInitial ClusterDomainUpdateStatusType = ""
 	// ClusterDomainUpdateStatusPending is state when clusterdomainsupdate is still pending
 	ClusterDomainUpdateStatusPending ClusterDomainUpdateStatusType = "Pending"
+	// ClusterDomainUpdateStatusPendingConfirmation is state when the impact
+	// of deactivating the cluster domain has been reported and the update
+	// is waiting to be confirmed, or is a dry run
+	ClusterDomainUpdateStatusPendingConfirmation ClusterDomainUpdateStatusType = "PendingConfirmation"
 	// ClusterDomainUpdateStatusFailed is state when clusterdomainsupdate has failed
 	ClusterDomainUpdateStatusFailed ClusterDomainUpdateStatusType = "Failed"
 	// ClusterDomainUpdateStatusSuccessful is state when clusterdomainsupdate has completed successfully
@@ -0,0 +1,41 @@
+package v1alpha1
+
+import (
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ClusterRuleResourceName is name for "clusterrule" resource
+	ClusterRuleResourceName = "clusterrule"
+	// ClusterRuleResourcePlural is plural for "clusterrule" resource
+	ClusterRuleResourcePlural = "clusterrules"
+)
+
+// +genclient
+// +genclient:nonNamespaced
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ClusterRule is a rule that is defined once for the cluster and can be
+// referenced from snapshots and migrations in any namespace allowed to use it.
+// The actions are run on the pods in the namespace of the object referencing
+// the rule.
+type ClusterRule struct {
+	meta.TypeMeta   `json:",inline"`
+	meta.ObjectMeta `json:"metadata,omitempty"`
+	// NamespaceSelector selects the namespaces from which the rule can be
+	// referenced. Set it to an empty selector to allow all namespaces, the
+	// rule can't be used from any namespace if it isn't set.
+	NamespaceSelector *meta.LabelSelector `json:"namespaceSelector,omitempty"`
+	Rules             []RuleItem          `json:"rules"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ClusterRuleList is a list of cluster rules
+type ClusterRuleList struct {
+	meta.TypeMeta `json:",inline"`
+	meta.ListMeta `json:"metadata,omitempty"`
+
+	Items []ClusterRule `json:"items"`
+}
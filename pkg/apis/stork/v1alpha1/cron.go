@@ -0,0 +1,186 @@
+package v1alpha1
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronField is one of the fields of a cron expression with the range of
+// values allowed for it
+type cronField struct {
+	name  string
+	min   int
+	max   int
+	names map[string]int
+}
+
+var (
+	cronSeconds     = cronField{name: "second", min: 0, max: 59}
+	cronMinutes     = cronField{name: "minute", min: 0, max: 59}
+	cronHours       = cronField{name: "hour", min: 0, max: 23}
+	cronDaysOfMonth = cronField{name: "day of month", min: 1, max: 31}
+	cronMonths      = cronField{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// Sunday can be specified as either 0 or 7
+	cronDaysOfWeek = cronField{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+// maxCronSearchYears is how far ahead the next time for a cron expression is
+// searched for, so that expressions that never match (for eg 30th February)
+// don't loop forever
+const maxCronSearchYears = 5
+
+// cronSchedule is a parsed cron expression with the values that match each
+// field
+// +k8s:deepcopy-gen=false
+type cronSchedule struct {
+	seconds     map[int]bool
+	minutes     map[int]bool
+	hours       map[int]bool
+	daysOfMonth map[int]bool
+	months      map[int]bool
+	daysOfWeek  map[int]bool
+	// anyDayOfMonth and anyDayOfWeek are set if the fields were "*". If both
+	// day fields are restricted a day matches if either of them matches.
+	anyDayOfMonth bool
+	anyDayOfWeek  bool
+}
+
+// parseCron parses a standard cron expression with 5 fields (minute, hour,
+// day of month, month and day of week), or 6 fields with a leading seconds
+// field. Each field can be "*", a value, a range "a-b", a step "*/n" or
+// "a-b/n", or a comma separated list of those. Months and days of the week
+// can also be specified by their 3 letter names.
+func parseCron(expression string) (*cronSchedule, error) {
+	fields := strings.Fields(expression)
+	if len(fields) == 5 {
+		fields = append([]string{"0"}, fields...)
+	} else if len(fields) != 6 {
+		return nil, fmt.Errorf("expected 5 or 6 fields, found %v", len(fields))
+	}
+
+	schedule := &cronSchedule{
+		anyDayOfMonth: fields[3] == "*",
+		anyDayOfWeek:  fields[5] == "*",
+	}
+	var err error
+	for i, parsed := range []struct {
+		field  cronField
+		values *map[int]bool
+	}{
+		{cronSeconds, &schedule.seconds},
+		{cronMinutes, &schedule.minutes},
+		{cronHours, &schedule.hours},
+		{cronDaysOfMonth, &schedule.daysOfMonth},
+		{cronMonths, &schedule.months},
+		{cronDaysOfWeek, &schedule.daysOfWeek},
+	} {
+		if *parsed.values, err = parsed.field.parse(fields[i]); err != nil {
+			return nil, err
+		}
+	}
+	if schedule.daysOfWeek[7] {
+		schedule.daysOfWeek[0] = true
+	}
+	return schedule, nil
+}
+
+// parse returns the values matching the field in the expression
+func (f cronField) parse(expression string) (map[int]bool, error) {
+	values := make(map[int]bool)
+	for _, item := range strings.Split(expression, ",") {
+		rangeExpr, step := item, 1
+		if i := strings.Index(item, "/"); i >= 0 {
+			var err error
+			rangeExpr = item[:i]
+			if step, err = strconv.Atoi(item[i+1:]); err != nil || step < 1 {
+				return nil, fmt.Errorf("invalid step in %v %q", f.name, item)
+			}
+		}
+
+		start, end := f.min, f.max
+		if rangeExpr != "*" {
+			bounds := strings.SplitN(rangeExpr, "-", 2)
+			var err error
+			if start, err = f.value(bounds[0]); err != nil {
+				return nil, err
+			}
+			end = start
+			if len(bounds) == 2 {
+				if end, err = f.value(bounds[1]); err != nil {
+					return nil, err
+				}
+			} else if step > 1 {
+				// "a/n" starts at a and continues until the max
+				end = f.max
+			}
+			if end < start {
+				return nil, fmt.Errorf("invalid range in %v %q", f.name, item)
+			}
+		}
+		for value := start; value <= end; value += step {
+			values[value] = true
+		}
+	}
+	return values, nil
+}
+
+// value parses a single value for the field
+func (f cronField) value(expression string) (int, error) {
+	if value, ok := f.names[strings.ToLower(expression)]; ok {
+		return value, nil
+	}
+	value, err := strconv.Atoi(expression)
+	if err != nil || value < f.min || value > f.max {
+		return 0, fmt.Errorf("invalid %v %q, expected a value between %v and %v", f.name, expression, f.min, f.max)
+	}
+	return value, nil
+}
+
+// matchesDay checks if the date of the time matches the day fields
+func (c *cronSchedule) matchesDay(t time.Time) bool {
+	domMatch := c.daysOfMonth[t.Day()]
+	dowMatch := c.daysOfWeek[int(t.Weekday())]
+	if c.anyDayOfMonth || c.anyDayOfWeek {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// next returns the first time after t that matches the schedule, in the
+// location of t. Returns the zero time if there is no match within
+// maxCronSearchYears.
+func (c *cronSchedule) next(t time.Time) time.Time {
+	t = t.Truncate(time.Second).Add(time.Second)
+	limit := t.AddDate(maxCronSearchYears, 0, 0)
+	for t.Before(limit) {
+		if !c.months[int(t.Month())] {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.hours[t.Hour()] {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.minutes[t.Minute()] {
+			t = t.Truncate(time.Minute).Add(time.Minute)
+			continue
+		}
+		if !c.seconds[t.Second()] {
+			t = t.Add(time.Second)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
@@ -32,8 +32,16 @@ type GroupVolumeSnapshotSpec struct {
 	// PreExecRule is the name of rule applied after taking the snapshot. The rule needs to be
 	// in the same namespace as the group volumesnapshot
 	PostExecRule string `json:"postExecRule"`
+	// RuleParameters are the parameters passed to the rules if they refer to
+	// built-in rule templates
+	RuleParameters map[string]string `json:"ruleParameters,omitempty"`
 	// PVCSelector selects the PVCs that are part of the group snapshot
 	PVCSelector PVCSelectorSpec `json:"pvcSelector"`
+	// Namespaces is the list of namespaces from which the PVCs are selected.
+	// Defaults to the namespace of the group snapshot. PVCs can only be
+	// selected from other namespaces that allow it with the
+	// stork.libopenstorage.org/group-snapshot-namespaces annotation
+	Namespaces []string `json:"namespaces,omitempty"`
 	// RestoreNamespaces is a list of namespaces to which the snapshots can be restored to
 	RestoreNamespaces []string `json:"restoreNamespaces"`
 	// MaxRetries is the number of times to retry the groupvolumesnapshot on failure. default: 0
@@ -63,6 +71,9 @@ type GroupVolumeSnapshotStatus struct {
 	Status          GroupVolumeSnapshotStatusType `json:"status"`
 	NumRetries      int                           `json:"numRetries"`
 	VolumeSnapshots []*VolumeSnapshotStatus       `json:"volumeSnapshots"`
+	// RuleResults are the results of running the pre and post exec rules for
+	// the group snapshot
+	RuleResults []*RuleExecutionResult `json:"ruleResults,omitempty"`
 }
 
 // VolumeSnapshotStatus captures the status of a volume snapshot operation
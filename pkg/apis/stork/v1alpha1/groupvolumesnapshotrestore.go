@@ -0,0 +1,69 @@
+package v1alpha1
+
+import (
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// GroupVolumeSnapshotRestoreResourceName is name for "groupvolumesnapshotrestore" resource
+	GroupVolumeSnapshotRestoreResourceName = "groupvolumesnapshotrestore"
+	// GroupVolumeSnapshotRestoreResourcePlural is plural for "groupvolumesnapshotrestore" resource
+	GroupVolumeSnapshotRestoreResourcePlural = "groupvolumesnapshotrestores"
+)
+
+// GroupVolumeSnapshotRestoreType is the type of restore for a group snapshot
+type GroupVolumeSnapshotRestoreType string
+
+const (
+	// GroupVolumeSnapshotRestoreTypeNewPVC restores the snapshots to new PVCs
+	GroupVolumeSnapshotRestoreTypeNewPVC GroupVolumeSnapshotRestoreType = "NewPVC"
+	// GroupVolumeSnapshotRestoreTypeInPlace restores the snapshots to the
+	// PVCs from which they were taken
+	GroupVolumeSnapshotRestoreTypeInPlace GroupVolumeSnapshotRestoreType = "InPlace"
+)
+
+// GroupVolumeSnapshotRestoreSpec is the spec used to restore all the volumes
+// from a group snapshot
+type GroupVolumeSnapshotRestoreSpec struct {
+	// SourceName is the name of the GroupVolumeSnapshot to restore from. The
+	// group snapshot needs to be in the same namespace as the restore object
+	SourceName string `json:"sourceName"`
+	// Type of the restore. Defaults to NewPVC
+	Type GroupVolumeSnapshotRestoreType `json:"type"`
+	// ScaleDownApplications scales down the applications using the PVCs
+	// during an in-place restore
+	ScaleDownApplications bool `json:"scaleDownApplications"`
+	// StorageClassName is the storage class used to create new PVCs. Defaults
+	// to stork-snapshot-sc
+	StorageClassName string `json:"storageClassName"`
+}
+
+// GroupVolumeSnapshotRestoreStatus is the status of a group snapshot restore
+type GroupVolumeSnapshotRestoreStatus struct {
+	Status          VolumeSnapshotRestoreStatusType `json:"status"`
+	Reason          string                          `json:"reason"`
+	Volumes         []*RestoreVolumeInfo            `json:"volumes"`
+	FinishTimestamp meta.Time                       `json:"finishTimestamp"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// GroupVolumeSnapshotRestore represents the restore of all the volumes from a
+// group snapshot
+type GroupVolumeSnapshotRestore struct {
+	meta.TypeMeta   `json:",inline"`
+	meta.ObjectMeta `json:"metadata,omitempty"`
+	Spec            GroupVolumeSnapshotRestoreSpec   `json:"spec"`
+	Status          GroupVolumeSnapshotRestoreStatus `json:"status"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// GroupVolumeSnapshotRestoreList is a list of GroupVolumeSnapshotRestores
+type GroupVolumeSnapshotRestoreList struct {
+	meta.TypeMeta `json:",inline"`
+	meta.ListMeta `json:"metadata,omitempty"`
+
+	Items []GroupVolumeSnapshotRestore `json:"items"`
+}
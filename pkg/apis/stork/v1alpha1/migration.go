@@ -22,6 +22,10 @@ type MigrationSpec struct {
 	Selectors         map[string]string `json:"selectors"`
 	PreExecRule       string            `json:"preExecRule"`
 	PostExecRule      string            `json:"postExecRule"`
+	// RuleParameters are the parameters passed to the pre and post exec
+	// rules, either to built-in templates or to rules that render their
+	// commands as templates
+	RuleParameters map[string]string `json:"ruleParameters,omitempty"`
 }
 
 // MigrationStatus is the status of a migration operation
@@ -31,6 +35,9 @@ type MigrationStatus struct {
 	Resources       []*ResourceInfo     `json:"resources"`
 	Volumes         []*VolumeInfo       `json:"volumes"`
 	FinishTimestamp meta.Time           `json:"finishTimestamp"`
+	// RuleResults are the results of running the pre and post exec rules for
+	// the migration
+	RuleResults []*RuleExecutionResult `json:"ruleResults,omitempty"`
 }
 
 // ResourceInfo is the info for the migration of a resource
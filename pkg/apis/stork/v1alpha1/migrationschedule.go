@@ -16,6 +16,20 @@ type MigrationScheduleSpec struct {
 	Template           MigrationTemplateSpec `json:"template"`
 	SchedulePolicyName string                `json:"schedulePolicyName"`
 	Suspend            *bool                 `json:"suspend"`
+	// CatchUpPolicy decides what happens to triggers that were missed while
+	// stork was down. Defaults to Skip
+	CatchUpPolicy CatchUpPolicyType `json:"catchUpPolicy,omitempty"`
+	// ConcurrencyPolicy decides what happens when the schedule is triggered
+	// while the previous migration is still running. Defaults to Forbid
+	ConcurrencyPolicy ConcurrencyPolicyType `json:"concurrencyPolicy,omitempty"`
+	// DependsOn is a schedule that needs to have run successfully since the
+	// last trigger of this schedule before it is triggered again, for eg to
+	// migrate after a snapshot has been taken
+	DependsOn *ScheduleDependency `json:"dependsOn,omitempty"`
+	// ClusterDomains are the cluster domains that the schedule depends on.
+	// The schedule is suspended automatically while any of them is inactive
+	// and resumed once they are all active again
+	ClusterDomains []string `json:"clusterDomains,omitempty"`
 }
 
 // MigrationTemplateSpec describes the data a Migration should have when created
@@ -27,6 +41,19 @@ type MigrationTemplateSpec struct {
 // MigrationScheduleStatus is the status of a migration schedule
 type MigrationScheduleStatus struct {
 	Items map[SchedulePolicyType][]*ScheduledMigrationStatus `json:"items"`
+	// SkippedTriggers are the most recent triggers that were skipped because
+	// they fell in a blackout window of the schedule policy
+	SkippedTriggers []*SkippedTrigger `json:"skippedTriggers,omitempty"`
+	// PendingCatchUpTriggers is the number of missed triggers for each
+	// policy that still need to be run
+	PendingCatchUpTriggers map[SchedulePolicyType]int `json:"pendingCatchUpTriggers,omitempty"`
+	// ClusterDomainSuspensions are the most recent automatic suspensions and
+	// resumptions of the schedule
+	ClusterDomainSuspensions []*ClusterDomainSuspension `json:"clusterDomainSuspensions,omitempty"`
+	// SuspendedForClusterDomains are the cluster domains that the schedule
+	// depends on that are inactive. The schedule isn't triggered while this
+	// is set
+	SuspendedForClusterDomains []string `json:"suspendedForClusterDomains,omitempty"`
 }
 
 // ScheduledMigrationStatus keeps track of the migration that was triggered by a
@@ -36,6 +63,13 @@ type ScheduledMigrationStatus struct {
 	CreationTimestamp meta.Time           `json:"creationTimestamp"`
 	FinishTimestamp   meta.Time           `json:"finishTimestamp"`
 	Status            MigrationStatusType `json:"status"`
+	// TriggerDelaySeconds is the delay after the scheduled time with which
+	// the migration was triggered because of the jitter in the schedule
+	// policy
+	TriggerDelaySeconds int64 `json:"triggerDelaySeconds,omitempty"`
+	// MaxRuntimeExceeded is set if the migration ran for longer than the max
+	// runtime of the schedule policy
+	MaxRuntimeExceeded bool `json:"maxRuntimeExceeded,omitempty"`
 }
 
 // +genclient
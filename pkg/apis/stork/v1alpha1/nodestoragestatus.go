@@ -0,0 +1,79 @@
+package v1alpha1
+
+import (
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// NodeStorageStatusResourceName is name for "nodestoragestatus" resource
+	NodeStorageStatusResourceName = "nodestoragestatus"
+	// NodeStorageStatusPlural is plural for "nodestoragestatus" resource
+	NodeStorageStatusPlural = "nodestoragestatuses"
+	// NodeStorageStatusShortName is the shortname for "nodestoragestatus" resource
+	NodeStorageStatusShortName = "nss"
+)
+
+// NodeStorageHealth is the health of the storage on a node
+type NodeStorageHealth string
+
+const (
+	// NodeStorageHealthOnline Storage on the node is online
+	NodeStorageHealthOnline NodeStorageHealth = "Online"
+	// NodeStorageHealthDegraded Storage on the node is degraded
+	NodeStorageHealthDegraded NodeStorageHealth = "Degraded"
+	// NodeStorageHealthOffline Storage on the node is offline
+	NodeStorageHealthOffline NodeStorageHealth = "Offline"
+)
+
+// StoragePoolStatus is the status of a storage pool on a node
+type StoragePoolStatus struct {
+	// ID of the storage pool
+	ID string `json:"id"`
+	// TotalCapacity is the total capacity of the pool in bytes
+	TotalCapacity uint64 `json:"totalCapacity"`
+	// FreeCapacity is the capacity of the pool in bytes that is available
+	FreeCapacity uint64 `json:"freeCapacity"`
+}
+
+// NodeStorageStatusInfo is the health of the storage on a node as reported
+// by the storage driver
+type NodeStorageStatusInfo struct {
+	// StorageID is the ID of the node for the storage driver
+	StorageID string `json:"storageID"`
+	// Health of the storage on the node
+	Health NodeStorageHealth `json:"health"`
+	// TotalCapacity is the total capacity of the storage pools on the node
+	// in bytes
+	TotalCapacity uint64 `json:"totalCapacity"`
+	// FreeCapacity is the capacity in bytes that is available in the storage
+	// pools on the node
+	FreeCapacity uint64 `json:"freeCapacity"`
+	// Pools are the storage pools on the node
+	Pools []StoragePoolStatus `json:"pools"`
+	// LastHeartbeatTime is the last time the status was reported by the
+	// storage driver
+	LastHeartbeatTime meta.Time `json:"lastHeartbeatTime"`
+	// LastTransitionTime is the last time the health changed
+	LastTransitionTime meta.Time `json:"lastTransitionTime"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// NodeStorageStatus represents the health of the storage on a node. It has
+// the same name as the node
+type NodeStorageStatus struct {
+	meta.TypeMeta   `json:",inline"`
+	meta.ObjectMeta `json:"metadata,omitempty"`
+	Status          NodeStorageStatusInfo `json:"status"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// NodeStorageStatusList is a list of storage statuses for nodes
+type NodeStorageStatusList struct {
+	meta.TypeMeta `json:",inline"`
+	meta.ListMeta `json:"metadata,omitempty"`
+	Items         []NodeStorageStatus `json:"items"`
+}
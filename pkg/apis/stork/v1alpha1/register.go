@@ -35,6 +35,8 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&RuleList{},
 		&ClusterPair{},
 		&ClusterPairList{},
+		&ClusterRule{},
+		&ClusterRuleList{},
 		&Migration{},
 		&MigrationList{},
 		&MigrationSchedule{},
@@ -53,6 +55,12 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&ClusterDomainUpdateList{},
 		&ApplicationClone{},
 		&ApplicationCloneList{},
+		&VolumeSnapshotRestore{},
+		&VolumeSnapshotRestoreList{},
+		&GroupVolumeSnapshotRestore{},
+		&GroupVolumeSnapshotRestoreList{},
+		&NodeStorageStatus{},
+		&NodeStorageStatusList{},
 	)
 
 	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
@@ -1,17 +1,34 @@
 package v1alpha1
 
 import (
+	v1 "k8s.io/api/core/v1"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 const (
 	// RuleActionCommand is a command action
 	RuleActionCommand RuleActionType = "command"
+	// RuleActionJob is an action that runs a command in a Job with a custom
+	// image in the namespace instead of in the selected pods
+	RuleActionJob RuleActionType = "job"
 )
 
 // RuleActionType is a type for actions that are supported in a stork rule
 type RuleActionType string
 
+// RuleActionParallelism is how the command of an action is run across the
+// selected pods
+type RuleActionParallelism string
+
+const (
+	// RuleActionParallel runs the command in all the pods at the same time
+	RuleActionParallel RuleActionParallelism = "Parallel"
+	// RuleActionSerial runs the command in one pod at a time, waiting for it
+	// to complete, or to start for background actions, before moving to the
+	// next pod
+	RuleActionSerial RuleActionParallelism = "Serial"
+)
+
 // +genclient
 // +genclient:noStatus
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -44,6 +61,128 @@ type RuleAction struct {
 	RunInSinglePod bool `json:"runInSinglePod,omitempty"`
 	// Value is the actual action value for e.g the command to run
 	Value string `json:"value"`
+	// Container is the name of the container in the pods in which the
+	// command is run. If it isn't set the container in the
+	// kubectl.kubernetes.io/default-container annotation on the pods is used,
+	// or the first container if the annotation isn't set either.
+	// +optional
+	Container string `json:"container,omitempty"`
+	// Condition is an expression in a subset of CEL that is evaluated for
+	// each selected pod, available as "pod". The command is only run in the
+	// pods for which it is true, for eg
+	// "pod.metadata.labels['role'] == 'primary'". The command is run in all
+	// the selected pods if it isn't set.
+	// +optional
+	Condition string `json:"condition,omitempty"`
+	// Parallelism is how the command is run across the selected pods.
+	// Defaults to Parallel, or to Serial if an order is specified.
+	// +optional
+	Parallelism RuleActionParallelism `json:"parallelism,omitempty"`
+	// Order is the order in which the command is run in the pods, for eg for
+	// clustered databases where the members need to be quiesced in sequence.
+	// The command is run serially if it is set. The pods are ordered by name
+	// if it isn't set.
+	// +optional
+	Order *RuleActionOrder `json:"order,omitempty"`
+	// Job is the spec of the Job used to run job actions
+	// +optional
+	Job *RuleActionJobSpec `json:"job,omitempty"`
+	// TimeoutSeconds is the maximum time for which a background action is
+	// left running. The action is terminated and its cleanup is run once the
+	// timeout expires, even if the operation that started it hasn't
+	// completed.
+	// +optional
+	TimeoutSeconds int `json:"timeoutSeconds,omitempty"`
+	// Cleanup is the command that is run in the pods once a background
+	// action is terminated, for eg to unfreeze a filesystem. It is run even
+	// if the operation that started the action fails or is deleted, within an
+	// hour if a timeout isn't set.
+	// +optional
+	Cleanup string `json:"cleanup,omitempty"`
+	// Retry is the policy used to retry the command if it fails to run in a
+	// pod. Only used for commands that aren't run in the background.
+	// +optional
+	Retry *RuleActionRetry `json:"retry,omitempty"`
+}
+
+// RuleActionOrder is the order in which the command of an action is run in
+// the pods, based on the value of a label on the pods
+type RuleActionOrder struct {
+	// Label is the key of the label whose value orders the pods
+	Label string `json:"label"`
+	// Values are the values of the label in the order in which the pods are
+	// picked, for eg ["primary", "replica"]. If it isn't set the pods are
+	// sorted by the value of the label, numerically if the values are
+	// numbers. Pods whose value isn't listed, or that don't have the label,
+	// are picked last.
+	// +optional
+	Values []string `json:"values,omitempty"`
+}
+
+// RuleActionRetry is the policy used to retry a failed action
+type RuleActionRetry struct {
+	// Attempts is the maximum number of times the action is run. Defaults to
+	// 12.
+	// +optional
+	Attempts int `json:"attempts,omitempty"`
+	// IntervalSeconds is the time to wait before the first retry. Defaults to
+	// 5 seconds.
+	// +optional
+	IntervalSeconds int `json:"intervalSeconds,omitempty"`
+	// BackoffFactor is the factor by which the interval is multiplied after
+	// every retry. The interval is constant if it isn't set.
+	// +optional
+	BackoffFactor int `json:"backoffFactor,omitempty"`
+}
+
+// RuleActionJobSpec is the spec of the Job that is run for a job action
+type RuleActionJobSpec struct {
+	// Image is the image used for the Job
+	Image string `json:"image"`
+	// Command is the entrypoint run in the image. The value of the action is
+	// run with "sh -c" if it isn't set.
+	// +optional
+	Command []string `json:"command,omitempty"`
+	// ServiceAccount is the service account used to run the Job
+	// +optional
+	ServiceAccount string `json:"serviceAccount,omitempty"`
+	// Resources are the resource requests and limits for the Job
+	// +optional
+	Resources v1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// RuleExecutionResult is the result of running a rule action on a pod
+type RuleExecutionResult struct {
+	// Rule is the name of the rule that was executed
+	Rule string `json:"rule"`
+	// Type is the type of the rule, eg preExecRule or postExecRule
+	Type string `json:"type"`
+	// Pod is the name of the pod on which the action was run
+	Pod string `json:"pod"`
+	// Container is the name of the container in which the action was run.
+	// Empty if it was run in the first container of the pod.
+	Container string `json:"container,omitempty"`
+	// Job is the name of the Job in which the action was run, for job actions
+	Job string `json:"job,omitempty"`
+	// Namespace is the namespace of the pod
+	Namespace string `json:"namespace"`
+	// Action is the value of the action that was run
+	Action string `json:"action"`
+	// Background is set if the action was started in the background
+	Background bool `json:"background,omitempty"`
+	// ExitCode of the command. Set to -1 if the exit code couldn't be
+	// determined
+	ExitCode int `json:"exitCode"`
+	// Attempts is the number of times the command was run
+	Attempts int `json:"attempts,omitempty"`
+	// Output is the truncated output of the command
+	Output string `json:"output,omitempty"`
+	// Error is the truncated error returned when running the command
+	Error string `json:"error,omitempty"`
+	// StartTimestamp is the time at which the action was started
+	StartTimestamp meta.Time `json:"startTimestamp"`
+	// Duration is the time taken to run the action, including retries
+	Duration meta.Duration `json:"duration"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
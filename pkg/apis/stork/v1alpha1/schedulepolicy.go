@@ -25,6 +25,11 @@ type SchedulePolicy struct {
 	meta.ObjectMeta `json:"metadata,omitempty"`
 	// Policy
 	Policy SchedulePolicyItem `json:"policy"`
+	// NamespaceSelector selects the namespaces in which schedules can use the
+	// policy, for eg to only allow namespaces labelled with a tier to use the
+	// policy for that tier. The policy can be used in all namespaces if it
+	// isn't set.
+	NamespaceSelector *meta.LabelSelector `json:"namespaceSelector,omitempty"`
 }
 
 // SchedulePolicyType is the type of schedule policy
@@ -41,12 +46,14 @@ const (
 	SchedulePolicyTypeWeekly SchedulePolicyType = "Weekly"
 	// SchedulePolicyTypeMonthly is the type for a monthly schedule policy
 	SchedulePolicyTypeMonthly SchedulePolicyType = "Monthly"
+	// SchedulePolicyTypeCron is the type for a cron schedule policy
+	SchedulePolicyTypeCron SchedulePolicyType = "Cron"
 )
 
 // GetValidSchedulePolicyTypes returns the valid types of schedule policies that
 // can be configured
 func GetValidSchedulePolicyTypes() []SchedulePolicyType {
-	return []SchedulePolicyType{SchedulePolicyTypeInterval, SchedulePolicyTypeDaily, SchedulePolicyTypeWeekly, SchedulePolicyTypeMonthly}
+	return []SchedulePolicyType{SchedulePolicyTypeInterval, SchedulePolicyTypeDaily, SchedulePolicyTypeWeekly, SchedulePolicyTypeMonthly, SchedulePolicyTypeCron}
 }
 
 // Days is a map of valid Day strings
@@ -81,6 +88,28 @@ type SchedulePolicyItem struct {
 	// Monthly policy that will be triggered on the specified date of the month
 	// at the specified time
 	Monthly *MonthlyPolicy `json:"monthly"`
+	// Cron policy that will be triggered at the times matching the cron
+	// expression
+	Cron *CronPolicy `json:"cron,omitempty"`
+	// BlackoutWindows are periods during which no actions should be
+	// triggered by the policy
+	BlackoutWindows []*BlackoutWindow `json:"blackoutWindows,omitempty"`
+	// ExcludedDates are dates on which no actions should be triggered by the
+	// policy, for eg holidays or change freezes
+	ExcludedDates []*ExcludedDates `json:"excludedDates,omitempty"`
+	// JitterMinutes spreads the triggers of schedules using the policy over
+	// a window of this many minutes after the scheduled time, so that they
+	// don't all trigger at the same time. Each schedule is delayed by a fixed
+	// amount within the window based on its namespace and name. For interval
+	// policies the triggers after the first one are aligned to multiples of
+	// the interval offset by that amount. Needs to be less than 60.
+	JitterMinutes int `json:"jitterMinutes,omitempty"`
+	// MaxRuntimeMinutes is the maximum time for which an action triggered by
+	// the policy is expected to run. There is no limit if it is 0.
+	MaxRuntimeMinutes int `json:"maxRuntimeMinutes,omitempty"`
+	// MaxRuntimeAction decides what happens to an action that runs for
+	// longer than MaxRuntimeMinutes. Defaults to Skip
+	MaxRuntimeAction MaxRuntimeActionType `json:"maxRuntimeAction,omitempty"`
 }
 
 // Retain Type to specify how many objects should be retained for a policy
@@ -103,6 +132,9 @@ func (i *IntervalPolicy) Validate() error {
 	if i.IntervalMinutes < 1 {
 		return fmt.Errorf("Invalid intervalMinutes (%v) in Interval policy", i.IntervalMinutes)
 	}
+	if i.Retain < 0 {
+		return fmt.Errorf("Invalid retain (%v) in Interval policy", i.Retain)
+	}
 	return nil
 }
 
@@ -118,6 +150,10 @@ type DailyPolicy struct {
 	// Retain Number of objects to retain for daily policy. Defaults to
 	// @DefaultDailyPolicyRetain
 	Retain Retain `json:"retain"`
+	// TimeZone is the name of the time zone in the IANA time zone database in
+	// which the time is specified, eg America/Los_Angeles. Defaults to the
+	// time zone of stork.
+	TimeZone string `json:"timeZone,omitempty"`
 }
 
 // GetHourMinute parses and return the hour and minute specified in the policy
@@ -125,14 +161,34 @@ func (d *DailyPolicy) GetHourMinute() (int, int, error) {
 	return getHourMinute(d.Time)
 }
 
+// GetLocation returns the location for the time zone of the policy
+func (d *DailyPolicy) GetLocation() (*time.Location, error) {
+	return getLocation(d.TimeZone)
+}
+
 // Validate validates a DailyPolicy
 func (d *DailyPolicy) Validate() error {
+	if _, err := d.GetLocation(); err != nil {
+		return fmt.Errorf("Invalid timeZone (%v) in Daily policy: %v", d.TimeZone, err)
+	}
 	if _, _, err := d.GetHourMinute(); err != nil {
 		return fmt.Errorf("Invalid time (%v) in Daily policy: %v", d.Time, err)
 	}
+	if d.Retain < 0 {
+		return fmt.Errorf("Invalid retain (%v) in Daily policy", d.Retain)
+	}
 	return nil
 }
 
+// getLocation returns the location for the time zone name. The local time
+// zone is used if the name is empty.
+func getLocation(timeZone string) (*time.Location, error) {
+	if timeZone == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(timeZone)
+}
+
 func getHourMinute(policyTime string) (int, int, error) {
 	parsedTime, err := time.Parse(time.Kitchen, policyTime)
 	if err != nil {
@@ -160,6 +216,10 @@ type WeeklyPolicy struct {
 	// Retain Number of objects to retain for weekly policy. Defaults to
 	// @DefaultWeeklyPolicyRetain
 	Retain Retain `json:"retain"`
+	// TimeZone is the name of the time zone in the IANA time zone database in
+	// which the time is specified, eg America/Los_Angeles. Defaults to the
+	// time zone of stork.
+	TimeZone string `json:"timeZone,omitempty"`
 }
 
 // GetHourMinute parses and return the hour and minute specified in the policy
@@ -167,14 +227,25 @@ func (w *WeeklyPolicy) GetHourMinute() (int, int, error) {
 	return getHourMinute(w.Time)
 }
 
+// GetLocation returns the location for the time zone of the policy
+func (w *WeeklyPolicy) GetLocation() (*time.Location, error) {
+	return getLocation(w.TimeZone)
+}
+
 // Validate validates a WeeklyPolicy
 func (w *WeeklyPolicy) Validate() error {
+	if _, err := w.GetLocation(); err != nil {
+		return fmt.Errorf("Invalid timeZone (%v) in Weekly policy: %v", w.TimeZone, err)
+	}
 	if _, _, err := w.GetHourMinute(); err != nil {
 		return fmt.Errorf("Invalid time (%v) in Weekly policy: %v", w.Time, err)
 	}
 	if _, present := Days[w.Day]; !present {
 		return fmt.Errorf("Invalid day of the week (%v) in Weekly policy", w.Day)
 	}
+	if w.Retain < 0 {
+		return fmt.Errorf("Invalid retain (%v) in Weekly policy", w.Retain)
+	}
 	return nil
 }
 
@@ -196,6 +267,10 @@ type MonthlyPolicy struct {
 	// Retain Number of objects to retain for monthly policy. Defaults to
 	// @DefaultMonthlyPolicyRetain
 	Retain Retain `json:"retain"`
+	// TimeZone is the name of the time zone in the IANA time zone database in
+	// which the time is specified, eg America/Los_Angeles. Defaults to the
+	// time zone of stork.
+	TimeZone string `json:"timeZone,omitempty"`
 }
 
 // GetHourMinute parses and return the hour and minute specified in the policy
@@ -203,17 +278,325 @@ func (m *MonthlyPolicy) GetHourMinute() (int, int, error) {
 	return getHourMinute(m.Time)
 }
 
+// GetLocation returns the location for the time zone of the policy
+func (m *MonthlyPolicy) GetLocation() (*time.Location, error) {
+	return getLocation(m.TimeZone)
+}
+
 // Validate validates a MonthlyPolicy
 func (m *MonthlyPolicy) Validate() error {
+	if _, err := m.GetLocation(); err != nil {
+		return fmt.Errorf("Invalid timeZone (%v) in Monthly policy: %v", m.TimeZone, err)
+	}
 	if _, _, err := m.GetHourMinute(); err != nil {
 		return fmt.Errorf("Invalid time (%v) in Monthly policy: %v", m.Time, err)
 	}
 	if m.Date < 1 || m.Date > 31 {
 		return fmt.Errorf("Invalid date of the month (%v) in Monthly policy", m.Date)
 	}
+	if m.Retain < 0 {
+		return fmt.Errorf("Invalid retain (%v) in Monthly policy", m.Retain)
+	}
+	return nil
+}
+
+// DefaultCronPolicyRetain Default for objects to be retained for the cron
+// policy
+const DefaultCronPolicyRetain = Retain(10)
+
+// CronPolicy contains the cron expression for the times when an action should
+// be executed
+type CronPolicy struct {
+	// Expression is a standard cron expression with 5 fields (minute, hour,
+	// day of month, month and day of week) eg "30 1 * * 1-5", with an
+	// optional leading seconds field
+	Expression string `json:"expression"`
+	// Retain Number of objects to retain for cron policy. Defaults to
+	// @DefaultCronPolicyRetain
+	Retain Retain `json:"retain"`
+	// TimeZone is the name of the time zone in the IANA time zone database in
+	// which the expression is evaluated, eg America/Los_Angeles. Defaults to
+	// the time zone of stork.
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+// GetLocation returns the location for the time zone of the policy
+func (c *CronPolicy) GetLocation() (*time.Location, error) {
+	return getLocation(c.TimeZone)
+}
+
+// Validate validates a CronPolicy
+func (c *CronPolicy) Validate() error {
+	if _, err := c.GetLocation(); err != nil {
+		return fmt.Errorf("Invalid timeZone (%v) in Cron policy: %v", c.TimeZone, err)
+	}
+	if _, err := parseCron(c.Expression); err != nil {
+		return fmt.Errorf("Invalid expression (%v) in Cron policy: %v", c.Expression, err)
+	}
+	if c.Retain < 0 {
+		return fmt.Errorf("Invalid retain (%v) in Cron policy", c.Retain)
+	}
+	return nil
+}
+
+// GetNextTime returns the first time after t that matches the cron expression
+// in the time zone of the policy. Returns the zero time if no time in the
+// next few years matches the expression.
+func (c *CronPolicy) GetNextTime(t time.Time) (time.Time, error) {
+	schedule, err := parseCron(c.Expression)
+	if err != nil {
+		return time.Time{}, err
+	}
+	loc, err := c.GetLocation()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return schedule.next(t.In(loc)), nil
+}
+
+// BlackoutWindow is a period of time during which a policy should not be
+// triggered
+type BlackoutWindow struct {
+	// Start time of the window. Expected format is time.Kitchen eg 01:00AM
+	Start string `json:"start"`
+	// End time of the window. Expected format is time.Kitchen eg 03:00AM. If
+	// the end time is before the start time the window ends on the next day
+	End string `json:"end"`
+	// Days of the week on which the window starts. Valid formats are
+	// specified in `Days` above. The window applies every day if empty
+	Days []string `json:"days,omitempty"`
+}
+
+// Validate validates a BlackoutWindow
+func (b *BlackoutWindow) Validate() error {
+	startHour, startMinute, err := getHourMinute(b.Start)
+	if err != nil {
+		return fmt.Errorf("Invalid start time (%v) in blackout window: %v", b.Start, err)
+	}
+	endHour, endMinute, err := getHourMinute(b.End)
+	if err != nil {
+		return fmt.Errorf("Invalid end time (%v) in blackout window: %v", b.End, err)
+	}
+	if startHour == endHour && startMinute == endMinute {
+		return fmt.Errorf("Start and end time (%v) in blackout window can't be the same", b.Start)
+	}
+	for _, day := range b.Days {
+		if _, present := Days[day]; !present {
+			return fmt.Errorf("Invalid day of the week (%v) in blackout window", day)
+		}
+	}
 	return nil
 }
 
+// Contains checks if the given time falls within the blackout window
+func (b *BlackoutWindow) Contains(t time.Time) (bool, error) {
+	startHour, startMinute, err := getHourMinute(b.Start)
+	if err != nil {
+		return false, err
+	}
+	endHour, endMinute, err := getHourMinute(b.End)
+	if err != nil {
+		return false, err
+	}
+	start := startHour*60 + startMinute
+	end := endHour*60 + endMinute
+	current := t.Hour()*60 + t.Minute()
+
+	// The day on which the window containing the time would have started
+	startDay := t.Weekday()
+	if start < end {
+		if current < start || current >= end {
+			return false, nil
+		}
+	} else {
+		if current < start && current >= end {
+			return false, nil
+		}
+		// Window wrapped around midnight, so it started on the previous day
+		if current < end {
+			startDay = (startDay + 6) % 7
+		}
+	}
+
+	if len(b.Days) == 0 {
+		return true, nil
+	}
+	for _, day := range b.Days {
+		if Days[day] == startDay {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+const (
+	excludedDateFormat          = "2006-01-02"
+	recurringExcludedDateFormat = "01-02"
+)
+
+// ExcludedDates is a date or a range of dates on which no actions should be
+// triggered by a policy. The dates are in the time zone of stork.
+type ExcludedDates struct {
+	// Name of the excluded dates, eg Quarterly freeze. Used when reporting
+	// the triggers that were skipped
+	Name string `json:"name,omitempty"`
+	// Start is the first excluded date. Expected format is 2006-01-02, or
+	// 01-02 for a date that recurs every year
+	Start string `json:"start"`
+	// End is the last excluded date, in the same format as the start date.
+	// Only the start date is excluded if empty. If a recurring end date is
+	// before the start date the range ends in the next year
+	End string `json:"end,omitempty"`
+}
+
+// parseExcludedDate parses a date and returns it as a number that can be
+// compared with other dates in the same format, and whether it is a recurring
+// date
+func parseExcludedDate(date string) (int, bool, error) {
+	if t, err := time.Parse(excludedDateFormat, date); err == nil {
+		return t.Year()*10000 + int(t.Month())*100 + t.Day(), false, nil
+	}
+	t, err := time.Parse(recurringExcludedDateFormat, date)
+	if err != nil {
+		return 0, false, fmt.Errorf("expected format is %v or %v", excludedDateFormat, recurringExcludedDateFormat)
+	}
+	return int(t.Month())*100 + t.Day(), true, nil
+}
+
+// getRange returns the start and end of the excluded dates and whether they
+// recur every year
+func (e *ExcludedDates) getRange() (int, int, bool, error) {
+	start, recurring, err := parseExcludedDate(e.Start)
+	if err != nil {
+		return 0, 0, false, fmt.Errorf("Invalid start date (%v) in excluded dates: %v", e.Start, err)
+	}
+	if e.End == "" {
+		return start, start, recurring, nil
+	}
+	end, endRecurring, err := parseExcludedDate(e.End)
+	if err != nil {
+		return 0, 0, false, fmt.Errorf("Invalid end date (%v) in excluded dates: %v", e.End, err)
+	}
+	if recurring != endRecurring {
+		return 0, 0, false, fmt.Errorf("Start (%v) and end date (%v) in excluded dates need to be in the same format", e.Start, e.End)
+	}
+	if !recurring && end < start {
+		return 0, 0, false, fmt.Errorf("End date (%v) in excluded dates can't be before the start date (%v)", e.End, e.Start)
+	}
+	return start, end, recurring, nil
+}
+
+// Validate validates ExcludedDates
+func (e *ExcludedDates) Validate() error {
+	_, _, _, err := e.getRange()
+	return err
+}
+
+// Contains checks if the date of the given time is one of the excluded dates
+func (e *ExcludedDates) Contains(t time.Time) (bool, error) {
+	start, end, recurring, err := e.getRange()
+	if err != nil {
+		return false, err
+	}
+	if !recurring {
+		current := t.Year()*10000 + int(t.Month())*100 + t.Day()
+		return current >= start && current <= end, nil
+	}
+	current := int(t.Month())*100 + t.Day()
+	if start <= end {
+		return current >= start && current <= end, nil
+	}
+	// Range wrapped around the end of the year
+	return current >= start || current <= end, nil
+}
+
+// String returns the name of the excluded dates, or the range if it doesn't
+// have a name
+func (e *ExcludedDates) String() string {
+	if e.Name != "" {
+		return e.Name
+	}
+	if e.End == "" {
+		return e.Start
+	}
+	return e.Start + " to " + e.End
+}
+
+// CatchUpPolicyType is the policy used for triggers that were missed while
+// stork was down
+type CatchUpPolicyType string
+
+const (
+	// CatchUpPolicySkip skips all the missed triggers and waits for the next
+	// scheduled trigger
+	CatchUpPolicySkip CatchUpPolicyType = "Skip"
+	// CatchUpPolicyRunOnce runs one trigger if any triggers were missed
+	CatchUpPolicyRunOnce CatchUpPolicyType = "RunOnce"
+	// CatchUpPolicyRunAllMissed runs a trigger for every trigger that was
+	// missed, up to a maximum of 10
+	CatchUpPolicyRunAllMissed CatchUpPolicyType = "RunAllMissed"
+)
+
+// MaxRuntimeActionType is the action taken for an action triggered by a
+// policy that runs for longer than the maximum runtime of the policy
+type MaxRuntimeActionType string
+
+const (
+	// MaxRuntimeActionSkip leaves the action running and skips the triggers
+	// that are due before it completes, instead of running them once it
+	// completes
+	MaxRuntimeActionSkip MaxRuntimeActionType = "Skip"
+	// MaxRuntimeActionCancel cancels the action so that the next trigger can
+	// run
+	MaxRuntimeActionCancel MaxRuntimeActionType = "Cancel"
+)
+
+// ConcurrencyPolicyType decides what happens when a schedule is triggered
+// while an action triggered by it earlier is still running
+type ConcurrencyPolicyType string
+
+const (
+	// ConcurrencyPolicyForbid doesn't start a new action until the running
+	// action completes
+	ConcurrencyPolicyForbid ConcurrencyPolicyType = "Forbid"
+	// ConcurrencyPolicyReplace cancels the running action and starts a new
+	// one
+	ConcurrencyPolicyReplace ConcurrencyPolicyType = "Replace"
+	// ConcurrencyPolicyAllow starts a new action while the earlier action is
+	// still running
+	ConcurrencyPolicyAllow ConcurrencyPolicyType = "Allow"
+)
+
+// ScheduleDependency refers to a schedule in the same namespace whose latest
+// run needs to have succeeded before a schedule that depends on it is
+// triggered
+type ScheduleDependency struct {
+	// Kind of the schedule, MigrationSchedule or VolumeSnapshotSchedule
+	Kind string `json:"kind"`
+	// Name of the schedule
+	Name string `json:"name"`
+}
+
+// SkippedTrigger keeps track of a trigger for a policy that was skipped
+type SkippedTrigger struct {
+	PolicyType SchedulePolicyType `json:"policyType"`
+	Timestamp  meta.Time          `json:"timestamp"`
+	Reason     string             `json:"reason"`
+}
+
+// ClusterDomainSuspension keeps track of a schedule that was suspended or
+// resumed automatically because the state of the cluster domains that it
+// depends on changed
+type ClusterDomainSuspension struct {
+	// Suspended is true if the schedule was suspended and false if it was
+	// resumed
+	Suspended bool `json:"suspended"`
+	// InactiveDomains are the cluster domains that the schedule depends on
+	// that were inactive
+	InactiveDomains []string  `json:"inactiveDomains,omitempty"`
+	Timestamp       meta.Time `json:"timestamp"`
+}
+
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
 // SchedulePolicyList is a list of schedule policies
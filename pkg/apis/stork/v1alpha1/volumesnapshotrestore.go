@@ -0,0 +1,115 @@
+package v1alpha1
+
+import (
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// VolumeSnapshotRestoreResourceName is name for "volumesnapshotrestore" resource
+	VolumeSnapshotRestoreResourceName = "volumesnapshotrestore"
+	// VolumeSnapshotRestoreResourcePlural is plural for "volumesnapshotrestore" resource
+	VolumeSnapshotRestoreResourcePlural = "volumesnapshotrestores"
+)
+
+// VolumeSnapshotRestoreSpec is the spec used to restore volumes in-place from
+// a snapshot
+type VolumeSnapshotRestoreSpec struct {
+	// SourceName is the name of the snapshot to restore from. The snapshot
+	// needs to be in the same namespace as the restore object
+	SourceName string `json:"sourceName"`
+	// GroupSnapshot should be set to true if the source is a
+	// GroupVolumeSnapshot
+	GroupSnapshot bool `json:"groupSnapshot"`
+	// ScaleDownApplications scales down the applications using the PVCs
+	// before the restore and scales them back up once the restore is complete
+	ScaleDownApplications bool `json:"scaleDownApplications"`
+	// DryRun only validates that the volumes can be restored from the
+	// snapshot without scaling down the applications or restoring the volumes
+	DryRun bool `json:"dryRun,omitempty"`
+}
+
+// VolumeSnapshotRestoreStatus is the status of an in-place restore operation
+type VolumeSnapshotRestoreStatus struct {
+	Stage           VolumeSnapshotRestoreStageType  `json:"stage"`
+	Status          VolumeSnapshotRestoreStatusType `json:"status"`
+	Reason          string                          `json:"reason"`
+	Volumes         []*RestoreVolumeInfo            `json:"volumes"`
+	Applications    []*RestoreApplicationInfo       `json:"applications"`
+	FinishTimestamp meta.Time                       `json:"finishTimestamp"`
+}
+
+// RestoreVolumeInfo is the info for the restore of a volume
+type RestoreVolumeInfo struct {
+	PersistentVolumeClaim string                          `json:"persistentVolumeClaim"`
+	Namespace             string                          `json:"namespace"`
+	Volume                string                          `json:"volume"`
+	Snapshot              string                          `json:"snapshot"`
+	SnapshotData          string                          `json:"snapshotData"`
+	Status                VolumeSnapshotRestoreStatusType `json:"status"`
+	Reason                string                          `json:"reason"`
+}
+
+// RestoreApplicationInfo is the info for an application that was scaled down
+// for the restore
+type RestoreApplicationInfo struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Replicas  int32  `json:"replicas"`
+}
+
+// VolumeSnapshotRestoreStatusType is the status of the restore
+type VolumeSnapshotRestoreStatusType string
+
+const (
+	// VolumeSnapshotRestoreStatusInitial is the initial state when restore is created
+	VolumeSnapshotRestoreStatusInitial VolumeSnapshotRestoreStatusType = ""
+	// VolumeSnapshotRestoreStatusPending for when restore is still pending
+	VolumeSnapshotRestoreStatusPending VolumeSnapshotRestoreStatusType = "Pending"
+	// VolumeSnapshotRestoreStatusInProgress for when restore is in progress
+	VolumeSnapshotRestoreStatusInProgress VolumeSnapshotRestoreStatusType = "InProgress"
+	// VolumeSnapshotRestoreStatusFailed for when restore has failed
+	VolumeSnapshotRestoreStatusFailed VolumeSnapshotRestoreStatusType = "Failed"
+	// VolumeSnapshotRestoreStatusSuccessful for when restore has completed successfully
+	VolumeSnapshotRestoreStatusSuccessful VolumeSnapshotRestoreStatusType = "Successful"
+)
+
+// VolumeSnapshotRestoreStageType is the stage of the restore
+type VolumeSnapshotRestoreStageType string
+
+const (
+	// VolumeSnapshotRestoreStageInitial for when restore is created
+	VolumeSnapshotRestoreStageInitial VolumeSnapshotRestoreStageType = ""
+	// VolumeSnapshotRestoreStageScaleDown for when applications using the
+	// volumes are being scaled down
+	VolumeSnapshotRestoreStageScaleDown VolumeSnapshotRestoreStageType = "ScaleDown"
+	// VolumeSnapshotRestoreStageRestore for when volumes are being restored
+	VolumeSnapshotRestoreStageRestore VolumeSnapshotRestoreStageType = "Restore"
+	// VolumeSnapshotRestoreStageScaleUp for when applications are being
+	// scaled back up
+	VolumeSnapshotRestoreStageScaleUp VolumeSnapshotRestoreStageType = "ScaleUp"
+	// VolumeSnapshotRestoreStageFinal is the final stage for restore
+	VolumeSnapshotRestoreStageFinal VolumeSnapshotRestoreStageType = "Final"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// VolumeSnapshotRestore represents an in-place restore of volumes from a
+// snapshot
+type VolumeSnapshotRestore struct {
+	meta.TypeMeta   `json:",inline"`
+	meta.ObjectMeta `json:"metadata,omitempty"`
+	Spec            VolumeSnapshotRestoreSpec   `json:"spec"`
+	Status          VolumeSnapshotRestoreStatus `json:"status"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// VolumeSnapshotRestoreList is a list of VolumeSnapshotRestores
+type VolumeSnapshotRestoreList struct {
+	meta.TypeMeta `json:",inline"`
+	meta.ListMeta `json:"metadata,omitempty"`
+
+	Items []VolumeSnapshotRestore `json:"items"`
+}
@@ -20,8 +20,67 @@ type VolumeSnapshotScheduleSpec struct {
 	ReclaimPolicy      ReclaimPolicyType          `json:"reclaimPolicy"`
 	PreExecRule        string                     `json:"preExecRule"`
 	PostExecRule       string                     `json:"postExecRule"`
+	// SnapshotType is the type of snapshot object that should be created by
+	// the schedule. Defaults to stork snapshots
+	SnapshotType VolumeSnapshotType `json:"snapshotType,omitempty"`
+	// VolumeSnapshotClassName is the name of the VolumeSnapshotClass to be
+	// used when creating CSI snapshots
+	VolumeSnapshotClassName string `json:"volumeSnapshotClassName,omitempty"`
+	// CloudSnapshot is used to additionally upload some of the scheduled
+	// snapshots to the cloud. Only supported for stork snapshots
+	CloudSnapshot *CloudSnapshotSpec `json:"cloudSnapshot,omitempty"`
+	// CatchUpPolicy decides what happens to triggers that were missed while
+	// stork was down. Defaults to Skip
+	CatchUpPolicy CatchUpPolicyType `json:"catchUpPolicy,omitempty"`
+	// ConcurrencyPolicy decides what happens when the schedule is triggered
+	// while the previous snapshot is still running. Defaults to Forbid
+	ConcurrencyPolicy ConcurrencyPolicyType `json:"concurrencyPolicy,omitempty"`
+	// DependsOn is a schedule that needs to have run successfully since the
+	// last trigger of this schedule before it is triggered again, for eg to
+	// migrate after a snapshot has been taken
+	DependsOn *ScheduleDependency `json:"dependsOn,omitempty"`
+	// ClusterDomains are the cluster domains that the schedule depends on.
+	// The schedule is suspended automatically while any of them is inactive
+	// and resumed once they are all active again
+	ClusterDomains []string `json:"clusterDomains,omitempty"`
 }
 
+// DefaultCloudSnapshotRetain Default for the number of cloud snapshots to be
+// retained for each policy
+const DefaultCloudSnapshotRetain = Retain(1)
+
+// CloudSnapshotSpec is the spec for cloud snapshots created by a schedule
+type CloudSnapshotSpec struct {
+	// Frequency is the number of scheduled snapshots for a policy after which
+	// a cloud snapshot is also created, ie every Nth snapshot is uploaded.
+	// Defaults to 1
+	Frequency int `json:"frequency"`
+	// Retain is the number of cloud snapshots to retain for each policy.
+	// Defaults to @DefaultCloudSnapshotRetain
+	Retain Retain `json:"retain"`
+	// Annotations are added to the cloud snapshots. These should be used to
+	// specify the snapshot type and credentials for the storage driver, eg
+	// portworx/snapshot-type: cloud
+	Annotations map[string]string `json:"annotations"`
+	// FullSnapshotFrequency forces every Nth cloud snapshot for a policy to
+	// be a full snapshot instead of an incremental one, limiting the length
+	// of incremental snapshot chains. The driver decides when to take full
+	// snapshots if this is 0
+	FullSnapshotFrequency int `json:"fullSnapshotFrequency,omitempty"`
+}
+
+// VolumeSnapshotType is the type of snapshot object created by a schedule
+type VolumeSnapshotType string
+
+const (
+	// VolumeSnapshotTypeStork is used to create snapshots using the
+	// external-storage snapshot CRDs handled by stork
+	VolumeSnapshotTypeStork VolumeSnapshotType = "stork"
+	// VolumeSnapshotTypeCSI is used to create snapshot.storage.k8s.io/v1
+	// VolumeSnapshots handled by the CSI external-snapshotter
+	VolumeSnapshotTypeCSI VolumeSnapshotType = "csi"
+)
+
 // VolumeSnapshotTemplateSpec describes the data a VolumeSnapshot should have when created
 // from a template
 type VolumeSnapshotTemplateSpec struct {
@@ -43,6 +102,28 @@ const (
 // VolumeSnapshotScheduleStatus is the status of a volumesnapshot schedule
 type VolumeSnapshotScheduleStatus struct {
 	Items map[SchedulePolicyType][]*ScheduledVolumeSnapshotStatus `json:"items"`
+	// SkippedTriggers are the most recent triggers that were skipped because
+	// they fell in a blackout window of the schedule policy
+	SkippedTriggers []*SkippedTrigger `json:"skippedTriggers,omitempty"`
+	// CloudItems are the cloud snapshots triggered by the schedule
+	CloudItems map[SchedulePolicyType][]*ScheduledVolumeSnapshotStatus `json:"cloudItems,omitempty"`
+	// NumTriggered is the number of snapshots triggered for each policy. It
+	// is used to decide when a cloud snapshot should be created
+	NumTriggered map[SchedulePolicyType]int `json:"numTriggered,omitempty"`
+	// ChainLength is the number of cloud snapshots for each policy since the
+	// last full snapshot that was requested by the schedule, including the
+	// full snapshot
+	ChainLength map[SchedulePolicyType]int `json:"chainLength,omitempty"`
+	// PendingCatchUpTriggers is the number of missed triggers for each
+	// policy that still need to be run
+	PendingCatchUpTriggers map[SchedulePolicyType]int `json:"pendingCatchUpTriggers,omitempty"`
+	// ClusterDomainSuspensions are the most recent automatic suspensions and
+	// resumptions of the schedule
+	ClusterDomainSuspensions []*ClusterDomainSuspension `json:"clusterDomainSuspensions,omitempty"`
+	// SuspendedForClusterDomains are the cluster domains that the schedule
+	// depends on that are inactive. The schedule isn't triggered while this
+	// is set
+	SuspendedForClusterDomains []string `json:"suspendedForClusterDomains,omitempty"`
 }
 
 // ScheduledVolumeSnapshotStatus keeps track of the volumesnapshot that was triggered by a
@@ -52,6 +133,13 @@ type ScheduledVolumeSnapshotStatus struct {
 	CreationTimestamp meta.Time                          `json:"creationTimestamp"`
 	FinishTimestamp   meta.Time                          `json:"finishTimestamp"`
 	Status            snapv1.VolumeSnapshotConditionType `json:"status"`
+	// TriggerDelaySeconds is the delay after the scheduled time with which
+	// the snapshot was triggered because of the jitter in the schedule
+	// policy
+	TriggerDelaySeconds int64 `json:"triggerDelaySeconds,omitempty"`
+	// MaxRuntimeExceeded is set if the snapshot ran for longer than the max
+	// runtime of the schedule policy
+	MaxRuntimeExceeded bool `json:"maxRuntimeExceeded,omitempty"`
 }
 
 // +genclient
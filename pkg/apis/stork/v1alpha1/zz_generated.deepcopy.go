@@ -183,6 +183,50 @@ func (in *ApplicationCloneVolumeInfo) DeepCopy() *ApplicationCloneVolumeInfo {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BlackoutWindow) DeepCopyInto(out *BlackoutWindow) {
+	*out = *in
+	if in.Days != nil {
+		in, out := &in.Days, &out.Days
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BlackoutWindow.
+func (in *BlackoutWindow) DeepCopy() *BlackoutWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(BlackoutWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudSnapshotSpec) DeepCopyInto(out *CloudSnapshotSpec) {
+	*out = *in
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudSnapshotSpec.
+func (in *CloudSnapshotSpec) DeepCopy() *CloudSnapshotSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudSnapshotSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CloudStorageSpec) DeepCopyInto(out *CloudStorageSpec) {
 	*out = *in
@@ -218,13 +262,52 @@ func (in *CloudStorageSpec) DeepCopy() *CloudStorageSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterDomainInfo) DeepCopyInto(out *ClusterDomainInfo) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterDomainInfo.
+func (in *ClusterDomainInfo) DeepCopy() *ClusterDomainInfo {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterDomainInfo)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterDomainSuspension) DeepCopyInto(out *ClusterDomainSuspension) {
+	*out = *in
+	if in.InactiveDomains != nil {
+		in, out := &in.InactiveDomains, &out.InactiveDomains
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.Timestamp.DeepCopyInto(&out.Timestamp)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterDomainSuspension.
+func (in *ClusterDomainSuspension) DeepCopy() *ClusterDomainSuspension {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterDomainSuspension)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterDomainUpdate) DeepCopyInto(out *ClusterDomainUpdate) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
-	out.Status = in.Status
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
@@ -246,6 +329,42 @@ func (in *ClusterDomainUpdate) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterDomainUpdateImpact) DeepCopyInto(out *ClusterDomainUpdateImpact) {
+	*out = *in
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PVCs != nil {
+		in, out := &in.PVCs, &out.PVCs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Pods != nil {
+		in, out := &in.Pods, &out.Pods
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AppsToActivate != nil {
+		in, out := &in.AppsToActivate, &out.AppsToActivate
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterDomainUpdateImpact.
+func (in *ClusterDomainUpdateImpact) DeepCopy() *ClusterDomainUpdateImpact {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterDomainUpdateImpact)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterDomainUpdateList) DeepCopyInto(out *ClusterDomainUpdateList) {
 	*out = *in
@@ -282,6 +401,13 @@ func (in *ClusterDomainUpdateList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterDomainUpdateSpec) DeepCopyInto(out *ClusterDomainUpdateSpec) {
 	*out = *in
+	if in.RuleParameters != nil {
+		in, out := &in.RuleParameters, &out.RuleParameters
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	return
 }
 
@@ -298,6 +424,22 @@ func (in *ClusterDomainUpdateSpec) DeepCopy() *ClusterDomainUpdateSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterDomainUpdateStatus) DeepCopyInto(out *ClusterDomainUpdateStatus) {
 	*out = *in
+	if in.Impact != nil {
+		in, out := &in.Impact, &out.Impact
+		*out = new(ClusterDomainUpdateImpact)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RuleResults != nil {
+		in, out := &in.RuleResults, &out.RuleResults
+		*out = make([]*RuleExecutionResult, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(RuleExecutionResult)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
 	return
 }
 
@@ -324,6 +466,13 @@ func (in *ClusterDomains) DeepCopyInto(out *ClusterDomains) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.ClusterDomainInfos != nil {
+		in, out := &in.ClusterDomainInfos, &out.ClusterDomainInfos
+		*out = make([]ClusterDomainInfo, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -498,6 +647,77 @@ func (in *ClusterPairStatus) DeepCopy() *ClusterPairStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterRule) DeepCopyInto(out *ClusterRule) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]RuleItem, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterRule.
+func (in *ClusterRule) DeepCopy() *ClusterRule {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterRule) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterRuleList) DeepCopyInto(out *ClusterRuleList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClusterRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterRuleList.
+func (in *ClusterRuleList) DeepCopy() *ClusterRuleList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterRuleList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterRuleList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CommonConfig) DeepCopyInto(out *CommonConfig) {
 	*out = *in
@@ -538,6 +758,22 @@ func (in *CommonConfig) DeepCopy() *CommonConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CronPolicy) DeepCopyInto(out *CronPolicy) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CronPolicy.
+func (in *CronPolicy) DeepCopy() *CronPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(CronPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DailyPolicy) DeepCopyInto(out *DailyPolicy) {
 	*out = *in
@@ -554,6 +790,22 @@ func (in *DailyPolicy) DeepCopy() *DailyPolicy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExcludedDates) DeepCopyInto(out *ExcludedDates) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExcludedDates.
+func (in *ExcludedDates) DeepCopy() *ExcludedDates {
+	if in == nil {
+		return nil
+	}
+	out := new(ExcludedDates)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Geography) DeepCopyInto(out *Geography) {
 	*out = *in
@@ -631,10 +883,127 @@ func (in *GroupVolumeSnapshotList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GroupVolumeSnapshotRestore) DeepCopyInto(out *GroupVolumeSnapshotRestore) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GroupVolumeSnapshotRestore.
+func (in *GroupVolumeSnapshotRestore) DeepCopy() *GroupVolumeSnapshotRestore {
+	if in == nil {
+		return nil
+	}
+	out := new(GroupVolumeSnapshotRestore)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GroupVolumeSnapshotRestore) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GroupVolumeSnapshotRestoreList) DeepCopyInto(out *GroupVolumeSnapshotRestoreList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]GroupVolumeSnapshotRestore, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GroupVolumeSnapshotRestoreList.
+func (in *GroupVolumeSnapshotRestoreList) DeepCopy() *GroupVolumeSnapshotRestoreList {
+	if in == nil {
+		return nil
+	}
+	out := new(GroupVolumeSnapshotRestoreList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GroupVolumeSnapshotRestoreList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GroupVolumeSnapshotRestoreSpec) DeepCopyInto(out *GroupVolumeSnapshotRestoreSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GroupVolumeSnapshotRestoreSpec.
+func (in *GroupVolumeSnapshotRestoreSpec) DeepCopy() *GroupVolumeSnapshotRestoreSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(GroupVolumeSnapshotRestoreSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GroupVolumeSnapshotRestoreStatus) DeepCopyInto(out *GroupVolumeSnapshotRestoreStatus) {
+	*out = *in
+	if in.Volumes != nil {
+		in, out := &in.Volumes, &out.Volumes
+		*out = make([]*RestoreVolumeInfo, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(RestoreVolumeInfo)
+				**out = **in
+			}
+		}
+	}
+	in.FinishTimestamp.DeepCopyInto(&out.FinishTimestamp)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GroupVolumeSnapshotRestoreStatus.
+func (in *GroupVolumeSnapshotRestoreStatus) DeepCopy() *GroupVolumeSnapshotRestoreStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(GroupVolumeSnapshotRestoreStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GroupVolumeSnapshotSpec) DeepCopyInto(out *GroupVolumeSnapshotSpec) {
 	*out = *in
+	if in.RuleParameters != nil {
+		in, out := &in.RuleParameters, &out.RuleParameters
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	in.PVCSelector.DeepCopyInto(&out.PVCSelector)
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.RestoreNamespaces != nil {
 		in, out := &in.RestoreNamespaces, &out.RestoreNamespaces
 		*out = make([]string, len(*in))
@@ -674,6 +1043,17 @@ func (in *GroupVolumeSnapshotStatus) DeepCopyInto(out *GroupVolumeSnapshotStatus
 			}
 		}
 	}
+	if in.RuleResults != nil {
+		in, out := &in.RuleResults, &out.RuleResults
+		*out = make([]*RuleExecutionResult, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(RuleExecutionResult)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
 	return
 }
 
@@ -855,6 +1235,16 @@ func (in *MigrationScheduleSpec) DeepCopyInto(out *MigrationScheduleSpec) {
 		*out = new(bool)
 		**out = **in
 	}
+	if in.DependsOn != nil {
+		in, out := &in.DependsOn, &out.DependsOn
+		*out = new(ScheduleDependency)
+		**out = **in
+	}
+	if in.ClusterDomains != nil {
+		in, out := &in.ClusterDomains, &out.ClusterDomains
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -892,6 +1282,40 @@ func (in *MigrationScheduleStatus) DeepCopyInto(out *MigrationScheduleStatus) {
 			(*out)[key] = outVal
 		}
 	}
+	if in.SkippedTriggers != nil {
+		in, out := &in.SkippedTriggers, &out.SkippedTriggers
+		*out = make([]*SkippedTrigger, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(SkippedTrigger)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	if in.PendingCatchUpTriggers != nil {
+		in, out := &in.PendingCatchUpTriggers, &out.PendingCatchUpTriggers
+		*out = make(map[SchedulePolicyType]int, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ClusterDomainSuspensions != nil {
+		in, out := &in.ClusterDomainSuspensions, &out.ClusterDomainSuspensions
+		*out = make([]*ClusterDomainSuspension, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(ClusterDomainSuspension)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	if in.SuspendedForClusterDomains != nil {
+		in, out := &in.SuspendedForClusterDomains, &out.SuspendedForClusterDomains
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -935,6 +1359,13 @@ func (in *MigrationSpec) DeepCopyInto(out *MigrationSpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.RuleParameters != nil {
+		in, out := &in.RuleParameters, &out.RuleParameters
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	return
 }
 
@@ -974,6 +1405,17 @@ func (in *MigrationStatus) DeepCopyInto(out *MigrationStatus) {
 		}
 	}
 	in.FinishTimestamp.DeepCopyInto(&out.FinishTimestamp)
+	if in.RuleResults != nil {
+		in, out := &in.RuleResults, &out.RuleResults
+		*out = make([]*RuleExecutionResult, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(RuleExecutionResult)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
 	return
 }
 
@@ -1145,6 +1587,89 @@ func (in *NodeStatus) DeepCopy() *NodeStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeStorageStatus) DeepCopyInto(out *NodeStorageStatus) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeStorageStatus.
+func (in *NodeStorageStatus) DeepCopy() *NodeStorageStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeStorageStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *NodeStorageStatus) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeStorageStatusInfo) DeepCopyInto(out *NodeStorageStatusInfo) {
+	*out = *in
+	if in.Pools != nil {
+		in, out := &in.Pools, &out.Pools
+		*out = make([]StoragePoolStatus, len(*in))
+		copy(*out, *in)
+	}
+	in.LastHeartbeatTime.DeepCopyInto(&out.LastHeartbeatTime)
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeStorageStatusInfo.
+func (in *NodeStorageStatusInfo) DeepCopy() *NodeStorageStatusInfo {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeStorageStatusInfo)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeStorageStatusList) DeepCopyInto(out *NodeStorageStatusList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]NodeStorageStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeStorageStatusList.
+func (in *NodeStorageStatusList) DeepCopy() *NodeStorageStatusList {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeStorageStatusList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *NodeStorageStatusList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PVCSelectorSpec) DeepCopyInto(out *PVCSelectorSpec) {
 	*out = *in
@@ -1152,99 +1677,223 @@ func (in *PVCSelectorSpec) DeepCopyInto(out *PVCSelectorSpec) {
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PVCSelectorSpec.
-func (in *PVCSelectorSpec) DeepCopy() *PVCSelectorSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PVCSelectorSpec.
+func (in *PVCSelectorSpec) DeepCopy() *PVCSelectorSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PVCSelectorSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlacementSpec) DeepCopyInto(out *PlacementSpec) {
+	*out = *in
+	if in.NodeAffinity != nil {
+		in, out := &in.NodeAffinity, &out.NodeAffinity
+		*out = new(v1.NodeAffinity)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlacementSpec.
+func (in *PlacementSpec) DeepCopy() *PlacementSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PlacementSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceInfo) DeepCopyInto(out *ResourceInfo) {
+	*out = *in
+	out.GroupVersionKind = in.GroupVersionKind
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceInfo.
+func (in *ResourceInfo) DeepCopy() *ResourceInfo {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceInfo)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestoreApplicationInfo) DeepCopyInto(out *RestoreApplicationInfo) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoreApplicationInfo.
+func (in *RestoreApplicationInfo) DeepCopy() *RestoreApplicationInfo {
+	if in == nil {
+		return nil
+	}
+	out := new(RestoreApplicationInfo)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestoreVolumeInfo) DeepCopyInto(out *RestoreVolumeInfo) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoreVolumeInfo.
+func (in *RestoreVolumeInfo) DeepCopy() *RestoreVolumeInfo {
+	if in == nil {
+		return nil
+	}
+	out := new(RestoreVolumeInfo)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Rule) DeepCopyInto(out *Rule) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]RuleItem, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Rule.
+func (in *Rule) DeepCopy() *Rule {
+	if in == nil {
+		return nil
+	}
+	out := new(Rule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Rule) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RuleAction) DeepCopyInto(out *RuleAction) {
+	*out = *in
+	if in.Order != nil {
+		in, out := &in.Order, &out.Order
+		*out = new(RuleActionOrder)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Job != nil {
+		in, out := &in.Job, &out.Job
+		*out = new(RuleActionJobSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Retry != nil {
+		in, out := &in.Retry, &out.Retry
+		*out = new(RuleActionRetry)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RuleAction.
+func (in *RuleAction) DeepCopy() *RuleAction {
 	if in == nil {
 		return nil
 	}
-	out := new(PVCSelectorSpec)
+	out := new(RuleAction)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PlacementSpec) DeepCopyInto(out *PlacementSpec) {
+func (in *RuleActionJobSpec) DeepCopyInto(out *RuleActionJobSpec) {
 	*out = *in
-	if in.NodeAffinity != nil {
-		in, out := &in.NodeAffinity, &out.NodeAffinity
-		*out = new(v1.NodeAffinity)
-		(*in).DeepCopyInto(*out)
+	if in.Command != nil {
+		in, out := &in.Command, &out.Command
+		*out = make([]string, len(*in))
+		copy(*out, *in)
 	}
+	in.Resources.DeepCopyInto(&out.Resources)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlacementSpec.
-func (in *PlacementSpec) DeepCopy() *PlacementSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RuleActionJobSpec.
+func (in *RuleActionJobSpec) DeepCopy() *RuleActionJobSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(PlacementSpec)
+	out := new(RuleActionJobSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ResourceInfo) DeepCopyInto(out *ResourceInfo) {
+func (in *RuleActionOrder) DeepCopyInto(out *RuleActionOrder) {
 	*out = *in
-	out.GroupVersionKind = in.GroupVersionKind
+	if in.Values != nil {
+		in, out := &in.Values, &out.Values
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceInfo.
-func (in *ResourceInfo) DeepCopy() *ResourceInfo {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RuleActionOrder.
+func (in *RuleActionOrder) DeepCopy() *RuleActionOrder {
 	if in == nil {
 		return nil
 	}
-	out := new(ResourceInfo)
+	out := new(RuleActionOrder)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Rule) DeepCopyInto(out *Rule) {
+func (in *RuleActionRetry) DeepCopyInto(out *RuleActionRetry) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	if in.Rules != nil {
-		in, out := &in.Rules, &out.Rules
-		*out = make([]RuleItem, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Rule.
-func (in *Rule) DeepCopy() *Rule {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RuleActionRetry.
+func (in *RuleActionRetry) DeepCopy() *RuleActionRetry {
 	if in == nil {
 		return nil
 	}
-	out := new(Rule)
+	out := new(RuleActionRetry)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *Rule) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
-	}
-	return nil
-}
-
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *RuleAction) DeepCopyInto(out *RuleAction) {
+func (in *RuleExecutionResult) DeepCopyInto(out *RuleExecutionResult) {
 	*out = *in
+	in.StartTimestamp.DeepCopyInto(&out.StartTimestamp)
+	out.Duration = in.Duration
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RuleAction.
-func (in *RuleAction) DeepCopy() *RuleAction {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RuleExecutionResult.
+func (in *RuleExecutionResult) DeepCopy() *RuleExecutionResult {
 	if in == nil {
 		return nil
 	}
-	out := new(RuleAction)
+	out := new(RuleExecutionResult)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -1262,7 +1911,9 @@ func (in *RuleItem) DeepCopyInto(out *RuleItem) {
 	if in.Actions != nil {
 		in, out := &in.Actions, &out.Actions
 		*out = make([]RuleAction, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	return
 }
@@ -1310,12 +1961,33 @@ func (in *RuleList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScheduleDependency) DeepCopyInto(out *ScheduleDependency) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScheduleDependency.
+func (in *ScheduleDependency) DeepCopy() *ScheduleDependency {
+	if in == nil {
+		return nil
+	}
+	out := new(ScheduleDependency)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SchedulePolicy) DeepCopyInto(out *SchedulePolicy) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Policy.DeepCopyInto(&out.Policy)
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -1360,6 +2032,33 @@ func (in *SchedulePolicyItem) DeepCopyInto(out *SchedulePolicyItem) {
 		*out = new(MonthlyPolicy)
 		**out = **in
 	}
+	if in.Cron != nil {
+		in, out := &in.Cron, &out.Cron
+		*out = new(CronPolicy)
+		**out = **in
+	}
+	if in.BlackoutWindows != nil {
+		in, out := &in.BlackoutWindows, &out.BlackoutWindows
+		*out = make([]*BlackoutWindow, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(BlackoutWindow)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	if in.ExcludedDates != nil {
+		in, out := &in.ExcludedDates, &out.ExcludedDates
+		*out = make([]*ExcludedDates, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(ExcludedDates)
+				**out = **in
+			}
+		}
+	}
 	return
 }
 
@@ -1442,6 +2141,23 @@ func (in *ScheduledVolumeSnapshotStatus) DeepCopy() *ScheduledVolumeSnapshotStat
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SkippedTrigger) DeepCopyInto(out *SkippedTrigger) {
+	*out = *in
+	in.Timestamp.DeepCopyInto(&out.Timestamp)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SkippedTrigger.
+func (in *SkippedTrigger) DeepCopy() *SkippedTrigger {
+	if in == nil {
+		return nil
+	}
+	out := new(SkippedTrigger)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StorageCluster) DeepCopyInto(out *StorageCluster) {
 	*out = *in
@@ -1569,6 +2285,22 @@ func (in *StorageClusterStatus) DeepCopy() *StorageClusterStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StoragePoolStatus) DeepCopyInto(out *StoragePoolStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StoragePoolStatus.
+func (in *StoragePoolStatus) DeepCopy() *StoragePoolStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(StoragePoolStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StorageSpec) DeepCopyInto(out *StorageSpec) {
 	*out = *in
@@ -1640,6 +2372,122 @@ func (in *VolumeInfo) DeepCopy() *VolumeInfo {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeSnapshotRestore) DeepCopyInto(out *VolumeSnapshotRestore) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeSnapshotRestore.
+func (in *VolumeSnapshotRestore) DeepCopy() *VolumeSnapshotRestore {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumeSnapshotRestore)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VolumeSnapshotRestore) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeSnapshotRestoreList) DeepCopyInto(out *VolumeSnapshotRestoreList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]VolumeSnapshotRestore, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeSnapshotRestoreList.
+func (in *VolumeSnapshotRestoreList) DeepCopy() *VolumeSnapshotRestoreList {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumeSnapshotRestoreList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VolumeSnapshotRestoreList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeSnapshotRestoreSpec) DeepCopyInto(out *VolumeSnapshotRestoreSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeSnapshotRestoreSpec.
+func (in *VolumeSnapshotRestoreSpec) DeepCopy() *VolumeSnapshotRestoreSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumeSnapshotRestoreSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeSnapshotRestoreStatus) DeepCopyInto(out *VolumeSnapshotRestoreStatus) {
+	*out = *in
+	if in.Volumes != nil {
+		in, out := &in.Volumes, &out.Volumes
+		*out = make([]*RestoreVolumeInfo, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(RestoreVolumeInfo)
+				**out = **in
+			}
+		}
+	}
+	if in.Applications != nil {
+		in, out := &in.Applications, &out.Applications
+		*out = make([]*RestoreApplicationInfo, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(RestoreApplicationInfo)
+				**out = **in
+			}
+		}
+	}
+	in.FinishTimestamp.DeepCopyInto(&out.FinishTimestamp)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeSnapshotRestoreStatus.
+func (in *VolumeSnapshotRestoreStatus) DeepCopy() *VolumeSnapshotRestoreStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumeSnapshotRestoreStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VolumeSnapshotSchedule) DeepCopyInto(out *VolumeSnapshotSchedule) {
 	*out = *in
@@ -1710,6 +2558,21 @@ func (in *VolumeSnapshotScheduleSpec) DeepCopyInto(out *VolumeSnapshotScheduleSp
 		*out = new(bool)
 		**out = **in
 	}
+	if in.CloudSnapshot != nil {
+		in, out := &in.CloudSnapshot, &out.CloudSnapshot
+		*out = new(CloudSnapshotSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DependsOn != nil {
+		in, out := &in.DependsOn, &out.DependsOn
+		*out = new(ScheduleDependency)
+		**out = **in
+	}
+	if in.ClusterDomains != nil {
+		in, out := &in.ClusterDomains, &out.ClusterDomains
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -1747,6 +2610,75 @@ func (in *VolumeSnapshotScheduleStatus) DeepCopyInto(out *VolumeSnapshotSchedule
 			(*out)[key] = outVal
 		}
 	}
+	if in.SkippedTriggers != nil {
+		in, out := &in.SkippedTriggers, &out.SkippedTriggers
+		*out = make([]*SkippedTrigger, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(SkippedTrigger)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	if in.CloudItems != nil {
+		in, out := &in.CloudItems, &out.CloudItems
+		*out = make(map[SchedulePolicyType][]*ScheduledVolumeSnapshotStatus, len(*in))
+		for key, val := range *in {
+			var outVal []*ScheduledVolumeSnapshotStatus
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = make([]*ScheduledVolumeSnapshotStatus, len(*in))
+				for i := range *in {
+					if (*in)[i] != nil {
+						in, out := &(*in)[i], &(*out)[i]
+						*out = new(ScheduledVolumeSnapshotStatus)
+						(*in).DeepCopyInto(*out)
+					}
+				}
+			}
+			(*out)[key] = outVal
+		}
+	}
+	if in.NumTriggered != nil {
+		in, out := &in.NumTriggered, &out.NumTriggered
+		*out = make(map[SchedulePolicyType]int, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ChainLength != nil {
+		in, out := &in.ChainLength, &out.ChainLength
+		*out = make(map[SchedulePolicyType]int, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.PendingCatchUpTriggers != nil {
+		in, out := &in.PendingCatchUpTriggers, &out.PendingCatchUpTriggers
+		*out = make(map[SchedulePolicyType]int, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ClusterDomainSuspensions != nil {
+		in, out := &in.ClusterDomainSuspensions, &out.ClusterDomainSuspensions
+		*out = make([]*ClusterDomainSuspension, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(ClusterDomainSuspension)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	if in.SuspendedForClusterDomains != nil {
+		in, out := &in.SuspendedForClusterDomains, &out.SuspendedForClusterDomains
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
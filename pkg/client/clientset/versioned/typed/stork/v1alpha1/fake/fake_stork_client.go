@@ -44,10 +44,18 @@ func (c *FakeStorkV1alpha1) ClusterPairs(namespace string) v1alpha1.ClusterPairI
 	return &FakeClusterPairs{c, namespace}
 }
 
+func (c *FakeStorkV1alpha1) ClusterRules() v1alpha1.ClusterRuleInterface {
+	return &FakeClusterRules{c}
+}
+
 func (c *FakeStorkV1alpha1) GroupVolumeSnapshots(namespace string) v1alpha1.GroupVolumeSnapshotInterface {
 	return &FakeGroupVolumeSnapshots{c, namespace}
 }
 
+func (c *FakeStorkV1alpha1) GroupVolumeSnapshotRestores(namespace string) v1alpha1.GroupVolumeSnapshotRestoreInterface {
+	return &FakeGroupVolumeSnapshotRestores{c, namespace}
+}
+
 func (c *FakeStorkV1alpha1) Migrations(namespace string) v1alpha1.MigrationInterface {
 	return &FakeMigrations{c, namespace}
 }
@@ -56,6 +64,10 @@ func (c *FakeStorkV1alpha1) MigrationSchedules(namespace string) v1alpha1.Migrat
 	return &FakeMigrationSchedules{c, namespace}
 }
 
+func (c *FakeStorkV1alpha1) NodeStorageStatuses() v1alpha1.NodeStorageStatusInterface {
+	return &FakeNodeStorageStatuses{c}
+}
+
 func (c *FakeStorkV1alpha1) Rules(namespace string) v1alpha1.RuleInterface {
 	return &FakeRules{c, namespace}
 }
@@ -68,6 +80,10 @@ func (c *FakeStorkV1alpha1) StorageClusters(namespace string) v1alpha1.StorageCl
 	return &FakeStorageClusters{c, namespace}
 }
 
+func (c *FakeStorkV1alpha1) VolumeSnapshotRestores(namespace string) v1alpha1.VolumeSnapshotRestoreInterface {
+	return &FakeVolumeSnapshotRestores{c, namespace}
+}
+
 func (c *FakeStorkV1alpha1) VolumeSnapshotSchedules(namespace string) v1alpha1.VolumeSnapshotScheduleInterface {
 	return &FakeVolumeSnapshotSchedules{c, namespace}
 }
@@ -26,16 +26,24 @@ type ClusterDomainsStatusExpansion interface{}
 
 type ClusterPairExpansion interface{}
 
+type ClusterRuleExpansion interface{}
+
 type GroupVolumeSnapshotExpansion interface{}
 
+type GroupVolumeSnapshotRestoreExpansion interface{}
+
 type MigrationExpansion interface{}
 
 type MigrationScheduleExpansion interface{}
 
+type NodeStorageStatusExpansion interface{}
+
 type RuleExpansion interface{}
 
 type SchedulePolicyExpansion interface{}
 
 type StorageClusterExpansion interface{}
 
+type VolumeSnapshotRestoreExpansion interface{}
+
 type VolumeSnapshotScheduleExpansion interface{}
@@ -31,12 +31,16 @@ type StorkV1alpha1Interface interface {
 	ClusterDomainUpdatesGetter
 	ClusterDomainsStatusesGetter
 	ClusterPairsGetter
+	ClusterRulesGetter
 	GroupVolumeSnapshotsGetter
+	GroupVolumeSnapshotRestoresGetter
 	MigrationsGetter
 	MigrationSchedulesGetter
+	NodeStorageStatusesGetter
 	RulesGetter
 	SchedulePoliciesGetter
 	StorageClustersGetter
+	VolumeSnapshotRestoresGetter
 	VolumeSnapshotSchedulesGetter
 }
 
@@ -61,10 +65,18 @@ func (c *StorkV1alpha1Client) ClusterPairs(namespace string) ClusterPairInterfac
 	return newClusterPairs(c, namespace)
 }
 
+func (c *StorkV1alpha1Client) ClusterRules() ClusterRuleInterface {
+	return newClusterRules(c)
+}
+
 func (c *StorkV1alpha1Client) GroupVolumeSnapshots(namespace string) GroupVolumeSnapshotInterface {
 	return newGroupVolumeSnapshots(c, namespace)
 }
 
+func (c *StorkV1alpha1Client) GroupVolumeSnapshotRestores(namespace string) GroupVolumeSnapshotRestoreInterface {
+	return newGroupVolumeSnapshotRestores(c, namespace)
+}
+
 func (c *StorkV1alpha1Client) Migrations(namespace string) MigrationInterface {
 	return newMigrations(c, namespace)
 }
@@ -73,6 +85,10 @@ func (c *StorkV1alpha1Client) MigrationSchedules(namespace string) MigrationSche
 	return newMigrationSchedules(c, namespace)
 }
 
+func (c *StorkV1alpha1Client) NodeStorageStatuses() NodeStorageStatusInterface {
+	return newNodeStorageStatuses(c)
+}
+
 func (c *StorkV1alpha1Client) Rules(namespace string) RuleInterface {
 	return newRules(c, namespace)
 }
@@ -85,6 +101,10 @@ func (c *StorkV1alpha1Client) StorageClusters(namespace string) StorageClusterIn
 	return newStorageClusters(c, namespace)
 }
 
+func (c *StorkV1alpha1Client) VolumeSnapshotRestores(namespace string) VolumeSnapshotRestoreInterface {
+	return newVolumeSnapshotRestores(c, namespace)
+}
+
 func (c *StorkV1alpha1Client) VolumeSnapshotSchedules(namespace string) VolumeSnapshotScheduleInterface {
 	return newVolumeSnapshotSchedules(c, namespace)
 }
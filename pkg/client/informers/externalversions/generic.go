@@ -61,18 +61,26 @@ func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Stork().V1alpha1().ClusterDomainsStatuses().Informer()}, nil
 	case v1alpha1.SchemeGroupVersion.WithResource("clusterpairs"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Stork().V1alpha1().ClusterPairs().Informer()}, nil
+	case v1alpha1.SchemeGroupVersion.WithResource("clusterrules"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Stork().V1alpha1().ClusterRules().Informer()}, nil
 	case v1alpha1.SchemeGroupVersion.WithResource("groupvolumesnapshots"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Stork().V1alpha1().GroupVolumeSnapshots().Informer()}, nil
+	case v1alpha1.SchemeGroupVersion.WithResource("groupvolumesnapshotrestores"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Stork().V1alpha1().GroupVolumeSnapshotRestores().Informer()}, nil
 	case v1alpha1.SchemeGroupVersion.WithResource("migrations"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Stork().V1alpha1().Migrations().Informer()}, nil
 	case v1alpha1.SchemeGroupVersion.WithResource("migrationschedules"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Stork().V1alpha1().MigrationSchedules().Informer()}, nil
+	case v1alpha1.SchemeGroupVersion.WithResource("nodestoragestatuses"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Stork().V1alpha1().NodeStorageStatuses().Informer()}, nil
 	case v1alpha1.SchemeGroupVersion.WithResource("rules"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Stork().V1alpha1().Rules().Informer()}, nil
 	case v1alpha1.SchemeGroupVersion.WithResource("schedulepolicies"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Stork().V1alpha1().SchedulePolicies().Informer()}, nil
 	case v1alpha1.SchemeGroupVersion.WithResource("storageclusters"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Stork().V1alpha1().StorageClusters().Informer()}, nil
+	case v1alpha1.SchemeGroupVersion.WithResource("volumesnapshotrestores"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Stork().V1alpha1().VolumeSnapshotRestores().Informer()}, nil
 	case v1alpha1.SchemeGroupVersion.WithResource("volumesnapshotschedules"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Stork().V1alpha1().VolumeSnapshotSchedules().Informer()}, nil
 
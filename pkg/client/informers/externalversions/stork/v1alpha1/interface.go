@@ -32,18 +32,26 @@ type Interface interface {
 	ClusterDomainsStatuses() ClusterDomainsStatusInformer
 	// ClusterPairs returns a ClusterPairInformer.
 	ClusterPairs() ClusterPairInformer
+	// ClusterRules returns a ClusterRuleInformer.
+	ClusterRules() ClusterRuleInformer
 	// GroupVolumeSnapshots returns a GroupVolumeSnapshotInformer.
 	GroupVolumeSnapshots() GroupVolumeSnapshotInformer
+	// GroupVolumeSnapshotRestores returns a GroupVolumeSnapshotRestoreInformer.
+	GroupVolumeSnapshotRestores() GroupVolumeSnapshotRestoreInformer
 	// Migrations returns a MigrationInformer.
 	Migrations() MigrationInformer
 	// MigrationSchedules returns a MigrationScheduleInformer.
 	MigrationSchedules() MigrationScheduleInformer
+	// NodeStorageStatuses returns a NodeStorageStatusInformer.
+	NodeStorageStatuses() NodeStorageStatusInformer
 	// Rules returns a RuleInformer.
 	Rules() RuleInformer
 	// SchedulePolicies returns a SchedulePolicyInformer.
 	SchedulePolicies() SchedulePolicyInformer
 	// StorageClusters returns a StorageClusterInformer.
 	StorageClusters() StorageClusterInformer
+	// VolumeSnapshotRestores returns a VolumeSnapshotRestoreInformer.
+	VolumeSnapshotRestores() VolumeSnapshotRestoreInformer
 	// VolumeSnapshotSchedules returns a VolumeSnapshotScheduleInformer.
 	VolumeSnapshotSchedules() VolumeSnapshotScheduleInformer
 }
@@ -79,11 +87,21 @@ func (v *version) ClusterPairs() ClusterPairInformer {
 	return &clusterPairInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
 
+// ClusterRules returns a ClusterRuleInformer.
+func (v *version) ClusterRules() ClusterRuleInformer {
+	return &clusterRuleInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
 // GroupVolumeSnapshots returns a GroupVolumeSnapshotInformer.
 func (v *version) GroupVolumeSnapshots() GroupVolumeSnapshotInformer {
 	return &groupVolumeSnapshotInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
 
+// GroupVolumeSnapshotRestores returns a GroupVolumeSnapshotRestoreInformer.
+func (v *version) GroupVolumeSnapshotRestores() GroupVolumeSnapshotRestoreInformer {
+	return &groupVolumeSnapshotRestoreInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // Migrations returns a MigrationInformer.
 func (v *version) Migrations() MigrationInformer {
 	return &migrationInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
@@ -94,6 +112,11 @@ func (v *version) MigrationSchedules() MigrationScheduleInformer {
 	return &migrationScheduleInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
 
+// NodeStorageStatuses returns a NodeStorageStatusInformer.
+func (v *version) NodeStorageStatuses() NodeStorageStatusInformer {
+	return &nodeStorageStatusInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
 // Rules returns a RuleInformer.
 func (v *version) Rules() RuleInformer {
 	return &ruleInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
@@ -109,6 +132,11 @@ func (v *version) StorageClusters() StorageClusterInformer {
 	return &storageClusterInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
 
+// VolumeSnapshotRestores returns a VolumeSnapshotRestoreInformer.
+func (v *version) VolumeSnapshotRestores() VolumeSnapshotRestoreInformer {
+	return &volumeSnapshotRestoreInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // VolumeSnapshotSchedules returns a VolumeSnapshotScheduleInformer.
 func (v *version) VolumeSnapshotSchedules() VolumeSnapshotScheduleInformer {
 	return &volumeSnapshotScheduleInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
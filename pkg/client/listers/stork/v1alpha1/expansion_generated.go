@@ -42,6 +42,10 @@ type ClusterPairListerExpansion interface{}
 // ClusterPairNamespaceLister.
 type ClusterPairNamespaceListerExpansion interface{}
 
+// ClusterRuleListerExpansion allows custom methods to be added to
+// ClusterRuleLister.
+type ClusterRuleListerExpansion interface{}
+
 // GroupVolumeSnapshotListerExpansion allows custom methods to be added to
 // GroupVolumeSnapshotLister.
 type GroupVolumeSnapshotListerExpansion interface{}
@@ -50,6 +54,14 @@ type GroupVolumeSnapshotListerExpansion interface{}
 // GroupVolumeSnapshotNamespaceLister.
 type GroupVolumeSnapshotNamespaceListerExpansion interface{}
 
+// GroupVolumeSnapshotRestoreListerExpansion allows custom methods to be added to
+// GroupVolumeSnapshotRestoreLister.
+type GroupVolumeSnapshotRestoreListerExpansion interface{}
+
+// GroupVolumeSnapshotRestoreNamespaceListerExpansion allows custom methods to be added to
+// GroupVolumeSnapshotRestoreNamespaceLister.
+type GroupVolumeSnapshotRestoreNamespaceListerExpansion interface{}
+
 // MigrationListerExpansion allows custom methods to be added to
 // MigrationLister.
 type MigrationListerExpansion interface{}
@@ -66,6 +78,10 @@ type MigrationScheduleListerExpansion interface{}
 // MigrationScheduleNamespaceLister.
 type MigrationScheduleNamespaceListerExpansion interface{}
 
+// NodeStorageStatusListerExpansion allows custom methods to be added to
+// NodeStorageStatusLister.
+type NodeStorageStatusListerExpansion interface{}
+
 // RuleListerExpansion allows custom methods to be added to
 // RuleLister.
 type RuleListerExpansion interface{}
@@ -86,6 +102,14 @@ type StorageClusterListerExpansion interface{}
 // StorageClusterNamespaceLister.
 type StorageClusterNamespaceListerExpansion interface{}
 
+// VolumeSnapshotRestoreListerExpansion allows custom methods to be added to
+// VolumeSnapshotRestoreLister.
+type VolumeSnapshotRestoreListerExpansion interface{}
+
+// VolumeSnapshotRestoreNamespaceListerExpansion allows custom methods to be added to
+// VolumeSnapshotRestoreNamespaceLister.
+type VolumeSnapshotRestoreNamespaceListerExpansion interface{}
+
 // VolumeSnapshotScheduleListerExpansion allows custom methods to be added to
 // VolumeSnapshotScheduleLister.
 type VolumeSnapshotScheduleListerExpansion interface{}
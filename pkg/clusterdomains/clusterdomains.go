@@ -2,6 +2,7 @@ package clusterdomains
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/libopenstorage/stork/drivers/volume"
 	"github.com/libopenstorage/stork/pkg/clusterdomains/controllers"
@@ -10,10 +11,26 @@ import (
 
 // ClusterDomains is a wrapper over the cluster domains CRD controllers
 type ClusterDomains struct {
-	Driver                         volume.Driver
-	Recorder                       record.EventRecorder
-	clusterDomainsStatusController *controllers.ClusterDomainsStatusController
-	clusterDomainUpdateController  *controllers.ClusterDomainUpdateController
+	Driver   volume.Driver
+	Recorder record.EventRecorder
+	// LocalDomain is the name of the cluster domain in which stork is running
+	LocalDomain string
+	// RequireConfirmation if set, ClusterDomainUpdates that deactivate a
+	// cluster domain need to be confirmed once their impact is reported
+	RequireConfirmation bool
+	// WitnessURL is the url of the witness used to automatically fail over
+	// cluster domains. Automatic failover is disabled if this isn't set
+	WitnessURL string
+	// FailoverTimeout is the time for which the witness shouldn't have
+	// received a heartbeat from a cluster domain before it is deactivated
+	FailoverTimeout time.Duration
+	// WitnessInterval is the interval at which heartbeats are sent to the
+	// witness
+	WitnessInterval time.Duration
+
+	clusterDomainsStatusController  *controllers.ClusterDomainsStatusController
+	clusterDomainUpdateController   *controllers.ClusterDomainUpdateController
+	clusterDomainFailoverController *controllers.ClusterDomainFailoverController
 }
 
 // Init initializes all the cluster domain controllers
@@ -26,11 +43,34 @@ func (c *ClusterDomains) Init() error {
 		return fmt.Errorf("error initializing clusterdomainsstatus controller: %v", err)
 	}
 	c.clusterDomainUpdateController = &controllers.ClusterDomainUpdateController{
-		Driver:   c.Driver,
-		Recorder: c.Recorder,
+		Driver:              c.Driver,
+		Recorder:            c.Recorder,
+		LocalDomain:         c.LocalDomain,
+		RequireConfirmation: c.RequireConfirmation,
 	}
 	if err := c.clusterDomainUpdateController.Init(); err != nil {
 		return fmt.Errorf("error initializing clusterdomainupdate controller: %v", err)
 	}
+	if c.WitnessURL != "" {
+		c.clusterDomainFailoverController = &controllers.ClusterDomainFailoverController{
+			Driver:          c.Driver,
+			Recorder:        c.Recorder,
+			LocalDomain:     c.LocalDomain,
+			WitnessURL:      c.WitnessURL,
+			FailoverTimeout: c.FailoverTimeout,
+			Interval:        c.WitnessInterval,
+		}
+		if err := c.clusterDomainFailoverController.Init(); err != nil {
+			return fmt.Errorf("error initializing clusterdomain failover controller: %v", err)
+		}
+	}
+	return nil
+}
+
+// Stop stops the cluster domain controllers that run in the background
+func (c *ClusterDomains) Stop() error {
+	if c.clusterDomainFailoverController != nil {
+		return c.clusterDomainFailoverController.Stop()
+	}
 	return nil
 }
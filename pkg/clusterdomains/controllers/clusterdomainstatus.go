@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"reflect"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 
@@ -30,6 +31,11 @@ const (
 	resyncPeriod                         = 1 * time.Minute
 	createCdsTimeout                     = 30 * time.Minute
 	createCdsRetryInterval               = 10 * time.Second
+
+	clusterDomainActivatedEventReason   = "ClusterDomainActivated"
+	clusterDomainDeactivatedEventReason = "ClusterDomainDeactivated"
+	clusterDomainInSyncEventReason      = "ClusterDomainInSync"
+	clusterDomainNotInSyncEventReason   = "ClusterDomainNotInSync"
 )
 
 var (
@@ -95,10 +101,18 @@ func (c *ClusterDomainsStatusController) Handle(ctx context.Context, event sdk.E
 					updated = true
 				}
 			}
+			infos := getClusterDomainInfos(clusterDomainsInfo, clusterDomainsStatus.Status.ClusterDomainInfos, metav1.Now())
+			c.recordTransitions(clusterDomainsStatus, infos)
+			setClusterDomainMetrics(infos)
+			if !reflect.DeepEqual(infos, clusterDomainsStatus.Status.ClusterDomainInfos) {
+				updated = true
+			}
+			clusterDomainsInfo.ClusterDomainInfos = infos
 		}
 		if updated {
 			clusterDomainsStatus.Status.Active = clusterDomainsInfo.Active
 			clusterDomainsStatus.Status.Inactive = clusterDomainsInfo.Inactive
+			clusterDomainsStatus.Status.ClusterDomainInfos = clusterDomainsInfo.ClusterDomainInfos
 			if err := sdk.Update(clusterDomainsStatus); err != nil {
 				return err
 			}
@@ -107,6 +121,93 @@ func (c *ClusterDomainsStatusController) Handle(ctx context.Context, event sdk.E
 	return nil
 }
 
+// getClusterDomainInfos returns the state and sync status of the cluster
+// domains reported by the driver. The sync status is unknown if the driver
+// doesn't report it. The last transition time is carried over from the
+// current infos for cluster domains whose state and sync status haven't
+// changed.
+func getClusterDomainInfos(
+	domains *storkv1.ClusterDomains,
+	current []storkv1.ClusterDomainInfo,
+	now metav1.Time,
+) []storkv1.ClusterDomainInfo {
+	reported := make(map[string]storkv1.ClusterDomainInfo)
+	for _, info := range domains.ClusterDomainInfos {
+		reported[info.Name] = info
+	}
+	previous := make(map[string]storkv1.ClusterDomainInfo)
+	for _, info := range current {
+		previous[info.Name] = info
+	}
+
+	infos := make([]storkv1.ClusterDomainInfo, 0, len(domains.Active)+len(domains.Inactive))
+	addInfo := func(name string, state storkv1.ClusterDomainActiveState) {
+		info := storkv1.ClusterDomainInfo{
+			Name:       name,
+			State:      state,
+			SyncStatus: reported[name].SyncStatus,
+		}
+		if info.SyncStatus == "" {
+			info.SyncStatus = storkv1.ClusterDomainSyncStatusUnknown
+		}
+		if prev, ok := previous[name]; ok && prev.State == info.State && prev.SyncStatus == info.SyncStatus {
+			info.LastTransitionTime = prev.LastTransitionTime
+		} else {
+			info.LastTransitionTime = now
+		}
+		infos = append(infos, info)
+	}
+	for _, name := range domains.Active {
+		addInfo(name, storkv1.ClusterDomainActive)
+	}
+	for _, name := range domains.Inactive {
+		addInfo(name, storkv1.ClusterDomainInactive)
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name < infos[j].Name
+	})
+	return infos
+}
+
+// recordTransitions raises events and updates the metrics for the cluster
+// domains whose state or sync status has changed since the last update of
+// the status
+func (c *ClusterDomainsStatusController) recordTransitions(
+	clusterDomainsStatus *storkv1.ClusterDomainsStatus,
+	infos []storkv1.ClusterDomainInfo,
+) {
+	previous := make(map[string]storkv1.ClusterDomainInfo)
+	for _, info := range clusterDomainsStatus.Status.ClusterDomainInfos {
+		previous[info.Name] = info
+	}
+	for _, info := range infos {
+		prev, ok := previous[info.Name]
+		if !ok {
+			continue
+		}
+		if prev.State != info.State {
+			clusterDomainTransitions.WithLabelValues(info.Name, string(info.State)).Inc()
+			if info.State == storkv1.ClusterDomainActive {
+				c.Recorder.Event(clusterDomainsStatus, v1.EventTypeNormal, clusterDomainActivatedEventReason,
+					fmt.Sprintf("Cluster domain %v is active", info.Name))
+			} else {
+				c.Recorder.Event(clusterDomainsStatus, v1.EventTypeWarning, clusterDomainDeactivatedEventReason,
+					fmt.Sprintf("Cluster domain %v is inactive", info.Name))
+			}
+		}
+		if prev.SyncStatus != info.SyncStatus {
+			switch info.SyncStatus {
+			case storkv1.ClusterDomainSyncStatusInSync:
+				c.Recorder.Event(clusterDomainsStatus, v1.EventTypeNormal, clusterDomainInSyncEventReason,
+					fmt.Sprintf("Cluster domain %v is in sync", info.Name))
+			case storkv1.ClusterDomainSyncStatusNotInSync:
+				c.Recorder.Event(clusterDomainsStatus, v1.EventTypeWarning, clusterDomainNotInSyncEventReason,
+					fmt.Sprintf("Cluster domain %v is not in sync", info.Name))
+			}
+		}
+	}
+}
+
 func (c *ClusterDomainsStatusController) doListsMatch(domainListSDK, domainListCRD []string) bool {
 	for _, sdkDomain := range domainListSDK {
 		for _, crdDomain := range domainListCRD {
@@ -121,6 +222,12 @@ func (c *ClusterDomainsStatusController) doListsMatch(domainListSDK, domainListC
 }
 
 func (c *ClusterDomainsStatusController) createClusterDomainsStatusObject() {
+	// The status can't be populated if the driver doesn't support cluster
+	// domains
+	if err := volume.CheckCapability(c.Driver, volume.CapabilityClusterDomains); err != nil {
+		logrus.Infof("Not creating cluster domains status object: %v", err)
+		return
+	}
 	t := func() (interface{}, bool, error) {
 
 		clusterID, err := c.Driver.GetClusterID()
@@ -0,0 +1,106 @@
+// +build unittest
+
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func getMetricValue(t *testing.T, metric prometheus.Metric) float64 {
+	m := &dto.Metric{}
+	require.NoError(t, metric.Write(m), "Error reading metric")
+	if m.GetCounter() != nil {
+		return m.GetCounter().GetValue()
+	}
+	return m.GetGauge().GetValue()
+}
+
+func TestClusterDomainInfos(t *testing.T) {
+	then := metav1.NewTime(time.Unix(1000, 0))
+	now := metav1.NewTime(time.Unix(2000, 0))
+	domains := &storkv1.ClusterDomains{
+		Active:   []string{"west", "east"},
+		Inactive: []string{"north"},
+		ClusterDomainInfos: []storkv1.ClusterDomainInfo{
+			{Name: "west", SyncStatus: storkv1.ClusterDomainSyncStatusNotInSync},
+		},
+	}
+	current := []storkv1.ClusterDomainInfo{
+		{
+			Name:               "east",
+			State:              storkv1.ClusterDomainActive,
+			SyncStatus:         storkv1.ClusterDomainSyncStatusUnknown,
+			LastTransitionTime: then,
+		},
+		{
+			Name:               "west",
+			State:              storkv1.ClusterDomainActive,
+			SyncStatus:         storkv1.ClusterDomainSyncStatusInSync,
+			LastTransitionTime: then,
+		},
+		{
+			Name:               "north",
+			State:              storkv1.ClusterDomainActive,
+			SyncStatus:         storkv1.ClusterDomainSyncStatusUnknown,
+			LastTransitionTime: then,
+		},
+	}
+
+	infos := getClusterDomainInfos(domains, current, now)
+	require.Equal(t, []storkv1.ClusterDomainInfo{
+		{
+			Name:               "east",
+			State:              storkv1.ClusterDomainActive,
+			SyncStatus:         storkv1.ClusterDomainSyncStatusUnknown,
+			LastTransitionTime: then,
+		},
+		{
+			Name:               "north",
+			State:              storkv1.ClusterDomainInactive,
+			SyncStatus:         storkv1.ClusterDomainSyncStatusUnknown,
+			LastTransitionTime: now,
+		},
+		{
+			Name:               "west",
+			State:              storkv1.ClusterDomainActive,
+			SyncStatus:         storkv1.ClusterDomainSyncStatusNotInSync,
+			LastTransitionTime: now,
+		},
+	}, infos)
+
+	recorder := record.NewFakeRecorder(10)
+	c := &ClusterDomainsStatusController{Recorder: recorder}
+	status := &storkv1.ClusterDomainsStatus{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		Status:     storkv1.ClusterDomains{ClusterDomainInfos: current},
+	}
+	transitions := getMetricValue(t, clusterDomainTransitions.WithLabelValues("north", string(storkv1.ClusterDomainInactive)))
+	c.recordTransitions(status, infos)
+	require.Len(t, recorder.Events, 2)
+	require.Contains(t, <-recorder.Events, "Warning ClusterDomainDeactivated Cluster domain north is inactive")
+	require.Contains(t, <-recorder.Events, "Warning ClusterDomainNotInSync Cluster domain west is not in sync")
+	require.Equal(t, transitions+1,
+		getMetricValue(t, clusterDomainTransitions.WithLabelValues("north", string(storkv1.ClusterDomainInactive))))
+
+	// Nothing is recorded if nothing has changed
+	status.Status.ClusterDomainInfos = infos
+	c.recordTransitions(status, getClusterDomainInfos(domains, infos, now))
+	require.Empty(t, recorder.Events)
+
+	setClusterDomainMetrics(infos)
+	require.Equal(t, 1.0, getMetricValue(t, clusterDomainActive.WithLabelValues("east")))
+	require.Equal(t, 0.0, getMetricValue(t, clusterDomainActive.WithLabelValues("north")))
+	require.Equal(t, 1.0, getMetricValue(t,
+		clusterDomainSyncStatus.WithLabelValues("west", string(storkv1.ClusterDomainSyncStatusNotInSync))))
+	require.Equal(t, 0.0, getMetricValue(t,
+		clusterDomainSyncStatus.WithLabelValues("west", string(storkv1.ClusterDomainSyncStatusInSync))))
+	require.Equal(t, 2000.0, getMetricValue(t, clusterDomainLastTransition.WithLabelValues("west")))
+}
@@ -10,6 +10,7 @@ import (
 	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
 	"github.com/libopenstorage/stork/pkg/controller"
 	"github.com/libopenstorage/stork/pkg/log"
+	"github.com/libopenstorage/stork/pkg/rule"
 	"github.com/operator-framework/operator-sdk/pkg/sdk"
 	"github.com/portworx/sched-ops/k8s"
 	v1 "k8s.io/api/core/v1"
@@ -19,10 +20,19 @@ import (
 	"k8s.io/client-go/tools/record"
 )
 
+const (
+	postExecRuleFailedEventReason = "PostExecRuleFailed"
+)
+
 // ClusterDomainUpdateController clusterdomainupdate controller
 type ClusterDomainUpdateController struct {
 	Driver   volume.Driver
 	Recorder record.EventRecorder
+	// LocalDomain is the name of the cluster domain in which stork is running
+	LocalDomain string
+	// RequireConfirmation if set, cluster domains are only deactivated once
+	// the update is confirmed after its impact is reported
+	RequireConfirmation bool
 }
 
 // Init initialize the clusterdomainupdate controller
@@ -53,17 +63,29 @@ func (c *ClusterDomainUpdateController) Handle(ctx context.Context, event sdk.Ev
 			return nil
 		}
 		switch clusterDomainUpdate.Status.Status {
-		case storkv1.ClusterDomainUpdateStatusInitial:
+		case storkv1.ClusterDomainUpdateStatusInitial, storkv1.ClusterDomainUpdateStatusPendingConfirmation:
+			if !clusterDomainUpdate.Spec.Active {
+				pending, err := c.checkDeactivation(clusterDomainUpdate)
+				if err != nil || pending {
+					return err
+				}
+			}
 			var (
 				action string
 				err    error
 			)
 			if clusterDomainUpdate.Spec.Active {
 				action = "activate"
-				err = c.Driver.ActivateClusterDomain(clusterDomainUpdate)
 			} else {
 				action = "deactivate"
-				err = c.Driver.DeactivateClusterDomain(clusterDomainUpdate)
+			}
+			err = volume.CheckCapability(c.Driver, volume.CapabilityClusterDomains)
+			if err == nil {
+				if clusterDomainUpdate.Spec.Active {
+					err = c.Driver.ActivateClusterDomain(clusterDomainUpdate)
+				} else {
+					err = c.Driver.DeactivateClusterDomain(clusterDomainUpdate)
+				}
 			}
 			if err != nil {
 				err = fmt.Errorf("unable to %v cluster domain: %v", action, err)
@@ -79,7 +101,21 @@ func (c *ClusterDomainUpdateController) Handle(ctx context.Context, event sdk.Ev
 
 			} else {
 				clusterDomainUpdate.Status.Status = storkv1.ClusterDomainUpdateStatusSuccessful
+				// The cluster domain has already been updated, so a failure
+				// in the hook is only reported
+				if err := c.runPostExecRule(clusterDomainUpdate, action); err != nil {
+					msg := fmt.Sprintf("Cluster domain updated but post exec rule failed: %v", err)
+					log.ClusterDomainUpdateLog(clusterDomainUpdate).Error(msg)
+					clusterDomainUpdate.Status.Reason = msg
+					c.Recorder.Event(
+						clusterDomainUpdate,
+						v1.EventTypeWarning,
+						postExecRuleFailedEventReason,
+						msg,
+					)
+				}
 			}
+			clusterDomainUpdates.WithLabelValues(action, string(clusterDomainUpdate.Status.Status)).Inc()
 
 			err = sdk.Update(clusterDomainUpdate)
 			if err != nil {
@@ -97,6 +133,91 @@ func (c *ClusterDomainUpdateController) Handle(ctx context.Context, event sdk.Ev
 	return nil
 }
 
+// runPostExecRule runs the post exec rule of the update once the cluster
+// domain has been activated or deactivated. The results are recorded in the
+// status of the update.
+func (c *ClusterDomainUpdateController) runPostExecRule(
+	clusterDomainUpdate *storkv1.ClusterDomainUpdate,
+	action string,
+) error {
+	if clusterDomainUpdate.Spec.PostExecRule == "" {
+		return nil
+	}
+	if clusterDomainUpdate.Spec.RuleNamespace == "" {
+		return fmt.Errorf("ruleNamespace is required to run the post exec rule")
+	}
+	parameters := map[string]string{
+		"clusterDomain": clusterDomainUpdate.Spec.ClusterDomain,
+		"action":        action,
+	}
+	for k, v := range clusterDomainUpdate.Spec.RuleParameters {
+		if _, ok := parameters[k]; !ok {
+			parameters[k] = v
+		}
+	}
+
+	r, err := rule.GetRule(clusterDomainUpdate.Spec.PostExecRule, clusterDomainUpdate.Spec.RuleNamespace,
+		rule.PostExecRule, parameters)
+	if err != nil {
+		return err
+	}
+	_, results, err := rule.ExecuteRule(r, rule.PostExecRule, clusterDomainUpdate,
+		clusterDomainUpdate.Spec.RuleNamespace, &rule.Parameters{Values: parameters})
+	clusterDomainUpdate.Status.RuleResults = rule.MergeRuleResults(
+		clusterDomainUpdate.Status.RuleResults, results, rule.PostExecRule)
+	return err
+}
+
+// checkDeactivation reports the impact of deactivating the cluster domain in
+// the status of the update. Returns true if the update is a dry run or is
+// waiting to be confirmed, in which case the cluster domain shouldn't be
+// deactivated yet. The impact is best effort so that deactivations aren't
+// blocked during an outage.
+func (c *ClusterDomainUpdateController) checkDeactivation(clusterDomainUpdate *storkv1.ClusterDomainUpdate) (bool, error) {
+	var summary string
+	impact, err := c.getDeactivationImpact(clusterDomainUpdate.Spec.ClusterDomain)
+	if err != nil {
+		log.ClusterDomainUpdateLog(clusterDomainUpdate).Warnf("Error getting impact of deactivating cluster domain: %v", err)
+		summary = fmt.Sprintf("Impact of deactivating cluster domain %v couldn't be determined: %v",
+			clusterDomainUpdate.Spec.ClusterDomain, err)
+	} else {
+		summary = fmt.Sprintf("Deactivating cluster domain %v impacts %v pods using %v PVCs in %v namespaces, "+
+			"and %v migrated applications will need to be activated",
+			clusterDomainUpdate.Spec.ClusterDomain, impact.NumPods, impact.NumPVCs, len(impact.Namespaces),
+			impact.NumAppsToActivate)
+	}
+
+	if !clusterDomainUpdate.Spec.DryRun && (!c.RequireConfirmation || clusterDomainUpdate.Spec.Confirmed) {
+		clusterDomainUpdate.Status.Impact = impact
+		log.ClusterDomainUpdateLog(clusterDomainUpdate).Info(summary)
+		return false, nil
+	}
+
+	reason := summary
+	if clusterDomainUpdate.Spec.DryRun {
+		reason = "Dry run: " + reason
+	} else {
+		reason = reason + ". Set confirmed in the spec to deactivate it"
+	}
+	if clusterDomainUpdate.Status.Status == storkv1.ClusterDomainUpdateStatusPendingConfirmation &&
+		clusterDomainUpdate.Status.Reason == reason &&
+		reflect.DeepEqual(clusterDomainUpdate.Status.Impact, impact) {
+		return true, nil
+	}
+	if clusterDomainUpdate.Status.Status != storkv1.ClusterDomainUpdateStatusPendingConfirmation {
+		c.Recorder.Event(
+			clusterDomainUpdate,
+			v1.EventTypeNormal,
+			string(storkv1.ClusterDomainUpdateStatusPendingConfirmation),
+			reason,
+		)
+	}
+	clusterDomainUpdate.Status.Status = storkv1.ClusterDomainUpdateStatusPendingConfirmation
+	clusterDomainUpdate.Status.Reason = reason
+	clusterDomainUpdate.Status.Impact = impact
+	return true, sdk.Update(clusterDomainUpdate)
+}
+
 // createCRD creates the CRD for ClusterDomainsStatus object
 func (c *ClusterDomainUpdateController) createCRD() error {
 	resource := k8s.CustomResource{
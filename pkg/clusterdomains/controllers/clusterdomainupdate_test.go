@@ -0,0 +1,90 @@
+// +build unittest
+
+package controllers
+
+import (
+	"testing"
+
+	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	fakeclient "github.com/libopenstorage/stork/pkg/client/clientset/versioned/fake"
+	"github.com/libopenstorage/stork/pkg/rule"
+	"github.com/portworx/sched-ops/k8s"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakekube "k8s.io/client-go/kubernetes/fake"
+	core "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestPostExecRule(t *testing.T) {
+	fakeKubeClient := fakekube.NewSimpleClientset()
+	jobs := make([]*batchv1.Job, 0)
+	fakeKubeClient.PrependReactor("create", "jobs", func(action core.Action) (bool, runtime.Object, error) {
+		jobs = append(jobs, action.(core.CreateAction).GetObject().(*batchv1.Job))
+		return false, nil, nil
+	})
+	fakeKubeClient.PrependReactor("get", "jobs", func(action core.Action) (bool, runtime.Object, error) {
+		for _, job := range jobs {
+			if job.Name == action.(core.GetAction).GetName() {
+				job = job.DeepCopy()
+				job.Status.Succeeded = 1
+				return true, job, nil
+			}
+		}
+		return false, nil, nil
+	})
+	storkClient := fakeclient.NewSimpleClientset(&storkv1.Rule{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "dns-failover",
+			Namespace:   "dr",
+			Annotations: map[string]string{rule.TemplateCommandsAnnotation: "true"},
+		},
+		Rules: []storkv1.RuleItem{
+			{
+				Actions: []storkv1.RuleAction{
+					{
+						Type:  storkv1.RuleActionJob,
+						Value: "update-dns --domain {{.Parameters.clusterDomain}} --{{.Parameters.action}} --zone {{.Parameters.zone}}",
+						Job:   &storkv1.RuleActionJobSpec{Image: "dns-tools:1.0"},
+					},
+				},
+			},
+		},
+	})
+	k8s.Instance().SetClient(fakeKubeClient, nil, storkClient, nil, nil, nil)
+
+	c := &ClusterDomainUpdateController{Recorder: record.NewFakeRecorder(10)}
+	update := &storkv1.ClusterDomainUpdate{
+		ObjectMeta: metav1.ObjectMeta{Name: "activate-east"},
+		Spec: storkv1.ClusterDomainUpdateSpec{
+			ClusterDomain: "east",
+			Active:        true,
+			PostExecRule:  "dns-failover",
+			RuleParameters: map[string]string{
+				"zone":          "example.com",
+				"clusterDomain": "west",
+			},
+		},
+	}
+
+	// Nothing is run without a rule
+	require.NoError(t, c.runPostExecRule(&storkv1.ClusterDomainUpdate{}, "activate"))
+
+	require.Error(t, c.runPostExecRule(update, "activate"), "Expected error without rule namespace")
+
+	update.Spec.RuleNamespace = "dr"
+	require.NoError(t, c.runPostExecRule(update, "activate"), "Error running post exec rule")
+	require.Len(t, jobs, 1)
+	require.Equal(t, "dr", jobs[0].Namespace)
+	// The parameters set by stork can't be overridden
+	require.Equal(t, []string{"sh", "-c", "update-dns --domain east --activate --zone example.com"},
+		jobs[0].Spec.Template.Spec.Containers[0].Command)
+	require.Len(t, update.Status.RuleResults, 1)
+	require.Equal(t, jobs[0].Name, update.Status.RuleResults[0].Job)
+	require.Equal(t, string(rule.PostExecRule), update.Status.RuleResults[0].Type)
+
+	update.Spec.PostExecRule = "missing"
+	require.Error(t, c.runPostExecRule(update, "activate"), "Expected error for missing rule")
+}
@@ -0,0 +1,261 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/libopenstorage/stork/drivers/volume"
+	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/clusterdomains/witness"
+	"github.com/libopenstorage/stork/pkg/log"
+	"github.com/portworx/sched-ops/k8s"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+const (
+	// AutomaticFailoverLabel is added to the ClusterDomainUpdates that are
+	// created for automatic failover
+	AutomaticFailoverLabel = "stork.libopenstorage.org/automatic-failover"
+
+	defaultWitnessInterval       = 10 * time.Second
+	defaultFailoverTimeout       = 60 * time.Second
+	automaticFailoverEventReason = "AutomaticFailover"
+)
+
+// ClusterDomainFailoverController sends heartbeats for the local cluster
+// domain to a witness and automatically deactivates the active cluster
+// domains that the witness hasn't heard from for the failover timeout. The
+// local cluster domain is activated if it is inactive and none of the active
+// cluster domains are reachable.
+//
+// No action is taken if the witness can't be reached, since the local
+// cluster domain could be the one that is partitioned.
+type ClusterDomainFailoverController struct {
+	Driver   volume.Driver
+	Recorder record.EventRecorder
+	// LocalDomain is the name of the cluster domain in which stork is running
+	LocalDomain string
+	// WitnessURL is the url of the witness
+	WitnessURL string
+	// FailoverTimeout is the time for which the witness shouldn't have
+	// received a heartbeat from a cluster domain before it is deactivated
+	FailoverTimeout time.Duration
+	// Interval is the interval at which heartbeats are sent to the witness
+	Interval time.Duration
+
+	witness     *witness.Client
+	stopChannel chan bool
+}
+
+// Init initializes the failover controller and starts sending heartbeats to
+// the witness
+func (c *ClusterDomainFailoverController) Init() error {
+	if c.LocalDomain == "" {
+		return fmt.Errorf("local cluster domain is required for automatic failover")
+	}
+	if c.FailoverTimeout <= 0 {
+		c.FailoverTimeout = defaultFailoverTimeout
+	}
+	if c.Interval <= 0 {
+		c.Interval = defaultWitnessInterval
+	}
+	if c.FailoverTimeout <= c.Interval {
+		return fmt.Errorf("failover timeout (%v) should be greater than the witness interval (%v)",
+			c.FailoverTimeout, c.Interval)
+	}
+	var err error
+	if c.witness, err = witness.NewClient(c.WitnessURL); err != nil {
+		return err
+	}
+	if err := volume.CheckCapability(c.Driver, volume.CapabilityClusterDomains); err != nil {
+		return err
+	}
+
+	c.stopChannel = make(chan bool)
+	go c.run()
+	return nil
+}
+
+// Stop stops the failover controller
+func (c *ClusterDomainFailoverController) Stop() error {
+	if c.stopChannel == nil {
+		return nil
+	}
+	close(c.stopChannel)
+	c.stopChannel = nil
+	return nil
+}
+
+func (c *ClusterDomainFailoverController) run() {
+	ticker := time.NewTicker(c.Interval)
+	defer ticker.Stop()
+	stopChannel := c.stopChannel
+	for {
+		select {
+		case <-ticker.C:
+			if err := c.check(); err != nil {
+				logrus.Warnf("Skipping automatic failover check for cluster domains: %v", err)
+			}
+		case <-stopChannel:
+			return
+		}
+	}
+}
+
+func (c *ClusterDomainFailoverController) check() error {
+	if err := c.witness.Heartbeat(c.LocalDomain); err != nil {
+		return err
+	}
+	heartbeats, err := c.witness.GetHeartbeats()
+	if err != nil {
+		return err
+	}
+	domains, err := c.Driver.GetClusterDomains()
+	if err != nil {
+		return fmt.Errorf("error getting cluster domains: %v", err)
+	}
+	return c.failover(domains, heartbeats)
+}
+
+// failover creates the ClusterDomainUpdates to deactivate the unreachable
+// cluster domains and activate the local cluster domain if required
+func (c *ClusterDomainFailoverController) failover(
+	domains *storkv1.ClusterDomains,
+	heartbeats map[string]time.Duration,
+) error {
+	deactivate, activateLocal := failoverActions(c.LocalDomain, domains, heartbeats, c.FailoverTimeout)
+	if len(deactivate) == 0 && !activateLocal {
+		return nil
+	}
+
+	updates, err := k8s.Instance().ListClusterDomainUpdates()
+	if err != nil {
+		return fmt.Errorf("error listing cluster domain updates: %v", err)
+	}
+	var lastErr error
+	for _, domain := range deactivate {
+		reason := fmt.Sprintf("witness hasn't received a heartbeat from cluster domain %v for %v",
+			domain, heartbeats[domain])
+		if err := c.createUpdate(domain, false, reason, updates); err != nil {
+			lastErr = err
+		}
+	}
+	if activateLocal {
+		reason := "none of the active cluster domains are reachable by the witness"
+		if err := c.createUpdate(c.LocalDomain, true, reason, updates); err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+// createUpdate creates a ClusterDomainUpdate for the cluster domain unless
+// there is already an automatic update for it that hasn't completed, or that
+// was created within the failover timeout
+func (c *ClusterDomainFailoverController) createUpdate(
+	domain string,
+	active bool,
+	reason string,
+	updates *storkv1.ClusterDomainUpdateList,
+) error {
+	for _, update := range updates.Items {
+		if update.Labels[AutomaticFailoverLabel] != "true" ||
+			update.Spec.ClusterDomain != domain ||
+			update.Spec.Active != active {
+			continue
+		}
+		if update.Status.Status == storkv1.ClusterDomainUpdateStatusInitial ||
+			update.Status.Status == storkv1.ClusterDomainUpdateStatusPending ||
+			time.Since(update.CreationTimestamp.Time) < c.FailoverTimeout {
+			return nil
+		}
+	}
+
+	action := "deactivate"
+	if active {
+		action = "activate"
+	}
+	name := strings.ToLower(clusterIDRegex.ReplaceAllString(
+		fmt.Sprintf("failover-%v-%v-%d", action, domain, time.Now().Unix()), "-"))
+	update, err := k8s.Instance().CreateClusterDomainUpdate(&storkv1.ClusterDomainUpdate{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{AutomaticFailoverLabel: "true"},
+		},
+		// Automatic updates don't wait for confirmation since the cluster
+		// domain is already unreachable
+		Spec: storkv1.ClusterDomainUpdateSpec{
+			ClusterDomain: domain,
+			Active:        active,
+			Confirmed:     true,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error creating cluster domain update to %v %v: %v", action, domain, err)
+	}
+	message := fmt.Sprintf("Automatically creating update to %v cluster domain %v: %v", action, domain, reason)
+	log.ClusterDomainUpdateLog(update).Warn(message)
+	c.Recorder.Event(update, v1.EventTypeWarning, automaticFailoverEventReason, message)
+	return nil
+}
+
+// failoverActions returns the active cluster domains that should be
+// deactivated, and whether the local cluster domain should be activated. The
+// local cluster domain is only activated if none of the active cluster
+// domains are reachable and another cluster domain is unreachable, so that
+// domains deactivated by an admin aren't activated again. Cluster domains
+// that have never sent a heartbeat to the witness are treated as reachable
+// so that they aren't deactivated when the witness is replaced.
+func failoverActions(
+	localDomain string,
+	domains *storkv1.ClusterDomains,
+	heartbeats map[string]time.Duration,
+	timeout time.Duration,
+) ([]string, bool) {
+	localActive := contains(domains.Active, localDomain)
+	if !localActive && !contains(domains.Inactive, localDomain) {
+		return nil, false
+	}
+	unreachable := func(domain string) bool {
+		age, ok := heartbeats[domain]
+		return ok && age > timeout
+	}
+
+	deactivate := make([]string, 0)
+	reachableActive := false
+	for _, domain := range domains.Active {
+		if domain == localDomain {
+			continue
+		}
+		if unreachable(domain) {
+			deactivate = append(deactivate, domain)
+		} else {
+			reachableActive = true
+		}
+	}
+	if localActive || reachableActive {
+		return deactivate, false
+	}
+	if len(deactivate) > 0 {
+		return deactivate, true
+	}
+	for _, domain := range domains.Inactive {
+		if domain != localDomain && unreachable(domain) {
+			return deactivate, true
+		}
+	}
+	return deactivate, false
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
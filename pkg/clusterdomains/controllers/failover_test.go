@@ -0,0 +1,100 @@
+// +build unittest
+
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	fakeclient "github.com/libopenstorage/stork/pkg/client/clientset/versioned/fake"
+	"github.com/portworx/sched-ops/k8s"
+	"github.com/stretchr/testify/require"
+	fakekube "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestFailoverActions(t *testing.T) {
+	timeout := time.Minute
+	domains := &storkv1.ClusterDomains{
+		Active:   []string{"east", "west"},
+		Inactive: []string{},
+	}
+
+	// Domains that haven't sent heartbeats aren't deactivated
+	deactivate, activate := failoverActions("east", domains, map[string]time.Duration{}, timeout)
+	require.Empty(t, deactivate)
+	require.False(t, activate)
+
+	heartbeats := map[string]time.Duration{"east": 0, "west": 2 * time.Minute}
+	deactivate, activate = failoverActions("east", domains, heartbeats, timeout)
+	require.Equal(t, []string{"west"}, deactivate)
+	require.False(t, activate, "Active local domain shouldn't be activated")
+
+	// The local domain is activated if no active domains are reachable
+	domains = &storkv1.ClusterDomains{
+		Active:   []string{"west"},
+		Inactive: []string{"east"},
+	}
+	deactivate, activate = failoverActions("east", domains, heartbeats, timeout)
+	require.Equal(t, []string{"west"}, deactivate)
+	require.True(t, activate)
+
+	heartbeats["west"] = 10 * time.Second
+	deactivate, activate = failoverActions("east", domains, heartbeats, timeout)
+	require.Empty(t, deactivate)
+	require.False(t, activate, "Local domain shouldn't be activated when an active domain is reachable")
+
+	// Domains deactivated by an admin aren't activated again if the other
+	// domains are reachable
+	domains = &storkv1.ClusterDomains{
+		Active:   []string{},
+		Inactive: []string{"east", "west"},
+	}
+	deactivate, activate = failoverActions("east", domains, heartbeats, timeout)
+	require.Empty(t, deactivate)
+	require.False(t, activate)
+	heartbeats["west"] = 2 * time.Minute
+	_, activate = failoverActions("east", domains, heartbeats, timeout)
+	require.True(t, activate)
+
+	// Nothing is done if the local domain isn't known to the driver
+	deactivate, activate = failoverActions("north", domains, heartbeats, timeout)
+	require.Empty(t, deactivate)
+	require.False(t, activate)
+}
+
+func TestFailover(t *testing.T) {
+	storkClient := fakeclient.NewSimpleClientset()
+	k8s.Instance().SetClient(fakekube.NewSimpleClientset(), nil, storkClient, nil, nil, nil)
+	recorder := record.NewFakeRecorder(10)
+	c := &ClusterDomainFailoverController{
+		Recorder:        recorder,
+		LocalDomain:     "east",
+		FailoverTimeout: time.Minute,
+	}
+	domains := &storkv1.ClusterDomains{
+		Active:   []string{"West_1"},
+		Inactive: []string{"east"},
+	}
+	heartbeats := map[string]time.Duration{"east": 0, "West_1": 5 * time.Minute}
+
+	require.NoError(t, c.failover(domains, heartbeats), "Error failing over")
+	updates, err := k8s.Instance().ListClusterDomainUpdates()
+	require.NoError(t, err, "Error listing cluster domain updates")
+	require.Len(t, updates.Items, 2)
+	active := make(map[string]bool)
+	for _, update := range updates.Items {
+		require.Equal(t, "true", update.Labels[AutomaticFailoverLabel])
+		require.Regexp(t, "^[a-z0-9-.]+$", update.Name)
+		active[update.Spec.ClusterDomain] = update.Spec.Active
+	}
+	require.Equal(t, map[string]bool{"West_1": false, "east": true}, active)
+	require.Len(t, recorder.Events, 2)
+
+	// Updates aren't created again while the previous ones are pending
+	require.NoError(t, c.failover(domains, heartbeats), "Error failing over")
+	updates, err = k8s.Instance().ListClusterDomainUpdates()
+	require.NoError(t, err, "Error listing cluster domain updates")
+	require.Len(t, updates.Items, 2)
+}
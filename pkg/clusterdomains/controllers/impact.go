@@ -0,0 +1,170 @@
+package controllers
+
+import (
+	"fmt"
+	"sort"
+
+	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	migration "github.com/libopenstorage/stork/pkg/migration/controllers"
+	"github.com/portworx/sched-ops/k8s"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	// ClusterDomainNodeLabel is the label on nodes with the name of the
+	// cluster domain that the node is in
+	ClusterDomainNodeLabel = "stork.libopenstorage.org/cluster-domain"
+
+	// maxImpactItems is the maximum number of items in each list of the
+	// impact of a cluster domain update
+	maxImpactItems = 100
+)
+
+// getDeactivationImpact returns the impact of deactivating the cluster domain
+// on the applications in this cluster. Pods are impacted if they run on nodes
+// labelled with the cluster domain and use volumes from the driver. If no
+// nodes are labelled, all the nodes are in the local cluster domain. Migrated
+// applications need to be activated if the cluster domain isn't the local
+// one.
+func (c *ClusterDomainUpdateController) getDeactivationImpact(domain string) (*storkv1.ClusterDomainUpdateImpact, error) {
+	nodes, err := c.getDomainNodes(domain)
+	if err != nil {
+		return nil, err
+	}
+
+	namespaces := make(map[string]bool)
+	pvcs := make(map[string]bool)
+	pods := make([]string, 0)
+	if len(nodes) > 0 {
+		podList, err := k8s.Instance().GetPods("", nil)
+		if err != nil {
+			return nil, fmt.Errorf("error getting pods: %v", err)
+		}
+		for _, pod := range podList.Items {
+			if !nodes[pod.Spec.NodeName] {
+				continue
+			}
+			podPVCs, err := c.getDriverPVCs(&pod)
+			if err != nil {
+				return nil, err
+			}
+			if len(podPVCs) == 0 {
+				continue
+			}
+			pods = append(pods, pod.Namespace+"/"+pod.Name)
+			namespaces[pod.Namespace] = true
+			for _, pvc := range podPVCs {
+				pvcs[pvc] = true
+			}
+		}
+	}
+
+	apps := make([]string, 0)
+	if c.LocalDomain != domain {
+		if apps, err = getAppsToActivate(); err != nil {
+			return nil, err
+		}
+	}
+
+	impact := &storkv1.ClusterDomainUpdateImpact{
+		NumPods:           len(pods),
+		NumPVCs:           len(pvcs),
+		NumAppsToActivate: len(apps),
+	}
+	impact.Namespaces = limitImpactItems(keys(namespaces))
+	impact.PVCs = limitImpactItems(keys(pvcs))
+	impact.Pods = limitImpactItems(pods)
+	impact.AppsToActivate = limitImpactItems(apps)
+	return impact, nil
+}
+
+// getDomainNodes returns the names of the nodes in the cluster domain
+func (c *ClusterDomainUpdateController) getDomainNodes(domain string) (map[string]bool, error) {
+	nodeList, err := k8s.Instance().GetNodes()
+	if err != nil {
+		return nil, fmt.Errorf("error getting nodes: %v", err)
+	}
+	nodes := make(map[string]bool)
+	labelled := false
+	for _, node := range nodeList.Items {
+		nodeDomain, ok := node.Labels[ClusterDomainNodeLabel]
+		if !ok {
+			continue
+		}
+		labelled = true
+		if nodeDomain == domain {
+			nodes[node.Name] = true
+		}
+	}
+	if !labelled && c.LocalDomain != "" && c.LocalDomain == domain {
+		for _, node := range nodeList.Items {
+			nodes[node.Name] = true
+		}
+	}
+	return nodes, nil
+}
+
+// getDriverPVCs returns the PVCs used by the pod that are owned by the driver
+func (c *ClusterDomainUpdateController) getDriverPVCs(pod *v1.Pod) ([]string, error) {
+	pvcs := make([]string, 0)
+	for _, volume := range pod.Spec.Volumes {
+		if volume.PersistentVolumeClaim == nil {
+			continue
+		}
+		pvc, err := k8s.Instance().GetPersistentVolumeClaim(volume.PersistentVolumeClaim.ClaimName, pod.Namespace)
+		if err != nil {
+			return nil, fmt.Errorf("error getting PVC %v/%v used by pod %v: %v",
+				pod.Namespace, volume.PersistentVolumeClaim.ClaimName, pod.Name, err)
+		}
+		if c.Driver.OwnsPVC(pvc) {
+			pvcs = append(pvcs, pvc.Namespace+"/"+pvc.Name)
+		}
+	}
+	return pvcs, nil
+}
+
+// getAppsToActivate returns the migrated deployments and statefulsets that
+// are scaled down and would need to be activated
+func getAppsToActivate() ([]string, error) {
+	apps := make([]string, 0)
+	deployments, err := k8s.Instance().ListDeployments("")
+	if err != nil {
+		return nil, fmt.Errorf("error getting deployments: %v", err)
+	}
+	for _, deployment := range deployments.Items {
+		if _, ok := deployment.Annotations[migration.StorkMigrationReplicasAnnotation]; ok &&
+			deployment.Spec.Replicas != nil && *deployment.Spec.Replicas == 0 {
+			apps = append(apps, "Deployment/"+deployment.Namespace+"/"+deployment.Name)
+		}
+	}
+	statefulSets, err := k8s.Instance().ListStatefulSets("")
+	if err != nil {
+		return nil, fmt.Errorf("error getting statefulsets: %v", err)
+	}
+	for _, statefulSet := range statefulSets.Items {
+		if _, ok := statefulSet.Annotations[migration.StorkMigrationReplicasAnnotation]; ok &&
+			statefulSet.Spec.Replicas != nil && *statefulSet.Spec.Replicas == 0 {
+			apps = append(apps, "StatefulSet/"+statefulSet.Namespace+"/"+statefulSet.Name)
+		}
+	}
+	return apps, nil
+}
+
+func keys(set map[string]bool) []string {
+	list := make([]string, 0, len(set))
+	for key := range set {
+		list = append(list, key)
+	}
+	return list
+}
+
+func limitImpactItems(items []string) []string {
+	sort.Strings(items)
+	if len(items) > maxImpactItems {
+		items = items[:maxImpactItems]
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	return items
+}
@@ -0,0 +1,135 @@
+// +build unittest
+
+package controllers
+
+import (
+	"testing"
+
+	"github.com/libopenstorage/stork/drivers/volume"
+	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	migration "github.com/libopenstorage/stork/pkg/migration/controllers"
+	"github.com/portworx/sched-ops/k8s"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1beta2"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakekube "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+// fakeDriver owns the PVCs with the driver storage class
+type fakeDriver struct {
+	volume.Driver
+}
+
+func (d *fakeDriver) OwnsPVC(pvc *v1.PersistentVolumeClaim) bool {
+	return pvc.Spec.StorageClassName != nil && *pvc.Spec.StorageClassName == "driver"
+}
+
+func newImpactPod(name string, node string, claims ...string) *v1.Pod {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns"},
+		Spec:       v1.PodSpec{NodeName: node},
+	}
+	for _, claim := range claims {
+		pod.Spec.Volumes = append(pod.Spec.Volumes, v1.Volume{
+			Name: claim,
+			VolumeSource: v1.VolumeSource{
+				PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: claim},
+			},
+		})
+	}
+	return pod
+}
+
+func newImpactPVC(name string, storageClass string) *v1.PersistentVolumeClaim {
+	return &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns"},
+		Spec:       v1.PersistentVolumeClaimSpec{StorageClassName: &storageClass},
+	}
+}
+
+func setupImpact(nodeLabels map[string]map[string]string) {
+	replicas := int32(0)
+	objects := []runtime.Object{
+		newImpactPod("db-0", "node1", "data-0"),
+		newImpactPod("db-1", "node2", "data-1"),
+		newImpactPod("cache-0", "node1", "local"),
+		newImpactPod("web", "node1"),
+		newImpactPVC("data-0", "driver"),
+		newImpactPVC("data-1", "driver"),
+		newImpactPVC("local", "local"),
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "migrated",
+				Namespace:   "ns",
+				Annotations: map[string]string{migration.StorkMigrationReplicasAnnotation: "2"},
+			},
+			Spec: appsv1.DeploymentSpec{Replicas: &replicas},
+		},
+		&appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "local", Namespace: "ns"},
+			Spec:       appsv1.StatefulSetSpec{Replicas: &replicas},
+		},
+	}
+	for name, labels := range nodeLabels {
+		objects = append(objects, &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}})
+	}
+	k8s.Instance().SetClient(fakekube.NewSimpleClientset(objects...), nil, nil, nil, nil, nil)
+}
+
+func TestDeactivationImpact(t *testing.T) {
+	c := &ClusterDomainUpdateController{
+		Driver:      &fakeDriver{},
+		Recorder:    record.NewFakeRecorder(10),
+		LocalDomain: "east",
+	}
+
+	// All the nodes are in the local domain if none are labelled
+	setupImpact(map[string]map[string]string{"node1": nil, "node2": nil})
+	impact, err := c.getDeactivationImpact("east")
+	require.NoError(t, err, "Error getting impact")
+	require.Equal(t, &storkv1.ClusterDomainUpdateImpact{
+		Namespaces: []string{"ns"},
+		PVCs:       []string{"ns/data-0", "ns/data-1"},
+		NumPVCs:    2,
+		Pods:       []string{"ns/db-0", "ns/db-1"},
+		NumPods:    2,
+	}, impact)
+
+	// Migrated applications need to be activated when a remote domain is
+	// deactivated
+	impact, err = c.getDeactivationImpact("west")
+	require.NoError(t, err, "Error getting impact")
+	require.Equal(t, &storkv1.ClusterDomainUpdateImpact{
+		AppsToActivate:    []string{"Deployment/ns/migrated"},
+		NumAppsToActivate: 1,
+	}, impact)
+
+	setupImpact(map[string]map[string]string{
+		"node1": {ClusterDomainNodeLabel: "east"},
+		"node2": {ClusterDomainNodeLabel: "west"},
+	})
+	impact, err = c.getDeactivationImpact("west")
+	require.NoError(t, err, "Error getting impact")
+	require.Equal(t, []string{"ns/db-1"}, impact.Pods)
+	require.Equal(t, []string{"ns/data-1"}, impact.PVCs)
+	require.Equal(t, 1, impact.NumAppsToActivate)
+
+	// The impact is recorded without waiting when confirmation isn't
+	// required
+	update := &storkv1.ClusterDomainUpdate{
+		Spec: storkv1.ClusterDomainUpdateSpec{ClusterDomain: "west"},
+	}
+	pending, err := c.checkDeactivation(update)
+	require.NoError(t, err)
+	require.False(t, pending, "Update shouldn't wait for confirmation")
+	require.Equal(t, impact, update.Status.Impact)
+
+	c.RequireConfirmation = true
+	update.Spec.Confirmed = true
+	pending, err = c.checkDeactivation(update)
+	require.NoError(t, err)
+	require.False(t, pending, "Confirmed update shouldn't wait")
+}
@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	clusterDomainActive = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "stork_cluster_domain_active",
+			Help: "Whether the cluster domain is active (1) or inactive (0)",
+		},
+		[]string{"domain"},
+	)
+	clusterDomainSyncStatus = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "stork_cluster_domain_sync_status",
+			Help: "Sync status of the cluster domain, set to 1 for the current status and 0 for the others",
+		},
+		[]string{"domain", "status"},
+	)
+	clusterDomainLastTransition = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "stork_cluster_domain_last_transition_timestamp_seconds",
+			Help: "Time at which the state or sync status of the cluster domain last changed",
+		},
+		[]string{"domain"},
+	)
+	clusterDomainTransitions = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "stork_cluster_domain_transitions_total",
+			Help: "Number of times the cluster domain changed to the state",
+		},
+		[]string{"domain", "state"},
+	)
+	clusterDomainUpdates = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "stork_cluster_domain_updates_total",
+			Help: "Number of cluster domain updates processed, by action and result",
+		},
+		[]string{"action", "status"},
+	)
+)
+
+var syncStatuses = []storkv1.ClusterDomainSyncStatus{
+	storkv1.ClusterDomainSyncStatusInSync,
+	storkv1.ClusterDomainSyncStatusNotInSync,
+	storkv1.ClusterDomainSyncStatusUnknown,
+}
+
+func init() {
+	prometheus.MustRegister(
+		clusterDomainActive,
+		clusterDomainSyncStatus,
+		clusterDomainLastTransition,
+		clusterDomainTransitions,
+		clusterDomainUpdates,
+	)
+}
+
+// setClusterDomainMetrics sets the metrics for the state of the cluster
+// domains. Metrics for cluster domains that no longer exist are removed.
+func setClusterDomainMetrics(infos []storkv1.ClusterDomainInfo) {
+	clusterDomainActive.Reset()
+	clusterDomainSyncStatus.Reset()
+	clusterDomainLastTransition.Reset()
+	for _, info := range infos {
+		active := 0.0
+		if info.State == storkv1.ClusterDomainActive {
+			active = 1
+		}
+		clusterDomainActive.WithLabelValues(info.Name).Set(active)
+		for _, status := range syncStatuses {
+			value := 0.0
+			if status == info.SyncStatus {
+				value = 1
+			}
+			clusterDomainSyncStatus.WithLabelValues(info.Name, string(status)).Set(value)
+		}
+		clusterDomainLastTransition.WithLabelValues(info.Name).Set(float64(info.LastTransitionTime.Unix()))
+	}
+}
@@ -0,0 +1,101 @@
+package witness
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Server is a lightweight witness that keeps the heartbeats of the cluster
+// domains in memory. It should be run outside the cluster domains that it is
+// a witness for, for eg in a third cluster, so that it can tell which domain
+// is unreachable during a partition.
+type Server struct {
+	lock       sync.Mutex
+	heartbeats map[string]time.Time
+	server     *http.Server
+	now        func() time.Time
+}
+
+// NewServer returns a witness server
+func NewServer() *Server {
+	return &Server{
+		heartbeats: make(map[string]time.Time),
+		now:        time.Now,
+	}
+}
+
+// Start starts serving the witness endpoints on the port
+func (s *Server) Start(port int) error {
+	s.server = &http.Server{
+		Addr:    fmt.Sprintf(":%d", port),
+		Handler: s,
+	}
+	go func() {
+		if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Errorf("Error running cluster domain witness: %v", err)
+		}
+	}()
+	return nil
+}
+
+// Stop stops the witness server
+func (s *Server) Stop() error {
+	if s.server == nil {
+		return nil
+	}
+	return s.server.Shutdown(context.Background())
+}
+
+// ServeHTTP handles the heartbeat requests
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == domainsPath {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(s.list()); err != nil {
+			log.Errorf("Error writing cluster domain heartbeats: %v", err)
+		}
+		return
+	}
+
+	domain := strings.TrimPrefix(r.URL.Path, domainsPath+"/")
+	if domain == r.URL.Path || domain == "" || strings.Contains(domain, "/") {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPut {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	s.lock.Lock()
+	s.heartbeats[domain] = s.now()
+	s.lock.Unlock()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) list() *DomainHeartbeatList {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	now := s.now()
+	list := &DomainHeartbeatList{Domains: make([]DomainHeartbeat, 0, len(s.heartbeats))}
+	for name, last := range s.heartbeats {
+		list.Domains = append(list.Domains, DomainHeartbeat{
+			Name:                  name,
+			SecondsSinceHeartbeat: int64(now.Sub(last).Seconds()),
+		})
+	}
+	sort.Slice(list.Domains, func(i, j int) bool {
+		return list.Domains[i].Name < list.Domains[j].Name
+	})
+	return list
+}
@@ -0,0 +1,103 @@
+package witness
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// domainsPath is the path on the witness for the heartbeats of the
+	// cluster domains
+	domainsPath = "/v1/domains"
+	// defaultClientTimeout is the timeout for requests to the witness
+	defaultClientTimeout = 10 * time.Second
+)
+
+// DomainHeartbeat is the last heartbeat received by the witness from a
+// cluster domain
+type DomainHeartbeat struct {
+	// Name of the cluster domain
+	Name string `json:"name"`
+	// SecondsSinceHeartbeat is the time in seconds since the witness last
+	// received a heartbeat from the cluster domain
+	SecondsSinceHeartbeat int64 `json:"secondsSinceHeartbeat"`
+}
+
+// DomainHeartbeatList is the list of heartbeats returned by the witness
+type DomainHeartbeatList struct {
+	Domains []DomainHeartbeat `json:"domains"`
+}
+
+// Client sends heartbeats to a witness and gets the heartbeats of all the
+// cluster domains from it.
+//
+// A PUT to <url>/v1/domains/<domain> on the witness records a heartbeat for
+// the domain, and a GET to <url>/v1/domains returns a DomainHeartbeatList.
+type Client struct {
+	url        string
+	httpClient *http.Client
+}
+
+// NewClient returns a client for the witness at the url
+func NewClient(witnessURL string) (*Client, error) {
+	u, err := url.Parse(witnessURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid witness url %v: %v", witnessURL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid witness url %v: scheme should be http or https", witnessURL)
+	}
+	return &Client{
+		url:        strings.TrimSuffix(witnessURL, "/"),
+		httpClient: &http.Client{Timeout: defaultClientTimeout},
+	}, nil
+}
+
+// Heartbeat records a heartbeat for the cluster domain on the witness
+func (c *Client) Heartbeat(domain string) error {
+	req, err := http.NewRequest(http.MethodPut, c.url+domainsPath+"/"+url.PathEscape(domain), bytes.NewReader(nil))
+	if err != nil {
+		return err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending heartbeat to witness: %v", err)
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("error sending heartbeat to witness: %v", resp.Status)
+	}
+	return nil
+}
+
+// GetHeartbeats returns the time since the last heartbeat from each cluster
+// domain known to the witness
+func (c *Client) GetHeartbeats() (map[string]time.Duration, error) {
+	resp, err := c.httpClient.Get(c.url + domainsPath)
+	if err != nil {
+		return nil, fmt.Errorf("error getting heartbeats from witness: %v", err)
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error getting heartbeats from witness: %v", resp.Status)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading heartbeats from witness: %v", err)
+	}
+	list := &DomainHeartbeatList{}
+	if err := json.Unmarshal(body, list); err != nil {
+		return nil, fmt.Errorf("error parsing heartbeats from witness: %v", err)
+	}
+	heartbeats := make(map[string]time.Duration)
+	for _, domain := range list.Domains {
+		heartbeats[domain.Name] = time.Duration(domain.SecondsSinceHeartbeat) * time.Second
+	}
+	return heartbeats, nil
+}
@@ -0,0 +1,61 @@
+// +build unittest
+
+package witness
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWitness(t *testing.T) {
+	now := time.Now()
+	server := NewServer()
+	server.now = func() time.Time { return now }
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	client, err := NewClient(ts.URL + "/")
+	require.NoError(t, err, "Error creating witness client")
+
+	heartbeats, err := client.GetHeartbeats()
+	require.NoError(t, err, "Error getting heartbeats")
+	require.Empty(t, heartbeats)
+
+	require.NoError(t, client.Heartbeat("east"), "Error sending heartbeat")
+	now = now.Add(30 * time.Second)
+	require.NoError(t, client.Heartbeat("west"), "Error sending heartbeat")
+	now = now.Add(10 * time.Second)
+
+	heartbeats, err = client.GetHeartbeats()
+	require.NoError(t, err, "Error getting heartbeats")
+	require.Equal(t, map[string]time.Duration{
+		"east": 40 * time.Second,
+		"west": 10 * time.Second,
+	}, heartbeats)
+
+	resp, err := http.Post(ts.URL+domainsPath, "application/json", nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+	resp, err = http.Get(ts.URL + "/v2/domains")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestWitnessUnreachable(t *testing.T) {
+	_, err := NewClient("witness:9010")
+	require.Error(t, err, "Expected error for url without scheme")
+
+	ts := httptest.NewServer(http.NotFoundHandler())
+	client, err := NewClient(ts.URL)
+	require.NoError(t, err, "Error creating witness client")
+	require.Error(t, client.Heartbeat("east"), "Expected error for failed heartbeat")
+	_, err = client.GetHeartbeats()
+	require.Error(t, err, "Expected error getting heartbeats")
+
+	ts.Close()
+	require.Error(t, client.Heartbeat("east"), "Expected error for unreachable witness")
+}
@@ -0,0 +1,95 @@
+package extender
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// apiCacheTTL is the time for which objects looked up from the API server
+	// to schedule pods are cached
+	apiCacheTTL = 30 * time.Second
+)
+
+type apiCacheEntry struct {
+	value  string
+	found  bool
+	expiry time.Time
+}
+
+// apiCache caches the values looked up from the API server for every
+// scheduling request, like the storage classes of PVCs and the annotations on
+// storage classes and namespaces. Lookup errors aren't cached.
+type apiCache struct {
+	ttl             time.Duration
+	lock            sync.Mutex
+	pvcClasses      map[string]*apiCacheEntry
+	enabledClasses  map[string]*apiCacheEntry
+	schedulingModes map[string]*apiCacheEntry
+	lastPrune       time.Time
+}
+
+func newAPICache(ttl time.Duration) *apiCache {
+	c := &apiCache{ttl: ttl}
+	c.reset()
+	return c
+}
+
+func (c *apiCache) reset() {
+	c.pvcClasses = make(map[string]*apiCacheEntry)
+	c.enabledClasses = make(map[string]*apiCacheEntry)
+	c.schedulingModes = make(map[string]*apiCacheEntry)
+}
+
+// invalidate removes all the entries from the cache
+func (c *apiCache) invalidate() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.reset()
+}
+
+// get returns the cached value for the key from the map returned by entries.
+// If the entry has expired, the value is looked up using fetch and cached.
+func (c *apiCache) get(
+	entries func() map[string]*apiCacheEntry,
+	key string,
+	fetch func() (string, bool, error),
+) (string, bool, error) {
+	c.lock.Lock()
+	entry, ok := entries()[key]
+	c.lock.Unlock()
+	if ok && time.Now().Before(entry.expiry) {
+		return entry.value, entry.found, nil
+	}
+
+	value, found, err := fetch()
+	if err != nil {
+		return "", false, err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	now := time.Now()
+	entries()[key] = &apiCacheEntry{
+		value:  value,
+		found:  found,
+		expiry: now.Add(c.ttl),
+	}
+	c.prune(now)
+	return value, found, nil
+}
+
+// prune removes expired entries so that the cache doesn't grow with objects
+// that have been deleted. Should be called with the lock held.
+func (c *apiCache) prune(now time.Time) {
+	if now.Sub(c.lastPrune) < c.ttl {
+		return
+	}
+	c.lastPrune = now
+	for _, entries := range []map[string]*apiCacheEntry{c.pvcClasses, c.enabledClasses, c.schedulingModes} {
+		for key, entry := range entries {
+			if now.After(entry.expiry) {
+				delete(entries, key)
+			}
+		}
+	}
+}
@@ -0,0 +1,298 @@
+package extender
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/libopenstorage/stork/drivers/volume"
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/api/core/v1"
+)
+
+const (
+	cacheTypeNodes   = "nodes"
+	cacheTypeVolumes = "volumes"
+
+	// volumesRetention is the minimum time for which the volumes for a pod
+	// are kept in the cache after they were fetched from the driver, so that
+	// they can be used if the driver can't be reached. Volumes for pods that
+	// haven't been scheduled since then are pruned.
+	volumesRetention = 1 * time.Hour
+	// volumesPruneInterval is the minimum interval at which the cache is
+	// checked for volumes to prune
+	volumesPruneInterval = 1 * time.Minute
+)
+
+var (
+	cacheHits = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "stork_extender_cache_hits_total",
+			Help: "Number of driver calls from the extender that were served from the cache",
+		},
+		[]string{"type"},
+	)
+	cacheMisses = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "stork_extender_cache_misses_total",
+			Help: "Number of driver calls from the extender that were not found in the cache",
+		},
+		[]string{"type"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(cacheHits, cacheMisses)
+}
+
+type volumeCacheEntry struct {
+	volumes []*volume.Info
+	expiry  time.Time
+	updated time.Time
+}
+
+// driverCache caches the node and volume info returned by the driver for a
+// configured TTL. Nothing is cached if the TTL is 0, unless the last known
+// info needs to be kept to be used when the driver can't be reached. When
+// multiple drivers are used, the drivers that can't be reached can be skipped
+// so that the locality from the other drivers is still used. Info from only
+// some of the drivers is never cached.
+type driverCache struct {
+	driver            volume.Driver
+	ttl               time.Duration
+	keepStale         bool
+	skipFailedDrivers bool
+	lock        sync.Mutex
+	nodes       []*volume.NodeInfo
+	nodesExpiry time.Time
+	volumes     map[string]*volumeCacheEntry
+	lastPrune   time.Time
+}
+
+func newDriverCache(driver volume.Driver, ttl time.Duration, keepStale bool, skipFailedDrivers bool) *driverCache {
+	return &driverCache{
+		driver:            driver,
+		ttl:               ttl,
+		keepStale:         keepStale,
+		skipFailedDrivers: skipFailedDrivers,
+		volumes:           make(map[string]*volumeCacheEntry),
+	}
+}
+
+// invalidate expires all the entries in the cache. The expired entries are
+// only used if the driver can't be reached.
+func (c *driverCache) invalidate() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.nodesExpiry = time.Time{}
+	for key, entry := range c.volumes {
+		c.volumes[key] = &volumeCacheEntry{volumes: entry.volumes, updated: entry.updated}
+	}
+}
+
+func (c *driverCache) enabled() bool {
+	return c.ttl != 0 || c.keepStale
+}
+
+// getNodes returns a copy of the cached nodes so that callers can update them
+func (c *driverCache) getNodes() ([]*volume.NodeInfo, error) {
+	if !c.enabled() {
+		nodes, _, err := c.driverGetNodes()
+		return nodes, err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.nodes == nil || !time.Now().Before(c.nodesExpiry) {
+		cacheMisses.WithLabelValues(cacheTypeNodes).Inc()
+		nodes, complete, err := c.driverGetNodes()
+		if err != nil {
+			return nil, err
+		}
+		if !complete {
+			return nodes, nil
+		}
+		c.nodes = copyNodes(nodes)
+		c.nodesExpiry = time.Now().Add(c.ttl)
+	} else {
+		cacheHits.WithLabelValues(cacheTypeNodes).Inc()
+	}
+	return copyNodes(c.nodes), nil
+}
+
+// getStaleNodes returns a copy of the last nodes returned by the driver even
+// if they have expired
+func (c *driverCache) getStaleNodes() ([]*volume.NodeInfo, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.nodes == nil {
+		return nil, false
+	}
+	return copyNodes(c.nodes), true
+}
+
+// getStalePodVolumes returns the last volumes returned by the driver for the
+// pod even if they have expired
+func (c *driverCache) getStalePodVolumes(pod *v1.Pod) ([]*volume.Info, bool) {
+	key, ok := volumesCacheKey(pod)
+	if !ok {
+		return nil, false
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	entry, present := c.volumes[key]
+	if !present {
+		return nil, false
+	}
+	return entry.volumes, true
+}
+
+// driverGetNodes returns the nodes from the driver and whether the nodes from
+// all the drivers were returned
+func (c *driverCache) driverGetNodes() ([]*volume.NodeInfo, bool, error) {
+	defer observeDriverCall(driverCallGetNodes, time.Now())
+	group, ok := c.driver.(volume.PartialDriverGroup)
+	if !ok || !c.skipFailedDrivers {
+		nodes, err := c.driver.GetNodes()
+		return nodes, true, err
+	}
+	nodes, errs := group.GetAvailableNodes()
+	if err := c.skipDrivers(group, errs, "nodes"); err != nil {
+		return nil, false, err
+	}
+	return nodes, len(errs) == 0, nil
+}
+
+// driverGetPodVolumes returns the volumes for the pod from the driver and
+// whether the volumes from all the drivers were returned
+func (c *driverCache) driverGetPodVolumes(pod *v1.Pod) ([]*volume.Info, bool, error) {
+	defer observeDriverCall(driverCallGetPodVolumes, time.Now())
+	group, ok := c.driver.(volume.PartialDriverGroup)
+	if !ok || !c.skipFailedDrivers {
+		volumes, err := c.driver.GetPodVolumes(&pod.Spec, pod.Namespace)
+		return volumes, true, err
+	}
+	volumes, errs := group.GetAvailablePodVolumes(&pod.Spec, pod.Namespace)
+	if err := c.skipDrivers(group, errs, "volumes"); err != nil {
+		return nil, false, err
+	}
+	return volumes, len(errs) == 0, nil
+}
+
+// skipDrivers logs the drivers that are skipped because they failed. An error
+// is returned if none of the drivers could be reached, or if a PVC for the pod
+// is pending since the pod needs to be retried then.
+func (c *driverCache) skipDrivers(group volume.PartialDriverGroup, errs map[string]error, infoType string) error {
+	drivers := group.Drivers()
+	failed := 0
+	for _, d := range drivers {
+		err, present := errs[d.String()]
+		if !present {
+			continue
+		}
+		if _, ok := err.(*volume.ErrPVCPending); ok {
+			return err
+		}
+		failed++
+	}
+	for _, d := range drivers {
+		err, present := errs[d.String()]
+		if !present {
+			continue
+		}
+		if failed == len(drivers) {
+			return err
+		}
+		log.Warnf("Skipping %v from driver %v since it can't be reached: %v", infoType, d.String(), err)
+	}
+	return nil
+}
+
+func copyNodes(nodes []*volume.NodeInfo) []*volume.NodeInfo {
+	nodesCopy := make([]*volume.NodeInfo, 0, len(nodes))
+	for _, node := range nodes {
+		nodeCopy := *node
+		nodesCopy = append(nodesCopy, &nodeCopy)
+	}
+	return nodesCopy
+}
+
+// getPodVolumes returns the driver volumes for the pod. Errors aren't cached
+// so that pods waiting for PVCs to be bound are retried.
+func (c *driverCache) getPodVolumes(pod *v1.Pod) ([]*volume.Info, error) {
+	key, ok := volumesCacheKey(pod)
+	if !c.enabled() || !ok {
+		volumes, _, err := c.driverGetPodVolumes(pod)
+		return volumes, err
+	}
+
+	c.lock.Lock()
+	entry, present := c.volumes[key]
+	c.lock.Unlock()
+	if present && time.Now().Before(entry.expiry) {
+		cacheHits.WithLabelValues(cacheTypeVolumes).Inc()
+		return entry.volumes, nil
+	}
+
+	cacheMisses.WithLabelValues(cacheTypeVolumes).Inc()
+	volumes, complete, err := c.driverGetPodVolumes(pod)
+	if err != nil {
+		return nil, err
+	}
+	if !complete {
+		return volumes, nil
+	}
+	c.lock.Lock()
+	c.volumes[key] = &volumeCacheEntry{
+		volumes: volumes,
+		expiry:  time.Now().Add(c.ttl),
+		updated: time.Now(),
+	}
+	if time.Since(c.lastPrune) >= volumesPruneInterval {
+		c.pruneVolumes()
+	}
+	c.lock.Unlock()
+	return volumes, nil
+}
+
+// pruneVolumes removes the volumes that were fetched from the driver longer
+// than the retention ago, since the cache is keyed by the PVCs of each pod and
+// would otherwise keep growing as pods are deleted. Needs to be called with
+// the lock held.
+func (c *driverCache) pruneVolumes() {
+	retention := volumesRetention
+	if c.ttl > retention {
+		retention = c.ttl
+	}
+	for key, entry := range c.volumes {
+		if time.Since(entry.updated) > retention {
+			delete(c.volumes, key)
+		}
+	}
+	c.lastPrune = time.Now()
+}
+
+// volumesCacheKey returns the key used to cache the volumes for a pod. Only
+// pods where all the volumes that could be from the driver are PVCs are
+// cached, since inline volumes can't be uniquely identified by name.
+func volumesCacheKey(pod *v1.Pod) (string, bool) {
+	claims := make([]string, 0)
+	for _, podVolume := range pod.Spec.Volumes {
+		switch {
+		case podVolume.PersistentVolumeClaim != nil:
+			claims = append(claims, podVolume.PersistentVolumeClaim.ClaimName)
+		case podVolume.ConfigMap != nil, podVolume.Secret != nil,
+			podVolume.EmptyDir != nil, podVolume.DownwardAPI != nil,
+			podVolume.Projected != nil:
+		default:
+			return "", false
+		}
+	}
+	if len(claims) == 0 {
+		return "", false
+	}
+	sort.Strings(claims)
+	return pod.Namespace + "/" + strings.Join(claims, ","), true
+}
@@ -0,0 +1,97 @@
+package extender
+
+import (
+	"fmt"
+
+	"github.com/libopenstorage/stork/drivers/volume"
+	storklog "github.com/libopenstorage/stork/pkg/log"
+	"k8s.io/api/core/v1"
+)
+
+const (
+	// DegradationPolicyFailOpen Schedule pods on any node without using the
+	// locality of their volumes when the driver can't be reached. When
+	// multiple drivers are used, only the drivers that can't be reached are
+	// skipped. This is the default
+	DegradationPolicyFailOpen = "fail-open"
+	// DegradationPolicyFailClosed Don't schedule pods using volumes from the
+	// driver when the driver can't be reached
+	DegradationPolicyFailClosed = "fail-closed"
+	// DegradationPolicyCachedData Use the last node and volume info returned
+	// by the driver when it can't be reached. Pods for which there is no
+	// cached info are scheduled like with the fail-open policy
+	DegradationPolicyCachedData = "cached"
+
+	degradedSchedulingEventReason = "DegradedScheduling"
+)
+
+// validateDegradationPolicy returns an error if the degradation policy isn't
+// supported
+func (e *Extender) validateDegradationPolicy() error {
+	switch e.DegradationPolicy {
+	case "", DegradationPolicyFailOpen, DegradationPolicyFailClosed, DegradationPolicyCachedData:
+		return nil
+	default:
+		return fmt.Errorf("invalid degradation policy %v, should be one of %v, %v or %v",
+			e.DegradationPolicy, DegradationPolicyFailOpen, DegradationPolicyFailClosed, DegradationPolicyCachedData)
+	}
+}
+
+// recordDegradation records that a pod is being scheduled without up-to-date
+// info from the driver
+func (e *Extender) recordDegradation(pod *v1.Pod, policy string, msg string, dryRun bool) {
+	storklog.PodLog(pod).Warn(msg)
+	e.recordEvent(pod, degradedSchedulingEventReason, msg, dryRun)
+	if !dryRun {
+		degradedRequests.WithLabelValues(policy).Inc()
+	}
+}
+
+// handleDriverError returns an error if pods shouldn't be scheduled when the
+// driver can't be reached, otherwise records that the pod is being scheduled
+// without using the locality of its volumes
+func (e *Extender) handleDriverError(pod *v1.Pod, err error, dryRun bool) error {
+	if e.DegradationPolicy == DegradationPolicyFailClosed {
+		if !dryRun {
+			degradedRequests.WithLabelValues(DegradationPolicyFailClosed).Inc()
+		}
+		return fmt.Errorf("not scheduling pod since storage driver can't be reached: %v", err)
+	}
+	e.recordDegradation(pod, DegradationPolicyFailOpen,
+		fmt.Sprintf("Scheduling pod without volume locality since storage driver can't be reached: %v", err), dryRun)
+	return nil
+}
+
+// getDriverPodVolumes returns the driver volumes for the pod. If the driver
+// can't be reached the last known volumes are returned when using the cached
+// data policy
+func (e *Extender) getDriverPodVolumes(pod *v1.Pod, dryRun bool) ([]*volume.Info, error) {
+	volumes, err := e.getCache().getPodVolumes(pod)
+	if err == nil || e.DegradationPolicy != DegradationPolicyCachedData {
+		return volumes, err
+	}
+	if _, ok := err.(*volume.ErrPVCPending); ok {
+		return nil, err
+	}
+	if staleVolumes, ok := e.getCache().getStalePodVolumes(pod); ok {
+		e.recordDegradation(pod, DegradationPolicyCachedData,
+			fmt.Sprintf("Using cached volume info since storage driver can't be reached: %v", err), dryRun)
+		return staleVolumes, nil
+	}
+	return nil, err
+}
+
+// getDriverNodes returns the nodes from the driver. If the driver can't be
+// reached the last known nodes are returned when using the cached data policy
+func (e *Extender) getDriverNodes(pod *v1.Pod, dryRun bool) ([]*volume.NodeInfo, error) {
+	nodes, err := e.getCache().getNodes()
+	if err == nil || e.DegradationPolicy != DegradationPolicyCachedData {
+		return nodes, err
+	}
+	if staleNodes, ok := e.getCache().getStaleNodes(); ok {
+		e.recordDegradation(pod, DegradationPolicyCachedData,
+			fmt.Sprintf("Using cached node info since storage driver can't be reached: %v", err), dryRun)
+		return staleNodes, nil
+	}
+	return nil, err
+}
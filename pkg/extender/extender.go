@@ -3,16 +3,26 @@ package extender
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/libopenstorage/stork/drivers/volume"
+	storkerrors "github.com/libopenstorage/stork/pkg/errors"
 	storklog "github.com/libopenstorage/stork/pkg/log"
+	"github.com/libopenstorage/stork/pkg/monitor"
+	"github.com/portworx/sched-ops/k8s"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	log "github.com/sirupsen/logrus"
 	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/record"
 	schedulerapi "k8s.io/kubernetes/pkg/scheduler/api"
 )
@@ -20,6 +30,9 @@ import (
 const (
 	filter     = "filter"
 	prioritize = "prioritize"
+	metrics    = "metrics"
+	healthz    = "healthz"
+	readyz     = "readyz"
 	// nodePriorityScore Score by which each node is bumped if it has data for a volume
 	nodePriorityScore = 100
 	// rackPriorityScore Score by which each node is bumped if it is in the same
@@ -33,17 +46,63 @@ const (
 	regionPriorityScore = 10
 	// defaultScore Score assigned to a node which doesn't have data for any volume
 	defaultScore = 5
+	// capacityPriorityScore Maximum score by which each node is bumped based
+	// on the free capacity in its storage pools. The node with the most free
+	// capacity gets the full score. The score is capped below the gap to the
+	// next higher locality score so that it only breaks ties between nodes
+	// with the same locality
+	capacityPriorityScore = 10
 
 	schedulingFailureEventReason = "FailedScheduling"
+
+	// SchedulingModeAnnotation Annotation on pods or namespaces used to
+	// change how nodes are scored for pods using volumes from the driver
+	SchedulingModeAnnotation = "stork.libopenstorage.org/scheduling-mode"
+	// SchedulingModeHyperconverged Prefer nodes that have data for the
+	// volumes. This is the default
+	SchedulingModeHyperconverged = "hyperconverged"
+	// SchedulingModeAntiHyperconverged Prefer nodes that do NOT have data for
+	// the volumes, for apps that are network-bound rather than IO-bound
+	SchedulingModeAntiHyperconverged = "anti-hyperconverged"
+	// SchedulingModeSpread Prefer nodes that have data for the volumes, but
+	// lower the scores of nodes in failure domains that are already running
+	// pods of the same app
+	SchedulingModeSpread = "spread"
 )
 
 // Extender Scheduler extender
 type Extender struct {
 	Recorder record.EventRecorder
 	Driver   volume.Driver
-	server   *http.Server
-	lock     sync.Mutex
-	started  bool
+	// CacheTTL is the time for which node and volume info from the driver is
+	// cached. Nothing is cached if this is 0
+	CacheTTL time.Duration
+	// ScoresConfigMapName is the name of the config map used to configure
+	// the scores for each level of locality. The default scores are used if
+	// this isn't set
+	ScoresConfigMapName string
+	// ScoresConfigMapNamespace is the namespace of the scores config map
+	ScoresConfigMapNamespace string
+	// StorageClassOptIn if set, only volumes from storage classes annotated
+	// with StorageClassEnabledAnnotation set to true are considered
+	StorageClassOptIn bool
+	// DegradationPolicy is the policy used to schedule pods when the driver
+	// can't be reached. Defaults to DegradationPolicyFailOpen
+	DegradationPolicy string
+	// KubeClient is the client used to look up the controllers of pods for
+	// the spread scheduling mode. Only the direct controllers of pods are
+	// used if this isn't set
+	KubeClient   kubernetes.Interface
+	server       *http.Server
+	lock         sync.Mutex
+	started      bool
+	stopChannel  chan struct{}
+	cache        *driverCache
+	cacheOnce    sync.Once
+	scores       *nodeScores
+	scoresLock   sync.RWMutex
+	apiCache     *apiCache
+	apiCacheOnce sync.Once
 }
 
 // Start Starts the extender
@@ -54,16 +113,27 @@ func (e *Extender) Start() error {
 	if e.started {
 		return fmt.Errorf("Extender has already been started")
 	}
+	if err := e.validateDegradationPolicy(); err != nil {
+		return err
+	}
 
 	// TODO: Make the listen port configurable
 	e.server = &http.Server{Addr: ":8099"}
 
 	http.HandleFunc("/", e.serveHTTP)
+	// Listen before returning so that requests can be served as soon as the
+	// extender has been started
+	listener, err := net.Listen("tcp", e.server.Addr)
+	if err != nil {
+		return fmt.Errorf("error starting extender server: %v", err)
+	}
 	go func() {
-		if err := e.server.ListenAndServe(); err != http.ErrServerClosed {
+		if err := e.server.Serve(listener); err != http.ErrServerClosed {
 			log.Panicf("Error starting extender server: %v", err)
 		}
 	}()
+	e.stopChannel = make(chan struct{})
+	e.startScoresRefresh(e.stopChannel)
 	e.started = true
 	return nil
 }
@@ -83,12 +153,53 @@ func (e *Extender) Stop() error {
 	if err := e.server.Shutdown(ctx); err != nil {
 		return err
 	}
+	close(e.stopChannel)
 	e.started = false
 	return nil
 }
 
+// InvalidateCache Removes all the node and volume info cached from the
+// driver. Should be called when the state of the storage nodes changes
+func (e *Extender) InvalidateCache() {
+	e.getCache().invalidate()
+}
+
+// recordEvent records a warning event for the pod, unless the request is only
+// being simulated
+func (e *Extender) recordEvent(pod *v1.Pod, reason string, msg string, dryRun bool) {
+	if !dryRun {
+		e.Recorder.Event(pod, v1.EventTypeWarning, reason, msg)
+	}
+}
+
+func (e *Extender) getAPICache() *apiCache {
+	e.apiCacheOnce.Do(func() {
+		e.apiCache = newAPICache(apiCacheTTL)
+	})
+	return e.apiCache
+}
+
+func (e *Extender) getCache() *driverCache {
+	e.cacheOnce.Do(func() {
+		e.cache = newDriverCache(e.Driver, e.CacheTTL,
+			e.DegradationPolicy == DegradationPolicyCachedData,
+			e.DegradationPolicy == "" || e.DegradationPolicy == DegradationPolicyFailOpen)
+	})
+	return e.cache
+}
+
 func (e *Extender) serveHTTP(w http.ResponseWriter, req *http.Request) {
-	if strings.Contains(req.URL.Path, filter) {
+	if strings.Contains(req.URL.Path, metrics) {
+		promhttp.Handler().ServeHTTP(w, req)
+	} else if strings.Contains(req.URL.Path, healthz) {
+		w.WriteHeader(http.StatusOK)
+	} else if strings.Contains(req.URL.Path, readyz) {
+		e.processReadyRequest(w)
+	} else if strings.Contains(req.URL.Path, simulate) {
+		e.processSimulateRequest(w, req)
+	} else if strings.Contains(req.URL.Path, volumes) {
+		e.processVolumesRequest(w, req)
+	} else if strings.Contains(req.URL.Path, filter) {
 		e.processFilterRequest(w, req)
 	} else if strings.Contains(req.URL.Path, prioritize) {
 		e.processPrioritizeRequest(w, req)
@@ -97,6 +208,17 @@ func (e *Extender) serveHTTP(w http.ResponseWriter, req *http.Request) {
 	}
 }
 
+// processReadyRequest returns an error if the driver can't be reached, since
+// the extender won't be able to use the locality of the volumes to schedule
+// pods
+func (e *Extender) processReadyRequest(w http.ResponseWriter) {
+	if _, err := e.getCache().getNodes(); err != nil {
+		http.Error(w, fmt.Sprintf("Error getting nodes from driver: %v", err), http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
 func (e *Extender) getHostname(node *v1.Node) string {
 	for _, address := range node.Status.Addresses {
 		if address.Type == v1.NodeHostName {
@@ -107,6 +229,7 @@ func (e *Extender) getHostname(node *v1.Node) string {
 }
 
 func (e *Extender) processFilterRequest(w http.ResponseWriter, req *http.Request) {
+	defer observeRequest(filter, time.Now())
 	decoder := json.NewDecoder(req.Body)
 	defer func() {
 		if err := req.Body.Close(); err != nil {
@@ -118,58 +241,108 @@ func (e *Extender) processFilterRequest(w http.ResponseWriter, req *http.Request
 	var args schedulerapi.ExtenderArgs
 	if err := decoder.Decode(&args); err != nil {
 		log.Errorf("Error decoding filter request: %v", err)
+		requestErrors.WithLabelValues(filter).Inc()
 		http.Error(w, "Decode error", http.StatusBadRequest)
 		return
 	}
 
-	pod := args.Pod
+	filteredNodes, err := e.Filter(args.Pod, args.Nodes.Items)
+	if err != nil {
+		requestErrors.WithLabelValues(filter).Inc()
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	response := &schedulerapi.ExtenderFilterResult{
+		Nodes: &v1.NodeList{
+			Items: filteredNodes,
+		},
+	}
+	if err := encoder.Encode(response); err != nil {
+		storklog.PodLog(args.Pod).Errorf("Error encoding filter response: %+v : %v", response, err)
+	}
+}
+
+// Filter Returns the nodes from the list on which the pod can be scheduled
+// based on the state of the storage driver on the nodes and the nodes where
+// the data for the pod's volumes is located
+func (e *Extender) Filter(pod *v1.Pod, nodes []v1.Node) ([]v1.Node, error) {
+	return e.filter(pod, nodes, false)
+}
+
+// filter returns the nodes on which the pod can be scheduled. If dryRun is
+// set, no events are recorded and the cache isn't invalidated, so that the
+// request can be simulated without affecting the scheduling of the pod.
+func (e *Extender) filter(pod *v1.Pod, nodes []v1.Node, dryRun bool) ([]v1.Node, error) {
 	storklog.PodLog(pod).Debugf("Nodes in filter request:")
-	for _, node := range args.Nodes.Items {
+	for _, node := range nodes {
 		storklog.PodLog(pod).Debugf("%v %+v", node.Name, node.Status.Addresses)
 	}
 
 	filteredNodes := []v1.Node{}
-	driverVolumes, err := e.Driver.GetPodVolumes(&pod.Spec, pod.Namespace)
+	var driverVolumes []*volume.Info
+	var err error
+	if volumesPod, ok := e.getEnabledVolumesPod(pod); ok {
+		driverVolumes, err = e.getDriverPodVolumes(volumesPod, dryRun)
+	}
 	if err != nil {
 		msg := fmt.Sprintf("Error getting volumes for Pod for driver: %v", err)
-		storklog.PodLog(pod).Warnf(msg)
-		e.Recorder.Event(pod, v1.EventTypeWarning, schedulingFailureEventReason, msg)
+		storklog.PodLog(pod).Warn(msg)
+		e.recordEvent(pod, schedulingFailureEventReason, msg, dryRun)
 		if _, ok := err.(*volume.ErrPVCPending); ok {
-			http.Error(w, "Waiting for PVC to be bound", http.StatusBadRequest)
-			return
+			return nil, errors.New("waiting for PVC to be bound")
+		}
+		if err := e.handleDriverError(pod, err, dryRun); err != nil {
+			return nil, err
 		}
 	} else if len(driverVolumes) > 0 {
-		driverNodes, err := e.Driver.GetNodes()
+		driverNodes, err := e.getDriverNodes(pod, dryRun)
 		if err != nil {
-			storklog.PodLog(pod).Errorf("Error getting list of driver nodes, returning all nodes")
+			storklog.PodLog(pod).Errorf("Error getting list of driver nodes: %v", err)
+			if err := e.handleDriverError(pod, err, dryRun); err != nil {
+				return nil, err
+			}
 		} else {
 			for _, volumeInfo := range driverVolumes {
 				onlineNodeFound := false
 				for _, volumeNode := range volumeInfo.DataNodes {
 					for _, driverNode := range driverNodes {
-						if volumeNode == driverNode.StorageID && driverNode.Status == volume.NodeOnline {
+						if volumeNode == driverNode.StorageID && driverNode.Driver == volumeInfo.Driver &&
+							driverNode.Status == volume.NodeOnline {
 							onlineNodeFound = true
 						}
 					}
 				}
 				if !onlineNodeFound {
+					// Invalidate the cache so that the next attempt to schedule
+					// the pod doesn't use stale info
+					if !dryRun {
+						e.InvalidateCache()
+					}
 					storklog.PodLog(pod).Errorf("No nodes in filter request have replica for volume, returning error")
 					msg := "No online node found with volume replica"
-					e.Recorder.Event(pod, v1.EventTypeWarning, schedulingFailureEventReason, msg)
-					http.Error(w, msg, http.StatusBadRequest)
-					return
+					e.recordEvent(pod, schedulingFailureEventReason, msg, dryRun)
+					return nil, errors.New(msg)
 				}
 			}
 
-			for _, node := range args.Nodes.Items {
+			// When multiple drivers are used, the drivers for all the volumes
+			// need to be online on the node
+			volumeDrivers := make(map[string]bool)
+			for _, volumeInfo := range driverVolumes {
+				volumeDrivers[volumeInfo.Driver] = true
+			}
+			for _, node := range nodes {
+				onlineDrivers := make(map[string]bool)
 				for _, driverNode := range driverNodes {
 					storklog.PodLog(pod).Debugf("nodeInfo: %v", driverNode)
-					if driverNode.Status == volume.NodeOnline &&
+					if driverNode.Status == volume.NodeOnline && volumeDrivers[driverNode.Driver] &&
 						volume.IsNodeMatch(&node, driverNode) {
-						filteredNodes = append(filteredNodes, node)
-						break
+						onlineDrivers[driverNode.Driver] = true
 					}
 				}
+				if len(onlineDrivers) == len(volumeDrivers) {
+					filteredNodes = append(filteredNodes, node)
+				}
 			}
 			// If we filtered out all the nodes, the driver isn't running on any
 			// of them, so return an error to avoid scheduling a pod on a
@@ -177,30 +350,53 @@ func (e *Extender) processFilterRequest(w http.ResponseWriter, req *http.Request
 			if len(filteredNodes) == 0 {
 				storklog.PodLog(pod).Errorf("No nodes in filter request have driver, returning error")
 				msg := "No node found with storage driver"
-				e.Recorder.Event(pod, v1.EventTypeWarning, schedulingFailureEventReason, msg)
-				http.Error(w, msg, http.StatusBadRequest)
-				return
+				e.recordEvent(pod, schedulingFailureEventReason, msg, dryRun)
+				return nil, errors.New(msg)
 			}
 		}
 	}
 
 	// If we didn't find a PVC that interested us, return all the nodes from the request
 	if len(filteredNodes) == 0 {
-		filteredNodes = args.Nodes.Items
+		filteredNodes = nodes
+	}
+
+	// Also check the status published by the health monitor for pods using
+	// volumes from the driver, in case the driver couldn't be reached or the
+	// node info from the driver is cached
+	if len(driverVolumes) > 0 || err != nil {
+		filteredNodes = filterStorageOfflineNodes(pod, filteredNodes)
+		if len(filteredNodes) == 0 {
+			msg := "No node found with storage online"
+			e.recordEvent(pod, schedulingFailureEventReason, msg, dryRun)
+			return nil, errors.New(msg)
+		}
+	}
+
+	filteredNodes, err = e.filterLiveMigrationSource(pod, filteredNodes, dryRun)
+	if err != nil {
+		return nil, err
 	}
 
 	storklog.PodLog(pod).Debugf("Nodes in filter response:")
 	for _, node := range filteredNodes {
 		log.Debugf("%v %+v", node.Name, node.Status.Addresses)
 	}
-	response := &schedulerapi.ExtenderFilterResult{
-		Nodes: &v1.NodeList{
-			Items: filteredNodes,
-		},
-	}
-	if err := encoder.Encode(response); err != nil {
-		storklog.PodLog(pod).Errorf("Error encoding filter response: %+v : %v", response, err)
+	return filteredNodes, nil
+}
+
+// filterStorageOfflineNodes removes the nodes that have been labeled by the
+// health monitor as not having the storage driver online
+func filterStorageOfflineNodes(pod *v1.Pod, nodes []v1.Node) []v1.Node {
+	filteredNodes := make([]v1.Node, 0, len(nodes))
+	for _, node := range nodes {
+		if status, ok := node.Labels[monitor.StorageStatusLabel]; ok {
+			storklog.PodLog(pod).Debugf("Filtering node %v with storage status %v", node.Name, status)
+			continue
+		}
+		filteredNodes = append(filteredNodes, node)
 	}
+	return filteredNodes
 }
 
 func (e *Extender) getNodeScore(
@@ -210,6 +406,7 @@ func (e *Extender) getNodeScore(
 	zoneInfo *localityInfo,
 	regionInfo *localityInfo,
 	idMap map[string]*volume.NodeInfo,
+	scores nodeScores,
 ) int {
 	for _, address := range node.Status.Addresses {
 		if address.Type != v1.NodeHostName {
@@ -227,21 +424,21 @@ func (e *Extender) getNodeScore(
 							if rack == nodeRack || nodeRack == "" {
 								for _, datanode := range volumeInfo.DataNodes {
 									if volume.IsNodeMatch(&node, idMap[datanode]) {
-										return nodePriorityScore
+										return scores.node
 									}
 								}
 								if nodeRack != "" {
-									return rackPriorityScore
+									return scores.rack
 								}
 							}
 						}
 						if nodeZone != "" {
-							return zonePriorityScore
+							return scores.zone
 						}
 					}
 				}
 				if nodeRegion != "" {
-					return regionPriorityScore
+					return scores.region
 				}
 			}
 		}
@@ -249,12 +446,229 @@ func (e *Extender) getNodeScore(
 	return 0
 }
 
+// getSharedVolumeLocality returns the volume info to be used to score nodes.
+// Pods using a shared volume access the data through the node that is serving
+// the volume, so for shared volumes nodes are scored based on their locality
+// to that node instead of the replicas.
+func (e *Extender) getSharedVolumeLocality(pod *v1.Pod, volumeInfo *volume.Info) *volume.Info {
+	if !volumeInfo.Shared {
+		return volumeInfo
+	}
+	coordinator, err := e.Driver.GetSharedVolumeCoordinator(volumeInfo)
+	if err != nil {
+		if _, ok := err.(*storkerrors.ErrNotSupported); !ok {
+			storklog.PodLog(pod).Warnf("Error getting coordinator for shared volume %v: %v", volumeInfo.VolumeName, err)
+		}
+		return volumeInfo
+	}
+	if coordinator == "" {
+		return volumeInfo
+	}
+	storklog.PodLog(pod).Debugf("Using coordinator %v for shared volume %v", coordinator, volumeInfo.VolumeName)
+	sharedVolumeInfo := *volumeInfo
+	sharedVolumeInfo.DataNodes = []string{coordinator}
+	return &sharedVolumeInfo
+}
+
+// getCapacityScores returns the score for each node in the request based on
+// the free capacity of the storage pools on the node relative to the node with
+// the most free capacity. Nodes for which the driver doesn't report capacity
+// aren't scored.
+func (e *Extender) getCapacityScores(
+	nodes []v1.Node,
+	driverNodes []*volume.NodeInfo,
+	scores nodeScores,
+) map[string]int {
+	capacityMap := make(map[string]int)
+	freeCapacity := make(map[string]uint64)
+	var maxFreeCapacity uint64
+	for _, node := range nodes {
+		for _, dnode := range driverNodes {
+			if dnode.Status != volume.NodeOnline || dnode.TotalCapacity == 0 ||
+				!volume.IsNodeMatch(&node, dnode) {
+				continue
+			}
+			freeCapacity[node.Name] = dnode.FreeCapacity
+			if dnode.FreeCapacity > maxFreeCapacity {
+				maxFreeCapacity = dnode.FreeCapacity
+			}
+			break
+		}
+	}
+	if maxFreeCapacity == 0 {
+		return capacityMap
+	}
+	for name, free := range freeCapacity {
+		capacityMap[name] = int(float64(free) / float64(maxFreeCapacity) * float64(scores.capacity))
+	}
+	return capacityMap
+}
+
+// limitCapacityScores caps the capacity score of each node below the gap
+// between its locality score and the next higher locality score of any node,
+// so that the free capacity only breaks ties between nodes with the same
+// locality and never ranks a node above one with better locality
+func limitCapacityScores(localityMap map[string]int, capacityMap map[string]int) {
+	localityScores := make([]int, 0, len(localityMap))
+	for _, score := range localityMap {
+		localityScores = append(localityScores, score)
+	}
+	sort.Ints(localityScores)
+	for name, capacity := range capacityMap {
+		score, ok := localityMap[name]
+		if !ok {
+			continue
+		}
+		next := sort.SearchInts(localityScores, score+1)
+		if next == len(localityScores) {
+			continue
+		}
+		if gap := localityScores[next] - score - 1; capacity > gap {
+			capacityMap[name] = gap
+		}
+	}
+}
+
+// getSchedulingMode returns the scheduling mode from the annotations on the
+// pod or its namespace
+func (e *Extender) getSchedulingMode(pod *v1.Pod) string {
+	mode, ok := pod.Annotations[SchedulingModeAnnotation]
+	if !ok {
+		var err error
+		mode, ok, err = e.getAPICache().getSchedulingMode(pod.Namespace)
+		if err != nil {
+			storklog.PodLog(pod).Debugf("Error getting namespace for scheduling mode: %v", err)
+			return SchedulingModeHyperconverged
+		}
+		if !ok {
+			return SchedulingModeHyperconverged
+		}
+	}
+	switch mode {
+	case SchedulingModeHyperconverged, SchedulingModeAntiHyperconverged, SchedulingModeSpread:
+		return mode
+	}
+	storklog.PodLog(pod).Warnf("Invalid scheduling mode %v, using %v", mode, SchedulingModeHyperconverged)
+	return SchedulingModeHyperconverged
+}
+
+// getSchedulingMode returns the value of SchedulingModeAnnotation on the
+// namespace, and whether the annotation is set
+func (c *apiCache) getSchedulingMode(namespace string) (string, bool, error) {
+	return c.get(
+		func() map[string]*apiCacheEntry { return c.schedulingModes },
+		namespace,
+		func() (string, bool, error) {
+			ns, err := k8s.Instance().GetNamespace(namespace)
+			if err != nil {
+				return "", false, err
+			}
+			mode, ok := ns.Annotations[SchedulingModeAnnotation]
+			return mode, ok, nil
+		})
+}
+
+// getFailureDomain returns the zone of the node if present, else the rack
+// and falls back to the hostname
+func getFailureDomain(hostname string, rackInfo, zoneInfo *localityInfo) string {
+	if zone := zoneInfo.HostnameMap[hostname]; zone != "" {
+		return zone
+	}
+	if rack := rackInfo.HostnameMap[hostname]; rack != "" {
+		return rack
+	}
+	return hostname
+}
+
+// applySpreadScores divides the score of each node by one more than the
+// number of pods from the same app already running in the failure domain of
+// the node. Pods are considered to be from the same app if they have the same
+// top-level controller, so pods from all the ReplicaSets of a Deployment are
+// counted during a rolling update.
+func (e *Extender) applySpreadScores(
+	pod *v1.Pod,
+	nodes []v1.Node,
+	driverNodes []*volume.NodeInfo,
+	rackInfo *localityInfo,
+	zoneInfo *localityInfo,
+	priorityMap map[string]int,
+) {
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return
+	}
+	ownerUIDs, err := e.getReplicaSetOwners(pod.Namespace)
+	if err != nil {
+		storklog.PodLog(pod).Warnf("Error getting controllers for spread scheduling: %v", err)
+		return
+	}
+	appUID := getTopLevelOwner(owner, ownerUIDs)
+	pods, err := k8s.Instance().GetPods(pod.Namespace, nil)
+	if err != nil {
+		storklog.PodLog(pod).Warnf("Error getting pods for spread scheduling: %v", err)
+		return
+	}
+
+	domainPods := make(map[string]int)
+	for _, appPod := range pods.Items {
+		if appPod.Spec.NodeName == "" || appPod.Name == pod.Name {
+			continue
+		}
+		appPodOwner := metav1.GetControllerOf(&appPod)
+		if appPodOwner == nil || getTopLevelOwner(appPodOwner, ownerUIDs) != appUID {
+			continue
+		}
+		for _, dnode := range driverNodes {
+			if dnode.SchedulerID == appPod.Spec.NodeName || dnode.Hostname == appPod.Spec.NodeName {
+				domainPods[getFailureDomain(dnode.Hostname, rackInfo, zoneInfo)]++
+				break
+			}
+		}
+	}
+	storklog.PodLog(pod).Debugf("Pods for app in failure domains: %v", domainPods)
+
+	for _, node := range nodes {
+		domain := getFailureDomain(e.getHostname(&node), rackInfo, zoneInfo)
+		priorityMap[node.Name] = priorityMap[node.Name] / (1 + domainPods[domain])
+	}
+}
+
+// getReplicaSetOwners returns the UIDs of the controllers of the ReplicaSets
+// in the namespace, keyed by the UID of the ReplicaSets
+func (e *Extender) getReplicaSetOwners(namespace string) (map[types.UID]types.UID, error) {
+	ownerUIDs := make(map[types.UID]types.UID)
+	if e.KubeClient == nil {
+		return ownerUIDs, nil
+	}
+	replicaSets, err := e.KubeClient.AppsV1().ReplicaSets(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, replicaSet := range replicaSets.Items {
+		if owner := metav1.GetControllerOf(&replicaSet); owner != nil {
+			ownerUIDs[replicaSet.UID] = owner.UID
+		}
+	}
+	return ownerUIDs, nil
+}
+
+// getTopLevelOwner returns the UID of the controller of the owner if the owner
+// is a ReplicaSet managed by another controller, like a Deployment, otherwise
+// the UID of the owner
+func getTopLevelOwner(owner *metav1.OwnerReference, ownerUIDs map[types.UID]types.UID) types.UID {
+	if uid, ok := ownerUIDs[owner.UID]; ok && owner.Kind == "ReplicaSet" {
+		return uid
+	}
+	return owner.UID
+}
+
 type localityInfo struct {
 	HostnameMap       map[string]string
 	PreferredLocality []string
 }
 
 func (e *Extender) processPrioritizeRequest(w http.ResponseWriter, req *http.Request) {
+	defer observeRequest(prioritize, time.Now())
 	decoder := json.NewDecoder(req.Body)
 	defer func() {
 		if err := req.Body.Close(); err != nil {
@@ -266,20 +680,45 @@ func (e *Extender) processPrioritizeRequest(w http.ResponseWriter, req *http.Req
 	var args schedulerapi.ExtenderArgs
 	if err := decoder.Decode(&args); err != nil {
 		log.Errorf("Error decoding prioritize request: %v", err)
+		requestErrors.WithLabelValues(prioritize).Inc()
 		http.Error(w, "Decode error", http.StatusBadRequest)
 		return
 	}
 
-	pod := args.Pod
+	respList, err := e.Prioritize(args.Pod, args.Nodes.Items)
+	if err != nil {
+		requestErrors.WithLabelValues(prioritize).Inc()
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := encoder.Encode(respList); err != nil {
+		storklog.PodLog(args.Pod).Errorf("Failed to encode response: %v", err)
+	}
+}
+
+// Prioritize Returns the scores for the nodes in the list for the pod based
+// on the locality of the data for the pod's volumes and the free capacity on
+// the nodes
+func (e *Extender) Prioritize(pod *v1.Pod, nodes []v1.Node) (schedulerapi.HostPriorityList, error) {
+	return e.prioritize(pod, nodes, false)
+}
+
+// prioritize returns the scores for the nodes. If dryRun is set, no events are
+// recorded.
+func (e *Extender) prioritize(pod *v1.Pod, nodes []v1.Node, dryRun bool) (schedulerapi.HostPriorityList, error) {
 	storklog.PodLog(pod).Debugf("Nodes in prioritize request:")
-	for _, node := range args.Nodes.Items {
+	for _, node := range nodes {
 		storklog.PodLog(pod).Debugf("%+v", node.Status.Addresses)
 	}
 	respList := schedulerapi.HostPriorityList{}
 
 	// Intialize scores to 0
 	priorityMap := make(map[string]int)
-	for _, node := range args.Nodes.Items {
+	capacityMap := make(map[string]int)
+	mode := e.getSchedulingMode(pod)
+	scores := e.getScores()
+	for _, node := range nodes {
 		for _, address := range node.Status.Addresses {
 			if address.Type == v1.NodeHostName {
 				priorityMap[address.Address] = 0
@@ -287,18 +726,23 @@ func (e *Extender) processPrioritizeRequest(w http.ResponseWriter, req *http.Req
 		}
 	}
 
-	driverVolumes, err := e.Driver.GetPodVolumes(&pod.Spec, pod.Namespace)
+	var driverVolumes []*volume.Info
+	var err error
+	// Volumes from the volume affinity annotation are only used to score
+	// nodes, they aren't required to be online to schedule the pod
+	if volumesPod, ok := e.getEnabledVolumesPod(e.getVolumeAffinityPod(pod)); ok {
+		driverVolumes, err = e.getDriverPodVolumes(volumesPod, dryRun)
+	}
 	if err != nil {
 		msg := fmt.Sprintf("Error getting volumes for Pod for driver: %v", err)
-		storklog.PodLog(pod).Warnf(msg)
-		e.Recorder.Event(pod, v1.EventTypeWarning, schedulingFailureEventReason, msg)
+		storklog.PodLog(pod).Warn(msg)
+		e.recordEvent(pod, schedulingFailureEventReason, msg, dryRun)
 		if _, ok := err.(*volume.ErrPVCPending); ok {
-			http.Error(w, "Waiting for PVC to be bound", http.StatusBadRequest)
-			return
+			return nil, errors.New("waiting for PVC to be bound")
 		}
 		goto sendResponse
 	} else if len(driverVolumes) > 0 {
-		driverNodes, err := e.Driver.GetNodes()
+		driverNodes, err := e.getDriverNodes(pod, dryRun)
 		if err != nil {
 			storklog.PodLog(pod).Errorf("Error getting nodes for driver: %v", err)
 			goto sendResponse
@@ -313,7 +757,7 @@ func (e *Extender) processPrioritizeRequest(w http.ResponseWriter, req *http.Req
 		for _, dnode := range driverNodes {
 			// Replace driver's hostname with the kubernetes hostname to make it
 			// easier to match nodes when calculating scores
-			for _, knode := range args.Nodes.Items {
+			for _, knode := range nodes {
 				if volume.IsNodeMatch(&knode, dnode) {
 					dnode.Hostname = e.getHostname(&knode)
 					break
@@ -348,7 +792,8 @@ func (e *Extender) processPrioritizeRequest(w http.ResponseWriter, req *http.Req
 		storklog.PodLog(pod).Debugf("zoneMap: %v", zoneInfo.HostnameMap)
 		storklog.PodLog(pod).Debugf("regionMap: %v", regionInfo.HostnameMap)
 
-		for _, volume := range driverVolumes {
+		for _, volumeInfo := range driverVolumes {
+			volume := e.getSharedVolumeLocality(pod, volumeInfo)
 			storklog.PodLog(pod).Debugf("Volume %v allocated on nodes:", volume.VolumeName)
 			// Get the racks, zones and regions where the volume is located
 			rackInfo.PreferredLocality = rackInfo.PreferredLocality[:0]
@@ -368,21 +813,43 @@ func (e *Extender) processPrioritizeRequest(w http.ResponseWriter, req *http.Req
 			storklog.PodLog(pod).Debugf("Volume %v allocated in zones: %v", volume.VolumeName, zoneInfo.PreferredLocality)
 			storklog.PodLog(pod).Debugf("Volume %v allocated in regions: %v", volume.VolumeName, regionInfo.PreferredLocality)
 
-			for _, node := range args.Nodes.Items {
-				priorityMap[node.Name] += e.getNodeScore(node, volume, &rackInfo, &zoneInfo, &regionInfo, idMap)
+			for _, node := range nodes {
+				score := e.getNodeScore(node, volume, &rackInfo, &zoneInfo, &regionInfo, idMap, scores)
+				if mode == SchedulingModeAntiHyperconverged {
+					score = scores.node - score
+					if score < 0 {
+						score = 0
+					}
+				}
+				priorityMap[node.Name] += score
 			}
 		}
+
+		if mode == SchedulingModeSpread {
+			e.applySpreadScores(pod, nodes, driverNodes, &rackInfo, &zoneInfo, priorityMap)
+		}
+
+		capacityMap = e.getCapacityScores(nodes, driverNodes, scores)
+		storklog.PodLog(pod).Debugf("capacityMap: %v", capacityMap)
 	}
 
 sendResponse:
 	// For any nodes that didn't have any volumes, assign it a
 	// default score so that it doesn't get completely ignored
 	// by the scheduler
-	for _, node := range args.Nodes.Items {
+	localityMap := make(map[string]int)
+	for _, node := range nodes {
 		score, ok := priorityMap[node.Name]
 		if !ok || score == 0 {
-			score = defaultScore
+			score = scores.def
 		}
+		localityMap[node.Name] = score
+	}
+	// Add the capacity score after the default score so that nodes
+	// without any data don't lose their default score
+	limitCapacityScores(localityMap, capacityMap)
+	for _, node := range nodes {
+		score := localityMap[node.Name] + capacityMap[node.Name]
 		hostPriority := schedulerapi.HostPriority{Host: node.Name, Score: score}
 		respList = append(respList, hostPriority)
 	}
@@ -392,7 +859,5 @@ sendResponse:
 		storklog.PodLog(pod).Debugf("%+v", node)
 	}
 
-	if err := encoder.Encode(respList); err != nil {
-		storklog.PodLog(pod).Errorf("Failed to encode response: %v", err)
-	}
+	return respList, nil
 }
@@ -10,14 +10,21 @@ import (
 	"net/http"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/libopenstorage/stork/drivers/volume"
 	"github.com/libopenstorage/stork/drivers/volume/mock"
+	"github.com/libopenstorage/stork/pkg/monitor"
+	"github.com/portworx/sched-ops/k8s"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
 	"k8s.io/api/core/v1"
 	apiv1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	kubernetes "k8s.io/client-go/kubernetes/fake"
 	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/tools/record"
@@ -50,13 +57,15 @@ func setup(t *testing.T) {
 	}
 
 	fakeKubeClient := kubernetes.NewSimpleClientset()
+	k8s.Instance().SetClient(fakeKubeClient, nil, nil, nil, nil, nil)
 	eventBroadcaster := record.NewBroadcaster()
 	eventBroadcaster.StartRecordingToSink(&corev1.EventSinkImpl{Interface: corev1.New(fakeKubeClient.Core().RESTClient()).Events("")})
 	recorder := eventBroadcaster.NewRecorder(legacyscheme.Scheme, apiv1.EventSource{Component: "storktest"})
 
 	extender = &Extender{
-		Driver:   storkdriver,
-		Recorder: recorder,
+		Driver:     storkdriver,
+		Recorder:   recorder,
+		KubeClient: fakeKubeClient,
 	}
 
 	if err = extender.Start(); err != nil {
@@ -278,6 +287,22 @@ func TestExtender(t *testing.T) {
 	t.Run("ipTest", ipTest)
 	t.Run("invalidRequestsTest", invalidRequestsTest)
 	t.Run("noReplicasTest", noReplicasTest)
+	t.Run("capacityTest", capacityTest)
+	t.Run("antiHyperconvergedTest", antiHyperconvergedTest)
+	t.Run("spreadTest", spreadTest)
+	t.Run("spreadDeploymentTest", spreadDeploymentTest)
+	t.Run("cacheTest", cacheTest)
+	t.Run("partialDriversCacheTest", partialDriversCacheTest)
+	t.Run("scoresConfigMapTest", scoresConfigMapTest)
+	t.Run("kubevirtMigrationTest", kubevirtMigrationTest)
+	t.Run("sharedVolumeTest", sharedVolumeTest)
+	t.Run("healthTest", healthTest)
+	t.Run("degradationPolicyTest", degradationPolicyTest)
+	t.Run("storageClassTest", storageClassTest)
+	t.Run("volumeAffinityTest", volumeAffinityTest)
+	t.Run("simulateTest", simulateTest)
+	t.Run("storageOfflineLabelTest", storageOfflineLabelTest)
+	t.Run("volumesTest", volumesTest)
 	t.Run("teardown", teardown)
 }
 
@@ -827,3 +852,1069 @@ func noReplicasTest(t *testing.T) {
 	_, err := sendFilterRequest(pod, requestNodes)
 	require.Error(t, err, "Expected error since no replicas are online")
 }
+
+// Create a pod with a PVC using the mock storage class.
+// Place the data on nodes n1, n2. Report capacity for all nodes except n5,
+// with n2 and n4 having the most free capacity.
+// The prioritize response should break ties between nodes with the same
+// locality using the free capacity
+func capacityTest(t *testing.T) {
+	nodes := &v1.NodeList{}
+	nodes.Items = append(nodes.Items, *newNode("node1", "node1", "192.168.0.1", "rack1", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node2", "node2", "192.168.0.2", "rack2", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node3", "node3", "192.168.0.3", "rack1", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node4", "node4", "192.168.0.4", "rack2", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node5", "node5", "192.168.0.5", "rack3", "", ""))
+
+	if err := driver.CreateCluster(5, nodes); err != nil {
+		t.Fatalf("Error creating cluster: %v", err)
+	}
+	capacities := [][]uint64{{100, 20}, {100, 80}, {100, 40}, {200, 80}}
+	for i, capacity := range capacities {
+		if err := driver.UpdateNodeCapacity(i, capacity[0], capacity[1]); err != nil {
+			t.Fatalf("Error updating node capacity: %v", err)
+		}
+	}
+
+	pod := newPod("capacityTest", []string{"capacityTest"})
+	if err := driver.ProvisionVolume("capacityTest", []int{0, 1}, 1); err != nil {
+		t.Fatalf("Error provisioning volume: %v", err)
+	}
+
+	prioritizeResponse, err := sendPrioritizeRequest(pod, nodes)
+	if err != nil {
+		t.Fatalf("Error sending prioritize request: %v", err)
+	}
+	verifyPrioritizeResponse(
+		t,
+		nodes,
+		[]int{nodePriorityScore + capacityPriorityScore/4,
+			nodePriorityScore + capacityPriorityScore,
+			rackPriorityScore + capacityPriorityScore/2,
+			rackPriorityScore + capacityPriorityScore,
+			defaultScore},
+		prioritizeResponse)
+
+	// The capacity score should never rank a node above one with a higher
+	// locality score, even if the gap is smaller than the capacity score
+	localityMap := map[string]int{
+		"node1": regionPriorityScore,
+		"node2": defaultScore,
+		"node3": defaultScore,
+		"node4": regionPriorityScore + 1,
+	}
+	capacityMap := map[string]int{
+		"node1": capacityPriorityScore,
+		"node2": capacityPriorityScore,
+		"node3": 2,
+	}
+	limitCapacityScores(localityMap, capacityMap)
+	require.Equal(t, map[string]int{
+		"node1": 0,
+		"node2": regionPriorityScore - defaultScore - 1,
+		"node3": 2,
+	}, capacityMap)
+}
+
+// Create a pod with a PVC using the mock storage class and the
+// anti-hyperconverged scheduling mode.
+// Place the data on nodes n1, n2.
+// The prioritize response should prefer nodes that don't have the data and
+// aren't in the same rack
+func antiHyperconvergedTest(t *testing.T) {
+	nodes := &v1.NodeList{}
+	nodes.Items = append(nodes.Items, *newNode("node1", "node1", "192.168.0.1", "rack1", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node2", "node2", "192.168.0.2", "rack2", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node3", "node3", "192.168.0.3", "rack1", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node4", "node4", "192.168.0.4", "rack3", "", ""))
+
+	if err := driver.CreateCluster(4, nodes); err != nil {
+		t.Fatalf("Error creating cluster: %v", err)
+	}
+	pod := newPod("antiHyperconvergedTest", []string{"antiHyperconvergedTest"})
+	pod.Annotations = map[string]string{SchedulingModeAnnotation: SchedulingModeAntiHyperconverged}
+	if err := driver.ProvisionVolume("antiHyperconvergedTest", []int{0, 1}, 1); err != nil {
+		t.Fatalf("Error provisioning volume: %v", err)
+	}
+
+	prioritizeResponse, err := sendPrioritizeRequest(pod, nodes)
+	if err != nil {
+		t.Fatalf("Error sending prioritize request: %v", err)
+	}
+	verifyPrioritizeResponse(
+		t,
+		nodes,
+		[]int{defaultScore,
+			defaultScore,
+			nodePriorityScore - rackPriorityScore,
+			nodePriorityScore},
+		prioritizeResponse)
+}
+
+// Create a pod with a PVC using the mock storage class and the spread
+// scheduling mode. Another pod from the same app is already running on n1.
+// Place the data on nodes n1, n3.
+// The prioritize response should lower the scores for nodes in the same rack
+// as n1
+func spreadTest(t *testing.T) {
+	nodes := &v1.NodeList{}
+	nodes.Items = append(nodes.Items, *newNode("node1", "node1", "192.168.0.1", "rack1", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node2", "node2", "192.168.0.2", "rack1", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node3", "node3", "192.168.0.3", "rack2", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node4", "node4", "192.168.0.4", "rack2", "", ""))
+
+	if err := driver.CreateCluster(4, nodes); err != nil {
+		t.Fatalf("Error creating cluster: %v", err)
+	}
+	owner := metav1.OwnerReference{
+		APIVersion: "apps/v1",
+		Kind:       "StatefulSet",
+		Name:       "spreadTest",
+		UID:        "spreadTestUID",
+		Controller: &[]bool{true}[0],
+	}
+	runningPod := newPod("spreadTest-0", nil)
+	runningPod.Namespace = defaultNamespace
+	runningPod.OwnerReferences = []metav1.OwnerReference{owner}
+	runningPod.Spec.NodeName = "node1"
+	if _, err := k8s.Instance().CreatePod(runningPod); err != nil {
+		t.Fatalf("Error creating pod: %v", err)
+	}
+	defer func() {
+		if err := k8s.Instance().DeletePods([]v1.Pod{*runningPod}, true); err != nil {
+			t.Fatalf("Error deleting pod: %v", err)
+		}
+	}()
+
+	pod := newPod("spreadTest-1", []string{"spreadTest"})
+	pod.Namespace = defaultNamespace
+	pod.OwnerReferences = []metav1.OwnerReference{owner}
+	pod.Annotations = map[string]string{SchedulingModeAnnotation: SchedulingModeSpread}
+	if err := driver.ProvisionVolume("spreadTest", []int{0, 2}, 1); err != nil {
+		t.Fatalf("Error provisioning volume: %v", err)
+	}
+
+	prioritizeResponse, err := sendPrioritizeRequest(pod, nodes)
+	if err != nil {
+		t.Fatalf("Error sending prioritize request: %v", err)
+	}
+	verifyPrioritizeResponse(
+		t,
+		nodes,
+		[]int{nodePriorityScore / 2,
+			rackPriorityScore / 2,
+			nodePriorityScore,
+			rackPriorityScore},
+		prioritizeResponse)
+}
+
+// Create a pod from a new ReplicaSet of a Deployment in a namespace using the
+// spread scheduling mode. A pod from the old ReplicaSet of the Deployment is
+// already running on n1. Place the data on nodes n1, n3.
+// The prioritize response should lower the scores for nodes in the same rack
+// as n1, and keep using the cached scheduling mode of the namespace
+func spreadDeploymentTest(t *testing.T) {
+	nodes := &v1.NodeList{}
+	nodes.Items = append(nodes.Items, *newNode("node1", "node1", "192.168.0.1", "rack1", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node2", "node2", "192.168.0.2", "rack1", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node3", "node3", "192.168.0.3", "rack2", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node4", "node4", "192.168.0.4", "rack2", "", ""))
+
+	if err := driver.CreateCluster(4, nodes); err != nil {
+		t.Fatalf("Error creating cluster: %v", err)
+	}
+	namespace := "spread-deployment-test"
+	_, err := k8s.Instance().CreateNamespace(namespace, nil)
+	require.NoError(t, err, "Error creating namespace")
+	ns, err := k8s.Instance().GetNamespace(namespace)
+	require.NoError(t, err, "Error getting namespace")
+	ns.Annotations = map[string]string{SchedulingModeAnnotation: SchedulingModeSpread}
+	ns, err = extender.KubeClient.CoreV1().Namespaces().Update(ns)
+	require.NoError(t, err, "Error updating namespace")
+
+	deploymentOwner := metav1.OwnerReference{
+		APIVersion: "apps/v1",
+		Kind:       "Deployment",
+		Name:       "spreadDeploymentTest",
+		UID:        "spreadDeploymentTestUID",
+		Controller: &[]bool{true}[0],
+	}
+	replicaSetOwners := make([]metav1.OwnerReference, 0)
+	for _, name := range []string{"spreadDeploymentTest-1", "spreadDeploymentTest-2"} {
+		replicaSet, err := extender.KubeClient.AppsV1().ReplicaSets(namespace).Create(&appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            name,
+				Namespace:       namespace,
+				UID:             types.UID(name + "UID"),
+				OwnerReferences: []metav1.OwnerReference{deploymentOwner},
+			},
+		})
+		require.NoError(t, err, "Error creating replica set")
+		replicaSetOwners = append(replicaSetOwners, metav1.OwnerReference{
+			APIVersion: "apps/v1",
+			Kind:       "ReplicaSet",
+			Name:       replicaSet.Name,
+			UID:        replicaSet.UID,
+			Controller: &[]bool{true}[0],
+		})
+	}
+
+	runningPod := newPod("spreadDeploymentTest-0", nil)
+	runningPod.Namespace = namespace
+	runningPod.OwnerReferences = []metav1.OwnerReference{replicaSetOwners[0]}
+	runningPod.Spec.NodeName = "node1"
+	if _, err := k8s.Instance().CreatePod(runningPod); err != nil {
+		t.Fatalf("Error creating pod: %v", err)
+	}
+	defer func() {
+		if err := k8s.Instance().DeletePods([]v1.Pod{*runningPod}, true); err != nil {
+			t.Fatalf("Error deleting pod: %v", err)
+		}
+	}()
+
+	pod := newPod("spreadDeploymentTest-1", []string{"spreadDeploymentTest"})
+	pod.Namespace = namespace
+	pod.OwnerReferences = []metav1.OwnerReference{replicaSetOwners[1]}
+	if err := driver.ProvisionVolume("spreadDeploymentTest", []int{0, 2}, 1); err != nil {
+		t.Fatalf("Error provisioning volume: %v", err)
+	}
+
+	prioritizeResponse, err := sendPrioritizeRequest(pod, nodes)
+	if err != nil {
+		t.Fatalf("Error sending prioritize request: %v", err)
+	}
+	verifyPrioritizeResponse(
+		t,
+		nodes,
+		[]int{nodePriorityScore / 2,
+			rackPriorityScore / 2,
+			nodePriorityScore,
+			rackPriorityScore},
+		prioritizeResponse)
+
+	delete(ns.Annotations, SchedulingModeAnnotation)
+	_, err = extender.KubeClient.CoreV1().Namespaces().Update(ns)
+	require.NoError(t, err, "Error updating namespace")
+	prioritizeResponse, err = sendPrioritizeRequest(pod, nodes)
+	if err != nil {
+		t.Fatalf("Error sending prioritize request: %v", err)
+	}
+	verifyPrioritizeResponse(
+		t,
+		nodes,
+		[]int{nodePriorityScore / 2,
+			rackPriorityScore / 2,
+			nodePriorityScore,
+			rackPriorityScore},
+		prioritizeResponse)
+
+	extender.getAPICache().invalidate()
+	prioritizeResponse, err = sendPrioritizeRequest(pod, nodes)
+	if err != nil {
+		t.Fatalf("Error sending prioritize request: %v", err)
+	}
+	verifyPrioritizeResponse(
+		t,
+		nodes,
+		[]int{nodePriorityScore,
+			rackPriorityScore,
+			nodePriorityScore,
+			rackPriorityScore},
+		prioritizeResponse)
+}
+
+// namedDriver is a driver in a partialDriver group
+type namedDriver struct {
+	volume.Driver
+	name string
+}
+
+func (d *namedDriver) String() string {
+	return d.name
+}
+
+// partialDriver is a group of drivers that returns the info from the mock
+// driver and the configured errors for the other drivers
+type partialDriver struct {
+	*mock.Driver
+	drivers []volume.Driver
+	errs    map[string]error
+}
+
+func (d *partialDriver) Drivers() []volume.Driver {
+	return d.drivers
+}
+
+func (d *partialDriver) firstError() error {
+	for _, groupDriver := range d.drivers {
+		if err, present := d.errs[groupDriver.String()]; present {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *partialDriver) GetNodes() ([]*volume.NodeInfo, error) {
+	if err := d.firstError(); err != nil {
+		return nil, err
+	}
+	return d.Driver.GetNodes()
+}
+
+func (d *partialDriver) GetPodVolumes(podSpec *v1.PodSpec, namespace string) ([]*volume.Info, error) {
+	if err := d.firstError(); err != nil {
+		return nil, err
+	}
+	return d.Driver.GetPodVolumes(podSpec, namespace)
+}
+
+func (d *partialDriver) GetAvailableNodes() ([]*volume.NodeInfo, map[string]error) {
+	if _, present := d.errs[d.Driver.String()]; present {
+		return nil, d.errs
+	}
+	nodes, _ := d.Driver.GetNodes()
+	return nodes, d.errs
+}
+
+func (d *partialDriver) GetAvailablePodVolumes(podSpec *v1.PodSpec, namespace string) ([]*volume.Info, map[string]error) {
+	if _, present := d.errs[d.Driver.String()]; present {
+		return nil, d.errs
+	}
+	volumes, _ := d.Driver.GetPodVolumes(podSpec, namespace)
+	return volumes, d.errs
+}
+
+// Check that drivers that can't be reached are skipped only when enabled, and
+// that the info from only some of the drivers isn't cached
+func partialDriversCacheTest(t *testing.T) {
+	nodes := &v1.NodeList{}
+	nodes.Items = append(nodes.Items, *newNode("node1", "node1", "192.168.0.1", "rack1", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node2", "node2", "192.168.0.2", "rack1", "", ""))
+	if err := driver.CreateCluster(2, nodes); err != nil {
+		t.Fatalf("Error creating cluster: %v", err)
+	}
+	pod := newPod("partialDriversCacheTest", []string{"partialDriversCacheTest"})
+	if err := driver.ProvisionVolume("partialDriversCacheTest", []int{0}, 1); err != nil {
+		t.Fatalf("Error provisioning volume: %v", err)
+	}
+	group := &partialDriver{
+		Driver:  driver,
+		drivers: []volume.Driver{driver, &namedDriver{Driver: driver, name: "other"}},
+		errs:    map[string]error{"other": fmt.Errorf("other error")},
+	}
+
+	cache := newDriverCache(group, time.Minute, false, false)
+	_, err := cache.getNodes()
+	require.EqualError(t, err, "other error")
+	_, err = cache.getPodVolumes(pod)
+	require.EqualError(t, err, "other error")
+
+	cache = newDriverCache(group, time.Minute, false, true)
+	cachedNodes, err := cache.getNodes()
+	require.NoError(t, err, "Error getting nodes")
+	require.Len(t, cachedNodes, 2)
+	require.Nil(t, cache.nodes)
+	volumes, err := cache.getPodVolumes(pod)
+	require.NoError(t, err, "Error getting volumes")
+	require.Len(t, volumes, 1)
+	require.Empty(t, cache.volumes)
+
+	// Pods with pending PVCs need to be retried
+	group.errs["other"] = &volume.ErrPVCPending{Name: "partialDriversCacheTest"}
+	_, err = cache.getPodVolumes(pod)
+	require.Error(t, err)
+	_, ok := err.(*volume.ErrPVCPending)
+	require.True(t, ok, "Expected pending PVC error")
+
+	// An error is returned if none of the drivers can be reached
+	group.errs[driver.String()] = fmt.Errorf("mock error")
+	group.errs["other"] = fmt.Errorf("other error")
+	_, err = cache.getNodes()
+	require.EqualError(t, err, "mock error")
+
+	group.errs = map[string]error{}
+	_, err = cache.getNodes()
+	require.NoError(t, err, "Error getting nodes")
+	require.Len(t, cache.nodes, 2)
+	_, err = cache.getPodVolumes(pod)
+	require.NoError(t, err, "Error getting volumes")
+	require.Len(t, cache.volumes, 1)
+}
+
+// Create a cache with the mock driver and check that nodes and volumes are
+// served from the cache until it is invalidated
+func cacheTest(t *testing.T) {
+	nodes := &v1.NodeList{}
+	nodes.Items = append(nodes.Items, *newNode("node1", "node1", "192.168.0.1", "rack1", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node2", "node2", "192.168.0.2", "rack1", "", ""))
+	if err := driver.CreateCluster(2, nodes); err != nil {
+		t.Fatalf("Error creating cluster: %v", err)
+	}
+	pod := newPod("cacheTest", []string{"cacheTest"})
+	if err := driver.ProvisionVolume("cacheTest", []int{0}, 1); err != nil {
+		t.Fatalf("Error provisioning volume: %v", err)
+	}
+
+	cache := newDriverCache(driver, time.Minute, false, false)
+	cachedNodes, err := cache.getNodes()
+	require.NoError(t, err, "Error getting nodes")
+	require.Equal(t, volume.NodeOnline, cachedNodes[1].Status)
+	// Updates by callers shouldn't change the cache
+	cachedNodes[0].Hostname = "updated"
+
+	volumes, err := cache.getPodVolumes(pod)
+	require.NoError(t, err, "Error getting volumes")
+	require.Len(t, volumes, 1)
+
+	err = driver.UpdateNodeStatus(1, volume.NodeOffline)
+	require.NoError(t, err, "Error updating node status")
+	driver.SetInterfaceError(fmt.Errorf("driver error"))
+
+	cachedNodes, err = cache.getNodes()
+	require.NoError(t, err, "Error getting nodes from cache")
+	require.Equal(t, "node1", cachedNodes[0].Hostname)
+	require.Equal(t, volume.NodeOnline, cachedNodes[1].Status)
+	volumes, err = cache.getPodVolumes(pod)
+	require.NoError(t, err, "Error getting volumes from cache")
+	require.Len(t, volumes, 1)
+
+	cache.invalidate()
+	_, err = cache.getNodes()
+	require.Error(t, err, "Expected error from driver after invalidating cache")
+	_, err = cache.getPodVolumes(pod)
+	require.Error(t, err, "Expected error from driver after invalidating cache")
+
+	driver.SetInterfaceError(nil)
+	cachedNodes, err = cache.getNodes()
+	require.NoError(t, err, "Error getting nodes")
+	require.Equal(t, volume.NodeOffline, cachedNodes[1].Status)
+
+	// Volumes that haven't been fetched within the retention are pruned
+	cache.volumes["default/deletedPVC"] = &volumeCacheEntry{updated: time.Now().Add(-2 * volumesRetention)}
+	cache.lastPrune = time.Time{}
+	_, err = cache.getPodVolumes(pod)
+	require.NoError(t, err, "Error getting volumes")
+	require.NotContains(t, cache.volumes, "default/deletedPVC")
+	require.Len(t, cache.volumes, 1)
+}
+
+// Configure the scores using a config map with an invalid value for the zone
+// score. Place the data on node n1.
+// The prioritize response should use the scores from the config map and the
+// default score for the zone
+func scoresConfigMapTest(t *testing.T) {
+	nodes := &v1.NodeList{}
+	nodes.Items = append(nodes.Items, *newNode("node1", "node1", "192.168.0.1", "rack1", "a", "us-east-1"))
+	nodes.Items = append(nodes.Items, *newNode("node2", "node2", "192.168.0.2", "rack1", "a", "us-east-1"))
+	nodes.Items = append(nodes.Items, *newNode("node3", "node3", "192.168.0.3", "rack2", "a", "us-east-1"))
+	nodes.Items = append(nodes.Items, *newNode("node4", "node4", "192.168.0.4", "rack3", "b", "us-east-1"))
+	nodes.Items = append(nodes.Items, *newNode("node5", "node5", "192.168.0.5", "rack4", "c", "us-west-1"))
+	if err := driver.CreateCluster(5, nodes); err != nil {
+		t.Fatalf("Error creating cluster: %v", err)
+	}
+
+	_, err := k8s.Instance().CreateConfigMap(&v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stork-scores",
+			Namespace: defaultNamespace,
+		},
+		Data: map[string]string{
+			NodeScoreKey:    "200",
+			RackScoreKey:    "30",
+			ZoneScoreKey:    "invalid",
+			RegionScoreKey:  "20",
+			DefaultScoreKey: "1",
+		},
+	})
+	require.NoError(t, err, "Error creating config map")
+	extender.ScoresConfigMapName = "stork-scores"
+	extender.ScoresConfigMapNamespace = defaultNamespace
+	extender.loadScores()
+	defer func() {
+		extender.ScoresConfigMapName = ""
+		extender.scores = nil
+	}()
+
+	pod := newPod("scoresConfigMapTest", []string{"scoresConfigMapTest"})
+	if err := driver.ProvisionVolume("scoresConfigMapTest", []int{0}, 1); err != nil {
+		t.Fatalf("Error provisioning volume: %v", err)
+	}
+
+	prioritizeResponse, err := sendPrioritizeRequest(pod, nodes)
+	if err != nil {
+		t.Fatalf("Error sending prioritize request: %v", err)
+	}
+	verifyPrioritizeResponse(
+		t,
+		nodes,
+		[]int{200, 30, zonePriorityScore, 20, 1},
+		prioritizeResponse)
+
+	// Scores that aren't in decreasing order should be ignored
+	configMap, err := k8s.Instance().GetConfigMap("stork-scores", defaultNamespace)
+	require.NoError(t, err, "Error getting config map")
+	configMap.Data[RackScoreKey] = "300"
+	_, err = k8s.Instance().UpdateConfigMap(configMap)
+	require.NoError(t, err, "Error updating config map")
+	extender.loadScores()
+	require.Equal(t, defaultNodeScores, extender.getScores())
+}
+
+// Create a virt-launcher pod for a VM running on n1 and a target pod for the
+// live migration of the VM. Place the data on nodes n1, n2.
+// The filter response should not include n1 since the VM is already running
+// there
+func kubevirtMigrationTest(t *testing.T) {
+	nodes := &v1.NodeList{}
+	nodes.Items = append(nodes.Items, *newNode("node1", "node1", "192.168.0.1", "rack1", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node2", "node2", "192.168.0.2", "rack1", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node3", "node3", "192.168.0.3", "rack2", "", ""))
+
+	if err := driver.CreateCluster(3, nodes); err != nil {
+		t.Fatalf("Error creating cluster: %v", err)
+	}
+	labels := map[string]string{
+		kubevirtAppLabel:       virtLauncherApp,
+		kubevirtCreatedByLabel: "kubevirtTestUID",
+	}
+	sourcePod := newPod("virt-launcher-vm-source", []string{"kubevirtTest"})
+	sourcePod.Namespace = defaultNamespace
+	sourcePod.Labels = labels
+	sourcePod.Spec.NodeName = "node1"
+	if _, err := k8s.Instance().CreatePod(sourcePod); err != nil {
+		t.Fatalf("Error creating pod: %v", err)
+	}
+	defer func() {
+		if err := k8s.Instance().DeletePods([]v1.Pod{*sourcePod}, true); err != nil {
+			t.Fatalf("Error deleting pod: %v", err)
+		}
+	}()
+
+	pod := newPod("virt-launcher-vm-target", []string{"kubevirtTest"})
+	pod.Namespace = defaultNamespace
+	pod.Labels = map[string]string{
+		kubevirtAppLabel:          virtLauncherApp,
+		kubevirtCreatedByLabel:    "kubevirtTestUID",
+		kubevirtMigrationJobLabel: "kubevirtTestMigrationUID",
+	}
+	if err := driver.ProvisionVolume("kubevirtTest", []int{0, 1}, 1); err != nil {
+		t.Fatalf("Error provisioning volume: %v", err)
+	}
+
+	filterResponse, err := sendFilterRequest(pod, nodes)
+	if err != nil {
+		t.Fatalf("Error sending filter request: %v", err)
+	}
+	verifyFilterResponse(t, nodes, []int{1, 2}, filterResponse)
+
+	// Without the migration label all nodes should be returned
+	delete(pod.Labels, kubevirtMigrationJobLabel)
+	filterResponse, err = sendFilterRequest(pod, nodes)
+	if err != nil {
+		t.Fatalf("Error sending filter request: %v", err)
+	}
+	verifyFilterResponse(t, nodes, []int{0, 1, 2}, filterResponse)
+}
+
+// Create a pod with a shared PVC using the mock storage class.
+// Place the data on nodes n1, n2 and serve the volume from n4.
+// The prioritize response should score nodes based on their locality to n4
+// instead of the replicas
+func sharedVolumeTest(t *testing.T) {
+	nodes := &v1.NodeList{}
+	nodes.Items = append(nodes.Items, *newNode("node1", "node1", "192.168.0.1", "rack1", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node2", "node2", "192.168.0.2", "rack1", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node3", "node3", "192.168.0.3", "rack2", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node4", "node4", "192.168.0.4", "rack2", "", ""))
+
+	if err := driver.CreateCluster(4, nodes); err != nil {
+		t.Fatalf("Error creating cluster: %v", err)
+	}
+	pod := newPod("sharedVolumeTest", []string{"sharedVolumeTest"})
+	if err := driver.ProvisionVolume("sharedVolumeTest", []int{0, 1}, 1); err != nil {
+		t.Fatalf("Error provisioning volume: %v", err)
+	}
+	if err := driver.SetSharedVolumeCoordinator("sharedVolumeTest", 3); err != nil {
+		t.Fatalf("Error setting coordinator for volume: %v", err)
+	}
+
+	prioritizeResponse, err := sendPrioritizeRequest(pod, nodes)
+	if err != nil {
+		t.Fatalf("Error sending prioritize request: %v", err)
+	}
+	verifyPrioritizeResponse(
+		t,
+		nodes,
+		[]int{defaultScore,
+			defaultScore,
+			rackPriorityScore,
+			nodePriorityScore},
+		prioritizeResponse)
+}
+
+func sendGetRequest(path string) (int, string, error) {
+	resp, err := http.Get("http://localhost:8099/" + path)
+	if err != nil {
+		return 0, "", err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			logrus.Warnf("Error closing body: %v", err)
+		}
+	}()
+	contents, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return 0, "", err
+	}
+	return resp.StatusCode, string(contents), nil
+}
+
+// Check the health and readiness of the extender when the driver is
+// reachable and when it returns errors, and check that the request metrics
+// are exported
+func healthTest(t *testing.T) {
+	nodes := &v1.NodeList{}
+	nodes.Items = append(nodes.Items, *newNode("node1", "node1", "192.168.0.1", "rack1", "", ""))
+	if err := driver.CreateCluster(1, nodes); err != nil {
+		t.Fatalf("Error creating cluster: %v", err)
+	}
+
+	status, _, err := sendGetRequest("healthz")
+	require.NoError(t, err, "Error sending health request")
+	require.Equal(t, http.StatusOK, status)
+
+	status, _, err = sendGetRequest("readyz")
+	require.NoError(t, err, "Error sending ready request")
+	require.Equal(t, http.StatusOK, status)
+
+	driver.SetInterfaceError(fmt.Errorf("driver error"))
+	status, _, err = sendGetRequest("readyz")
+	require.NoError(t, err, "Error sending ready request")
+	require.Equal(t, http.StatusServiceUnavailable, status)
+
+	status, _, err = sendGetRequest("healthz")
+	require.NoError(t, err, "Error sending health request")
+	require.Equal(t, http.StatusOK, status)
+	driver.SetInterfaceError(nil)
+
+	status, contents, err := sendGetRequest("metrics")
+	require.NoError(t, err, "Error sending metrics request")
+	require.Equal(t, http.StatusOK, status)
+	require.Contains(t, contents, "stork_extender_request_duration_seconds")
+	require.Contains(t, contents, "stork_extender_request_errors_total")
+	require.Contains(t, contents, "stork_extender_driver_call_duration_seconds")
+}
+
+// Create a cluster where the driver is only running on n1 and n2 and check
+// the nodes returned by the filter for each degradation policy when the driver
+// returns errors
+func degradationPolicyTest(t *testing.T) {
+	nodes := &v1.NodeList{}
+	nodes.Items = append(nodes.Items, *newNode("node1", "node1", "192.168.0.1", "rack1", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node2", "node2", "192.168.0.2", "rack1", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node3", "node3", "192.168.0.3", "rack2", "", ""))
+	if err := driver.CreateCluster(2, nodes); err != nil {
+		t.Fatalf("Error creating cluster: %v", err)
+	}
+	pod := newPod("degradationPolicyTest", []string{"degradationPolicyTest"})
+	if err := driver.ProvisionVolume("degradationPolicyTest", []int{0}, 1); err != nil {
+		t.Fatalf("Error provisioning volume: %v", err)
+	}
+	defer driver.SetInterfaceError(nil)
+
+	invalidExtender := &Extender{DegradationPolicy: "invalid"}
+	require.Error(t, invalidExtender.validateDegradationPolicy(), "Expected error for invalid policy")
+
+	failOpenExtender := &Extender{Driver: driver, Recorder: extender.Recorder}
+	failClosedExtender := &Extender{
+		Driver:            driver,
+		Recorder:          extender.Recorder,
+		DegradationPolicy: DegradationPolicyFailClosed,
+	}
+	cachedExtender := &Extender{
+		Driver:            driver,
+		Recorder:          extender.Recorder,
+		DegradationPolicy: DegradationPolicyCachedData,
+	}
+
+	filteredNodes, err := cachedExtender.Filter(pod, nodes.Items)
+	require.NoError(t, err, "Error filtering nodes")
+	require.Len(t, filteredNodes, 2)
+
+	driver.SetInterfaceError(fmt.Errorf("driver error"))
+	filteredNodes, err = failOpenExtender.Filter(pod, nodes.Items)
+	require.NoError(t, err, "Error filtering nodes")
+	require.Len(t, filteredNodes, 3)
+
+	_, err = failClosedExtender.Filter(pod, nodes.Items)
+	require.Error(t, err, "Expected error when driver can't be reached")
+
+	filteredNodes, err = cachedExtender.Filter(pod, nodes.Items)
+	require.NoError(t, err, "Error filtering nodes")
+	require.Len(t, filteredNodes, 2)
+	require.Equal(t, "node1", filteredNodes[0].Name)
+	require.Equal(t, "node2", filteredNodes[1].Name)
+}
+
+func updateStorageClass(t *testing.T, storageClass *storagev1.StorageClass) *storagev1.StorageClass {
+	err := k8s.Instance().DeleteStorageClass(storageClass.Name)
+	require.NoError(t, err, "Error deleting storage class")
+	storageClass.ResourceVersion = ""
+	storageClass, err = k8s.Instance().CreateStorageClass(storageClass)
+	require.NoError(t, err, "Error creating storage class")
+	extender.getAPICache().invalidate()
+	return storageClass
+}
+
+// Create a pod with a PVC from a storage class that is ignored by the
+// extender. Place the data on n1.
+// The filter response should include all nodes and the prioritize response
+// should use the default scores until the storage class is enabled
+func storageClassTest(t *testing.T) {
+	nodes := &v1.NodeList{}
+	nodes.Items = append(nodes.Items, *newNode("node1", "node1", "192.168.0.1", "rack1", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node2", "node2", "192.168.0.2", "rack2", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node3", "node3", "192.168.0.3", "rack2", "", ""))
+	if err := driver.CreateCluster(2, nodes); err != nil {
+		t.Fatalf("Error creating cluster: %v", err)
+	}
+
+	storageClass, err := k8s.Instance().CreateStorageClass(&storagev1.StorageClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "storageClassTest",
+			Annotations: map[string]string{StorageClassEnabledAnnotation: "false"},
+		},
+	})
+	require.NoError(t, err, "Error creating storage class")
+	storageClassName := storageClass.Name
+	_, err = k8s.Instance().CreatePersistentVolumeClaim(&v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "storageClassTest",
+			Namespace: defaultNamespace,
+		},
+		Spec: v1.PersistentVolumeClaimSpec{
+			StorageClassName: &storageClassName,
+		},
+	})
+	require.NoError(t, err, "Error creating PVC")
+
+	pod := newPod("storageClassTest", []string{"storageClassTest"})
+	pod.Namespace = defaultNamespace
+	if err := driver.ProvisionVolume("storageClassTest", []int{0}, 1); err != nil {
+		t.Fatalf("Error provisioning volume: %v", err)
+	}
+
+	filterResponse, err := sendFilterRequest(pod, nodes)
+	if err != nil {
+		t.Fatalf("Error sending filter request: %v", err)
+	}
+	verifyFilterResponse(t, nodes, []int{0, 1, 2}, filterResponse)
+	prioritizeResponse, err := sendPrioritizeRequest(pod, nodes)
+	if err != nil {
+		t.Fatalf("Error sending prioritize request: %v", err)
+	}
+	verifyPrioritizeResponse(t, nodes, []int{defaultScore, defaultScore, defaultScore}, prioritizeResponse)
+
+	// Storage classes without the annotation should only be ignored in
+	// opt-in mode
+	delete(storageClass.Annotations, StorageClassEnabledAnnotation)
+	storageClass = updateStorageClass(t, storageClass)
+	filterResponse, err = sendFilterRequest(pod, nodes)
+	if err != nil {
+		t.Fatalf("Error sending filter request: %v", err)
+	}
+	verifyFilterResponse(t, nodes, []int{0, 1}, filterResponse)
+
+	extender.StorageClassOptIn = true
+	defer func() {
+		extender.StorageClassOptIn = false
+	}()
+	filterResponse, err = sendFilterRequest(pod, nodes)
+	if err != nil {
+		t.Fatalf("Error sending filter request: %v", err)
+	}
+	verifyFilterResponse(t, nodes, []int{0, 1, 2}, filterResponse)
+
+	storageClass.Annotations = map[string]string{StorageClassEnabledAnnotation: "true"}
+	updateStorageClass(t, storageClass)
+	prioritizeResponse, err = sendPrioritizeRequest(pod, nodes)
+	if err != nil {
+		t.Fatalf("Error sending prioritize request: %v", err)
+	}
+	verifyPrioritizeResponse(t, nodes, []int{nodePriorityScore, defaultScore, defaultScore}, prioritizeResponse)
+
+	// The storage class should be served from the cache until it expires
+	storageClass.Annotations = map[string]string{StorageClassEnabledAnnotation: "false"}
+	err = k8s.Instance().DeleteStorageClass(storageClass.Name)
+	require.NoError(t, err, "Error deleting storage class")
+	storageClass.ResourceVersion = ""
+	_, err = k8s.Instance().CreateStorageClass(storageClass)
+	require.NoError(t, err, "Error creating storage class")
+	prioritizeResponse, err = sendPrioritizeRequest(pod, nodes)
+	if err != nil {
+		t.Fatalf("Error sending prioritize request: %v", err)
+	}
+	verifyPrioritizeResponse(t, nodes, []int{nodePriorityScore, defaultScore, defaultScore}, prioritizeResponse)
+
+	cache := extender.getAPICache()
+	cache.lock.Lock()
+	for _, entry := range cache.enabledClasses {
+		entry.expiry = time.Now()
+	}
+	cache.lock.Unlock()
+	prioritizeResponse, err = sendPrioritizeRequest(pod, nodes)
+	if err != nil {
+		t.Fatalf("Error sending prioritize request: %v", err)
+	}
+	verifyPrioritizeResponse(t, nodes, []int{defaultScore, defaultScore, defaultScore}, prioritizeResponse)
+}
+
+// Create a pod without any volumes that has affinity to a PVC with data on n2.
+// The filter response should include all nodes and the prioritize response
+// should prefer n2
+func volumeAffinityTest(t *testing.T) {
+	nodes := &v1.NodeList{}
+	nodes.Items = append(nodes.Items, *newNode("node1", "node1", "192.168.0.1", "rack1", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node2", "node2", "192.168.0.2", "rack2", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node3", "node3", "192.168.0.3", "rack2", "", ""))
+	if err := driver.CreateCluster(3, nodes); err != nil {
+		t.Fatalf("Error creating cluster: %v", err)
+	}
+
+	pvc := driver.NewPVC("volumeAffinityTest")
+	pvc.Namespace = defaultNamespace
+	pvc.Status.Phase = v1.ClaimBound
+	_, err := k8s.Instance().CreatePersistentVolumeClaim(pvc)
+	require.NoError(t, err, "Error creating PVC")
+	if err := driver.ProvisionVolume("volumeAffinityTest", []int{1}, 1); err != nil {
+		t.Fatalf("Error provisioning volume: %v", err)
+	}
+
+	pod := newPod("volumeAffinityTest", nil)
+	pod.Namespace = defaultNamespace
+	pod.Annotations = map[string]string{VolumeAffinityAnnotation: "volumeAffinityTest, missingPVC"}
+
+	filterResponse, err := sendFilterRequest(pod, nodes)
+	if err != nil {
+		t.Fatalf("Error sending filter request: %v", err)
+	}
+	verifyFilterResponse(t, nodes, []int{0, 1, 2}, filterResponse)
+	prioritizeResponse, err := sendPrioritizeRequest(pod, nodes)
+	if err != nil {
+		t.Fatalf("Error sending prioritize request: %v", err)
+	}
+	verifyPrioritizeResponse(t, nodes, []int{defaultScore, nodePriorityScore, rackPriorityScore}, prioritizeResponse)
+}
+
+func sendSimulateRequest(
+	pod *v1.Pod,
+	nodeList *v1.NodeList,
+) (*SimulationResult, error) {
+	resp, err := sendRequest("simulate", pod, nodeList)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			logrus.Warnf("Error closing decoder: %v", err)
+		}
+	}()
+	if resp.StatusCode != http.StatusOK {
+		contents, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		return nil, errors.New(strings.TrimSpace(string(contents)))
+	}
+
+	decoder := json.NewDecoder(resp.Body)
+	var result SimulationResult
+	if err := decoder.Decode(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Create a cluster where the driver is only running on n1 and n2 and place
+// the data for a volume on n1. The simulation should return n1 and n2 with
+// their scores, using all the nodes in the cluster if no nodes are specified
+func simulateTest(t *testing.T) {
+	nodes := &v1.NodeList{}
+	nodes.Items = append(nodes.Items, *newNode("node1", "node1", "192.168.0.1", "rack1", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node2", "node2", "192.168.0.2", "rack2", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node3", "node3", "192.168.0.3", "rack2", "", ""))
+	if err := driver.CreateCluster(2, nodes); err != nil {
+		t.Fatalf("Error creating cluster: %v", err)
+	}
+	pod := newPod("simulateTest", []string{"simulateTest"})
+	if err := driver.ProvisionVolume("simulateTest", []int{0}, 1); err != nil {
+		t.Fatalf("Error provisioning volume: %v", err)
+	}
+
+	result, err := sendSimulateRequest(pod, nodes)
+	require.NoError(t, err, "Error sending simulate request")
+	require.Empty(t, result.FilterError)
+	require.Equal(t, []string{"node1", "node2"}, result.FilteredNodes)
+	require.Equal(t, schedulerapi.HostPriorityList{
+		{Host: "node1", Score: nodePriorityScore},
+		{Host: "node2", Score: defaultScore},
+	}, result.Scores)
+
+	for _, node := range nodes.Items {
+		_, err := k8s.Instance().CreateNode(node.DeepCopy())
+		require.NoError(t, err, "Error creating node")
+	}
+	result, err = sendSimulateRequest(pod, nil)
+	require.NoError(t, err, "Error sending simulate request")
+	require.Equal(t, []string{"node1", "node2"}, result.FilteredNodes)
+
+	// Simulating requests shouldn't record events or invalidate the cache
+	recorder := record.NewFakeRecorder(10)
+	cachedExtender := &Extender{Driver: driver, Recorder: recorder, CacheTTL: time.Minute}
+	err = driver.UpdateNodeStatus(0, volume.NodeOffline)
+	require.NoError(t, err, "Error setting node status to Offline")
+	result, err = cachedExtender.Simulate(pod, nodes.Items)
+	require.NoError(t, err, "Error simulating request")
+	require.NotEmpty(t, result.FilterError)
+	require.Len(t, recorder.Events, 0)
+
+	err = driver.UpdateNodeStatus(0, volume.NodeOnline)
+	require.NoError(t, err, "Error setting node status to Online")
+	result, err = cachedExtender.Simulate(pod, nodes.Items)
+	require.NoError(t, err, "Error simulating request")
+	require.NotEmpty(t, result.FilterError)
+	require.Len(t, recorder.Events, 0)
+
+	_, err = cachedExtender.Filter(pod, nodes.Items)
+	require.Error(t, err, "Expected error with cached offline node")
+	require.Len(t, recorder.Events, 1)
+	result, err = cachedExtender.Simulate(pod, nodes.Items)
+	require.NoError(t, err, "Error simulating request")
+	require.Empty(t, result.FilterError)
+	require.Equal(t, []string{"node1", "node2"}, result.FilteredNodes)
+
+	driver.SetInterfaceError(fmt.Errorf("driver error"))
+	defer driver.SetInterfaceError(nil)
+	extender.DegradationPolicy = DegradationPolicyFailClosed
+	defer func() {
+		extender.DegradationPolicy = ""
+	}()
+	result, err = sendSimulateRequest(pod, nodes)
+	require.NoError(t, err, "Error sending simulate request")
+	require.NotEmpty(t, result.FilterError)
+	require.Empty(t, result.FilteredNodes)
+	require.Empty(t, result.Scores)
+}
+
+// Create a cluster where n2 has been labeled by the health monitor as having
+// the storage offline. Place the data on n1, n2.
+// The filter response should not include n2, even if the driver can't be
+// reached
+func storageOfflineLabelTest(t *testing.T) {
+	nodes := &v1.NodeList{}
+	nodes.Items = append(nodes.Items, *newNode("node1", "node1", "192.168.0.1", "rack1", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node2", "node2", "192.168.0.2", "rack1", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node3", "node3", "192.168.0.3", "rack2", "", ""))
+	if err := driver.CreateCluster(3, nodes); err != nil {
+		t.Fatalf("Error creating cluster: %v", err)
+	}
+	nodes.Items[1].Labels[monitor.StorageStatusLabel] = string(volume.NodeOffline)
+
+	pod := newPod("storageOfflineLabelTest", []string{"storageOfflineLabelTest"})
+	if err := driver.ProvisionVolume("storageOfflineLabelTest", []int{0, 1}, 1); err != nil {
+		t.Fatalf("Error provisioning volume: %v", err)
+	}
+	filterResponse, err := sendFilterRequest(pod, nodes)
+	if err != nil {
+		t.Fatalf("Error sending filter request: %v", err)
+	}
+	verifyFilterResponse(t, nodes, []int{0, 2}, filterResponse)
+
+	driver.SetInterfaceError(fmt.Errorf("driver error"))
+	defer driver.SetInterfaceError(nil)
+	filterResponse, err = sendFilterRequest(pod, nodes)
+	if err != nil {
+		t.Fatalf("Error sending filter request: %v", err)
+	}
+	verifyFilterResponse(t, nodes, []int{0, 2}, filterResponse)
+
+	// Pods without volumes can still be scheduled on the node
+	filterResponse, err = sendFilterRequest(newPod("storageOfflineLabelTestNoPVC", nil), nodes)
+	if err != nil {
+		t.Fatalf("Error sending filter request: %v", err)
+	}
+	verifyFilterResponse(t, nodes, []int{0, 1, 2}, filterResponse)
+}
+
+// Place the data for a volume on n1 and n2 and attach it on n2. The volume
+// placement should return the replica and attached nodes and whether the
+// pods using the volume are running on the replica nodes
+func volumesTest(t *testing.T) {
+	nodes := &v1.NodeList{}
+	nodes.Items = append(nodes.Items, *newNode("node1", "node1", "192.168.0.1", "rack1", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node2", "node2", "192.168.0.2", "rack1", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node3", "node3", "192.168.0.3", "rack2", "", ""))
+	if err := driver.CreateCluster(3, nodes); err != nil {
+		t.Fatalf("Error creating cluster: %v", err)
+	}
+	for _, node := range nodes.Items {
+		_, err := k8s.Instance().CreateNode(node.DeepCopy())
+		if err != nil && !k8serrors.IsAlreadyExists(err) {
+			require.NoError(t, err, "Error creating node")
+		}
+	}
+
+	namespace := "volumesTest"
+	driver.NewPVC("volumesTest")
+	require.NoError(t, driver.ProvisionVolume("volumesTest", []int{0, 1}, 1), "Error provisioning volume")
+	require.NoError(t, driver.AttachVolume("volumesTest", 1), "Error attaching volume")
+	// PVCs from other drivers don't have volumes in the driver
+	otherStorageClass := "other"
+	driver.NewPVC("otherVolume").Spec.StorageClassName = &otherStorageClass
+	for _, name := range []string{"volumesTest", "otherVolume"} {
+		_, err := k8s.Instance().CreatePersistentVolumeClaim(&v1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		})
+		require.NoError(t, err, "Error creating PVC")
+	}
+	for i, nodeName := range []string{"node2", "node3"} {
+		pod := newPod(fmt.Sprintf("volumesTest%v", i), []string{"volumesTest"})
+		pod.Namespace = namespace
+		pod.Spec.NodeName = nodeName
+		_, err := k8s.Instance().CreatePod(pod)
+		require.NoError(t, err, "Error creating pod")
+	}
+
+	status, contents, err := sendGetRequest("volumes?namespace=" + namespace)
+	require.NoError(t, err, "Error sending volumes request")
+	require.Equal(t, http.StatusOK, status, contents)
+	var placements []*VolumePlacement
+	require.NoError(t, json.Unmarshal([]byte(contents), &placements), "Error decoding volumes response")
+	require.Equal(t, []*VolumePlacement{
+		{
+			PVC:          "otherVolume",
+			Namespace:    namespace,
+			ReplicaNodes: []string{},
+			Pods:         []PodPlacement{},
+		},
+		{
+			PVC:          "volumesTest",
+			Namespace:    namespace,
+			Driver:       mockDriverName,
+			VolumeID:     "volumesTest",
+			ReplicaNodes: []string{"node1", "node2"},
+			AttachedNode: "node2",
+			Pods: []PodPlacement{
+				{Name: "volumesTest0", Node: "node2", Hyperconverged: true},
+				{Name: "volumesTest1", Node: "node3", Hyperconverged: false},
+			},
+		},
+	}, placements)
+
+	driver.SetInterfaceError(fmt.Errorf("driver error"))
+	defer driver.SetInterfaceError(nil)
+	status, _, err = sendGetRequest("volumes?namespace=" + namespace)
+	require.NoError(t, err, "Error sending volumes request")
+	require.Equal(t, http.StatusInternalServerError, status)
+}
@@ -0,0 +1,82 @@
+package extender
+
+import (
+	"errors"
+
+	storklog "github.com/libopenstorage/stork/pkg/log"
+	"github.com/portworx/sched-ops/k8s"
+	"k8s.io/api/core/v1"
+)
+
+const (
+	// kubevirtAppLabel Label set by KubeVirt on all its pods
+	kubevirtAppLabel = "kubevirt.io"
+	// virtLauncherApp Value of the KubeVirt app label for the pods running
+	// VMs
+	virtLauncherApp = "virt-launcher"
+	// kubevirtCreatedByLabel Label set on virt-launcher pods with the UID of
+	// the VM instance
+	kubevirtCreatedByLabel = "kubevirt.io/created-by"
+	// kubevirtMigrationJobLabel Label set on virt-launcher pods that are the
+	// target of a live migration
+	kubevirtMigrationJobLabel = "kubevirt.io/migrationJobUID"
+)
+
+// isVirtLauncherPod returns true if the pod runs a KubeVirt VM. The volumes
+// for the VM's DataVolumes are mounted as PVCs in the virt-launcher pod, so
+// they are scored like any other pod's volumes
+func isVirtLauncherPod(pod *v1.Pod) bool {
+	return pod.Labels[kubevirtAppLabel] == virtLauncherApp
+}
+
+// isLiveMigrationTarget returns true if the pod was created as the target
+// for the live migration of a VM
+func isLiveMigrationTarget(pod *v1.Pod) bool {
+	if !isVirtLauncherPod(pod) {
+		return false
+	}
+	_, ok := pod.Labels[kubevirtMigrationJobLabel]
+	return ok
+}
+
+// filterLiveMigrationSource removes the nodes that are already running the VM
+// from the list of nodes for the target pod of a live migration, since a VM
+// can't be migrated to the node it is running on
+func (e *Extender) filterLiveMigrationSource(pod *v1.Pod, nodes []v1.Node, dryRun bool) ([]v1.Node, error) {
+	if !isLiveMigrationTarget(pod) {
+		return nodes, nil
+	}
+	vmiUID := pod.Labels[kubevirtCreatedByLabel]
+	if vmiUID == "" {
+		return nodes, nil
+	}
+	vmPods, err := k8s.Instance().GetPods(pod.Namespace, map[string]string{kubevirtCreatedByLabel: vmiUID})
+	if err != nil {
+		storklog.PodLog(pod).Warnf("Error getting pods for VM: %v", err)
+		return nodes, nil
+	}
+
+	sourceNodes := make(map[string]bool)
+	for _, vmPod := range vmPods.Items {
+		if vmPod.Name != pod.Name && vmPod.Spec.NodeName != "" {
+			sourceNodes[vmPod.Spec.NodeName] = true
+		}
+	}
+	if len(sourceNodes) == 0 {
+		return nodes, nil
+	}
+
+	filteredNodes := make([]v1.Node, 0)
+	for _, node := range nodes {
+		if !sourceNodes[node.Name] {
+			filteredNodes = append(filteredNodes, node)
+		}
+	}
+	storklog.PodLog(pod).Debugf("Removed nodes running the VM for live migration: %v", sourceNodes)
+	if len(filteredNodes) == 0 {
+		msg := "No node found to live migrate VM to"
+		e.recordEvent(pod, schedulingFailureEventReason, msg, dryRun)
+		return nil, errors.New(msg)
+	}
+	return filteredNodes, nil
+}
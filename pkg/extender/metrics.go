@@ -0,0 +1,61 @@
+package extender
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	driverCallGetNodes      = "GetNodes"
+	driverCallGetPodVolumes = "GetPodVolumes"
+)
+
+var (
+	requestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "stork_extender_request_duration_seconds",
+			Help:    "Time taken to process filter and prioritize requests from the scheduler",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"type"},
+	)
+	requestErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "stork_extender_request_errors_total",
+			Help: "Number of filter and prioritize requests from the scheduler that returned an error",
+		},
+		[]string{"type"},
+	)
+	degradedRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "stork_extender_degraded_requests_total",
+			Help: "Number of requests from the scheduler that were processed while the storage driver couldn't be reached",
+		},
+		[]string{"policy"},
+	)
+	driverCallDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "stork_extender_driver_call_duration_seconds",
+			Help:    "Time taken by calls to the storage driver from the extender",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"call"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(requestDuration, requestErrors, degradedRequests, driverCallDuration)
+}
+
+// observeDriverCall records the time taken by a driver call that was started
+// at the given time
+func observeDriverCall(call string, start time.Time) {
+	driverCallDuration.WithLabelValues(call).Observe(time.Since(start).Seconds())
+}
+
+// observeRequest records the time taken by a request of the given type that
+// was started at the given time
+func observeRequest(requestType string, start time.Time) {
+	requestDuration.WithLabelValues(requestType).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,136 @@
+package extender
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/portworx/sched-ops/k8s"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/errors"
+)
+
+const (
+	// NodeScoreKey Key in the scores config map for the score of nodes that
+	// have data for a volume
+	NodeScoreKey = "node"
+	// RackScoreKey Key in the scores config map for the score of nodes in the
+	// same rack as a node that has data for a volume
+	RackScoreKey = "rack"
+	// ZoneScoreKey Key in the scores config map for the score of nodes in the
+	// same zone as a node that has data for a volume
+	ZoneScoreKey = "zone"
+	// RegionScoreKey Key in the scores config map for the score of nodes in
+	// the same region as a node that has data for a volume
+	RegionScoreKey = "region"
+	// DefaultScoreKey Key in the scores config map for the score of nodes
+	// that don't have data for any volume
+	DefaultScoreKey = "default"
+	// CapacityScoreKey Key in the scores config map for the maximum score
+	// based on free capacity
+	CapacityScoreKey = "capacity"
+
+	scoresRefreshInterval = 1 * time.Minute
+)
+
+// nodeScores are the scores used to prioritize nodes for each level of
+// locality
+type nodeScores struct {
+	node     int
+	rack     int
+	zone     int
+	region   int
+	def      int
+	capacity int
+}
+
+var defaultNodeScores = nodeScores{
+	node:     nodePriorityScore,
+	rack:     rackPriorityScore,
+	zone:     zonePriorityScore,
+	region:   regionPriorityScore,
+	def:      defaultScore,
+	capacity: capacityPriorityScore,
+}
+
+// isOrdered returns true if the scores decrease with the locality of the
+// node, which is required for nodes closer to the data to be preferred and for
+// the anti-hyperconverged scores to not be negative
+func (s nodeScores) isOrdered() bool {
+	return s.node >= s.rack && s.rack >= s.zone && s.zone >= s.region && s.region >= s.def
+}
+
+// getScores returns the scores that are currently in use
+func (e *Extender) getScores() nodeScores {
+	e.scoresLock.RLock()
+	defer e.scoresLock.RUnlock()
+	if e.scores == nil {
+		return defaultNodeScores
+	}
+	return *e.scores
+}
+
+// startScoresRefresh loads the scores from the config map and keeps
+// refreshing them till the stop channel is closed
+func (e *Extender) startScoresRefresh(stopChannel chan struct{}) {
+	if e.ScoresConfigMapName == "" {
+		return
+	}
+	e.loadScores()
+	go func() {
+		ticker := time.NewTicker(scoresRefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				e.loadScores()
+			case <-stopChannel:
+				return
+			}
+		}
+	}()
+}
+
+// loadScores loads the scores from the config map. Keys that aren't present
+// or are invalid use the default scores
+func (e *Extender) loadScores() {
+	scores := defaultNodeScores
+	configMap, err := k8s.Instance().GetConfigMap(e.ScoresConfigMapName, e.ScoresConfigMapNamespace)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			log.Errorf("Error getting scores config map %v/%v: %v", e.ScoresConfigMapNamespace, e.ScoresConfigMapName, err)
+			return
+		}
+	} else {
+		for key, score := range map[string]*int{
+			NodeScoreKey:     &scores.node,
+			RackScoreKey:     &scores.rack,
+			ZoneScoreKey:     &scores.zone,
+			RegionScoreKey:   &scores.region,
+			DefaultScoreKey:  &scores.def,
+			CapacityScoreKey: &scores.capacity,
+		} {
+			value, ok := configMap.Data[key]
+			if !ok {
+				continue
+			}
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < 0 {
+				log.Warnf("Invalid value %v for %v in scores config map, using default %v", value, key, *score)
+				continue
+			}
+			*score = parsed
+		}
+		if !scores.isOrdered() {
+			log.Errorf("Scores in config map %v/%v must be in decreasing order from node to default, using default scores: %+v",
+				e.ScoresConfigMapNamespace, e.ScoresConfigMapName, scores)
+			scores = defaultNodeScores
+		}
+	}
+
+	e.scoresLock.Lock()
+	defer e.scoresLock.Unlock()
+	if e.scores == nil || *e.scores != scores {
+		log.Infof("Using scores for extender: %+v", scores)
+	}
+	e.scores = &scores
+}
@@ -0,0 +1,92 @@
+package extender
+
+import (
+	"encoding/json"
+	"net/http"
+
+	storklog "github.com/libopenstorage/stork/pkg/log"
+	"github.com/portworx/sched-ops/k8s"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/api/core/v1"
+	schedulerapi "k8s.io/kubernetes/pkg/scheduler/api"
+)
+
+const (
+	simulate = "simulate"
+)
+
+// SimulationResult is the result of simulating the filter and prioritize
+// requests for a pod
+type SimulationResult struct {
+	// FilteredNodes are the nodes on which the pod can be scheduled
+	FilteredNodes []string `json:"filteredNodes"`
+	// FilterError is the error returned by the filter request, if any. The
+	// nodes aren't scored if the filter request failed
+	FilterError string `json:"filterError,omitempty"`
+	// Scores are the scores for the filtered nodes
+	Scores schedulerapi.HostPriorityList `json:"scores"`
+}
+
+// Simulate returns the nodes that would be returned by the filter request for
+// the pod and the scores for those nodes. If no nodes are specified all the
+// nodes in the cluster are used. No events are recorded for the pod and the
+// cache isn't invalidated.
+func (e *Extender) Simulate(pod *v1.Pod, nodes []v1.Node) (*SimulationResult, error) {
+	if nodes == nil {
+		nodeList, err := k8s.Instance().GetNodes()
+		if err != nil {
+			return nil, err
+		}
+		nodes = nodeList.Items
+	}
+
+	result := &SimulationResult{
+		FilteredNodes: make([]string, 0),
+		Scores:        make(schedulerapi.HostPriorityList, 0),
+	}
+	filteredNodes, err := e.filter(pod, nodes, true)
+	if err != nil {
+		result.FilterError = err.Error()
+		return result, nil
+	}
+	for _, node := range filteredNodes {
+		result.FilteredNodes = append(result.FilteredNodes, node.Name)
+	}
+
+	scores, err := e.prioritize(pod, filteredNodes, true)
+	if err != nil {
+		return nil, err
+	}
+	result.Scores = scores
+	return result, nil
+}
+
+func (e *Extender) processSimulateRequest(w http.ResponseWriter, req *http.Request) {
+	decoder := json.NewDecoder(req.Body)
+	defer func() {
+		if err := req.Body.Close(); err != nil {
+			log.Warnf("Error closing decoder")
+		}
+	}()
+	encoder := json.NewEncoder(w)
+
+	var args schedulerapi.ExtenderArgs
+	if err := decoder.Decode(&args); err != nil || args.Pod == nil {
+		log.Errorf("Error decoding simulate request: %v", err)
+		http.Error(w, "Decode error", http.StatusBadRequest)
+		return
+	}
+
+	var nodes []v1.Node
+	if args.Nodes != nil {
+		nodes = args.Nodes.Items
+	}
+	result, err := e.Simulate(args.Pod, nodes)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := encoder.Encode(result); err != nil {
+		storklog.PodLog(args.Pod).Errorf("Error encoding simulate response: %+v : %v", result, err)
+	}
+}
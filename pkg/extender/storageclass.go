@@ -0,0 +1,108 @@
+package extender
+
+import (
+	"strconv"
+
+	storklog "github.com/libopenstorage/stork/pkg/log"
+	"github.com/portworx/sched-ops/k8s"
+	"k8s.io/api/core/v1"
+	k8shelper "k8s.io/kubernetes/pkg/apis/core/v1/helper"
+)
+
+const (
+	// StorageClassEnabledAnnotation Annotation on storage classes to
+	// configure whether the extender should consider volumes from the storage
+	// class when scheduling pods. Set to "false" to ignore the storage class,
+	// or to "true" to consider it when only opted-in storage classes are used
+	StorageClassEnabledAnnotation = "stork.libopenstorage.org/extender-enabled"
+)
+
+// getPVCStorageClass returns the name of the storage class of the PVC
+func (c *apiCache) getPVCStorageClass(name, namespace string) (string, error) {
+	value, _, err := c.get(
+		func() map[string]*apiCacheEntry { return c.pvcClasses },
+		namespace+"/"+name,
+		func() (string, bool, error) {
+			pvc, err := k8s.Instance().GetPersistentVolumeClaim(name, namespace)
+			if err != nil {
+				return "", false, err
+			}
+			return k8shelper.GetPersistentVolumeClaimClass(pvc), true, nil
+		})
+	return value, err
+}
+
+// getEnabledAnnotation returns the value of StorageClassEnabledAnnotation on
+// the storage class, and whether the annotation is set
+func (c *apiCache) getEnabledAnnotation(storageClassName string) (string, bool, error) {
+	return c.get(
+		func() map[string]*apiCacheEntry { return c.enabledClasses },
+		storageClassName,
+		func() (string, bool, error) {
+			storageClass, err := k8s.Instance().GetStorageClass(storageClassName)
+			if err != nil {
+				return "", false, err
+			}
+			value, ok := storageClass.Annotations[StorageClassEnabledAnnotation]
+			return value, ok, nil
+		})
+}
+
+// isStorageVolume returns false for pod volumes that can't be provided by a
+// storage driver
+func isStorageVolume(podVolume *v1.Volume) bool {
+	return podVolume.ConfigMap == nil && podVolume.Secret == nil &&
+		podVolume.EmptyDir == nil && podVolume.DownwardAPI == nil &&
+		podVolume.Projected == nil && podVolume.HostPath == nil
+}
+
+// isStorageClassEnabled returns true if the extender should consider volumes
+// from the storage class. Storage classes that can't be found are considered
+// so that the driver can decide if it owns the volumes.
+func (e *Extender) isStorageClassEnabled(pod *v1.Pod, storageClassName string) bool {
+	value, ok, err := e.getAPICache().getEnabledAnnotation(storageClassName)
+	if err != nil {
+		storklog.PodLog(pod).Debugf("Error getting storage class %v: %v", storageClassName, err)
+		return true
+	}
+	if !ok {
+		return !e.StorageClassOptIn
+	}
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		storklog.PodLog(pod).Warnf("Invalid value %v for %v on storage class %v", value, StorageClassEnabledAnnotation, storageClassName)
+		return !e.StorageClassOptIn
+	}
+	return enabled
+}
+
+// getEnabledVolumesPod returns a copy of the pod without the PVCs from storage
+// classes that the extender should ignore. Also returns false if the pod
+// doesn't have any volumes that need to be looked up from the driver, in which
+// case the driver doesn't need to be called at all.
+func (e *Extender) getEnabledVolumesPod(pod *v1.Pod) (*v1.Pod, bool) {
+	volumes := make([]v1.Volume, 0, len(pod.Spec.Volumes))
+	storageVolumeFound := false
+	for _, podVolume := range pod.Spec.Volumes {
+		if !isStorageVolume(&podVolume) {
+			volumes = append(volumes, podVolume)
+			continue
+		}
+		if podVolume.PersistentVolumeClaim != nil {
+			claimName := podVolume.PersistentVolumeClaim.ClaimName
+			storageClassName, err := e.getAPICache().getPVCStorageClass(claimName, pod.Namespace)
+			if err == nil && storageClassName != "" && !e.isStorageClassEnabled(pod, storageClassName) {
+				storklog.PodLog(pod).Debugf("Ignoring PVC %v from storage class %v", claimName, storageClassName)
+				continue
+			}
+		}
+		volumes = append(volumes, podVolume)
+		storageVolumeFound = true
+	}
+	if len(volumes) == len(pod.Spec.Volumes) {
+		return pod, storageVolumeFound
+	}
+	podCopy := pod.DeepCopy()
+	podCopy.Spec.Volumes = volumes
+	return podCopy, storageVolumeFound
+}
@@ -0,0 +1,65 @@
+package extender
+
+import (
+	"strings"
+
+	storklog "github.com/libopenstorage/stork/pkg/log"
+	"github.com/portworx/sched-ops/k8s"
+	"k8s.io/api/core/v1"
+)
+
+const (
+	// VolumeAffinityAnnotation Annotation on pods with a comma separated list
+	// of PVCs in the same namespace. Nodes are scored based on the locality of
+	// the data for these PVCs even though the pod doesn't mount them, for eg
+	// for backup agents
+	VolumeAffinityAnnotation = "stork.libopenstorage.org/volume-affinity"
+
+	volumeAffinityVolumePrefix = "stork-volume-affinity-"
+)
+
+// getVolumeAffinityPod returns a copy of the pod with volumes added for the
+// PVCs from the volume affinity annotation, so that they are scored like the
+// pod's own volumes. PVCs that aren't bound are skipped since the pod doesn't
+// need to wait for them.
+func (e *Extender) getVolumeAffinityPod(pod *v1.Pod) *v1.Pod {
+	value, ok := pod.Annotations[VolumeAffinityAnnotation]
+	if !ok {
+		return pod
+	}
+
+	claims := make(map[string]bool)
+	for _, podVolume := range pod.Spec.Volumes {
+		if podVolume.PersistentVolumeClaim != nil {
+			claims[podVolume.PersistentVolumeClaim.ClaimName] = true
+		}
+	}
+
+	podCopy := pod.DeepCopy()
+	for _, claimName := range strings.Split(value, ",") {
+		claimName = strings.TrimSpace(claimName)
+		if claimName == "" || claims[claimName] {
+			continue
+		}
+		pvc, err := k8s.Instance().GetPersistentVolumeClaim(claimName, pod.Namespace)
+		if err != nil {
+			storklog.PodLog(pod).Warnf("Error getting PVC %v for volume affinity: %v", claimName, err)
+			continue
+		}
+		if pvc.Status.Phase != v1.ClaimBound {
+			storklog.PodLog(pod).Debugf("Ignoring PVC %v for volume affinity since it isn't bound", claimName)
+			continue
+		}
+		claims[claimName] = true
+		podCopy.Spec.Volumes = append(podCopy.Spec.Volumes, v1.Volume{
+			Name: volumeAffinityVolumePrefix + claimName,
+			VolumeSource: v1.VolumeSource{
+				PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{
+					ClaimName: claimName,
+					ReadOnly:  true,
+				},
+			},
+		})
+	}
+	return podCopy
+}
@@ -0,0 +1,182 @@
+package extender
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"github.com/libopenstorage/stork/drivers/volume"
+	storkerrors "github.com/libopenstorage/stork/pkg/errors"
+	"github.com/portworx/sched-ops/k8s"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/api/core/v1"
+)
+
+const (
+	volumes = "volumes"
+)
+
+// VolumePlacement is the placement of the data for a PVC and the nodes where
+// the pods using it are running
+type VolumePlacement struct {
+	// PVC is the name of the PVC
+	PVC string `json:"pvc"`
+	// Namespace of the PVC
+	Namespace string `json:"namespace"`
+	// Driver is the name of the driver for the volume. Empty if the volume
+	// isn't owned by the driver
+	Driver string `json:"driver,omitempty"`
+	// VolumeID is the ID of the volume in the driver
+	VolumeID string `json:"volumeID,omitempty"`
+	// ReplicaNodes are the nodes where the data for the volume is located
+	ReplicaNodes []string `json:"replicaNodes"`
+	// AttachedNode is the node where the volume is attached, if any
+	AttachedNode string `json:"attachedNode,omitempty"`
+	// Pods are the pods using the PVC
+	Pods []PodPlacement `json:"pods"`
+}
+
+// PodPlacement is the node where a pod using a volume is running
+type PodPlacement struct {
+	// Name of the pod
+	Name string `json:"name"`
+	// Node where the pod is running. Empty if the pod hasn't been scheduled
+	Node string `json:"node,omitempty"`
+	// Hyperconverged is true if the pod is running on one of the nodes with
+	// the data for the volume
+	Hyperconverged bool `json:"hyperconverged"`
+}
+
+// GetVolumePlacement returns the placement of the volumes for all the PVCs in
+// the namespace, using the node names from Kubernetes for the nodes returned
+// by the driver
+func (e *Extender) GetVolumePlacement(namespace string) ([]*VolumePlacement, error) {
+	pvcs, err := k8s.Instance().GetPersistentVolumeClaims(namespace, nil)
+	if err != nil {
+		return nil, err
+	}
+	pods, err := k8s.Instance().GetPods(namespace, nil)
+	if err != nil {
+		return nil, err
+	}
+	nodeNames, err := e.getDriverNodeNames()
+	if err != nil {
+		return nil, err
+	}
+
+	placements := make([]*VolumePlacement, 0)
+	for _, pvc := range pvcs.Items {
+		placement := &VolumePlacement{
+			PVC:          pvc.Name,
+			Namespace:    pvc.Namespace,
+			ReplicaNodes: make([]string, 0),
+			Pods:         make([]PodPlacement, 0),
+		}
+		podSpec := &v1.PodSpec{
+			Volumes: []v1.Volume{
+				{
+					Name: pvc.Name,
+					VolumeSource: v1.VolumeSource{
+						PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{
+							ClaimName: pvc.Name,
+						},
+					},
+				},
+			},
+		}
+		driverVolumes, err := e.Driver.GetPodVolumes(podSpec, pvc.Namespace)
+		if err != nil {
+			// Pending PVCs don't have volumes yet
+			if _, ok := err.(*volume.ErrPVCPending); !ok {
+				return nil, err
+			}
+		}
+		for _, volumeInfo := range driverVolumes {
+			placement.Driver = e.Driver.String()
+			if volumeInfo.Driver != "" {
+				placement.Driver = volumeInfo.Driver
+			}
+			placement.VolumeID = volumeInfo.VolumeID
+			for _, dataNode := range volumeInfo.DataNodes {
+				placement.ReplicaNodes = append(placement.ReplicaNodes, getNodeName(nodeNames, dataNode))
+			}
+			attachedNode, err := e.Driver.GetVolumeAttachedNode(volumeInfo)
+			if err != nil {
+				if _, ok := err.(*storkerrors.ErrNotSupported); !ok {
+					return nil, err
+				}
+			}
+			if attachedNode != "" {
+				placement.AttachedNode = getNodeName(nodeNames, attachedNode)
+			}
+		}
+
+		for _, pod := range pods.Items {
+			for _, podVolume := range pod.Spec.Volumes {
+				if podVolume.PersistentVolumeClaim == nil || podVolume.PersistentVolumeClaim.ClaimName != pvc.Name {
+					continue
+				}
+				podPlacement := PodPlacement{
+					Name: pod.Name,
+					Node: pod.Spec.NodeName,
+				}
+				for _, replicaNode := range placement.ReplicaNodes {
+					if pod.Spec.NodeName != "" && replicaNode == pod.Spec.NodeName {
+						podPlacement.Hyperconverged = true
+					}
+				}
+				placement.Pods = append(placement.Pods, podPlacement)
+				break
+			}
+		}
+		placements = append(placements, placement)
+	}
+	sort.Slice(placements, func(i, j int) bool {
+		return placements[i].PVC < placements[j].PVC
+	})
+	return placements, nil
+}
+
+// getDriverNodeNames returns the Kubernetes node names for the storage IDs of
+// the driver nodes
+func (e *Extender) getDriverNodeNames() (map[string]string, error) {
+	driverNodes, err := e.Driver.GetNodes()
+	if err != nil {
+		return nil, err
+	}
+	k8sNodes, err := k8s.Instance().GetNodes()
+	if err != nil {
+		return nil, err
+	}
+	nodeNames := make(map[string]string)
+	for _, driverNode := range driverNodes {
+		for _, k8sNode := range k8sNodes.Items {
+			if volume.IsNodeMatch(&k8sNode, driverNode) {
+				nodeNames[driverNode.StorageID] = k8sNode.Name
+				break
+			}
+		}
+	}
+	return nodeNames, nil
+}
+
+// getNodeName returns the Kubernetes node name for a driver node, or the ID
+// from the driver if it doesn't match any node
+func getNodeName(nodeNames map[string]string, storageID string) string {
+	if name, ok := nodeNames[storageID]; ok {
+		return name
+	}
+	return storageID
+}
+
+func (e *Extender) processVolumesRequest(w http.ResponseWriter, req *http.Request) {
+	namespace := req.URL.Query().Get("namespace")
+	placements, err := e.GetVolumePlacement(namespace)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := json.NewEncoder(w).Encode(placements); err != nil {
+		log.Errorf("Error encoding volumes response for namespace %v: %v", namespace, err)
+	}
+}
@@ -217,13 +217,21 @@ func (m *GroupSnapshotController) handleInitial(groupSnap *stork_api.GroupVolume
 		err = fmt.Errorf("matchLabels are required for group snapshots. Refer to spec examples")
 	}
 
+	if namespaceErr := checkNamespacesAllowed(groupSnap); namespaceErr != nil {
+		err = namespaceErr
+	}
+
+	if capabilityErr := volume.CheckCapability(m.Driver, volume.CapabilityGroupSnapshots); capabilityErr != nil {
+		err = capabilityErr
+	}
+
 	if err != nil {
 		groupSnap.Status.Status = stork_api.GroupSnapshotFailed
 		groupSnap.Status.Stage = stork_api.GroupSnapshotStageFinal
 		return updateCRD, err
 	}
 
-	_, err = k8sutils.GetPVCsForGroupSnapshot(groupSnap.Namespace, groupSnap.Spec.PVCSelector.MatchLabels)
+	_, err = k8sutils.GetPVCsForGroupSnapshot(k8sutils.GetGroupSnapshotNamespaces(groupSnap), groupSnap.Spec.PVCSelector.MatchLabels)
 	if err != nil {
 		if groupSnap.Status.Status == stork_api.GroupSnapshotPending {
 			return !updateCRD, err
@@ -235,14 +243,14 @@ func (m *GroupSnapshotController) handleInitial(groupSnap *stork_api.GroupVolume
 		// Validate pre and post snap rules
 		preSnapRuleName := groupSnap.Spec.PreExecRule
 		if len(preSnapRuleName) > 0 {
-			if _, err := k8s.Instance().GetRule(preSnapRuleName, groupSnap.Namespace); err != nil {
+			if _, err := rule.GetRule(preSnapRuleName, groupSnap.Namespace, rule.PreExecRule, groupSnap.Spec.RuleParameters); err != nil {
 				return !updateCRD, err
 			}
 		}
 
 		postSnapRuleName := groupSnap.Spec.PostExecRule
 		if len(postSnapRuleName) > 0 {
-			if _, err := k8s.Instance().GetRule(postSnapRuleName, groupSnap.Namespace); err != nil {
+			if _, err := rule.GetRule(postSnapRuleName, groupSnap.Namespace, rule.PostExecRule, groupSnap.Spec.RuleParameters); err != nil {
 				return !updateCRD, err
 			}
 		}
@@ -261,6 +269,66 @@ func (m *GroupSnapshotController) handleInitial(groupSnap *stork_api.GroupVolume
 	return updateCRD, err
 }
 
+// checkNamespacesAllowed returns an error if the group snapshot selects PVCs
+// from a namespace that doesn't allow group snapshots from its namespace.
+// The webhook also checks that the user creating the group snapshot has
+// access to the namespaces, but it can be skipped while stork is down.
+func checkNamespacesAllowed(groupSnap *stork_api.GroupVolumeSnapshot) error {
+	for _, ns := range groupSnap.Spec.Namespaces {
+		allowed, err := snapshotcontrollers.IsGroupSnapshotNamespaceAllowed(ns, groupSnap.Namespace)
+		if err != nil {
+			return fmt.Errorf("error checking if PVCs can be selected from namespace %v: %v", ns, err)
+		}
+		if !allowed {
+			return fmt.Errorf("namespace %v doesn't allow group snapshots from namespace %v, set the %v annotation on it",
+				ns, groupSnap.Namespace, snapshotcontrollers.GroupSnapshotNamespacesAnnotation)
+		}
+	}
+	return nil
+}
+
+// updateRuleResults records the results of a failed rule in the status of the
+// group snapshot so that users can see which command failed
+func (m *GroupSnapshotController) updateRuleResults(
+	groupSnap *stork_api.GroupVolumeSnapshot,
+	results []*stork_api.RuleExecutionResult,
+	rType rule.Type,
+) {
+	if len(results) == 0 {
+		return
+	}
+	// Get the latest groupSnap as ExecuteRule might have updated it
+	latest, err := k8s.Instance().GetGroupSnapshot(groupSnap.GetName(), groupSnap.GetNamespace())
+	if err != nil {
+		log.GroupSnapshotLog(groupSnap).Warnf("Failed to get group snapshot to update rule results: %v", err)
+		return
+	}
+	latest.Status.RuleResults = rule.MergeRuleResults(latest.Status.RuleResults, results, rType)
+	if _, err := k8s.Instance().UpdateGroupSnapshot(latest); err != nil {
+		log.GroupSnapshotLog(groupSnap).Warnf("Failed to update rule results: %v", err)
+	}
+}
+
+// getRuleExecuteParameters returns the parameters passed to the rules for the
+// group snapshot
+func getRuleExecuteParameters(groupSnap *stork_api.GroupVolumeSnapshot) *rule.Parameters {
+	parameters := &rule.Parameters{
+		PVCs:   make([]string, 0),
+		Values: groupSnap.Spec.RuleParameters,
+	}
+	pvcList, err := k8sutils.GetPVCsForGroupSnapshot(
+		k8sutils.GetGroupSnapshotNamespaces(groupSnap),
+		groupSnap.Spec.PVCSelector.MatchLabels)
+	if err != nil {
+		log.GroupSnapshotLog(groupSnap).Warnf("Failed to get PVCs for rule parameters: %v", err)
+		return parameters
+	}
+	for _, pvc := range pvcList {
+		parameters.PVCs = append(parameters.PVCs, pvc.Name)
+	}
+	return parameters
+}
+
 func (m *GroupSnapshotController) handlePreSnap(groupSnap *stork_api.GroupVolumeSnapshot) (
 	*stork_api.GroupVolumeSnapshot, bool, error) {
 	ruleName := groupSnap.Spec.PreExecRule
@@ -272,17 +340,19 @@ func (m *GroupSnapshotController) handlePreSnap(groupSnap *stork_api.GroupVolume
 	}
 
 	log.GroupSnapshotLog(groupSnap).Infof("Running pre-snapshot rule: %s", ruleName)
-	r, err := k8s.Instance().GetRule(ruleName, groupSnap.Namespace)
+	r, err := rule.GetRule(ruleName, groupSnap.Namespace, rule.PreExecRule, groupSnap.Spec.RuleParameters)
 	if err != nil {
 		return nil, !updateCRD, err
 	}
 
-	backgroundCommandTermChan, err := rule.ExecuteRule(r, rule.PreExecRule, groupSnap, groupSnap.Namespace)
+	backgroundCommandTermChan, results, err := rule.ExecuteRule(r, rule.PreExecRule, groupSnap, groupSnap.Namespace,
+		getRuleExecuteParameters(groupSnap))
 	if err != nil {
 		if backgroundCommandTermChan != nil {
 			backgroundCommandTermChan <- true // terminate background commands if running
 		}
 
+		m.updateRuleResults(groupSnap, results, rule.PreExecRule)
 		return nil, !updateCRD, err
 	}
 
@@ -291,6 +361,7 @@ func (m *GroupSnapshotController) handlePreSnap(groupSnap *stork_api.GroupVolume
 	if err != nil {
 		return nil, !updateCRD, err
 	}
+	groupSnap.Status.RuleResults = rule.MergeRuleResults(groupSnap.Status.RuleResults, results, rule.PreExecRule)
 
 	if backgroundCommandTermChan != nil {
 		snapUID := string(groupSnap.ObjectMeta.UID)
@@ -403,12 +474,30 @@ func (m *GroupSnapshotController) createSnapAndDataObjects(
 	}
 
 	for _, snapshot := range snapshots {
-		parentPVCOrVolID, err := m.getPVCNameFromVolumeID(snapshot.ParentVolumeID)
+		parentPVCOrVolID, pvcNamespace, err := m.getPVCNameFromVolumeID(snapshot.ParentVolumeID)
 		if err != nil {
 			return nil, err
 		}
 
 		volumeSnapshotName := fmt.Sprintf("%s-%s-%s", parentName, parentPVCOrVolID, parentUUID)
+		volumeSnapshotAnnotations := snapAnnotations
+		if pvcNamespace != "" && pvcNamespace != parentNamespace {
+			// PVCs from other namespaces could have the same name, so add the
+			// namespace to the snapshot name. Also allow the snapshot to be
+			// restored to the namespace of the PVC.
+			volumeSnapshotName = fmt.Sprintf("%s-%s-%s-%s", parentName, pvcNamespace, parentPVCOrVolID, parentUUID)
+			volumeSnapshotAnnotations = make(map[string]string)
+			for k, v := range snapAnnotations {
+				volumeSnapshotAnnotations[k] = v
+			}
+			// Copy the namespaces so that appending doesn't modify the
+			// spec of the group snapshot
+			restoreNamespaces := make([]string, 0, len(groupSnap.Spec.RestoreNamespaces)+1)
+			restoreNamespaces = append(restoreNamespaces, groupSnap.Spec.RestoreNamespaces...)
+			restoreNamespaces = append(restoreNamespaces, pvcNamespace)
+			volumeSnapshotAnnotations[snapshotcontrollers.StorkSnapshotRestoreNamespacesAnnotation] = strings.Join(restoreNamespaces, ",")
+			volumeSnapshotAnnotations[snapshotcontrollers.SnapshotPVCNamespaceAnnotation] = pvcNamespace
+		}
 
 		var lastCondition crdv1.VolumeSnapshotDataCondition
 		if snapshot.Conditions != nil && len(snapshot.Conditions) > 0 {
@@ -425,7 +514,7 @@ func (m *GroupSnapshotController) createSnapAndDataObjects(
 			Metadata: metav1.ObjectMeta{
 				Name:        volumeSnapshotName,
 				Labels:      snapLabels,
-				Annotations: snapAnnotations,
+				Annotations: volumeSnapshotAnnotations,
 			},
 			Spec: crdv1.VolumeSnapshotDataSpec{
 				VolumeSnapshotRef: &v1.ObjectReference{
@@ -456,7 +545,7 @@ func (m *GroupSnapshotController) createSnapAndDataObjects(
 				Name:        volumeSnapshotName,
 				Namespace:   parentNamespace,
 				Labels:      snapLabels,
-				Annotations: snapAnnotations,
+				Annotations: volumeSnapshotAnnotations,
 				OwnerReferences: []metav1.OwnerReference{
 					{
 						Name:       parentName,
@@ -531,26 +620,27 @@ func revertSnapObjs(snapObjs []*crdv1.VolumeSnapshot) {
 	logrus.Infof("Successfully reverted volumesnapshots")
 }
 
-// this is best effort as can be vol ID if PVC is deleted
-func (m *GroupSnapshotController) getPVCNameFromVolumeID(volID string) (string, error) {
+// this is best effort as can be vol ID if PVC is deleted. The namespace of
+// the PVC is returned as empty if it can't be found.
+func (m *GroupSnapshotController) getPVCNameFromVolumeID(volID string) (string, string, error) {
 	volInfo, err := m.Driver.InspectVolume(volID)
 	if err != nil {
 		logrus.Warnf("Volume: %s not found due to: %v", volID, err)
-		return volID, nil
+		return volID, "", nil
 	}
 
 	parentPV, err := k8s.Instance().GetPersistentVolume(volInfo.VolumeName)
 	if err != nil {
 		logrus.Warnf("Parent PV: %s not found due to: %v", volInfo.VolumeName, err)
-		return volID, nil
+		return volID, "", nil
 	}
 
 	pvc, err := k8s.Instance().GetPersistentVolumeClaim(parentPV.Spec.ClaimRef.Name, parentPV.Spec.ClaimRef.Namespace)
 	if err != nil {
-		return volID, nil
+		return volID, "", nil
 	}
 
-	return pvc.GetName(), nil
+	return pvc.GetName(), pvc.GetNamespace(), nil
 
 }
 
@@ -566,13 +656,15 @@ func (m *GroupSnapshotController) handlePostSnap(groupSnap *stork_api.GroupVolum
 	}
 
 	logrus.Infof("Running post-snapshot rule: %s", ruleName)
-	r, err := k8s.Instance().GetRule(ruleName, groupSnap.Namespace)
+	r, err := rule.GetRule(ruleName, groupSnap.Namespace, rule.PostExecRule, groupSnap.Spec.RuleParameters)
 	if err != nil {
 		return nil, !updateCRD, err
 	}
 
-	_, err = rule.ExecuteRule(r, rule.PostExecRule, groupSnap, groupSnap.Namespace)
+	_, results, err := rule.ExecuteRule(r, rule.PostExecRule, groupSnap, groupSnap.Namespace,
+		getRuleExecuteParameters(groupSnap))
 	if err != nil {
+		m.updateRuleResults(groupSnap, results, rule.PostExecRule)
 		return nil, !updateCRD, err
 	}
 
@@ -581,6 +673,7 @@ func (m *GroupSnapshotController) handlePostSnap(groupSnap *stork_api.GroupVolum
 	if err != nil {
 		return nil, !updateCRD, err
 	}
+	groupSnap.Status.RuleResults = rule.MergeRuleResults(groupSnap.Status.RuleResults, results, rule.PostExecRule)
 
 	// done with post-snapshot, move to final stage
 	if groupSnap.Status.Status != stork_api.GroupSnapshotFailed {
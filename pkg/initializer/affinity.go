@@ -0,0 +1,117 @@
+package initializer
+
+import (
+	"github.com/libopenstorage/stork/drivers/volume"
+	"github.com/portworx/sched-ops/k8s"
+	"github.com/sirupsen/logrus"
+	"k8s.io/api/core/v1"
+	kubeletapis "k8s.io/kubernetes/pkg/kubelet/apis"
+)
+
+const (
+	// Weights for the node affinity terms injected for the locality of the
+	// volume data. Nodes with the data are preferred over other nodes in the
+	// same zone
+	dataNodeAffinityWeight = 100
+	dataZoneAffinityWeight = 50
+)
+
+// getVolumeAffinityTerms returns preferred node affinity terms for the nodes
+// and zones where the data for the volumes is located, based on the failure
+// domains reported by the driver
+func (i *Initializer) getVolumeAffinityTerms(volumes []*volume.Info) ([]v1.PreferredSchedulingTerm, error) {
+	driverNodes, err := i.Driver.GetNodes()
+	if err != nil {
+		return nil, err
+	}
+	k8sNodes, err := k8s.Instance().GetNodes()
+	if err != nil {
+		return nil, err
+	}
+
+	dataNodes := make(map[string]bool)
+	for _, volumeInfo := range volumes {
+		for _, dataNode := range volumeInfo.DataNodes {
+			dataNodes[dataNode] = true
+		}
+	}
+
+	hostnames := make([]string, 0)
+	zones := make([]string, 0)
+	foundZones := make(map[string]bool)
+	for _, driverNode := range driverNodes {
+		if !dataNodes[driverNode.StorageID] {
+			continue
+		}
+		for j := range k8sNodes.Items {
+			k8sNode := &k8sNodes.Items[j]
+			if !volume.IsNodeMatch(k8sNode, driverNode) {
+				continue
+			}
+			if hostname, ok := k8sNode.Labels[kubeletapis.LabelHostname]; ok {
+				hostnames = append(hostnames, hostname)
+			}
+			if zone, ok := k8sNode.Labels[kubeletapis.LabelZoneFailureDomain]; ok && !foundZones[zone] {
+				foundZones[zone] = true
+				zones = append(zones, zone)
+			}
+			break
+		}
+	}
+
+	terms := make([]v1.PreferredSchedulingTerm, 0)
+	if len(hostnames) > 0 {
+		terms = append(terms, v1.PreferredSchedulingTerm{
+			Weight: dataNodeAffinityWeight,
+			Preference: v1.NodeSelectorTerm{
+				MatchExpressions: []v1.NodeSelectorRequirement{{
+					Key:      kubeletapis.LabelHostname,
+					Operator: v1.NodeSelectorOpIn,
+					Values:   hostnames,
+				}},
+			},
+		})
+	}
+	if len(zones) > 0 {
+		terms = append(terms, v1.PreferredSchedulingTerm{
+			Weight: dataZoneAffinityWeight,
+			Preference: v1.NodeSelectorTerm{
+				MatchExpressions: []v1.NodeSelectorRequirement{{
+					Key:      kubeletapis.LabelZoneFailureDomain,
+					Operator: v1.NodeSelectorOpIn,
+					Values:   zones,
+				}},
+			},
+		})
+	}
+	return terms, nil
+}
+
+// injectVolumeAffinity adds preferred node affinity for the locality of the
+// volume data to the pod spec, so that pods are placed close to their data
+// even when they aren't scheduled by stork. Pod specs that already have
+// preferred node affinity are left as is.
+func (i *Initializer) injectVolumeAffinity(podSpec *v1.PodSpec, volumes []*volume.Info) {
+	if !i.InjectAffinity || len(volumes) == 0 {
+		return
+	}
+	if podSpec.Affinity != nil && podSpec.Affinity.NodeAffinity != nil &&
+		len(podSpec.Affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution) > 0 {
+		return
+	}
+	terms, err := i.getVolumeAffinityTerms(volumes)
+	if err != nil {
+		logrus.Errorf("Error getting affinity for volumes: %v", err)
+		return
+	}
+	if len(terms) == 0 {
+		return
+	}
+	if podSpec.Affinity == nil {
+		podSpec.Affinity = &v1.Affinity{}
+	}
+	if podSpec.Affinity.NodeAffinity == nil {
+		podSpec.Affinity.NodeAffinity = &v1.NodeAffinity{}
+	}
+	podSpec.Affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution = terms
+}
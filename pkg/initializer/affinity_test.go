@@ -0,0 +1,114 @@
+// +build unittest
+
+package initializer
+
+import (
+	"testing"
+
+	"github.com/libopenstorage/stork/drivers/volume"
+	"github.com/libopenstorage/stork/drivers/volume/mock"
+	"github.com/portworx/sched-ops/k8s"
+	"github.com/stretchr/testify/require"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakekube "k8s.io/client-go/kubernetes/fake"
+	kubeletapis "k8s.io/kubernetes/pkg/kubelet/apis"
+)
+
+func newNode(name string, zone string) *v1.Node {
+	return &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Labels: map[string]string{
+				kubeletapis.LabelHostname:          name,
+				kubeletapis.LabelZoneFailureDomain: zone,
+			},
+		},
+		Status: v1.NodeStatus{
+			Addresses: []v1.NodeAddress{{Type: v1.NodeHostName, Address: name}},
+		},
+	}
+}
+
+func TestInjectVolumeAffinity(t *testing.T) {
+	k8s.Instance().SetClient(fakekube.NewSimpleClientset(), nil, nil, nil, nil, nil)
+	nodes := &v1.NodeList{}
+	nodes.Items = append(nodes.Items, *newNode("node1", "zone1"))
+	nodes.Items = append(nodes.Items, *newNode("node2", "zone1"))
+	nodes.Items = append(nodes.Items, *newNode("node3", "zone2"))
+	for _, n := range nodes.Items {
+		_, err := k8s.Instance().CreateNode(&n)
+		require.NoError(t, err, "Error creating node")
+	}
+
+	storkDriver, err := volume.Get("MockDriver")
+	require.NoError(t, err, "Error getting mock volume driver")
+	driver, ok := storkDriver.(*mock.Driver)
+	require.True(t, ok, "Error casting mock driver")
+	require.NoError(t, driver.CreateCluster(3, nodes), "Error creating cluster")
+	require.NoError(t, driver.ProvisionVolume("affinityVolume", []int{0, 1}, 1), "Error provisioning volume")
+	volumeInfo, err := driver.InspectVolume("affinityVolume")
+	require.NoError(t, err, "Error inspecting volume")
+
+	i := &Initializer{Driver: driver}
+	podSpec := &v1.PodSpec{}
+	i.injectVolumeAffinity(podSpec, []*volume.Info{volumeInfo})
+	require.Nil(t, podSpec.Affinity, "Affinity shouldn't be injected unless enabled")
+
+	i.InjectAffinity = true
+	i.injectVolumeAffinity(podSpec, []*volume.Info{volumeInfo})
+	require.NotNil(t, podSpec.Affinity, "Affinity should be injected")
+	terms := podSpec.Affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution
+	require.Len(t, terms, 2)
+	require.Equal(t, int32(dataNodeAffinityWeight), terms[0].Weight)
+	require.Equal(t, kubeletapis.LabelHostname, terms[0].Preference.MatchExpressions[0].Key)
+	require.Equal(t, []string{"node1", "node2"}, terms[0].Preference.MatchExpressions[0].Values)
+	require.Equal(t, int32(dataZoneAffinityWeight), terms[1].Weight)
+	require.Equal(t, []string{"zone1"}, terms[1].Preference.MatchExpressions[0].Values)
+
+	// Existing preferred affinity shouldn't be replaced
+	existing := []v1.PreferredSchedulingTerm{{Weight: 1}}
+	podSpec.Affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution = existing
+	i.injectVolumeAffinity(podSpec, []*volume.Info{volumeInfo})
+	require.Equal(t, existing, podSpec.Affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution)
+
+	// Affinity is injected even if the scheduler name isn't updated
+	driver.NewPVC("affinityVolume")
+	volumeSpec := v1.PodSpec{
+		SchedulerName: "custom-scheduler",
+		Volumes: []v1.Volume{{
+			Name: "data",
+			VolumeSource: v1.VolumeSource{
+				PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: "affinityVolume"},
+			},
+		}},
+	}
+	objectMeta := &metav1.ObjectMeta{Name: "app", Namespace: "default"}
+	template := &v1.PodTemplateSpec{Spec: *volumeSpec.DeepCopy()}
+	require.NoError(t, i.UpdateDeploymentTemplate(objectMeta, template), "Error updating deployment template")
+	require.Equal(t, "custom-scheduler", template.Spec.SchedulerName)
+	require.NotNil(t, template.Spec.Affinity, "Affinity should be injected for deployment")
+	require.Len(t, template.Spec.Affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution, 2)
+
+	template = &v1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{SkipAnnotation: "true"}},
+		Spec:       *volumeSpec.DeepCopy(),
+	}
+	template.Spec.SchedulerName = defaultSchedulerName
+	require.NoError(t, i.UpdateDeploymentTemplate(objectMeta, template), "Error updating deployment template")
+	require.Equal(t, defaultSchedulerName, template.Spec.SchedulerName)
+	require.NotNil(t, template.Spec.Affinity, "Affinity should be injected for deployment that opted out")
+
+	// StatefulSets get affinity for the PVCs used directly by the template
+	template = &v1.PodTemplateSpec{Spec: *volumeSpec.DeepCopy()}
+	template.Spec.SchedulerName = defaultSchedulerName
+	require.NoError(t, i.UpdateStatefulSetTemplate(objectMeta, template, nil), "Error updating statefulset template")
+	require.Equal(t, defaultSchedulerName, template.Spec.SchedulerName)
+	require.NotNil(t, template.Spec.Affinity, "Affinity should be injected for statefulset")
+	require.Len(t, template.Spec.Affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution, 2)
+
+	i.InjectAffinity = false
+	template = &v1.PodTemplateSpec{Spec: *volumeSpec.DeepCopy()}
+	require.NoError(t, i.UpdateStatefulSetTemplate(objectMeta, template, nil), "Error updating statefulset template")
+	require.Nil(t, template.Spec.Affinity, "Affinity shouldn't be injected unless enabled")
+}
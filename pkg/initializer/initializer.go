@@ -28,10 +28,18 @@ const (
 
 // Initializer Kubernetes object initializer
 type Initializer struct {
-	Driver      volume.Driver
-	lock        sync.Mutex
-	started     bool
-	stopChannel chan struct{}
+	Driver volume.Driver
+	// SkipConfigMapName is the name of the config map with the namespaces
+	// and owners whose applications shouldn't be updated
+	SkipConfigMapName string
+	// SkipConfigMapNamespace is the namespace of the skip config map
+	SkipConfigMapNamespace string
+	// InjectAffinity if set, preferred node affinity for the nodes and zones
+	// with the volume data is added to deployments
+	InjectAffinity bool
+	lock           sync.Mutex
+	started        bool
+	stopChannel    chan struct{}
 }
 
 // Start Starts the Initializer
@@ -0,0 +1,83 @@
+package initializer
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/portworx/sched-ops/k8s"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// SkipAnnotation Annotation on applications or their pod templates, or
+	// label on namespaces, to skip updating the scheduler name when set to
+	// "true"
+	SkipAnnotation = "stork.libopenstorage.org/skip-scheduler-name"
+
+	// skipNamespacesKey Key in the skip config map with a list of namespaces
+	// in which applications shouldn't be updated
+	skipNamespacesKey = "namespaces"
+	// skipOwnersKey Key in the skip config map with a list of owners, as
+	// Kind or Kind/Name, whose applications shouldn't be updated
+	skipOwnersKey = "owners"
+)
+
+// splitList splits a list separated by commas or new lines
+func splitList(value string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == '\n'
+	}) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func isSkipSet(values map[string]string) bool {
+	value, ok := values[SkipAnnotation]
+	if !ok {
+		return false
+	}
+	skip, err := strconv.ParseBool(value)
+	return err == nil && skip
+}
+
+// shouldSkip returns true if the scheduler name shouldn't be updated for the
+// application with the given metadata and pod template metadata
+func (i *Initializer) shouldSkip(objectMeta *metav1.ObjectMeta, templateMeta *metav1.ObjectMeta) bool {
+	if isSkipSet(objectMeta.Annotations) || isSkipSet(templateMeta.Annotations) {
+		return true
+	}
+
+	namespace, err := k8s.Instance().GetNamespace(objectMeta.Namespace)
+	if err != nil {
+		logrus.Warnf("Error getting namespace %v: %v", objectMeta.Namespace, err)
+	} else if isSkipSet(namespace.Labels) {
+		return true
+	}
+
+	if i.SkipConfigMapName == "" {
+		return false
+	}
+	configMap, err := k8s.Instance().GetConfigMap(i.SkipConfigMapName, i.SkipConfigMapNamespace)
+	if err != nil {
+		logrus.Warnf("Error getting skip config map %v/%v: %v", i.SkipConfigMapNamespace, i.SkipConfigMapName, err)
+		return false
+	}
+	for _, namespace := range splitList(configMap.Data[skipNamespacesKey]) {
+		if namespace == objectMeta.Namespace {
+			return true
+		}
+	}
+	for _, owner := range splitList(configMap.Data[skipOwnersKey]) {
+		for _, ownerRef := range objectMeta.OwnerReferences {
+			if owner == ownerRef.Kind || owner == ownerRef.Kind+"/"+ownerRef.Name {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+// +build unittest
+
+package initializer
+
+import (
+	"testing"
+
+	"github.com/portworx/sched-ops/k8s"
+	"github.com/stretchr/testify/require"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakekube "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestShouldSkip(t *testing.T) {
+	k8s.Instance().SetClient(fakekube.NewSimpleClientset(), nil, nil, nil, nil, nil)
+	_, err := k8s.Instance().CreateNamespace("app", nil)
+	require.NoError(t, err, "Error creating namespace")
+	_, err = k8s.Instance().CreateNamespace("skipped", map[string]string{SkipAnnotation: "true"})
+	require.NoError(t, err, "Error creating namespace")
+	_, err = k8s.Instance().CreateConfigMap(&v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stork-skip",
+			Namespace: "kube-system",
+		},
+		Data: map[string]string{
+			skipNamespacesKey: "system, monitoring",
+			skipOwnersKey:     "Operator\nCluster/db",
+		},
+	})
+	require.NoError(t, err, "Error creating config map")
+
+	i := &Initializer{}
+	objectMeta := &metav1.ObjectMeta{Namespace: "app"}
+	templateMeta := &metav1.ObjectMeta{}
+	require.False(t, i.shouldSkip(objectMeta, templateMeta))
+
+	templateMeta.Annotations = map[string]string{SkipAnnotation: "true"}
+	require.True(t, i.shouldSkip(objectMeta, templateMeta), "Pod template annotation should be honored")
+	templateMeta.Annotations = nil
+	objectMeta.Annotations = map[string]string{SkipAnnotation: "true"}
+	require.True(t, i.shouldSkip(objectMeta, templateMeta), "Application annotation should be honored")
+	objectMeta.Annotations[SkipAnnotation] = "false"
+	require.False(t, i.shouldSkip(objectMeta, templateMeta))
+
+	require.True(t, i.shouldSkip(&metav1.ObjectMeta{Namespace: "skipped"}, templateMeta), "Namespace label should be honored")
+
+	// Config map is only used when configured
+	objectMeta = &metav1.ObjectMeta{Namespace: "monitoring"}
+	require.False(t, i.shouldSkip(objectMeta, templateMeta))
+	i.SkipConfigMapName = "stork-skip"
+	i.SkipConfigMapNamespace = "kube-system"
+	require.True(t, i.shouldSkip(objectMeta, templateMeta), "Namespace from config map should be skipped")
+
+	objectMeta = &metav1.ObjectMeta{
+		Namespace:       "app",
+		OwnerReferences: []metav1.OwnerReference{{Kind: "Cluster", Name: "db"}},
+	}
+	require.True(t, i.shouldSkip(objectMeta, templateMeta), "Owner from config map should be skipped")
+	objectMeta.OwnerReferences[0].Name = "other"
+	require.False(t, i.shouldSkip(objectMeta, templateMeta))
+	objectMeta.OwnerReferences[0].Kind = "Operator"
+	require.True(t, i.shouldSkip(objectMeta, templateMeta), "Owner kind from config map should be skipped")
+}
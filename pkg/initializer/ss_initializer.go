@@ -35,15 +35,9 @@ func (i *Initializer) initializeStatefulSetV1(ss *appv1.StatefulSet, clientset *
 		updatedStatefulSet.ObjectMeta.Initializers.Pending = append(pendingInitializers[:0], pendingInitializers[1:]...)
 	}
 
-	// Only check to update scheduler name if it is set to the default
-	if ss.Spec.Template.Spec.SchedulerName == defaultSchedulerName {
-		// Remove the initializer even if we get errors in this step
-		driverVolumeTemplates, err := i.Driver.GetVolumeClaimTemplates(ss.Spec.VolumeClaimTemplates)
-		if err != nil {
-			storklog.StatefulSetV1Log(ss).Infof("Error getting volume templates for statefulset: %v", err)
-		} else if len(driverVolumeTemplates) > 0 {
-			updatedStatefulSet.Spec.Template.Spec.SchedulerName = storkSchedulerName
-		}
+	// Remove the initializer even if we get errors in this step
+	if err := i.UpdateStatefulSetTemplate(&ss.ObjectMeta, &updatedStatefulSet.Spec.Template, ss.Spec.VolumeClaimTemplates); err != nil {
+		storklog.StatefulSetV1Log(ss).Infof("Error getting volume templates for statefulset: %v", err)
 	}
 
 	newData, err := json.Marshal(updatedStatefulSet)
@@ -86,15 +80,9 @@ func (i *Initializer) initializeStatefulSetV1Beta1(ss *appv1beta1.StatefulSet, c
 		updatedStatefulSet.ObjectMeta.Initializers.Pending = append(pendingInitializers[:0], pendingInitializers[1:]...)
 	}
 
-	// Only check to update scheduler name if it is set to the default
-	if ss.Spec.Template.Spec.SchedulerName == defaultSchedulerName {
-		// Remove the initializer even if we get errors in this step
-		driverVolumeTemplates, err := i.Driver.GetVolumeClaimTemplates(ss.Spec.VolumeClaimTemplates)
-		if err != nil {
-			storklog.StatefulSetV1Beta1Log(ss).Infof("Error getting volume templates for statefulset: %v", err)
-		} else if len(driverVolumeTemplates) > 0 {
-			updatedStatefulSet.Spec.Template.Spec.SchedulerName = storkSchedulerName
-		}
+	// Remove the initializer even if we get errors in this step
+	if err := i.UpdateStatefulSetTemplate(&ss.ObjectMeta, &updatedStatefulSet.Spec.Template, ss.Spec.VolumeClaimTemplates); err != nil {
+		storklog.StatefulSetV1Beta1Log(ss).Infof("Error getting volume templates for statefulset: %v", err)
 	}
 
 	newData, err := json.Marshal(updatedStatefulSet)
@@ -137,15 +125,9 @@ func (i *Initializer) initializeStatefulSetV1Beta2(ss *appv1beta2.StatefulSet, c
 		updatedStatefulSet.ObjectMeta.Initializers.Pending = append(pendingInitializers[:0], pendingInitializers[1:]...)
 	}
 
-	// Only check to update scheduler name if it is set to the default
-	if ss.Spec.Template.Spec.SchedulerName == defaultSchedulerName {
-		// Remove the initializer even if we get errors in this step
-		driverVolumeTemplates, err := i.Driver.GetVolumeClaimTemplates(ss.Spec.VolumeClaimTemplates)
-		if err != nil {
-			storklog.StatefulSetV1Beta2Log(ss).Infof("Error getting volume templates for statefulset: %v", err)
-		} else if len(driverVolumeTemplates) > 0 {
-			updatedStatefulSet.Spec.Template.Spec.SchedulerName = storkSchedulerName
-		}
+	// Remove the initializer even if we get errors in this step
+	if err := i.UpdateStatefulSetTemplate(&ss.ObjectMeta, &updatedStatefulSet.Spec.Template, ss.Spec.VolumeClaimTemplates); err != nil {
+		storklog.StatefulSetV1Beta2Log(ss).Infof("Error getting volume templates for statefulset: %v", err)
 	}
 
 	newData, err := json.Marshal(updatedStatefulSet)
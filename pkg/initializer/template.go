@@ -0,0 +1,77 @@
+package initializer
+
+import (
+	"github.com/libopenstorage/stork/drivers/volume"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// UpdateDeploymentTemplate sets the scheduler name to stork in the pod
+// template of a deployment that uses volumes from the driver, unless the
+// application has opted out. Node affinity for the volumes is injected if
+// enabled, even if the scheduler name isn't updated. Returns an error if the
+// volumes for the template couldn't be looked up, in which case the template
+// isn't updated.
+func (i *Initializer) UpdateDeploymentTemplate(objectMeta *metav1.ObjectMeta, template *v1.PodTemplateSpec) error {
+	// Only check to update scheduler name if it is set to the default and the
+	// application hasn't opted out
+	setSchedulerName := template.Spec.SchedulerName == defaultSchedulerName &&
+		!i.shouldSkip(objectMeta, &template.ObjectMeta)
+	if !setSchedulerName && !i.InjectAffinity {
+		return nil
+	}
+	driverVolumes, err := i.Driver.GetPodVolumes(&template.Spec, objectMeta.Namespace)
+	if err != nil {
+		if _, ok := err.(*volume.ErrPVCPending); ok {
+			if setSchedulerName {
+				template.Spec.SchedulerName = storkSchedulerName
+			}
+			return nil
+		}
+		return err
+	}
+	if len(driverVolumes) != 0 && setSchedulerName {
+		template.Spec.SchedulerName = storkSchedulerName
+	}
+	i.injectVolumeAffinity(&template.Spec, driverVolumes)
+	return nil
+}
+
+// UpdateStatefulSetTemplate sets the scheduler name to stork in the pod
+// template of a statefulset with volume claim templates for the driver,
+// unless the application has opted out. Node affinity is injected if enabled
+// for the PVCs from the driver that are used directly by the template, since
+// the volumes for the claim templates are different for each pod. Returns an
+// error if the volumes couldn't be checked, in which case the template isn't
+// updated.
+func (i *Initializer) UpdateStatefulSetTemplate(
+	objectMeta *metav1.ObjectMeta,
+	template *v1.PodTemplateSpec,
+	claimTemplates []v1.PersistentVolumeClaim,
+) error {
+	// Only check to update scheduler name if it is set to the default and the
+	// application hasn't opted out
+	setSchedulerName := template.Spec.SchedulerName == defaultSchedulerName &&
+		!i.shouldSkip(objectMeta, &template.ObjectMeta)
+	var driverVolumes []*volume.Info
+	if i.InjectAffinity {
+		var err error
+		driverVolumes, err = i.Driver.GetPodVolumes(&template.Spec, objectMeta.Namespace)
+		if err != nil {
+			if _, ok := err.(*volume.ErrPVCPending); !ok {
+				return err
+			}
+		}
+	}
+	if setSchedulerName {
+		driverVolumeTemplates, err := i.Driver.GetVolumeClaimTemplates(claimTemplates)
+		if err != nil {
+			return err
+		}
+		if len(driverVolumeTemplates) > 0 {
+			template.Spec.SchedulerName = storkSchedulerName
+		}
+	}
+	i.injectVolumeAffinity(&template.Spec, driverVolumes)
+	return nil
+}
@@ -3,36 +3,50 @@ package k8sutils
 import (
 	"fmt"
 
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
 	"github.com/portworx/sched-ops/k8s"
 	"k8s.io/api/core/v1"
 )
 
-// GetPVCsForGroupSnapshot returns all PVCs in given namespace that match the given matchLabels. All PVCs need to be bound.
-func GetPVCsForGroupSnapshot(namespace string, matchLabels map[string]string) ([]v1.PersistentVolumeClaim, error) {
-	pvcList, err := k8s.Instance().GetPersistentVolumeClaims(namespace, matchLabels)
-	if err != nil {
-		return nil, err
+// GetGroupSnapshotNamespaces returns the namespaces from which PVCs should be
+// selected for the given group snapshot
+func GetGroupSnapshotNamespaces(groupSnap *stork_api.GroupVolumeSnapshot) []string {
+	if len(groupSnap.Spec.Namespaces) == 0 {
+		return []string{groupSnap.Namespace}
 	}
+	return groupSnap.Spec.Namespaces
+}
 
-	if len(pvcList.Items) == 0 {
+// GetPVCsForGroupSnapshot returns all PVCs in given namespaces that match the given matchLabels. All PVCs need to be bound.
+func GetPVCsForGroupSnapshot(namespaces []string, matchLabels map[string]string) ([]v1.PersistentVolumeClaim, error) {
+	pvcs := make([]v1.PersistentVolumeClaim, 0)
+	for _, namespace := range namespaces {
+		pvcList, err := k8s.Instance().GetPersistentVolumeClaims(namespace, matchLabels)
+		if err != nil {
+			return nil, err
+		}
+		pvcs = append(pvcs, pvcList.Items...)
+	}
+
+	if len(pvcs) == 0 {
 		return nil, fmt.Errorf("found no PVCs for group snapshot with given label selectors: %v", matchLabels)
 	}
 
 	// Check if no PVCs are in pending state
-	for _, pvc := range pvcList.Items {
+	for _, pvc := range pvcs {
 		if pvc.Status.Phase == v1.ClaimPending {
 			return nil, fmt.Errorf("PVC: [%s] %s is still in %s phase. Group snapshot will trigger after all PVCs are bound",
 				pvc.Namespace, pvc.Name, pvc.Status.Phase)
 		}
 	}
 
-	return pvcList.Items, nil
+	return pvcs, nil
 }
 
-// GetVolumeNamesFromLabelSelector returns PV names for all PVCs in given namespace that match the given
+// GetVolumeNamesFromLabelSelector returns PV names for all PVCs in given namespaces that match the given
 // labels
-func GetVolumeNamesFromLabelSelector(namespace string, labels map[string]string) ([]string, error) {
-	pvcs, err := GetPVCsForGroupSnapshot(namespace, labels)
+func GetVolumeNamesFromLabelSelector(namespaces []string, labels map[string]string) ([]string, error) {
+	pvcs, err := GetPVCsForGroupSnapshot(namespaces, labels)
 	if err != nil {
 		return nil, err
 	}
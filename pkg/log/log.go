@@ -120,6 +120,30 @@ func VolumeSnapshotScheduleLog(snapshotSchedule *storkv1.VolumeSnapshotSchedule)
 	return logrus.WithFields(logrus.Fields{})
 }
 
+// VolumeSnapshotRestoreLog formats a log message with volumesnapshotrestore information
+func VolumeSnapshotRestoreLog(snapRestore *storkv1.VolumeSnapshotRestore) *logrus.Entry {
+	if snapRestore != nil {
+		return logrus.WithFields(logrus.Fields{
+			"VolumeSnapshotRestoreName": snapRestore.Name,
+			"Namespace":                 snapRestore.Namespace,
+		})
+	}
+
+	return logrus.WithFields(logrus.Fields{})
+}
+
+// GroupVolumeSnapshotRestoreLog formats a log message with groupvolumesnapshotrestore information
+func GroupVolumeSnapshotRestoreLog(groupSnapRestore *storkv1.GroupVolumeSnapshotRestore) *logrus.Entry {
+	if groupSnapRestore != nil {
+		return logrus.WithFields(logrus.Fields{
+			"GroupVolumeSnapshotRestoreName": groupSnapRestore.Name,
+			"Namespace":                      groupSnapRestore.Namespace,
+		})
+	}
+
+	return logrus.WithFields(logrus.Fields{})
+}
+
 // RuleLog formats a log message with Rule information
 func RuleLog(
 	rule *storkv1.Rule,
@@ -20,6 +20,8 @@ func TestLog(t *testing.T) {
 	t.Run("statefulsetLogTest", statefulsetLogTest)
 	t.Run("snapshotLogTest", snapshotLogTest)
 	t.Run("snapshotScheduleLogTest", snapshotScheduleLogTest)
+	t.Run("snapshotRestoreLogTest", snapshotRestoreLogTest)
+	t.Run("groupSnapshotRestoreLogTest", groupSnapshotRestoreLogTest)
 	t.Run("migrationLogTest", migrationLogTest)
 	t.Run("migrationScheduleLogTest", migrationScheduleLogTest)
 	t.Run("ruleLogTest", ruleLogTest)
@@ -118,6 +120,30 @@ func snapshotScheduleLogTest(t *testing.T) {
 	VolumeSnapshotScheduleLog(nil).Infof("snapshot schedule nil log")
 }
 
+func snapshotRestoreLogTest(t *testing.T) {
+	metadata := metav1.ObjectMeta{
+		Name:      "testsnapshotrestore",
+		Namespace: "testnamespace",
+	}
+	snapshotRestore := &storkv1.VolumeSnapshotRestore{
+		ObjectMeta: metadata,
+	}
+	VolumeSnapshotRestoreLog(snapshotRestore).Infof("snapshot restore log")
+	VolumeSnapshotRestoreLog(nil).Infof("snapshot restore nil log")
+}
+
+func groupSnapshotRestoreLogTest(t *testing.T) {
+	metadata := metav1.ObjectMeta{
+		Name:      "testgroupsnapshotrestore",
+		Namespace: "testnamespace",
+	}
+	groupSnapshotRestore := &storkv1.GroupVolumeSnapshotRestore{
+		ObjectMeta: metadata,
+	}
+	GroupVolumeSnapshotRestoreLog(groupSnapshotRestore).Infof("group snapshot restore log")
+	GroupVolumeSnapshotRestoreLog(nil).Infof("group snapshot restore nil log")
+}
+
 func migrationLogTest(t *testing.T) {
 	metadata := metav1.ObjectMeta{
 		Name:      "testmigration",
@@ -74,6 +74,18 @@ func (c *ClusterPairController) Handle(ctx context.Context, event sdk.Event) err
 			if err != nil {
 				return err
 			}
+		} else if err := volume.CheckCapability(c.Driver, volume.CapabilityMigration); err != nil {
+			if clusterPair.Status.StorageStatus != stork_api.ClusterPairStatusError {
+				clusterPair.Status.StorageStatus = stork_api.ClusterPairStatusError
+				c.Recorder.Event(clusterPair,
+					v1.EventTypeWarning,
+					string(clusterPair.Status.StorageStatus),
+					err.Error())
+				err = sdk.Update(clusterPair)
+				if err != nil {
+					return err
+				}
+			}
 		} else {
 			if clusterPair.Status.StorageStatus != stork_api.ClusterPairStatusReady {
 				remoteID, err := c.Driver.CreatePair(clusterPair)
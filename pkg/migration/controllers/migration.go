@@ -156,6 +156,24 @@ func (m *MigrationController) Handle(ctx context.Context, event sdk.Event) error
 
 		switch migration.Status.Stage {
 		case stork_api.MigrationStageInitial:
+			// Make sure the driver can migrate the volumes
+			if *migration.Spec.IncludeVolumes {
+				if err := volume.CheckCapability(m.Driver, volume.CapabilityMigration); err != nil {
+					migration.Status.Status = stork_api.MigrationStatusFailed
+					migration.Status.Stage = stork_api.MigrationStageFinal
+					migration.Status.FinishTimestamp = metav1.Now()
+					log.MigrationLog(migration).Error(err)
+					m.Recorder.Event(migration,
+						v1.EventTypeWarning,
+						string(stork_api.MigrationStatusFailed),
+						err.Error())
+					err = sdk.Update(migration)
+					if err != nil {
+						log.MigrationLog(migration).Errorf("Error updating")
+					}
+					return nil
+				}
+			}
 			// Make sure the namespaces exist
 			for _, ns := range migration.Spec.Namespaces {
 				_, err := k8s.Instance().GetNamespace(ns)
@@ -178,7 +196,7 @@ func (m *MigrationController) Handle(ctx context.Context, event sdk.Event) error
 			}
 			// Make sure the rules exist if configured
 			if migration.Spec.PreExecRule != "" {
-				_, err := k8s.Instance().GetRule(migration.Spec.PreExecRule, migration.Namespace)
+				_, err := rule.GetRule(migration.Spec.PreExecRule, migration.Namespace, rule.PreExecRule, migration.Spec.RuleParameters)
 				if err != nil {
 					message := fmt.Sprintf("Error getting PreExecRule %v: %v", migration.Spec.PreExecRule, err)
 					log.MigrationLog(migration).Errorf(message)
@@ -190,7 +208,7 @@ func (m *MigrationController) Handle(ctx context.Context, event sdk.Event) error
 				}
 			}
 			if migration.Spec.PostExecRule != "" {
-				_, err := k8s.Instance().GetRule(migration.Spec.PostExecRule, migration.Namespace)
+				_, err := rule.GetRule(migration.Spec.PostExecRule, migration.Namespace, rule.PostExecRule, migration.Spec.RuleParameters)
 				if err != nil {
 					message := fmt.Sprintf("Error getting PostExecRule %v: %v", migration.Spec.PreExecRule, err)
 					log.MigrationLog(migration).Errorf(message)
@@ -457,8 +475,14 @@ func (m *MigrationController) runPreExecRule(migration *stork_api.Migration) ([]
 		}
 	}
 	terminationChannels := make([]chan bool, 0)
+	results := make([]*stork_api.RuleExecutionResult, 0)
+	// Record the results in the status, including the ones for a rule that
+	// failed
+	defer func() {
+		migration.Status.RuleResults = rule.MergeRuleResults(migration.Status.RuleResults, results, rule.PreExecRule)
+	}()
 	for _, ns := range migration.Spec.Namespaces {
-		r, err := k8s.Instance().GetRule(migration.Spec.PreExecRule, ns)
+		r, err := rule.GetRule(migration.Spec.PreExecRule, ns, rule.PreExecRule, migration.Spec.RuleParameters)
 		if err != nil {
 			for _, channel := range terminationChannels {
 				channel <- true
@@ -466,7 +490,9 @@ func (m *MigrationController) runPreExecRule(migration *stork_api.Migration) ([]
 			return nil, err
 		}
 
-		ch, err := rule.ExecuteRule(r, rule.PreExecRule, migration, ns)
+		ch, nsResults, err := rule.ExecuteRule(r, rule.PreExecRule, migration, ns,
+			&rule.Parameters{Values: migration.Spec.RuleParameters})
+		results = append(results, nsResults...)
 		if err != nil {
 			for _, channel := range terminationChannels {
 				channel <- true
@@ -481,13 +507,19 @@ func (m *MigrationController) runPreExecRule(migration *stork_api.Migration) ([]
 }
 
 func (m *MigrationController) runPostExecRule(migration *stork_api.Migration) error {
+	results := make([]*stork_api.RuleExecutionResult, 0)
+	defer func() {
+		migration.Status.RuleResults = rule.MergeRuleResults(migration.Status.RuleResults, results, rule.PostExecRule)
+	}()
 	for _, ns := range migration.Spec.Namespaces {
-		r, err := k8s.Instance().GetRule(migration.Spec.PostExecRule, ns)
+		r, err := rule.GetRule(migration.Spec.PostExecRule, ns, rule.PostExecRule, migration.Spec.RuleParameters)
 		if err != nil {
 			return err
 		}
 
-		_, err = rule.ExecuteRule(r, rule.PostExecRule, migration, ns)
+		_, nsResults, err := rule.ExecuteRule(r, rule.PostExecRule, migration, ns,
+			&rule.Parameters{Values: migration.Spec.RuleParameters})
+		results = append(results, nsResults...)
 		if err != nil {
 			return fmt.Errorf("error executing PreExecRule for namespace %v: %v", ns, err)
 		}
@@ -677,7 +709,11 @@ func (m *MigrationController) prepareApplicationResource(
 		return err
 	}
 
-	annotations[StorkMigrationReplicasAnnotation] = strconv.FormatInt(replicas, 10)
+	// Applications that were scaled down for a failover already have the
+	// replicas from before they were scaled down in the annotation
+	if _, present := annotations[StorkMigrationReplicasAnnotation]; !present || replicas != 0 {
+		annotations[StorkMigrationReplicasAnnotation] = strconv.FormatInt(replicas, 10)
+	}
 	spec["replicas"] = 0
 	return nil
 }
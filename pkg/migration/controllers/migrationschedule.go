@@ -70,8 +70,15 @@ func (m *MigrationScheduleController) Handle(ctx context.Context, event sdk.Even
 			return err
 		}
 
+		// Suspend or resume the schedule if the state of the cluster domains
+		// it depends on has changed
+		if err := m.updateClusterDomainSuspension(migrationSchedule); err != nil {
+			log.MigrationScheduleLog(migrationSchedule).Warnf("Error checking cluster domains for schedule: %v", err)
+		}
+
 		// Then check if any of the policies require a trigger if it is enabled
-		if migrationSchedule.Spec.Suspend == nil || !*migrationSchedule.Spec.Suspend {
+		if (migrationSchedule.Spec.Suspend == nil || !*migrationSchedule.Spec.Suspend) &&
+			len(migrationSchedule.Status.SuspendedForClusterDomains) == 0 {
 			policyType, start, err := m.shouldStartMigration(migrationSchedule)
 			if err != nil {
 				msg := fmt.Sprintf("Error checking if migration should be triggered: %v", err)
@@ -180,25 +187,58 @@ func (m *MigrationScheduleController) isMigrationComplete(status stork_api.Migra
 func (m *MigrationScheduleController) shouldStartMigration(
 	migrationSchedule *stork_api.MigrationSchedule,
 ) (stork_api.SchedulePolicyType, bool, error) {
-	// Don't trigger a new migration if one is already in progress
+	maxRuntime, maxRuntimeAction, err := schedule.GetMaxRuntime(migrationSchedule.Spec.SchedulePolicyName)
+	if err != nil {
+		return stork_api.SchedulePolicyTypeInvalid, false, err
+	}
+	// Find the migrations that are still in progress, cancelling the ones
+	// that have run for longer than the max runtime if required
+	running := make([]*stork_api.ScheduledMigrationStatus, 0)
 	for _, policyType := range stork_api.GetValidSchedulePolicyTypes() {
 		policyMigration, present := migrationSchedule.Status.Items[policyType]
 		if present {
 			for _, migration := range policyMigration {
-				if !m.isMigrationComplete(migration.Status) {
-					return stork_api.SchedulePolicyTypeInvalid, false, nil
+				if m.isMigrationComplete(migration.Status) {
+					continue
 				}
+				if maxRuntime != 0 && schedule.GetCurrentTime().Sub(migration.CreationTimestamp.Time) >= maxRuntime {
+					if err := m.maxRuntimeExceeded(migrationSchedule, migration, maxRuntime, maxRuntimeAction); err != nil {
+						return stork_api.SchedulePolicyTypeInvalid, false, err
+					}
+					if m.isMigrationComplete(migration.Status) {
+						continue
+					}
+				}
+				running = append(running, migration)
 			}
 		}
 	}
+	concurrencyPolicy := migrationSchedule.Spec.ConcurrencyPolicy
+	// Don't trigger a new migration if one is already in progress unless
+	// the concurrency policy allows it
+	if len(running) > 0 && concurrencyPolicy != stork_api.ConcurrencyPolicyReplace &&
+		concurrencyPolicy != stork_api.ConcurrencyPolicyAllow {
+		return stork_api.SchedulePolicyTypeInvalid, false, nil
+	}
 
+	delay, err := schedule.GetTriggerDelay(migrationSchedule.Spec.SchedulePolicyName, migrationSchedule.Namespace, migrationSchedule.Name)
+	if err != nil {
+		return stork_api.SchedulePolicyTypeInvalid, false, err
+	}
 	for _, policyType := range stork_api.GetValidSchedulePolicyTypes() {
 		var latestMigrationTimestamp meta.Time
 		policyMigration, present := migrationSchedule.Status.Items[policyType]
 		if present {
 			for _, migration := range policyMigration {
-				if latestMigrationTimestamp.Before(&migration.CreationTimestamp) {
-					latestMigrationTimestamp = migration.CreationTimestamp
+				lastTrigger := migration.CreationTimestamp
+				// Triggers that were due while a migration ran for longer
+				// than the max runtime are skipped
+				if migration.MaxRuntimeExceeded && maxRuntimeAction == stork_api.MaxRuntimeActionSkip &&
+					!migration.FinishTimestamp.IsZero() {
+					lastTrigger = migration.FinishTimestamp
+				}
+				if latestMigrationTimestamp.Before(&lastTrigger) {
+					latestMigrationTimestamp = lastTrigger
 				}
 			}
 		}
@@ -206,17 +246,161 @@ func (m *MigrationScheduleController) shouldStartMigration(
 			migrationSchedule.Spec.SchedulePolicyName,
 			policyType,
 			latestMigrationTimestamp,
+			delay,
 		)
 		if err != nil {
 			return stork_api.SchedulePolicyTypeInvalid, false, err
 		}
+		trigger, pending, err := schedule.CatchUpRequired(
+			migrationSchedule.Spec.SchedulePolicyName,
+			policyType,
+			latestMigrationTimestamp,
+			migrationSchedule.Spec.CatchUpPolicy,
+			trigger,
+			migrationSchedule.Status.PendingCatchUpTriggers[policyType],
+			delay,
+		)
+		if err != nil {
+			return stork_api.SchedulePolicyTypeInvalid, false, err
+		}
+		if pending != migrationSchedule.Status.PendingCatchUpTriggers[policyType] {
+			if migrationSchedule.Status.PendingCatchUpTriggers == nil {
+				migrationSchedule.Status.PendingCatchUpTriggers = make(map[stork_api.SchedulePolicyType]int)
+			}
+			migrationSchedule.Status.PendingCatchUpTriggers[policyType] = pending
+		}
 		if trigger {
+			reason, err := schedule.GetSkipReason(migrationSchedule.Spec.SchedulePolicyName)
+			if err != nil {
+				return stork_api.SchedulePolicyTypeInvalid, false, err
+			}
+			if reason == "" {
+				reason, err = schedule.GetDependencySkipReason(migrationSchedule.Namespace, migrationSchedule.Spec.DependsOn, latestMigrationTimestamp)
+				if err != nil {
+					return stork_api.SchedulePolicyTypeInvalid, false, err
+				}
+			}
+			if reason != "" {
+				return stork_api.SchedulePolicyTypeInvalid, false, m.skipMigration(migrationSchedule, policyType, latestMigrationTimestamp, reason)
+			}
+			if concurrencyPolicy == stork_api.ConcurrencyPolicyReplace {
+				for _, migration := range running {
+					if err := m.cancelMigration(migrationSchedule, migration); err != nil {
+						return stork_api.SchedulePolicyTypeInvalid, false, err
+					}
+					m.Recorder.Event(migrationSchedule,
+						v1.EventTypeNormal,
+						"Cancelled",
+						fmt.Sprintf("Scheduled migration (%v) cancelled to start a new migration", migration.Name))
+				}
+			}
 			return policyType, true, nil
 		}
 	}
 	return stork_api.SchedulePolicyTypeInvalid, false, nil
 }
 
+// maxRuntimeExceeded handles a migration that has been running for longer
+// than the max runtime of the schedule policy. The migration is cancelled if
+// the policy says so, otherwise it is left running and an event is raised
+// once.
+func (m *MigrationScheduleController) maxRuntimeExceeded(
+	migrationSchedule *stork_api.MigrationSchedule,
+	migration *stork_api.ScheduledMigrationStatus,
+	maxRuntime time.Duration,
+	action stork_api.MaxRuntimeActionType,
+) error {
+	if migration.MaxRuntimeExceeded && action != stork_api.MaxRuntimeActionCancel {
+		return nil
+	}
+	migration.MaxRuntimeExceeded = true
+	var msg string
+	if action == stork_api.MaxRuntimeActionCancel {
+		if err := m.cancelMigration(migrationSchedule, migration); err != nil {
+			return err
+		}
+		msg = fmt.Sprintf("Scheduled migration (%v) cancelled since it ran for longer than %v", migration.Name, maxRuntime)
+		m.Recorder.Event(migrationSchedule,
+			v1.EventTypeWarning,
+			"Cancelled",
+			msg)
+	} else {
+		msg = fmt.Sprintf("Scheduled migration (%v) has run for longer than %v, triggers will be skipped until it completes", migration.Name, maxRuntime)
+		m.Recorder.Event(migrationSchedule,
+			v1.EventTypeWarning,
+			"MaxRuntimeExceeded",
+			msg)
+	}
+	log.MigrationScheduleLog(migrationSchedule).Warn(msg)
+	return sdk.Update(migrationSchedule)
+}
+
+// cancelMigration cancels a migration that is in progress by deleting it and
+// marks it as failed
+func (m *MigrationScheduleController) cancelMigration(
+	migrationSchedule *stork_api.MigrationSchedule,
+	migration *stork_api.ScheduledMigrationStatus,
+) error {
+	err := k8s.Instance().DeleteMigration(migration.Name, migrationSchedule.Namespace)
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("error cancelling migration %v: %v", migration.Name, err)
+	}
+	migration.Status = stork_api.MigrationStatusFailed
+	migration.FinishTimestamp = meta.NewTime(schedule.GetCurrentTime())
+	log.MigrationScheduleLog(migrationSchedule).Infof("Cancelled migration %v", migration.Name)
+	return nil
+}
+
+func (m *MigrationScheduleController) skipMigration(
+	migrationSchedule *stork_api.MigrationSchedule,
+	policyType stork_api.SchedulePolicyType,
+	lastTrigger meta.Time,
+	reason string,
+) error {
+	skippedTriggers, updated := schedule.RecordSkippedTrigger(migrationSchedule.Status.SkippedTriggers, policyType, lastTrigger, reason)
+	if !updated {
+		return nil
+	}
+	migrationSchedule.Status.SkippedTriggers = skippedTriggers
+	msg := skippedTriggers[len(skippedTriggers)-1].Reason
+	m.Recorder.Event(migrationSchedule,
+		v1.EventTypeNormal,
+		"Skipped",
+		fmt.Sprintf("%v: %v", policyType, msg))
+	log.MigrationScheduleLog(migrationSchedule).Infof("%v: %v", policyType, msg)
+	return sdk.Update(migrationSchedule)
+}
+
+// updateClusterDomainSuspension suspends the schedule while any of the
+// cluster domains it depends on are inactive, and resumes it once they are
+// all active. The state of the cluster domains isn't changed if it can't be
+// checked.
+func (m *MigrationScheduleController) updateClusterDomainSuspension(migrationSchedule *stork_api.MigrationSchedule) error {
+	inactiveDomains, err := schedule.GetInactiveClusterDomains(migrationSchedule.Spec.ClusterDomains)
+	if err != nil {
+		return err
+	}
+	suspendedFor, suspensions, updated := schedule.RecordClusterDomainSuspension(
+		migrationSchedule.Status.SuspendedForClusterDomains,
+		migrationSchedule.Status.ClusterDomainSuspensions,
+		inactiveDomains)
+	if !updated {
+		return nil
+	}
+	migrationSchedule.Status.SuspendedForClusterDomains = suspendedFor
+	migrationSchedule.Status.ClusterDomainSuspensions = suspensions
+	if len(suspendedFor) > 0 {
+		msg := fmt.Sprintf("Schedule suspended since cluster domains %v are inactive", suspendedFor)
+		m.Recorder.Event(migrationSchedule, v1.EventTypeWarning, "Suspended", msg)
+		log.MigrationScheduleLog(migrationSchedule).Info(msg)
+	} else {
+		msg := "Schedule resumed since all the cluster domains it depends on are active"
+		m.Recorder.Event(migrationSchedule, v1.EventTypeNormal, "Resumed", msg)
+		log.MigrationScheduleLog(migrationSchedule).Info(msg)
+	}
+	return sdk.Update(migrationSchedule)
+}
+
 func (m *MigrationScheduleController) formatMigrationName(
 	migrationSchedule *stork_api.MigrationSchedule,
 	policyType stork_api.SchedulePolicyType,
@@ -231,6 +415,10 @@ func (m *MigrationScheduleController) startMigration(
 	policyType stork_api.SchedulePolicyType,
 ) error {
 	migrationName := m.formatMigrationName(migrationSchedule, policyType)
+	delay, err := schedule.GetTriggerDelay(migrationSchedule.Spec.SchedulePolicyName, migrationSchedule.Namespace, migrationSchedule.Name)
+	if err != nil {
+		return err
+	}
 	if migrationSchedule.Status.Items == nil {
 		migrationSchedule.Status.Items = make(map[stork_api.SchedulePolicyType][]*stork_api.ScheduledMigrationStatus)
 	}
@@ -239,11 +427,12 @@ func (m *MigrationScheduleController) startMigration(
 	}
 	migrationSchedule.Status.Items[policyType] = append(migrationSchedule.Status.Items[policyType],
 		&stork_api.ScheduledMigrationStatus{
-			Name:              migrationName,
-			CreationTimestamp: meta.NewTime(schedule.GetCurrentTime()),
-			Status:            stork_api.MigrationStatusPending,
+			Name:                migrationName,
+			CreationTimestamp:   meta.NewTime(schedule.GetCurrentTime()),
+			Status:              stork_api.MigrationStatusPending,
+			TriggerDelaySeconds: int64(delay / time.Second),
 		})
-	err := sdk.Update(migrationSchedule)
+	err = sdk.Update(migrationSchedule)
 	if err != nil {
 		return err
 	}
@@ -263,7 +452,7 @@ func (m *MigrationScheduleController) startMigration(
 		},
 		Spec: migrationSchedule.Spec.Template.Spec,
 	}
-	log.MigrationScheduleLog(migrationSchedule).Infof("Starting migration %v", migrationName)
+	log.MigrationScheduleLog(migrationSchedule).Infof("Starting migration %v with trigger delay %v", migrationName, delay)
 	_, err = k8s.Instance().CreateMigration(migration)
 	return err
 }
@@ -282,13 +471,20 @@ func (m *MigrationScheduleController) pruneMigrations(migrationSchedule *stork_a
 					break
 				}
 			}
+			// Migrations that are still in progress, for eg with the Allow
+			// concurrency policy, are kept
+			inProgress := make([]*stork_api.ScheduledMigrationStatus, 0)
 			for i := 0; i < deleteBefore; i++ {
+				if !m.isMigrationComplete(policyMigration[i].Status) {
+					inProgress = append(inProgress, policyMigration[i])
+					continue
+				}
 				err := k8s.Instance().DeleteMigration(policyMigration[i].Name, migrationSchedule.Namespace)
 				if err != nil {
 					log.MigrationScheduleLog(migrationSchedule).Warnf("Error deleting %v: %v", policyMigration[i].Name, err)
 				}
 			}
-			migrationSchedule.Status.Items[policyType] = policyMigration[deleteBefore:]
+			migrationSchedule.Status.Items[policyType] = append(inProgress, policyMigration[deleteBefore:]...)
 		}
 	}
 	return sdk.Update(migrationSchedule)
@@ -0,0 +1,97 @@
+package monitor
+
+import (
+	"time"
+
+	"github.com/libopenstorage/stork/drivers/volume"
+	"github.com/portworx/sched-ops/k8s"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/api/core/v1"
+)
+
+const (
+	// StorageOfflineTaint Taint added to nodes where the storage has been
+	// offline for longer than the cordon threshold so that the default
+	// scheduler also stops placing pods on them
+	StorageOfflineTaint = "stork.libopenstorage.org/storage-offline"
+
+	// cordonedAnnotation Annotation added to nodes cordoned by the monitor so
+	// that nodes cordoned by users aren't uncordoned
+	cordonedAnnotation = "stork.libopenstorage.org/cordoned"
+)
+
+// updateNodeCordons cordons and taints the nodes where the storage has been
+// offline for longer than the cordon threshold, and uncordons the nodes
+// cordoned by the monitor once the storage is back online
+func (m *Monitor) updateNodeCordons(driverNodes []*volume.NodeInfo) {
+	if m.CordonThresholdSec == 0 || len(driverNodes) == 0 {
+		return
+	}
+	k8sNodes, err := k8s.Instance().GetNodes()
+	if err != nil {
+		log.Errorf("Error getting nodes to update cordons: %v", err)
+		return
+	}
+	threshold := time.Duration(m.CordonThresholdSec) * time.Second
+	for i := range k8sNodes.Items {
+		k8sNode := &k8sNodes.Items[i]
+		for _, driverNode := range driverNodes {
+			if !volume.IsNodeMatch(k8sNode, driverNode) {
+				continue
+			}
+			_, cordoned := k8sNode.Annotations[cordonedAnnotation]
+			if driverNode.Status == volume.NodeOnline {
+				if cordoned {
+					m.uncordonNode(k8sNode)
+				}
+			} else if since, ok := m.offlineSince[driverNode.StorageID]; ok && !cordoned && time.Since(since) >= threshold {
+				m.cordonNode(k8sNode, driverNode)
+			}
+			break
+		}
+	}
+}
+
+func (m *Monitor) cordonNode(node *v1.Node, driverNode *volume.NodeInfo) {
+	if m.DryRun {
+		log.Infof("Dry run: Node %v would be cordoned since storage is %v", node.Name, driverNode.Status)
+		return
+	}
+	log.Infof("Cordoning node %v since storage has been %v for more than %v seconds",
+		node.Name, driverNode.Status, m.CordonThresholdSec)
+	if node.Annotations == nil {
+		node.Annotations = make(map[string]string)
+	}
+	// Only uncordon the node later if it wasn't already cordoned
+	node.Annotations[cordonedAnnotation] = "false"
+	if !node.Spec.Unschedulable {
+		node.Annotations[cordonedAnnotation] = "true"
+		node.Spec.Unschedulable = true
+	}
+	node.Spec.Taints = append(node.Spec.Taints, v1.Taint{
+		Key:    StorageOfflineTaint,
+		Value:  string(driverNode.Status),
+		Effect: v1.TaintEffectNoSchedule,
+	})
+	if _, err := k8s.Instance().UpdateNode(node); err != nil {
+		log.Errorf("Error cordoning node %v: %v", node.Name, err)
+	}
+}
+
+func (m *Monitor) uncordonNode(node *v1.Node) {
+	log.Infof("Uncordoning node %v since storage is back online", node.Name)
+	if node.Annotations[cordonedAnnotation] == "true" {
+		node.Spec.Unschedulable = false
+	}
+	delete(node.Annotations, cordonedAnnotation)
+	taints := make([]v1.Taint, 0, len(node.Spec.Taints))
+	for _, taint := range node.Spec.Taints {
+		if taint.Key != StorageOfflineTaint {
+			taints = append(taints, taint)
+		}
+	}
+	node.Spec.Taints = taints
+	if _, err := k8s.Instance().UpdateNode(node); err != nil {
+		log.Errorf("Error uncordoning node %v: %v", node.Name, err)
+	}
+}
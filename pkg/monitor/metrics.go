@@ -0,0 +1,74 @@
+package monitor
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	failoverReasonStorageOffline            = "storage-offline"
+	failoverReasonUnknownState              = "unknown-state"
+	failoverReasonSharedVolumeServerOffline = "shared-volume-server-offline"
+)
+
+var (
+	storageOfflineNodes = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "stork_monitor_storage_offline_nodes",
+			Help: "Number of nodes where the storage driver isn't online",
+		},
+	)
+	podsFailedOver = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "stork_monitor_pods_failed_over_total",
+			Help: "Number of pods deleted by the health monitor so that they can be rescheduled",
+		},
+		[]string{"reason"},
+	)
+	failoverDuration = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "stork_monitor_failover_duration_seconds",
+			Help:    "Time from the storage on a node being detected offline to pods on the node being deleted",
+			Buckets: []float64{30, 60, 120, 300, 600, 1200, 1800, 3600},
+		},
+	)
+	evictionFailures = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "stork_monitor_eviction_failures_total",
+			Help: "Number of times the health monitor failed to delete a pod",
+		},
+		[]string{"reason"},
+	)
+	stuckVolumeAttachments = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "stork_monitor_stuck_volume_attachments",
+			Help: "Number of volume attachments stuck attaching or detaching for longer than the threshold",
+		},
+		[]string{"state"},
+	)
+	volumeAttachmentRemediations = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "stork_monitor_volume_attachment_remediations_total",
+			Help: "Number of volumes force detached by the health monitor for stuck volume attachments",
+		},
+		[]string{"state"},
+	)
+	dryRunPodDeletions = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "stork_monitor_dry_run_pod_deletions_total",
+			Help: "Number of pods that would have been deleted by the health monitor in dry-run mode",
+		},
+		[]string{"reason"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(
+		storageOfflineNodes,
+		podsFailedOver,
+		failoverDuration,
+		evictionFailures,
+		stuckVolumeAttachments,
+		volumeAttachmentRemediations,
+		dryRunPodDeletions,
+	)
+}
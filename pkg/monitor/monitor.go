@@ -6,13 +6,19 @@ import (
 	"time"
 
 	"github.com/libopenstorage/stork/drivers/volume"
+	storkclientset "github.com/libopenstorage/stork/pkg/client/clientset/versioned"
+	storkerrors "github.com/libopenstorage/stork/pkg/errors"
 	storklog "github.com/libopenstorage/stork/pkg/log"
 	"github.com/portworx/sched-ops/k8s"
 	log "github.com/sirupsen/logrus"
 	"k8s.io/api/core/v1"
+	policy "k8s.io/api/policy/v1beta1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/kubernetes/pkg/scheduler/algorithm"
 	"k8s.io/kubernetes/pkg/util/node"
 )
@@ -20,16 +26,94 @@ import (
 const (
 	defaultIntervalSec = 120
 	minimumIntervalSec = 30
+	// driverRetryInterval Time to wait before retrying when nodes can't be
+	// fetched from the driver
+	driverRetryInterval = 2 * time.Second
+
+	// StorageStatusLabel Label added to nodes where the storage driver isn't
+	// online, with the status of the driver on the node as the value. New pods
+	// using volumes from the driver aren't scheduled on these nodes
+	StorageStatusLabel = "stork.libopenstorage.org/storage-status"
+
+	dryRunEventReason         = "DryRunPodDeletion"
+	failoverEventReason       = "FailingOverPod"
+	failoverFailedEventReason = "FailedToFailOverPod"
 )
 
 // Monitor Storage driver monitor
 type Monitor struct {
-	Driver      volume.Driver
+	Driver volume.Driver
+	// IntervalSec is the interval in seconds at which the status of the
+	// storage nodes is checked
 	IntervalSec int64
-	lock        sync.Mutex
-	started     bool
-	stopChannel chan int
-	done        chan int
+	// GracePeriodSec is the time in seconds for which the storage on a node
+	// needs to be offline before pods using it are deleted. Pods are deleted
+	// as soon as the storage is detected offline if this is 0
+	GracePeriodSec int64
+	// EvictionBackoffSec is the minimum time in seconds before a pod with the
+	// same name is deleted again, for eg if a StatefulSet pod gets recreated
+	// on the same node
+	EvictionBackoffSec int64
+	// UseEvictionAPI if set, pods are deleted using the eviction API so that
+	// PodDisruptionBudgets are honored, instead of being force deleted
+	UseEvictionAPI bool
+	// EvictionTimeoutSec is the time in seconds after which pods that
+	// couldn't be evicted or are still terminating are force deleted when
+	// using the eviction API. Pods are never force deleted if this is 0
+	EvictionTimeoutSec int64
+	// KubeClient is the client used to evict pods
+	KubeClient kubernetes.Interface
+	// ForceDetachVolumes if set, ReadWriteOnce volumes that are still
+	// attached to a node with offline storage are force detached through the
+	// driver once the pods using them have been deleted
+	ForceDetachVolumes bool
+	// FailoverSharedVolumes if set, shared volumes served by a node where the
+	// storage is offline are force detached so that the driver serves them
+	// from another node, and only the pods on other nodes using those volumes
+	// are restarted so that they remount them
+	FailoverSharedVolumes bool
+	// PublishNodeStorageStatus if set, the status of the storage on each node
+	// is published as a NodeStorageStatus object
+	PublishNodeStorageStatus bool
+	// StorkClient is the client used to publish NodeStorageStatus objects
+	StorkClient storkclientset.Interface
+	// CordonThresholdSec is the time in seconds for which the storage on a
+	// node needs to be offline before the node is cordoned and tainted. Nodes
+	// aren't cordoned if this is 0
+	CordonThresholdSec int64
+	// StuckAttachmentThresholdSec is the time in seconds after which volume
+	// attachments that haven't been attached or detached are reported as
+	// stuck. Volume attachments aren't checked if this is 0
+	StuckAttachmentThresholdSec int64
+	// RemediateStuckAttachments if set, volumes for stuck volume attachments
+	// are force detached through the driver if they are attached to a node
+	// where the storage isn't online
+	RemediateStuckAttachments bool
+	// Namespaces if set, only pods in these namespaces are deleted by the
+	// monitor
+	Namespaces []string
+	// PodSelector if set, only pods matching this label selector are deleted
+	// by the monitor
+	PodSelector string
+	// DryRun if set, pods that would be deleted by the monitor are only
+	// reported with events and metrics without being deleted
+	DryRun bool
+	// Recorder is used to raise events on pods
+	Recorder record.EventRecorder
+	// NodeStatusChangeHandler is called when the status of any of the storage
+	// nodes changes
+	NodeStatusChangeHandler func()
+	lock                    sync.Mutex
+	started                 bool
+	stopChannel             chan int
+	done                    chan int
+	nodeStatus              map[string]volume.NodeStatus
+	offlineSince            map[string]time.Time
+	evictedPods             map[string]time.Time
+	evictionAttempts        map[string]time.Time
+	pendingDetaches         map[string]pendingDetach
+	sharedVolumeFailovers   map[string]string
+	podSelector             labels.Selector
 }
 
 // Start Starts the monitor
@@ -46,6 +130,48 @@ func (m *Monitor) Start() error {
 	} else if m.IntervalSec < minimumIntervalSec {
 		return fmt.Errorf("minimum interval for health monitor is %v seconds", minimumIntervalSec)
 	}
+	if m.GracePeriodSec < 0 {
+		return fmt.Errorf("grace period for health monitor can't be negative")
+	}
+	if m.EvictionBackoffSec < 0 {
+		return fmt.Errorf("eviction backoff for health monitor can't be negative")
+	}
+	if m.CordonThresholdSec < 0 {
+		return fmt.Errorf("cordon threshold for health monitor can't be negative")
+	}
+	if m.StuckAttachmentThresholdSec < 0 {
+		return fmt.Errorf("stuck attachment threshold for health monitor can't be negative")
+	}
+	if m.StuckAttachmentThresholdSec > 0 && m.KubeClient == nil {
+		return fmt.Errorf("kubernetes client is required to check volume attachments")
+	}
+	if m.EvictionTimeoutSec < 0 {
+		return fmt.Errorf("eviction timeout for health monitor can't be negative")
+	}
+	if m.UseEvictionAPI && m.KubeClient == nil {
+		return fmt.Errorf("kubernetes client is required to use the eviction API")
+	}
+	m.podSelector = labels.Everything()
+	if m.PodSelector != "" {
+		selector, err := labels.Parse(m.PodSelector)
+		if err != nil {
+			return fmt.Errorf("invalid pod selector %v for health monitor: %v", m.PodSelector, err)
+		}
+		m.podSelector = selector
+	}
+	if m.PublishNodeStorageStatus {
+		if m.StorkClient == nil {
+			return fmt.Errorf("stork client is required to publish node storage status")
+		}
+		if err := m.createNodeStorageStatusCRD(); err != nil {
+			return fmt.Errorf("error creating CRD for node storage status: %v", err)
+		}
+	}
+	m.offlineSince = make(map[string]time.Time)
+	m.evictedPods = make(map[string]time.Time)
+	m.evictionAttempts = make(map[string]time.Time)
+	m.pendingDetaches = make(map[string]pendingDetach)
+	m.sharedVolumeFailovers = make(map[string]string)
 
 	m.stopChannel = make(chan int)
 	m.done = make(chan int)
@@ -117,12 +243,17 @@ func (m *Monitor) podMonitor() error {
 			}
 		}
 
-		if podUnknownState {
-			owns, err := m.doesDriverOwnPodVolumes(pod)
+		if podUnknownState && m.isPodMonitored(pod) {
+			owns, err := m.doesDriverOwnPodVolumes(pod, nil)
 			if err != nil || !owns {
 				return nil
 			}
 
+			if m.DryRun {
+				m.recordDryRunDeletion(pod, failoverReasonUnknownState,
+					"Pod would be force deleted since it is in unknown state")
+				return nil
+			}
 			storklog.PodLog(pod).Infof("Force deleting pod as it's in unknown state.")
 
 			// force delete the pod
@@ -133,8 +264,10 @@ func (m *Monitor) podMonitor() error {
 				}
 
 				storklog.PodLog(pod).Errorf("Error deleting pod: %v", err)
+				m.recordFailoverFailure(pod, failoverReasonUnknownState, err)
 				return err
 			}
+			m.recordFailover(pod, failoverReasonUnknownState, "Force deleted pod since it is in unknown state")
 		}
 
 		return nil
@@ -158,34 +291,22 @@ func (m *Monitor) driverMonitor() {
 			nodes, err := m.Driver.GetNodes()
 			if err != nil {
 				log.Errorf("Error getting nodes: %v", err)
-				time.Sleep(2 * time.Second)
+				time.Sleep(driverRetryInterval)
 			}
+			m.checkNodeStatusChange(nodes)
+			m.updateStorageStatusLabels(nodes)
+			m.updateNodeStorageStatuses(nodes)
+			m.updateOfflineNodes(nodes)
+			m.updateNodeCordons(nodes)
+			m.checkVolumeAttachments(nodes)
+			m.pruneEvictedPods()
+			m.detachDeletedPodVolumes()
 			for _, node := range nodes {
 				// Check if nodes are reported online by the storage driver
-				// If not online, look at all the pods on that node
-				// For any Running pod on that node using volume by the driver, kill the pod
-				if node.Status != volume.NodeOnline {
-					pods, err := k8s.Instance().GetPods("", nil)
-					if err != nil {
-						log.Errorf("Error getting pods: %v", err)
-						continue
-					}
-					for _, pod := range pods.Items {
-						owns, err := m.doesDriverOwnPodVolumes(&pod)
-						if err != nil || !owns {
-							continue
-						}
-
-						if m.isSameNode(pod.Spec.NodeName, node) &&
-							(pod.Status.Phase == v1.PodRunning || pod.Status.Phase == v1.PodFailed) {
-							storklog.PodLog(&pod).Infof("Deleting Pod from Node: %v", pod.Spec.NodeName)
-							err = k8s.Instance().DeletePods([]v1.Pod{pod}, true)
-							if err != nil {
-								storklog.PodLog(&pod).Errorf("Error deleting pod: %v", err)
-								continue
-							}
-						}
-					}
+				// If not online for the grace period, delete the pods on that
+				// node using volumes from the driver
+				if node.Status != volume.NodeOnline && m.isGracePeriodExpired(node) {
+					m.evictPodsFromNode(node)
 				}
 			}
 			time.Sleep(time.Duration(m.IntervalSec) * time.Second)
@@ -195,13 +316,375 @@ func (m *Monitor) driverMonitor() {
 	}
 }
 
-func (m *Monitor) doesDriverOwnPodVolumes(pod *v1.Pod) (bool, error) {
+// evictPodsFromNode deletes all the Running or Failed pods on the node that
+// are using volumes from the driver, and fails over the shared volumes served
+// by the node if enabled
+func (m *Monitor) evictPodsFromNode(node *volume.NodeInfo) {
+	pods, err := k8s.Instance().GetPods("", nil)
+	if err != nil {
+		log.Errorf("Error getting pods: %v", err)
+		return
+	}
+	m.pruneEvictionAttempts(pods.Items)
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != v1.PodRunning && pod.Status.Phase != v1.PodFailed {
+			continue
+		}
+		if !m.isPodMonitored(&pod) {
+			continue
+		}
+		owns, err := m.doesDriverOwnPodVolumes(&pod, node)
+		if err != nil || !owns {
+			continue
+		}
+
+		if m.isSameNode(pod.Spec.NodeName, node) {
+			if m.isInEvictionBackoff(&pod) {
+				storklog.PodLog(&pod).Infof("Not deleting pod from node %v since it was deleted in the last %v seconds",
+					pod.Spec.NodeName, m.EvictionBackoffSec)
+				continue
+			}
+			if m.DryRun {
+				m.recordDryRunDeletion(&pod, failoverReasonStorageOffline,
+					fmt.Sprintf("Pod would be deleted since storage on node %v is %v", pod.Spec.NodeName, node.Status))
+				continue
+			}
+			storklog.PodLog(&pod).Infof("Deleting Pod from Node: %v", pod.Spec.NodeName)
+			deleted, err := m.deletePod(&pod)
+			if err != nil {
+				storklog.PodLog(&pod).Errorf("Error deleting pod: %v", err)
+				m.recordFailoverFailure(&pod, failoverReasonStorageOffline, err)
+				continue
+			}
+			if deleted {
+				m.evictedPods[pod.Namespace+"/"+pod.Name] = time.Now()
+				if m.ForceDetachVolumes {
+					m.pendingDetaches[pod.Namespace+"/"+pod.Name] = pendingDetach{pod: pod, node: node}
+				}
+				m.recordFailover(&pod, failoverReasonStorageOffline,
+					fmt.Sprintf("Deleted pod since storage on node %v is %v", pod.Spec.NodeName, node.Status))
+				if since, ok := m.offlineSince[node.StorageID]; ok {
+					failoverDuration.Observe(time.Since(since).Seconds())
+				}
+			}
+		}
+	}
+	if m.ForceDetachVolumes {
+		m.detachDeletedPodVolumes()
+	}
+	if m.FailoverSharedVolumes {
+		m.failoverSharedVolumes(node, pods.Items)
+	}
+}
+
+// pendingDetach is a pod that was deleted from a node where the storage is
+// offline, whose volumes need to be detached once it has terminated
+type pendingDetach struct {
+	pod  v1.Pod
+	node *volume.NodeInfo
+}
+
+// detachDeletedPodVolumes force detaches the volumes of the pods deleted from
+// nodes with offline storage once the pods don't exist anymore. Volumes are
+// never detached while the pod could still be using them, for eg while an
+// evicted pod is terminating. Pods are dropped if the storage on the node has
+// come back online.
+func (m *Monitor) detachDeletedPodVolumes() {
+	for key, pending := range m.pendingDetaches {
+		pod := &pending.pod
+		if _, ok := m.offlineSince[pending.node.StorageID]; !ok {
+			delete(m.pendingDetaches, key)
+			continue
+		}
+		if _, err := k8s.Instance().GetPodByUID(pod.UID, pod.Namespace); err == nil {
+			storklog.PodLog(pod).Debugf("Waiting for pod to be deleted before detaching volumes")
+			continue
+		} else if err != k8s.ErrPodsNotFound {
+			storklog.PodLog(pod).Errorf("Error getting pod: %v", err)
+			continue
+		}
+		if err := m.detachPodVolumes(pod, pending.node); err != nil {
+			storklog.PodLog(pod).Errorf("Error detaching volumes from node %v, will retry: %v",
+				pod.Spec.NodeName, err)
+			m.recordFailoverFailure(pod, failoverReasonStorageOffline,
+				fmt.Errorf("error detaching volumes: %v", err))
+			continue
+		}
+		delete(m.pendingDetaches, key)
+	}
+}
+
+// isPodMonitored returns true if the pod can be deleted by the monitor based
+// on the namespaces and pod selector that are being monitored
+func (m *Monitor) isPodMonitored(pod *v1.Pod) bool {
+	if len(m.Namespaces) > 0 {
+		found := false
+		for _, namespace := range m.Namespaces {
+			if namespace == pod.Namespace {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return m.podSelector.Matches(labels.Set(pod.Labels))
+}
+
+// detachPodVolumes force detaches the volumes used by the pod that are still
+// attached to the node, so that they can be attached on the node where the pod
+// is rescheduled without multi-attach errors. Shared volumes are skipped since
+// they can be attached to multiple nodes.
+func (m *Monitor) detachPodVolumes(pod *v1.Pod, node *volume.NodeInfo) error {
+	volumes, err := m.Driver.GetPodVolumes(&pod.Spec, pod.Namespace)
+	if err != nil {
+		return err
+	}
+	for _, volumeInfo := range volumes {
+		if volumeInfo.Shared || volumeInfo.Driver != node.Driver {
+			continue
+		}
+		attachedNode, err := m.Driver.GetVolumeAttachedNode(volumeInfo)
+		if err != nil {
+			if _, ok := err.(*storkerrors.ErrNotSupported); ok {
+				return nil
+			}
+			return err
+		}
+		if attachedNode != node.StorageID {
+			continue
+		}
+		storklog.PodLog(pod).Infof("Force detaching volume %v from node %v", volumeInfo.VolumeName, pod.Spec.NodeName)
+		if err := m.Driver.ForceDetachVolume(volumeInfo); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordDryRunDeletion reports a pod that would have been deleted if the
+// monitor wasn't running in dry-run mode
+func (m *Monitor) recordDryRunDeletion(pod *v1.Pod, reason string, msg string) {
+	storklog.PodLog(pod).Infof("Dry run: %v", msg)
+	dryRunPodDeletions.WithLabelValues(reason).Inc()
+	m.recordEvent(pod, dryRunEventReason, msg)
+}
+
+// recordFailover reports a pod that was deleted so that it can be rescheduled
+func (m *Monitor) recordFailover(pod *v1.Pod, reason string, msg string) {
+	podsFailedOver.WithLabelValues(reason).Inc()
+	m.recordEvent(pod, failoverEventReason, msg)
+}
+
+// recordFailoverFailure reports a pod that couldn't be deleted
+func (m *Monitor) recordFailoverFailure(pod *v1.Pod, reason string, err error) {
+	evictionFailures.WithLabelValues(reason).Inc()
+	m.recordEvent(pod, failoverFailedEventReason, fmt.Sprintf("Error failing over pod: %v", err))
+}
+
+func (m *Monitor) recordEvent(pod *v1.Pod, reason string, msg string) {
+	if m.Recorder != nil {
+		m.Recorder.Event(pod, v1.EventTypeWarning, reason, msg)
+	}
+}
+
+// deletePod deletes the pod from a node where the storage is offline. When
+// using the eviction API, pods are only force deleted if they couldn't be
+// evicted or haven't terminated within the eviction timeout. Returns false if
+// the pod hasn't been deleted yet.
+func (m *Monitor) deletePod(pod *v1.Pod) (bool, error) {
+	if !m.UseEvictionAPI {
+		return true, k8s.Instance().DeletePods([]v1.Pod{*pod}, true)
+	}
+
+	key := pod.Namespace + "/" + pod.Name
+	firstAttempt, ok := m.evictionAttempts[key]
+	if !ok {
+		firstAttempt = time.Now()
+		m.evictionAttempts[key] = firstAttempt
+	}
+	if m.EvictionTimeoutSec > 0 && time.Since(firstAttempt) >= time.Duration(m.EvictionTimeoutSec)*time.Second {
+		storklog.PodLog(pod).Warnf("Force deleting pod since it couldn't be evicted in %v seconds", m.EvictionTimeoutSec)
+		if err := k8s.Instance().DeletePods([]v1.Pod{*pod}, true); err != nil {
+			return false, err
+		}
+		delete(m.evictionAttempts, key)
+		return true, nil
+	}
+	if pod.DeletionTimestamp != nil {
+		storklog.PodLog(pod).Infof("Waiting for evicted pod to terminate")
+		return false, nil
+	}
+
+	err := m.KubeClient.CoreV1().Pods(pod.Namespace).Evict(&policy.Eviction{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pod.Name,
+			Namespace: pod.Namespace,
+		},
+	})
+	if err != nil {
+		if errors.IsTooManyRequests(err) {
+			storklog.PodLog(pod).Infof("Eviction blocked by PodDisruptionBudget, will retry: %v", err)
+			return false, nil
+		}
+		return false, err
+	}
+	delete(m.evictionAttempts, key)
+	return true, nil
+}
+
+// pruneEvictionAttempts removes the eviction attempts for pods that don't
+// exist anymore
+func (m *Monitor) pruneEvictionAttempts(pods []v1.Pod) {
+	if len(m.evictionAttempts) == 0 {
+		return
+	}
+	existingPods := make(map[string]bool)
+	for _, pod := range pods {
+		existingPods[pod.Namespace+"/"+pod.Name] = true
+	}
+	for key := range m.evictionAttempts {
+		if !existingPods[key] {
+			delete(m.evictionAttempts, key)
+		}
+	}
+}
+
+// updateOfflineNodes records the time since which the storage on each node
+// has been offline
+func (m *Monitor) updateOfflineNodes(nodes []*volume.NodeInfo) {
+	if len(nodes) == 0 {
+		return
+	}
+	offlineSince := make(map[string]time.Time)
+	for _, node := range nodes {
+		if node.Status == volume.NodeOnline {
+			continue
+		}
+		if since, ok := m.offlineSince[node.StorageID]; ok {
+			offlineSince[node.StorageID] = since
+		} else {
+			offlineSince[node.StorageID] = time.Now()
+		}
+	}
+	m.offlineSince = offlineSince
+	storageOfflineNodes.Set(float64(len(offlineSince)))
+}
+
+// isGracePeriodExpired returns true if the storage on the node has been
+// offline for longer than the grace period
+func (m *Monitor) isGracePeriodExpired(node *volume.NodeInfo) bool {
+	since, ok := m.offlineSince[node.StorageID]
+	if !ok {
+		return m.GracePeriodSec == 0
+	}
+	gracePeriod := time.Duration(m.GracePeriodSec) * time.Second
+	if time.Since(since) < gracePeriod {
+		log.Infof("Storage on node %v has been %v for %v, waiting for grace period of %v before deleting pods",
+			node.Hostname, node.Status, time.Since(since).Round(time.Second), gracePeriod)
+		return false
+	}
+	return true
+}
+
+// isInEvictionBackoff returns true if a pod with the same name was deleted
+// within the eviction backoff
+func (m *Monitor) isInEvictionBackoff(pod *v1.Pod) bool {
+	evictedAt, ok := m.evictedPods[pod.Namespace+"/"+pod.Name]
+	return ok && time.Since(evictedAt) < time.Duration(m.EvictionBackoffSec)*time.Second
+}
+
+// pruneEvictedPods removes the pods for which the eviction backoff has
+// expired
+func (m *Monitor) pruneEvictedPods() {
+	for name, evictedAt := range m.evictedPods {
+		if time.Since(evictedAt) >= time.Duration(m.EvictionBackoffSec)*time.Second {
+			delete(m.evictedPods, name)
+		}
+	}
+}
+
+// checkNodeStatusChange calls the handler if the status of any node has
+// changed since the last time it was checked
+func (m *Monitor) checkNodeStatusChange(nodes []*volume.NodeInfo) {
+	if len(nodes) == 0 {
+		return
+	}
+	changed := false
+	nodeStatus := make(map[string]volume.NodeStatus)
+	for _, node := range nodes {
+		nodeStatus[node.StorageID] = node.Status
+		if status, ok := m.nodeStatus[node.StorageID]; ok && status != node.Status {
+			log.Infof("Status of node %v changed from %v to %v", node.Hostname, status, node.Status)
+			changed = true
+		}
+	}
+	if m.nodeStatus != nil && len(m.nodeStatus) != len(nodeStatus) {
+		changed = true
+	}
+	m.nodeStatus = nodeStatus
+	if changed && m.NodeStatusChangeHandler != nil {
+		m.NodeStatusChangeHandler()
+	}
+}
+
+// updateStorageStatusLabels adds the storage status label to the nodes where
+// the driver isn't online and removes it from the nodes where it is back
+// online
+func (m *Monitor) updateStorageStatusLabels(driverNodes []*volume.NodeInfo) {
+	if len(driverNodes) == 0 {
+		return
+	}
+	k8sNodes, err := k8s.Instance().GetNodes()
+	if err != nil {
+		log.Errorf("Error getting nodes to update storage status: %v", err)
+		return
+	}
+	for _, k8sNode := range k8sNodes.Items {
+		for _, driverNode := range driverNodes {
+			if !volume.IsNodeMatch(&k8sNode, driverNode) {
+				continue
+			}
+			status, labeled := k8sNode.Labels[StorageStatusLabel]
+			if driverNode.Status == volume.NodeOnline {
+				if labeled {
+					log.Infof("Removing storage status label from node %v", k8sNode.Name)
+					if err := k8s.Instance().RemoveLabelOnNode(k8sNode.Name, StorageStatusLabel); err != nil {
+						log.Errorf("Error removing storage status label from node %v: %v", k8sNode.Name, err)
+					}
+				}
+			} else if status != string(driverNode.Status) {
+				log.Infof("Setting storage status label on node %v to %v", k8sNode.Name, driverNode.Status)
+				if err := k8s.Instance().AddLabelOnNode(k8sNode.Name, StorageStatusLabel, string(driverNode.Status)); err != nil {
+					log.Errorf("Error setting storage status label on node %v: %v", k8sNode.Name, err)
+				}
+			}
+			break
+		}
+	}
+}
+
+// doesDriverOwnPodVolumes returns true if the pod is using volumes from the
+// driver. If the node is set, only the volumes from the same driver as the
+// node are considered when multiple drivers are used.
+func (m *Monitor) doesDriverOwnPodVolumes(pod *v1.Pod, node *volume.NodeInfo) (bool, error) {
 	volumes, err := m.Driver.GetPodVolumes(&pod.Spec, pod.Namespace)
 	if err != nil {
 		storklog.PodLog(pod).Errorf("Error getting volumes for pod: %v", err)
 		return false, err
 	}
 
+	if node != nil {
+		nodeVolumes := make([]*volume.Info, 0)
+		for _, volumeInfo := range volumes {
+			if volumeInfo.Driver == node.Driver {
+				nodeVolumes = append(nodeVolumes, volumeInfo)
+			}
+		}
+		volumes = nodeVolumes
+	}
+
 	if len(volumes) == 0 {
 		storklog.PodLog(pod).Debugf("Pod doesn't have any volumes by driver")
 		return false, nil
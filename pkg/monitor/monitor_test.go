@@ -11,12 +11,19 @@ import (
 	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
 	fakeclient "github.com/libopenstorage/stork/pkg/client/clientset/versioned/fake"
 	"github.com/portworx/sched-ops/k8s"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/require"
 	"k8s.io/api/core/v1"
+	storagev1beta1 "k8s.io/api/storage/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	kubernetes "k8s.io/client-go/kubernetes/fake"
+	core "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/kubernetes/pkg/scheduler/algorithm"
 	"k8s.io/kubernetes/pkg/util/node"
 )
@@ -29,6 +36,7 @@ const (
 
 var (
 	fakeStorkClient *fakeclient.Clientset
+	fakeKubeClient  *kubernetes.Clientset
 	driver          *mock.Driver
 	monitor         *Monitor
 )
@@ -39,6 +47,18 @@ func TestMonitor(t *testing.T) {
 	t.Run("testUnknownOtherDriverPod", testUnknownOtherDriverPod)
 	t.Run("testEvictedDriverPod", testEvictedDriverPod)
 	t.Run("testEvictedOtherDriverPod", testEvictedOtherDriverPod)
+	t.Run("testStorageStatusLabels", testStorageStatusLabels)
+	t.Run("testGracePeriod", testGracePeriod)
+	t.Run("testEvictionBackoff", testEvictionBackoff)
+	t.Run("testEvictionAPI", testEvictionAPI)
+	t.Run("testDryRun", testDryRun)
+	t.Run("testForceDetach", testForceDetach)
+	t.Run("testSharedVolumeFailover", testSharedVolumeFailover)
+	t.Run("testFailoverEventsAndMetrics", testFailoverEventsAndMetrics)
+	t.Run("testNodeStorageStatus", testNodeStorageStatus)
+	t.Run("testSelectiveMonitoring", testSelectiveMonitoring)
+	t.Run("testCordon", testCordon)
+	t.Run("testStuckVolumeAttachments", testStuckVolumeAttachments)
 }
 
 func setup(t *testing.T) {
@@ -48,7 +68,7 @@ func setup(t *testing.T) {
 	require.NoError(t, err, "Error adding stork scheme")
 
 	fakeStorkClient = fakeclient.NewSimpleClientset()
-	fakeKubeClient := kubernetes.NewSimpleClientset()
+	fakeKubeClient = kubernetes.NewSimpleClientset()
 
 	k8s.Instance().SetClient(fakeKubeClient, nil, fakeStorkClient, nil, nil, nil)
 
@@ -63,7 +83,8 @@ func setup(t *testing.T) {
 	require.NoError(t, err, "Error initializing mock volume driver")
 
 	monitor = &Monitor{
-		Driver: storkdriver,
+		Driver:     storkdriver,
+		KubeClient: fakeKubeClient,
 	}
 
 	err = monitor.Start()
@@ -229,3 +250,572 @@ func newNode(name, hostname, ip, rack, zone, region string) *v1.Node {
 
 	return &node
 }
+
+func testStorageStatusLabels(t *testing.T) {
+	err := driver.UpdateNodeStatus(1, volume.NodeOffline)
+	require.NoError(t, err, "Error setting node status to Offline")
+	defer func() {
+		err = driver.UpdateNodeStatus(1, volume.NodeOnline)
+		require.NoError(t, err, "Error setting node status to Online")
+	}()
+
+	driverNodes, err := driver.GetNodes()
+	require.NoError(t, err, "Error getting driver nodes")
+	monitor.updateStorageStatusLabels(driverNodes)
+
+	labels, err := k8s.Instance().GetLabelsOnNode("node2.domain")
+	require.NoError(t, err, "Error getting labels on node")
+	require.Equal(t, string(volume.NodeOffline), labels[StorageStatusLabel])
+	labels, err = k8s.Instance().GetLabelsOnNode(nodeForPod)
+	require.NoError(t, err, "Error getting labels on node")
+	require.NotContains(t, labels, StorageStatusLabel)
+
+	err = driver.UpdateNodeStatus(1, volume.NodeOnline)
+	require.NoError(t, err, "Error setting node status to Online")
+	monitor.updateStorageStatusLabels(driverNodes)
+	labels, err = k8s.Instance().GetLabelsOnNode("node2.domain")
+	require.NoError(t, err, "Error getting labels on node")
+	require.NotContains(t, labels, StorageStatusLabel)
+}
+
+func testGracePeriod(t *testing.T) {
+	monitor.GracePeriodSec = 60
+	defer func() {
+		monitor.GracePeriodSec = 0
+	}()
+	driverNodes, err := driver.GetNodes()
+	require.NoError(t, err, "Error getting driver nodes")
+
+	err = driver.UpdateNodeStatus(1, volume.NodeOffline)
+	require.NoError(t, err, "Error setting node status to Offline")
+	monitor.updateOfflineNodes(driverNodes)
+	require.False(t, monitor.isGracePeriodExpired(driverNodes[1]), "Grace period shouldn't have expired")
+
+	monitor.offlineSince[driverNodes[1].StorageID] = time.Now().Add(-2 * time.Minute)
+	monitor.updateOfflineNodes(driverNodes)
+	require.True(t, monitor.isGracePeriodExpired(driverNodes[1]), "Grace period should have expired")
+
+	err = driver.UpdateNodeStatus(1, volume.NodeOnline)
+	require.NoError(t, err, "Error setting node status to Online")
+	monitor.updateOfflineNodes(driverNodes)
+	require.NotContains(t, monitor.offlineSince, driverNodes[1].StorageID)
+}
+
+func testEvictionBackoff(t *testing.T) {
+	monitor.EvictionBackoffSec = 60
+	defer func() {
+		monitor.EvictionBackoffSec = 0
+	}()
+	driverNodes, err := driver.GetNodes()
+	require.NoError(t, err, "Error getting driver nodes")
+
+	pod := newPod("backoffPod", []string{driverVolumeName})
+	pod.Spec.NodeName = "node2.domain"
+	pod.Status.Phase = v1.PodRunning
+	_, err = k8s.Instance().CreatePod(pod)
+	require.NoError(t, err, "failed to create pod")
+
+	monitor.evictPodsFromNode(driverNodes[1])
+	_, err = k8s.Instance().GetPodByName(pod.Name, "")
+	require.Error(t, err, "expected error from get pod as pod should be deleted")
+
+	// The recreated pod shouldn't be deleted again within the backoff
+	_, err = k8s.Instance().CreatePod(pod)
+	require.NoError(t, err, "failed to create pod")
+	monitor.evictPodsFromNode(driverNodes[1])
+	_, err = k8s.Instance().GetPodByName(pod.Name, "")
+	require.NoError(t, err, "failed to get pod")
+
+	monitor.evictedPods["/"+pod.Name] = time.Now().Add(-2 * time.Minute)
+	monitor.pruneEvictedPods()
+	require.Empty(t, monitor.evictedPods)
+	monitor.evictPodsFromNode(driverNodes[1])
+	_, err = k8s.Instance().GetPodByName(pod.Name, "")
+	require.Error(t, err, "expected error from get pod as pod should be deleted")
+}
+
+func testEvictionAPI(t *testing.T) {
+	monitor.UseEvictionAPI = true
+	monitor.EvictionTimeoutSec = 60
+	defer func() {
+		monitor.UseEvictionAPI = false
+		monitor.EvictionTimeoutSec = 0
+	}()
+	driverNodes, err := driver.GetNodes()
+	require.NoError(t, err, "Error getting driver nodes")
+
+	// Evictions are blocked until the disruption budget allows them
+	evictionBlocked := true
+	fakeKubeClient.PrependReactor("create", "pods", func(action core.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "eviction" {
+			return false, nil, nil
+		}
+		if evictionBlocked {
+			return true, nil, errors.NewTooManyRequests("Cannot evict pod as it would violate the pod's disruption budget", 0)
+		}
+		return true, nil, nil
+	})
+
+	pod := newPod("evictionPod", []string{driverVolumeName})
+	pod.Spec.NodeName = "node2.domain"
+	pod.Status.Phase = v1.PodRunning
+	_, err = k8s.Instance().CreatePod(pod)
+	require.NoError(t, err, "failed to create pod")
+
+	monitor.evictPodsFromNode(driverNodes[1])
+	_, err = k8s.Instance().GetPodByName(pod.Name, "")
+	require.NoError(t, err, "pod shouldn't be deleted when eviction is blocked")
+	require.Contains(t, monitor.evictionAttempts, "/"+pod.Name)
+	require.NotContains(t, monitor.evictedPods, "/"+pod.Name)
+
+	// Pod should be force deleted once the eviction timeout expires
+	monitor.evictionAttempts["/"+pod.Name] = time.Now().Add(-2 * time.Minute)
+	monitor.evictPodsFromNode(driverNodes[1])
+	_, err = k8s.Instance().GetPodByName(pod.Name, "")
+	require.Error(t, err, "expected error from get pod as pod should be deleted")
+	require.NotContains(t, monitor.evictionAttempts, "/"+pod.Name)
+
+	// Pod should be evicted once the disruption budget allows it
+	evictionBlocked = false
+	pod.Name = "evictionPod2"
+	_, err = k8s.Instance().CreatePod(pod)
+	require.NoError(t, err, "failed to create pod")
+	monitor.evictPodsFromNode(driverNodes[1])
+	require.Contains(t, monitor.evictedPods, "/"+pod.Name)
+	require.Empty(t, monitor.evictionAttempts)
+	err = k8s.Instance().DeletePods([]v1.Pod{*pod}, true)
+	require.NoError(t, err, "failed to delete pod")
+}
+
+func testDryRun(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	monitor.DryRun = true
+	monitor.Recorder = recorder
+	defer func() {
+		monitor.DryRun = false
+		monitor.Recorder = nil
+	}()
+	driverNodes, err := driver.GetNodes()
+	require.NoError(t, err, "Error getting driver nodes")
+
+	pod := newPod("dryRunPod", []string{driverVolumeName})
+	pod.Spec.NodeName = "node2.domain"
+	pod.Status.Phase = v1.PodRunning
+	_, err = k8s.Instance().CreatePod(pod)
+	require.NoError(t, err, "failed to create pod")
+
+	monitor.evictPodsFromNode(driverNodes[1])
+	_, err = k8s.Instance().GetPodByName(pod.Name, "")
+	require.NoError(t, err, "pod shouldn't be deleted in dry-run mode")
+	require.NotContains(t, monitor.evictedPods, "/"+pod.Name)
+	require.Len(t, recorder.Events, 1)
+	require.Contains(t, <-recorder.Events, dryRunEventReason)
+
+	err = k8s.Instance().DeletePods([]v1.Pod{*pod}, true)
+	require.NoError(t, err, "failed to delete pod")
+}
+
+func testForceDetach(t *testing.T) {
+	monitor.ForceDetachVolumes = true
+	defer func() {
+		monitor.ForceDetachVolumes = false
+	}()
+	driverNodes, err := driver.GetNodes()
+	require.NoError(t, err, "Error getting driver nodes")
+	volumeInfo, err := driver.InspectVolume(driverVolumeName)
+	require.NoError(t, err, "Error inspecting volume")
+
+	pod := newPod("detachPod", []string{driverVolumeName})
+	pod.UID = "detachPod"
+	pod.Spec.NodeName = "node2.domain"
+	pod.Status.Phase = v1.PodRunning
+	_, err = k8s.Instance().CreatePod(pod)
+	require.NoError(t, err, "failed to create pod")
+
+	// Volume attached to another node shouldn't be detached
+	err = driver.AttachVolume(driverVolumeName, 0)
+	require.NoError(t, err, "Error attaching volume")
+	err = monitor.detachPodVolumes(pod, driverNodes[1])
+	require.NoError(t, err, "Error detaching volumes")
+	attachedNode, err := driver.GetVolumeAttachedNode(volumeInfo)
+	require.NoError(t, err, "Error getting attached node")
+	require.Equal(t, driverNodes[0].StorageID, attachedNode)
+
+	// Volume should be detached once the pod has been force deleted
+	monitor.offlineSince[driverNodes[1].StorageID] = time.Now()
+	defer delete(monitor.offlineSince, driverNodes[1].StorageID)
+	err = driver.AttachVolume(driverVolumeName, 1)
+	require.NoError(t, err, "Error attaching volume")
+	monitor.evictPodsFromNode(driverNodes[1])
+	_, err = k8s.Instance().GetPodByName(pod.Name, "")
+	require.Error(t, err, "expected error from get pod as pod should be deleted")
+	attachedNode, err = driver.GetVolumeAttachedNode(volumeInfo)
+	require.NoError(t, err, "Error getting attached node")
+	require.Empty(t, attachedNode, "Volume should have been detached")
+	require.Empty(t, monitor.pendingDetaches)
+
+	// Volume shouldn't be detached while an evicted pod is terminating
+	monitor.UseEvictionAPI = true
+	defer func() {
+		monitor.UseEvictionAPI = false
+	}()
+	fakeKubeClient.PrependReactor("create", "pods", func(action core.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "eviction" {
+			return false, nil, nil
+		}
+		return true, nil, nil
+	})
+	pod.Name = "detachEvictedPod"
+	pod.UID = "detachEvictedPod"
+	_, err = k8s.Instance().CreatePod(pod)
+	require.NoError(t, err, "failed to create pod")
+	err = driver.AttachVolume(driverVolumeName, 1)
+	require.NoError(t, err, "Error attaching volume")
+	monitor.evictPodsFromNode(driverNodes[1])
+	require.Contains(t, monitor.pendingDetaches, "/"+pod.Name)
+	attachedNode, err = driver.GetVolumeAttachedNode(volumeInfo)
+	require.NoError(t, err, "Error getting attached node")
+	require.Equal(t, driverNodes[1].StorageID, attachedNode, "Volume shouldn't be detached while pod exists")
+
+	err = k8s.Instance().DeletePods([]v1.Pod{*pod}, true)
+	require.NoError(t, err, "failed to delete pod")
+	monitor.detachDeletedPodVolumes()
+	attachedNode, err = driver.GetVolumeAttachedNode(volumeInfo)
+	require.NoError(t, err, "Error getting attached node")
+	require.Empty(t, attachedNode, "Volume should have been detached")
+	require.Empty(t, monitor.pendingDetaches)
+
+	// Pending detaches are dropped once the storage is back online
+	monitor.pendingDetaches["/"+pod.Name] = pendingDetach{pod: *pod, node: driverNodes[2]}
+	monitor.detachDeletedPodVolumes()
+	require.Empty(t, monitor.pendingDetaches)
+}
+
+func testSharedVolumeFailover(t *testing.T) {
+	monitor.FailoverSharedVolumes = true
+	driverNodes, err := driver.GetNodes()
+	require.NoError(t, err, "Error getting driver nodes")
+	monitor.offlineSince[driverNodes[1].StorageID] = time.Now()
+	defer func() {
+		monitor.FailoverSharedVolumes = false
+		delete(monitor.offlineSince, driverNodes[1].StorageID)
+	}()
+
+	sharedVolumeName := "sharedVolume"
+	err = driver.ProvisionVolume(sharedVolumeName, []int{1, 2}, 1)
+	require.NoError(t, err, "Error provisioning volume")
+	err = driver.SetSharedVolumeCoordinator(sharedVolumeName, 1)
+	require.NoError(t, err, "Error setting shared volume coordinator")
+	volumeInfo, err := driver.InspectVolume(sharedVolumeName)
+	require.NoError(t, err, "Error inspecting volume")
+
+	serverPod := newPod("sharedServerPod", []string{sharedVolumeName})
+	serverPod.Spec.NodeName = "node2.domain"
+	clientPod := newPod("sharedClientPod", []string{sharedVolumeName})
+	clientPod.Spec.NodeName = "node3.domain"
+	otherPod := newPod("sharedOtherPod", []string{driverVolumeName})
+	otherPod.Spec.NodeName = "node3.domain"
+	for _, pod := range []*v1.Pod{serverPod, clientPod, otherPod} {
+		pod.Status.Phase = v1.PodRunning
+		_, err = k8s.Instance().CreatePod(pod)
+		require.NoError(t, err, "failed to create pod")
+	}
+
+	// The volume should be moved off the node and only the pods using it
+	// should be restarted
+	monitor.evictPodsFromNode(driverNodes[1])
+	_, err = k8s.Instance().GetPodByName(serverPod.Name, "")
+	require.Error(t, err, "expected error from get pod as pod should be deleted")
+	_, err = k8s.Instance().GetPodByName(clientPod.Name, "")
+	require.Error(t, err, "expected error from get pod as pod should be deleted")
+	_, err = k8s.Instance().GetPodByName(otherPod.Name, "")
+	require.NoError(t, err, "pod not using the shared volume shouldn't be deleted")
+	coordinator, err := driver.GetSharedVolumeCoordinator(volumeInfo)
+	require.NoError(t, err, "Error getting shared volume coordinator")
+	require.Empty(t, coordinator, "Shared volume should have been moved")
+	require.Equal(t, driverNodes[1].StorageID, monitor.sharedVolumeFailovers[sharedVolumeName])
+
+	// The restarted pod shouldn't be deleted again even if the driver serves
+	// the volume from the same node
+	err = driver.SetSharedVolumeCoordinator(sharedVolumeName, 1)
+	require.NoError(t, err, "Error setting shared volume coordinator")
+	_, err = k8s.Instance().CreatePod(clientPod)
+	require.NoError(t, err, "failed to create pod")
+	monitor.evictPodsFromNode(driverNodes[1])
+	_, err = k8s.Instance().GetPodByName(clientPod.Name, "")
+	require.NoError(t, err, "pod shouldn't be restarted again")
+
+	// Failovers are forgotten once the node is back online
+	delete(monitor.offlineSince, driverNodes[1].StorageID)
+	monitor.failoverSharedVolumes(driverNodes[1], nil)
+	require.Empty(t, monitor.sharedVolumeFailovers)
+
+	err = k8s.Instance().DeletePods([]v1.Pod{*clientPod, *otherPod}, true)
+	require.NoError(t, err, "failed to delete pods")
+}
+
+func getCounterValue(t *testing.T, counter prometheus.Counter) float64 {
+	metric := &dto.Metric{}
+	require.NoError(t, counter.Write(metric), "Error reading counter")
+	return metric.GetCounter().GetValue()
+}
+
+func testFailoverEventsAndMetrics(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	monitor.Recorder = recorder
+	defer func() {
+		monitor.Recorder = nil
+	}()
+	err := driver.UpdateNodeStatus(1, volume.NodeOffline)
+	require.NoError(t, err, "Error setting node status to Offline")
+	defer func() {
+		err = driver.UpdateNodeStatus(1, volume.NodeOnline)
+		require.NoError(t, err, "Error setting node status to Online")
+		driverNodes, err := driver.GetNodes()
+		require.NoError(t, err, "Error getting driver nodes")
+		monitor.updateOfflineNodes(driverNodes)
+	}()
+	driverNodes, err := driver.GetNodes()
+	require.NoError(t, err, "Error getting driver nodes")
+	monitor.updateOfflineNodes(driverNodes)
+
+	metric := &dto.Metric{}
+	require.NoError(t, storageOfflineNodes.Write(metric), "Error reading gauge")
+	require.Equal(t, float64(1), metric.GetGauge().GetValue())
+
+	pod := newPod("eventPod", []string{driverVolumeName})
+	pod.Spec.NodeName = "node2.domain"
+	pod.Status.Phase = v1.PodRunning
+	_, err = k8s.Instance().CreatePod(pod)
+	require.NoError(t, err, "failed to create pod")
+
+	failedOver := getCounterValue(t, podsFailedOver.WithLabelValues(failoverReasonStorageOffline))
+	monitor.evictPodsFromNode(driverNodes[1])
+	_, err = k8s.Instance().GetPodByName(pod.Name, "")
+	require.Error(t, err, "expected error from get pod as pod should be deleted")
+	require.Equal(t, failedOver+1, getCounterValue(t, podsFailedOver.WithLabelValues(failoverReasonStorageOffline)))
+	require.Len(t, recorder.Events, 1)
+	require.Contains(t, <-recorder.Events, failoverEventReason)
+}
+
+func testNodeStorageStatus(t *testing.T) {
+	monitor.PublishNodeStorageStatus = true
+	monitor.StorkClient = fakeStorkClient
+	defer func() {
+		monitor.PublishNodeStorageStatus = false
+		monitor.StorkClient = nil
+	}()
+	client := fakeStorkClient.Stork().NodeStorageStatuses()
+	_, err := client.Create(&stork_api.NodeStorageStatus{
+		ObjectMeta: metav1.ObjectMeta{Name: "removednode.domain"},
+	})
+	require.NoError(t, err, "Error creating node storage status")
+
+	driverNodes, err := driver.GetNodes()
+	require.NoError(t, err, "Error getting driver nodes")
+	driverNodes[1].Pools = []*volume.StoragePoolInfo{{ID: "0", TotalCapacity: 100, FreeCapacity: 40}}
+	defer func() {
+		driverNodes[1].Pools = nil
+	}()
+	monitor.updateNodeStorageStatuses(driverNodes)
+
+	statusList, err := client.List(metav1.ListOptions{})
+	require.NoError(t, err, "Error listing node storage statuses")
+	require.Len(t, statusList.Items, 5)
+	status, err := client.Get("node2.domain", metav1.GetOptions{})
+	require.NoError(t, err, "Error getting node storage status")
+	require.Equal(t, driverNodes[1].StorageID, status.Status.StorageID)
+	require.Equal(t, stork_api.NodeStorageHealthOnline, status.Status.Health)
+	require.Equal(t, []stork_api.StoragePoolStatus{{ID: "0", TotalCapacity: 100, FreeCapacity: 40}}, status.Status.Pools)
+
+	transitionTime := metav1.NewTime(time.Now().Add(-time.Hour))
+	status.Status.LastTransitionTime = transitionTime
+	_, err = client.Update(status)
+	require.NoError(t, err, "Error updating node storage status")
+	monitor.updateNodeStorageStatuses(driverNodes)
+	status, err = client.Get("node2.domain", metav1.GetOptions{})
+	require.NoError(t, err, "Error getting node storage status")
+	require.True(t, transitionTime.Equal(&status.Status.LastTransitionTime), "Transition time shouldn't change")
+
+	err = driver.UpdateNodeStatus(1, volume.NodeOffline)
+	require.NoError(t, err, "Error setting node status to Offline")
+	defer func() {
+		err = driver.UpdateNodeStatus(1, volume.NodeOnline)
+		require.NoError(t, err, "Error setting node status to Online")
+	}()
+	monitor.updateNodeStorageStatuses(driverNodes)
+	status, err = client.Get("node2.domain", metav1.GetOptions{})
+	require.NoError(t, err, "Error getting node storage status")
+	require.Equal(t, stork_api.NodeStorageHealthOffline, status.Status.Health)
+	require.True(t, status.Status.LastTransitionTime.After(transitionTime.Time), "Transition time should be updated")
+}
+
+func testSelectiveMonitoring(t *testing.T) {
+	selector, err := labels.Parse("failover=enabled")
+	require.NoError(t, err, "Error parsing selector")
+	monitor.Namespaces = []string{"monitored"}
+	monitor.podSelector = selector
+	defer func() {
+		monitor.Namespaces = nil
+		monitor.podSelector = labels.Everything()
+	}()
+	driverNodes, err := driver.GetNodes()
+	require.NoError(t, err, "Error getting driver nodes")
+
+	otherNamespacePod := newPod("otherNamespacePod", []string{driverVolumeName})
+	otherNamespacePod.Labels = map[string]string{"failover": "enabled"}
+	unlabeledPod := newPod("unlabeledPod", []string{driverVolumeName})
+	unlabeledPod.Namespace = "monitored"
+	monitoredPod := newPod("monitoredPod", []string{driverVolumeName})
+	monitoredPod.Namespace = "monitored"
+	monitoredPod.Labels = map[string]string{"failover": "enabled"}
+	for _, pod := range []*v1.Pod{otherNamespacePod, unlabeledPod, monitoredPod} {
+		pod.Spec.NodeName = "node2.domain"
+		pod.Status.Phase = v1.PodRunning
+		_, err = k8s.Instance().CreatePod(pod)
+		require.NoError(t, err, "failed to create pod")
+	}
+
+	monitor.evictPodsFromNode(driverNodes[1])
+	_, err = k8s.Instance().GetPodByName(otherNamespacePod.Name, otherNamespacePod.Namespace)
+	require.NoError(t, err, "pod in other namespace shouldn't be deleted")
+	_, err = k8s.Instance().GetPodByName(unlabeledPod.Name, unlabeledPod.Namespace)
+	require.NoError(t, err, "pod without label shouldn't be deleted")
+	_, err = k8s.Instance().GetPodByName(monitoredPod.Name, monitoredPod.Namespace)
+	require.Error(t, err, "expected error from get pod as pod should be deleted")
+
+	err = k8s.Instance().DeletePods([]v1.Pod{*otherNamespacePod, *unlabeledPod}, true)
+	require.NoError(t, err, "failed to delete pods")
+}
+
+func hasStorageOfflineTaint(node *v1.Node) bool {
+	for _, taint := range node.Spec.Taints {
+		if taint.Key == StorageOfflineTaint {
+			return true
+		}
+	}
+	return false
+}
+
+func testCordon(t *testing.T) {
+	monitor.CordonThresholdSec = 60
+	defer func() {
+		monitor.CordonThresholdSec = 0
+	}()
+	driverNodes, err := driver.GetNodes()
+	require.NoError(t, err, "Error getting driver nodes")
+
+	// Node 3 was already cordoned by the user and shouldn't be uncordoned
+	node, err := k8s.Instance().GetNodeByName("node3.domain")
+	require.NoError(t, err, "Error getting node")
+	node.Spec.Unschedulable = true
+	_, err = k8s.Instance().UpdateNode(node)
+	require.NoError(t, err, "Error updating node")
+
+	for _, index := range []int{1, 2} {
+		err = driver.UpdateNodeStatus(index, volume.NodeOffline)
+		require.NoError(t, err, "Error setting node status to Offline")
+	}
+	monitor.updateOfflineNodes(driverNodes)
+	monitor.updateNodeCordons(driverNodes)
+	node, err = k8s.Instance().GetNodeByName("node2.domain")
+	require.NoError(t, err, "Error getting node")
+	require.False(t, node.Spec.Unschedulable, "Node shouldn't be cordoned before the threshold")
+
+	monitor.offlineSince[driverNodes[1].StorageID] = time.Now().Add(-2 * time.Minute)
+	monitor.offlineSince[driverNodes[2].StorageID] = time.Now().Add(-2 * time.Minute)
+	monitor.updateNodeCordons(driverNodes)
+	for _, name := range []string{"node2.domain", "node3.domain"} {
+		node, err = k8s.Instance().GetNodeByName(name)
+		require.NoError(t, err, "Error getting node")
+		require.True(t, node.Spec.Unschedulable, "Node should be cordoned")
+		require.True(t, hasStorageOfflineTaint(node), "Node should be tainted")
+	}
+
+	for _, index := range []int{1, 2} {
+		err = driver.UpdateNodeStatus(index, volume.NodeOnline)
+		require.NoError(t, err, "Error setting node status to Online")
+	}
+	monitor.updateOfflineNodes(driverNodes)
+	monitor.updateNodeCordons(driverNodes)
+	node, err = k8s.Instance().GetNodeByName("node2.domain")
+	require.NoError(t, err, "Error getting node")
+	require.False(t, node.Spec.Unschedulable, "Node should be uncordoned")
+	require.False(t, hasStorageOfflineTaint(node), "Taint should be removed")
+	node, err = k8s.Instance().GetNodeByName("node3.domain")
+	require.NoError(t, err, "Error getting node")
+	require.True(t, node.Spec.Unschedulable, "Node cordoned by user shouldn't be uncordoned")
+	require.False(t, hasStorageOfflineTaint(node), "Taint should be removed")
+
+	node.Spec.Unschedulable = false
+	_, err = k8s.Instance().UpdateNode(node)
+	require.NoError(t, err, "Error updating node")
+}
+
+func testStuckVolumeAttachments(t *testing.T) {
+	monitor.StuckAttachmentThresholdSec = 60
+	monitor.RemediateStuckAttachments = true
+	defer func() {
+		monitor.StuckAttachmentThresholdSec = 0
+		monitor.RemediateStuckAttachments = false
+	}()
+	volumeName := driverVolumeName
+	deletionTime := metav1.NewTime(time.Now().Add(-10 * time.Minute))
+	attachments := []*storagev1beta1.VolumeAttachment{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "stuckattachment",
+				DeletionTimestamp: &deletionTime,
+			},
+			Spec: storagev1beta1.VolumeAttachmentSpec{
+				NodeName: "node2.domain",
+				Source:   storagev1beta1.VolumeAttachmentSource{PersistentVolumeName: &volumeName},
+			},
+			Status: storagev1beta1.VolumeAttachmentStatus{Attached: true},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "attachment",
+				CreationTimestamp: deletionTime,
+			},
+			Spec: storagev1beta1.VolumeAttachmentSpec{
+				NodeName: "node3.domain",
+				Source:   storagev1beta1.VolumeAttachmentSource{PersistentVolumeName: &volumeName},
+			},
+			Status: storagev1beta1.VolumeAttachmentStatus{Attached: true},
+		},
+	}
+	for _, attachment := range attachments {
+		_, err := fakeKubeClient.StorageV1beta1().VolumeAttachments().Create(attachment)
+		require.NoError(t, err, "Error creating volume attachment")
+	}
+	require.Equal(t, attachmentStateDetaching, monitor.getStuckAttachmentState(attachments[0]))
+	require.Empty(t, monitor.getStuckAttachmentState(attachments[1]))
+
+	volumeInfo, err := driver.InspectVolume(driverVolumeName)
+	require.NoError(t, err, "Error inspecting volume")
+	err = driver.AttachVolume(driverVolumeName, 1)
+	require.NoError(t, err, "Error attaching volume")
+
+	// Volume shouldn't be detached while the storage on the node is online
+	driverNodes, err := driver.GetNodes()
+	require.NoError(t, err, "Error getting driver nodes")
+	monitor.checkVolumeAttachments(driverNodes)
+	attachedNode, err := driver.GetVolumeAttachedNode(volumeInfo)
+	require.NoError(t, err, "Error getting attached node")
+	require.Equal(t, driverNodes[1].StorageID, attachedNode)
+	metric := &dto.Metric{}
+	require.NoError(t, stuckVolumeAttachments.WithLabelValues(attachmentStateDetaching).Write(metric), "Error reading gauge")
+	require.Equal(t, float64(1), metric.GetGauge().GetValue())
+
+	err = driver.UpdateNodeStatus(1, volume.NodeOffline)
+	require.NoError(t, err, "Error setting node status to Offline")
+	defer func() {
+		err = driver.UpdateNodeStatus(1, volume.NodeOnline)
+		require.NoError(t, err, "Error setting node status to Online")
+	}()
+	monitor.checkVolumeAttachments(driverNodes)
+	attachedNode, err = driver.GetVolumeAttachedNode(volumeInfo)
+	require.NoError(t, err, "Error getting attached node")
+	require.Empty(t, attachedNode, "Volume should have been detached")
+}
@@ -0,0 +1,124 @@
+package monitor
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/libopenstorage/stork/drivers/volume"
+	stork "github.com/libopenstorage/stork/pkg/apis/stork"
+	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/portworx/sched-ops/k8s"
+	log "github.com/sirupsen/logrus"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	validateCRDInterval time.Duration = 5 * time.Second
+	validateCRDTimeout  time.Duration = 1 * time.Minute
+)
+
+// createNodeStorageStatusCRD creates the CRD for NodeStorageStatus objects
+func (m *Monitor) createNodeStorageStatusCRD() error {
+	resource := k8s.CustomResource{
+		Name:       storkv1.NodeStorageStatusResourceName,
+		Plural:     storkv1.NodeStorageStatusPlural,
+		Group:      stork.GroupName,
+		Version:    storkv1.SchemeGroupVersion.Version,
+		Scope:      apiextensionsv1beta1.ClusterScoped,
+		Kind:       reflect.TypeOf(storkv1.NodeStorageStatus{}).Name(),
+		ShortNames: []string{storkv1.NodeStorageStatusShortName},
+	}
+	err := k8s.Instance().CreateCRD(resource)
+	if err != nil && !errors.IsAlreadyExists(err) {
+		return err
+	}
+
+	return k8s.Instance().ValidateCRD(resource, validateCRDTimeout, validateCRDInterval)
+}
+
+// newNodeStorageStatusInfo returns the status to be published for a node from
+// the info reported by the driver
+func newNodeStorageStatusInfo(driverNode *volume.NodeInfo, heartbeat metav1.Time) storkv1.NodeStorageStatusInfo {
+	status := storkv1.NodeStorageStatusInfo{
+		StorageID:         driverNode.StorageID,
+		Health:            storkv1.NodeStorageHealth(driverNode.Status),
+		TotalCapacity:     driverNode.TotalCapacity,
+		FreeCapacity:      driverNode.FreeCapacity,
+		Pools:             make([]storkv1.StoragePoolStatus, 0, len(driverNode.Pools)),
+		LastHeartbeatTime: heartbeat,
+	}
+	for _, pool := range driverNode.Pools {
+		status.Pools = append(status.Pools, storkv1.StoragePoolStatus{
+			ID:            pool.ID,
+			TotalCapacity: pool.TotalCapacity,
+			FreeCapacity:  pool.FreeCapacity,
+		})
+	}
+	return status
+}
+
+// updateNodeStorageStatuses publishes the status of the storage on each node
+// as a NodeStorageStatus object with the same name as the node. Objects for
+// nodes that aren't reported by the driver anymore are deleted.
+func (m *Monitor) updateNodeStorageStatuses(driverNodes []*volume.NodeInfo) {
+	if !m.PublishNodeStorageStatus || len(driverNodes) == 0 {
+		return
+	}
+	k8sNodes, err := k8s.Instance().GetNodes()
+	if err != nil {
+		log.Errorf("Error getting nodes to update node storage status: %v", err)
+		return
+	}
+	client := m.StorkClient.Stork().NodeStorageStatuses()
+	statusList, err := client.List(metav1.ListOptions{})
+	if err != nil {
+		log.Errorf("Error getting node storage statuses: %v", err)
+		return
+	}
+	existing := make(map[string]*storkv1.NodeStorageStatus)
+	for i := range statusList.Items {
+		existing[statusList.Items[i].Name] = &statusList.Items[i]
+	}
+
+	now := metav1.Now()
+	for _, k8sNode := range k8sNodes.Items {
+		for _, driverNode := range driverNodes {
+			if !volume.IsNodeMatch(&k8sNode, driverNode) {
+				continue
+			}
+			status := newNodeStorageStatusInfo(driverNode, now)
+			current, ok := existing[k8sNode.Name]
+			if !ok {
+				status.LastTransitionTime = now
+				if _, err := client.Create(&storkv1.NodeStorageStatus{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: k8sNode.Name,
+					},
+					Status: status,
+				}); err != nil {
+					log.Errorf("Error creating node storage status for node %v: %v", k8sNode.Name, err)
+				}
+				break
+			}
+			delete(existing, k8sNode.Name)
+			status.LastTransitionTime = current.Status.LastTransitionTime
+			if current.Status.Health != status.Health {
+				status.LastTransitionTime = now
+			}
+			current.Status = status
+			if _, err := client.Update(current); err != nil {
+				log.Errorf("Error updating node storage status for node %v: %v", k8sNode.Name, err)
+			}
+			break
+		}
+	}
+
+	for name := range existing {
+		log.Infof("Deleting node storage status for node %v", name)
+		if err := client.Delete(name, &metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			log.Errorf("Error deleting node storage status for node %v: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package monitor
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/libopenstorage/stork/drivers/volume"
+	storkerrors "github.com/libopenstorage/stork/pkg/errors"
+	storklog "github.com/libopenstorage/stork/pkg/log"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/api/core/v1"
+)
+
+// failoverSharedVolumes moves the shared volumes served by a node where the
+// storage is offline to another node, and restarts the pods on other nodes
+// using those volumes so that they remount them from the new node. Pods using
+// shared volumes served by other nodes aren't touched. Each volume is only
+// failed over once while the node is offline.
+func (m *Monitor) failoverSharedVolumes(node *volume.NodeInfo, pods []v1.Pod) {
+	for volumeID, storageID := range m.sharedVolumeFailovers {
+		if _, offline := m.offlineSince[storageID]; !offline {
+			delete(m.sharedVolumeFailovers, volumeID)
+		}
+	}
+
+	served := make(map[string]bool)
+	moved := make(map[string]error)
+	for _, pod := range pods {
+		if pod.Status.Phase != v1.PodRunning || !m.isPodMonitored(&pod) {
+			continue
+		}
+		// Pods on the node itself are deleted by the eviction
+		if m.isSameNode(pod.Spec.NodeName, node) {
+			continue
+		}
+		volumes, err := m.Driver.GetPodVolumes(&pod.Spec, pod.Namespace)
+		if err != nil {
+			continue
+		}
+
+		affected := false
+		var moveErr error
+		for _, volumeInfo := range volumes {
+			if !volumeInfo.Shared || volumeInfo.Driver != node.Driver {
+				continue
+			}
+			if !m.isSharedVolumeServedBy(volumeInfo, node, served) {
+				continue
+			}
+			affected = true
+			if m.DryRun {
+				continue
+			}
+			err, ok := moved[volumeInfo.VolumeID]
+			if !ok {
+				err = m.moveSharedVolume(volumeInfo, node)
+				moved[volumeInfo.VolumeID] = err
+			}
+			if err != nil {
+				moveErr = err
+			}
+		}
+		if !affected {
+			continue
+		}
+
+		if m.isInEvictionBackoff(&pod) {
+			storklog.PodLog(&pod).Infof("Not restarting pod since it was deleted in the last %v seconds", m.EvictionBackoffSec)
+			continue
+		}
+		if m.DryRun {
+			m.recordDryRunDeletion(&pod, failoverReasonSharedVolumeServerOffline,
+				fmt.Sprintf("Pod would be restarted since storage on node %v serving its shared volumes is %v",
+					node.Hostname, node.Status))
+			continue
+		}
+		if moveErr != nil {
+			storklog.PodLog(&pod).Errorf("Not restarting pod since its shared volumes couldn't be moved from node %v: %v",
+				node.Hostname, moveErr)
+			m.recordFailoverFailure(&pod, failoverReasonSharedVolumeServerOffline,
+				fmt.Errorf("error moving shared volumes: %v", moveErr))
+			continue
+		}
+		storklog.PodLog(&pod).Infof("Restarting pod since node %v serving its shared volumes is %v", node.Hostname, node.Status)
+		deleted, err := m.deletePod(&pod)
+		if err != nil {
+			storklog.PodLog(&pod).Errorf("Error deleting pod: %v", err)
+			m.recordFailoverFailure(&pod, failoverReasonSharedVolumeServerOffline, err)
+			continue
+		}
+		if deleted {
+			m.evictedPods[pod.Namespace+"/"+pod.Name] = time.Now()
+			m.recordFailover(&pod, failoverReasonSharedVolumeServerOffline,
+				fmt.Sprintf("Deleted pod since storage on node %v serving its shared volumes is %v",
+					node.Hostname, node.Status))
+		}
+	}
+
+	for volumeID, err := range moved {
+		if err == nil {
+			m.sharedVolumeFailovers[volumeID] = node.StorageID
+		}
+	}
+}
+
+// isSharedVolumeServedBy returns true if the shared volume is served by the
+// node and hasn't already been failed over from it. The results are cached in
+// served for the volumes that have been checked.
+func (m *Monitor) isSharedVolumeServedBy(
+	volumeInfo *volume.Info,
+	node *volume.NodeInfo,
+	served map[string]bool,
+) bool {
+	if isServed, ok := served[volumeInfo.VolumeID]; ok {
+		return isServed
+	}
+	isServed := false
+	if m.sharedVolumeFailovers[volumeInfo.VolumeID] != node.StorageID {
+		coordinator, err := m.Driver.GetSharedVolumeCoordinator(volumeInfo)
+		if err != nil {
+			if _, ok := err.(*storkerrors.ErrNotSupported); !ok {
+				log.Errorf("Error getting node serving shared volume %v: %v", volumeInfo.VolumeName, err)
+			}
+		} else {
+			isServed = coordinator == node.StorageID
+		}
+	}
+	served[volumeInfo.VolumeID] = isServed
+	return isServed
+}
+
+// moveSharedVolume force detaches the shared volume from the node serving it,
+// so that the driver serves it from another node, moving the service endpoint
+// for the volume if it's exposed through one. Drivers that don't support force
+// detaching are expected to move the volume themselves.
+func (m *Monitor) moveSharedVolume(volumeInfo *volume.Info, node *volume.NodeInfo) error {
+	log.Infof("Moving shared volume %v from node %v since its storage is %v",
+		volumeInfo.VolumeName, node.Hostname, node.Status)
+	if err := m.Driver.ForceDetachVolume(volumeInfo); err != nil {
+		if _, ok := err.(*storkerrors.ErrNotSupported); ok {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
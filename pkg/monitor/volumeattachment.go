@@ -0,0 +1,115 @@
+package monitor
+
+import (
+	"time"
+
+	"github.com/libopenstorage/stork/drivers/volume"
+	storkerrors "github.com/libopenstorage/stork/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	storagev1beta1 "k8s.io/api/storage/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	attachmentStateAttaching = "attaching"
+	attachmentStateDetaching = "detaching"
+)
+
+// getStuckAttachmentState returns the state in which the volume attachment is
+// stuck, or an empty string if it isn't stuck. Attachments are considered
+// stuck if they haven't been attached or deleted within the threshold.
+func (m *Monitor) getStuckAttachmentState(attachment *storagev1beta1.VolumeAttachment) string {
+	threshold := time.Duration(m.StuckAttachmentThresholdSec) * time.Second
+	if attachment.DeletionTimestamp != nil {
+		if time.Since(attachment.DeletionTimestamp.Time) >= threshold {
+			return attachmentStateDetaching
+		}
+		return ""
+	}
+	if !attachment.Status.Attached && time.Since(attachment.CreationTimestamp.Time) >= threshold {
+		return attachmentStateAttaching
+	}
+	return ""
+}
+
+// checkVolumeAttachments looks for volume attachments that are stuck
+// attaching or detaching for longer than the threshold, and force detaches the
+// volumes through the driver if they are still attached to a node where the
+// storage isn't online
+func (m *Monitor) checkVolumeAttachments(driverNodes []*volume.NodeInfo) {
+	if m.StuckAttachmentThresholdSec == 0 {
+		return
+	}
+	attachments, err := m.KubeClient.StorageV1beta1().VolumeAttachments().List(metav1.ListOptions{})
+	if err != nil {
+		log.Errorf("Error getting volume attachments: %v", err)
+		return
+	}
+
+	nodeStatus := make(map[string]volume.NodeStatus)
+	for _, node := range driverNodes {
+		nodeStatus[node.StorageID] = node.Status
+	}
+	stuck := map[string]int{
+		attachmentStateAttaching: 0,
+		attachmentStateDetaching: 0,
+	}
+	for i := range attachments.Items {
+		attachment := &attachments.Items[i]
+		state := m.getStuckAttachmentState(attachment)
+		if state == "" {
+			continue
+		}
+		stuck[state]++
+		log.Warnf("Volume attachment %v for node %v has been %v for more than %v seconds",
+			attachment.Name, attachment.Spec.NodeName, state, m.StuckAttachmentThresholdSec)
+		if m.RemediateStuckAttachments {
+			m.remediateVolumeAttachment(attachment, state, nodeStatus)
+		}
+	}
+	for state, count := range stuck {
+		stuckVolumeAttachments.WithLabelValues(state).Set(float64(count))
+	}
+}
+
+// remediateVolumeAttachment force detaches the volume for a stuck attachment
+// if the driver reports it attached to a node where the storage isn't online
+func (m *Monitor) remediateVolumeAttachment(
+	attachment *storagev1beta1.VolumeAttachment,
+	state string,
+	nodeStatus map[string]volume.NodeStatus,
+) {
+	if attachment.Spec.Source.PersistentVolumeName == nil {
+		return
+	}
+	volumeInfo, err := m.Driver.InspectVolume(*attachment.Spec.Source.PersistentVolumeName)
+	if err != nil {
+		if _, ok := err.(*storkerrors.ErrNotFound); !ok {
+			log.Errorf("Error inspecting volume for volume attachment %v: %v", attachment.Name, err)
+		}
+		return
+	}
+	attachedNode, err := m.Driver.GetVolumeAttachedNode(volumeInfo)
+	if err != nil {
+		if _, ok := err.(*storkerrors.ErrNotSupported); !ok {
+			log.Errorf("Error getting attached node for volume %v: %v", volumeInfo.VolumeName, err)
+		}
+		return
+	}
+	if attachedNode == "" {
+		return
+	}
+	if status, ok := nodeStatus[attachedNode]; ok && status == volume.NodeOnline {
+		return
+	}
+	if m.DryRun {
+		log.Infof("Dry run: Volume %v would be force detached for volume attachment %v", volumeInfo.VolumeName, attachment.Name)
+		return
+	}
+	log.Infof("Force detaching volume %v for volume attachment %v stuck %v", volumeInfo.VolumeName, attachment.Name, state)
+	if err := m.Driver.ForceDetachVolume(volumeInfo); err != nil {
+		log.Errorf("Error force detaching volume %v: %v", volumeInfo.VolumeName, err)
+		return
+	}
+	volumeAttachmentRemediations.WithLabelValues(state).Inc()
+}
@@ -26,6 +26,19 @@ const (
 	annotationPrefix                       = "stork.libopenstorage.org/"
 	snapshotSchedulePolicyAnnotationPrefix = "snapshotschedule." + annotationPrefix
 	scheduleCreatedAnnotation              = annotationPrefix + "snapshot-schedule-created"
+	// snapshotSchedulePolicyAnnotation is used on a PVC to specify the name of
+	// the schedule policy that should be used to snapshot it
+	snapshotSchedulePolicyAnnotation = annotationPrefix + "snapshot-schedule-policy"
+	// pvcScheduleLabel is added to the snapshot schedules created for PVCs
+	// with the snapshot schedule policy annotation
+	pvcScheduleLabel = annotationPrefix + "pvc-snapshot-schedule"
+	// pvcScheduleSuffix is the suffix used to name snapshot schedules
+	// created for PVCs with the snapshot schedule policy annotation
+	pvcScheduleSuffix = "-pvc-schedule"
+	// pvcScheduleCreatedAnnotation is set on a PVC once the snapshot schedule
+	// has been created for its snapshot schedule policy annotation, so that
+	// the schedule can be deleted if the annotation is removed
+	pvcScheduleCreatedAnnotation = annotationPrefix + "pvc-snapshot-schedule-created"
 )
 
 // PVCWatcher watches for changes in PVCs
@@ -96,6 +109,10 @@ func (p *PVCWatcher) handleSnapshotScheduleUpdates(pvc *v1.PersistentVolumeClaim
 		return nil
 	}
 
+	if err := p.handlePVCScheduleAnnotation(pvc); err != nil {
+		return err
+	}
+
 	// Also skip if we've already configured the snapshot schedule for this PVC
 	if configured, ok := pvc.Annotations[scheduleCreatedAnnotation]; ok && configured == "yes" {
 		return nil
@@ -176,3 +193,123 @@ func (p *PVCWatcher) handleSnapshotScheduleUpdates(pvc *v1.PersistentVolumeClaim
 
 	return err
 }
+
+// handlePVCScheduleAnnotation creates, updates or deletes the snapshot
+// schedule for a PVC based on the schedule policy in its annotations
+func (p *PVCWatcher) handlePVCScheduleAnnotation(pvc *v1.PersistentVolumeClaim) error {
+	schedulePolicyName := pvc.Annotations[snapshotSchedulePolicyAnnotation]
+	_, created := pvc.Annotations[pvcScheduleCreatedAnnotation]
+	// Nothing to do for PVCs that have never used the annotation, so that
+	// the schedule isn't looked up for every PVC on each resync
+	if schedulePolicyName == "" && !created {
+		return nil
+	}
+	snapshotScheduleName := pvc.Name + pvcScheduleSuffix
+	snapshotSchedule, err := k8s.Instance().GetSnapshotSchedule(snapshotScheduleName, pvc.Namespace)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	exists := err == nil
+	if exists {
+		// Don't touch schedules that weren't created for the annotation
+		if _, ok := snapshotSchedule.Labels[pvcScheduleLabel]; !ok {
+			return nil
+		}
+	}
+
+	if schedulePolicyName == "" {
+		if exists {
+			// The annotation was removed, so delete the schedule
+			if err := k8s.Instance().DeleteSnapshotSchedule(snapshotScheduleName, pvc.Namespace); err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+			p.Recorder.Event(pvc,
+				v1.EventTypeNormal,
+				"Success",
+				fmt.Sprintf("Deleted volume snapshot schedule (%v) for PVC", snapshotScheduleName))
+		}
+		return setPVCScheduleCreated(pvc, false)
+	}
+
+	if exists {
+		if snapshotSchedule.Spec.SchedulePolicyName == schedulePolicyName {
+			return setPVCScheduleCreated(pvc, true)
+		}
+		snapshotSchedule.Spec.SchedulePolicyName = schedulePolicyName
+		if _, err := k8s.Instance().UpdateSnapshotSchedule(snapshotSchedule); err != nil {
+			p.Recorder.Event(pvc,
+				v1.EventTypeWarning,
+				"Error",
+				fmt.Sprintf("Error updating snapshot schedule for PVC: %v", err))
+			return err
+		}
+		p.Recorder.Event(pvc,
+			v1.EventTypeNormal,
+			"Success",
+			fmt.Sprintf("Updated volume snapshot schedule (%v) for PVC to use policy %v", snapshotScheduleName, schedulePolicyName))
+		return setPVCScheduleCreated(pvc, true)
+	}
+
+	snapshotSchedule = &storkv1.VolumeSnapshotSchedule{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      snapshotScheduleName,
+			Namespace: pvc.Namespace,
+			Labels: map[string]string{
+				pvcScheduleLabel: pvc.Name,
+			},
+			// Set the owner reference so that the schedule gets deleted
+			// with the PVC
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					Name:       pvc.Name,
+					UID:        pvc.UID,
+					Kind:       pvc.GetObjectKind().GroupVersionKind().Kind,
+					APIVersion: pvc.GetObjectKind().GroupVersionKind().GroupVersion().String(),
+				},
+			},
+		},
+		Spec: storkv1.VolumeSnapshotScheduleSpec{
+			Template: storkv1.VolumeSnapshotTemplateSpec{
+				Spec: snapv1.VolumeSnapshotSpec{
+					PersistentVolumeClaimName: pvc.Name,
+				},
+			},
+			SchedulePolicyName: schedulePolicyName,
+			ReclaimPolicy:      storkv1.ReclaimPolicyRetain,
+		},
+	}
+	if _, err := k8s.Instance().CreateSnapshotSchedule(snapshotSchedule); err != nil {
+		p.Recorder.Event(pvc,
+			v1.EventTypeWarning,
+			"Error",
+			fmt.Sprintf("Error creating snapshot schedule for PVC: %v", err))
+		return err
+	}
+	p.Recorder.Event(pvc,
+		v1.EventTypeNormal,
+		"Success",
+		fmt.Sprintf("Created volume snapshot schedule (%v) for PVC", snapshotScheduleName))
+	return setPVCScheduleCreated(pvc, true)
+}
+
+// setPVCScheduleCreated adds or removes the annotation recording that the
+// snapshot schedule was created for the PVC
+func setPVCScheduleCreated(pvc *v1.PersistentVolumeClaim, created bool) error {
+	if _, ok := pvc.Annotations[pvcScheduleCreatedAnnotation]; ok == created {
+		return nil
+	}
+	if created {
+		if pvc.Annotations == nil {
+			pvc.Annotations = make(map[string]string)
+		}
+		pvc.Annotations[pvcScheduleCreatedAnnotation] = "true"
+	} else {
+		delete(pvc.Annotations, pvcScheduleCreatedAnnotation)
+	}
+	updatedPVC, err := k8s.Instance().UpdatePersistentVolumeClaim(pvc)
+	if err != nil {
+		return err
+	}
+	*pvc = *updatedPVC
+	return nil
+}
@@ -0,0 +1,84 @@
+// +build unittest
+
+package pvcwatcher
+
+import (
+	"testing"
+
+	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	fakeclient "github.com/libopenstorage/stork/pkg/client/clientset/versioned/fake"
+	"github.com/portworx/sched-ops/k8s"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakekube "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+func setupPVCWatcherTest(pvc *v1.PersistentVolumeClaim) (*PVCWatcher, *fakeclient.Clientset) {
+	storkClient := fakeclient.NewSimpleClientset()
+	k8s.Instance().SetClient(fakekube.NewSimpleClientset(pvc), nil, storkClient, nil, nil, nil)
+	return &PVCWatcher{Recorder: record.NewFakeRecorder(10)}, storkClient
+}
+
+func newTestPVC(annotations map[string]string) *v1.PersistentVolumeClaim {
+	return &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "pvc1", Namespace: "test", Annotations: annotations},
+	}
+}
+
+func TestPVCScheduleAnnotationAbsent(t *testing.T) {
+	pvc := newTestPVC(nil)
+	p, storkClient := setupPVCWatcherTest(pvc)
+
+	// The snapshot schedule shouldn't be looked up for PVCs without the
+	// annotation
+	require.NoError(t, p.handlePVCScheduleAnnotation(pvc))
+	require.Empty(t, storkClient.Actions())
+
+	// Schedules with the same name that weren't created for the annotation
+	// aren't touched
+	_, err := k8s.Instance().CreateSnapshotSchedule(&storkv1.VolumeSnapshotSchedule{
+		ObjectMeta: metav1.ObjectMeta{Name: "pvc1" + pvcScheduleSuffix, Namespace: "test"},
+		Spec:       storkv1.VolumeSnapshotScheduleSpec{SchedulePolicyName: "daily"},
+	})
+	require.NoError(t, err, "Error creating snapshot schedule")
+	pvc.Annotations = map[string]string{snapshotSchedulePolicyAnnotation: "weekly"}
+	require.NoError(t, p.handlePVCScheduleAnnotation(pvc))
+	schedule, err := k8s.Instance().GetSnapshotSchedule("pvc1"+pvcScheduleSuffix, "test")
+	require.NoError(t, err, "Error getting snapshot schedule")
+	require.Equal(t, "daily", schedule.Spec.SchedulePolicyName)
+}
+
+func TestPVCScheduleAnnotation(t *testing.T) {
+	pvc := newTestPVC(map[string]string{snapshotSchedulePolicyAnnotation: "daily"})
+	p, _ := setupPVCWatcherTest(pvc)
+
+	require.NoError(t, p.handlePVCScheduleAnnotation(pvc))
+	schedule, err := k8s.Instance().GetSnapshotSchedule("pvc1"+pvcScheduleSuffix, "test")
+	require.NoError(t, err, "Error getting snapshot schedule")
+	require.Equal(t, "daily", schedule.Spec.SchedulePolicyName)
+	require.Equal(t, "pvc1", schedule.Spec.Template.Spec.PersistentVolumeClaimName)
+	require.Equal(t, storkv1.ReclaimPolicyRetain, schedule.Spec.ReclaimPolicy)
+	require.Equal(t, "pvc1", schedule.Labels[pvcScheduleLabel])
+	updatedPVC, err := k8s.Instance().GetPersistentVolumeClaim("pvc1", "test")
+	require.NoError(t, err, "Error getting PVC")
+	require.Contains(t, updatedPVC.Annotations, pvcScheduleCreatedAnnotation)
+
+	// The schedule is updated when the policy in the annotation changes
+	pvc.Annotations[snapshotSchedulePolicyAnnotation] = "weekly"
+	require.NoError(t, p.handlePVCScheduleAnnotation(pvc))
+	schedule, err = k8s.Instance().GetSnapshotSchedule("pvc1"+pvcScheduleSuffix, "test")
+	require.NoError(t, err, "Error getting snapshot schedule")
+	require.Equal(t, "weekly", schedule.Spec.SchedulePolicyName)
+
+	// The schedule is deleted when the annotation is removed
+	delete(pvc.Annotations, snapshotSchedulePolicyAnnotation)
+	require.NoError(t, p.handlePVCScheduleAnnotation(pvc))
+	_, err = k8s.Instance().GetSnapshotSchedule("pvc1"+pvcScheduleSuffix, "test")
+	require.True(t, errors.IsNotFound(err), "Snapshot schedule should have been deleted")
+	updatedPVC, err = k8s.Instance().GetPersistentVolumeClaim("pvc1", "test")
+	require.NoError(t, err, "Error getting PVC")
+	require.NotContains(t, updatedPVC.Annotations, pvcScheduleCreatedAnnotation)
+}
@@ -0,0 +1,291 @@
+package rule
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/cmdexecutor"
+	"github.com/libopenstorage/stork/pkg/schedule"
+	"github.com/portworx/sched-ops/k8s"
+	"github.com/sirupsen/logrus"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// backgroundActionsKey is the label and annotation on pods with
+	// background actions that need to be terminated and cleaned up. The
+	// annotation has the list of actions.
+	backgroundActionsKey = "stork.libopenstorage.org/background-actions"
+	// defaultBackgroundActionTimeout is the time after which a background
+	// action with a cleanup command is terminated if it doesn't have a
+	// timeout
+	defaultBackgroundActionTimeout = time.Hour
+	defaultCleanupInterval         = time.Minute
+)
+
+// backgroundAction is a background action running in a pod that needs to be
+// terminated and cleaned up by its deadline
+type backgroundAction struct {
+	TaskID    string      `json:"taskID"`
+	Container string      `json:"container,omitempty"`
+	Cleanup   string      `json:"cleanup,omitempty"`
+	Deadline  metav1.Time `json:"deadline"`
+}
+
+// needsTracking checks if a background action needs to be tracked so that it
+// is terminated and cleaned up even if its owner is gone
+func needsTracking(action stork_api.RuleAction) bool {
+	return action.Background && (action.TimeoutSeconds > 0 || action.Cleanup != "")
+}
+
+func getBackgroundActions(pod *v1.Pod) ([]backgroundAction, error) {
+	actions := make([]backgroundAction, 0)
+	value := pod.GetAnnotations()[backgroundActionsKey]
+	if value == "" {
+		return actions, nil
+	}
+	if err := json.Unmarshal([]byte(value), &actions); err != nil {
+		return nil, fmt.Errorf("failed to parse background actions for pod: [%s] %s due to: %v",
+			pod.GetNamespace(), pod.GetName(), err)
+	}
+	return actions, nil
+}
+
+// updateBackgroundActions updates the background actions tracked on the
+// latest version of the pod. The label and annotation are removed once there
+// are no actions left.
+func updateBackgroundActions(pod v1.Pod, update func([]backgroundAction) []backgroundAction) error {
+	return wait.ExponentialBackoff(ownerAPICallBackoff, func() (bool, error) {
+		latest, err := k8s.Instance().GetPodByUID(pod.GetUID(), pod.GetNamespace())
+		if err != nil {
+			if err == k8s.ErrPodsNotFound {
+				return true, nil
+			}
+			logrus.Warnf("Failed to get pod: [%s] %s due to: %v. Will retry.", pod.GetNamespace(), pod.GetName(), err)
+			return false, nil
+		}
+		actions, err := getBackgroundActions(latest)
+		if err != nil {
+			return false, err
+		}
+		actions = update(actions)
+
+		if len(actions) == 0 {
+			delete(latest.Labels, backgroundActionsKey)
+			delete(latest.Annotations, backgroundActionsKey)
+		} else {
+			actionBytes, err := json.Marshal(actions)
+			if err != nil {
+				return false, err
+			}
+			if latest.Labels == nil {
+				latest.Labels = make(map[string]string)
+			}
+			if latest.Annotations == nil {
+				latest.Annotations = make(map[string]string)
+			}
+			latest.Labels[backgroundActionsKey] = "true"
+			latest.Annotations[backgroundActionsKey] = string(actionBytes)
+		}
+		if _, err := k8s.Instance().UpdatePod(latest); err != nil {
+			logrus.Warnf("Failed to update pod: [%s] %s due to: %v. Will retry.", pod.GetNamespace(), pod.GetName(), err)
+			return false, nil
+		}
+		return true, nil
+	})
+}
+
+// trackBackgroundAction records the background action on the pods so that it
+// is terminated and cleaned up by its deadline
+func trackBackgroundAction(
+	pods []v1.Pod,
+	containers map[types.UID]string,
+	action stork_api.RuleAction,
+	taskID string,
+) error {
+	timeout := defaultBackgroundActionTimeout
+	if action.TimeoutSeconds > 0 {
+		timeout = time.Duration(action.TimeoutSeconds) * time.Second
+	}
+	deadline := metav1.NewTime(schedule.GetCurrentTime().Add(timeout))
+	for _, pod := range pods {
+		err := updateBackgroundActions(pod, func(actions []backgroundAction) []backgroundAction {
+			return append(actions, backgroundAction{
+				TaskID:    taskID,
+				Container: containers[pod.GetUID()],
+				Cleanup:   action.Cleanup,
+				Deadline:  deadline,
+			})
+		})
+		if err != nil {
+			return fmt.Errorf("failed to track background action in pod: [%s] %s due to: %v",
+				pod.GetNamespace(), pod.GetName(), err)
+		}
+	}
+	return nil
+}
+
+// cleanupBackgroundActions runs the cleanup commands for the background
+// actions in the pod that match the filter and stops tracking them. Actions
+// whose cleanup fails are left to be retried by the cleanup controller.
+func cleanupBackgroundActions(pod v1.Pod, filter func(backgroundAction) bool) error {
+	latest, err := k8s.Instance().GetPodByUID(pod.GetUID(), pod.GetNamespace())
+	if err != nil {
+		if err == k8s.ErrPodsNotFound {
+			return nil
+		}
+		return err
+	}
+	pod = *latest
+	actions, err := getBackgroundActions(&pod)
+	if err != nil {
+		return err
+	}
+	done := make(map[backgroundAction]bool)
+	var lastErr error
+	for _, action := range actions {
+		if !filter(action) {
+			continue
+		}
+		if action.Cleanup != "" {
+			containers := map[types.UID]string{pod.GetUID(): action.Container}
+			backoff := wait.Backoff{
+				Duration: execPodCmdRetryInterval,
+				Factor:   execPodCmdRetryFactor,
+				Steps:    execPodStepLow,
+			}
+			if _, _, err := runCommandOnPods([]v1.Pod{pod}, containers, action.Cleanup, backoff, true); err != nil {
+				lastErr = err
+				continue
+			}
+		}
+		done[action] = true
+	}
+	if len(done) > 0 {
+		err := updateBackgroundActions(pod, func(actions []backgroundAction) []backgroundAction {
+			remaining := make([]backgroundAction, 0)
+			for _, action := range actions {
+				if !done[action] {
+					remaining = append(remaining, action)
+				}
+			}
+			return remaining
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return lastErr
+}
+
+// finishBackgroundActions runs the cleanup for the tracked background actions
+// of the task once they have been terminated in the pods
+func finishBackgroundActions(pods []v1.Pod, taskID string) {
+	for _, pod := range pods {
+		err := cleanupBackgroundActions(pod, func(action backgroundAction) bool {
+			return action.TaskID == taskID
+		})
+		if err != nil {
+			logrus.Warnf("Failed to clean up background actions in pod: [%s] %s due to: %v",
+				pod.GetNamespace(), pod.GetName(), err)
+		}
+	}
+}
+
+// CleanupController periodically terminates background actions that have run
+// past their deadline and runs their cleanup commands. This makes sure that
+// applications aren't left quiesced if the operation that started the action
+// fails or is deleted.
+type CleanupController struct {
+	// Interval at which pods are checked. Defaults to 1 minute
+	Interval    time.Duration
+	stopChannel chan struct{}
+}
+
+// Start starts the cleanup controller
+func (c *CleanupController) Start() error {
+	if c.Interval == 0 {
+		c.Interval = defaultCleanupInterval
+	}
+	c.stopChannel = make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(c.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := c.cleanup(); err != nil {
+					logrus.Errorf("Error cleaning up background rule actions: %v", err)
+				}
+			case <-c.stopChannel:
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop stops the cleanup controller
+func (c *CleanupController) Stop() error {
+	close(c.stopChannel)
+	return nil
+}
+
+func (c *CleanupController) cleanup() error {
+	pods, err := k8s.Instance().GetPods("", map[string]string{backgroundActionsKey: "true"})
+	if err != nil {
+		return err
+	}
+	now := schedule.GetCurrentTime()
+	for _, pod := range pods.Items {
+		actions, err := getBackgroundActions(&pod)
+		if err != nil {
+			logrus.Warn(err)
+			continue
+		}
+		expired := make(map[string]bool)
+		for _, action := range actions {
+			if now.After(action.Deadline.Time) {
+				expired[action.TaskID] = true
+			}
+		}
+		for taskID := range expired {
+			logrus.Infof("Terminating background rule action for task %v in pod: [%s] %s since it is past its deadline",
+				taskID, pod.GetNamespace(), pod.GetName())
+			c.terminate(pod, actions, taskID)
+		}
+		if len(expired) == 0 {
+			continue
+		}
+		err = cleanupBackgroundActions(pod, func(action backgroundAction) bool {
+			return expired[action.TaskID]
+		})
+		if err != nil {
+			logrus.Warnf("Failed to clean up background actions in pod: [%s] %s due to: %v",
+				pod.GetNamespace(), pod.GetName(), err)
+		}
+	}
+	return nil
+}
+
+// terminate terminates the command for the task in the pod by creating the
+// kill file in the containers where it was run
+func (c *CleanupController) terminate(pod v1.Pod, actions []backgroundAction, taskID string) {
+	killFile := fmt.Sprintf(cmdexecutor.KillFileFormat, taskID)
+	for _, action := range actions {
+		if action.TaskID != taskID {
+			continue
+		}
+		_, err := k8s.Instance().RunCommandInPod([]string{"sh", "-c", fmt.Sprintf("touch %s", killFile)},
+			pod.GetName(), action.Container, pod.GetNamespace())
+		if err != nil {
+			logrus.Warnf("Failed to terminate background action in pod: [%s] %s due to: %v",
+				pod.GetNamespace(), pod.GetName(), err)
+		}
+	}
+}
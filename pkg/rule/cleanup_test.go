@@ -0,0 +1,75 @@
+// +build unittest
+
+package rule
+
+import (
+	"testing"
+	"time"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/portworx/sched-ops/k8s"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	fakekube "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestBackgroundActionCleanup(t *testing.T) {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "db-0", Namespace: "ns", UID: "uid-1"},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{{Name: "db"}},
+		},
+	}
+	k8s.Instance().SetClient(fakekube.NewSimpleClientset(pod), nil, nil, nil, nil, nil)
+
+	action := stork_api.RuleAction{
+		Type:           stork_api.RuleActionCommand,
+		Background:     true,
+		Value:          "fsfreeze -f /data && ${WAIT_CMD}",
+		TimeoutSeconds: 300,
+	}
+	require.True(t, needsTracking(action))
+	require.False(t, needsTracking(stork_api.RuleAction{Type: stork_api.RuleActionCommand, Background: true}))
+	require.NoError(t, ValidateRule(newJobRule(action), PreExecRule))
+	action.Background = false
+	require.Error(t, ValidateRule(newJobRule(action), PreExecRule), "Expected error for timeout on foreground action")
+	action.Background = true
+
+	containers := map[types.UID]string{pod.UID: "db"}
+	require.NoError(t, trackBackgroundAction([]v1.Pod{*pod}, containers, action, "task-1"))
+	require.NoError(t, trackBackgroundAction([]v1.Pod{*pod}, containers, action, "task-2"))
+	latest, err := k8s.Instance().GetPodByUID(pod.UID, pod.Namespace)
+	require.NoError(t, err, "Error getting pod")
+	require.Equal(t, "true", latest.Labels[backgroundActionsKey])
+	actions, err := getBackgroundActions(latest)
+	require.NoError(t, err, "Error getting background actions")
+	require.Len(t, actions, 2)
+	require.Equal(t, "db", actions[0].Container)
+	require.WithinDuration(t, time.Now().Add(5*time.Minute), actions[0].Deadline.Time, 5*time.Second)
+
+	// Nothing is terminated before the deadline
+	controller := &CleanupController{}
+	require.NoError(t, controller.cleanup())
+	latest, err = k8s.Instance().GetPodByUID(pod.UID, pod.Namespace)
+	require.NoError(t, err, "Error getting pod")
+	actions, err = getBackgroundActions(latest)
+	require.NoError(t, err, "Error getting background actions")
+	require.Len(t, actions, 2)
+
+	// Actions stop being tracked once they are finished
+	finishBackgroundActions([]v1.Pod{*pod}, "task-1")
+	latest, err = k8s.Instance().GetPodByUID(pod.UID, pod.Namespace)
+	require.NoError(t, err, "Error getting pod")
+	actions, err = getBackgroundActions(latest)
+	require.NoError(t, err, "Error getting background actions")
+	require.Len(t, actions, 1)
+	require.Equal(t, "task-2", actions[0].TaskID)
+
+	finishBackgroundActions([]v1.Pod{*pod}, "task-2")
+	latest, err = k8s.Instance().GetPodByUID(pod.UID, pod.Namespace)
+	require.NoError(t, err, "Error getting pod")
+	require.NotContains(t, latest.Labels, backgroundActionsKey)
+	require.NotContains(t, latest.Annotations, backgroundActionsKey)
+}
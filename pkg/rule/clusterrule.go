@@ -0,0 +1,109 @@
+package rule
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/libopenstorage/stork/pkg/apis/stork"
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	storkclientset "github.com/libopenstorage/stork/pkg/client/clientset/versioned"
+	"github.com/portworx/sched-ops/k8s"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// ClusterRulePrefix is the prefix used to reference a ClusterRule
+	// instead of a Rule in the namespace, for eg "clusterrule/fsfreeze"
+	ClusterRulePrefix = "clusterrule/"
+	// ClusterRuleUseVerb is the verb that users need to be granted on a
+	// ClusterRule to reference it
+	ClusterRuleUseVerb = "use"
+)
+
+var storkClient storkclientset.Interface
+
+// SetStorkClient sets the client used to get cluster rules
+func SetStorkClient(client storkclientset.Interface) {
+	storkClient = client
+}
+
+// IsClusterRule returns true if the rule name refers to a ClusterRule
+func IsClusterRule(name string) bool {
+	return strings.HasPrefix(name, ClusterRulePrefix)
+}
+
+// GetClusterRuleName returns the name of the ClusterRule referenced by the
+// rule name
+func GetClusterRuleName(name string) string {
+	return strings.TrimPrefix(name, ClusterRulePrefix)
+}
+
+func createClusterRuleCRD() error {
+	resource := k8s.CustomResource{
+		Name:    stork_api.ClusterRuleResourceName,
+		Plural:  stork_api.ClusterRuleResourcePlural,
+		Group:   stork.GroupName,
+		Version: stork_api.SchemeGroupVersion.Version,
+		Scope:   apiextensionsv1beta1.ClusterScoped,
+		Kind:    reflect.TypeOf(stork_api.ClusterRule{}).Name(),
+	}
+	err := k8s.Instance().CreateCRD(resource)
+	if err != nil && !errors.IsAlreadyExists(err) {
+		return err
+	}
+
+	return k8s.Instance().ValidateCRD(resource, validateCRDTimeout, validateCRDInterval)
+}
+
+// clusterRuleAllowed checks if the cluster rule can be referenced from the
+// namespace, ie the labels of the namespace match the namespace selector of
+// the rule. Rules without a selector can't be referenced from any namespace,
+// since the webhook checking the use verb can be skipped while stork is down.
+func clusterRuleAllowed(clusterRule *stork_api.ClusterRule, namespace string) (bool, error) {
+	if clusterRule.NamespaceSelector == nil {
+		return false, nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(clusterRule.NamespaceSelector)
+	if err != nil {
+		return false, fmt.Errorf("invalid namespaceSelector in cluster rule %v: %v", clusterRule.Name, err)
+	}
+	ns, err := k8s.Instance().GetNamespace(namespace)
+	if err != nil {
+		return false, err
+	}
+	return selector.Matches(labels.Set(ns.Labels)), nil
+}
+
+// getClusterRule returns the rule for a ClusterRule referenced from the
+// namespace. The rule is run in the namespace, so the selectors of the rule
+// match pods in that namespace.
+func getClusterRule(name string, namespace string) (*stork_api.Rule, error) {
+	if storkClient == nil {
+		return nil, fmt.Errorf("client to get cluster rules hasn't been set")
+	}
+	clusterRuleName := GetClusterRuleName(name)
+	clusterRule, err := storkClient.StorkV1alpha1().ClusterRules().Get(clusterRuleName, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	allowed, err := clusterRuleAllowed(clusterRule, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("error checking if namespace %v can use cluster rule %v: %v", namespace, clusterRuleName, err)
+	}
+	if !allowed {
+		return nil, fmt.Errorf("cluster rule %v can't be used in namespace %v", clusterRuleName, namespace)
+	}
+
+	return &stork_api.Rule{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   namespace,
+			Annotations: clusterRule.Annotations,
+		},
+		Rules: clusterRule.DeepCopy().Rules,
+	}, nil
+}
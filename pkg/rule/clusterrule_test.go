@@ -0,0 +1,77 @@
+// +build unittest
+
+package rule
+
+import (
+	"testing"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	fakeclient "github.com/libopenstorage/stork/pkg/client/clientset/versioned/fake"
+	"github.com/portworx/sched-ops/k8s"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakekube "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestGetClusterRule(t *testing.T) {
+	fakeStorkClient := fakeclient.NewSimpleClientset()
+	k8s.Instance().SetClient(fakekube.NewSimpleClientset(), nil, fakeStorkClient, nil, nil, nil)
+	SetStorkClient(fakeStorkClient)
+	for name, tier := range map[string]string{"app": "silver", "db": "gold"} {
+		_, err := k8s.Instance().CreateNamespace(name, map[string]string{"tier": tier})
+		require.NoError(t, err, "Error creating namespace")
+	}
+
+	clusterRule := &stork_api.ClusterRule{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "fsfreeze",
+			Annotations: map[string]string{TemplateCommandsAnnotation: "true"},
+		},
+		NamespaceSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{"tier": "gold"},
+		},
+		Rules: []stork_api.RuleItem{
+			{
+				PodSelector: map[string]string{"app": "db"},
+				Actions: []stork_api.RuleAction{
+					{Type: stork_api.RuleActionCommand, Value: "fsfreeze -f /data"},
+				},
+			},
+		},
+	}
+	_, err := fakeStorkClient.StorkV1alpha1().ClusterRules().Create(clusterRule)
+	require.NoError(t, err, "Error creating cluster rule")
+
+	require.True(t, IsClusterRule(ClusterRulePrefix+"fsfreeze"))
+	require.False(t, IsClusterRule("fsfreeze"))
+
+	// The rule is run in the namespace referencing it
+	r, err := GetRule(ClusterRulePrefix+"fsfreeze", "db", PreExecRule, nil)
+	require.NoError(t, err, "Error getting cluster rule")
+	require.Equal(t, "db", r.Namespace)
+	require.Equal(t, ClusterRulePrefix+"fsfreeze", r.Name)
+	require.Equal(t, clusterRule.Rules, r.Rules)
+	require.Equal(t, "true", r.Annotations[TemplateCommandsAnnotation])
+
+	_, err = GetRule(ClusterRulePrefix+"fsfreeze", "app", PreExecRule, nil)
+	require.EqualError(t, err, "cluster rule fsfreeze can't be used in namespace app")
+
+	_, err = GetRule(ClusterRulePrefix+"missing", "db", PreExecRule, nil)
+	require.Error(t, err, "Expected error for missing cluster rule")
+
+	// Rules without a namespace selector can't be used from any namespace,
+	// an empty selector allows all of them
+	clusterRule.Name = "unrestricted"
+	clusterRule.NamespaceSelector = nil
+	_, err = fakeStorkClient.StorkV1alpha1().ClusterRules().Create(clusterRule)
+	require.NoError(t, err, "Error creating cluster rule")
+	_, err = GetRule(ClusterRulePrefix+"unrestricted", "db", PreExecRule, nil)
+	require.EqualError(t, err, "cluster rule unrestricted can't be used in namespace db")
+	clusterRule.NamespaceSelector = &metav1.LabelSelector{}
+	_, err = fakeStorkClient.StorkV1alpha1().ClusterRules().Update(clusterRule)
+	require.NoError(t, err, "Error updating cluster rule")
+	for _, ns := range []string{"app", "db"} {
+		_, err = GetRule(ClusterRulePrefix+"unrestricted", ns, PreExecRule, nil)
+		require.NoError(t, err, "Error getting cluster rule")
+	}
+}
@@ -0,0 +1,762 @@
+package rule
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Conditions are expressions in a subset of the Common Expression Language
+// (CEL) that are evaluated against each selected pod, available as the "pod"
+// variable, to check if an action should be run on it. For eg:
+//
+//   pod.metadata.labels['role'] == 'primary'
+//   has(pod.metadata.annotations.replica) && pod.status.phase in ['Running']
+//
+// The supported syntax is:
+//   - literals: strings in single or double quotes, integers, floats, true,
+//     false, null and lists in [ ]
+//   - field selection with "." and indexing of maps and lists with [ ]
+//   - operators, in decreasing order of precedence: ! and unary -,
+//     == != < <= > >= in, && and ||
+//   - functions: has(field), size(value), and the string methods
+//     startsWith, endsWith, contains and matches
+//
+// Selecting a field that doesn't exist is an error, like in CEL, so has()
+// should be used to check optional fields. && and || are evaluated from left
+// to right and stop once the result is known.
+
+// podConditionVariable is the name of the variable for the pod in conditions
+const podConditionVariable = "pod"
+
+// condition is a parsed condition expression
+type condition struct {
+	expression string
+	root       conditionNode
+}
+
+// conditionNode is a node in the syntax tree of a condition
+type conditionNode interface {
+	eval(vars map[string]interface{}) (interface{}, error)
+}
+
+// parseCondition parses a condition expression
+func parseCondition(expression string) (*condition, error) {
+	tokens, err := tokenizeCondition(expression)
+	if err != nil {
+		return nil, fmt.Errorf("invalid condition %q: %v", expression, err)
+	}
+	p := &conditionParser{tokens: tokens}
+	root, err := p.parseOr()
+	if err == nil && p.peek().kind != tokenEOF {
+		err = fmt.Errorf("unexpected %q", p.peek().value)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid condition %q: %v", expression, err)
+	}
+	return &condition{expression: expression, root: root}, nil
+}
+
+// matches evaluates the condition for the pod. The condition has to evaluate
+// to a bool.
+func (c *condition) matches(pod *v1.Pod) (bool, error) {
+	podValue, err := runtime.DefaultUnstructuredConverter.ToUnstructured(pod)
+	if err != nil {
+		return false, err
+	}
+	result, err := c.root.eval(map[string]interface{}{podConditionVariable: podValue})
+	if err != nil {
+		return false, fmt.Errorf("error evaluating condition %q for pod: [%s] %s: %v",
+			c.expression, pod.GetNamespace(), pod.GetName(), err)
+	}
+	matched, ok := result.(bool)
+	if !ok {
+		return false, fmt.Errorf("condition %q evaluated to %v for pod: [%s] %s, expected a bool",
+			c.expression, result, pod.GetNamespace(), pod.GetName())
+	}
+	return matched, nil
+}
+
+// filterPodsByCondition returns the pods for which the condition is true. All
+// the pods are returned if there is no condition.
+func filterPodsByCondition(pods []v1.Pod, expression string) ([]v1.Pod, error) {
+	if expression == "" {
+		return pods, nil
+	}
+	c, err := parseCondition(expression)
+	if err != nil {
+		return nil, err
+	}
+	filtered := make([]v1.Pod, 0)
+	for _, pod := range pods {
+		matched, err := c.matches(&pod)
+		if err != nil {
+			return nil, err
+		}
+		if matched {
+			filtered = append(filtered, pod)
+		}
+	}
+	return filtered, nil
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenInt
+	tokenFloat
+	tokenOperator
+)
+
+type conditionToken struct {
+	kind  tokenKind
+	value string
+}
+
+var conditionOperators = []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!", "-", "(", ")", "[", "]", ".", ","}
+
+func tokenizeCondition(expression string) ([]conditionToken, error) {
+	tokens := make([]conditionToken, 0)
+	input := []rune(expression)
+	for i := 0; i < len(input); {
+		r := input[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(input) && (unicode.IsLetter(input[i]) || unicode.IsDigit(input[i]) || input[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, conditionToken{kind: tokenIdent, value: string(input[start:i])})
+		case unicode.IsDigit(r):
+			start := i
+			kind := tokenInt
+			for i < len(input) && (unicode.IsDigit(input[i]) || input[i] == '.') {
+				if input[i] == '.' {
+					kind = tokenFloat
+				}
+				i++
+			}
+			tokens = append(tokens, conditionToken{kind: kind, value: string(input[start:i])})
+		case r == '\'' || r == '"':
+			var value strings.Builder
+			i++
+			for ; i < len(input) && input[i] != r; i++ {
+				if input[i] == '\\' && i+1 < len(input) {
+					i++
+				}
+				value.WriteRune(input[i])
+			}
+			if i == len(input) {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			i++
+			tokens = append(tokens, conditionToken{kind: tokenString, value: value.String()})
+		default:
+			matched := false
+			for _, op := range conditionOperators {
+				if strings.HasPrefix(string(input[i:]), op) {
+					tokens = append(tokens, conditionToken{kind: tokenOperator, value: op})
+					i += len([]rune(op))
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("unexpected character %q", r)
+			}
+		}
+	}
+	return append(tokens, conditionToken{kind: tokenEOF}), nil
+}
+
+type conditionParser struct {
+	tokens []conditionToken
+	pos    int
+}
+
+func (p *conditionParser) peek() conditionToken {
+	return p.tokens[p.pos]
+}
+
+func (p *conditionParser) next() conditionToken {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// accept consumes the next token if it is the given operator
+func (p *conditionParser) accept(op string) bool {
+	if t := p.peek(); t.kind == tokenOperator && t.value == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *conditionParser) expect(op string) error {
+	if !p.accept(op) {
+		if p.peek().kind == tokenEOF {
+			return fmt.Errorf("expected %q at end of expression", op)
+		}
+		return fmt.Errorf("expected %q, found %q", op, p.peek().value)
+	}
+	return nil
+}
+
+func (p *conditionParser) parseOr() (conditionNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{or: true, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *conditionParser) parseAnd() (conditionNode, error) {
+	left, err := p.parseRelation()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("&&") {
+		right, err := p.parseRelation()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *conditionParser) parseRelation() (conditionNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	t := p.peek()
+	op := ""
+	if t.kind == tokenOperator {
+		switch t.value {
+		case "==", "!=", "<", "<=", ">", ">=":
+			op = t.value
+		}
+	} else if t.kind == tokenIdent && t.value == "in" {
+		op = t.value
+	}
+	if op == "" {
+		return left, nil
+	}
+	p.next()
+	right, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	return &relationNode{op: op, left: left, right: right}, nil
+}
+
+func (p *conditionParser) parseUnary() (conditionNode, error) {
+	if p.accept("!") {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{operand: operand}, nil
+	}
+	if p.accept("-") {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &negateNode{operand: operand}, nil
+	}
+	return p.parseMember()
+}
+
+func (p *conditionParser) parseMember() (conditionNode, error) {
+	node, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if p.accept(".") {
+			t := p.next()
+			if t.kind != tokenIdent {
+				return nil, fmt.Errorf("expected field name after \".\"")
+			}
+			if p.accept("(") {
+				args, err := p.parseArgs()
+				if err != nil {
+					return nil, err
+				}
+				node = &callNode{name: t.value, target: node, args: args}
+			} else {
+				node = &selectNode{operand: node, field: t.value}
+			}
+		} else if p.accept("[") {
+			index, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			node = &indexNode{operand: node, index: index}
+		} else {
+			return node, nil
+		}
+	}
+}
+
+// parseArgs parses the arguments of a call after the opening parenthesis
+func (p *conditionParser) parseArgs() ([]conditionNode, error) {
+	return p.parseList(")")
+}
+
+func (p *conditionParser) parseList(end string) ([]conditionNode, error) {
+	items := make([]conditionNode, 0)
+	if p.accept(end) {
+		return items, nil
+	}
+	for {
+		item, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+		if p.accept(end) {
+			return items, nil
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *conditionParser) parsePrimary() (conditionNode, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenString:
+		return &literalNode{value: t.value}, nil
+	case tokenInt:
+		value, err := strconv.ParseInt(t.value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid integer %q", t.value)
+		}
+		return &literalNode{value: value}, nil
+	case tokenFloat:
+		value, err := strconv.ParseFloat(t.value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", t.value)
+		}
+		return &literalNode{value: value}, nil
+	case tokenIdent:
+		switch t.value {
+		case "true":
+			return &literalNode{value: true}, nil
+		case "false":
+			return &literalNode{value: false}, nil
+		case "null":
+			return &literalNode{value: nil}, nil
+		}
+		if !p.accept("(") {
+			return &variableNode{name: t.value}, nil
+		}
+		args, err := p.parseArgs()
+		if err != nil {
+			return nil, err
+		}
+		switch t.value {
+		case "has":
+			if len(args) != 1 {
+				return nil, fmt.Errorf("has() takes 1 argument")
+			}
+			field, ok := args[0].(*selectNode)
+			if !ok {
+				return nil, fmt.Errorf("has() requires a field selection, for eg has(pod.metadata.labels.role)")
+			}
+			return &hasNode{field: field}, nil
+		case "size":
+			if len(args) != 1 {
+				return nil, fmt.Errorf("size() takes 1 argument")
+			}
+			return &callNode{name: t.value, target: args[0]}, nil
+		}
+		return nil, fmt.Errorf("unknown function %v", t.value)
+	case tokenOperator:
+		switch t.value {
+		case "(":
+			node, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return node, nil
+		case "[":
+			items, err := p.parseList("]")
+			if err != nil {
+				return nil, err
+			}
+			return &listNode{items: items}, nil
+		}
+		return nil, fmt.Errorf("unexpected %q", t.value)
+	}
+	return nil, fmt.Errorf("unexpected end of expression")
+}
+
+type literalNode struct {
+	value interface{}
+}
+
+func (n *literalNode) eval(map[string]interface{}) (interface{}, error) {
+	return n.value, nil
+}
+
+type variableNode struct {
+	name string
+}
+
+func (n *variableNode) eval(vars map[string]interface{}) (interface{}, error) {
+	value, ok := vars[n.name]
+	if !ok {
+		return nil, fmt.Errorf("undeclared reference to %v", n.name)
+	}
+	return value, nil
+}
+
+type listNode struct {
+	items []conditionNode
+}
+
+func (n *listNode) eval(vars map[string]interface{}) (interface{}, error) {
+	values := make([]interface{}, 0, len(n.items))
+	for _, item := range n.items {
+		value, err := item.eval(vars)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+type selectNode struct {
+	operand conditionNode
+	field   string
+}
+
+func (n *selectNode) eval(vars map[string]interface{}) (interface{}, error) {
+	operand, err := n.operand.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	fields, ok := operand.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("can't select field %v from %v", n.field, typeName(operand))
+	}
+	value, ok := fields[n.field]
+	if !ok {
+		return nil, fmt.Errorf("no such key: %v", n.field)
+	}
+	return value, nil
+}
+
+type hasNode struct {
+	field *selectNode
+}
+
+func (n *hasNode) eval(vars map[string]interface{}) (interface{}, error) {
+	operand, err := n.field.operand.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	fields, ok := operand.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("can't check field %v in %v", n.field.field, typeName(operand))
+	}
+	_, ok = fields[n.field.field]
+	return ok, nil
+}
+
+type indexNode struct {
+	operand conditionNode
+	index   conditionNode
+}
+
+func (n *indexNode) eval(vars map[string]interface{}) (interface{}, error) {
+	operand, err := n.operand.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	index, err := n.index.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	switch o := operand.(type) {
+	case map[string]interface{}:
+		key, ok := index.(string)
+		if !ok {
+			return nil, fmt.Errorf("can't index map with %v", typeName(index))
+		}
+		value, ok := o[key]
+		if !ok {
+			return nil, fmt.Errorf("no such key: %v", key)
+		}
+		return value, nil
+	case []interface{}:
+		i, ok := index.(int64)
+		if !ok {
+			return nil, fmt.Errorf("can't index list with %v", typeName(index))
+		}
+		if i < 0 || i >= int64(len(o)) {
+			return nil, fmt.Errorf("index %v out of range", i)
+		}
+		return o[i], nil
+	}
+	return nil, fmt.Errorf("can't index %v", typeName(operand))
+}
+
+type notNode struct {
+	operand conditionNode
+}
+
+func (n *notNode) eval(vars map[string]interface{}) (interface{}, error) {
+	value, err := n.operand.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	b, ok := value.(bool)
+	if !ok {
+		return nil, fmt.Errorf("can't apply ! to %v", typeName(value))
+	}
+	return !b, nil
+}
+
+type negateNode struct {
+	operand conditionNode
+}
+
+func (n *negateNode) eval(vars map[string]interface{}) (interface{}, error) {
+	value, err := n.operand.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	switch v := value.(type) {
+	case int64:
+		return -v, nil
+	case float64:
+		return -v, nil
+	}
+	return nil, fmt.Errorf("can't apply - to %v", typeName(value))
+}
+
+type logicalNode struct {
+	or    bool
+	left  conditionNode
+	right conditionNode
+}
+
+func (n *logicalNode) eval(vars map[string]interface{}) (interface{}, error) {
+	for _, operand := range []conditionNode{n.left, n.right} {
+		value, err := operand.eval(vars)
+		if err != nil {
+			return nil, err
+		}
+		b, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("expected bool operands for && and ||, found %v", typeName(value))
+		}
+		// Stop once the result is known
+		if b == n.or {
+			return b, nil
+		}
+	}
+	return !n.or, nil
+}
+
+type relationNode struct {
+	op    string
+	left  conditionNode
+	right conditionNode
+}
+
+func (n *relationNode) eval(vars map[string]interface{}) (interface{}, error) {
+	left, err := n.left.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	right, err := n.right.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "==":
+		return valuesEqual(left, right), nil
+	case "!=":
+		return !valuesEqual(left, right), nil
+	case "in":
+		switch r := right.(type) {
+		case []interface{}:
+			for _, item := range r {
+				if valuesEqual(left, item) {
+					return true, nil
+				}
+			}
+			return false, nil
+		case map[string]interface{}:
+			key, ok := left.(string)
+			if !ok {
+				return nil, fmt.Errorf("map keys are strings, found %v", typeName(left))
+			}
+			_, ok = r[key]
+			return ok, nil
+		}
+		return nil, fmt.Errorf("can't use in with %v", typeName(right))
+	}
+
+	var compared int
+	if l, ok := toFloat(left); ok {
+		r, ok := toFloat(right)
+		if !ok {
+			return nil, fmt.Errorf("can't compare %v and %v", typeName(left), typeName(right))
+		}
+		if l < r {
+			compared = -1
+		} else if l > r {
+			compared = 1
+		}
+	} else if l, ok := left.(string); ok {
+		r, ok := right.(string)
+		if !ok {
+			return nil, fmt.Errorf("can't compare %v and %v", typeName(left), typeName(right))
+		}
+		compared = strings.Compare(l, r)
+	} else {
+		return nil, fmt.Errorf("can't compare %v and %v", typeName(left), typeName(right))
+	}
+	switch n.op {
+	case "<":
+		return compared < 0, nil
+	case "<=":
+		return compared <= 0, nil
+	case ">":
+		return compared > 0, nil
+	}
+	return compared >= 0, nil
+}
+
+type callNode struct {
+	name   string
+	target conditionNode
+	args   []conditionNode
+}
+
+func (n *callNode) eval(vars map[string]interface{}) (interface{}, error) {
+	target, err := n.target.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	if n.name == "size" {
+		if len(n.args) != 0 {
+			return nil, fmt.Errorf("size() takes no arguments")
+		}
+		switch t := target.(type) {
+		case string:
+			return int64(len([]rune(t))), nil
+		case []interface{}:
+			return int64(len(t)), nil
+		case map[string]interface{}:
+			return int64(len(t)), nil
+		}
+		return nil, fmt.Errorf("can't get size of %v", typeName(target))
+	}
+
+	s, ok := target.(string)
+	if !ok {
+		return nil, fmt.Errorf("can't call %v() on %v", n.name, typeName(target))
+	}
+	if len(n.args) != 1 {
+		return nil, fmt.Errorf("%v() takes 1 argument", n.name)
+	}
+	value, err := n.args[0].eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	arg, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("%v() takes a string argument, found %v", n.name, typeName(value))
+	}
+	switch n.name {
+	case "startsWith":
+		return strings.HasPrefix(s, arg), nil
+	case "endsWith":
+		return strings.HasSuffix(s, arg), nil
+	case "contains":
+		return strings.Contains(s, arg), nil
+	case "matches":
+		re, err := regexp.Compile(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression %q: %v", arg, err)
+		}
+		return re.MatchString(s), nil
+	}
+	return nil, fmt.Errorf("unknown function %v", n.name)
+}
+
+// toFloat converts numbers to float64 so that ints and floats can be compared
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+func valuesEqual(left interface{}, right interface{}) bool {
+	if l, ok := toFloat(left); ok {
+		r, ok := toFloat(right)
+		return ok && l == r
+	}
+	return reflect.DeepEqual(left, right)
+}
+
+func typeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "bool"
+	case int64:
+		return "int"
+	case float64:
+		return "double"
+	case string:
+		return "string"
+	case []interface{}:
+		return "list"
+	case map[string]interface{}:
+		return "map"
+	}
+	return fmt.Sprintf("%T", value)
+}
@@ -0,0 +1,115 @@
+// +build unittest
+
+package rule
+
+import (
+	"testing"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newConditionPod(name string, role string) v1.Pod {
+	pod := v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "ns",
+			Labels:    map[string]string{"app": "postgres"},
+		},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{{Name: "postgres"}, {Name: "exporter"}},
+		},
+		Status: v1.PodStatus{Phase: v1.PodRunning},
+	}
+	if role != "" {
+		pod.Labels["role"] = role
+	}
+	return pod
+}
+
+func TestConditionMatches(t *testing.T) {
+	primary := newConditionPod("postgres-0", "primary")
+	replica := newConditionPod("postgres-1", "replica")
+
+	for expression, expected := range map[string][]bool{
+		"pod.metadata.labels['role'] == 'primary'":                                    {true, false},
+		`pod.metadata.labels.role != "primary"`:                                       {false, true},
+		"pod.metadata.name.endsWith('-0') && pod.status.phase == 'Running'":           {true, false},
+		"pod.metadata.labels.role in ['primary', 'leader']":                           {true, false},
+		"'role' in pod.metadata.labels":                                               {true, true},
+		"size(pod.spec.containers) == 2 && pod.spec.containers[1].name == 'exporter'": {true, true},
+		"!pod.metadata.name.matches('^postgres-[1-9]$')":                              {true, false},
+		"has(pod.metadata.labels.role) && pod.metadata.labels.role.startsWith('pri')": {true, false},
+		"pod.metadata.name.size() > 9 || -1 >= 0":                                     {true, true},
+		"(false || true) && !(1 < 0.5)":                                               {true, true},
+	} {
+		c, err := parseCondition(expression)
+		require.NoError(t, err, "Error parsing condition %v", expression)
+		for i, pod := range []v1.Pod{primary, replica} {
+			matched, err := c.matches(&pod)
+			require.NoError(t, err, "Error evaluating condition %v", expression)
+			require.Equal(t, expected[i], matched, "Unexpected result for condition %v on pod %v", expression, pod.Name)
+		}
+	}
+
+	// Selecting a missing field is an error unless it is checked with has()
+	unlabelled := newConditionPod("postgres-2", "")
+	c, err := parseCondition("pod.metadata.labels.role == 'primary'")
+	require.NoError(t, err, "Error parsing condition")
+	_, err = c.matches(&unlabelled)
+	require.EqualError(t, err, `error evaluating condition "pod.metadata.labels.role == 'primary'" for pod: [ns] postgres-2: no such key: role`)
+	c, err = parseCondition("has(pod.metadata.labels.role) && pod.metadata.labels.role == 'primary'")
+	require.NoError(t, err, "Error parsing condition")
+	matched, err := c.matches(&unlabelled)
+	require.NoError(t, err, "Error evaluating condition")
+	require.False(t, matched)
+
+	c, err = parseCondition("pod.metadata.name")
+	require.NoError(t, err, "Error parsing condition")
+	_, err = c.matches(&primary)
+	require.EqualError(t, err, `condition "pod.metadata.name" evaluated to postgres-0 for pod: [ns] postgres-0, expected a bool`)
+
+	for _, expression := range []string{
+		"pod.metadata.name ==",
+		"pod.metadata.labels['role'",
+		"'primary",
+		"pod.metadata.name # 1",
+		"exists(pod.metadata)",
+		"has(pod)",
+		"pod.metadata.name == 'a' 'b'",
+	} {
+		_, err := parseCondition(expression)
+		require.Error(t, err, "Expected error parsing condition %v", expression)
+	}
+}
+
+func TestFilterPodsByCondition(t *testing.T) {
+	pods := []v1.Pod{newConditionPod("postgres-0", "primary"), newConditionPod("postgres-1", "replica")}
+
+	filtered, err := filterPodsByCondition(pods, "")
+	require.NoError(t, err, "Error filtering pods")
+	require.Equal(t, pods, filtered)
+
+	filtered, err = filterPodsByCondition(pods, "pod.metadata.labels.role == 'primary'")
+	require.NoError(t, err, "Error filtering pods")
+	require.Len(t, filtered, 1)
+	require.Equal(t, "postgres-0", filtered[0].Name)
+
+	action := stork_api.RuleAction{
+		Type:      stork_api.RuleActionCommand,
+		Value:     "psql -c 'CHECKPOINT'",
+		Condition: "pod.metadata.labels.role == 'primary'",
+	}
+	require.NoError(t, ValidateRule(newJobRule(action), PreExecRule))
+	action.Condition = "pod.metadata.labels.role = 'primary'"
+	require.Error(t, ValidateRule(newJobRule(action), PreExecRule), "Expected error for invalid condition")
+	action = stork_api.RuleAction{
+		Type:      stork_api.RuleActionJob,
+		Value:     "quiesce",
+		Condition: "true",
+		Job:       &stork_api.RuleActionJobSpec{Image: "tools:1.0"},
+	}
+	require.Error(t, ValidateRule(newJobRule(action), PreExecRule), "Expected error for condition on job action")
+}
@@ -0,0 +1,101 @@
+package rule
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"k8s.io/api/core/v1"
+)
+
+// validateOrdering validates the parallelism and order of a command action
+func validateOrdering(action stork_api.RuleAction) error {
+	switch action.Parallelism {
+	case "", stork_api.RuleActionParallel, stork_api.RuleActionSerial:
+	default:
+		return fmt.Errorf("invalid parallelism %v, expected %v or %v",
+			action.Parallelism, stork_api.RuleActionParallel, stork_api.RuleActionSerial)
+	}
+	if action.Order == nil {
+		return nil
+	}
+	if action.Parallelism == stork_api.RuleActionParallel {
+		return fmt.Errorf("order can't be specified for actions that run in parallel")
+	}
+	if action.Order.Label == "" {
+		return fmt.Errorf("label is required for the order of an action")
+	}
+	return nil
+}
+
+// runsSerially checks if the command for the action is run in one pod at a
+// time
+func runsSerially(action stork_api.RuleAction) bool {
+	return action.Parallelism == stork_api.RuleActionSerial ||
+		(action.Parallelism == "" && action.Order != nil)
+}
+
+// orderPods returns the pods in the order in which the command for the action
+// is run. The order of the pods isn't changed for actions that run in
+// parallel.
+func orderPods(pods []v1.Pod, action stork_api.RuleAction) []v1.Pod {
+	if !runsSerially(action) {
+		return pods
+	}
+	ordered := append([]v1.Pod{}, pods...)
+	order := action.Order
+	if order == nil {
+		sort.SliceStable(ordered, func(i, j int) bool {
+			return ordered[i].GetName() < ordered[j].GetName()
+		})
+		return ordered
+	}
+
+	ranks := make(map[string]int)
+	for i, value := range order.Values {
+		if _, ok := ranks[value]; !ok {
+			ranks[value] = i
+		}
+	}
+	// rank returns the position of the pod in the list of values. Pods that
+	// aren't listed, or don't have the label, are picked last.
+	rank := func(pod v1.Pod) int {
+		value, ok := pod.GetLabels()[order.Label]
+		if !ok {
+			return len(order.Values) + 1
+		}
+		if len(order.Values) == 0 {
+			return 0
+		}
+		if r, ok := ranks[value]; ok {
+			return r
+		}
+		return len(order.Values)
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		ri, rj := rank(ordered[i]), rank(ordered[j])
+		if ri != rj {
+			return ri < rj
+		}
+		if len(order.Values) == 0 {
+			vi, vj := ordered[i].GetLabels()[order.Label], ordered[j].GetLabels()[order.Label]
+			if vi != vj {
+				return labelValueLess(vi, vj)
+			}
+		}
+		return ordered[i].GetName() < ordered[j].GetName()
+	})
+	return ordered
+}
+
+// labelValueLess compares label values numerically if both are numbers, and
+// lexically otherwise
+func labelValueLess(a string, b string) bool {
+	na, errA := strconv.ParseInt(a, 10, 64)
+	nb, errB := strconv.ParseInt(b, 10, 64)
+	if errA == nil && errB == nil {
+		return na < nb
+	}
+	return a < b
+}
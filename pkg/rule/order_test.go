@@ -0,0 +1,76 @@
+// +build unittest
+
+package rule
+
+import (
+	"testing"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newOrderPod(name string, labels map[string]string) v1.Pod {
+	return v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns", Labels: labels}}
+}
+
+func podNames(pods []v1.Pod) []string {
+	names := make([]string, 0, len(pods))
+	for _, pod := range pods {
+		names = append(names, pod.Name)
+	}
+	return names
+}
+
+func TestOrderPods(t *testing.T) {
+	pods := []v1.Pod{
+		newOrderPod("db-c", map[string]string{"role": "replica", "member": "10"}),
+		newOrderPod("db-b", map[string]string{"role": "primary", "member": "2"}),
+		newOrderPod("db-d", map[string]string{}),
+		newOrderPod("db-a", map[string]string{"role": "arbiter", "member": "1"}),
+	}
+	action := stork_api.RuleAction{Type: stork_api.RuleActionCommand, Value: "quiesce"}
+
+	// Pods aren't reordered for actions that run in parallel
+	require.Equal(t, []string{"db-c", "db-b", "db-d", "db-a"}, podNames(orderPods(pods, action)))
+
+	action.Parallelism = stork_api.RuleActionSerial
+	require.Equal(t, []string{"db-a", "db-b", "db-c", "db-d"}, podNames(orderPods(pods, action)))
+
+	// Numeric label values are sorted as numbers
+	action.Parallelism = ""
+	action.Order = &stork_api.RuleActionOrder{Label: "member"}
+	require.Equal(t, []string{"db-a", "db-b", "db-c", "db-d"}, podNames(orderPods(pods, action)))
+
+	// Pods with unlisted values come after the listed ones, followed by
+	// pods without the label
+	action.Order = &stork_api.RuleActionOrder{Label: "role", Values: []string{"primary", "replica"}}
+	require.Equal(t, []string{"db-b", "db-c", "db-a", "db-d"}, podNames(orderPods(pods, action)))
+	// The pods passed in aren't modified
+	require.Equal(t, "db-c", pods[0].Name)
+}
+
+func TestValidateOrdering(t *testing.T) {
+	action := stork_api.RuleAction{Type: stork_api.RuleActionCommand, Value: "quiesce", Parallelism: "Random"}
+	require.Error(t, ValidateRule(newJobRule(action), PreExecRule), "Expected error for invalid parallelism")
+
+	action.Parallelism = stork_api.RuleActionParallel
+	require.NoError(t, ValidateRule(newJobRule(action), PreExecRule))
+	action.Order = &stork_api.RuleActionOrder{Label: "role"}
+	require.Error(t, ValidateRule(newJobRule(action), PreExecRule), "Expected error for order with parallel actions")
+
+	action.Parallelism = ""
+	require.NoError(t, ValidateRule(newJobRule(action), PreExecRule))
+	require.True(t, runsSerially(action))
+	action.Order.Label = ""
+	require.Error(t, ValidateRule(newJobRule(action), PreExecRule), "Expected error for order without label")
+
+	action = stork_api.RuleAction{
+		Type:        stork_api.RuleActionJob,
+		Value:       "quiesce",
+		Parallelism: stork_api.RuleActionSerial,
+		Job:         &stork_api.RuleActionJobSpec{Image: "tools:1.0"},
+	}
+	require.Error(t, ValidateRule(newJobRule(action), PreExecRule), "Expected error for parallelism on job action")
+}
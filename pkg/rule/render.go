@@ -0,0 +1,127 @@
+package rule
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	// TemplateCommandsAnnotation is the annotation on rules to render their
+	// commands as Go templates with the parameters of the object that
+	// triggered the rule. This is opt-in so that existing commands with "{{"
+	// aren't changed.
+	TemplateCommandsAnnotation = "stork.libopenstorage.org/template-commands"
+)
+
+// Parameters are the values passed to rules from the object that triggered
+// them
+type Parameters struct {
+	// PVCs are the names of the PVCs for the operation
+	PVCs []string
+	// Values are custom parameters, for eg from the schedule that created
+	// the object
+	Values map[string]string
+}
+
+// templateData is the data that can be referenced in the commands of a rule,
+// for eg {{.Namespace}} or {{.Parameters.keyspace}}
+type templateData struct {
+	// Namespace in which the rule is run
+	Namespace string
+	// Name of the object that triggered the rule
+	Name string
+	// Kind of the object that triggered the rule
+	Kind string
+	// PVCs are the names of the PVCs for the operation
+	PVCs []string
+	// Parameters are the custom parameters
+	Parameters map[string]string
+}
+
+var templateFuncs = template.FuncMap{
+	"join": strings.Join,
+}
+
+// renderRule returns a copy of the rule with the commands rendered as
+// templates if the rule has the template annotation. The rule is returned as
+// is otherwise.
+func renderRule(
+	rule *stork_api.Rule,
+	owner runtime.Object,
+	namespace string,
+	parameters *Parameters,
+) (*stork_api.Rule, error) {
+	if rule.GetAnnotations()[TemplateCommandsAnnotation] != "true" {
+		return rule, nil
+	}
+
+	data := &templateData{
+		Namespace:  namespace,
+		PVCs:       make([]string, 0),
+		Parameters: make(map[string]string),
+	}
+	if metadata, err := meta.Accessor(owner); err == nil {
+		data.Name = metadata.GetName()
+	}
+	if objectType, err := meta.TypeAccessor(owner); err == nil {
+		data.Kind = objectType.GetKind()
+	}
+	if parameters != nil {
+		data.PVCs = append(data.PVCs, parameters.PVCs...)
+		for k, v := range parameters.Values {
+			if err := validateParameterValue(k, v); err != nil {
+				return nil, fmt.Errorf("failed to render rule: [%s] %s due to: %v",
+					rule.GetNamespace(), rule.GetName(), err)
+			}
+			data.Parameters[k] = v
+		}
+	}
+
+	rendered := rule.DeepCopy()
+	for i := range rendered.Rules {
+		for j := range rendered.Rules[i].Actions {
+			action := &rendered.Rules[i].Actions[j]
+			var err error
+			if action.Value, err = renderCommand(action.Value, data); err != nil {
+				return nil, fmt.Errorf("failed to render command in rule: [%s] %s due to: %v",
+					rule.GetNamespace(), rule.GetName(), err)
+			}
+			if action.Cleanup, err = renderCommand(action.Cleanup, data); err != nil {
+				return nil, fmt.Errorf("failed to render cleanup in rule: [%s] %s due to: %v",
+					rule.GetNamespace(), rule.GetName(), err)
+			}
+			if action.Job != nil {
+				for k := range action.Job.Command {
+					if action.Job.Command[k], err = renderCommand(action.Job.Command[k], data); err != nil {
+						return nil, fmt.Errorf("failed to render job command in rule: [%s] %s due to: %v",
+							rule.GetNamespace(), rule.GetName(), err)
+					}
+				}
+			}
+		}
+	}
+	return rendered, nil
+}
+
+// renderCommand renders the command as a template. Parameters that aren't
+// set are errors so that commands aren't run with empty values.
+func renderCommand(command string, data *templateData) (string, error) {
+	if !strings.Contains(command, "{{") {
+		return command, nil
+	}
+	t, err := template.New("command").Funcs(templateFuncs).Option("missingkey=error").Parse(command)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
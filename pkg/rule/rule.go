@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"math"
 	"reflect"
+	"regexp"
 	"strconv"
 	"strings"
 	"sync"
@@ -18,6 +19,7 @@ import (
 	"github.com/portworx/sched-ops/k8s"
 	"github.com/sirupsen/logrus"
 	"github.com/skyrings/skyring-common/tools/uuid"
+	batchv1 "k8s.io/api/batch/v1"
 	"k8s.io/api/core/v1"
 	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -26,6 +28,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/kubernetes/pkg/apis/core"
 )
 
@@ -38,17 +41,34 @@ const (
 	storkServiceAccount                  = "stork-account"
 	podsWithRunningCommandsKeyDeprecated = "stork/pods-with-running-cmds"
 	podsWithRunningCommandsKey           = "stork.libopenstorage.org/pods-with-running-cmds"
+	// defaultContainerAnnotation is the annotation on pods with the name of
+	// the container in which commands are run by default
+	defaultContainerAnnotation = "kubectl.kubernetes.io/default-container"
 
 	// constants
 	perPodCommandExecTimeout = 900 // 15 minutes
+	// jobActionTimeout is the maximum time for which the Job for a job action
+	// can run
+	jobActionTimeout = 15 * time.Minute
+	// JobServiceAccountAnnotation is the annotation that needs to be set to
+	// true on a service account for it to be used by job actions. Anyone who
+	// can create rules would otherwise be able to run Jobs as any service
+	// account in the namespace.
+	JobServiceAccountAnnotation = "stork.libopenstorage.org/allow-rule-jobs"
 
 	execPodCmdRetryInterval = 5 * time.Second
 	execPodCmdRetryFactor   = 1
 	execPodStepLow          = 12
 	execPodStepMed          = 36
 	execPodStepsHigh        = math.MaxInt32
+
+	// maxRuleResultOutputLength is the maximum length of the output and error
+	// recorded in the result for a rule action
+	maxRuleResultOutputLength = 512
 )
 
+var exitCodeRegex = regexp.MustCompile(`exit code (\d+)`)
+
 // Type The type of rule to be executed
 type Type string
 
@@ -59,10 +79,19 @@ const (
 	PostExecRule Type = "postExecRule"
 )
 
-// Pod is a simple type to encapsulate a Pod's uid and namespace
+// Pod is a simple type to encapsulate a Pod's uid and namespace, along with
+// the container in which the command was run
 type Pod struct {
 	UID       string `json:"uid"`
 	Namespace string `json:"namespace"`
+	Container string `json:"container,omitempty"`
+}
+
+// podContainer is a pod along with the container in it in which a command
+// was run
+type podContainer struct {
+	pod       v1.Pod
+	container string
 }
 
 type podErrorResponse struct {
@@ -88,6 +117,14 @@ var ownerAPICallBackoff = wait.Backoff{
 	Steps:    20,
 }
 
+var kubeClient kubernetes.Interface
+
+// SetKubeClient sets the client used to check the service accounts for job
+// actions
+func SetKubeClient(client kubernetes.Interface) {
+	kubeClient = client
+}
+
 // Init initializes the rule executor
 func Init() error {
 	storkRuleResource := k8s.CustomResource{
@@ -111,6 +148,10 @@ func Init() error {
 		return fmt.Errorf("failed to validate stork rules CRD due to: %v", err)
 	}
 
+	if err := createClusterRuleCRD(); err != nil {
+		return fmt.Errorf("failed to create cluster rules CRD due to: %v", err)
+	}
+
 	return nil
 }
 
@@ -122,6 +163,44 @@ func ValidateRule(rule *stork_api.Rule, ruleType Type) error {
 				if action.Background && ruleType == PostExecRule {
 					return fmt.Errorf("background actions are not supported for post exec rules")
 				}
+				if (action.TimeoutSeconds != 0 || action.Cleanup != "") && !action.Background {
+					return fmt.Errorf("timeoutSeconds and cleanup are only supported for background actions in rule: [%s] %s",
+						rule.GetNamespace(), rule.GetName())
+				}
+				if action.TimeoutSeconds < 0 {
+					return fmt.Errorf("timeoutSeconds can't be negative in rule: [%s] %s",
+						rule.GetNamespace(), rule.GetName())
+				}
+				if action.Retry != nil && (action.Retry.Attempts < 0 ||
+					action.Retry.IntervalSeconds < 0 || action.Retry.BackoffFactor < 0) {
+					return fmt.Errorf("retry values can't be negative in rule: [%s] %s",
+						rule.GetNamespace(), rule.GetName())
+				}
+				if err := validateOrdering(action); err != nil {
+					return fmt.Errorf("%v in rule: [%s] %s", err, rule.GetNamespace(), rule.GetName())
+				}
+				if action.Condition != "" {
+					if _, err := parseCondition(action.Condition); err != nil {
+						return fmt.Errorf("%v in rule: [%s] %s", err, rule.GetNamespace(), rule.GetName())
+					}
+				}
+			} else if action.Type == stork_api.RuleActionJob {
+				if action.Job == nil || action.Job.Image == "" {
+					return fmt.Errorf("image is required for job actions in rule: [%s] %s",
+						rule.GetNamespace(), rule.GetName())
+				}
+				if action.Background {
+					return fmt.Errorf("background is not supported for job actions in rule: [%s] %s",
+						rule.GetNamespace(), rule.GetName())
+				}
+				if action.Condition != "" || action.Parallelism != "" || action.Order != nil {
+					return fmt.Errorf("condition, parallelism and order are not supported for job actions in rule: [%s] %s",
+						rule.GetNamespace(), rule.GetName())
+				}
+				if len(action.Job.Command) == 0 && action.Value == "" {
+					return fmt.Errorf("command or value is required for job actions in rule: [%s] %s",
+						rule.GetNamespace(), rule.GetName())
+				}
 			} else {
 				return fmt.Errorf("unsupported action type: %s in rule: [%s] %s",
 					action.Type, rule.GetNamespace(), rule.GetName())
@@ -132,16 +211,50 @@ func ValidateRule(rule *stork_api.Rule, ruleType Type) error {
 	return nil
 }
 
+// MergeRuleResults replaces the results for the given rule type in the
+// existing results with the new results
+func MergeRuleResults(
+	existing []*stork_api.RuleExecutionResult,
+	results []*stork_api.RuleExecutionResult,
+	rType Type,
+) []*stork_api.RuleExecutionResult {
+	merged := make([]*stork_api.RuleExecutionResult, 0)
+	for _, result := range existing {
+		if result.Type != string(rType) {
+			merged = append(merged, result)
+		}
+	}
+	return append(merged, results...)
+}
+
 // terminateCommandInPods terminates a previously running background command on given pods for given task ID
-func terminateCommandInPods(owner runtime.Object, pods []v1.Pod, taskID string) error {
+func terminateCommandInPods(owner runtime.Object, pods []v1.Pod, containers map[types.UID]string, taskID string) error {
 	killFile := fmt.Sprintf(cmdexecutor.KillFileFormat, taskID)
-	failedPods, err := runCommandOnPods(pods, fmt.Sprintf("touch %s", killFile), execPodStepsHigh, false)
+	failedPods, _, err := runCommandOnPods(pods, containers, fmt.Sprintf("touch %s", killFile), execCmdBackoff, false)
 
-	updateErr := updateRunningCommandPodListInOwner(owner, failedPods, taskID)
+	updateErr := updateRunningCommandPodListInOwner(owner, failedPods, containers, taskID)
 	if updateErr != nil {
 		log.RuleLog(nil, owner).Warnf("Failed to update list of pods with running command in owner due to: %v", updateErr)
 	}
 
+	// Run the cleanup for the pods where the commands were terminated. The
+	// cleanup controller retries the pods that failed once their deadline
+	// passes.
+	terminated := make([]v1.Pod, 0)
+	for _, pod := range pods {
+		failed := false
+		for _, failedPod := range failedPods {
+			if failedPod.GetUID() == pod.GetUID() {
+				failed = true
+				break
+			}
+		}
+		if !failed {
+			terminated = append(terminated, pod)
+		}
+	}
+	finishBackgroundActions(terminated, taskID)
+
 	return err
 }
 
@@ -157,6 +270,7 @@ func PerformRuleRecovery(
 
 	if taskTracker != nil && len(taskTracker.Pods) > 0 {
 		backgroundPodList := make([]v1.Pod, 0)
+		containers := make(map[types.UID]string)
 		log.RuleLog(nil, owner).Infof("Performing recovery to terminate commands tracker: %v", taskTracker)
 		for _, pod := range taskTracker.Pods {
 			p, err := k8s.Instance().GetPodByUID(types.UID(pod.UID), pod.Namespace)
@@ -167,12 +281,12 @@ func PerformRuleRecovery(
 
 				metadata, err := meta.Accessor(owner)
 				if err != nil {
-					log.RuleLog(nil, owner).Warnf(err.Error())
+					log.RuleLog(nil, owner).Warn(err)
 					continue
 				}
 
 				err = fmt.Errorf("failed to get pod with uid: %s due to: %v", pod.UID, err)
-				log.RuleLog(nil, owner).Warnf(err.Error())
+				log.RuleLog(nil, owner).Warn(err)
 
 				ev := &v1.Event{
 					ObjectMeta: metav1.ObjectMeta{
@@ -200,9 +314,10 @@ func PerformRuleRecovery(
 			}
 
 			backgroundPodList = append(backgroundPodList, *p)
+			containers[p.GetUID()] = pod.Container
 		}
 
-		err = terminateCommandInPods(owner, backgroundPodList, taskTracker.TaskID)
+		err = terminateCommandInPods(owner, backgroundPodList, containers, taskTracker.TaskID)
 		if err != nil {
 			return fmt.Errorf("failed to terminate running commands in pods due to: %v", err)
 		}
@@ -212,44 +327,54 @@ func PerformRuleRecovery(
 }
 
 // ExecuteRule executes rules for the given owner. PVCs are used to figure out the pods on which the rule actions will be
-// run on
+// run on. The parameters are used to render the commands of rules that use templates and can be nil. The results of
+// the actions that were run on each pod are returned even if the rule fails.
 func ExecuteRule(
 	rule *stork_api.Rule,
 	rType Type,
 	owner runtime.Object,
 	podNamespace string,
-) (chan bool, error) {
+	parameters *Parameters,
+) (chan bool, []*stork_api.RuleExecutionResult, error) {
 	// Validate the rule. Don't depend on callers to invoke this
 	if err := ValidateRule(rule, rType); err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	rule, err := renderRule(rule, owner, podNamespace, parameters)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	log.RuleLog(rule, owner).Infof("Running %v", rType)
 	taskID, err := uuid.New()
 	if err != nil {
 		err = fmt.Errorf("failed to generate uuid for rule tasks due to: %v", err)
-		return nil, err
+		return nil, nil, err
 	}
 
 	pods := make([]v1.Pod, 0)
 	for _, item := range rule.Rules {
 		p, err := k8s.Instance().GetPods(podNamespace, item.PodSelector)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
 		pods = append(pods, p.Items...)
 	}
 
-	if len(pods) > 0 {
+	// Job actions don't need any pods to be selected since they are run in
+	// their own Job
+	if len(pods) > 0 || hasJobAction(rule) {
 		// start a watcher thread that will accumulate pods which have background commands to
 		// terminate and also watch a signal channel that indicates when to terminate them
 		backgroundCommandTermChan := make(chan bool, 1)
-		backgroundPodListChan := make(chan v1.Pod)
+		backgroundPodListChan := make(chan podContainer)
 		go cmdTerminationWatcher(backgroundPodListChan, backgroundCommandTermChan, owner, taskID.String())
 
 		// backgroundActionPresent is used to track if there is atleast one background action
 		backgroundActionPresent := false
+		results := make([]*stork_api.RuleExecutionResult, 0)
 		for _, item := range rule.Rules {
 			filteredPods := make([]v1.Pod, 0)
 			// filter pods and only uses the ones that match this selector
@@ -259,7 +384,7 @@ func ExecuteRule(
 				}
 			}
 
-			if len(filteredPods) == 0 {
+			if len(filteredPods) == 0 && !itemHasJobAction(item) {
 				log.RuleLog(rule, owner).Warnf("None of the pods matched selectors for rule spec: %v", item)
 				continue
 			}
@@ -269,32 +394,163 @@ func ExecuteRule(
 					backgroundActionPresent = true
 				}
 
+				var actionResults []*stork_api.RuleExecutionResult
+				var err error
 				if action.Type == stork_api.RuleActionCommand {
-					err := executeCommandAction(filteredPods, rule, owner, action, backgroundPodListChan, rType, taskID)
-					if err != nil {
-						// if any action fails, terminate all background jobs and don't depend on caller
-						// to clean them up
-						if backgroundActionPresent {
-							backgroundCommandTermChan <- true
-							return nil, err
+					var actionPods []v1.Pod
+					actionPods, err = filterPodsByCondition(filteredPods, action.Condition)
+					if err == nil {
+						if len(actionPods) == 0 {
+							log.RuleLog(rule, owner).Infof("Skipping action %v since none of the pods matched its condition: %v",
+								action.Value, action.Condition)
+							continue
 						}
-
-						backgroundCommandTermChan <- false
-						return nil, err
+						actionResults, err = executeCommandAction(actionPods, rule, owner, action, backgroundPodListChan, rType, taskID)
 					}
+				} else if action.Type == stork_api.RuleActionJob {
+					var result *stork_api.RuleExecutionResult
+					result, err = executeJobAction(rule, owner, action, podNamespace, rType, taskID, len(results))
+					actionResults = []*stork_api.RuleExecutionResult{result}
+				}
+				results = append(results, actionResults...)
+				if err != nil {
+					// if any action fails, terminate all background jobs and don't depend on caller
+					// to clean them up
+					if backgroundActionPresent {
+						backgroundCommandTermChan <- true
+						return nil, results, err
+					}
+
+					backgroundCommandTermChan <- false
+					return nil, results, err
 				}
 			}
 		}
 
 		if backgroundActionPresent {
-			return backgroundCommandTermChan, nil
+			return backgroundCommandTermChan, results, nil
 		}
 
 		backgroundCommandTermChan <- false
-		return nil, nil
+		return nil, results, nil
 	}
 
-	return nil, nil
+	return nil, nil, nil
+}
+
+// hasJobAction checks if any of the items in the rule have a job action
+func hasJobAction(rule *stork_api.Rule) bool {
+	for _, item := range rule.Rules {
+		if itemHasJobAction(item) {
+			return true
+		}
+	}
+	return false
+}
+
+func itemHasJobAction(item stork_api.RuleItem) bool {
+	for _, action := range item.Actions {
+		if action.Type == stork_api.RuleActionJob {
+			return true
+		}
+	}
+	return false
+}
+
+// executeJobAction runs the job type action in a Job in the given namespace
+// and waits for it to complete. The Job is deleted once it completes.
+func executeJobAction(
+	rule *stork_api.Rule,
+	owner runtime.Object,
+	action stork_api.RuleAction,
+	namespace string,
+	rType Type,
+	taskID *uuid.UUID,
+	index int,
+) (*stork_api.RuleExecutionResult, error) {
+	command := action.Job.Command
+	if len(command) == 0 {
+		command = []string{"sh", "-c", action.Value}
+	}
+	start := time.Now()
+	if err := checkJobServiceAccount(action.Job.ServiceAccount, namespace); err != nil {
+		result := newRuleExecutionResult(v1.Pod{}, strings.Join(command, " "), start, "", err)
+		result.Namespace = namespace
+		setRuleForResults([]*stork_api.RuleExecutionResult{result}, rule, rType)
+		return result, fmt.Errorf("job action failed in namespace %v due to: %v", namespace, err)
+	}
+	backoffLimit := int32(0)
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("stork-rule-%s-%d", taskID.String(), index),
+			Namespace: namespace,
+			Labels: map[string]string{
+				"app": "stork-rule",
+			},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: v1.PodTemplateSpec{
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{
+						{
+							Name:      "rule",
+							Image:     action.Job.Image,
+							Command:   command,
+							Resources: action.Job.Resources,
+						},
+					},
+					RestartPolicy:      v1.RestartPolicyNever,
+					ServiceAccountName: action.Job.ServiceAccount,
+				},
+			},
+		},
+	}
+
+	log.RuleLog(rule, owner).Infof("Running job action %v in job: [%s] %s", command, job.Namespace, job.Name)
+	err := runJob(job)
+	result := newRuleExecutionResult(v1.Pod{}, strings.Join(command, " "), start, "", err)
+	result.Job = job.Name
+	result.Namespace = namespace
+	setRuleForResults([]*stork_api.RuleExecutionResult{result}, rule, rType)
+	if err != nil {
+		return result, fmt.Errorf("job action failed in job: [%s] %s due to: %v", job.Namespace, job.Name, err)
+	}
+	return result, nil
+}
+
+// checkJobServiceAccount checks that the service account for a job action has
+// been allowed to be used by job actions with JobServiceAccountAnnotation. Jobs
+// without a service account run as the default service account of the
+// namespace.
+func checkJobServiceAccount(name string, namespace string) error {
+	if name == "" {
+		return nil
+	}
+	if kubeClient == nil {
+		return fmt.Errorf("kubernetes client not set to check service account %v", name)
+	}
+	serviceAccount, err := kubeClient.CoreV1().ServiceAccounts(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("error getting service account %v: %v", name, err)
+	}
+	if serviceAccount.Annotations[JobServiceAccountAnnotation] != "true" {
+		return fmt.Errorf("service account %v can't be used by job actions since it isn't annotated with %v=true",
+			name, JobServiceAccountAnnotation)
+	}
+	return nil
+}
+
+// runJob creates the job, waits for it to complete and deletes it
+func runJob(job *batchv1.Job) error {
+	if _, err := k8s.Instance().CreateJob(job); err != nil {
+		return err
+	}
+	jobErr := k8s.Instance().ValidateJob(job.Name, job.Namespace, jobActionTimeout)
+	if err := k8s.Instance().DeleteJob(job.Name, job.Namespace); err != nil && !errors.IsNotFound(err) {
+		logrus.Warnf("Failed to delete job: [%s] %s due to: %v", job.Namespace, job.Name, err)
+	}
+	return jobErr
 }
 
 // executeCommandAction executes the command type action on given pods:
@@ -303,12 +559,14 @@ func executeCommandAction(
 	rule *stork_api.Rule,
 	owner runtime.Object,
 	action stork_api.RuleAction,
-	backgroundPodNotifyChan chan v1.Pod,
-	rType Type, taskID *uuid.UUID) error {
+	backgroundPodNotifyChan chan podContainer,
+	rType Type, taskID *uuid.UUID) ([]*stork_api.RuleExecutionResult, error) {
 	if len(pods) == 0 {
-		return nil
+		return nil, nil
 	}
 
+	// With an order the single pod is the first one in the order
+	pods = orderPods(pods, action)
 	podsForAction := make([]v1.Pod, 0)
 	if action.RunInSinglePod {
 		podsForAction = []v1.Pod{pods[0]}
@@ -324,22 +582,33 @@ func executeCommandAction(
 		}
 	}
 
+	containers := make(map[types.UID]string)
+	for _, pod := range podsForAction {
+		container, err := getContainer(pod, action)
+		if err != nil {
+			return nil, err
+		}
+		containers[pod.GetUID()] = container
+	}
+
 	if action.Background {
 		for _, podToTerminate := range podsForAction {
-			backgroundPodNotifyChan <- podToTerminate
+			backgroundPodNotifyChan <- podContainer{pod: podToTerminate, container: containers[podToTerminate.GetUID()]}
 		}
 
 		// regardless of the outcome of running the background command, we first update the
 		// owner to track pods which might have a running background command
 		podsForTracker := make(map[string]v1.Pod)
+		containersForTracker := make(map[types.UID]string)
 		for _, pod := range podsForAction {
 			podsForTracker[string(pod.UID)] = pod
+			containersForTracker[pod.GetUID()] = containers[pod.GetUID()]
 		}
 
 		// Get pods already existing in tracker so we don't lose them
 		existingTracker, err := getPodsTrackerForOwner(owner)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		if existingTracker != nil && len(existingTracker.Pods) > 0 {
@@ -351,10 +620,11 @@ func executeCommandAction(
 						continue
 					}
 
-					return err
+					return nil, err
 				}
 
 				podsForTracker[existingPod.UID] = *existingPodObject
+				containersForTracker[existingPodObject.GetUID()] = existingPod.Container
 			}
 		}
 
@@ -363,22 +633,174 @@ func executeCommandAction(
 			podsForTrackerList = append(podsForTrackerList, pod)
 		}
 
-		updateErr := updateRunningCommandPodListInOwner(owner, podsForTrackerList, taskID.String())
+		updateErr := updateRunningCommandPodListInOwner(owner, podsForTrackerList, containersForTracker, taskID.String())
 		if updateErr != nil {
 			log.RuleLog(rule, owner).Warnf("Failed to update list of pods with running command in owner due to: %v", updateErr)
 		}
 
-		err = runBackgroundCommandOnPods(podsForAction, action.Value, taskID.String(), cmdExecutorImage)
+		// Track the action on the pods so that it gets terminated and
+		// cleaned up even if the owner is gone
+		if needsTracking(action) {
+			if err := trackBackgroundAction(podsForAction, containers, action, taskID.String()); err != nil {
+				return nil, err
+			}
+		}
+
+		// The command executor runs the command in the same container in
+		// all the pods, so start one for each container. When the action is
+		// run serially one is started for each pod in order, and the next one
+		// is only started once the command has started in the previous pod.
+		podGroups := make([][]v1.Pod, 0)
+		if runsSerially(action) {
+			for _, pod := range podsForAction {
+				podGroups = append(podGroups, []v1.Pod{pod})
+			}
+		} else {
+			groupIndex := make(map[string]int)
+			for _, pod := range podsForAction {
+				container := containers[pod.GetUID()]
+				if i, ok := groupIndex[container]; ok {
+					podGroups[i] = append(podGroups[i], pod)
+					continue
+				}
+				groupIndex[container] = len(podGroups)
+				podGroups = append(podGroups, []v1.Pod{pod})
+			}
+		}
+
+		results := make([]*stork_api.RuleExecutionResult, 0)
+		for i, group := range podGroups {
+			container := containers[group[0].GetUID()]
+			executorName := fmt.Sprintf("pod-cmd-executor-%s", taskID.String())
+			if i > 0 {
+				executorName = fmt.Sprintf("%s-%d", executorName, i)
+			}
+			start := time.Now()
+			err = runBackgroundCommandOnPods(group, container, action.Value, taskID.String(), executorName, cmdExecutorImage)
+			for _, pod := range group {
+				result := newRuleExecutionResult(pod, action.Value, start, "", err)
+				result.Container = container
+				result.Background = true
+				results = append(results, result)
+			}
+			if err != nil {
+				break
+			}
+		}
+		setRuleForResults(results, rule, rType)
 		if err != nil {
-			return err
+			return results, err
 		}
-	} else {
-		_, err := runCommandOnPods(podsForAction, action.Value, execPodStepLow, true)
+		return results, nil
+	}
+
+	if !runsSerially(action) {
+		_, results, err := runCommandOnPods(podsForAction, containers, action.Value, getRetryBackoff(action), true)
+		setRuleForResults(results, rule, rType)
 		if err != nil {
-			return err
+			return results, err
 		}
+		return results, nil
+	}
+
+	// Run the command in one pod at a time and stop at the first failure
+	results := make([]*stork_api.RuleExecutionResult, 0)
+	for _, pod := range podsForAction {
+		_, podResults, err := runCommandOnPods([]v1.Pod{pod}, containers, action.Value, getRetryBackoff(action), true)
+		results = append(results, podResults...)
+		if err != nil {
+			setRuleForResults(results, rule, rType)
+			return results, err
+		}
+	}
+	setRuleForResults(results, rule, rType)
+	return results, nil
+}
+
+// getContainer returns the container in the pod in which the command for the
+// action is run. It is the container named in the action, or the default
+// container in the annotation on the pod. An empty name is returned if neither
+// is set, in which case the first container is used.
+func getContainer(pod v1.Pod, action stork_api.RuleAction) (string, error) {
+	container := action.Container
+	if container == "" {
+		container = pod.GetAnnotations()[defaultContainerAnnotation]
+		if container == "" {
+			return "", nil
+		}
+	}
+	for _, c := range pod.Spec.Containers {
+		if c.Name == container {
+			return container, nil
+		}
+	}
+	return "", fmt.Errorf("container %v not found in pod: [%s] %s", container, pod.GetNamespace(), pod.GetName())
+}
+
+// getRetryBackoff returns the backoff used to retry the command for an
+// action, using the defaults for values that aren't set in its retry policy
+func getRetryBackoff(action stork_api.RuleAction) wait.Backoff {
+	backoff := wait.Backoff{
+		Duration: execPodCmdRetryInterval,
+		Factor:   execPodCmdRetryFactor,
+		Steps:    execPodStepLow,
+	}
+	if action.Retry != nil {
+		if action.Retry.Attempts > 0 {
+			backoff.Steps = action.Retry.Attempts
+		}
+		if action.Retry.IntervalSeconds > 0 {
+			backoff.Duration = time.Duration(action.Retry.IntervalSeconds) * time.Second
+		}
+		if action.Retry.BackoffFactor > 1 {
+			backoff.Factor = float64(action.Retry.BackoffFactor)
+		}
+	}
+	return backoff
+}
+
+func setRuleForResults(results []*stork_api.RuleExecutionResult, rule *stork_api.Rule, rType Type) {
+	for _, result := range results {
+		result.Rule = rule.Name
+		result.Type = string(rType)
 	}
-	return nil
+}
+
+// newRuleExecutionResult creates the result for running a command on a pod
+func newRuleExecutionResult(
+	pod v1.Pod,
+	cmd string,
+	start time.Time,
+	output string,
+	err error,
+) *stork_api.RuleExecutionResult {
+	result := &stork_api.RuleExecutionResult{
+		Pod:            pod.GetName(),
+		Namespace:      pod.GetNamespace(),
+		Action:         cmd,
+		Output:         truncateOutput(output),
+		StartTimestamp: metav1.NewTime(start),
+		Duration:       metav1.Duration{Duration: time.Since(start)},
+	}
+	if err != nil {
+		result.Error = truncateOutput(err.Error())
+		result.ExitCode = -1
+		if matches := exitCodeRegex.FindStringSubmatch(err.Error()); len(matches) == 2 {
+			if exitCode, convErr := strconv.Atoi(matches[1]); convErr == nil {
+				result.ExitCode = exitCode
+			}
+		}
+	}
+	return result
+}
+
+// truncateOutput keeps only the end of the output since that usually
+// contains the reason for a failure
+func truncateOutput(output string) string {
+	if len(output) <= maxRuleResultOutputLength {
+		return output
+	}
+	return "..." + output[len(output)-maxRuleResultOutputLength:]
 }
 
 // podsToString is a helper function to create a user-friendly single string from a list of pods
@@ -397,13 +819,15 @@ func podsToString(pods []v1.Pod) string {
 func updateRunningCommandPodListInOwner(
 	owner runtime.Object,
 	pods []v1.Pod,
+	containers map[types.UID]string,
 	taskID string,
 ) error {
 	podsWithNs := make([]Pod, 0)
 	for _, p := range pods {
 		podsWithNs = append(podsWithNs, Pod{
 			Namespace: p.GetNamespace(),
-			UID:       string(p.GetUID())})
+			UID:       string(p.GetUID()),
+			Container: containers[p.GetUID()]})
 	}
 
 	tracker := &CommandTask{
@@ -450,14 +874,29 @@ func updateRunningCommandPodListInOwner(
 	return err
 }
 
-// runCommandOnPods runs cmd on given pods. If failFast is true, it will return on the first failure. It will
-// return a list of pods that failed.
-func runCommandOnPods(pods []v1.Pod, cmd string, numRetries int, failFast bool) ([]v1.Pod, error) {
+// runCommandOnPods runs cmd on given pods in the container for each pod, retrying failures with the given backoff.
+// The first container is used for pods without a container. If failFast is true, it will return on the first
+// failure. It will return a list of pods that failed along with the results for the pods on which the command has
+// completed.
+func runCommandOnPods(
+	pods []v1.Pod,
+	containers map[types.UID]string,
+	cmd string,
+	backOff wait.Backoff,
+	failFast bool,
+) ([]v1.Pod, []*stork_api.RuleExecutionResult, error) {
 	var wg sync.WaitGroup
-	backOff := wait.Backoff{
-		Duration: execPodCmdRetryInterval,
-		Factor:   execPodCmdRetryFactor,
-		Steps:    numRetries,
+	var resultsLock sync.Mutex
+	results := make([]*stork_api.RuleExecutionResult, 0)
+	addResult := func(result *stork_api.RuleExecutionResult) {
+		resultsLock.Lock()
+		defer resultsLock.Unlock()
+		results = append(results, result)
+	}
+	getResults := func() []*stork_api.RuleExecutionResult {
+		resultsLock.Lock()
+		defer resultsLock.Unlock()
+		return append([]*stork_api.RuleExecutionResult{}, results...)
 	}
 	errChannel := make(chan podErrorResponse)
 	finished := make(chan bool, 1)
@@ -466,6 +905,10 @@ func runCommandOnPods(pods []v1.Pod, cmd string, numRetries int, failFast bool)
 		wg.Add(1)
 		go func(pod v1.Pod, errRespChan chan podErrorResponse) {
 			defer wg.Done()
+			start := time.Now()
+			var output string
+			var cmdErr error
+			attempts := 0
 			err := wait.ExponentialBackoff(backOff, func() (bool, error) {
 				ns, name := pod.GetNamespace(), pod.GetName()
 				_, err := k8s.Instance().GetPodByUID(pod.GetUID(), ns)
@@ -502,15 +945,23 @@ func runCommandOnPods(pods []v1.Pod, cmd string, numRetries int, failFast bool)
 					return false, nil
 				}
 
-				_, err = k8s.Instance().RunCommandInPod([]string{"sh", "-c", cmd}, name, "", ns)
-				if err != nil {
-					logrus.Warnf("Failed to run command: %s on pod: [%s] %s due to: %v", cmd, ns, name, err)
+				attempts++
+				output, cmdErr = k8s.Instance().RunCommandInPod([]string{"sh", "-c", cmd}, name, containers[pod.GetUID()], ns)
+				if cmdErr != nil {
+					logrus.Warnf("Failed to run command: %s on pod: [%s] %s due to: %v", cmd, ns, name, cmdErr)
 					return false, nil
 				}
 
 				logrus.Infof("Command: %s succeeded on pod: [%s] %s", cmd, ns, name)
 				return true, nil
 			})
+			if err != nil && cmdErr == nil {
+				cmdErr = err
+			}
+			result := newRuleExecutionResult(pod, cmd, start, output, cmdErr)
+			result.Container = containers[pod.GetUID()]
+			result.Attempts = attempts
+			addResult(result)
 			if err != nil {
 				errChannel <- podErrorResponse{
 					Pod: pod,
@@ -536,31 +987,35 @@ func runCommandOnPods(pods []v1.Pod, cmd string, numRetries int, failFast bool)
 	case <-finished:
 		if len(failed) > 0 {
 			err := fmt.Errorf("command: %s failed on pods: %s", cmd, podsToString(failed))
-			return failed, err
+			return failed, getResults(), err
 		}
 
 		logrus.Infof("Command: %s finished successfully on all pods", cmd)
-		return nil, nil
+		return nil, getResults(), nil
 	case errResp := <-errChannel:
 		failed = append(failed, errResp.Pod) // TODO also accumulate atleast the last error
 		if failFast {
-			return failed, fmt.Errorf("command: %s failed in pod: [%s] %s due to: %s",
+			return failed, getResults(), fmt.Errorf("command: %s failed in pod: [%s] %s due to: %s",
 				cmd, errResp.Pod.GetNamespace(), errResp.Pod.GetName(), errResp.err)
 		}
 	}
 
-	return nil, nil
+	return nil, getResults(), nil
 }
 
-// runBackgroundCommandOnPods will start the given "cmd" on all the given "pods". The taskID is given to
-// the executor pod so it can have unique status files in the target pods where it runs the actual commands
-func runBackgroundCommandOnPods(pods []v1.Pod, cmd, taskID, cmdExecutorImage string) error {
+// runBackgroundCommandOnPods will start the given "cmd" on all the given "pods" in the given container using an
+// executor pod with the given name. The taskID is given to the executor pod so it can have unique status files in
+// the target pods where it runs the actual commands
+func runBackgroundCommandOnPods(pods []v1.Pod, container, cmd, taskID, executorName, cmdExecutorImage string) error {
 	executorArgs := []string{
 		"/cmdexecutor",
 		"-timeout", strconv.FormatInt(perPodCommandExecTimeout, 10),
 		"-cmd", cmd,
 		"-taskid", taskID,
 	}
+	if container != "" {
+		executorArgs = append(executorArgs, "-container", container)
+	}
 
 	for _, pod := range pods {
 		executorArgs = append(executorArgs, []string{"-pod", fmt.Sprintf("%s/%s", pod.GetNamespace(), pod.GetName())}...)
@@ -572,7 +1027,7 @@ func runBackgroundCommandOnPods(pods []v1.Pod, cmd, taskID, cmdExecutorImage str
 	}
 	executorPod := &v1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      fmt.Sprintf("pod-cmd-executor-%s", taskID),
+			Name:      executorName,
 			Namespace: core.NamespaceSystem,
 			Labels:    labels,
 		},
@@ -661,24 +1116,26 @@ func waitForExecPodCompletion(pod *v1.Pod) error {
 // the terminationSignalChan is sent a true signal, it terminates commands on the accumulated
 // pods
 func cmdTerminationWatcher(
-	podListChan chan v1.Pod,
+	podListChan chan podContainer,
 	terminationSignalChan chan bool,
 	owner runtime.Object,
 	id string) {
 	// For tracking, use a map/set keyed by uid to handle duplicates
-	podsToTerminate := make(map[string]v1.Pod)
+	podsToTerminate := make(map[string]podContainer)
 	for {
 		select {
 		case pod := <-podListChan:
-			podsToTerminate[string(pod.GetUID())] = pod
+			podsToTerminate[string(pod.pod.GetUID())] = pod
 		case terminate := <-terminationSignalChan:
 			if terminate {
 				podList := make([]v1.Pod, 0)
+				containers := make(map[types.UID]string)
 				for _, pod := range podsToTerminate {
-					podList = append(podList, pod)
+					podList = append(podList, pod.pod)
+					containers[pod.pod.GetUID()] = pod.container
 				}
 
-				if err := terminateCommandInPods(owner, podList, id); err != nil {
+				if err := terminateCommandInPods(owner, podList, containers, id); err != nil {
 					log.RuleLog(nil, owner).Warnf("failed to terminate background command in pods due to: %v", err)
 				}
 			}
@@ -0,0 +1,283 @@
+// +build unittest
+
+package rule
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/portworx/sched-ops/k8s"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	fakekube "k8s.io/client-go/kubernetes/fake"
+	core "k8s.io/client-go/testing"
+)
+
+func newJobRule(action stork_api.RuleAction) *stork_api.Rule {
+	return &stork_api.Rule{
+		ObjectMeta: metav1.ObjectMeta{Name: "quiesce", Namespace: "ns"},
+		Rules: []stork_api.RuleItem{
+			{
+				PodSelector: map[string]string{"app": "db"},
+				Actions:     []stork_api.RuleAction{action},
+			},
+		},
+	}
+}
+
+// setupJobs sets up a fake cluster where jobs fail if their image is in failed
+// and succeed otherwise when they are checked. Returns the jobs that were
+// created.
+func setupJobs(failed map[string]bool) *[]*batchv1.Job {
+	fakeKubeClient := fakekube.NewSimpleClientset()
+	jobs := &[]*batchv1.Job{}
+	running := make(map[string]*batchv1.Job)
+	fakeKubeClient.PrependReactor("create", "jobs", func(action core.Action) (bool, runtime.Object, error) {
+		job := action.(core.CreateAction).GetObject().(*batchv1.Job)
+		*jobs = append(*jobs, job)
+		running[job.Name] = job
+		return false, nil, nil
+	})
+	fakeKubeClient.PrependReactor("get", "jobs", func(action core.Action) (bool, runtime.Object, error) {
+		job, ok := running[action.(core.GetAction).GetName()]
+		if !ok {
+			return false, nil, nil
+		}
+		job = job.DeepCopy()
+		if failed[job.Spec.Template.Spec.Containers[0].Image] {
+			job.Status.Failed = 1
+		} else {
+			job.Status.Succeeded = 1
+		}
+		return true, job, nil
+	})
+	fakeKubeClient.PrependReactor("delete", "jobs", func(action core.Action) (bool, runtime.Object, error) {
+		delete(running, action.(core.DeleteAction).GetName())
+		return false, nil, nil
+	})
+	k8s.Instance().SetClient(fakeKubeClient, nil, nil, nil, nil, nil)
+	SetKubeClient(fakeKubeClient)
+	return jobs
+}
+
+func TestValidateJobAction(t *testing.T) {
+	action := stork_api.RuleAction{Type: stork_api.RuleActionJob, Value: "quiesce"}
+	require.Error(t, ValidateRule(newJobRule(action), PreExecRule), "Expected error without image")
+
+	action.Job = &stork_api.RuleActionJobSpec{Image: "tools:1.0"}
+	require.NoError(t, ValidateRule(newJobRule(action), PreExecRule))
+
+	action.Background = true
+	require.Error(t, ValidateRule(newJobRule(action), PreExecRule), "Expected error for background job action")
+
+	action.Background = false
+	action.Value = ""
+	require.Error(t, ValidateRule(newJobRule(action), PreExecRule), "Expected error without command")
+	action.Job.Command = []string{"/quiesce", "--all"}
+	require.NoError(t, ValidateRule(newJobRule(action), PreExecRule))
+}
+
+func TestExecuteJobAction(t *testing.T) {
+	jobs := setupJobs(map[string]bool{"broken:1.0": true})
+	owner := &stork_api.Migration{ObjectMeta: metav1.ObjectMeta{Name: "migration", Namespace: "ns"}}
+	action := stork_api.RuleAction{
+		Type:  stork_api.RuleActionJob,
+		Value: "quiesce --all",
+		Job: &stork_api.RuleActionJobSpec{
+			Image:          "tools:1.0",
+			ServiceAccount: "quiesce-account",
+			Resources: v1.ResourceRequirements{
+				Limits: v1.ResourceList{v1.ResourceCPU: resource.MustParse("500m")},
+			},
+		},
+	}
+
+	// Service accounts need to be allowed to be used by job actions
+	_, results, err := ExecuteRule(newJobRule(action), PreExecRule, owner, "ns", nil)
+	require.Error(t, err, "Expected error for missing service account")
+	require.Len(t, results, 1)
+	require.NotEmpty(t, results[0].Error)
+	require.Empty(t, *jobs)
+	serviceAccount, err := kubeClient.CoreV1().ServiceAccounts("ns").Create(&v1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{Name: "quiesce-account", Namespace: "ns"},
+	})
+	require.NoError(t, err, "Error creating service account")
+	_, _, err = ExecuteRule(newJobRule(action), PreExecRule, owner, "ns", nil)
+	require.Error(t, err, "Expected error for service account without annotation")
+	require.Empty(t, *jobs)
+	serviceAccount.Annotations = map[string]string{JobServiceAccountAnnotation: "true"}
+	_, err = kubeClient.CoreV1().ServiceAccounts("ns").Update(serviceAccount)
+	require.NoError(t, err, "Error updating service account")
+
+	// Job actions are run even if no pods match the selector
+	termChan, results, err := ExecuteRule(newJobRule(action), PreExecRule, owner, "ns", nil)
+	require.NoError(t, err, "Error executing rule")
+	require.Nil(t, termChan)
+	require.Len(t, *jobs, 1)
+	job := (*jobs)[0]
+	require.Equal(t, "ns", job.Namespace)
+	require.Equal(t, "quiesce-account", job.Spec.Template.Spec.ServiceAccountName)
+	require.Equal(t, "tools:1.0", job.Spec.Template.Spec.Containers[0].Image)
+	require.Equal(t, []string{"sh", "-c", "quiesce --all"}, job.Spec.Template.Spec.Containers[0].Command)
+	require.Equal(t, action.Job.Resources, job.Spec.Template.Spec.Containers[0].Resources)
+
+	require.Len(t, results, 1)
+	require.Equal(t, job.Name, results[0].Job)
+	require.Equal(t, "ns", results[0].Namespace)
+	require.Equal(t, "quiesce", results[0].Rule)
+	require.Equal(t, string(PreExecRule), results[0].Type)
+	require.Empty(t, results[0].Error)
+
+	// The job is deleted once it completes
+	_, err = k8s.Instance().GetJob(job.Name, job.Namespace)
+	require.Error(t, err)
+
+	action.Job.Image = "broken:1.0"
+	_, results, err = ExecuteRule(newJobRule(action), PreExecRule, owner, "ns", nil)
+	require.Error(t, err, "Expected error for failed job")
+	require.Len(t, results, 1)
+	require.Equal(t, -1, results[0].ExitCode)
+	require.NotEmpty(t, results[0].Error)
+}
+
+func TestRetryBackoff(t *testing.T) {
+	action := stork_api.RuleAction{Type: stork_api.RuleActionCommand, Value: "sync"}
+	require.NoError(t, ValidateRule(newJobRule(action), PreExecRule))
+	require.Equal(t, wait.Backoff{Duration: 5 * time.Second, Factor: 1, Steps: 12}, getRetryBackoff(action))
+
+	action.Retry = &stork_api.RuleActionRetry{Attempts: 3}
+	require.NoError(t, ValidateRule(newJobRule(action), PreExecRule))
+	require.Equal(t, wait.Backoff{Duration: 5 * time.Second, Factor: 1, Steps: 3}, getRetryBackoff(action))
+
+	action.Retry = &stork_api.RuleActionRetry{Attempts: 4, IntervalSeconds: 2, BackoffFactor: 2}
+	require.NoError(t, ValidateRule(newJobRule(action), PreExecRule))
+	require.Equal(t, wait.Backoff{Duration: 2 * time.Second, Factor: 2, Steps: 4}, getRetryBackoff(action))
+
+	action.Retry = &stork_api.RuleActionRetry{Attempts: -1}
+	require.Error(t, ValidateRule(newJobRule(action), PreExecRule), "Expected error for negative attempts")
+}
+
+func TestGetContainer(t *testing.T) {
+	pod := v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "db-0", Namespace: "ns"},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{{Name: "istio-proxy"}, {Name: "db"}},
+		},
+	}
+	action := stork_api.RuleAction{Type: stork_api.RuleActionCommand, Value: "sync"}
+
+	// The first container is used if none is specified
+	container, err := getContainer(pod, action)
+	require.NoError(t, err, "Error getting container")
+	require.Empty(t, container)
+
+	pod.Annotations = map[string]string{defaultContainerAnnotation: "db"}
+	container, err = getContainer(pod, action)
+	require.NoError(t, err, "Error getting container")
+	require.Equal(t, "db", container)
+
+	// The container in the action overrides the annotation
+	action.Container = "istio-proxy"
+	container, err = getContainer(pod, action)
+	require.NoError(t, err, "Error getting container")
+	require.Equal(t, "istio-proxy", container)
+
+	action.Container = "app"
+	_, err = getContainer(pod, action)
+	require.EqualError(t, err, "container app not found in pod: [ns] db-0")
+}
+
+func TestRenderRule(t *testing.T) {
+	owner := &stork_api.Migration{
+		TypeMeta:   metav1.TypeMeta{Kind: "Migration"},
+		ObjectMeta: metav1.ObjectMeta{Name: "migration", Namespace: "ns"},
+	}
+	action := stork_api.RuleAction{
+		Type:       stork_api.RuleActionCommand,
+		Background: true,
+		Value:      `quiesce --ns {{.Namespace}} --by {{.Kind}}/{{.Name}} --pvcs {{join .PVCs ","}} && ${WAIT_CMD}`,
+		Cleanup:    `unquiesce --keyspace {{.Parameters.keyspace}}`,
+	}
+	r := newJobRule(action)
+	parameters := &Parameters{
+		PVCs:   []string{"data-0", "data-1"},
+		Values: map[string]string{"keyspace": "ks1"},
+	}
+
+	// Commands aren't rendered without the annotation
+	rendered, err := renderRule(r, owner, "ns", parameters)
+	require.NoError(t, err, "Error rendering rule")
+	require.Equal(t, action.Value, rendered.Rules[0].Actions[0].Value)
+
+	r.Annotations = map[string]string{TemplateCommandsAnnotation: "true"}
+	rendered, err = renderRule(r, owner, "ns", parameters)
+	require.NoError(t, err, "Error rendering rule")
+	require.Equal(t, "quiesce --ns ns --by Migration/migration --pvcs data-0,data-1 && ${WAIT_CMD}",
+		rendered.Rules[0].Actions[0].Value)
+	require.Equal(t, "unquiesce --keyspace ks1", rendered.Rules[0].Actions[0].Cleanup)
+	// The original rule isn't modified
+	require.Equal(t, action.Value, r.Rules[0].Actions[0].Value)
+
+	// Missing parameters are errors
+	_, err = renderRule(r, owner, "ns", nil)
+	require.Error(t, err, "Expected error for missing parameter")
+
+	// Parameters that could change the commands are errors
+	parameters.Values["keyspace"] = "ks1; reboot"
+	_, err = renderRule(r, owner, "ns", parameters)
+	require.Error(t, err, "Expected error for unsafe parameter")
+}
+
+func TestRuleExecutionResults(t *testing.T) {
+	pod := v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "db-0", Namespace: "ns"}}
+	start := time.Now().Add(-time.Second)
+
+	result := newRuleExecutionResult(pod, "sync", start, "done", nil)
+	require.Equal(t, "db-0", result.Pod)
+	require.Equal(t, "ns", result.Namespace)
+	require.Equal(t, "sync", result.Action)
+	require.Equal(t, "done", result.Output)
+	require.Equal(t, 0, result.ExitCode)
+	require.Empty(t, result.Error)
+	require.True(t, result.Duration.Duration >= time.Second)
+
+	// The exit code is parsed from the error
+	result = newRuleExecutionResult(pod, "false", start, "", fmt.Errorf("command terminated with exit code 2"))
+	require.Equal(t, 2, result.ExitCode)
+	require.Equal(t, "command terminated with exit code 2", result.Error)
+	result = newRuleExecutionResult(pod, "false", start, "", fmt.Errorf("pod not found"))
+	require.Equal(t, -1, result.ExitCode)
+
+	// Only the end of long output is kept
+	output := strings.Repeat("a", maxRuleResultOutputLength) + "failed"
+	result = newRuleExecutionResult(pod, "sync", start, output, nil)
+	require.Len(t, result.Output, maxRuleResultOutputLength+3)
+	require.True(t, strings.HasPrefix(result.Output, "..."))
+	require.True(t, strings.HasSuffix(result.Output, "failed"))
+
+	r := &stork_api.Rule{ObjectMeta: metav1.ObjectMeta{Name: "quiesce"}}
+	results := []*stork_api.RuleExecutionResult{result}
+	setRuleForResults(results, r, PostExecRule)
+	require.Equal(t, "quiesce", result.Rule)
+	require.Equal(t, string(PostExecRule), result.Type)
+
+	// Results are replaced only for the same rule type
+	existing := []*stork_api.RuleExecutionResult{
+		{Rule: "freeze", Type: string(PreExecRule), Pod: "db-0"},
+		{Rule: "old", Type: string(PostExecRule), Pod: "db-0"},
+	}
+	merged := MergeRuleResults(existing, results, PostExecRule)
+	require.Len(t, merged, 2)
+	require.Equal(t, "freeze", merged[0].Rule)
+	require.Equal(t, "quiesce", merged[1].Rule)
+	require.Len(t, MergeRuleResults(existing, nil, PreExecRule), 1)
+	require.Empty(t, MergeRuleResults(nil, nil, PreExecRule))
+}
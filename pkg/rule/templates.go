@@ -0,0 +1,252 @@
+package rule
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/portworx/sched-ops/k8s"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// TemplatePrefix is the prefix used to select a built-in rule template
+	// instead of a Rule object, for eg "template/mysql"
+	TemplatePrefix = "template/"
+
+	// podSelectorParameter is the parameter used by all templates to select
+	// the pods on which the actions are run. Specified as comma separated
+	// key=value pairs
+	podSelectorParameter = "podSelector"
+)
+
+// Template is a built-in rule that can be customized using parameters.
+// Parameters are referenced in actions as {{name}}
+type Template struct {
+	// Description of the template
+	Description string
+	// Parameters are the parameters supported by the template along with
+	// their default values
+	Parameters map[string]string
+	// PreExecActions are the actions run for a pre exec rule
+	PreExecActions []stork_api.RuleAction
+	// PostExecActions are the actions run for a post exec rule
+	PostExecActions []stork_api.RuleAction
+}
+
+// safeParameterValue matches the parameter values that can be substituted in
+// commands without quoting, since the commands are run with a shell. Values
+// can't start with "-" so that they aren't parsed as options either.
+var safeParameterValue = regexp.MustCompile(`^([A-Za-z0-9_.,:=/@+][A-Za-z0-9_.,:=/@+-]*)?$`)
+
+// validateParameterValue returns an error if the value of the parameter could
+// change the commands it is substituted in
+func validateParameterValue(name string, value string) error {
+	if !safeParameterValue.MatchString(value) {
+		return fmt.Errorf("invalid value %q for parameter %v, only letters, digits and _.,:=/@+- are allowed "+
+			"and it can't start with -", value, name)
+	}
+	return nil
+}
+
+var templates = map[string]*Template{
+	"postgresql": {
+		Description: "Runs a checkpoint to flush all dirty buffers to disk",
+		Parameters: map[string]string{
+			podSelectorParameter: "app=postgres",
+			"user":               "postgres",
+		},
+		PreExecActions: []stork_api.RuleAction{
+			{
+				Type:  stork_api.RuleActionCommand,
+				Value: `psql -U {{user}} -c "CHECKPOINT;"`,
+			},
+		},
+	},
+	"mysql": {
+		Description: "Flushes tables with a read lock which is held until the snapshot is triggered",
+		Parameters: map[string]string{
+			podSelectorParameter: "app=mysql",
+			"user":               "root",
+			"passwordEnv":        "MYSQL_ROOT_PASSWORD",
+		},
+		PreExecActions: []stork_api.RuleAction{
+			{
+				Type:       stork_api.RuleActionCommand,
+				Background: true,
+				Value:      `mysql --user={{user}} --password=${{passwordEnv}} -Bse 'flush tables with read lock;system ${WAIT_CMD};'`,
+			},
+		},
+	},
+	"mongodb": {
+		Description: "Locks the mongod instance before the snapshot and unlocks it after",
+		Parameters: map[string]string{
+			podSelectorParameter: "app=mongodb",
+		},
+		PreExecActions: []stork_api.RuleAction{
+			{
+				Type:  stork_api.RuleActionCommand,
+				Value: `mongo --eval "printjson(db.fsyncLock())"`,
+			},
+		},
+		PostExecActions: []stork_api.RuleAction{
+			{
+				Type:  stork_api.RuleActionCommand,
+				Value: `mongo --eval "printjson(db.fsyncUnlock())"`,
+			},
+		},
+	},
+	"elasticsearch": {
+		Description: "Flushes all the indices to disk",
+		Parameters: map[string]string{
+			podSelectorParameter: "app=elasticsearch",
+			"port":               "9200",
+		},
+		PreExecActions: []stork_api.RuleAction{
+			{
+				Type:           stork_api.RuleActionCommand,
+				RunInSinglePod: true,
+				Value:          `curl -s -XPOST "http://localhost:{{port}}/_flush"`,
+			},
+		},
+	},
+	"cassandra": {
+		Description: "Flushes the memtables to disk",
+		Parameters: map[string]string{
+			podSelectorParameter: "app=cassandra",
+			"keyspace":           "",
+		},
+		PreExecActions: []stork_api.RuleAction{
+			{
+				Type:  stork_api.RuleActionCommand,
+				Value: `nodetool flush {{keyspace}}`,
+			},
+		},
+	},
+}
+
+// GetTemplateNames returns the names of the built-in rule templates
+func GetTemplateNames() []string {
+	names := make([]string, 0, len(templates))
+	for name := range templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsTemplate returns true if the rule name refers to a built-in template
+func IsTemplate(name string) bool {
+	return strings.HasPrefix(name, TemplatePrefix)
+}
+
+// GetRule returns the rule with the given name. If the name refers to a
+// built-in template the rule is generated from the template using the
+// parameters, and if it refers to a ClusterRule the rule is generated from it
+// if the namespace is allowed to use it. Otherwise the Rule object is fetched
+// from the namespace.
+func GetRule(
+	name string,
+	namespace string,
+	rType Type,
+	parameters map[string]string,
+) (*stork_api.Rule, error) {
+	if IsTemplate(name) {
+		return RuleFromTemplate(name, namespace, rType, parameters)
+	}
+	if IsClusterRule(name) {
+		return getClusterRule(name, namespace)
+	}
+	return k8s.Instance().GetRule(name, namespace)
+}
+
+// RuleFromTemplate generates a rule of the given type from a built-in template
+func RuleFromTemplate(
+	name string,
+	namespace string,
+	rType Type,
+	parameters map[string]string,
+) (*stork_api.Rule, error) {
+	templateName := strings.TrimPrefix(name, TemplatePrefix)
+	template, ok := templates[templateName]
+	if !ok {
+		return nil, fmt.Errorf("invalid rule template %v, supported templates: %v",
+			templateName, strings.Join(GetTemplateNames(), ", "))
+	}
+
+	values := make(map[string]string)
+	for k, v := range template.Parameters {
+		values[k] = v
+	}
+	for k, v := range parameters {
+		if _, ok := template.Parameters[k]; !ok {
+			return nil, fmt.Errorf("invalid parameter %v for rule template %v", k, templateName)
+		}
+		// The pod selector is only used to select the pods, the other
+		// parameters are substituted in the commands
+		if k != podSelectorParameter {
+			if err := validateParameterValue(k, v); err != nil {
+				return nil, fmt.Errorf("%v for rule template %v", err, templateName)
+			}
+		}
+		values[k] = v
+	}
+
+	podSelector, err := parsePodSelector(values[podSelectorParameter])
+	if err != nil {
+		return nil, fmt.Errorf("invalid %v for rule template %v: %v", podSelectorParameter, templateName, err)
+	}
+
+	replacements := make([]string, 0)
+	for k, v := range values {
+		replacements = append(replacements, "{{"+k+"}}", v)
+	}
+	replacer := strings.NewReplacer(replacements...)
+
+	templateActions := template.PreExecActions
+	if rType == PostExecRule {
+		templateActions = template.PostExecActions
+	}
+	rule := &stork_api.Rule{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Rules: make([]stork_api.RuleItem, 0),
+	}
+	if len(templateActions) == 0 {
+		return rule, nil
+	}
+
+	actions := make([]stork_api.RuleAction, 0, len(templateActions))
+	for _, action := range templateActions {
+		action.Value = strings.TrimSpace(replacer.Replace(action.Value))
+		actions = append(actions, action)
+	}
+	rule.Rules = append(rule.Rules, stork_api.RuleItem{
+		PodSelector: podSelector,
+		Actions:     actions,
+	})
+	return rule, nil
+}
+
+func parsePodSelector(selector string) (map[string]string, error) {
+	podSelector := make(map[string]string)
+	for _, label := range strings.Split(selector, ",") {
+		label = strings.TrimSpace(label)
+		if label == "" {
+			continue
+		}
+		kv := strings.SplitN(label, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("invalid label %v", label)
+		}
+		podSelector[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+	if len(podSelector) == 0 {
+		return nil, fmt.Errorf("selector can't be empty")
+	}
+	return podSelector, nil
+}
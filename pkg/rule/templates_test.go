@@ -0,0 +1,89 @@
+// +build unittest
+
+package rule
+
+import (
+	"testing"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplates(t *testing.T) {
+	t.Run("templateDefaultsTest", templateDefaultsTest)
+	t.Run("templateParametersTest", templateParametersTest)
+	t.Run("templateInvalidTest", templateInvalidTest)
+	t.Run("templatesValidTest", templatesValidTest)
+}
+
+func templateDefaultsTest(t *testing.T) {
+	r, err := GetRule(TemplatePrefix+"mysql", "ns", PreExecRule, nil)
+	require.NoError(t, err, "Error getting rule from template")
+	require.Equal(t, "ns", r.Namespace)
+	require.Len(t, r.Rules, 1)
+	require.Equal(t, map[string]string{"app": "mysql"}, r.Rules[0].PodSelector)
+	require.Len(t, r.Rules[0].Actions, 1)
+	require.True(t, r.Rules[0].Actions[0].Background)
+	require.Equal(t,
+		"mysql --user=root --password=$MYSQL_ROOT_PASSWORD -Bse 'flush tables with read lock;system ${WAIT_CMD};'",
+		r.Rules[0].Actions[0].Value)
+
+	// mysql doesn't have any post exec actions
+	r, err = GetRule(TemplatePrefix+"mysql", "ns", PostExecRule, nil)
+	require.NoError(t, err, "Error getting rule from template")
+	require.Len(t, r.Rules, 0)
+
+	r, err = GetRule(TemplatePrefix+"mongodb", "ns", PostExecRule, nil)
+	require.NoError(t, err, "Error getting rule from template")
+	require.Len(t, r.Rules, 1)
+	require.Equal(t, `mongo --eval "printjson(db.fsyncUnlock())"`, r.Rules[0].Actions[0].Value)
+}
+
+func templateParametersTest(t *testing.T) {
+	r, err := GetRule(TemplatePrefix+"cassandra", "ns", PreExecRule, map[string]string{
+		"podSelector": "app=cass, tier=db",
+		"keyspace":    "ks1",
+	})
+	require.NoError(t, err, "Error getting rule from template")
+	require.Equal(t, map[string]string{"app": "cass", "tier": "db"}, r.Rules[0].PodSelector)
+	require.Equal(t, "nodetool flush ks1", r.Rules[0].Actions[0].Value)
+
+	r, err = GetRule(TemplatePrefix+"cassandra", "ns", PreExecRule, nil)
+	require.NoError(t, err, "Error getting rule from template")
+	require.Equal(t, "nodetool flush", r.Rules[0].Actions[0].Value)
+}
+
+func templateInvalidTest(t *testing.T) {
+	_, err := GetRule(TemplatePrefix+"oracle", "ns", PreExecRule, nil)
+	require.Error(t, err, "Expected error for invalid template")
+
+	_, err = GetRule(TemplatePrefix+"mysql", "ns", PreExecRule, map[string]string{"invalid": "value"})
+	require.Error(t, err, "Expected error for invalid parameter")
+
+	_, err = GetRule(TemplatePrefix+"mysql", "ns", PreExecRule, map[string]string{"podSelector": "app"})
+	require.Error(t, err, "Expected error for invalid pod selector")
+
+	_, err = GetRule(TemplatePrefix+"mysql", "ns", PreExecRule, map[string]string{"podSelector": ""})
+	require.Error(t, err, "Expected error for empty pod selector")
+
+	for _, value := range []string{"root; rm -rf /", "$(id)", "`id`", "a b", "-h evil", "a'b", `a"b`, "a|b", "a\nb"} {
+		_, err = GetRule(TemplatePrefix+"mysql", "ns", PreExecRule, map[string]string{"user": value})
+		require.Error(t, err, "Expected error for unsafe parameter value %q", value)
+	}
+}
+
+func templatesValidTest(t *testing.T) {
+	for _, name := range GetTemplateNames() {
+		for _, rType := range []Type{PreExecRule, PostExecRule} {
+			r, err := GetRule(TemplatePrefix+name, "ns", rType, nil)
+			require.NoError(t, err, "Error getting rule from template %v", name)
+			require.NoError(t, ValidateRule(r, rType), "Invalid rule for template %v", name)
+			for _, item := range r.Rules {
+				for _, action := range item.Actions {
+					require.Equal(t, stork_api.RuleActionCommand, action.Type)
+					require.NotContains(t, action.Value, "{{", "Unreplaced parameter in template %v", name)
+				}
+			}
+		}
+	}
+}
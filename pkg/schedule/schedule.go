@@ -2,10 +2,12 @@ package schedule
 
 import (
 	"fmt"
+	"hash/fnv"
 	"os"
 	"reflect"
 	"time"
 
+	snapv1 "github.com/kubernetes-incubator/external-storage/snapshot/pkg/apis/crd/v1"
 	"github.com/libopenstorage/stork/pkg/apis/stork"
 	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
 	"github.com/portworx/sched-ops/k8s"
@@ -14,6 +16,7 @@ import (
 	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -28,6 +31,18 @@ const (
 	MockTimeConfigMapNamespace = "kube-system"
 	// MockTimeConfigMapKey is the key name in the config map data that contains the time
 	MockTimeConfigMapKey = "time"
+	// maxSkippedTriggers is the number of skipped triggers that are recorded
+	// in the status of a schedule
+	maxSkippedTriggers = 10
+	// maxClusterDomainSuspensions is the number of automatic suspensions and
+	// resumptions that are recorded in the status of a schedule
+	maxClusterDomainSuspensions = 10
+	// maxCatchUpTriggers is the maximum number of missed triggers that are
+	// run with the RunAllMissed catch up policy
+	maxCatchUpTriggers = 10
+	// triggerWindow is the duration after a scheduled time during which the
+	// trigger is run
+	triggerWindow = time.Hour
 )
 
 var mockTime *time.Time
@@ -46,11 +61,13 @@ func GetCurrentTime() time.Time {
 }
 
 // TriggerRequired Check if a trigger is required for a policy given the last
-// trigger time
+// trigger time. The scheduled times of the policy are delayed by delay, which
+// should be the delay returned by GetTriggerDelay for the schedule.
 func TriggerRequired(
 	policyName string,
 	policyType stork_api.SchedulePolicyType,
 	lastTrigger meta.Time,
+	delay time.Duration,
 ) (bool, error) {
 	schedulePolicy, err := k8s.Instance().GetSchedulePolicy(policyName)
 	if err != nil {
@@ -61,13 +78,24 @@ func TriggerRequired(
 		return false, err
 	}
 
-	now := GetCurrentTime()
+	// Delaying the scheduled times is the same as checking the trigger
+	// that much earlier
+	firstTrigger := lastTrigger.IsZero()
+	now := GetCurrentTime().Add(-delay)
+	lastTrigger = meta.NewTime(lastTrigger.Add(-delay))
 	switch policyType {
 	case stork_api.SchedulePolicyTypeInterval:
 		if schedulePolicy.Policy.Interval == nil {
 			return false, nil
 		}
 		duration := time.Duration(schedulePolicy.Policy.Interval.IntervalMinutes) * time.Minute
+		// The delay cancels out when counting the interval from the last
+		// trigger, so with a delay the triggers are aligned to multiples of
+		// the interval offset by the delay instead. This keeps the schedules
+		// sharing the policy staggered.
+		if delay > 0 {
+			return firstTrigger || now.Truncate(duration).After(lastTrigger.Truncate(duration)), nil
+		}
 		// Trigger if more than intervalMinutes has passed since
 		// last trigger
 		if lastTrigger.Add(duration).Before(now) {
@@ -84,8 +112,13 @@ func TriggerRequired(
 		if err != nil {
 			return false, err
 		}
+		loc, err := schedulePolicy.Policy.Daily.GetLocation()
+		if err != nil {
+			return false, err
+		}
+		now = now.In(loc)
 
-		nextTrigger := time.Date(now.Year(), now.Month(), now.Day(), policyHour, policyMinute, 0, 0, time.Local)
+		nextTrigger := time.Date(now.Year(), now.Month(), now.Day(), policyHour, policyMinute, 0, 0, loc)
 
 		return checkTrigger(lastTrigger.Time, nextTrigger, now)
 
@@ -93,20 +126,27 @@ func TriggerRequired(
 		if schedulePolicy.Policy.Weekly == nil {
 			return false, nil
 		}
+		loc, err := schedulePolicy.Policy.Weekly.GetLocation()
+		if err != nil {
+			return false, err
+		}
+		now = now.In(loc)
 		currentDay := now.Weekday()
 		scheduledDay := stork_api.Days[schedulePolicy.Policy.Weekly.Day]
 		policyHour, policyMinute, err := schedulePolicy.Policy.Weekly.GetHourMinute()
 		if err != nil {
 			return false, err
 		}
-		nextTrigger := time.Date(now.Year(), now.Month(), now.Day(), policyHour, policyMinute, 0, 0, time.Local)
 		// Figure out how many days to add to get to the next
-		// trigger week day
+		// trigger week day. The days are added to the date so that the
+		// time stays the same across daylight saving changes.
+		days := 0
 		if currentDay < scheduledDay {
-			nextTrigger = nextTrigger.Add(time.Hour * time.Duration((scheduledDay-currentDay)*24))
+			days = int(scheduledDay - currentDay)
 		} else if currentDay > scheduledDay {
-			nextTrigger = nextTrigger.Add(time.Duration((7-(currentDay-scheduledDay))*24) * time.Hour)
+			days = 7 - int(currentDay-scheduledDay)
 		}
+		nextTrigger := time.Date(now.Year(), now.Month(), now.Day()+days, policyHour, policyMinute, 0, 0, loc)
 
 		return checkTrigger(lastTrigger.Time, nextTrigger, now)
 	case stork_api.SchedulePolicyTypeMonthly:
@@ -117,9 +157,29 @@ func TriggerRequired(
 		if err != nil {
 			return false, err
 		}
-		nextTrigger := time.Date(now.Year(), now.Month(), schedulePolicy.Policy.Monthly.Date, policyHour, policyMinute, 0, 0, time.Local)
+		loc, err := schedulePolicy.Policy.Monthly.GetLocation()
+		if err != nil {
+			return false, err
+		}
+		now = now.In(loc)
+		nextTrigger := time.Date(now.Year(), now.Month(), schedulePolicy.Policy.Monthly.Date, policyHour, policyMinute, 0, 0, loc)
 
 		return checkTrigger(lastTrigger.Time, nextTrigger, now)
+	case stork_api.SchedulePolicyTypeCron:
+		if schedulePolicy.Policy.Cron == nil {
+			return false, nil
+		}
+		// Trigger if a scheduled time has passed since the last trigger and
+		// we are still within the trigger window for it
+		start := lastTrigger.Time
+		if windowStart := now.Add(-triggerWindow); start.Before(windowStart) {
+			start = windowStart
+		}
+		nextTrigger, err := schedulePolicy.Policy.Cron.GetNextTime(start)
+		if err != nil {
+			return false, err
+		}
+		return !nextTrigger.IsZero() && !nextTrigger.After(now), nil
 	}
 	return false, nil
 }
@@ -137,12 +197,350 @@ func checkTrigger(
 
 	// If we are within one hour after the next trigger time, trigger a new
 	// schedule
-	if now.After(nextTrigger) && now.Sub(nextTrigger).Hours() < 1 {
+	if now.After(nextTrigger) && now.Sub(nextTrigger) < triggerWindow {
 		return true, nil
 	}
 	return false, nil
 }
 
+// CatchUpRequired Checks if a trigger is required to catch up on triggers
+// that were missed since the last trigger, for eg when stork was down.
+// triggered is the result from TriggerRequired and pending is the number of
+// catch up triggers that are still pending for the policy type. Returns
+// whether a trigger is required and the updated number of pending triggers.
+func CatchUpRequired(
+	policyName string,
+	policyType stork_api.SchedulePolicyType,
+	lastTrigger meta.Time,
+	catchUpPolicy stork_api.CatchUpPolicyType,
+	triggered bool,
+	pending int,
+	delay time.Duration,
+) (bool, int, error) {
+	switch catchUpPolicy {
+	case "", stork_api.CatchUpPolicySkip:
+		return triggered, 0, nil
+	case stork_api.CatchUpPolicyRunOnce, stork_api.CatchUpPolicyRunAllMissed:
+	default:
+		return false, 0, fmt.Errorf("invalid catch up policy %v", catchUpPolicy)
+	}
+
+	if pending > 0 {
+		// Don't count the regular trigger as one of the catch up triggers
+		if triggered {
+			return true, pending, nil
+		}
+		return true, pending - 1, nil
+	}
+
+	// Nothing to catch up on if nothing has been triggered yet
+	if lastTrigger.IsZero() {
+		return triggered, 0, nil
+	}
+	missed, err := MissedTriggers(policyName, policyType, lastTrigger, delay)
+	if err != nil {
+		return false, 0, err
+	}
+	if missed == 0 {
+		return triggered, 0, nil
+	}
+	if catchUpPolicy == stork_api.CatchUpPolicyRunOnce {
+		return true, 0, nil
+	}
+	if triggered {
+		return true, missed, nil
+	}
+	return true, missed - 1, nil
+}
+
+// MissedTriggers Returns the number of scheduled triggers for a policy that
+// were missed after the last trigger, up to a maximum of 10. For interval
+// policies the trigger that is currently due isn't counted as missed. The
+// scheduled times are delayed by delay, like in TriggerRequired.
+func MissedTriggers(
+	policyName string,
+	policyType stork_api.SchedulePolicyType,
+	lastTrigger meta.Time,
+	delay time.Duration,
+) (int, error) {
+	schedulePolicy, err := k8s.Instance().GetSchedulePolicy(policyName)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := ValidateSchedulePolicy(schedulePolicy); err != nil {
+		return 0, err
+	}
+
+	now := GetCurrentTime().Add(-delay)
+	lastTrigger = meta.NewTime(lastTrigger.Add(-delay))
+	// Get the last scheduled time for which the trigger window has passed
+	// and a function to get the scheduled time before that
+	var scheduled time.Time
+	var previous func(time.Time) time.Time
+	switch policyType {
+	case stork_api.SchedulePolicyTypeInterval:
+		if schedulePolicy.Policy.Interval == nil {
+			return 0, nil
+		}
+		duration := time.Duration(schedulePolicy.Policy.Interval.IntervalMinutes) * time.Minute
+		missed := int(now.Sub(lastTrigger.Time)/duration) - 1
+		if delay > 0 {
+			// Count the aligned triggers like in TriggerRequired
+			missed = int(now.Truncate(duration).Sub(lastTrigger.Truncate(duration))/duration) - 1
+		}
+		if missed < 0 {
+			missed = 0
+		} else if missed > maxCatchUpTriggers {
+			missed = maxCatchUpTriggers
+		}
+		return missed, nil
+
+	case stork_api.SchedulePolicyTypeDaily:
+		if schedulePolicy.Policy.Daily == nil {
+			return 0, nil
+		}
+		policyHour, policyMinute, err := schedulePolicy.Policy.Daily.GetHourMinute()
+		if err != nil {
+			return 0, err
+		}
+		loc, err := schedulePolicy.Policy.Daily.GetLocation()
+		if err != nil {
+			return 0, err
+		}
+		now = now.In(loc)
+		scheduled = time.Date(now.Year(), now.Month(), now.Day(), policyHour, policyMinute, 0, 0, loc)
+		previous = func(t time.Time) time.Time {
+			return t.AddDate(0, 0, -1)
+		}
+
+	case stork_api.SchedulePolicyTypeWeekly:
+		if schedulePolicy.Policy.Weekly == nil {
+			return 0, nil
+		}
+		policyHour, policyMinute, err := schedulePolicy.Policy.Weekly.GetHourMinute()
+		if err != nil {
+			return 0, err
+		}
+		loc, err := schedulePolicy.Policy.Weekly.GetLocation()
+		if err != nil {
+			return 0, err
+		}
+		now = now.In(loc)
+		scheduled = time.Date(now.Year(), now.Month(), now.Day(), policyHour, policyMinute, 0, 0, loc)
+		// Go back to the scheduled week day
+		daysSince := (int(now.Weekday()) - int(stork_api.Days[schedulePolicy.Policy.Weekly.Day]) + 7) % 7
+		scheduled = scheduled.AddDate(0, 0, -daysSince)
+		previous = func(t time.Time) time.Time {
+			return t.AddDate(0, 0, -7)
+		}
+
+	case stork_api.SchedulePolicyTypeMonthly:
+		if schedulePolicy.Policy.Monthly == nil {
+			return 0, nil
+		}
+		policyHour, policyMinute, err := schedulePolicy.Policy.Monthly.GetHourMinute()
+		if err != nil {
+			return 0, err
+		}
+		loc, err := schedulePolicy.Policy.Monthly.GetLocation()
+		if err != nil {
+			return 0, err
+		}
+		now = now.In(loc)
+		date := schedulePolicy.Policy.Monthly.Date
+		months := 0
+		scheduled = time.Date(now.Year(), now.Month(), date, policyHour, policyMinute, 0, 0, loc)
+		previous = func(t time.Time) time.Time {
+			months++
+			return time.Date(now.Year(), now.Month()-time.Month(months), date, policyHour, policyMinute, 0, 0, loc)
+		}
+
+	case stork_api.SchedulePolicyTypeCron:
+		if schedulePolicy.Policy.Cron == nil {
+			return 0, nil
+		}
+		// Count the scheduled times after the last trigger for which the
+		// trigger window has passed
+		missed := 0
+		next := lastTrigger.Time
+		for missed < maxCatchUpTriggers {
+			next, err = schedulePolicy.Policy.Cron.GetNextTime(next)
+			if err != nil {
+				return 0, err
+			}
+			if next.IsZero() || now.Sub(next) < triggerWindow {
+				break
+			}
+			missed++
+		}
+		return missed, nil
+
+	default:
+		return 0, nil
+	}
+
+	// Skip scheduled times that are still in the future or within the
+	// trigger window since those will be triggered by TriggerRequired
+	for now.Sub(scheduled) < triggerWindow {
+		scheduled = previous(scheduled)
+	}
+	missed := 0
+	for scheduled.After(lastTrigger.Time) && missed < maxCatchUpTriggers {
+		missed++
+		scheduled = previous(scheduled)
+	}
+	return missed, nil
+}
+
+// InBlackoutWindow Checks if the current time falls within one of the
+// blackout windows for the policy. Returns the window if it does.
+func InBlackoutWindow(policyName string) (*stork_api.BlackoutWindow, error) {
+	schedulePolicy, err := k8s.Instance().GetSchedulePolicy(policyName)
+	if err != nil {
+		return nil, err
+	}
+
+	now := GetCurrentTime()
+	for _, window := range schedulePolicy.Policy.BlackoutWindows {
+		if err := window.Validate(); err != nil {
+			return nil, err
+		}
+		inWindow, err := window.Contains(now)
+		if err != nil {
+			return nil, err
+		}
+		if inWindow {
+			return window, nil
+		}
+	}
+	return nil, nil
+}
+
+// InExcludedDates Checks if the current date is one of the excluded dates for
+// the policy. Returns the excluded dates if it is.
+func InExcludedDates(policyName string) (*stork_api.ExcludedDates, error) {
+	schedulePolicy, err := k8s.Instance().GetSchedulePolicy(policyName)
+	if err != nil {
+		return nil, err
+	}
+
+	now := GetCurrentTime()
+	for _, excluded := range schedulePolicy.Policy.ExcludedDates {
+		contains, err := excluded.Contains(now)
+		if err != nil {
+			return nil, err
+		}
+		if contains {
+			return excluded, nil
+		}
+	}
+	return nil, nil
+}
+
+// GetSkipReason Checks if triggers for the policy should be skipped right
+// now because of a blackout window or excluded dates. Returns the reason if
+// they should be skipped, or an empty string otherwise.
+func GetSkipReason(policyName string) (string, error) {
+	window, err := InBlackoutWindow(policyName)
+	if err != nil {
+		return "", err
+	}
+	if window != nil {
+		return fmt.Sprintf("Trigger skipped during blackout window %v-%v", window.Start, window.End), nil
+	}
+	excluded, err := InExcludedDates(policyName)
+	if err != nil {
+		return "", err
+	}
+	if excluded != nil {
+		return fmt.Sprintf("Trigger skipped on excluded dates %v", excluded), nil
+	}
+	return "", nil
+}
+
+// GetDependencySkipReason Checks if the schedule that a schedule in the
+// namespace depends on has run successfully since the last trigger of the
+// schedule. Returns the reason the trigger should be skipped if it hasn't, or
+// an empty string otherwise.
+func GetDependencySkipReason(
+	namespace string,
+	dependency *stork_api.ScheduleDependency,
+	lastTrigger meta.Time,
+) (string, error) {
+	if dependency == nil {
+		return "", nil
+	}
+	// Find the latest run of the dependency and whether it succeeded
+	var latest meta.Time
+	var finished meta.Time
+	succeeded := false
+	switch dependency.Kind {
+	case reflect.TypeOf(stork_api.MigrationSchedule{}).Name():
+		migrationSchedule, err := k8s.Instance().GetMigrationSchedule(dependency.Name, namespace)
+		if err != nil {
+			return "", err
+		}
+		for _, policyMigration := range migrationSchedule.Status.Items {
+			for _, migration := range policyMigration {
+				if latest.Before(&migration.CreationTimestamp) {
+					latest = migration.CreationTimestamp
+					finished = migration.FinishTimestamp
+					succeeded = migration.Status == stork_api.MigrationStatusSuccessful
+				}
+			}
+		}
+	case reflect.TypeOf(stork_api.VolumeSnapshotSchedule{}).Name():
+		snapshotSchedule, err := k8s.Instance().GetSnapshotSchedule(dependency.Name, namespace)
+		if err != nil {
+			return "", err
+		}
+		for _, policyVolumeSnapshot := range snapshotSchedule.Status.Items {
+			for _, snapshot := range policyVolumeSnapshot {
+				if latest.Before(&snapshot.CreationTimestamp) {
+					latest = snapshot.CreationTimestamp
+					finished = snapshot.FinishTimestamp
+					succeeded = snapshot.Status == snapv1.VolumeSnapshotConditionReady
+				}
+			}
+		}
+	default:
+		return "", fmt.Errorf("invalid kind %v for schedule dependency", dependency.Kind)
+	}
+
+	if !succeeded || finished.Before(&lastTrigger) {
+		return fmt.Sprintf("Trigger skipped since %v %v hasn't run successfully since the last trigger",
+			dependency.Kind, dependency.Name), nil
+	}
+	return "", nil
+}
+
+// RecordSkippedTrigger Adds a skipped trigger for the policy type to the list
+// of skipped triggers if one hasn't already been recorded since the last
+// trigger. Returns true if the list was updated.
+func RecordSkippedTrigger(
+	skippedTriggers []*stork_api.SkippedTrigger,
+	policyType stork_api.SchedulePolicyType,
+	lastTrigger meta.Time,
+	reason string,
+) ([]*stork_api.SkippedTrigger, bool) {
+	for _, skipped := range skippedTriggers {
+		if skipped.PolicyType == policyType && lastTrigger.Before(&skipped.Timestamp) {
+			return skippedTriggers, false
+		}
+	}
+
+	skippedTriggers = append(skippedTriggers, &stork_api.SkippedTrigger{
+		PolicyType: policyType,
+		Timestamp:  meta.NewTime(GetCurrentTime()),
+		Reason:     reason,
+	})
+	if len(skippedTriggers) > maxSkippedTriggers {
+		skippedTriggers = skippedTriggers[len(skippedTriggers)-maxSkippedTriggers:]
+	}
+	return skippedTriggers, true
+}
+
 // ValidateSchedulePolicy Validate if a given schedule policy is valid
 func ValidateSchedulePolicy(policy *stork_api.SchedulePolicy) error {
 	if policy == nil {
@@ -169,9 +567,147 @@ func ValidateSchedulePolicy(policy *stork_api.SchedulePolicy) error {
 			return err
 		}
 	}
+	if policy.Policy.Cron != nil {
+		if err := policy.Policy.Cron.Validate(); err != nil {
+			return err
+		}
+	}
+	for _, window := range policy.Policy.BlackoutWindows {
+		if err := window.Validate(); err != nil {
+			return err
+		}
+	}
+	for _, excluded := range policy.Policy.ExcludedDates {
+		if err := excluded.Validate(); err != nil {
+			return err
+		}
+	}
+	if policy.Policy.JitterMinutes < 0 || time.Duration(policy.Policy.JitterMinutes)*time.Minute >= triggerWindow {
+		return fmt.Errorf("Invalid jitterMinutes (%v), expected a value less than %v", policy.Policy.JitterMinutes, int(triggerWindow/time.Minute))
+	}
+	if policy.Policy.MaxRuntimeMinutes < 0 {
+		return fmt.Errorf("Invalid maxRuntimeMinutes (%v)", policy.Policy.MaxRuntimeMinutes)
+	}
+	switch policy.Policy.MaxRuntimeAction {
+	case "", stork_api.MaxRuntimeActionSkip, stork_api.MaxRuntimeActionCancel:
+	default:
+		return fmt.Errorf("Invalid maxRuntimeAction (%v)", policy.Policy.MaxRuntimeAction)
+	}
+	if policy.NamespaceSelector != nil {
+		if _, err := meta.LabelSelectorAsSelector(policy.NamespaceSelector); err != nil {
+			return fmt.Errorf("Invalid namespaceSelector: %v", err)
+		}
+	}
 	return nil
 }
 
+// GetTriggerDelay Returns the delay for the triggers of a schedule using the
+// policy. Schedules using a policy with jitter are delayed by a fixed amount
+// within the jitter window based on their namespace and name, so that the
+// schedules sharing the policy are staggered.
+func GetTriggerDelay(policyName string, namespace string, name string) (time.Duration, error) {
+	schedulePolicy, err := k8s.Instance().GetSchedulePolicy(policyName)
+	if err != nil {
+		return 0, err
+	}
+	if schedulePolicy.Policy.JitterMinutes <= 0 {
+		return 0, nil
+	}
+	hash := fnv.New32a()
+	if _, err := hash.Write([]byte(namespace + "/" + name)); err != nil {
+		return 0, err
+	}
+	window := uint32(schedulePolicy.Policy.JitterMinutes * 60)
+	return time.Duration(hash.Sum32()%window) * time.Second, nil
+}
+
+// GetMaxRuntime Returns the maximum runtime for actions triggered by the
+// policy and the action to take for actions that run for longer than that.
+// Returns 0 if there is no maximum runtime.
+func GetMaxRuntime(policyName string) (time.Duration, stork_api.MaxRuntimeActionType, error) {
+	schedulePolicy, err := k8s.Instance().GetSchedulePolicy(policyName)
+	if err != nil {
+		return 0, "", err
+	}
+	action := schedulePolicy.Policy.MaxRuntimeAction
+	if action == "" {
+		action = stork_api.MaxRuntimeActionSkip
+	}
+	return time.Duration(schedulePolicy.Policy.MaxRuntimeMinutes) * time.Minute, action, nil
+}
+
+// NamespaceAllowed checks if schedules in the namespace are allowed to use the
+// policy, ie the labels of the namespace match the namespace selector of the
+// policy
+func NamespaceAllowed(policy *stork_api.SchedulePolicy, namespace string) (bool, error) {
+	if policy.NamespaceSelector == nil {
+		return true, nil
+	}
+	selector, err := meta.LabelSelectorAsSelector(policy.NamespaceSelector)
+	if err != nil {
+		return false, fmt.Errorf("invalid namespaceSelector in schedule policy %v: %v", policy.Name, err)
+	}
+	ns, err := k8s.Instance().GetNamespace(namespace)
+	if err != nil {
+		return false, err
+	}
+	return selector.Matches(labels.Set(ns.Labels)), nil
+}
+
+// GetInactiveClusterDomains Returns the cluster domains from the list that
+// are inactive as per the ClusterDomainsStatus. Cluster domains that aren't
+// in the status are treated as active.
+func GetInactiveClusterDomains(domains []string) ([]string, error) {
+	if len(domains) == 0 {
+		return nil, nil
+	}
+	statuses, err := k8s.Instance().ListClusterDomainStatuses()
+	if err != nil {
+		return nil, fmt.Errorf("error getting status of cluster domains: %v", err)
+	}
+	inactive := make(map[string]bool)
+	for _, status := range statuses.Items {
+		for _, domain := range status.Status.Inactive {
+			inactive[domain] = true
+		}
+	}
+	inactiveDomains := make([]string, 0)
+	for _, domain := range domains {
+		if inactive[domain] {
+			inactiveDomains = append(inactiveDomains, domain)
+		}
+	}
+	return inactiveDomains, nil
+}
+
+// RecordClusterDomainSuspension Updates the cluster domains for which a
+// schedule is suspended to the inactive domains, and records the suspension
+// or resumption of the schedule if they have changed. Returns the updated
+// domains and suspensions, and true if they were updated.
+func RecordClusterDomainSuspension(
+	suspendedFor []string,
+	suspensions []*stork_api.ClusterDomainSuspension,
+	inactiveDomains []string,
+) ([]string, []*stork_api.ClusterDomainSuspension, bool) {
+	if len(inactiveDomains) == 0 {
+		inactiveDomains = nil
+	}
+	if reflect.DeepEqual(suspendedFor, inactiveDomains) ||
+		(len(suspendedFor) == 0 && len(inactiveDomains) == 0) {
+		return suspendedFor, suspensions, false
+	}
+
+	suspensions = append(suspensions, &stork_api.ClusterDomainSuspension{
+		Suspended:       len(inactiveDomains) > 0,
+		InactiveDomains: inactiveDomains,
+		Timestamp:       meta.NewTime(GetCurrentTime()),
+	})
+	if len(suspensions) > maxClusterDomainSuspensions {
+		suspensions = suspensions[len(suspensions)-maxClusterDomainSuspensions:]
+	}
+	return inactiveDomains, suspensions, true
+}
+
 // GetRetain Returns the retain value for the specified policy. Returns the
 // default for the policy if none is specified
 func GetRetain(policyName string, policyType stork_api.SchedulePolicyType) (stork_api.Retain, error) {
@@ -208,6 +744,13 @@ func GetRetain(policyName string, policyType stork_api.SchedulePolicyType) (stor
 			}
 			return schedulePolicy.Policy.Monthly.Retain, nil
 		}
+	case stork_api.SchedulePolicyTypeCron:
+		if schedulePolicy.Policy.Cron != nil {
+			if schedulePolicy.Policy.Cron.Retain == 0 {
+				return stork_api.DefaultCronPolicyRetain, nil
+			}
+			return schedulePolicy.Policy.Cron.Retain, nil
+		}
 	default:
 		return 0, fmt.Errorf("invalid policy type: %v", policyType)
 	}
@@ -238,7 +781,7 @@ func Init() error {
 					t, err := time.Parse(time.RFC1123, timeString)
 					if err != nil {
 						err = fmt.Errorf("failed to parse time in mock config map due to: %v", err)
-						logrus.Errorf(err.Error())
+						logrus.Error(err)
 						return err
 					}
 
@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	snapv1 "github.com/kubernetes-incubator/external-storage/snapshot/pkg/apis/crd/v1"
 	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
 	fakeclient "github.com/libopenstorage/stork/pkg/client/clientset/versioned/fake"
 	"github.com/portworx/sched-ops/k8s"
@@ -29,8 +30,21 @@ func TestSchedule(t *testing.T) {
 	t.Run("triggerDailyRequiredTest", triggerDailyRequiredTest)
 	t.Run("triggerWeeklyRequiredTest", triggerWeeklyRequiredTest)
 	t.Run("triggerMonthlyRequiredTest", triggerMonthlyRequiredTest)
+	t.Run("triggerCronRequiredTest", triggerCronRequiredTest)
+	t.Run("cronNextTimeTest", cronNextTimeTest)
 	t.Run("validateSchedulePolicyTest", validateSchedulePolicyTest)
 	t.Run("policyRetainTest", policyRetainTest)
+	t.Run("blackoutWindowTest", blackoutWindowTest)
+	t.Run("recordSkippedTriggerTest", recordSkippedTriggerTest)
+	t.Run("missedTriggersTest", missedTriggersTest)
+	t.Run("catchUpRequiredTest", catchUpRequiredTest)
+	t.Run("cronMissedTriggersTest", cronMissedTriggersTest)
+	t.Run("timeZoneTest", timeZoneTest)
+	t.Run("jitterTest", jitterTest)
+	t.Run("maxRuntimeTest", maxRuntimeTest)
+	t.Run("excludedDatesTest", excludedDatesTest)
+	t.Run("dependencyTest", dependencyTest)
+	t.Run("clusterDomainSuspensionTest", clusterDomainSuspensionTest)
 }
 
 func triggerIntervalRequiredTest(t *testing.T) {
@@ -52,25 +66,25 @@ func triggerIntervalRequiredTest(t *testing.T) {
 	require.NoError(t, err, "Error creating policy")
 
 	var latestMigrationTimestamp meta.Time
-	required, err := TriggerRequired("intervalpolicy", stork_api.SchedulePolicyTypeInterval, latestMigrationTimestamp)
+	required, err := TriggerRequired("intervalpolicy", stork_api.SchedulePolicyTypeInterval, latestMigrationTimestamp, 0)
 	require.NoError(t, err, "Error checking if trigger required")
 	require.True(t, required, "Trigger should have been required")
 
-	_, err = TriggerRequired("missingpolicy", stork_api.SchedulePolicyTypeInterval, meta.Date(2019, time.February, 7, 23, 14, 0, 0, time.Local))
+	_, err = TriggerRequired("missingpolicy", stork_api.SchedulePolicyTypeInterval, meta.Date(2019, time.February, 7, 23, 14, 0, 0, time.Local), 0)
 	require.Error(t, err, "Should return error for missing policy")
 
 	mockNow := time.Date(2019, time.February, 7, 23, 16, 0, 0, time.Local)
 	setMockTime(&mockNow)
 	// Last triggered 2 mins ago
-	required, err = TriggerRequired("intervalpolicy", stork_api.SchedulePolicyTypeInterval, meta.Date(2019, time.February, 7, 23, 14, 0, 0, time.Local))
+	required, err = TriggerRequired("intervalpolicy", stork_api.SchedulePolicyTypeInterval, meta.Date(2019, time.February, 7, 23, 14, 0, 0, time.Local), 0)
 	require.NoError(t, err, "Error checking if trigger required")
 	require.False(t, required, "Trigger should not have been required")
 	// Last triggered 59 mins ago
-	required, err = TriggerRequired("intervalpolicy", stork_api.SchedulePolicyTypeInterval, meta.Date(2019, time.February, 7, 22, 16, 0, 0, time.Local))
+	required, err = TriggerRequired("intervalpolicy", stork_api.SchedulePolicyTypeInterval, meta.Date(2019, time.February, 7, 22, 16, 0, 0, time.Local), 0)
 	require.NoError(t, err, "Error checking if trigger required")
 	require.False(t, required, "Trigger should not have been required")
 	// Last triggered 61 mins ago
-	required, err = TriggerRequired("intervalpolicy", stork_api.SchedulePolicyTypeInterval, meta.Date(2019, time.February, 7, 22, 14, 0, 0, time.Local))
+	required, err = TriggerRequired("intervalpolicy", stork_api.SchedulePolicyTypeInterval, meta.Date(2019, time.February, 7, 22, 14, 0, 0, time.Local), 0)
 	require.NoError(t, err, "Error checking if trigger required")
 	require.True(t, required, "Trigger should have been required")
 }
@@ -93,33 +107,33 @@ func triggerDailyRequiredTest(t *testing.T) {
 	})
 	require.NoError(t, err, "Error creating policy")
 
-	_, err = TriggerRequired("missingpolicy", stork_api.SchedulePolicyTypeDaily, meta.Date(2019, time.February, 7, 23, 14, 0, 0, time.Local))
+	_, err = TriggerRequired("missingpolicy", stork_api.SchedulePolicyTypeDaily, meta.Date(2019, time.February, 7, 23, 14, 0, 0, time.Local), 0)
 	require.Error(t, err, "Should return error for missing policy")
 
 	mockNow := time.Date(2019, time.February, 7, 23, 16, 0, 0, time.Local)
 	setMockTime(&mockNow)
 	// Last triggered before schedule
-	required, err := TriggerRequired("dailypolicy", stork_api.SchedulePolicyTypeDaily, meta.Date(2019, time.February, 7, 23, 14, 0, 0, time.Local))
+	required, err := TriggerRequired("dailypolicy", stork_api.SchedulePolicyTypeDaily, meta.Date(2019, time.February, 7, 23, 14, 0, 0, time.Local), 0)
 	require.NoError(t, err, "Error checking if trigger required")
 	require.True(t, required, "Trigger should have been required")
 
 	// Last triggered at schedule
-	required, err = TriggerRequired("dailypolicy", stork_api.SchedulePolicyTypeDaily, meta.Date(2019, time.February, 7, 23, 15, 0, 0, time.Local))
+	required, err = TriggerRequired("dailypolicy", stork_api.SchedulePolicyTypeDaily, meta.Date(2019, time.February, 7, 23, 15, 0, 0, time.Local), 0)
 	require.NoError(t, err, "Error checking if trigger required")
 	require.False(t, required, "Trigger should not have been required")
 
 	// Last triggered one day ago at schedule
-	required, err = TriggerRequired("dailypolicy", stork_api.SchedulePolicyTypeDaily, meta.Date(2019, time.February, 6, 23, 15, 0, 0, time.Local))
+	required, err = TriggerRequired("dailypolicy", stork_api.SchedulePolicyTypeDaily, meta.Date(2019, time.February, 6, 23, 15, 0, 0, time.Local), 0)
 	require.NoError(t, err, "Error checking if trigger required")
 	require.True(t, required, "Trigger should have been required")
 
 	// Last triggered one day ago before schedule
-	required, err = TriggerRequired("dailypolicy", stork_api.SchedulePolicyTypeDaily, meta.Date(2019, time.February, 6, 23, 14, 0, 0, time.Local))
+	required, err = TriggerRequired("dailypolicy", stork_api.SchedulePolicyTypeDaily, meta.Date(2019, time.February, 6, 23, 14, 0, 0, time.Local), 0)
 	require.NoError(t, err, "Error checking if trigger required")
 	require.True(t, required, "Trigger should have been required")
 
 	// Last triggered one day ago after schedule
-	required, err = TriggerRequired("dailypolicy", stork_api.SchedulePolicyTypeDaily, meta.Date(2019, time.February, 6, 23, 16, 0, 0, time.Local))
+	required, err = TriggerRequired("dailypolicy", stork_api.SchedulePolicyTypeDaily, meta.Date(2019, time.February, 6, 23, 16, 0, 0, time.Local), 0)
 	require.NoError(t, err, "Error checking if trigger required")
 	require.True(t, required, "Trigger should have been required")
 
@@ -128,12 +142,12 @@ func triggerDailyRequiredTest(t *testing.T) {
 	setMockTime(&mockNow)
 
 	// Last triggered one day ago at schedule
-	required, err = TriggerRequired("dailypolicy", stork_api.SchedulePolicyTypeDaily, meta.Date(2019, time.February, 7, 23, 15, 0, 0, time.Local))
+	required, err = TriggerRequired("dailypolicy", stork_api.SchedulePolicyTypeDaily, meta.Date(2019, time.February, 7, 23, 15, 0, 0, time.Local), 0)
 	require.NoError(t, err, "Error checking if trigger required")
 	require.False(t, required, "Trigger should not have been required")
 
 	// Last triggered one day ago after schedule
-	required, err = TriggerRequired("dailypolicy", stork_api.SchedulePolicyTypeDaily, meta.Date(2019, time.February, 7, 23, 16, 0, 0, time.Local))
+	required, err = TriggerRequired("dailypolicy", stork_api.SchedulePolicyTypeDaily, meta.Date(2019, time.February, 7, 23, 16, 0, 0, time.Local), 0)
 	require.NoError(t, err, "Error checking if trigger required")
 	require.False(t, required, "Trigger should not have been required")
 }
@@ -157,25 +171,25 @@ func triggerWeeklyRequiredTest(t *testing.T) {
 	})
 	require.NoError(t, err, "Error creating policy")
 
-	_, err = TriggerRequired("missingpolicy", stork_api.SchedulePolicyTypeWeekly, meta.Date(2019, time.February, 7, 23, 14, 0, 0, time.Local))
+	_, err = TriggerRequired("missingpolicy", stork_api.SchedulePolicyTypeWeekly, meta.Date(2019, time.February, 7, 23, 14, 0, 0, time.Local), 0)
 	require.Error(t, err, "Should return error for missing policy")
 
 	newTime := time.Date(2019, time.February, 7, 23, 16, 0, 0, time.Local) // Current day: Thursday
 	setMockTime(&newTime)
 	// LastTriggered one week before on Saturday at 11:15pm
-	required, err := TriggerRequired("weeklypolicy", stork_api.SchedulePolicyTypeWeekly, meta.Date(2019, time.February, 2, 23, 16, 0, 0, time.Local))
+	required, err := TriggerRequired("weeklypolicy", stork_api.SchedulePolicyTypeWeekly, meta.Date(2019, time.February, 2, 23, 16, 0, 0, time.Local), 0)
 	require.NoError(t, err, "Error checking if trigger required")
 	require.False(t, required, "Trigger should not have been required")
 
 	// LastTriggered one week before on Sunday at 11:15pm
-	required, err = TriggerRequired("weeklypolicy", stork_api.SchedulePolicyTypeWeekly, meta.Date(2019, time.February, 3, 23, 15, 0, 0, time.Local))
+	required, err = TriggerRequired("weeklypolicy", stork_api.SchedulePolicyTypeWeekly, meta.Date(2019, time.February, 3, 23, 15, 0, 0, time.Local), 0)
 	require.NoError(t, err, "Error checking if trigger required")
 	require.False(t, required, "Trigger should not have been required")
 
 	newTime = time.Date(2019, time.February, 10, 23, 16, 0, 0, time.Local) // Current date: Sunday 11:16pm
 	setMockTime(&newTime)
 	// LastTriggered last Wednesday at 11:16pm
-	required, err = TriggerRequired("weeklypolicy", stork_api.SchedulePolicyTypeWeekly, meta.Date(2019, time.February, 6, 23, 16, 0, 0, time.Local))
+	required, err = TriggerRequired("weeklypolicy", stork_api.SchedulePolicyTypeWeekly, meta.Date(2019, time.February, 6, 23, 16, 0, 0, time.Local), 0)
 	require.NoError(t, err, "Error checking if trigger required")
 	require.True(t, required, "Trigger should have been required")
 }
@@ -194,18 +208,18 @@ func triggerMonthlyRequiredTest(t *testing.T) {
 	})
 	require.NoError(t, err, "Error creating policy")
 
-	_, err = TriggerRequired("missingpolicy", stork_api.SchedulePolicyTypeMonthly, meta.Date(2019, time.February, 7, 23, 14, 0, 0, time.Local))
+	_, err = TriggerRequired("missingpolicy", stork_api.SchedulePolicyTypeMonthly, meta.Date(2019, time.February, 7, 23, 14, 0, 0, time.Local), 0)
 	require.Error(t, err, "Should return error for missing policy")
 
 	newTime := time.Date(2019, time.February, 28, 23, 16, 0, 0, time.Local)
 	setMockTime(&newTime)
 	// Last triggered before schedule
-	required, err := TriggerRequired("monthlypolicy", stork_api.SchedulePolicyTypeMonthly, meta.Date(2019, time.February, 2, 23, 16, 0, 0, time.Local))
+	required, err := TriggerRequired("monthlypolicy", stork_api.SchedulePolicyTypeMonthly, meta.Date(2019, time.February, 2, 23, 16, 0, 0, time.Local), 0)
 	require.NoError(t, err, "Error checking if trigger required")
 	require.True(t, required, "Trigger should have been required")
 
 	// Last triggered one minute after schedule
-	required, err = TriggerRequired("monthlypolicy", stork_api.SchedulePolicyTypeMonthly, meta.Date(2019, time.February, 28, 23, 16, 0, 0, time.Local))
+	required, err = TriggerRequired("monthlypolicy", stork_api.SchedulePolicyTypeMonthly, meta.Date(2019, time.February, 28, 23, 16, 0, 0, time.Local), 0)
 	require.NoError(t, err, "Error checking if trigger required")
 	require.False(t, required, "Trigger should not have been required")
 }
@@ -311,6 +325,34 @@ func validateSchedulePolicyTest(t *testing.T) {
 	}
 	err = ValidateSchedulePolicy(policy)
 	require.Error(t, err, "Invalid monthly policy should return error")
+
+	policy = &stork_api.SchedulePolicy{
+		ObjectMeta: meta.ObjectMeta{
+			Name: "invalidblackoutpolicy",
+		},
+		Policy: stork_api.SchedulePolicyItem{
+			Daily: &stork_api.DailyPolicy{
+				Time: "01:15am",
+			},
+			BlackoutWindows: []*stork_api.BlackoutWindow{
+				{
+					Start: "01:00am",
+					End:   "01:00am",
+				},
+			},
+		},
+	}
+	err = ValidateSchedulePolicy(policy)
+	require.Error(t, err, "Blackout window with same start and end should return error")
+
+	policy.Policy.BlackoutWindows[0].End = "03:00am"
+	policy.Policy.BlackoutWindows[0].Days = []string{"Funday"}
+	err = ValidateSchedulePolicy(policy)
+	require.Error(t, err, "Blackout window with invalid day should return error")
+
+	policy.Policy.BlackoutWindows[0].Days = []string{"Mon"}
+	err = ValidateSchedulePolicy(policy)
+	require.NoError(t, err, "Valid blackout window shouldn't return error")
 }
 
 func policyRetainTest(t *testing.T) {
@@ -407,3 +449,768 @@ func policyRetainTest(t *testing.T) {
 	require.NoError(t, err, "Error getting retain")
 	require.Equal(t, policy.Policy.Monthly.Retain, retain, "Wrong default retain for monthly policy")
 }
+
+func blackoutWindowTest(t *testing.T) {
+	defer func() {
+		err := k8s.Instance().DeleteSchedulePolicy("blackoutpolicy")
+		require.NoError(t, err, "Error cleaning up schedule policy")
+	}()
+
+	_, err := k8s.Instance().CreateSchedulePolicy(&stork_api.SchedulePolicy{
+		ObjectMeta: meta.ObjectMeta{
+			Name: "blackoutpolicy",
+		},
+		Policy: stork_api.SchedulePolicyItem{
+			Interval: &stork_api.IntervalPolicy{
+				IntervalMinutes: 60,
+			},
+			BlackoutWindows: []*stork_api.BlackoutWindow{
+				{
+					Start: "01:00AM",
+					End:   "03:00AM",
+				},
+				{
+					Start: "11:00PM",
+					End:   "12:30AM",
+					Days:  []string{"Friday"},
+				},
+			},
+		},
+	})
+	require.NoError(t, err, "Error creating policy")
+
+	_, err = InBlackoutWindow("missingpolicy")
+	require.Error(t, err, "Should return error for missing policy")
+
+	// Thursday 2:00AM, in the daily window
+	mockNow := time.Date(2019, time.February, 7, 2, 0, 0, 0, time.Local)
+	setMockTime(&mockNow)
+	window, err := InBlackoutWindow("blackoutpolicy")
+	require.NoError(t, err, "Error checking blackout window")
+	require.NotNil(t, window, "Should be in blackout window")
+	require.Equal(t, "01:00AM", window.Start, "Wrong blackout window returned")
+
+	// Thursday 3:00AM, end of the daily window
+	mockNow = time.Date(2019, time.February, 7, 3, 0, 0, 0, time.Local)
+	setMockTime(&mockNow)
+	window, err = InBlackoutWindow("blackoutpolicy")
+	require.NoError(t, err, "Error checking blackout window")
+	require.Nil(t, window, "Should not be in blackout window")
+
+	// Thursday 11:30PM, window only applies on Friday
+	mockNow = time.Date(2019, time.February, 7, 23, 30, 0, 0, time.Local)
+	setMockTime(&mockNow)
+	window, err = InBlackoutWindow("blackoutpolicy")
+	require.NoError(t, err, "Error checking blackout window")
+	require.Nil(t, window, "Should not be in blackout window")
+
+	// Friday 11:30PM
+	mockNow = time.Date(2019, time.February, 8, 23, 30, 0, 0, time.Local)
+	setMockTime(&mockNow)
+	window, err = InBlackoutWindow("blackoutpolicy")
+	require.NoError(t, err, "Error checking blackout window")
+	require.NotNil(t, window, "Should be in blackout window")
+	require.Equal(t, "11:00PM", window.Start, "Wrong blackout window returned")
+
+	// Saturday 12:15AM, window started on Friday
+	mockNow = time.Date(2019, time.February, 9, 0, 15, 0, 0, time.Local)
+	setMockTime(&mockNow)
+	window, err = InBlackoutWindow("blackoutpolicy")
+	require.NoError(t, err, "Error checking blackout window")
+	require.NotNil(t, window, "Should be in blackout window")
+
+	// Friday 12:15AM, window would have started on Thursday
+	mockNow = time.Date(2019, time.February, 8, 0, 15, 0, 0, time.Local)
+	setMockTime(&mockNow)
+	window, err = InBlackoutWindow("blackoutpolicy")
+	require.NoError(t, err, "Error checking blackout window")
+	require.Nil(t, window, "Should not be in blackout window")
+}
+
+func recordSkippedTriggerTest(t *testing.T) {
+	reason := "Trigger skipped during blackout window 01:00AM-03:00AM"
+	lastTrigger := meta.Date(2019, time.February, 7, 0, 30, 0, 0, time.Local)
+	mockNow := time.Date(2019, time.February, 7, 1, 30, 0, 0, time.Local)
+	setMockTime(&mockNow)
+
+	skipped, updated := RecordSkippedTrigger(nil, stork_api.SchedulePolicyTypeInterval, lastTrigger, reason)
+	require.True(t, updated, "Skipped trigger should have been recorded")
+	require.Len(t, skipped, 1, "Wrong number of skipped triggers")
+	require.Equal(t, stork_api.SchedulePolicyTypeInterval, skipped[0].PolicyType, "Wrong policy type for skipped trigger")
+	require.Equal(t, reason, skipped[0].Reason, "Wrong reason for skipped trigger")
+
+	// Same trigger shouldn't be recorded again
+	mockNow = time.Date(2019, time.February, 7, 1, 45, 0, 0, time.Local)
+	setMockTime(&mockNow)
+	skipped, updated = RecordSkippedTrigger(skipped, stork_api.SchedulePolicyTypeInterval, lastTrigger, reason)
+	require.False(t, updated, "Skipped trigger shouldn't have been recorded again")
+	require.Len(t, skipped, 1, "Wrong number of skipped triggers")
+
+	skipped, updated = RecordSkippedTrigger(skipped, stork_api.SchedulePolicyTypeDaily, lastTrigger, reason)
+	require.True(t, updated, "Skipped trigger should have been recorded")
+	require.Len(t, skipped, 2, "Wrong number of skipped triggers")
+
+	// Only the latest skipped triggers should be retained
+	for i := 0; i < maxSkippedTriggers; i++ {
+		mockNow = mockNow.Add(time.Minute)
+		setMockTime(&mockNow)
+		skipped, _ = RecordSkippedTrigger(skipped, stork_api.SchedulePolicyTypeInterval, meta.NewTime(mockNow.Add(-time.Second)), reason)
+	}
+	require.Len(t, skipped, maxSkippedTriggers, "Wrong number of skipped triggers")
+	require.Equal(t, stork_api.SchedulePolicyTypeInterval, skipped[0].PolicyType, "Oldest skipped triggers should have been removed")
+}
+
+func missedTriggersTest(t *testing.T) {
+	defer func() {
+		err := k8s.Instance().DeleteSchedulePolicy("catchuppolicy")
+		require.NoError(t, err, "Error cleaning up schedule policy")
+	}()
+
+	_, err := k8s.Instance().CreateSchedulePolicy(&stork_api.SchedulePolicy{
+		ObjectMeta: meta.ObjectMeta{
+			Name: "catchuppolicy",
+		},
+		Policy: stork_api.SchedulePolicyItem{
+			Interval: &stork_api.IntervalPolicy{
+				IntervalMinutes: 60,
+			},
+			Daily: &stork_api.DailyPolicy{
+				Time: "11:15PM",
+			},
+			Weekly: &stork_api.WeeklyPolicy{
+				Day:  "Thursday",
+				Time: "11:15PM",
+			},
+			Monthly: &stork_api.MonthlyPolicy{
+				Date: 7,
+				Time: "11:15PM",
+			},
+		},
+	})
+	require.NoError(t, err, "Error creating policy")
+
+	// Thursday
+	mockNow := time.Date(2019, time.February, 7, 23, 16, 0, 0, time.Local)
+	setMockTime(&mockNow)
+
+	// 4 hours since the last interval trigger, the current trigger isn't
+	// counted
+	missed, err := MissedTriggers("catchuppolicy", stork_api.SchedulePolicyTypeInterval, meta.Date(2019, time.February, 7, 19, 16, 0, 0, time.Local), 0)
+	require.NoError(t, err, "Error getting missed triggers")
+	require.Equal(t, 3, missed, "Wrong number of missed interval triggers")
+
+	// Current daily trigger is still in the trigger window
+	missed, err = MissedTriggers("catchuppolicy", stork_api.SchedulePolicyTypeDaily, meta.Date(2019, time.February, 6, 23, 15, 0, 0, time.Local), 0)
+	require.NoError(t, err, "Error getting missed triggers")
+	require.Equal(t, 0, missed, "Wrong number of missed daily triggers")
+
+	missed, err = MissedTriggers("catchuppolicy", stork_api.SchedulePolicyTypeDaily, meta.Date(2019, time.February, 3, 23, 15, 0, 0, time.Local), 0)
+	require.NoError(t, err, "Error getting missed triggers")
+	require.Equal(t, 3, missed, "Wrong number of missed daily triggers")
+
+	// Missed triggers are capped
+	missed, err = MissedTriggers("catchuppolicy", stork_api.SchedulePolicyTypeDaily, meta.Date(2018, time.February, 3, 23, 15, 0, 0, time.Local), 0)
+	require.NoError(t, err, "Error getting missed triggers")
+	require.Equal(t, maxCatchUpTriggers, missed, "Wrong number of missed daily triggers")
+
+	mockNow = time.Date(2019, time.February, 9, 10, 0, 0, 0, time.Local)
+	setMockTime(&mockNow)
+	missed, err = MissedTriggers("catchuppolicy", stork_api.SchedulePolicyTypeWeekly, meta.Date(2019, time.January, 24, 23, 15, 0, 0, time.Local), 0)
+	require.NoError(t, err, "Error getting missed triggers")
+	require.Equal(t, 2, missed, "Wrong number of missed weekly triggers")
+
+	missed, err = MissedTriggers("catchuppolicy", stork_api.SchedulePolicyTypeMonthly, meta.Date(2018, time.December, 7, 23, 15, 0, 0, time.Local), 0)
+	require.NoError(t, err, "Error getting missed triggers")
+	require.Equal(t, 2, missed, "Wrong number of missed monthly triggers")
+
+	missed, err = MissedTriggers("catchuppolicy", stork_api.SchedulePolicyTypeMonthly, meta.Date(2019, time.February, 7, 23, 15, 0, 0, time.Local), 0)
+	require.NoError(t, err, "Error getting missed triggers")
+	require.Equal(t, 0, missed, "Wrong number of missed monthly triggers")
+}
+
+func catchUpRequiredTest(t *testing.T) {
+	defer func() {
+		err := k8s.Instance().DeleteSchedulePolicy("catchuppolicy")
+		require.NoError(t, err, "Error cleaning up schedule policy")
+	}()
+
+	_, err := k8s.Instance().CreateSchedulePolicy(&stork_api.SchedulePolicy{
+		ObjectMeta: meta.ObjectMeta{
+			Name: "catchuppolicy",
+		},
+		Policy: stork_api.SchedulePolicyItem{
+			Daily: &stork_api.DailyPolicy{
+				Time: "11:15PM",
+			},
+		},
+	})
+	require.NoError(t, err, "Error creating policy")
+
+	mockNow := time.Date(2019, time.February, 7, 10, 0, 0, 0, time.Local)
+	setMockTime(&mockNow)
+	// 3 missed triggers
+	lastTrigger := meta.Date(2019, time.February, 3, 23, 15, 0, 0, time.Local)
+
+	trigger, pending, err := CatchUpRequired("catchuppolicy", stork_api.SchedulePolicyTypeDaily, lastTrigger, "", false, 0, 0)
+	require.NoError(t, err, "Error checking catch up")
+	require.False(t, trigger, "Trigger should not have been required for default policy")
+	require.Equal(t, 0, pending)
+
+	trigger, pending, err = CatchUpRequired("catchuppolicy", stork_api.SchedulePolicyTypeDaily, lastTrigger, stork_api.CatchUpPolicySkip, false, 0, 0)
+	require.NoError(t, err, "Error checking catch up")
+	require.False(t, trigger, "Trigger should not have been required for Skip policy")
+	require.Equal(t, 0, pending)
+
+	trigger, pending, err = CatchUpRequired("catchuppolicy", stork_api.SchedulePolicyTypeDaily, lastTrigger, stork_api.CatchUpPolicyRunOnce, false, 0, 0)
+	require.NoError(t, err, "Error checking catch up")
+	require.True(t, trigger, "Trigger should have been required for RunOnce policy")
+	require.Equal(t, 0, pending)
+
+	trigger, pending, err = CatchUpRequired("catchuppolicy", stork_api.SchedulePolicyTypeDaily, lastTrigger, stork_api.CatchUpPolicyRunAllMissed, false, 0, 0)
+	require.NoError(t, err, "Error checking catch up")
+	require.True(t, trigger, "Trigger should have been required for RunAllMissed policy")
+	require.Equal(t, 2, pending)
+
+	// Pending triggers are run even though nothing was missed since the last
+	// trigger
+	lastTrigger = meta.NewTime(mockNow)
+	trigger, pending, err = CatchUpRequired("catchuppolicy", stork_api.SchedulePolicyTypeDaily, lastTrigger, stork_api.CatchUpPolicyRunAllMissed, false, 2, 0)
+	require.NoError(t, err, "Error checking catch up")
+	require.True(t, trigger, "Trigger should have been required for pending trigger")
+	require.Equal(t, 1, pending)
+
+	trigger, pending, err = CatchUpRequired("catchuppolicy", stork_api.SchedulePolicyTypeDaily, lastTrigger, stork_api.CatchUpPolicyRunAllMissed, false, 0, 0)
+	require.NoError(t, err, "Error checking catch up")
+	require.False(t, trigger, "Trigger should not have been required")
+	require.Equal(t, 0, pending)
+
+	// Nothing to catch up on for the first trigger
+	trigger, _, err = CatchUpRequired("catchuppolicy", stork_api.SchedulePolicyTypeDaily, meta.Time{}, stork_api.CatchUpPolicyRunOnce, false, 0, 0)
+	require.NoError(t, err, "Error checking catch up")
+	require.False(t, trigger, "Trigger should not have been required")
+
+	_, _, err = CatchUpRequired("catchuppolicy", stork_api.SchedulePolicyTypeDaily, lastTrigger, "Invalid", false, 0, 0)
+	require.Error(t, err, "Expected error for invalid catch up policy")
+}
+
+func triggerCronRequiredTest(t *testing.T) {
+	defer func() {
+		err := k8s.Instance().DeleteSchedulePolicy("cronpolicy")
+		require.NoError(t, err, "Error cleaning up schedule policy")
+	}()
+
+	// Weekdays at 23:15
+	_, err := k8s.Instance().CreateSchedulePolicy(&stork_api.SchedulePolicy{
+		ObjectMeta: meta.ObjectMeta{
+			Name: "cronpolicy",
+		},
+		Policy: stork_api.SchedulePolicyItem{
+			Cron: &stork_api.CronPolicy{
+				Expression: "15 23 * * mon-fri",
+			},
+		},
+	})
+	require.NoError(t, err, "Error creating policy")
+
+	// Thursday before the scheduled time
+	mockNow := time.Date(2019, time.February, 7, 23, 14, 0, 0, time.Local)
+	setMockTime(&mockNow)
+	required, err := TriggerRequired("cronpolicy", stork_api.SchedulePolicyTypeCron, meta.Date(2019, time.February, 6, 23, 15, 0, 0, time.Local), 0)
+	require.NoError(t, err, "Error checking if trigger required")
+	require.False(t, required, "Trigger should not have been required")
+
+	// After the scheduled time, with and without a trigger since then
+	mockNow = time.Date(2019, time.February, 7, 23, 16, 0, 0, time.Local)
+	setMockTime(&mockNow)
+	required, err = TriggerRequired("cronpolicy", stork_api.SchedulePolicyTypeCron, meta.Date(2019, time.February, 6, 23, 15, 0, 0, time.Local), 0)
+	require.NoError(t, err, "Error checking if trigger required")
+	require.True(t, required, "Trigger should have been required")
+	required, err = TriggerRequired("cronpolicy", stork_api.SchedulePolicyTypeCron, meta.Date(2019, time.February, 7, 23, 15, 0, 0, time.Local), 0)
+	require.NoError(t, err, "Error checking if trigger required")
+	require.False(t, required, "Trigger should not have been required")
+
+	// Not triggered after the trigger window has passed
+	mockNow = time.Date(2019, time.February, 8, 0, 16, 0, 0, time.Local)
+	setMockTime(&mockNow)
+	required, err = TriggerRequired("cronpolicy", stork_api.SchedulePolicyTypeCron, meta.Date(2019, time.February, 6, 23, 15, 0, 0, time.Local), 0)
+	require.NoError(t, err, "Error checking if trigger required")
+	require.False(t, required, "Trigger should not have been required")
+
+	// Not triggered on Saturday
+	mockNow = time.Date(2019, time.February, 9, 23, 16, 0, 0, time.Local)
+	setMockTime(&mockNow)
+	required, err = TriggerRequired("cronpolicy", stork_api.SchedulePolicyTypeCron, meta.Date(2019, time.February, 8, 23, 15, 0, 0, time.Local), 0)
+	require.NoError(t, err, "Error checking if trigger required")
+	require.False(t, required, "Trigger should not have been required")
+
+	retain, err := GetRetain("cronpolicy", stork_api.SchedulePolicyTypeCron)
+	require.NoError(t, err, "Error getting retain")
+	require.Equal(t, stork_api.DefaultCronPolicyRetain, retain)
+}
+
+func cronNextTimeTest(t *testing.T) {
+	start := time.Date(2019, time.February, 7, 23, 14, 30, 0, time.Local)
+	for _, test := range []struct {
+		expression string
+		next       time.Time
+	}{
+		{"* * * * *", time.Date(2019, time.February, 7, 23, 15, 0, 0, time.Local)},
+		{"*/10 * * * * *", time.Date(2019, time.February, 7, 23, 14, 40, 0, time.Local)},
+		{"0 */6 * * *", time.Date(2019, time.February, 8, 0, 0, 0, 0, time.Local)},
+		{"30 2 1,15 * *", time.Date(2019, time.February, 15, 2, 30, 0, 0, time.Local)},
+		{"0 0 * * 0", time.Date(2019, time.February, 10, 0, 0, 0, 0, time.Local)},
+		{"0 0 * * 7", time.Date(2019, time.February, 10, 0, 0, 0, 0, time.Local)},
+		{"0 0 29 feb *", time.Date(2020, time.February, 29, 0, 0, 0, 0, time.Local)},
+		// Either day field matches if both are restricted
+		{"0 0 1 * fri", time.Date(2019, time.February, 8, 0, 0, 0, 0, time.Local)},
+		{"0 0 30 2 *", time.Time{}},
+	} {
+		policy := &stork_api.CronPolicy{Expression: test.expression}
+		require.NoError(t, policy.Validate(), "Error validating %v", test.expression)
+		next, err := policy.GetNextTime(start)
+		require.NoError(t, err, "Error getting next time for %v", test.expression)
+		require.True(t, test.next.Equal(next), "Unexpected next time for %v: %v", test.expression, next)
+	}
+
+	for _, expression := range []string{"", "* * * *", "* * * * * * *", "60 * * * *", "* 24 * * *",
+		"* * 0 * *", "* * * 13 *", "* * * * 8", "5-1 * * * *", "*/0 * * * *", "* * * * someday"} {
+		policy := &stork_api.CronPolicy{Expression: expression}
+		require.Error(t, policy.Validate(), "Expression %q should be invalid", expression)
+		err := ValidateSchedulePolicy(&stork_api.SchedulePolicy{
+			Policy: stork_api.SchedulePolicyItem{Cron: policy},
+		})
+		require.Error(t, err, "Policy with expression %q should be invalid", expression)
+	}
+}
+
+func cronMissedTriggersTest(t *testing.T) {
+	defer func() {
+		err := k8s.Instance().DeleteSchedulePolicy("croncatchuppolicy")
+		require.NoError(t, err, "Error cleaning up schedule policy")
+	}()
+
+	// Every 6 hours
+	_, err := k8s.Instance().CreateSchedulePolicy(&stork_api.SchedulePolicy{
+		ObjectMeta: meta.ObjectMeta{
+			Name: "croncatchuppolicy",
+		},
+		Policy: stork_api.SchedulePolicyItem{
+			Cron: &stork_api.CronPolicy{
+				Expression: "0 */6 * * *",
+			},
+		},
+	})
+	require.NoError(t, err, "Error creating policy")
+
+	// 00:00, 06:00 and 12:00 were missed, 18:00 is still within the trigger
+	// window
+	mockNow := time.Date(2019, time.February, 7, 18, 30, 0, 0, time.Local)
+	setMockTime(&mockNow)
+	missed, err := MissedTriggers("croncatchuppolicy", stork_api.SchedulePolicyTypeCron, meta.Date(2019, time.February, 6, 18, 0, 0, 0, time.Local), 0)
+	require.NoError(t, err, "Error getting missed triggers")
+	require.Equal(t, 3, missed)
+
+	missed, err = MissedTriggers("croncatchuppolicy", stork_api.SchedulePolicyTypeCron, meta.Date(2019, time.January, 6, 18, 0, 0, 0, time.Local), 0)
+	require.NoError(t, err, "Error getting missed triggers")
+	require.Equal(t, maxCatchUpTriggers, missed)
+}
+
+func timeZoneTest(t *testing.T) {
+	defer func() {
+		err := k8s.Instance().DeleteSchedulePolicy("timezonepolicy")
+		require.NoError(t, err, "Error cleaning up schedule policy")
+	}()
+
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err, "Error loading location")
+	_, err = k8s.Instance().CreateSchedulePolicy(&stork_api.SchedulePolicy{
+		ObjectMeta: meta.ObjectMeta{
+			Name: "timezonepolicy",
+		},
+		Policy: stork_api.SchedulePolicyItem{
+			Daily: &stork_api.DailyPolicy{
+				Time:     "11:15pm",
+				TimeZone: "America/New_York",
+			},
+			Weekly: &stork_api.WeeklyPolicy{
+				Day:      "Sunday",
+				Time:     "11:15pm",
+				TimeZone: "America/New_York",
+			},
+			Cron: &stork_api.CronPolicy{
+				Expression: "15 23 * * *",
+				TimeZone:   "America/New_York",
+			},
+		},
+	})
+	require.NoError(t, err, "Error creating policy")
+
+	// 11:16pm in New York is the next day in UTC
+	mockNow := time.Date(2019, time.February, 8, 4, 16, 0, 0, time.UTC)
+	setMockTime(&mockNow)
+	lastTrigger := meta.NewTime(time.Date(2019, time.February, 6, 23, 16, 0, 0, newYork))
+	for _, policyType := range []stork_api.SchedulePolicyType{stork_api.SchedulePolicyTypeDaily, stork_api.SchedulePolicyTypeCron} {
+		required, err := TriggerRequired("timezonepolicy", policyType, lastTrigger, 0)
+		require.NoError(t, err, "Error checking if trigger required")
+		require.True(t, required, "Trigger should have been required for %v", policyType)
+	}
+
+	// 11:16pm UTC is still the afternoon in New York
+	mockNow = time.Date(2019, time.February, 7, 23, 16, 0, 0, time.UTC)
+	setMockTime(&mockNow)
+	for _, policyType := range []stork_api.SchedulePolicyType{stork_api.SchedulePolicyTypeDaily, stork_api.SchedulePolicyTypeCron} {
+		required, err := TriggerRequired("timezonepolicy", policyType, lastTrigger, 0)
+		require.NoError(t, err, "Error checking if trigger required")
+		require.False(t, required, "Trigger should not have been required for %v", policyType)
+	}
+
+	// Triggered at 11:15pm local time after daylight saving time starts
+	mockNow = time.Date(2019, time.March, 10, 23, 16, 0, 0, newYork)
+	setMockTime(&mockNow)
+	required, err := TriggerRequired("timezonepolicy", stork_api.SchedulePolicyTypeWeekly, meta.NewTime(time.Date(2019, time.March, 3, 23, 15, 0, 0, newYork)), 0)
+	require.NoError(t, err, "Error checking if trigger required")
+	require.True(t, required, "Trigger should have been required")
+	missed, err := MissedTriggers("timezonepolicy", stork_api.SchedulePolicyTypeDaily, meta.NewTime(time.Date(2019, time.March, 7, 23, 15, 0, 0, newYork)), 0)
+	require.NoError(t, err, "Error getting missed triggers")
+	require.Equal(t, 2, missed)
+
+	err = ValidateSchedulePolicy(&stork_api.SchedulePolicy{
+		Policy: stork_api.SchedulePolicyItem{
+			Daily: &stork_api.DailyPolicy{
+				Time:     "11:15pm",
+				TimeZone: "Mars/Olympus_Mons",
+			},
+		},
+	})
+	require.Error(t, err, "Policy with invalid time zone should be invalid")
+}
+
+func jitterTest(t *testing.T) {
+	defer func() {
+		err := k8s.Instance().DeleteSchedulePolicy("jitterpolicy")
+		require.NoError(t, err, "Error cleaning up schedule policy")
+	}()
+
+	_, err := k8s.Instance().CreateSchedulePolicy(&stork_api.SchedulePolicy{
+		ObjectMeta: meta.ObjectMeta{
+			Name: "jitterpolicy",
+		},
+		Policy: stork_api.SchedulePolicyItem{
+			Daily: &stork_api.DailyPolicy{
+				Time: "11:15pm",
+			},
+			JitterMinutes: 30,
+		},
+	})
+	require.NoError(t, err, "Error creating policy")
+
+	// The delay is fixed for a schedule and within the jitter window
+	delay, err := GetTriggerDelay("jitterpolicy", "default", "schedule1")
+	require.NoError(t, err, "Error getting trigger delay")
+	require.True(t, delay >= 0 && delay < 30*time.Minute, "Unexpected delay %v", delay)
+	sameDelay, err := GetTriggerDelay("jitterpolicy", "default", "schedule1")
+	require.NoError(t, err, "Error getting trigger delay")
+	require.Equal(t, delay, sameDelay)
+	otherDelay, err := GetTriggerDelay("jitterpolicy", "default", "schedule2")
+	require.NoError(t, err, "Error getting trigger delay")
+	require.NotEqual(t, delay, otherDelay, "Schedules should have been staggered")
+
+	lastTrigger := meta.Date(2019, time.February, 6, 23, 25, 0, 0, time.Local)
+	mockNow := time.Date(2019, time.February, 7, 23, 20, 0, 0, time.Local)
+	setMockTime(&mockNow)
+	required, err := TriggerRequired("jitterpolicy", stork_api.SchedulePolicyTypeDaily, lastTrigger, 10*time.Minute)
+	require.NoError(t, err, "Error checking if trigger required")
+	require.False(t, required, "Trigger should not have been required before the delay")
+
+	mockNow = time.Date(2019, time.February, 7, 23, 26, 0, 0, time.Local)
+	setMockTime(&mockNow)
+	required, err = TriggerRequired("jitterpolicy", stork_api.SchedulePolicyTypeDaily, lastTrigger, 10*time.Minute)
+	require.NoError(t, err, "Error checking if trigger required")
+	require.True(t, required, "Trigger should have been required after the delay")
+
+	// Triggered after the delay
+	required, err = TriggerRequired("jitterpolicy", stork_api.SchedulePolicyTypeDaily, meta.Date(2019, time.February, 7, 23, 25, 0, 0, time.Local), 10*time.Minute)
+	require.NoError(t, err, "Error checking if trigger required")
+	require.False(t, required, "Trigger should not have been required")
+
+	// The delayed trigger yesterday wasn't missed
+	missed, err := MissedTriggers("jitterpolicy", stork_api.SchedulePolicyTypeDaily, lastTrigger, 10*time.Minute)
+	require.NoError(t, err, "Error getting missed triggers")
+	require.Equal(t, 0, missed)
+
+	// Interval triggers are aligned to the interval offset by the delay,
+	// instead of the delay canceling out when counting from the last trigger
+	policy, err := k8s.Instance().GetSchedulePolicy("jitterpolicy")
+	require.NoError(t, err, "Error getting policy")
+	policy.Policy.Interval = &stork_api.IntervalPolicy{IntervalMinutes: 60}
+	_, err = k8s.Instance().UpdateSchedulePolicy(policy)
+	require.NoError(t, err, "Error updating policy")
+	lastTrigger = meta.Date(2019, time.February, 7, 10, 0, 0, 0, time.UTC)
+	for _, check := range []struct {
+		now      time.Time
+		required bool
+	}{
+		{time.Date(2019, time.February, 7, 10, 5, 0, 0, time.UTC), false},
+		{time.Date(2019, time.February, 7, 10, 11, 0, 0, time.UTC), true},
+		{time.Date(2019, time.February, 7, 10, 59, 0, 0, time.UTC), true},
+	} {
+		setMockTime(&check.now)
+		required, err = TriggerRequired("jitterpolicy", stork_api.SchedulePolicyTypeInterval, lastTrigger, 10*time.Minute)
+		require.NoError(t, err, "Error checking if trigger required")
+		require.Equal(t, check.required, required, "Unexpected trigger at %v", check.now)
+	}
+	lastTrigger = meta.Date(2019, time.February, 7, 10, 11, 0, 0, time.UTC)
+	for _, check := range []struct {
+		now      time.Time
+		required bool
+	}{
+		{time.Date(2019, time.February, 7, 11, 9, 0, 0, time.UTC), false},
+		{time.Date(2019, time.February, 7, 11, 11, 0, 0, time.UTC), true},
+	} {
+		setMockTime(&check.now)
+		required, err = TriggerRequired("jitterpolicy", stork_api.SchedulePolicyTypeInterval, lastTrigger, 10*time.Minute)
+		require.NoError(t, err, "Error checking if trigger required")
+		require.Equal(t, check.required, required, "Unexpected trigger at %v", check.now)
+	}
+	// The first trigger isn't delayed
+	required, err = TriggerRequired("jitterpolicy", stork_api.SchedulePolicyTypeInterval, meta.Time{}, 10*time.Minute)
+	require.NoError(t, err, "Error checking if trigger required")
+	require.True(t, required, "First trigger should have been required")
+
+	mockNow = time.Date(2019, time.February, 7, 13, 15, 0, 0, time.UTC)
+	setMockTime(&mockNow)
+	missed, err = MissedTriggers("jitterpolicy", stork_api.SchedulePolicyTypeInterval, lastTrigger, 10*time.Minute)
+	require.NoError(t, err, "Error getting missed triggers")
+	require.Equal(t, 2, missed)
+
+	for _, jitter := range []int{-1, 60} {
+		err = ValidateSchedulePolicy(&stork_api.SchedulePolicy{
+			Policy: stork_api.SchedulePolicyItem{
+				Daily:         &stork_api.DailyPolicy{Time: "11:15pm"},
+				JitterMinutes: jitter,
+			},
+		})
+		require.Error(t, err, "Policy with jitter %v should be invalid", jitter)
+	}
+}
+
+func maxRuntimeTest(t *testing.T) {
+	defer func() {
+		err := k8s.Instance().DeleteSchedulePolicy("maxruntimepolicy")
+		require.NoError(t, err, "Error cleaning up schedule policy")
+	}()
+
+	policy := &stork_api.SchedulePolicy{
+		ObjectMeta: meta.ObjectMeta{
+			Name: "maxruntimepolicy",
+		},
+		Policy: stork_api.SchedulePolicyItem{
+			Interval: &stork_api.IntervalPolicy{
+				IntervalMinutes: 60,
+			},
+		},
+	}
+	_, err := k8s.Instance().CreateSchedulePolicy(policy)
+	require.NoError(t, err, "Error creating policy")
+
+	maxRuntime, action, err := GetMaxRuntime("maxruntimepolicy")
+	require.NoError(t, err, "Error getting max runtime")
+	require.Equal(t, time.Duration(0), maxRuntime)
+	require.Equal(t, stork_api.MaxRuntimeActionSkip, action)
+
+	policy.Policy.MaxRuntimeMinutes = 45
+	policy.Policy.MaxRuntimeAction = stork_api.MaxRuntimeActionCancel
+	_, err = k8s.Instance().UpdateSchedulePolicy(policy)
+	require.NoError(t, err, "Error updating policy")
+	maxRuntime, action, err = GetMaxRuntime("maxruntimepolicy")
+	require.NoError(t, err, "Error getting max runtime")
+	require.Equal(t, 45*time.Minute, maxRuntime)
+	require.Equal(t, stork_api.MaxRuntimeActionCancel, action)
+
+	policy.Policy.MaxRuntimeAction = "Wait"
+	require.Error(t, ValidateSchedulePolicy(policy), "Policy with invalid max runtime action should be invalid")
+	policy.Policy.MaxRuntimeAction = ""
+	policy.Policy.MaxRuntimeMinutes = -1
+	require.Error(t, ValidateSchedulePolicy(policy), "Policy with invalid max runtime should be invalid")
+}
+
+func excludedDatesTest(t *testing.T) {
+	defer func() {
+		err := k8s.Instance().DeleteSchedulePolicy("excludedpolicy")
+		require.NoError(t, err, "Error cleaning up schedule policy")
+	}()
+
+	_, err := k8s.Instance().CreateSchedulePolicy(&stork_api.SchedulePolicy{
+		ObjectMeta: meta.ObjectMeta{
+			Name: "excludedpolicy",
+		},
+		Policy: stork_api.SchedulePolicyItem{
+			Interval: &stork_api.IntervalPolicy{
+				IntervalMinutes: 60,
+			},
+			BlackoutWindows: []*stork_api.BlackoutWindow{
+				{Start: "01:00AM", End: "03:00AM"},
+			},
+			ExcludedDates: []*stork_api.ExcludedDates{
+				{Start: "2019-03-25", End: "2019-04-05", Name: "Quarterly freeze"},
+				{Start: "12-24", End: "01-01"},
+				{Start: "07-04"},
+			},
+		},
+	})
+	require.NoError(t, err, "Error creating policy")
+
+	for _, test := range []struct {
+		now    time.Time
+		reason string
+	}{
+		{time.Date(2019, time.March, 24, 12, 0, 0, 0, time.Local), ""},
+		{time.Date(2019, time.March, 25, 0, 0, 0, 0, time.Local), "Trigger skipped on excluded dates Quarterly freeze"},
+		{time.Date(2019, time.April, 5, 23, 59, 0, 0, time.Local), "Trigger skipped on excluded dates Quarterly freeze"},
+		{time.Date(2020, time.March, 30, 12, 0, 0, 0, time.Local), ""},
+		{time.Date(2019, time.December, 31, 12, 0, 0, 0, time.Local), "Trigger skipped on excluded dates 12-24 to 01-01"},
+		{time.Date(2020, time.January, 1, 12, 0, 0, 0, time.Local), "Trigger skipped on excluded dates 12-24 to 01-01"},
+		{time.Date(2020, time.January, 2, 12, 0, 0, 0, time.Local), ""},
+		{time.Date(2021, time.July, 4, 12, 0, 0, 0, time.Local), "Trigger skipped on excluded dates 07-04"},
+		{time.Date(2019, time.March, 26, 2, 0, 0, 0, time.Local), "Trigger skipped during blackout window 01:00AM-03:00AM"},
+	} {
+		mockNow := test.now
+		setMockTime(&mockNow)
+		reason, err := GetSkipReason("excludedpolicy")
+		require.NoError(t, err, "Error getting skip reason")
+		require.Equal(t, test.reason, reason, "Wrong skip reason at %v", test.now)
+	}
+
+	for _, excluded := range []*stork_api.ExcludedDates{
+		{Start: "2019-13-01"},
+		{Start: "12/25"},
+		{Start: "2019-04-05", End: "2019-03-25"},
+		{Start: "12-24", End: "2020-01-01"},
+	} {
+		err := ValidateSchedulePolicy(&stork_api.SchedulePolicy{
+			Policy: stork_api.SchedulePolicyItem{
+				Interval:      &stork_api.IntervalPolicy{IntervalMinutes: 60},
+				ExcludedDates: []*stork_api.ExcludedDates{excluded},
+			},
+		})
+		require.Error(t, err, "Excluded dates %v should be invalid", excluded)
+	}
+}
+
+func dependencyTest(t *testing.T) {
+	defer func() {
+		err := k8s.Instance().DeleteSnapshotSchedule("snapshotschedule", "default")
+		require.NoError(t, err, "Error cleaning up snapshot schedule")
+	}()
+
+	snapshotSchedule, err := k8s.Instance().CreateSnapshotSchedule(&stork_api.VolumeSnapshotSchedule{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      "snapshotschedule",
+			Namespace: "default",
+		},
+	})
+	require.NoError(t, err, "Error creating snapshot schedule")
+	dependency := &stork_api.ScheduleDependency{Kind: "VolumeSnapshotSchedule", Name: "snapshotschedule"}
+	lastTrigger := meta.Date(2019, time.February, 7, 23, 0, 0, 0, time.Local)
+
+	reason, err := GetDependencySkipReason("default", nil, lastTrigger)
+	require.NoError(t, err, "Error checking dependency")
+	require.Empty(t, reason)
+
+	// Dependency hasn't run yet
+	reason, err = GetDependencySkipReason("default", dependency, lastTrigger)
+	require.NoError(t, err, "Error checking dependency")
+	require.Equal(t, "Trigger skipped since VolumeSnapshotSchedule snapshotschedule hasn't run successfully since the last trigger", reason)
+
+	// Latest run is still in progress
+	snapshotSchedule.Status.Items = map[stork_api.SchedulePolicyType][]*stork_api.ScheduledVolumeSnapshotStatus{
+		stork_api.SchedulePolicyTypeDaily: {
+			{
+				Name:              "snapshot",
+				CreationTimestamp: meta.Date(2019, time.February, 7, 23, 15, 0, 0, time.Local),
+				Status:            snapv1.VolumeSnapshotConditionPending,
+			},
+		},
+	}
+	snapshotSchedule, err = k8s.Instance().UpdateSnapshotSchedule(snapshotSchedule)
+	require.NoError(t, err, "Error updating snapshot schedule")
+	reason, err = GetDependencySkipReason("default", dependency, lastTrigger)
+	require.NoError(t, err, "Error checking dependency")
+	require.NotEmpty(t, reason, "Trigger should have been skipped while the dependency is running")
+
+	// Latest run succeeded after the last trigger
+	snapshot := snapshotSchedule.Status.Items[stork_api.SchedulePolicyTypeDaily][0]
+	snapshot.Status = snapv1.VolumeSnapshotConditionReady
+	snapshot.FinishTimestamp = meta.Date(2019, time.February, 7, 23, 20, 0, 0, time.Local)
+	_, err = k8s.Instance().UpdateSnapshotSchedule(snapshotSchedule)
+	require.NoError(t, err, "Error updating snapshot schedule")
+	reason, err = GetDependencySkipReason("default", dependency, lastTrigger)
+	require.NoError(t, err, "Error checking dependency")
+	require.Empty(t, reason)
+
+	// Not triggered again until the dependency runs again
+	reason, err = GetDependencySkipReason("default", dependency, meta.Date(2019, time.February, 7, 23, 25, 0, 0, time.Local))
+	require.NoError(t, err, "Error checking dependency")
+	require.NotEmpty(t, reason, "Trigger should have been skipped")
+
+	_, err = GetDependencySkipReason("default", &stork_api.ScheduleDependency{Kind: "Job", Name: "job"}, lastTrigger)
+	require.Error(t, err, "Invalid kind should return error")
+	_, err = GetDependencySkipReason("default", &stork_api.ScheduleDependency{Kind: "MigrationSchedule", Name: "missing"}, lastTrigger)
+	require.Error(t, err, "Missing dependency should return error")
+}
+
+func clusterDomainSuspensionTest(t *testing.T) {
+	mockNow := time.Date(2019, time.February, 7, 1, 30, 0, 0, time.Local)
+	setMockTime(&mockNow)
+	defer setMockTime(nil)
+
+	// Schedules aren't suspended without a status for the cluster domains
+	inactive, err := GetInactiveClusterDomains([]string{"east"})
+	require.NoError(t, err, "Error getting inactive cluster domains")
+	require.Empty(t, inactive)
+
+	_, err = fakeStorkClient.StorkV1alpha1().ClusterDomainsStatuses().Create(&stork_api.ClusterDomainsStatus{
+		ObjectMeta: meta.ObjectMeta{Name: "cluster"},
+		Status: stork_api.ClusterDomains{
+			Active:   []string{"west"},
+			Inactive: []string{"east", "north"},
+		},
+	})
+	require.NoError(t, err, "Error creating cluster domains status")
+	inactive, err = GetInactiveClusterDomains([]string{"east", "west", "south"})
+	require.NoError(t, err, "Error getting inactive cluster domains")
+	require.Equal(t, []string{"east"}, inactive)
+	inactive, err = GetInactiveClusterDomains(nil)
+	require.NoError(t, err, "Error getting inactive cluster domains")
+	require.Empty(t, inactive)
+
+	suspendedFor, suspensions, updated := RecordClusterDomainSuspension(nil, nil, []string{})
+	require.False(t, updated, "Schedule shouldn't have been suspended")
+	require.Empty(t, suspendedFor)
+	require.Empty(t, suspensions)
+
+	suspendedFor, suspensions, updated = RecordClusterDomainSuspension(nil, nil, []string{"east"})
+	require.True(t, updated, "Schedule should have been suspended")
+	require.Equal(t, []string{"east"}, suspendedFor)
+	require.Len(t, suspensions, 1)
+	require.True(t, suspensions[0].Suspended)
+	require.Equal(t, []string{"east"}, suspensions[0].InactiveDomains)
+	require.Equal(t, meta.NewTime(mockNow), suspensions[0].Timestamp)
+
+	// Nothing changes while the same domains are inactive
+	_, _, updated = RecordClusterDomainSuspension(suspendedFor, suspensions, []string{"east"})
+	require.False(t, updated, "Suspension shouldn't have been recorded again")
+
+	suspendedFor, suspensions, updated = RecordClusterDomainSuspension(suspendedFor, suspensions, []string{})
+	require.True(t, updated, "Schedule should have been resumed")
+	require.Empty(t, suspendedFor)
+	require.Len(t, suspensions, 2)
+	require.False(t, suspensions[1].Suspended)
+
+	// Only the latest suspensions should be retained
+	for i := 0; i < maxClusterDomainSuspensions; i++ {
+		suspendedFor, suspensions, _ = RecordClusterDomainSuspension(suspendedFor, suspensions, []string{"east"})
+		suspendedFor, suspensions, _ = RecordClusterDomainSuspension(suspendedFor, suspensions, nil)
+	}
+	require.Len(t, suspensions, maxClusterDomainSuspensions)
+}
@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	snapv1 "github.com/kubernetes-incubator/external-storage/snapshot/pkg/apis/crd/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	csiSnapshotGroup   = "snapshot.storage.k8s.io"
+	csiSnapshotVersion = "v1"
+	csiSnapshotKind    = "VolumeSnapshot"
+	csiSnapshotPlural  = "volumesnapshots"
+)
+
+var csiSnapshotResource = schema.GroupVersionResource{
+	Group:    csiSnapshotGroup,
+	Version:  csiSnapshotVersion,
+	Resource: csiSnapshotPlural,
+}
+
+// csiSnapshotter is used to manage snapshot.storage.k8s.io/v1 VolumeSnapshots.
+// The typed client for these isn't available so a dynamic client is used
+// instead.
+type csiSnapshotter struct {
+	client dynamic.Interface
+}
+
+func newCSISnapshotter() (*csiSnapshotter, error) {
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, err
+	}
+	client, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return &csiSnapshotter{
+		client: client,
+	}, nil
+}
+
+func (c *csiSnapshotter) createSnapshot(
+	objectMeta meta.ObjectMeta,
+	pvcName string,
+	snapshotClassName string,
+) error {
+	spec := map[string]interface{}{
+		"source": map[string]interface{}{
+			"persistentVolumeClaimName": pvcName,
+		},
+	}
+	if snapshotClassName != "" {
+		spec["volumeSnapshotClassName"] = snapshotClassName
+	}
+	snapshot := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"spec": spec,
+		},
+	}
+	snapshot.SetAPIVersion(csiSnapshotGroup + "/" + csiSnapshotVersion)
+	snapshot.SetKind(csiSnapshotKind)
+	snapshot.SetName(objectMeta.Name)
+	snapshot.SetNamespace(objectMeta.Namespace)
+	snapshot.SetLabels(objectMeta.Labels)
+	snapshot.SetAnnotations(objectMeta.Annotations)
+	snapshot.SetOwnerReferences(objectMeta.OwnerReferences)
+
+	_, err := c.client.Resource(csiSnapshotResource).Namespace(objectMeta.Namespace).Create(snapshot)
+	return err
+}
+
+// getSnapshotStatus maps the status of a CSI snapshot to the conditions used
+// for stork snapshots
+func (c *csiSnapshotter) getSnapshotStatus(name string, namespace string) (snapv1.VolumeSnapshotConditionType, error) {
+	snapshot, err := c.client.Resource(csiSnapshotResource).Namespace(namespace).Get(name, meta.GetOptions{})
+	if err != nil {
+		return snapv1.VolumeSnapshotConditionError, err
+	}
+	if _, found, err := unstructured.NestedMap(snapshot.Object, "status", "error"); err == nil && found {
+		return snapv1.VolumeSnapshotConditionError, nil
+	}
+	ready, found, err := unstructured.NestedBool(snapshot.Object, "status", "readyToUse")
+	if err != nil {
+		return snapv1.VolumeSnapshotConditionError, err
+	}
+	if found && ready {
+		return snapv1.VolumeSnapshotConditionReady, nil
+	}
+	return snapv1.VolumeSnapshotConditionPending, nil
+}
+
+func (c *csiSnapshotter) deleteSnapshot(name string, namespace string) error {
+	return c.client.Resource(csiSnapshotResource).Namespace(namespace).Delete(name, &meta.DeleteOptions{})
+}
+
+// listSnapshots returns the CSI snapshots in all namespaces that have the label
+func (c *csiSnapshotter) listSnapshots(label string) (*unstructured.UnstructuredList, error) {
+	return c.client.Resource(csiSnapshotResource).List(meta.ListOptions{
+		LabelSelector: label,
+	})
+}
+
+func (c *csiSnapshotter) updateSnapshot(snapshot *unstructured.Unstructured) error {
+	_, err := c.client.Resource(csiSnapshotResource).Namespace(snapshot.GetNamespace()).Update(snapshot)
+	return err
+}
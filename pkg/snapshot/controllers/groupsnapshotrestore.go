@@ -0,0 +1,496 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	snapv1 "github.com/kubernetes-incubator/external-storage/snapshot/pkg/apis/crd/v1"
+	crdclient "github.com/kubernetes-incubator/external-storage/snapshot/pkg/client"
+	"github.com/libopenstorage/stork/pkg/apis/stork"
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/controller"
+	"github.com/libopenstorage/stork/pkg/log"
+	"github.com/operator-framework/operator-sdk/pkg/sdk"
+	"github.com/portworx/sched-ops/k8s"
+	"k8s.io/api/core/v1"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
+)
+
+const (
+	defaultSnapshotStorageClass = "stork-snapshot-sc"
+	// GroupSnapshotRestoreNameLabel Label used to specify the name of the
+	// group snapshot restore that created a PVC
+	GroupSnapshotRestoreNameLabel = "stork.libopenstorage.org/groupSnapshotRestoreName"
+	// GroupSnapshotRestoreUIDLabel Label used to specify the UID of the group
+	// snapshot restore that created a PVC. Only the PVCs with the label are
+	// deleted if the restore fails.
+	GroupSnapshotRestoreUIDLabel = "stork.libopenstorage.org/groupSnapshotRestoreUID"
+	// SnapshotPVCNamespaceAnnotation Annotation set on the snapshots of a
+	// group snapshot for PVCs from other namespaces, with the namespace of the
+	// PVC
+	SnapshotPVCNamespaceAnnotation = "stork.libopenstorage.org/snapshot-pvc-namespace"
+	// GroupSnapshotNamespacesAnnotation Annotation set on a namespace with
+	// the comma separated list of namespaces whose group snapshots can select
+	// PVCs from it and restore them. Regexes are supported.
+	GroupSnapshotNamespacesAnnotation = "stork.libopenstorage.org/group-snapshot-namespaces"
+)
+
+// GroupSnapshotRestoreController reconciles GroupVolumeSnapshotRestore objects
+type GroupSnapshotRestoreController struct {
+	Recorder record.EventRecorder
+}
+
+// Init Initialize the group snapshot restore controller
+func (c *GroupSnapshotRestoreController) Init() error {
+	err := c.createCRD()
+	if err != nil {
+		return err
+	}
+	return controller.Register(
+		&schema.GroupVersionKind{
+			Group:   stork.GroupName,
+			Version: stork_api.SchemeGroupVersion.Version,
+			Kind:    reflect.TypeOf(stork_api.GroupVolumeSnapshotRestore{}).Name(),
+		},
+		"",
+		restoreResyncPeriod,
+		c)
+}
+
+// Handle updates for GroupVolumeSnapshotRestore objects
+func (c *GroupSnapshotRestoreController) Handle(ctx context.Context, event sdk.Event) error {
+	switch o := event.Object.(type) {
+	case *stork_api.GroupVolumeSnapshotRestore:
+		groupRestore := o
+		// Nothing to do for delete
+		if event.Deleted {
+			return nil
+		}
+
+		if groupRestore.Spec.Type == "" {
+			groupRestore.Spec.Type = stork_api.GroupVolumeSnapshotRestoreTypeNewPVC
+		}
+
+		var err error
+		switch groupRestore.Status.Status {
+		case stork_api.VolumeSnapshotRestoreStatusInitial:
+			err = c.handleInitial(groupRestore)
+		case stork_api.VolumeSnapshotRestoreStatusInProgress:
+			if groupRestore.Spec.Type == stork_api.GroupVolumeSnapshotRestoreTypeInPlace {
+				err = c.handleInPlaceRestore(groupRestore)
+			} else {
+				err = c.handleNewPVCRestore(groupRestore)
+			}
+		default:
+			// Nothing to do once the restore is complete
+			return nil
+		}
+		if err != nil {
+			message := fmt.Sprintf("Error restoring group snapshot: %v", err)
+			log.GroupVolumeSnapshotRestoreLog(groupRestore).Error(message)
+			c.Recorder.Event(groupRestore,
+				v1.EventTypeWarning,
+				string(stork_api.VolumeSnapshotRestoreStatusFailed),
+				message)
+		}
+	}
+	return nil
+}
+
+func (c *GroupSnapshotRestoreController) finishRestore(
+	groupRestore *stork_api.GroupVolumeSnapshotRestore,
+	status stork_api.VolumeSnapshotRestoreStatusType,
+	reason string,
+) error {
+	groupRestore.Status.Status = status
+	groupRestore.Status.Reason = reason
+	groupRestore.Status.FinishTimestamp = meta.Now()
+	eventType := v1.EventTypeNormal
+	if status == stork_api.VolumeSnapshotRestoreStatusFailed {
+		eventType = v1.EventTypeWarning
+		log.GroupVolumeSnapshotRestoreLog(groupRestore).Error(reason)
+	}
+	c.Recorder.Event(groupRestore,
+		eventType,
+		string(status),
+		reason)
+	return sdk.Update(groupRestore)
+}
+
+func (c *GroupSnapshotRestoreController) handleInitial(groupRestore *stork_api.GroupVolumeSnapshotRestore) error {
+	if groupRestore.Spec.SourceName == "" {
+		return c.finishRestore(groupRestore, stork_api.VolumeSnapshotRestoreStatusFailed,
+			"sourceName for restore cannot be empty")
+	}
+	if groupRestore.Spec.Type != stork_api.GroupVolumeSnapshotRestoreTypeNewPVC &&
+		groupRestore.Spec.Type != stork_api.GroupVolumeSnapshotRestoreTypeInPlace {
+		return c.finishRestore(groupRestore, stork_api.VolumeSnapshotRestoreStatusFailed,
+			fmt.Sprintf("Invalid type for restore: %v", groupRestore.Spec.Type))
+	}
+
+	if groupRestore.Spec.Type == stork_api.GroupVolumeSnapshotRestoreTypeInPlace {
+		// The in-place restore validates all the volumes before restoring
+		// any of them
+		snapRestore := &stork_api.VolumeSnapshotRestore{
+			TypeMeta: meta.TypeMeta{
+				Kind:       reflect.TypeOf(stork_api.VolumeSnapshotRestore{}).Name(),
+				APIVersion: stork_api.SchemeGroupVersion.String(),
+			},
+			ObjectMeta: meta.ObjectMeta{
+				Name:      groupRestore.Name,
+				Namespace: groupRestore.Namespace,
+				OwnerReferences: []meta.OwnerReference{
+					{
+						Name:       groupRestore.Name,
+						UID:        groupRestore.UID,
+						Kind:       groupRestore.GetObjectKind().GroupVersionKind().Kind,
+						APIVersion: groupRestore.GetObjectKind().GroupVersionKind().GroupVersion().String(),
+					},
+				},
+			},
+			Spec: stork_api.VolumeSnapshotRestoreSpec{
+				SourceName:            groupRestore.Spec.SourceName,
+				GroupSnapshot:         true,
+				ScaleDownApplications: groupRestore.Spec.ScaleDownApplications,
+			},
+		}
+		if err := sdk.Create(snapRestore); err != nil {
+			if !errors.IsAlreadyExists(err) {
+				return c.finishRestore(groupRestore, stork_api.VolumeSnapshotRestoreStatusFailed,
+					fmt.Sprintf("Error starting in-place restore: %v", err))
+			}
+			// The restore could have been created before the controller
+			// restarted, anything else is a name collision
+			if err := sdk.Get(snapRestore); err != nil {
+				return err
+			}
+			if err := checkInPlaceRestoreOwner(snapRestore, groupRestore); err != nil {
+				return c.finishRestore(groupRestore, stork_api.VolumeSnapshotRestoreStatusFailed, err.Error())
+			}
+		}
+		groupRestore.Status.Status = stork_api.VolumeSnapshotRestoreStatusInProgress
+		return sdk.Update(groupRestore)
+	}
+
+	groupSnap, err := k8s.Instance().GetGroupSnapshot(groupRestore.Spec.SourceName, groupRestore.Namespace)
+	if err != nil {
+		return c.finishRestore(groupRestore, stork_api.VolumeSnapshotRestoreStatusFailed,
+			fmt.Sprintf("Error getting group snapshot %v: %v", groupRestore.Spec.SourceName, err))
+	}
+	if groupSnap.Status.Status != stork_api.GroupSnapshotSuccessful {
+		return c.finishRestore(groupRestore, stork_api.VolumeSnapshotRestoreStatusFailed,
+			fmt.Sprintf("Group snapshot %v is not successful, current status: %v", groupSnap.Name, groupSnap.Status.Status))
+	}
+
+	// Validate all the snapshots and source PVCs before creating any of the
+	// new PVCs
+	pvcs, volumes, err := getNewPVCs(groupRestore, groupSnap)
+	if err != nil {
+		return c.finishRestore(groupRestore, stork_api.VolumeSnapshotRestoreStatusFailed, err.Error())
+	}
+	groupRestore.Status.Volumes = volumes
+	if err := c.createNewPVCs(groupRestore, pvcs); err != nil {
+		return c.finishRestore(groupRestore, stork_api.VolumeSnapshotRestoreStatusFailed, err.Error())
+	}
+	groupRestore.Status.Status = stork_api.VolumeSnapshotRestoreStatusInProgress
+	return sdk.Update(groupRestore)
+}
+
+// getSnapshotPVCNamespace returns the namespace of the PVC from which the
+// snapshot was taken. Snapshots of group snapshots that select PVCs from
+// other namespaces are created in the namespace of the group snapshot.
+func getSnapshotPVCNamespace(snapshot *snapv1.VolumeSnapshot) string {
+	if namespace := snapshot.Metadata.Annotations[SnapshotPVCNamespaceAnnotation]; namespace != "" {
+		return namespace
+	}
+	return snapshot.Metadata.Namespace
+}
+
+// IsGroupSnapshotNamespaceAllowed checks if group snapshots in
+// groupSnapNamespace can use the PVCs in namespace. Only the namespace itself
+// can allow this, with GroupSnapshotNamespacesAnnotation, so that it doesn't
+// depend on the admission webhook having checked the user.
+func IsGroupSnapshotNamespaceAllowed(namespace string, groupSnapNamespace string) (bool, error) {
+	if namespace == groupSnapNamespace {
+		return true, nil
+	}
+	ns, err := k8s.Instance().GetNamespace(namespace)
+	if err != nil {
+		return false, err
+	}
+	allowedNamespaces, ok := ns.Annotations[GroupSnapshotNamespacesAnnotation]
+	if !ok {
+		return false, nil
+	}
+	return isNamespaceAllowed(allowedNamespaces, groupSnapNamespace), nil
+}
+
+// checkSnapshotPVCNamespace returns an error if the source PVC of a snapshot
+// from the group snapshot is in a namespace that the group snapshot wasn't
+// allowed to select PVCs from. The annotation with the namespace of the PVC
+// can be edited by users, so it is only trusted for the namespaces selected by
+// the group snapshot that allow group snapshots from its namespace.
+func checkSnapshotPVCNamespace(pvcNamespace string, groupSnap *stork_api.GroupVolumeSnapshot) error {
+	if pvcNamespace == groupSnap.Namespace {
+		return nil
+	}
+	selected := false
+	for _, ns := range groupSnap.Spec.Namespaces {
+		if ns == pvcNamespace {
+			selected = true
+			break
+		}
+	}
+	if !selected {
+		return fmt.Errorf("namespace %v isn't selected by group snapshot %v", pvcNamespace, groupSnap.Name)
+	}
+	allowed, err := IsGroupSnapshotNamespaceAllowed(pvcNamespace, groupSnap.Namespace)
+	if err != nil {
+		return fmt.Errorf("error checking if namespace %v allows group snapshots: %v", pvcNamespace, err)
+	}
+	if !allowed {
+		return fmt.Errorf("namespace %v doesn't allow group snapshots from namespace %v", pvcNamespace, groupSnap.Namespace)
+	}
+	return nil
+}
+
+// isCreatedByRestore returns true if the PVC was created by the group restore
+func isCreatedByRestore(pvc *v1.PersistentVolumeClaim, groupRestore *stork_api.GroupVolumeSnapshotRestore) bool {
+	return pvc.Labels[GroupSnapshotRestoreUIDLabel] == string(groupRestore.UID)
+}
+
+// checkInPlaceRestoreOwner returns an error if the in-place restore wasn't
+// created for the group restore
+func checkInPlaceRestoreOwner(
+	snapRestore *stork_api.VolumeSnapshotRestore,
+	groupRestore *stork_api.GroupVolumeSnapshotRestore,
+) error {
+	for _, owner := range snapRestore.OwnerReferences {
+		if owner.UID == groupRestore.UID {
+			return nil
+		}
+	}
+	return fmt.Errorf("in-place restore %v already exists and wasn't created for this group restore", snapRestore.Name)
+}
+
+// getNewPVCs returns the PVCs to be created for the snapshots in the group
+// snapshot. The PVCs are created in the namespaces of the source PVCs, which
+// need to still allow group snapshots from the namespace of the restore. An
+// error is returned if a snapshot can't be restored or a PVC with the same
+// name already exists and wasn't created for the restore.
+func getNewPVCs(
+	groupRestore *stork_api.GroupVolumeSnapshotRestore,
+	groupSnap *stork_api.GroupVolumeSnapshot,
+) ([]*v1.PersistentVolumeClaim, []*stork_api.RestoreVolumeInfo, error) {
+	storageClassName := groupRestore.Spec.StorageClassName
+	if storageClassName == "" {
+		storageClassName = defaultSnapshotStorageClass
+	}
+	pvcs := make([]*v1.PersistentVolumeClaim, 0)
+	volumes := make([]*stork_api.RestoreVolumeInfo, 0)
+	for _, volumeSnapshot := range groupSnap.Status.VolumeSnapshots {
+		snapshot, err := k8s.Instance().GetSnapshot(volumeSnapshot.VolumeSnapshotName, groupRestore.Namespace)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error getting snapshot %v: %v", volumeSnapshot.VolumeSnapshotName, err)
+		}
+		if !isSnapshotReady(snapshot) {
+			return nil, nil, fmt.Errorf("snapshot %v is not ready", snapshot.Metadata.Name)
+		}
+		pvcNamespace := getSnapshotPVCNamespace(snapshot)
+		if err := checkSnapshotPVCNamespace(pvcNamespace, groupSnap); err != nil {
+			return nil, nil, fmt.Errorf("can't restore snapshot %v: %v", snapshot.Metadata.Name, err)
+		}
+		sourcePVC, err := k8s.Instance().GetPersistentVolumeClaim(snapshot.Spec.PersistentVolumeClaimName, pvcNamespace)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error getting PVC for snapshot %v: %v", snapshot.Metadata.Name, err)
+		}
+		pvcName := sourcePVC.Name + "-" + groupRestore.Name
+		existing, err := k8s.Instance().GetPersistentVolumeClaim(pvcName, pvcNamespace)
+		if err == nil && !isCreatedByRestore(existing, groupRestore) {
+			return nil, nil, fmt.Errorf("PVC %v/%v already exists", pvcNamespace, pvcName)
+		} else if err != nil && !errors.IsNotFound(err) {
+			return nil, nil, fmt.Errorf("error checking if PVC %v/%v exists: %v", pvcNamespace, pvcName, err)
+		}
+		annotations := map[string]string{
+			crdclient.SnapshotPVCAnnotation: snapshot.Metadata.Name,
+		}
+		if pvcNamespace != groupRestore.Namespace {
+			annotations[StorkSnapshotSourceNamespaceAnnotation] = groupRestore.Namespace
+		}
+		pvcs = append(pvcs, &v1.PersistentVolumeClaim{
+			ObjectMeta: meta.ObjectMeta{
+				Name:        pvcName,
+				Namespace:   pvcNamespace,
+				Annotations: annotations,
+				Labels: map[string]string{
+					GroupSnapshotRestoreNameLabel: groupRestore.Name,
+					GroupSnapshotRestoreUIDLabel:  string(groupRestore.UID),
+				},
+			},
+			Spec: v1.PersistentVolumeClaimSpec{
+				AccessModes:      sourcePVC.Spec.AccessModes,
+				Resources:        sourcePVC.Spec.Resources,
+				StorageClassName: &storageClassName,
+			},
+		})
+		volumes = append(volumes, &stork_api.RestoreVolumeInfo{
+			PersistentVolumeClaim: pvcName,
+			Namespace:             pvcNamespace,
+			Snapshot:              snapshot.Metadata.Name,
+			SnapshotData:          snapshot.Spec.SnapshotDataName,
+			Status:                stork_api.VolumeSnapshotRestoreStatusPending,
+		})
+	}
+	return pvcs, volumes, nil
+}
+
+// createNewPVCs creates the PVCs for the restore. If any of them can't be
+// created the ones that were created are deleted.
+func (c *GroupSnapshotRestoreController) createNewPVCs(
+	groupRestore *stork_api.GroupVolumeSnapshotRestore,
+	pvcs []*v1.PersistentVolumeClaim,
+) error {
+	for _, pvc := range pvcs {
+		_, err := k8s.Instance().CreatePersistentVolumeClaim(pvc)
+		if errors.IsAlreadyExists(err) {
+			// The PVC could have been created before the controller
+			// restarted, anything else is a name collision
+			existing, getErr := k8s.Instance().GetPersistentVolumeClaim(pvc.Name, pvc.Namespace)
+			if getErr != nil {
+				err = getErr
+			} else if isCreatedByRestore(existing, groupRestore) {
+				err = nil
+			}
+		}
+		if err != nil {
+			c.rollbackNewPVCs(groupRestore)
+			return fmt.Errorf("error creating PVC %v/%v: %v", pvc.Namespace, pvc.Name, err)
+		}
+	}
+	return nil
+}
+
+func (c *GroupSnapshotRestoreController) handleInPlaceRestore(groupRestore *stork_api.GroupVolumeSnapshotRestore) error {
+	snapRestore := &stork_api.VolumeSnapshotRestore{
+		TypeMeta: meta.TypeMeta{
+			Kind:       reflect.TypeOf(stork_api.VolumeSnapshotRestore{}).Name(),
+			APIVersion: stork_api.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: meta.ObjectMeta{
+			Name:      groupRestore.Name,
+			Namespace: groupRestore.Namespace,
+		},
+	}
+	if err := sdk.Get(snapRestore); err != nil {
+		if errors.IsNotFound(err) {
+			return c.finishRestore(groupRestore, stork_api.VolumeSnapshotRestoreStatusFailed,
+				"In-place restore was deleted before it completed")
+		}
+		return err
+	}
+	if err := checkInPlaceRestoreOwner(snapRestore, groupRestore); err != nil {
+		return c.finishRestore(groupRestore, stork_api.VolumeSnapshotRestoreStatusFailed, err.Error())
+	}
+
+	groupRestore.Status.Volumes = snapRestore.Status.Volumes
+	if !isRestoreComplete(snapRestore.Status.Status) {
+		return sdk.Update(groupRestore)
+	}
+	return c.finishRestore(groupRestore, snapRestore.Status.Status, snapRestore.Status.Reason)
+}
+
+func (c *GroupSnapshotRestoreController) handleNewPVCRestore(groupRestore *stork_api.GroupVolumeSnapshotRestore) error {
+	failedVolume := updateNewPVCStatus(groupRestore)
+
+	// If any of the volumes failed to restore, delete all the PVCs that were
+	// created so that the group is restored either completely or not at all
+	if failedVolume != nil {
+		c.rollbackNewPVCs(groupRestore)
+		return c.finishRestore(groupRestore, stork_api.VolumeSnapshotRestoreStatusFailed,
+			fmt.Sprintf("Restore of PVC %v failed: %v", failedVolume.PersistentVolumeClaim, failedVolume.Reason))
+	}
+
+	for _, volumeInfo := range groupRestore.Status.Volumes {
+		if !isRestoreComplete(volumeInfo.Status) {
+			return sdk.Update(groupRestore)
+		}
+	}
+	return c.finishRestore(groupRestore, stork_api.VolumeSnapshotRestoreStatusSuccessful,
+		"Restore completed successfully")
+}
+
+// updateNewPVCStatus updates the status of the volumes from the PVCs created
+// for the restore and returns the first volume that failed, if any
+func updateNewPVCStatus(groupRestore *stork_api.GroupVolumeSnapshotRestore) *stork_api.RestoreVolumeInfo {
+	var failedVolume *stork_api.RestoreVolumeInfo
+	for _, volumeInfo := range groupRestore.Status.Volumes {
+		if isRestoreComplete(volumeInfo.Status) {
+			continue
+		}
+		pvc, err := k8s.Instance().GetPersistentVolumeClaim(volumeInfo.PersistentVolumeClaim, volumeInfo.Namespace)
+		if err != nil {
+			volumeInfo.Status = stork_api.VolumeSnapshotRestoreStatusFailed
+			volumeInfo.Reason = fmt.Sprintf("Error getting PVC: %v", err)
+		} else if restoreErr, ok := pvc.Annotations[StorkSnapshotRestoreErrorAnnotation]; ok {
+			volumeInfo.Status = stork_api.VolumeSnapshotRestoreStatusFailed
+			volumeInfo.Reason = restoreErr
+		} else if pvc.Status.Phase == v1.ClaimLost {
+			volumeInfo.Status = stork_api.VolumeSnapshotRestoreStatusFailed
+			volumeInfo.Reason = "PVC lost its volume"
+		} else if pvc.Status.Phase == v1.ClaimBound {
+			volumeInfo.Volume = pvc.Spec.VolumeName
+			volumeInfo.Status = stork_api.VolumeSnapshotRestoreStatusSuccessful
+			volumeInfo.Reason = "Volume restored successfully"
+		} else {
+			volumeInfo.Status = stork_api.VolumeSnapshotRestoreStatusInProgress
+		}
+		if volumeInfo.Status == stork_api.VolumeSnapshotRestoreStatusFailed && failedVolume == nil {
+			failedVolume = volumeInfo
+		}
+	}
+	return failedVolume
+}
+
+// rollbackNewPVCs deletes the PVCs that were created for the restore. PVCs
+// with the same name that weren't created by the restore are left alone.
+func (c *GroupSnapshotRestoreController) rollbackNewPVCs(groupRestore *stork_api.GroupVolumeSnapshotRestore) {
+	for _, volumeInfo := range groupRestore.Status.Volumes {
+		pvc, err := k8s.Instance().GetPersistentVolumeClaim(volumeInfo.PersistentVolumeClaim, volumeInfo.Namespace)
+		if err == nil {
+			if !isCreatedByRestore(pvc, groupRestore) {
+				continue
+			}
+			err = k8s.Instance().DeletePersistentVolumeClaim(volumeInfo.PersistentVolumeClaim, volumeInfo.Namespace)
+		}
+		if err != nil && !errors.IsNotFound(err) {
+			log.GroupVolumeSnapshotRestoreLog(groupRestore).Warnf("Error deleting PVC %v: %v", volumeInfo.PersistentVolumeClaim, err)
+			continue
+		}
+		if volumeInfo.Status != stork_api.VolumeSnapshotRestoreStatusFailed {
+			volumeInfo.Status = stork_api.VolumeSnapshotRestoreStatusFailed
+			volumeInfo.Reason = "PVC deleted since the restore of the group failed"
+		}
+	}
+}
+
+func (c *GroupSnapshotRestoreController) createCRD() error {
+	resource := k8s.CustomResource{
+		Name:    stork_api.GroupVolumeSnapshotRestoreResourceName,
+		Plural:  stork_api.GroupVolumeSnapshotRestoreResourcePlural,
+		Group:   stork.GroupName,
+		Version: stork_api.SchemeGroupVersion.Version,
+		Scope:   apiextensionsv1beta1.NamespaceScoped,
+		Kind:    reflect.TypeOf(stork_api.GroupVolumeSnapshotRestore{}).Name(),
+	}
+	err := k8s.Instance().CreateCRD(resource)
+	if err != nil && !errors.IsAlreadyExists(err) {
+		return err
+	}
+
+	return k8s.Instance().ValidateCRD(resource, validateCRDTimeout, validateCRDInterval)
+}
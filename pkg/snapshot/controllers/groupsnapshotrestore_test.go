@@ -0,0 +1,269 @@
+// +build unittest
+
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"testing"
+
+	snapv1 "github.com/kubernetes-incubator/external-storage/snapshot/pkg/apis/crd/v1"
+	crdclient "github.com/kubernetes-incubator/external-storage/snapshot/pkg/client"
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/portworx/sched-ops/k8s"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	fakekube "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+// setupSnapshotClient sets up fake clients where the snapshot client serves
+// the given snapshots
+func setupSnapshotClient(t *testing.T, snapshots ...*snapv1.VolumeSnapshot) *fakekube.Clientset {
+	scheme := runtime.NewScheme()
+	require.NoError(t, snapv1.AddToScheme(scheme), "Error updating scheme")
+	snapshotClient := &fake.RESTClient{
+		NegotiatedSerializer: serializer.DirectCodecFactory{CodecFactory: serializer.NewCodecFactory(scheme)},
+		Client: fake.CreateHTTPClient(func(req *http.Request) (*http.Response, error) {
+			for _, snapshot := range snapshots {
+				if path.Base(req.URL.Path) == snapshot.Metadata.Name {
+					body, err := json.Marshal(snapshot)
+					if err != nil {
+						return nil, err
+					}
+					header := http.Header{}
+					header.Set("Content-Type", runtime.ContentTypeJSON)
+					return &http.Response{StatusCode: http.StatusOK, Header: header, Body: ioutil.NopCloser(bytes.NewReader(body))}, nil
+				}
+			}
+			return &http.Response{StatusCode: http.StatusNotFound, Body: ioutil.NopCloser(bytes.NewReader(nil))}, nil
+		}),
+	}
+	kubeClient := fakekube.NewSimpleClientset()
+	k8s.Instance().SetClient(kubeClient, snapshotClient, nil, nil, nil, nil)
+	return kubeClient
+}
+
+func newReadySnapshot(name string, namespace string, pvcName string, pvcNamespace string) *snapv1.VolumeSnapshot {
+	snapshot := &snapv1.VolumeSnapshot{
+		Metadata: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: snapv1.VolumeSnapshotSpec{
+			PersistentVolumeClaimName: pvcName,
+			SnapshotDataName:          name + "-data",
+		},
+		Status: snapv1.VolumeSnapshotStatus{
+			Conditions: []snapv1.VolumeSnapshotCondition{
+				{Type: snapv1.VolumeSnapshotConditionReady, Status: v1.ConditionTrue},
+			},
+		},
+	}
+	if pvcNamespace != namespace {
+		snapshot.Metadata.Annotations = map[string]string{SnapshotPVCNamespaceAnnotation: pvcNamespace}
+	}
+	return snapshot
+}
+
+func createSourcePVC(t *testing.T, name string, namespace string) {
+	_, err := k8s.Instance().CreatePersistentVolumeClaim(&v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: v1.PersistentVolumeClaimSpec{
+			AccessModes: []v1.PersistentVolumeAccessMode{v1.ReadWriteOnce},
+			Resources: v1.ResourceRequirements{
+				Requests: v1.ResourceList{v1.ResourceStorage: resource.MustParse("1Gi")},
+			},
+		},
+	})
+	require.NoError(t, err, "Error creating PVC")
+}
+
+func newGroupRestore() (*stork_api.GroupVolumeSnapshotRestore, *stork_api.GroupVolumeSnapshot) {
+	groupRestore := &stork_api.GroupVolumeSnapshotRestore{
+		ObjectMeta: metav1.ObjectMeta{Name: "restore", Namespace: "app", UID: "restore-uid"},
+		Spec:       stork_api.GroupVolumeSnapshotRestoreSpec{SourceName: "group"},
+	}
+	groupSnap := &stork_api.GroupVolumeSnapshot{
+		ObjectMeta: metav1.ObjectMeta{Name: "group", Namespace: "app"},
+		Spec:       stork_api.GroupVolumeSnapshotSpec{Namespaces: []string{"app", "db"}},
+		Status: stork_api.GroupVolumeSnapshotStatus{
+			VolumeSnapshots: []*stork_api.VolumeSnapshotStatus{
+				{VolumeSnapshotName: "snap-data"},
+				{VolumeSnapshotName: "snap-db-logs"},
+			},
+		},
+	}
+	return groupRestore, groupSnap
+}
+
+// createNamespace creates a namespace that allows group snapshots from the
+// given namespaces
+func createNamespace(t *testing.T, kubeClient *fakekube.Clientset, name string, groupSnapshotNamespaces string) {
+	ns := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	if groupSnapshotNamespaces != "" {
+		ns.Annotations = map[string]string{GroupSnapshotNamespacesAnnotation: groupSnapshotNamespaces}
+	}
+	_, err := kubeClient.CoreV1().Namespaces().Create(ns)
+	require.NoError(t, err, "Error creating namespace")
+}
+
+func TestGroupRestoreNewPVCs(t *testing.T) {
+	kubeClient := setupSnapshotClient(t,
+		newReadySnapshot("snap-data", "app", "data", "app"),
+		newReadySnapshot("snap-db-logs", "app", "logs", "db"))
+	createSourcePVC(t, "data", "app")
+	createSourcePVC(t, "logs", "db")
+	createNamespace(t, kubeClient, "db", "app")
+	groupRestore, groupSnap := newGroupRestore()
+	c := &GroupSnapshotRestoreController{Recorder: record.NewFakeRecorder(10)}
+
+	// The PVC from the other namespace is restored in its namespace from the
+	// snapshot in the namespace of the group snapshot
+	pvcs, volumes, err := getNewPVCs(groupRestore, groupSnap)
+	require.NoError(t, err, "Error getting PVCs to restore")
+	require.Len(t, pvcs, 2)
+	require.Equal(t, "data-restore", pvcs[0].Name)
+	require.Equal(t, "app", pvcs[0].Namespace)
+	require.NotContains(t, pvcs[0].Annotations, StorkSnapshotSourceNamespaceAnnotation)
+	require.Equal(t, "logs-restore", pvcs[1].Name)
+	require.Equal(t, "db", pvcs[1].Namespace)
+	require.Equal(t, "snap-db-logs", pvcs[1].Annotations[crdclient.SnapshotPVCAnnotation])
+	require.Equal(t, "app", pvcs[1].Annotations[StorkSnapshotSourceNamespaceAnnotation])
+	require.Equal(t, "restore-uid", pvcs[1].Labels[GroupSnapshotRestoreUIDLabel])
+	require.Equal(t, defaultSnapshotStorageClass, *pvcs[1].Spec.StorageClassName)
+	require.Equal(t, "db", volumes[1].Namespace)
+
+	groupRestore.Status.Volumes = volumes
+	require.NoError(t, c.createNewPVCs(groupRestore, pvcs), "Error creating PVCs")
+	// PVCs that were already created for the restore are reused if the
+	// controller restarts
+	_, _, err = getNewPVCs(groupRestore, groupSnap)
+	require.NoError(t, err, "Error getting PVCs to restore")
+	require.NoError(t, c.createNewPVCs(groupRestore, pvcs), "Error creating PVCs")
+
+	// PVCs that weren't created by the restore aren't adopted
+	otherRestore := groupRestore.DeepCopy()
+	otherRestore.UID = "other-uid"
+	_, _, err = getNewPVCs(otherRestore, groupSnap)
+	require.EqualError(t, err, "PVC app/data-restore already exists")
+	err = c.createNewPVCs(otherRestore, pvcs)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "error creating PVC app/data-restore")
+	// and aren't deleted during the rollback
+	_, err = k8s.Instance().GetPersistentVolumeClaim("data-restore", "app")
+	require.NoError(t, err, "PVC created by another restore was deleted")
+
+	// Failed restores delete all the PVCs created for them
+	pvc, err := k8s.Instance().GetPersistentVolumeClaim("data-restore", "app")
+	require.NoError(t, err, "Error getting PVC")
+	pvc.Status.Phase = v1.ClaimBound
+	pvc.Spec.VolumeName = "pv1"
+	_, err = k8s.Instance().UpdatePersistentVolumeClaim(pvc)
+	require.NoError(t, err, "Error updating PVC")
+	pvc, err = k8s.Instance().GetPersistentVolumeClaim("logs-restore", "db")
+	require.NoError(t, err, "Error getting PVC")
+	pvc.Annotations[StorkSnapshotRestoreErrorAnnotation] = "snapshot not found"
+	_, err = k8s.Instance().UpdatePersistentVolumeClaim(pvc)
+	require.NoError(t, err, "Error updating PVC")
+
+	failedVolume := updateNewPVCStatus(groupRestore)
+	require.NotNil(t, failedVolume)
+	require.Equal(t, "logs-restore", failedVolume.PersistentVolumeClaim)
+	require.Equal(t, stork_api.VolumeSnapshotRestoreStatusSuccessful, groupRestore.Status.Volumes[0].Status)
+	require.Equal(t, "pv1", groupRestore.Status.Volumes[0].Volume)
+	c.rollbackNewPVCs(groupRestore)
+	for _, volumeInfo := range groupRestore.Status.Volumes {
+		require.Equal(t, stork_api.VolumeSnapshotRestoreStatusFailed, volumeInfo.Status)
+		_, err = k8s.Instance().GetPersistentVolumeClaim(volumeInfo.PersistentVolumeClaim, volumeInfo.Namespace)
+		require.Error(t, err, "PVC %v wasn't deleted", volumeInfo.PersistentVolumeClaim)
+	}
+}
+
+func TestGroupRestoreValidation(t *testing.T) {
+	notReady := newReadySnapshot("snap-db-logs", "app", "logs", "db")
+	notReady.Status.Conditions = nil
+	kubeClient := setupSnapshotClient(t, newReadySnapshot("snap-data", "app", "data", "app"), notReady)
+	createNamespace(t, kubeClient, "db", "app")
+	groupRestore, groupSnap := newGroupRestore()
+
+	_, _, err := getNewPVCs(groupRestore, groupSnap)
+	require.EqualError(t, err, `error getting PVC for snapshot snap-data: persistentvolumeclaims "data" not found`)
+	createSourcePVC(t, "data", "app")
+	_, _, err = getNewPVCs(groupRestore, groupSnap)
+	require.EqualError(t, err, "snapshot snap-db-logs is not ready")
+	groupSnap.Status.VolumeSnapshots[1].VolumeSnapshotName = "missing"
+	_, _, err = getNewPVCs(groupRestore, groupSnap)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "error getting snapshot missing")
+}
+
+func TestGroupRestoreSnapshotPVCNamespace(t *testing.T) {
+	kubeClient := setupSnapshotClient(t,
+		newReadySnapshot("snap-data", "app", "data", "app"),
+		newReadySnapshot("snap-db-logs", "app", "logs", "db"))
+	createSourcePVC(t, "data", "app")
+	createSourcePVC(t, "logs", "db")
+	createNamespace(t, kubeClient, "db", "")
+	groupRestore, groupSnap := newGroupRestore()
+
+	// The namespace from the annotation on the snapshot needs to allow group
+	// snapshots from the namespace of the group snapshot
+	_, _, err := getNewPVCs(groupRestore, groupSnap)
+	require.EqualError(t, err, "can't restore snapshot snap-db-logs: namespace db doesn't allow group snapshots from namespace app")
+
+	// and be one of the namespaces selected by the group snapshot
+	groupSnap.Spec.Namespaces = []string{"app"}
+	_, _, err = getNewPVCs(groupRestore, groupSnap)
+	require.EqualError(t, err, "can't restore snapshot snap-db-logs: namespace db isn't selected by group snapshot group")
+}
+
+func TestGroupRestoreInPlaceOwner(t *testing.T) {
+	groupRestore, _ := newGroupRestore()
+	snapRestore := &stork_api.VolumeSnapshotRestore{
+		ObjectMeta: metav1.ObjectMeta{Name: "restore", Namespace: "app"},
+	}
+	require.EqualError(t, checkInPlaceRestoreOwner(snapRestore, groupRestore),
+		"in-place restore restore already exists and wasn't created for this group restore")
+	snapRestore.OwnerReferences = []metav1.OwnerReference{{Name: "restore", UID: groupRestore.UID}}
+	require.NoError(t, checkInPlaceRestoreOwner(snapRestore, groupRestore))
+}
+
+func TestGroupSnapshotNamespaceAllowed(t *testing.T) {
+	kubeClient := setupSnapshotClient(t)
+	_, err := k8s.Instance().CreateNamespace("db", nil)
+	require.NoError(t, err, "Error creating namespace")
+
+	// Group snapshots can always use the PVCs in their own namespace
+	allowed, err := IsGroupSnapshotNamespaceAllowed("app", "app")
+	require.NoError(t, err)
+	require.True(t, allowed)
+
+	// Other namespaces need to allow it
+	allowed, err = IsGroupSnapshotNamespaceAllowed("db", "app")
+	require.NoError(t, err)
+	require.False(t, allowed)
+
+	ns, err := k8s.Instance().GetNamespace("db")
+	require.NoError(t, err, "Error getting namespace")
+	ns.Annotations = map[string]string{GroupSnapshotNamespacesAnnotation: "backup, app-.*"}
+	_, err = kubeClient.CoreV1().Namespaces().Update(ns)
+	require.NoError(t, err, "Error updating namespace")
+	allowed, err = IsGroupSnapshotNamespaceAllowed("db", "app")
+	require.NoError(t, err)
+	require.False(t, allowed)
+	allowed, err = IsGroupSnapshotNamespaceAllowed("db", "app-prod")
+	require.NoError(t, err)
+	require.True(t, allowed)
+	allowed, err = IsGroupSnapshotNamespaceAllowed("db", "backup")
+	require.NoError(t, err)
+	require.True(t, allowed)
+
+	_, err = IsGroupSnapshotNamespaceAllowed("missing", "app")
+	require.Error(t, err, "Expected error for missing namespace")
+}
@@ -11,11 +11,15 @@ import (
 	crdv1 "github.com/kubernetes-incubator/external-storage/snapshot/pkg/apis/crd/v1"
 	crdclient "github.com/kubernetes-incubator/external-storage/snapshot/pkg/client"
 	"github.com/kubernetes-incubator/external-storage/snapshot/pkg/volume"
+	storkvolume "github.com/libopenstorage/stork/drivers/volume"
+	storkerrors "github.com/libopenstorage/stork/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+	k8shelper "k8s.io/kubernetes/pkg/apis/core/v1/helper"
+	kubeletapis "k8s.io/kubernetes/pkg/kubelet/apis"
 )
 
 // Most of this has been taken from the kubernetes-incubator snapshot
@@ -24,7 +28,8 @@ import (
 
 const (
 	// StorkSnapshotRestoreNamespacesAnnotation is annotation used to specify the
-	// command separated list of namespaces to which the snapshot can be restored
+	// command separated list of namespaces to which the snapshot can be restored.
+	// When set on a namespace it applies to all snapshots in that namespace
 	StorkSnapshotRestoreNamespacesAnnotation = "stork.libopenstorage.org/snapshot-restore-namespaces"
 	// StorkSnapshotRestoreNamespacesAnnotationDeprecated deprecated version of StorkSnapshotRestoreNamespacesAnnotation
 	StorkSnapshotRestoreNamespacesAnnotationDeprecated = "stork/snapshot-restore-namespaces"
@@ -33,6 +38,14 @@ const (
 	StorkSnapshotSourceNamespaceAnnotation = "stork.libopenstorage.org/snapshot-source-namespace"
 	// StorkSnapshotSourceNamespaceAnnotationDeprecated deprecated version of StorkSnapshotSourceNamespaceAnnotation
 	StorkSnapshotSourceNamespaceAnnotationDeprecated = "stork/snapshot-source-namespace"
+	// StorkSnapshotRestoreErrorAnnotation Annotation set on a PVC when it
+	// can't be restored from the requested snapshot
+	StorkSnapshotRestoreErrorAnnotation = "stork.libopenstorage.org/snapshot-restore-error"
+	// StorkSnapshotRestoreParametersAnnotation Annotation used to specify a
+	// comma separated list of key=value parameters for the volume that the
+	// snapshot is restored to, for eg "repl=2,io_profile=db". The parameters
+	// are passed to the driver before the snapshot is restored.
+	StorkSnapshotRestoreParametersAnnotation = "stork.libopenstorage.org/snapshot-restore-parameters"
 )
 
 type snapshotProvisioner struct {
@@ -45,6 +58,7 @@ type snapshotProvisioner struct {
 	// provisioner's PVs.
 	identity      string
 	volumePlugins map[string]volume.Plugin
+	driver        storkvolume.Driver
 }
 
 // NewSnapshotProvisioner Creates a new snapshot provisioner controller
@@ -52,16 +66,57 @@ func NewSnapshotProvisioner(
 	client kubernetes.Interface,
 	crdclient *rest.RESTClient,
 	volumePlugins map[string]volume.Plugin,
+	driver storkvolume.Driver,
 	id string,
 ) controller.Provisioner {
 	return &snapshotProvisioner{
 		client:        client,
 		crdclient:     crdclient,
 		volumePlugins: volumePlugins,
+		driver:        driver,
 		identity:      id,
 	}
 }
 
+// GetRestoreParameters returns the parameters set for the volume that a
+// snapshot is restored to for the PVC. Returns nil if no parameters were set.
+func GetRestoreParameters(pvc *v1.PersistentVolumeClaim) (map[string]string, error) {
+	value, ok := pvc.Annotations[StorkSnapshotRestoreParametersAnnotation]
+	if !ok || strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+	parameters := make(map[string]string)
+	for _, parameter := range strings.Split(value, ",") {
+		keyValue := strings.SplitN(parameter, "=", 2)
+		key := strings.TrimSpace(keyValue[0])
+		if len(keyValue) != 2 || key == "" {
+			return nil, fmt.Errorf("invalid restore parameter %q, expected key=value", parameter)
+		}
+		parameters[key] = strings.TrimSpace(keyValue[1])
+	}
+	return parameters, nil
+}
+
+// preProvisionRestoreVolume passes the restore parameters for the PVC to the
+// driver so that the volume is provisioned with them before the snapshot is
+// restored
+func (p *snapshotProvisioner) preProvisionRestoreVolume(
+	snapshotData *crdv1.VolumeSnapshotData,
+	pvc *v1.PersistentVolumeClaim,
+) error {
+	parameters, err := GetRestoreParameters(pvc)
+	if err != nil || len(parameters) == 0 {
+		return err
+	}
+	if err := p.driver.PreProvisionRestoreVolume(snapshotData, pvc, parameters); err != nil {
+		if _, ok := err.(*storkerrors.ErrNotSupported); ok {
+			return fmt.Errorf("restore parameters aren't supported by driver %v", p.driver.String())
+		}
+		return fmt.Errorf("error provisioning volume with restore parameters %v: %v", parameters, err)
+	}
+	return nil
+}
+
 var _ controller.Provisioner = &snapshotProvisioner{}
 
 func (p *snapshotProvisioner) snapshotRestore(
@@ -98,11 +153,28 @@ func (p *snapshotProvisioner) isSnapshotAllowed(
 	allowedNamespaces, ok := snapshot.Metadata.Annotations[StorkSnapshotRestoreNamespacesAnnotation]
 	if !ok {
 		allowedNamespaces, ok = snapshot.Metadata.Annotations[StorkSnapshotRestoreNamespacesAnnotationDeprecated]
-		if !ok {
-			return false
-		}
 	}
+	if ok {
+		return isNamespaceAllowed(allowedNamespaces, namespace)
+	}
+
+	// If the snapshot doesn't have the annotation check if the namespace of
+	// the snapshot allows restores for all its snapshots
+	snapshotNamespace, err := p.client.CoreV1().Namespaces().Get(snapshot.Metadata.Namespace, metav1.GetOptions{})
+	if err != nil {
+		log.Errorf("Error getting namespace %v for snapshot %v: %v", snapshot.Metadata.Namespace, snapshot.Metadata.Name, err)
+		return false
+	}
+	allowedNamespaces, ok = snapshotNamespace.Annotations[StorkSnapshotRestoreNamespacesAnnotation]
+	if !ok {
+		return false
+	}
+	return isNamespaceAllowed(allowedNamespaces, namespace)
+}
 
+// isNamespaceAllowed checks if the namespace matches any of the regexes in the
+// comma separated list of allowed namespaces
+func isNamespaceAllowed(allowedNamespaces string, namespace string) bool {
 	csvReader := csv.NewReader(strings.NewReader(allowedNamespaces))
 	namespaces, err := csvReader.ReadAll()
 	if err != nil {
@@ -130,6 +202,71 @@ func (p *snapshotProvisioner) isSnapshotAllowed(
 	return false
 }
 
+// validateRestore checks that the snapshot can be restored to the PVC with its
+// storage class before the driver is called to restore it
+func (p *snapshotProvisioner) validateRestore(
+	snapshotName string,
+	snapshotData *crdv1.VolumeSnapshotData,
+	pvc *v1.PersistentVolumeClaim,
+) error {
+	volumeType := crdv1.GetSupportedVolumeFromSnapshotDataSpec(&snapshotData.Spec)
+	if _, ok := p.volumePlugins[volumeType]; !ok {
+		return fmt.Errorf("snapshot %v was created by driver %q which doesn't match any of the configured drivers", snapshotName, volumeType)
+	}
+
+	storageClassName := k8shelper.GetPersistentVolumeClaimClass(pvc)
+	storageClass, err := p.client.StorageV1().StorageClasses().Get(storageClassName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("error getting storage class %q for PVC %v: %v", storageClassName, pvc.Name, err)
+	}
+
+	if snapshotData.Spec.PersistentVolumeRef == nil {
+		return nil
+	}
+	sourcePV, err := p.client.CoreV1().PersistentVolumes().Get(snapshotData.Spec.PersistentVolumeRef.Name, metav1.GetOptions{})
+	if err != nil {
+		// The source volume could have been deleted, so the size can't be
+		// validated
+		log.Warnf("Error getting source volume %v for snapshot %v: %v", snapshotData.Spec.PersistentVolumeRef.Name, snapshotName, err)
+		return nil
+	}
+	snapshotSize := sourcePV.Spec.Capacity[v1.ResourceStorage]
+	requestedSize := pvc.Spec.Resources.Requests[v1.ResourceStorage]
+	if requestedSize.Cmp(snapshotSize) < 0 {
+		return fmt.Errorf("requested size %v for PVC %v is smaller than the size %v of snapshot %v",
+			requestedSize.String(), pvc.Name, snapshotSize.String(), snapshotName)
+	}
+	// The volume is restored with the size of the snapshot, so it can only
+	// be provisioned with a larger size if the storage class allows the
+	// volume to be expanded
+	if requestedSize.Cmp(snapshotSize) > 0 &&
+		(storageClass.AllowVolumeExpansion == nil || !*storageClass.AllowVolumeExpansion) {
+		return fmt.Errorf("requested size %v for PVC %v is larger than the size %v of snapshot %v but storage class %v "+
+			"doesn't allow volume expansion", requestedSize.String(), pvc.Name, snapshotSize.String(), snapshotName, storageClass.Name)
+	}
+	return nil
+}
+
+// setRestoreError records the reason a PVC can't be restored on the PVC so
+// that it doesn't look like it is pending for other reasons
+func (p *snapshotProvisioner) setRestoreError(pvc *v1.PersistentVolumeClaim, restoreErr error) {
+	current, err := p.client.CoreV1().PersistentVolumeClaims(pvc.Namespace).Get(pvc.Name, metav1.GetOptions{})
+	if err != nil {
+		log.Errorf("Error getting PVC %v/%v to update restore error: %v", pvc.Namespace, pvc.Name, err)
+		return
+	}
+	if current.Annotations == nil {
+		current.Annotations = make(map[string]string)
+	}
+	if current.Annotations[StorkSnapshotRestoreErrorAnnotation] == restoreErr.Error() {
+		return
+	}
+	current.Annotations[StorkSnapshotRestoreErrorAnnotation] = restoreErr.Error()
+	if _, err := p.client.CoreV1().PersistentVolumeClaims(pvc.Namespace).Update(current); err != nil {
+		log.Errorf("Error updating restore error for PVC %v/%v: %v", pvc.Namespace, pvc.Name, err)
+	}
+}
+
 // Provision creates a storage asset and returns a PV object representing it.
 func (p *snapshotProvisioner) Provision(options controller.VolumeOptions) (*v1.PersistentVolume, error) {
 	if options.PVC.Spec.Selector != nil {
@@ -176,6 +313,15 @@ func (p *snapshotProvisioner) Provision(options controller.VolumeOptions) (*v1.P
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve VolumeSnapshotData %s: %v", snapshot.Spec.SnapshotDataName, err)
 	}
+
+	if err := p.validateRestore(snapshotName, &snapshotData, options.PVC); err != nil {
+		p.setRestoreError(options.PVC, err)
+		return nil, err
+	}
+	if err := p.preProvisionRestoreVolume(&snapshotData, options.PVC); err != nil {
+		p.setRestoreError(options.PVC, err)
+		return nil, err
+	}
 	log.Infof("restore from VolumeSnapshotData %s", snapshot.Spec.SnapshotDataName)
 
 	pvSrc, labels, err := p.snapshotRestore(snapshot.Spec.SnapshotDataName, snapshotData, options)
@@ -208,6 +354,21 @@ func (p *snapshotProvisioner) Provision(options controller.VolumeOptions) (*v1.P
 		}
 	}
 
+	// Volumes restored to a directory on a node can only be used on that node
+	if node, ok := labels[kubeletapis.LabelHostname]; ok && pvSrc.HostPath != nil {
+		pv.Spec.NodeAffinity = &v1.VolumeNodeAffinity{
+			Required: &v1.NodeSelector{
+				NodeSelectorTerms: []v1.NodeSelectorTerm{{
+					MatchExpressions: []v1.NodeSelectorRequirement{{
+						Key:      kubeletapis.LabelHostname,
+						Operator: v1.NodeSelectorOpIn,
+						Values:   []string{node},
+					}},
+				}},
+			},
+		}
+	}
+
 	log.Infof("successfully created Snapshot share %#v", pv)
 
 	return pv, nil
@@ -0,0 +1,143 @@
+// +build unittest
+
+package controllers
+
+import (
+	"testing"
+
+	crdv1 "github.com/kubernetes-incubator/external-storage/snapshot/pkg/apis/crd/v1"
+	"github.com/kubernetes-incubator/external-storage/snapshot/pkg/volume"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakekube "k8s.io/client-go/kubernetes/fake"
+)
+
+func newRestorePVC(parameters string) *v1.PersistentVolumeClaim {
+	pvc := &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "restore", Namespace: "default"},
+	}
+	if parameters != "" {
+		pvc.Annotations = map[string]string{StorkSnapshotRestoreParametersAnnotation: parameters}
+	}
+	return pvc
+}
+
+func TestGetRestoreParameters(t *testing.T) {
+	parameters, err := GetRestoreParameters(newRestorePVC(""))
+	require.NoError(t, err, "Error getting restore parameters")
+	require.Nil(t, parameters)
+
+	parameters, err = GetRestoreParameters(newRestorePVC("repl=2, io_profile=db,secret_key=a=b"))
+	require.NoError(t, err, "Error getting restore parameters")
+	require.Equal(t, map[string]string{"repl": "2", "io_profile": "db", "secret_key": "a=b"}, parameters)
+
+	_, err = GetRestoreParameters(newRestorePVC("repl=2,secure"))
+	require.Error(t, err)
+	require.Equal(t, `invalid restore parameter "secure", expected key=value`, err.Error())
+}
+
+func TestValidateRestore(t *testing.T) {
+	allowExpansion := true
+	sourcePV := &v1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "source"},
+		Spec: v1.PersistentVolumeSpec{
+			Capacity: v1.ResourceList{v1.ResourceStorage: resource.MustParse("2Gi")},
+		},
+	}
+	p := &snapshotProvisioner{
+		client: fakekube.NewSimpleClientset(
+			sourcePV,
+			&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "stork-snapshot-sc"}},
+			&storagev1.StorageClass{
+				ObjectMeta:           metav1.ObjectMeta{Name: "expandable"},
+				AllowVolumeExpansion: &allowExpansion,
+			},
+		),
+		volumePlugins: map[string]volume.Plugin{"hostPath": nil},
+	}
+	snapshotData := &crdv1.VolumeSnapshotData{
+		Spec: crdv1.VolumeSnapshotDataSpec{
+			VolumeSnapshotDataSource: crdv1.VolumeSnapshotDataSource{
+				HostPath: &crdv1.HostPathVolumeSnapshotSource{Path: "/snap"},
+			},
+			PersistentVolumeRef: &v1.ObjectReference{Name: "source"},
+		},
+	}
+	pvc := newRestorePVC("")
+	setRequest := func(storageClass string, size string) {
+		pvc.Spec.StorageClassName = &storageClass
+		pvc.Spec.Resources.Requests = v1.ResourceList{v1.ResourceStorage: resource.MustParse(size)}
+	}
+
+	setRequest("stork-snapshot-sc", "2Gi")
+	require.NoError(t, p.validateRestore("snap", snapshotData, pvc))
+
+	setRequest("missing", "2Gi")
+	err := p.validateRestore("snap", snapshotData, pvc)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `error getting storage class "missing" for PVC restore`)
+
+	setRequest("stork-snapshot-sc", "1Gi")
+	require.EqualError(t, p.validateRestore("snap", snapshotData, pvc),
+		"requested size 1Gi for PVC restore is smaller than the size 2Gi of snapshot snap")
+
+	// Larger sizes need the storage class to allow the volume to be expanded
+	setRequest("stork-snapshot-sc", "3Gi")
+	require.EqualError(t, p.validateRestore("snap", snapshotData, pvc),
+		"requested size 3Gi for PVC restore is larger than the size 2Gi of snapshot snap but storage class "+
+			"stork-snapshot-sc doesn't allow volume expansion")
+	setRequest("expandable", "3Gi")
+	require.NoError(t, p.validateRestore("snap", snapshotData, pvc))
+
+	// The size can't be validated if the source volume has been deleted
+	snapshotData.Spec.PersistentVolumeRef.Name = "deleted"
+	setRequest("stork-snapshot-sc", "1Gi")
+	require.NoError(t, p.validateRestore("snap", snapshotData, pvc))
+
+	snapshotData.Spec.HostPath = nil
+	snapshotData.Spec.PortworxSnapshot = &crdv1.PortworxVolumeSnapshotSource{SnapshotID: "snap-id"}
+	require.EqualError(t, p.validateRestore("snap", snapshotData, pvc),
+		`snapshot snap was created by driver "pxd" which doesn't match any of the configured drivers`)
+}
+
+func TestIsSnapshotAllowed(t *testing.T) {
+	p := &snapshotProvisioner{
+		client: fakekube.NewSimpleClientset(
+			&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+			&v1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "prod",
+					Annotations: map[string]string{StorkSnapshotRestoreNamespacesAnnotation: "dev, test-.*"},
+				},
+			},
+		),
+	}
+	newSnapshot := func(namespace string, annotations map[string]string) crdv1.VolumeSnapshot {
+		return crdv1.VolumeSnapshot{
+			Metadata: metav1.ObjectMeta{Name: "snap", Namespace: namespace, Annotations: annotations},
+		}
+	}
+
+	// The annotation on the snapshot is used if present
+	snapshot := newSnapshot("default", map[string]string{StorkSnapshotRestoreNamespacesAnnotation: "dev"})
+	require.True(t, p.isSnapshotAllowed(snapshot, "dev"))
+	require.False(t, p.isSnapshotAllowed(snapshot, "test-1"))
+	snapshot = newSnapshot("default", map[string]string{StorkSnapshotRestoreNamespacesAnnotationDeprecated: "dev"})
+	require.True(t, p.isSnapshotAllowed(snapshot, "dev"))
+	require.False(t, p.isSnapshotAllowed(newSnapshot("default", nil), "dev"))
+
+	// Otherwise the annotation on the namespace of the snapshot is used
+	snapshot = newSnapshot("prod", nil)
+	require.True(t, p.isSnapshotAllowed(snapshot, "dev"))
+	require.True(t, p.isSnapshotAllowed(snapshot, "test-1"))
+	require.False(t, p.isSnapshotAllowed(snapshot, "staging"))
+	// The snapshot annotation overrides the namespace annotation
+	snapshot = newSnapshot("prod", map[string]string{StorkSnapshotRestoreNamespacesAnnotation: "staging"})
+	require.True(t, p.isSnapshotAllowed(snapshot, "staging"))
+	require.False(t, p.isSnapshotAllowed(snapshot, "dev"))
+
+	require.False(t, p.isSnapshotAllowed(newSnapshot("missing", nil), "dev"))
+}
@@ -7,7 +7,6 @@ import (
 
 	"github.com/kubernetes-incubator/external-storage/snapshot/pkg/client"
 	snapshotcontroller "github.com/kubernetes-incubator/external-storage/snapshot/pkg/controller/snapshot-controller"
-	snapshotvolume "github.com/kubernetes-incubator/external-storage/snapshot/pkg/volume"
 	"github.com/libopenstorage/stork/drivers/volume"
 	"github.com/portworx/sched-ops/k8s"
 	log "github.com/sirupsen/logrus"
@@ -73,8 +72,7 @@ func (s *Snapshotter) Start(stopChannel <-chan struct{}) error {
 		return err
 	}
 
-	plugins := make(map[string]snapshotvolume.Plugin)
-	plugins[s.Driver.String()] = s.Driver.GetSnapshotPlugin()
+	plugins := volume.GetSnapshotPlugins(s.Driver)
 
 	snapController := snapshotcontroller.NewSnapshotController(snapshotClient, snapshotScheme,
 		clientset, &plugins, defaultSyncDuration)
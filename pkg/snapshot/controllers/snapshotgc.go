@@ -0,0 +1,225 @@
+package controllers
+
+import (
+	"time"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/log"
+	"github.com/libopenstorage/stork/pkg/schedule"
+	"github.com/portworx/sched-ops/k8s"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	// SnapshotOrphanedTimestampAnnotation Annotation used to record the time
+	// at which a scheduled snapshot was first found to be orphaned
+	SnapshotOrphanedTimestampAnnotation = "stork.libopenstorage.org/orphanedTimestamp"
+
+	defaultSnapshotGCInterval = 10 * time.Minute
+)
+
+// SnapshotGarbageCollector periodically deletes snapshots created by snapshot
+// schedules whose schedule or PVC no longer exists. Both stork snapshots and
+// snapshot.storage.k8s.io/v1 VolumeSnapshots are collected. Snapshots from
+// schedules with the Retain reclaim policy are never deleted.
+type SnapshotGarbageCollector struct {
+	// GracePeriod is the time after which an orphaned snapshot is deleted
+	GracePeriod time.Duration
+	// Interval at which snapshots are checked. Defaults to 10 minutes
+	Interval time.Duration
+
+	csiSnapshotter *csiSnapshotter
+}
+
+// scheduledSnapshot is a stork or CSI snapshot created by a snapshot schedule
+type scheduledSnapshot struct {
+	meta.Object
+	pvcName string
+	log     *logrus.Entry
+	update  func() error
+	delete  func() error
+}
+
+// Start Starts the garbage collector
+func (g *SnapshotGarbageCollector) Start(stopChannel chan struct{}) {
+	if g.Interval == 0 {
+		g.Interval = defaultSnapshotGCInterval
+	}
+	if g.csiSnapshotter == nil {
+		csiSnapshotter, err := newCSISnapshotter()
+		if err != nil {
+			logrus.Warnf("Error creating client for CSI snapshots, only stork snapshots will be collected: %v", err)
+		} else {
+			g.csiSnapshotter = csiSnapshotter
+		}
+	}
+	go func() {
+		ticker := time.NewTicker(g.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := g.collect(); err != nil {
+					logrus.Errorf("Error collecting orphaned snapshots: %v", err)
+				}
+			case <-stopChannel:
+				return
+			}
+		}
+	}()
+}
+
+func (g *SnapshotGarbageCollector) collect() error {
+	snapshots, err := g.getScheduledSnapshots()
+	if err != nil {
+		return err
+	}
+	now := schedule.GetCurrentTime()
+	for _, snapshot := range snapshots {
+		if isRetained(snapshot) {
+			continue
+		}
+		orphaned, err := g.isOrphaned(snapshot)
+		if err != nil {
+			snapshot.log.Errorf("Error checking if snapshot is orphaned: %v", err)
+			continue
+		}
+
+		annotations := snapshot.GetAnnotations()
+		orphanedTimestamp, present := annotations[SnapshotOrphanedTimestampAnnotation]
+		if !orphaned {
+			// Clear the annotation if the schedule or PVC was recreated
+			if present {
+				delete(annotations, SnapshotOrphanedTimestampAnnotation)
+				snapshot.SetAnnotations(annotations)
+				if err := snapshot.update(); err != nil {
+					snapshot.log.Errorf("Error updating snapshot: %v", err)
+				}
+			}
+			continue
+		}
+
+		if !present {
+			if annotations == nil {
+				annotations = make(map[string]string)
+			}
+			annotations[SnapshotOrphanedTimestampAnnotation] = now.Format(time.RFC3339)
+			snapshot.SetAnnotations(annotations)
+			snapshot.log.Infof("Snapshot is orphaned, will be deleted after %v", g.GracePeriod)
+			if err := snapshot.update(); err != nil {
+				snapshot.log.Errorf("Error updating snapshot: %v", err)
+			}
+			continue
+		}
+
+		orphanedTime, err := time.Parse(time.RFC3339, orphanedTimestamp)
+		if err != nil {
+			snapshot.log.Errorf("Error parsing orphaned timestamp %v: %v", orphanedTimestamp, err)
+			continue
+		}
+		if now.Sub(orphanedTime) < g.GracePeriod {
+			continue
+		}
+		snapshot.log.Infof("Deleting orphaned snapshot")
+		if err := snapshot.delete(); err != nil && !errors.IsNotFound(err) {
+			snapshot.log.Errorf("Error deleting orphaned snapshot: %v", err)
+		}
+	}
+	return nil
+}
+
+// getScheduledSnapshots returns the stork and CSI snapshots that were created
+// by snapshot schedules
+func (g *SnapshotGarbageCollector) getScheduledSnapshots() ([]*scheduledSnapshot, error) {
+	snapshots, err := k8s.Instance().ListSnapshots("")
+	if err != nil {
+		return nil, err
+	}
+	scheduledSnapshots := make([]*scheduledSnapshot, 0)
+	for i := range snapshots.Items {
+		snapshot := &snapshots.Items[i]
+		if _, ok := snapshot.Metadata.Labels[SnapshotScheduleNameLabel]; !ok {
+			continue
+		}
+		scheduledSnapshots = append(scheduledSnapshots, &scheduledSnapshot{
+			Object:  &snapshot.Metadata,
+			pvcName: snapshot.Spec.PersistentVolumeClaimName,
+			log:     log.SnapshotLog(snapshot),
+			update: func() error {
+				_, err := k8s.Instance().UpdateSnapshot(snapshot)
+				return err
+			},
+			delete: func() error {
+				return k8s.Instance().DeleteSnapshot(snapshot.Metadata.Name, snapshot.Metadata.Namespace)
+			},
+		})
+	}
+
+	if g.csiSnapshotter == nil {
+		return scheduledSnapshots, nil
+	}
+	csiSnapshots, err := g.csiSnapshotter.listSnapshots(SnapshotScheduleNameLabel)
+	if err != nil {
+		// The CSI snapshot CRD doesn't need to be installed
+		if errors.IsNotFound(err) {
+			return scheduledSnapshots, nil
+		}
+		return nil, err
+	}
+	for i := range csiSnapshots.Items {
+		snapshot := &csiSnapshots.Items[i]
+		pvcName, _, err := unstructured.NestedString(snapshot.Object, "spec", "source", "persistentVolumeClaimName")
+		if err != nil {
+			return nil, err
+		}
+		scheduledSnapshots = append(scheduledSnapshots, &scheduledSnapshot{
+			Object:  snapshot,
+			pvcName: pvcName,
+			log: logrus.WithFields(logrus.Fields{
+				"CSIVolumeSnapshotName": snapshot.GetName(),
+				"Namespace":             snapshot.GetNamespace(),
+			}),
+			update: func() error {
+				return g.csiSnapshotter.updateSnapshot(snapshot)
+			},
+			delete: func() error {
+				return g.csiSnapshotter.deleteSnapshot(snapshot.GetName(), snapshot.GetNamespace())
+			},
+		})
+	}
+	return scheduledSnapshots, nil
+}
+
+// isRetained returns true if the snapshot was created by a schedule with the
+// Retain reclaim policy. Snapshots created before the reclaim policy label was
+// added are retained if they aren't owned by their schedule, since only the
+// snapshots from schedules with the Delete policy have owners.
+func isRetained(snapshot *scheduledSnapshot) bool {
+	if reclaimPolicy, ok := snapshot.GetLabels()[SnapshotScheduleReclaimPolicyLabel]; ok {
+		return reclaimPolicy == string(stork_api.ReclaimPolicyRetain)
+	}
+	return len(snapshot.GetOwnerReferences()) == 0
+}
+
+// isOrphaned returns true if either the schedule that created the snapshot or
+// the PVC for the snapshot doesn't exist anymore
+func (g *SnapshotGarbageCollector) isOrphaned(snapshot *scheduledSnapshot) (bool, error) {
+	_, err := k8s.Instance().GetSnapshotSchedule(snapshot.GetLabels()[SnapshotScheduleNameLabel], snapshot.GetNamespace())
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	}
+	_, err = k8s.Instance().GetPersistentVolumeClaim(snapshot.pvcName, snapshot.GetNamespace())
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	}
+	return false, nil
+}
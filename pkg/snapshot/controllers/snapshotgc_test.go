@@ -0,0 +1,325 @@
+// +build unittest
+
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	snapv1 "github.com/kubernetes-incubator/external-storage/snapshot/pkg/apis/crd/v1"
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	fakeclient "github.com/libopenstorage/stork/pkg/client/clientset/versioned/fake"
+	"github.com/portworx/sched-ops/k8s"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/client-go/dynamic"
+	fakekube "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/rest/fake"
+)
+
+// fakeSnapshotStore serves snapshots from memory for the list, create, update
+// and delete requests made by the controllers
+type fakeSnapshotStore struct {
+	lock       sync.Mutex
+	apiVersion string
+	snapshots  map[string]map[string]interface{}
+}
+
+func newFakeSnapshotStore(t *testing.T, apiVersion string, snapshots ...interface{}) *fakeSnapshotStore {
+	s := &fakeSnapshotStore{
+		apiVersion: apiVersion,
+		snapshots:  make(map[string]map[string]interface{}),
+	}
+	for _, snapshot := range snapshots {
+		body, err := json.Marshal(snapshot)
+		require.NoError(t, err, "Error encoding snapshot")
+		object := make(map[string]interface{})
+		require.NoError(t, json.Unmarshal(body, &object), "Error decoding snapshot")
+		metadata := object["metadata"].(map[string]interface{})
+		s.snapshots[metadata["namespace"].(string)+"/"+metadata["name"].(string)] = object
+	}
+	return s
+}
+
+func (s *fakeSnapshotStore) get(namespace string, name string) map[string]interface{} {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.snapshots[namespace+"/"+name]
+}
+
+func (s *fakeSnapshotStore) annotations(namespace string, name string) map[string]interface{} {
+	snapshot := s.get(namespace, name)
+	if snapshot == nil {
+		return nil
+	}
+	annotations, _ := snapshot["metadata"].(map[string]interface{})["annotations"].(map[string]interface{})
+	return annotations
+}
+
+func (s *fakeSnapshotStore) handle(req *http.Request) (*http.Response, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var response interface{} = &metav1.Status{}
+	parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	switch {
+	case req.Method == http.MethodGet && parts[len(parts)-1] == "volumesnapshots":
+		label := req.URL.Query().Get("labelSelector")
+		items := make([]interface{}, 0)
+		for _, snapshot := range s.snapshots {
+			labels, _ := snapshot["metadata"].(map[string]interface{})["labels"].(map[string]interface{})
+			if _, ok := labels[label]; label != "" && !ok {
+				continue
+			}
+			items = append(items, snapshot)
+		}
+		response = map[string]interface{}{
+			"apiVersion": s.apiVersion,
+			"kind":       "VolumeSnapshotList",
+			"metadata":   map[string]interface{}{},
+			"items":      items,
+		}
+	case req.Method == http.MethodPost:
+		object := make(map[string]interface{})
+		if err := json.NewDecoder(req.Body).Decode(&object); err != nil {
+			return nil, err
+		}
+		metadata := object["metadata"].(map[string]interface{})
+		s.snapshots[metadata["namespace"].(string)+"/"+metadata["name"].(string)] = object
+		response = object
+	case req.Method == http.MethodPut:
+		object := make(map[string]interface{})
+		if err := json.NewDecoder(req.Body).Decode(&object); err != nil {
+			return nil, err
+		}
+		s.snapshots[parts[len(parts)-3]+"/"+parts[len(parts)-1]] = object
+		response = object
+	case req.Method == http.MethodDelete:
+		delete(s.snapshots, parts[len(parts)-3]+"/"+parts[len(parts)-1])
+	default:
+		return &http.Response{StatusCode: http.StatusNotFound, Body: ioutil.NopCloser(bytes.NewReader(nil))}, nil
+	}
+
+	body, err := json.Marshal(response)
+	if err != nil {
+		return nil, err
+	}
+	header := http.Header{}
+	header.Set("Content-Type", runtime.ContentTypeJSON)
+	return &http.Response{StatusCode: http.StatusOK, Header: header, Body: ioutil.NopCloser(bytes.NewReader(body))}, nil
+}
+
+// newCSISnapshotServer returns a server for the CSI snapshots in the store
+func newCSISnapshotServer(store *fakeSnapshotStore) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp, err := store.handle(r)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		for key, values := range resp.Header {
+			w.Header()[key] = values
+		}
+		w.WriteHeader(resp.StatusCode)
+		body, _ := ioutil.ReadAll(resp.Body)
+		_, _ = w.Write(body)
+	}))
+}
+
+// setupGCClients sets up fake clients with the stork snapshots in the store
+func setupGCClients(t *testing.T, store *fakeSnapshotStore, objects ...runtime.Object) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, snapv1.AddToScheme(scheme), "Error updating scheme")
+	snapshotClient := &fake.RESTClient{
+		NegotiatedSerializer: serializer.DirectCodecFactory{CodecFactory: serializer.NewCodecFactory(scheme)},
+		Client:               fake.CreateHTTPClient(store.handle),
+	}
+	kubeClient := fakekube.NewSimpleClientset(objects...)
+	storkClient := fakeclient.NewSimpleClientset(&stork_api.VolumeSnapshotSchedule{
+		ObjectMeta: metav1.ObjectMeta{Name: "schedule", Namespace: "default"},
+	})
+	k8s.Instance().SetClient(kubeClient, snapshotClient, storkClient, nil, nil, nil)
+}
+
+func newCSISnapshot(name string, scheduleName string, pvcName string, orphanedTimestamp string) map[string]interface{} {
+	metadata := map[string]interface{}{
+		"name":      name,
+		"namespace": "default",
+	}
+	if scheduleName != "" {
+		metadata["labels"] = map[string]interface{}{
+			SnapshotScheduleNameLabel:          scheduleName,
+			SnapshotScheduleReclaimPolicyLabel: string(stork_api.ReclaimPolicyDelete),
+		}
+	}
+	if orphanedTimestamp != "" {
+		metadata["annotations"] = map[string]interface{}{SnapshotOrphanedTimestampAnnotation: orphanedTimestamp}
+	}
+	return map[string]interface{}{
+		"apiVersion": csiSnapshotGroup + "/" + csiSnapshotVersion,
+		"kind":       csiSnapshotKind,
+		"metadata":   metadata,
+		"spec": map[string]interface{}{
+			"source": map[string]interface{}{"persistentVolumeClaimName": pvcName},
+		},
+	}
+}
+
+func TestSnapshotGCCSISnapshots(t *testing.T) {
+	expired := time.Now().Add(-2 * time.Hour).Format(time.RFC3339)
+	csiStore := newFakeSnapshotStore(t, csiSnapshotGroup+"/"+csiSnapshotVersion,
+		// Not created by a schedule
+		newCSISnapshot("manual", "", "missing", ""),
+		// Schedule and PVC exist
+		newCSISnapshot("valid", "schedule", "pvc", expired),
+		// Schedule was deleted
+		newCSISnapshot("no-schedule", "deleted", "pvc", ""),
+		// PVC was deleted past the grace period
+		newCSISnapshot("expired", "schedule", "missing", expired),
+	)
+	server := newCSISnapshotServer(csiStore)
+	defer server.Close()
+	client, err := dynamic.NewForConfig(&rest.Config{Host: server.URL})
+	require.NoError(t, err, "Error creating dynamic client")
+
+	setupGCClients(t, newFakeSnapshotStore(t, "volumesnapshot.external-storage.k8s.io/v1"),
+		&v1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "pvc", Namespace: "default"}})
+	g := &SnapshotGarbageCollector{
+		GracePeriod:    time.Hour,
+		csiSnapshotter: &csiSnapshotter{client: client},
+	}
+	require.NoError(t, g.collect(), "Error collecting snapshots")
+
+	require.NotNil(t, csiStore.get("default", "manual"))
+	require.NotNil(t, csiStore.get("default", "valid"))
+	require.NotContains(t, csiStore.annotations("default", "valid"), SnapshotOrphanedTimestampAnnotation)
+	require.NotNil(t, csiStore.get("default", "no-schedule"))
+	require.Contains(t, csiStore.annotations("default", "no-schedule"), SnapshotOrphanedTimestampAnnotation)
+	require.Nil(t, csiStore.get("default", "expired"))
+}
+
+func newScheduledSnapshot(name string, scheduleName string, pvcName string, orphanedTimestamp string) *snapv1.VolumeSnapshot {
+	snapshot := &snapv1.VolumeSnapshot{
+		Metadata: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec:     snapv1.VolumeSnapshotSpec{PersistentVolumeClaimName: pvcName},
+	}
+	if scheduleName != "" {
+		snapshot.Metadata.Labels = map[string]string{
+			SnapshotScheduleNameLabel:          scheduleName,
+			SnapshotScheduleReclaimPolicyLabel: string(stork_api.ReclaimPolicyDelete),
+		}
+	}
+	if orphanedTimestamp != "" {
+		snapshot.Metadata.Annotations = map[string]string{SnapshotOrphanedTimestampAnnotation: orphanedTimestamp}
+	}
+	return snapshot
+}
+
+func TestSnapshotGC(t *testing.T) {
+	recent := time.Now().Add(-10 * time.Minute).Format(time.RFC3339)
+	expired := time.Now().Add(-2 * time.Hour).Format(time.RFC3339)
+	store := newFakeSnapshotStore(t, "volumesnapshot.external-storage.k8s.io/v1",
+		// Not created by a schedule
+		newScheduledSnapshot("manual", "", "missing", ""),
+		// Schedule and PVC exist
+		newScheduledSnapshot("valid", "schedule", "pvc", ""),
+		// Schedule and PVC were recreated after the snapshot was orphaned
+		newScheduledSnapshot("recreated", "schedule", "pvc", expired),
+		// Schedule was deleted
+		newScheduledSnapshot("no-schedule", "deleted", "pvc", ""),
+		// PVC was deleted within the grace period
+		newScheduledSnapshot("grace-period", "schedule", "missing", recent),
+		// PVC was deleted past the grace period
+		newScheduledSnapshot("expired", "schedule", "missing", expired),
+		// Invalid timestamp
+		newScheduledSnapshot("invalid", "schedule", "missing", "invalid"),
+	)
+	setupGCClients(t, store,
+		&v1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "pvc", Namespace: "default"}})
+	g := &SnapshotGarbageCollector{GracePeriod: time.Hour}
+	require.NoError(t, g.collect(), "Error collecting snapshots")
+
+	require.NotNil(t, store.get("default", "manual"))
+	require.Nil(t, store.annotations("default", "manual"))
+	require.NotNil(t, store.get("default", "valid"))
+	require.Nil(t, store.annotations("default", "valid"))
+	require.NotNil(t, store.get("default", "recreated"))
+	require.NotContains(t, store.annotations("default", "recreated"), SnapshotOrphanedTimestampAnnotation)
+
+	require.NotNil(t, store.get("default", "no-schedule"))
+	timestamp, ok := store.annotations("default", "no-schedule")[SnapshotOrphanedTimestampAnnotation].(string)
+	require.True(t, ok, "Orphaned timestamp not set")
+	_, err := time.Parse(time.RFC3339, timestamp)
+	require.NoError(t, err, "Error parsing orphaned timestamp")
+
+	require.NotNil(t, store.get("default", "grace-period"))
+	require.Equal(t, recent, store.annotations("default", "grace-period")[SnapshotOrphanedTimestampAnnotation])
+	require.Nil(t, store.get("default", "expired"))
+	require.NotNil(t, store.get("default", "invalid"))
+
+	// Snapshots are deleted once the grace period passes
+	g.GracePeriod = 5 * time.Minute
+	require.NoError(t, g.collect(), "Error collecting snapshots")
+	require.Nil(t, store.get("default", "grace-period"))
+	require.NotNil(t, store.get("default", "no-schedule"))
+}
+
+func TestSnapshotGCRetained(t *testing.T) {
+	expired := time.Now().Add(-2 * time.Hour).Format(time.RFC3339)
+	retained := newScheduledSnapshot("retained", "deleted", "missing", expired)
+	retained.Metadata.Labels[SnapshotScheduleReclaimPolicyLabel] = string(stork_api.ReclaimPolicyRetain)
+	// Snapshots created before the reclaim policy label was added are only
+	// collected if they are owned by their schedule
+	legacyRetained := newScheduledSnapshot("legacy-retained", "deleted", "missing", expired)
+	delete(legacyRetained.Metadata.Labels, SnapshotScheduleReclaimPolicyLabel)
+	legacyDeleted := newScheduledSnapshot("legacy-deleted", "schedule", "missing", expired)
+	delete(legacyDeleted.Metadata.Labels, SnapshotScheduleReclaimPolicyLabel)
+	legacyDeleted.Metadata.OwnerReferences = []metav1.OwnerReference{{Name: "schedule", UID: "schedule-uid"}}
+	store := newFakeSnapshotStore(t, "volumesnapshot.external-storage.k8s.io/v1",
+		retained,
+		legacyRetained,
+		legacyDeleted,
+		// Snapshots from schedules with the Delete policy are still collected
+		newScheduledSnapshot("deleted", "deleted", "pvc", expired),
+	)
+	setupGCClients(t, store)
+	g := &SnapshotGarbageCollector{GracePeriod: time.Hour}
+	require.NoError(t, g.collect(), "Error collecting snapshots")
+
+	require.NotNil(t, store.get("default", "retained"))
+	require.NotNil(t, store.get("default", "legacy-retained"))
+	require.Nil(t, store.get("default", "legacy-deleted"))
+	require.Nil(t, store.get("default", "deleted"))
+}
+
+func TestSnapshotGCIsOrphaned(t *testing.T) {
+	setupGCClients(t, newFakeSnapshotStore(t, "volumesnapshot.external-storage.k8s.io/v1"),
+		&v1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "pvc", Namespace: "default"}})
+	g := &SnapshotGarbageCollector{}
+	for _, test := range []struct {
+		snapshot *snapv1.VolumeSnapshot
+		orphaned bool
+	}{
+		{newScheduledSnapshot("valid", "schedule", "pvc", ""), false},
+		{newScheduledSnapshot("no-schedule", "deleted", "pvc", ""), true},
+		{newScheduledSnapshot("no-pvc", "schedule", "missing", ""), true},
+	} {
+		orphaned, err := g.isOrphaned(&scheduledSnapshot{
+			Object:  &test.snapshot.Metadata,
+			pvcName: test.snapshot.Spec.PersistentVolumeClaimName,
+		})
+		require.NoError(t, err, "Error checking if snapshot is orphaned")
+		require.Equal(t, test.orphaned, orphaned, "Unexpected result for %v", test.snapshot.Metadata.Name)
+	}
+}
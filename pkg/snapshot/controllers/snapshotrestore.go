@@ -0,0 +1,554 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	snapv1 "github.com/kubernetes-incubator/external-storage/snapshot/pkg/apis/crd/v1"
+	"github.com/libopenstorage/stork/drivers/volume"
+	"github.com/libopenstorage/stork/pkg/apis/stork"
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/controller"
+	storkerrors "github.com/libopenstorage/stork/pkg/errors"
+	"github.com/libopenstorage/stork/pkg/log"
+	"github.com/operator-framework/operator-sdk/pkg/sdk"
+	"github.com/portworx/sched-ops/k8s"
+	"k8s.io/api/core/v1"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
+)
+
+const (
+	restoreResyncPeriod = 30 * time.Second
+
+	deploymentKind  = "Deployment"
+	replicaSetKind  = "ReplicaSet"
+	statefulSetKind = "StatefulSet"
+)
+
+// SnapshotRestoreController reconciles VolumeSnapshotRestore objects
+type SnapshotRestoreController struct {
+	Driver   volume.Driver
+	Recorder record.EventRecorder
+	// KubeClient is the client used to look up the replicasets of the pods
+	// using the volumes being restored
+	KubeClient kubernetes.Interface
+}
+
+// Init Initialize the snapshot restore controller
+func (c *SnapshotRestoreController) Init() error {
+	err := c.createCRD()
+	if err != nil {
+		return err
+	}
+	return controller.Register(
+		&schema.GroupVersionKind{
+			Group:   stork.GroupName,
+			Version: stork_api.SchemeGroupVersion.Version,
+			Kind:    reflect.TypeOf(stork_api.VolumeSnapshotRestore{}).Name(),
+		},
+		"",
+		restoreResyncPeriod,
+		c)
+}
+
+// Handle updates for VolumeSnapshotRestore objects
+func (c *SnapshotRestoreController) Handle(ctx context.Context, event sdk.Event) error {
+	switch o := event.Object.(type) {
+	case *stork_api.VolumeSnapshotRestore:
+		snapRestore := o
+		// Nothing to do for delete
+		if event.Deleted {
+			return nil
+		}
+
+		var err error
+		switch snapRestore.Status.Stage {
+		case stork_api.VolumeSnapshotRestoreStageInitial:
+			err = c.handleInitial(snapRestore)
+		case stork_api.VolumeSnapshotRestoreStageScaleDown:
+			err = c.handleScaleDown(snapRestore)
+		case stork_api.VolumeSnapshotRestoreStageRestore:
+			err = c.handleRestore(snapRestore)
+		case stork_api.VolumeSnapshotRestoreStageScaleUp:
+			err = c.handleScaleUp(snapRestore)
+		case stork_api.VolumeSnapshotRestoreStageFinal:
+			// Do Nothing
+			return nil
+		default:
+			log.VolumeSnapshotRestoreLog(snapRestore).Errorf("Invalid stage for restore: %v", snapRestore.Status.Stage)
+			return nil
+		}
+		if err != nil {
+			message := fmt.Sprintf("Error during %v stage of restore: %v", snapRestore.Status.Stage, err)
+			log.VolumeSnapshotRestoreLog(snapRestore).Error(message)
+			c.Recorder.Event(snapRestore,
+				v1.EventTypeWarning,
+				string(stork_api.VolumeSnapshotRestoreStatusFailed),
+				message)
+		}
+	}
+	return nil
+}
+
+func (c *SnapshotRestoreController) failRestore(snapRestore *stork_api.VolumeSnapshotRestore, reason string) error {
+	snapRestore.Status.Status = stork_api.VolumeSnapshotRestoreStatusFailed
+	snapRestore.Status.Stage = stork_api.VolumeSnapshotRestoreStageFinal
+	snapRestore.Status.Reason = reason
+	snapRestore.Status.FinishTimestamp = meta.Now()
+	log.VolumeSnapshotRestoreLog(snapRestore).Error(reason)
+	c.Recorder.Event(snapRestore,
+		v1.EventTypeWarning,
+		string(stork_api.VolumeSnapshotRestoreStatusFailed),
+		reason)
+	return sdk.Update(snapRestore)
+}
+
+// getSnapshotNames returns the names of the volumesnapshots that need to be
+// restored, and the group snapshot they are from for group restores
+func (c *SnapshotRestoreController) getSnapshotNames(
+	snapRestore *stork_api.VolumeSnapshotRestore,
+) ([]string, *stork_api.GroupVolumeSnapshot, error) {
+	if !snapRestore.Spec.GroupSnapshot {
+		return []string{snapRestore.Spec.SourceName}, nil, nil
+	}
+
+	groupSnap, err := k8s.Instance().GetGroupSnapshot(snapRestore.Spec.SourceName, snapRestore.Namespace)
+	if err != nil {
+		return nil, nil, err
+	}
+	if groupSnap.Status.Status != stork_api.GroupSnapshotSuccessful {
+		return nil, nil, fmt.Errorf("group snapshot %v is not successful, current status: %v",
+			groupSnap.Name, groupSnap.Status.Status)
+	}
+	snapshotNames := make([]string, 0)
+	for _, snapshot := range groupSnap.Status.VolumeSnapshots {
+		snapshotNames = append(snapshotNames, snapshot.VolumeSnapshotName)
+	}
+	return snapshotNames, groupSnap, nil
+}
+
+// checkRestorePVCNamespace returns an error if the PVC in the namespace can't
+// be restored in place. PVCs in other namespaces can only be restored from
+// group snapshots that were allowed to select PVCs from them.
+func checkRestorePVCNamespace(
+	snapRestore *stork_api.VolumeSnapshotRestore,
+	pvcNamespace string,
+	groupSnap *stork_api.GroupVolumeSnapshot,
+) error {
+	if groupSnap != nil {
+		return checkSnapshotPVCNamespace(pvcNamespace, groupSnap)
+	}
+	if pvcNamespace != snapRestore.Namespace {
+		return fmt.Errorf("PVC is in namespace %v, only PVCs in the namespace of the restore can be restored", pvcNamespace)
+	}
+	return nil
+}
+
+func isSnapshotReady(snapshot *snapv1.VolumeSnapshot) bool {
+	if len(snapshot.Status.Conditions) == 0 {
+		return false
+	}
+	lastCondition := snapshot.Status.Conditions[len(snapshot.Status.Conditions)-1]
+	return lastCondition.Type == snapv1.VolumeSnapshotConditionReady && lastCondition.Status == v1.ConditionTrue
+}
+
+func (c *SnapshotRestoreController) handleInitial(snapRestore *stork_api.VolumeSnapshotRestore) error {
+	if snapRestore.Spec.SourceName == "" {
+		return c.failRestore(snapRestore, "sourceName for restore cannot be empty")
+	}
+	if err := volume.CheckCapability(c.Driver, volume.CapabilitySnapshotRestore); err != nil {
+		return c.failRestore(snapRestore, err.Error())
+	}
+
+	snapshotNames, groupSnap, err := c.getSnapshotNames(snapRestore)
+	if err != nil {
+		return c.failRestore(snapRestore, fmt.Sprintf("Error getting snapshots to restore: %v", err))
+	}
+
+	volumes := make([]*stork_api.RestoreVolumeInfo, 0)
+	for _, snapshotName := range snapshotNames {
+		snapshot, err := k8s.Instance().GetSnapshot(snapshotName, snapRestore.Namespace)
+		if err != nil {
+			return c.failRestore(snapRestore, fmt.Sprintf("Error getting snapshot %v: %v", snapshotName, err))
+		}
+		if !isSnapshotReady(snapshot) {
+			return c.failRestore(snapRestore, fmt.Sprintf("Snapshot %v is not ready", snapshotName))
+		}
+		pvcNamespace := getSnapshotPVCNamespace(snapshot)
+		if err := checkRestorePVCNamespace(snapRestore, pvcNamespace, groupSnap); err != nil {
+			return c.failRestore(snapRestore, fmt.Sprintf("Can't restore snapshot %v: %v", snapshotName, err))
+		}
+		pvc, err := k8s.Instance().GetPersistentVolumeClaim(snapshot.Spec.PersistentVolumeClaimName, pvcNamespace)
+		if err != nil {
+			return c.failRestore(snapRestore, fmt.Sprintf("Error getting PVC for snapshot %v: %v", snapshotName, err))
+		}
+		snapshotData, err := k8s.Instance().GetSnapshotData(snapshot.Spec.SnapshotDataName)
+		if err != nil {
+			return c.failRestore(snapRestore, fmt.Sprintf("Error getting snapshot data for snapshot %v: %v", snapshotName, err))
+		}
+		driver, err := volume.GetPVCDriver(c.Driver, pvc)
+		if err != nil {
+			return c.failRestore(snapRestore, fmt.Sprintf("PVC %v isn't owned by the storage driver: %v", pvc.Name, err))
+		}
+		if err := volume.CheckCapability(driver, volume.CapabilitySnapshotRestore); err != nil {
+			return c.failRestore(snapRestore, err.Error())
+		}
+		if volumeType := snapv1.GetSupportedVolumeFromSnapshotDataSpec(&snapshotData.Spec); volumeType != driver.String() {
+			return c.failRestore(snapRestore,
+				fmt.Sprintf("Snapshot %v was created by driver %q which doesn't match the storage driver %q",
+					snapshotName, volumeType, driver.String()))
+		}
+		volumeName, err := k8s.Instance().GetVolumeForPersistentVolumeClaim(pvc)
+		if err != nil {
+			return c.failRestore(snapRestore, fmt.Sprintf("Error getting volume for PVC %v: %v", pvc.Name, err))
+		}
+		if !snapRestore.Spec.ScaleDownApplications {
+			pods, err := k8s.Instance().GetPodsUsingPVC(pvc.Name, pvc.Namespace)
+			if err != nil {
+				return err
+			}
+			if len(pods) > 0 {
+				return c.failRestore(snapRestore,
+					fmt.Sprintf("PVC %v is being used by pod %v. Set scaleDownApplications to scale down "+
+						"the applications during the restore", pvc.Name, pods[0].Name))
+			}
+		}
+		volumes = append(volumes, &stork_api.RestoreVolumeInfo{
+			PersistentVolumeClaim: pvc.Name,
+			Namespace:             pvc.Namespace,
+			Volume:                volumeName,
+			Snapshot:              snapshotName,
+			SnapshotData:          snapshot.Spec.SnapshotDataName,
+			Status:                stork_api.VolumeSnapshotRestoreStatusPending,
+		})
+	}
+
+	snapRestore.Status.Volumes = volumes
+	if snapRestore.Spec.DryRun {
+		return c.finishDryRun(snapRestore)
+	}
+	snapRestore.Status.Status = stork_api.VolumeSnapshotRestoreStatusInProgress
+	if snapRestore.Spec.ScaleDownApplications {
+		snapRestore.Status.Stage = stork_api.VolumeSnapshotRestoreStageScaleDown
+	} else {
+		snapRestore.Status.Stage = stork_api.VolumeSnapshotRestoreStageRestore
+	}
+	return sdk.Update(snapRestore)
+}
+
+// getApplicationsForPVC returns the deployments and statefulsets with pods
+// using the PVC. The applications are found from the controllers of the pods,
+// so applications whose selectors happen to match the pods aren't returned.
+func (c *SnapshotRestoreController) getApplicationsForPVC(
+	pvcName string,
+	namespace string,
+) ([]*stork_api.RestoreApplicationInfo, error) {
+	pods, err := k8s.Instance().GetPodsUsingPVC(pvcName, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	apps := make([]*stork_api.RestoreApplicationInfo, 0)
+	for i := range pods {
+		app, err := c.getPodApplication(&pods[i])
+		if err != nil {
+			return nil, err
+		}
+		if app == nil {
+			return nil, fmt.Errorf("pod %v using PVC %v isn't managed by a deployment or statefulset", pods[i].Name, pvcName)
+		}
+		apps = append(apps, app)
+	}
+	return apps, nil
+}
+
+// getPodApplication returns the statefulset controlling the pod, or the
+// deployment controlling its replicaset. nil is returned if the pod isn't
+// managed by either.
+func (c *SnapshotRestoreController) getPodApplication(pod *v1.Pod) (*stork_api.RestoreApplicationInfo, error) {
+	owner := meta.GetControllerOf(pod)
+	if owner == nil {
+		return nil, nil
+	}
+	switch owner.Kind {
+	case statefulSetKind:
+		statefulSet, err := k8s.Instance().GetStatefulSet(owner.Name, pod.Namespace)
+		if err != nil {
+			return nil, fmt.Errorf("error getting statefulset for pod %v: %v", pod.Name, err)
+		}
+		if statefulSet.UID != owner.UID {
+			return nil, nil
+		}
+		app := &stork_api.RestoreApplicationInfo{
+			Kind:      statefulSetKind,
+			Name:      statefulSet.Name,
+			Namespace: statefulSet.Namespace,
+		}
+		if statefulSet.Spec.Replicas != nil {
+			app.Replicas = *statefulSet.Spec.Replicas
+		}
+		return app, nil
+	case replicaSetKind:
+		if c.KubeClient == nil {
+			return nil, fmt.Errorf("client to get replicasets hasn't been set")
+		}
+		replicaSet, err := c.KubeClient.AppsV1beta2().ReplicaSets(pod.Namespace).Get(owner.Name, meta.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("error getting replicaset for pod %v: %v", pod.Name, err)
+		}
+		if replicaSet.UID != owner.UID {
+			return nil, nil
+		}
+		owner = meta.GetControllerOf(replicaSet)
+		if owner == nil || owner.Kind != deploymentKind {
+			return nil, nil
+		}
+		deployment, err := k8s.Instance().GetDeployment(owner.Name, pod.Namespace)
+		if err != nil {
+			return nil, fmt.Errorf("error getting deployment for pod %v: %v", pod.Name, err)
+		}
+		if deployment.UID != owner.UID {
+			return nil, nil
+		}
+		app := &stork_api.RestoreApplicationInfo{
+			Kind:      deploymentKind,
+			Name:      deployment.Name,
+			Namespace: deployment.Namespace,
+		}
+		if deployment.Spec.Replicas != nil {
+			app.Replicas = *deployment.Spec.Replicas
+		}
+		return app, nil
+	}
+	return nil, nil
+}
+
+func (c *SnapshotRestoreController) scaleApplication(app *stork_api.RestoreApplicationInfo, replicas int32) error {
+	switch app.Kind {
+	case deploymentKind:
+		deployment, err := k8s.Instance().GetDeployment(app.Name, app.Namespace)
+		if err != nil {
+			return err
+		}
+		deployment.Spec.Replicas = &replicas
+		_, err = k8s.Instance().UpdateDeployment(deployment)
+		return err
+	case statefulSetKind:
+		statefulSet, err := k8s.Instance().GetStatefulSet(app.Name, app.Namespace)
+		if err != nil {
+			return err
+		}
+		statefulSet.Spec.Replicas = &replicas
+		_, err = k8s.Instance().UpdateStatefulSet(statefulSet)
+		return err
+	default:
+		return fmt.Errorf("unsupported application kind %v", app.Kind)
+	}
+}
+
+func (c *SnapshotRestoreController) handleScaleDown(snapRestore *stork_api.VolumeSnapshotRestore) error {
+	// Record the applications and their replicas before scaling them down so
+	// that they can be scaled back up even if stork restarts
+	if snapRestore.Status.Applications == nil {
+		apps := make([]*stork_api.RestoreApplicationInfo, 0)
+		found := make(map[string]bool)
+		for _, volumeInfo := range snapRestore.Status.Volumes {
+			pvcApps, err := c.getApplicationsForPVC(volumeInfo.PersistentVolumeClaim, volumeInfo.Namespace)
+			if err != nil {
+				return c.failRestore(snapRestore, fmt.Sprintf("Error getting applications to scale down: %v", err))
+			}
+			for _, app := range pvcApps {
+				key := app.Kind + "/" + app.Namespace + "/" + app.Name
+				if found[key] {
+					continue
+				}
+				found[key] = true
+				apps = append(apps, app)
+			}
+		}
+		snapRestore.Status.Applications = apps
+		if err := sdk.Update(snapRestore); err != nil {
+			return err
+		}
+	}
+
+	for _, app := range snapRestore.Status.Applications {
+		log.VolumeSnapshotRestoreLog(snapRestore).Infof("Scaling down %v %v", app.Kind, app.Name)
+		if err := c.scaleApplication(app, 0); err != nil {
+			return fmt.Errorf("error scaling down %v %v: %v", app.Kind, app.Name, err)
+		}
+	}
+
+	// Wait for all the pods using the volumes to be deleted before starting
+	// the restore
+	for _, volumeInfo := range snapRestore.Status.Volumes {
+		pods, err := k8s.Instance().GetPodsUsingPVC(volumeInfo.PersistentVolumeClaim, volumeInfo.Namespace)
+		if err != nil {
+			return err
+		}
+		if len(pods) > 0 {
+			log.VolumeSnapshotRestoreLog(snapRestore).Infof("Waiting for pods using PVC %v to be deleted", volumeInfo.PersistentVolumeClaim)
+			return nil
+		}
+	}
+	// The volumes can still be attached for a while after the pods have been
+	// deleted, so also wait for the driver to detach them
+	attachedVolume, err := c.getAttachedVolume(snapRestore)
+	if err != nil {
+		return err
+	}
+	if attachedVolume != "" {
+		log.VolumeSnapshotRestoreLog(snapRestore).Infof("Waiting for volume %v to be detached", attachedVolume)
+		return nil
+	}
+
+	snapRestore.Status.Stage = stork_api.VolumeSnapshotRestoreStageRestore
+	return sdk.Update(snapRestore)
+}
+
+// getAttachedVolume returns the name of a volume being restored that is still
+// attached to a node, or an empty string if none of them are attached. Volumes
+// for drivers that don't report attachments are assumed to be detached.
+func (c *SnapshotRestoreController) getAttachedVolume(snapRestore *stork_api.VolumeSnapshotRestore) (string, error) {
+	for _, volumeInfo := range snapRestore.Status.Volumes {
+		driverVolume, err := c.Driver.InspectVolume(volumeInfo.Volume)
+		if err != nil {
+			return "", fmt.Errorf("error inspecting volume %v: %v", volumeInfo.Volume, err)
+		}
+		attachedNode, err := c.Driver.GetVolumeAttachedNode(driverVolume)
+		if err != nil {
+			if _, ok := err.(*storkerrors.ErrNotSupported); ok {
+				continue
+			}
+			return "", fmt.Errorf("error getting attached node for volume %v: %v", volumeInfo.Volume, err)
+		}
+		if attachedNode != "" {
+			return volumeInfo.Volume, nil
+		}
+	}
+	return "", nil
+}
+
+func (c *SnapshotRestoreController) handleRestore(snapRestore *stork_api.VolumeSnapshotRestore) error {
+	started := false
+	for _, volumeInfo := range snapRestore.Status.Volumes {
+		if volumeInfo.Status != stork_api.VolumeSnapshotRestoreStatusPending {
+			started = true
+			break
+		}
+	}
+
+	var err error
+	if started {
+		err = c.Driver.GetVolumeSnapshotRestoreStatus(snapRestore)
+	} else {
+		log.VolumeSnapshotRestoreLog(snapRestore).Infof("Starting restore of volumes")
+		err = c.Driver.StartVolumeSnapshotRestore(snapRestore)
+	}
+	if err != nil {
+		// Fail the volumes that haven't completed, the applications still
+		// need to be scaled back up
+		for _, volumeInfo := range snapRestore.Status.Volumes {
+			if !isRestoreComplete(volumeInfo.Status) {
+				volumeInfo.Status = stork_api.VolumeSnapshotRestoreStatusFailed
+				volumeInfo.Reason = fmt.Sprintf("Error restoring volume: %v", err)
+			}
+		}
+	}
+
+	for _, volumeInfo := range snapRestore.Status.Volumes {
+		if !isRestoreComplete(volumeInfo.Status) {
+			return sdk.Update(snapRestore)
+		}
+	}
+
+	if len(snapRestore.Status.Applications) > 0 {
+		snapRestore.Status.Stage = stork_api.VolumeSnapshotRestoreStageScaleUp
+		if err := sdk.Update(snapRestore); err != nil {
+			return err
+		}
+		return c.handleScaleUp(snapRestore)
+	}
+	return c.finishRestore(snapRestore)
+}
+
+func (c *SnapshotRestoreController) handleScaleUp(snapRestore *stork_api.VolumeSnapshotRestore) error {
+	for _, app := range snapRestore.Status.Applications {
+		log.VolumeSnapshotRestoreLog(snapRestore).Infof("Scaling up %v %v to %v replicas", app.Kind, app.Name, app.Replicas)
+		if err := c.scaleApplication(app, app.Replicas); err != nil {
+			return fmt.Errorf("error scaling up %v %v: %v", app.Kind, app.Name, err)
+		}
+	}
+	return c.finishRestore(snapRestore)
+}
+
+func (c *SnapshotRestoreController) finishRestore(snapRestore *stork_api.VolumeSnapshotRestore) error {
+	snapRestore.Status.Status = stork_api.VolumeSnapshotRestoreStatusSuccessful
+	snapRestore.Status.Reason = "Restore completed successfully"
+	for _, volumeInfo := range snapRestore.Status.Volumes {
+		if volumeInfo.Status == stork_api.VolumeSnapshotRestoreStatusFailed {
+			snapRestore.Status.Status = stork_api.VolumeSnapshotRestoreStatusFailed
+			snapRestore.Status.Reason = fmt.Sprintf("Restore of PVC %v failed: %v", volumeInfo.PersistentVolumeClaim, volumeInfo.Reason)
+			break
+		}
+	}
+	snapRestore.Status.Stage = stork_api.VolumeSnapshotRestoreStageFinal
+	snapRestore.Status.FinishTimestamp = meta.Now()
+
+	eventType := v1.EventTypeNormal
+	if snapRestore.Status.Status == stork_api.VolumeSnapshotRestoreStatusFailed {
+		eventType = v1.EventTypeWarning
+	}
+	c.Recorder.Event(snapRestore,
+		eventType,
+		string(snapRestore.Status.Status),
+		snapRestore.Status.Reason)
+	return sdk.Update(snapRestore)
+}
+
+// finishDryRun marks the restore as successful once all the volumes have been
+// validated, without restoring any of them
+func (c *SnapshotRestoreController) finishDryRun(snapRestore *stork_api.VolumeSnapshotRestore) error {
+	for _, volumeInfo := range snapRestore.Status.Volumes {
+		volumeInfo.Status = stork_api.VolumeSnapshotRestoreStatusSuccessful
+		volumeInfo.Reason = "Volume can be restored from the snapshot"
+	}
+	snapRestore.Status.Status = stork_api.VolumeSnapshotRestoreStatusSuccessful
+	snapRestore.Status.Reason = "Dry run completed successfully, volumes were not restored"
+	snapRestore.Status.Stage = stork_api.VolumeSnapshotRestoreStageFinal
+	snapRestore.Status.FinishTimestamp = meta.Now()
+	c.Recorder.Event(snapRestore,
+		v1.EventTypeNormal,
+		string(snapRestore.Status.Status),
+		snapRestore.Status.Reason)
+	return sdk.Update(snapRestore)
+}
+
+func isRestoreComplete(status stork_api.VolumeSnapshotRestoreStatusType) bool {
+	return status == stork_api.VolumeSnapshotRestoreStatusSuccessful ||
+		status == stork_api.VolumeSnapshotRestoreStatusFailed
+}
+
+func (c *SnapshotRestoreController) createCRD() error {
+	resource := k8s.CustomResource{
+		Name:    stork_api.VolumeSnapshotRestoreResourceName,
+		Plural:  stork_api.VolumeSnapshotRestoreResourcePlural,
+		Group:   stork.GroupName,
+		Version: stork_api.SchemeGroupVersion.Version,
+		Scope:   apiextensionsv1beta1.NamespaceScoped,
+		Kind:    reflect.TypeOf(stork_api.VolumeSnapshotRestore{}).Name(),
+	}
+	err := k8s.Instance().CreateCRD(resource)
+	if err != nil && !errors.IsAlreadyExists(err) {
+		return err
+	}
+
+	return k8s.Instance().ValidateCRD(resource, validateCRDTimeout, validateCRDInterval)
+}
@@ -0,0 +1,190 @@
+// +build unittest
+
+package controllers
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/libopenstorage/stork/drivers/volume"
+	"github.com/libopenstorage/stork/drivers/volume/mock"
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/portworx/sched-ops/k8s"
+	"github.com/stretchr/testify/require"
+	appsv1beta2 "k8s.io/api/apps/v1beta2"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	fakekube "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestRestoreGetAttachedVolume(t *testing.T) {
+	storkDriver, err := volume.Get("MockDriver")
+	require.NoError(t, err, "Error getting mock volume driver")
+	driver, ok := storkDriver.(*mock.Driver)
+	require.True(t, ok, "Error casting mock driver")
+	require.NoError(t, driver.CreateCluster(2, &v1.NodeList{}), "Error creating cluster")
+	require.NoError(t, driver.ProvisionVolume("vol1", []int{0}, 1), "Error provisioning volume")
+	require.NoError(t, driver.ProvisionVolume("vol2", []int{1}, 1), "Error provisioning volume")
+
+	c := &SnapshotRestoreController{Driver: driver}
+	snapRestore := &stork_api.VolumeSnapshotRestore{
+		Status: stork_api.VolumeSnapshotRestoreStatus{
+			Volumes: []*stork_api.RestoreVolumeInfo{{Volume: "vol1"}, {Volume: "vol2"}},
+		},
+	}
+	attachedVolume, err := c.getAttachedVolume(snapRestore)
+	require.NoError(t, err, "Error getting attached volume")
+	require.Empty(t, attachedVolume)
+
+	// The restore should wait while the driver still has a volume attached,
+	// even if the pods using it have been deleted
+	require.NoError(t, driver.AttachVolume("vol2", 0), "Error attaching volume")
+	attachedVolume, err = c.getAttachedVolume(snapRestore)
+	require.NoError(t, err, "Error getting attached volume")
+	require.Equal(t, "vol2", attachedVolume)
+
+	require.NoError(t, driver.ForceDetachVolume(&volume.Info{VolumeID: "vol2"}), "Error detaching volume")
+	attachedVolume, err = c.getAttachedVolume(snapRestore)
+	require.NoError(t, err, "Error getting attached volume")
+	require.Empty(t, attachedVolume)
+
+	snapRestore.Status.Volumes = append(snapRestore.Status.Volumes, &stork_api.RestoreVolumeInfo{Volume: "missing"})
+	_, err = c.getAttachedVolume(snapRestore)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "error inspecting volume missing")
+
+	driver.SetInterfaceError(fmt.Errorf("driver error"))
+	defer driver.SetInterfaceError(nil)
+	_, err = c.getAttachedVolume(snapRestore)
+	require.EqualError(t, err, "error inspecting volume vol1: driver error")
+}
+
+func TestRestorePVCNamespace(t *testing.T) {
+	kubeClient := setupSnapshotClient(t)
+	createNamespace(t, kubeClient, "db", "app")
+	snapRestore := &stork_api.VolumeSnapshotRestore{
+		ObjectMeta: metav1.ObjectMeta{Name: "restore", Namespace: "app"},
+	}
+	require.NoError(t, checkRestorePVCNamespace(snapRestore, "app", nil))
+	// The namespace annotation on a snapshot can't be used to restore PVCs
+	// in other namespaces
+	require.EqualError(t, checkRestorePVCNamespace(snapRestore, "db", nil),
+		"PVC is in namespace db, only PVCs in the namespace of the restore can be restored")
+
+	// unless the snapshot is from a group snapshot allowed to select PVCs
+	// from the namespace
+	_, groupSnap := newGroupRestore()
+	require.NoError(t, checkRestorePVCNamespace(snapRestore, "db", groupSnap))
+	require.EqualError(t, checkRestorePVCNamespace(snapRestore, "other", groupSnap),
+		"namespace other isn't selected by group snapshot group")
+}
+
+func newAppPod(name string, pvcName string, owner metav1.Object, kind string) *v1.Pod {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "app",
+			Labels:    map[string]string{"app": "web"},
+		},
+		Spec: v1.PodSpec{
+			Volumes: []v1.Volume{
+				{
+					Name: "data",
+					VolumeSource: v1.VolumeSource{
+						PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: pvcName},
+					},
+				},
+			},
+		},
+	}
+	if owner != nil {
+		pod.OwnerReferences = []metav1.OwnerReference{newControllerRef(owner, kind)}
+	}
+	return pod
+}
+
+func newControllerRef(owner metav1.Object, kind string) metav1.OwnerReference {
+	isController := true
+	return metav1.OwnerReference{
+		APIVersion: "apps/v1",
+		Kind:       kind,
+		Name:       owner.GetName(),
+		UID:        owner.GetUID(),
+		Controller: &isController,
+	}
+}
+
+func TestRestoreGetApplicationsForPVC(t *testing.T) {
+	replicas := int32(3)
+	selector := &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}
+	deployment := &appsv1beta2.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "app", UID: "web-uid"},
+		Spec:       appsv1beta2.DeploymentSpec{Replicas: &replicas, Selector: selector},
+	}
+	// Deployment with a selector that also matches the pods of the other
+	// deployment
+	otherDeployment := &appsv1beta2.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "aaa", Namespace: "app", UID: "aaa-uid"},
+		Spec:       appsv1beta2.DeploymentSpec{Replicas: &replicas, Selector: selector},
+	}
+	replicaSet := &appsv1beta2.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "web-abc",
+			Namespace:       "app",
+			UID:             "web-abc-uid",
+			OwnerReferences: []metav1.OwnerReference{newControllerRef(deployment, "Deployment")},
+		},
+	}
+	statefulSetReplicas := int32(2)
+	statefulSet := &appsv1beta2.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "app", UID: "db-uid"},
+		Spec:       appsv1beta2.StatefulSetSpec{Replicas: &statefulSetReplicas, Selector: selector},
+	}
+	staleReplicaSet := replicaSet.DeepCopy()
+	staleReplicaSet.UID = types.UID("stale-uid")
+	kubeClient := fakekube.NewSimpleClientset(
+		deployment,
+		otherDeployment,
+		replicaSet,
+		statefulSet,
+		newAppPod("web-abc-1", "data", replicaSet, "ReplicaSet"),
+		newAppPod("web-abc-2", "data", replicaSet, "ReplicaSet"),
+		newAppPod("db-0", "logs", statefulSet, "StatefulSet"),
+		newAppPod("standalone", "tmp", nil, ""),
+		newAppPod("stale", "cache", staleReplicaSet, "ReplicaSet"),
+	)
+	k8s.Instance().SetClient(kubeClient, nil, nil, nil, nil, nil)
+	c := &SnapshotRestoreController{KubeClient: kubeClient}
+
+	// The deployment is found from the replicaset of the pods instead of
+	// matching the selectors
+	apps, err := c.getApplicationsForPVC("data", "app")
+	require.NoError(t, err, "Error getting applications for PVC")
+	require.Len(t, apps, 2)
+	for _, app := range apps {
+		require.Equal(t, &stork_api.RestoreApplicationInfo{
+			Kind:      deploymentKind,
+			Name:      "web",
+			Namespace: "app",
+			Replicas:  3,
+		}, app)
+	}
+
+	apps, err = c.getApplicationsForPVC("logs", "app")
+	require.NoError(t, err, "Error getting applications for PVC")
+	require.Equal(t, []*stork_api.RestoreApplicationInfo{
+		{Kind: statefulSetKind, Name: "db", Namespace: "app", Replicas: 2},
+	}, apps)
+
+	apps, err = c.getApplicationsForPVC("unused", "app")
+	require.NoError(t, err, "Error getting applications for PVC")
+	require.Empty(t, apps)
+
+	_, err = c.getApplicationsForPVC("tmp", "app")
+	require.EqualError(t, err, "pod standalone using PVC tmp isn't managed by a deployment or statefulset")
+	// Owners are matched by UID so objects recreated with the same name
+	// aren't scaled
+	_, err = c.getApplicationsForPVC("cache", "app")
+	require.EqualError(t, err, "pod stale using PVC cache isn't managed by a deployment or statefulset")
+}
@@ -34,11 +34,18 @@ const (
 	// SnapshotSchedulePolicyTypeLabel Label used to specify the type of the
 	// policy that triggered the snapshot
 	SnapshotSchedulePolicyTypeLabel = "stork.libopenstorage.org/snapshotSchedulePolicyType"
+	// SnapshotScheduleReclaimPolicyLabel Label used to specify the reclaim
+	// policy of the schedule that created the snapshot
+	SnapshotScheduleReclaimPolicyLabel = "stork.libopenstorage.org/snapshotScheduleReclaimPolicy"
+	// SnapshotFullAnnotation Annotation used to request a full snapshot from
+	// the driver instead of an incremental one
+	SnapshotFullAnnotation = "stork.libopenstorage.org/full-snapshot"
 )
 
 // SnapshotScheduleController reconciles VolumeSnapshotSchedule objects
 type SnapshotScheduleController struct {
-	Recorder record.EventRecorder
+	Recorder       record.EventRecorder
+	csiSnapshotter *csiSnapshotter
 }
 
 // Init Initialize the snapshot schedule controller
@@ -47,6 +54,10 @@ func (s *SnapshotScheduleController) Init() error {
 	if err != nil {
 		return err
 	}
+	s.csiSnapshotter, err = newCSISnapshotter()
+	if err != nil {
+		return err
+	}
 	return controller.Register(
 		&schema.GroupVersionKind{
 			Group:   stork.GroupName,
@@ -81,7 +92,14 @@ func (s *SnapshotScheduleController) Handle(ctx context.Context, event sdk.Event
 			return err
 		}
 
-		if snapshotSchedule.Spec.Suspend == nil || !*snapshotSchedule.Spec.Suspend {
+		// Suspend or resume the schedule if the state of the cluster domains
+		// it depends on has changed
+		if err := s.updateClusterDomainSuspension(snapshotSchedule); err != nil {
+			log.VolumeSnapshotScheduleLog(snapshotSchedule).Warnf("Error checking cluster domains for schedule: %v", err)
+		}
+
+		if (snapshotSchedule.Spec.Suspend == nil || !*snapshotSchedule.Spec.Suspend) &&
+			len(snapshotSchedule.Status.SuspendedForClusterDomains) == 0 {
 			// Then check if any of the policies require a trigger
 			policyType, start, err := s.shouldStartVolumeSnapshot(snapshotSchedule)
 			if err != nil {
@@ -125,10 +143,51 @@ func (s *SnapshotScheduleController) Handle(ctx context.Context, event sdk.Event
 	return nil
 }
 
+// updateClusterDomainSuspension suspends the schedule while any of the
+// cluster domains it depends on are inactive, and resumes it once they are
+// all active. The state of the cluster domains isn't changed if it can't be
+// checked.
+func (s *SnapshotScheduleController) updateClusterDomainSuspension(snapshotSchedule *stork_api.VolumeSnapshotSchedule) error {
+	inactiveDomains, err := schedule.GetInactiveClusterDomains(snapshotSchedule.Spec.ClusterDomains)
+	if err != nil {
+		return err
+	}
+	suspendedFor, suspensions, updated := schedule.RecordClusterDomainSuspension(
+		snapshotSchedule.Status.SuspendedForClusterDomains,
+		snapshotSchedule.Status.ClusterDomainSuspensions,
+		inactiveDomains)
+	if !updated {
+		return nil
+	}
+	snapshotSchedule.Status.SuspendedForClusterDomains = suspendedFor
+	snapshotSchedule.Status.ClusterDomainSuspensions = suspensions
+	if len(suspendedFor) > 0 {
+		msg := fmt.Sprintf("Schedule suspended since cluster domains %v are inactive", suspendedFor)
+		s.Recorder.Event(snapshotSchedule, v1.EventTypeWarning, "Suspended", msg)
+		log.VolumeSnapshotScheduleLog(snapshotSchedule).Info(msg)
+	} else {
+		msg := "Schedule resumed since all the cluster domains it depends on are active"
+		s.Recorder.Event(snapshotSchedule, v1.EventTypeNormal, "Resumed", msg)
+		log.VolumeSnapshotScheduleLog(snapshotSchedule).Info(msg)
+	}
+	return sdk.Update(snapshotSchedule)
+}
+
 func (s *SnapshotScheduleController) setDefaults(snapshotSchedule *stork_api.VolumeSnapshotSchedule) {
 	if snapshotSchedule.Spec.ReclaimPolicy == "" {
 		snapshotSchedule.Spec.ReclaimPolicy = stork_api.ReclaimPolicyDelete
 	}
+	if snapshotSchedule.Spec.SnapshotType == "" {
+		snapshotSchedule.Spec.SnapshotType = stork_api.VolumeSnapshotTypeStork
+	}
+	if snapshotSchedule.Spec.CloudSnapshot != nil {
+		if snapshotSchedule.Spec.CloudSnapshot.Frequency < 1 {
+			snapshotSchedule.Spec.CloudSnapshot.Frequency = 1
+		}
+		if snapshotSchedule.Spec.CloudSnapshot.Retain == 0 {
+			snapshotSchedule.Spec.CloudSnapshot.Retain = stork_api.DefaultCloudSnapshotRetain
+		}
+	}
 }
 
 func getVolumeSnapshotStatus(name string, namespace string) (snapv1.VolumeSnapshotConditionType, error) {
@@ -152,13 +211,46 @@ func getVolumeSnapshotStatus(name string, namespace string) (snapv1.VolumeSnapsh
 
 }
 
+func (s *SnapshotScheduleController) getVolumeSnapshotStatus(
+	snapshotSchedule *stork_api.VolumeSnapshotSchedule,
+	name string,
+) (snapv1.VolumeSnapshotConditionType, error) {
+	if snapshotSchedule.Spec.SnapshotType == stork_api.VolumeSnapshotTypeCSI {
+		return s.csiSnapshotter.getSnapshotStatus(name, snapshotSchedule.Namespace)
+	}
+	return getVolumeSnapshotStatus(name, snapshotSchedule.Namespace)
+}
+
+func (s *SnapshotScheduleController) deleteVolumeSnapshot(
+	snapshotSchedule *stork_api.VolumeSnapshotSchedule,
+	name string,
+) error {
+	if snapshotSchedule.Spec.SnapshotType == stork_api.VolumeSnapshotTypeCSI {
+		return s.csiSnapshotter.deleteSnapshot(name, snapshotSchedule.Namespace)
+	}
+	return k8s.Instance().DeleteSnapshot(name, snapshotSchedule.Namespace)
+}
+
+func getScheduledVolumeSnapshots(
+	items map[stork_api.SchedulePolicyType][]*stork_api.ScheduledVolumeSnapshotStatus,
+) []*stork_api.ScheduledVolumeSnapshotStatus {
+	snapshots := make([]*stork_api.ScheduledVolumeSnapshotStatus, 0)
+	for _, policyVolumeSnapshot := range items {
+		snapshots = append(snapshots, policyVolumeSnapshot...)
+	}
+	return snapshots
+}
+
 func (s *SnapshotScheduleController) updateVolumeSnapshotStatus(snapshotSchedule *stork_api.VolumeSnapshotSchedule) error {
 	updated := false
-	for _, policyVolumeSnapshot := range snapshotSchedule.Status.Items {
-		for _, snapshot := range policyVolumeSnapshot {
+	for _, items := range []map[stork_api.SchedulePolicyType][]*stork_api.ScheduledVolumeSnapshotStatus{
+		snapshotSchedule.Status.Items,
+		snapshotSchedule.Status.CloudItems,
+	} {
+		for _, snapshot := range getScheduledVolumeSnapshots(items) {
 			// Get the updated status if we see it as not completed
 			if !s.isVolumeSnapshotComplete(snapshot.Status) {
-				pendingVolumeSnapshotStatus, err := getVolumeSnapshotStatus(snapshot.Name, snapshotSchedule.Namespace)
+				pendingVolumeSnapshotStatus, err := s.getVolumeSnapshotStatus(snapshotSchedule, snapshot.Name)
 				if err != nil {
 					s.Recorder.Event(snapshotSchedule,
 						v1.EventTypeWarning,
@@ -200,25 +292,58 @@ func (s *SnapshotScheduleController) isVolumeSnapshotComplete(status snapv1.Volu
 }
 
 func (s *SnapshotScheduleController) shouldStartVolumeSnapshot(snapshotSchedule *stork_api.VolumeSnapshotSchedule) (stork_api.SchedulePolicyType, bool, error) {
-	// Don't trigger a new snapshot if one is already in progress
+	maxRuntime, maxRuntimeAction, err := schedule.GetMaxRuntime(snapshotSchedule.Spec.SchedulePolicyName)
+	if err != nil {
+		return stork_api.SchedulePolicyTypeInvalid, false, err
+	}
+	// Find the snapshots that are still in progress, cancelling the ones
+	// that have run for longer than the max runtime if required
+	running := make([]*stork_api.ScheduledVolumeSnapshotStatus, 0)
 	for _, policyType := range stork_api.GetValidSchedulePolicyTypes() {
 		policyVolumeSnapshot, present := snapshotSchedule.Status.Items[policyType]
 		if present {
 			for _, snapshot := range policyVolumeSnapshot {
-				if !s.isVolumeSnapshotComplete(snapshot.Status) {
-					return stork_api.SchedulePolicyTypeInvalid, false, nil
+				if s.isVolumeSnapshotComplete(snapshot.Status) {
+					continue
 				}
+				if maxRuntime != 0 && schedule.GetCurrentTime().Sub(snapshot.CreationTimestamp.Time) >= maxRuntime {
+					if err := s.maxRuntimeExceeded(snapshotSchedule, snapshot, maxRuntime, maxRuntimeAction); err != nil {
+						return stork_api.SchedulePolicyTypeInvalid, false, err
+					}
+					if s.isVolumeSnapshotComplete(snapshot.Status) {
+						continue
+					}
+				}
+				running = append(running, snapshot)
 			}
 		}
 	}
+	concurrencyPolicy := snapshotSchedule.Spec.ConcurrencyPolicy
+	// Don't trigger a new snapshot if one is already in progress unless the
+	// concurrency policy allows it
+	if len(running) > 0 && concurrencyPolicy != stork_api.ConcurrencyPolicyReplace &&
+		concurrencyPolicy != stork_api.ConcurrencyPolicyAllow {
+		return stork_api.SchedulePolicyTypeInvalid, false, nil
+	}
 
+	delay, err := schedule.GetTriggerDelay(snapshotSchedule.Spec.SchedulePolicyName, snapshotSchedule.Namespace, snapshotSchedule.Name)
+	if err != nil {
+		return stork_api.SchedulePolicyTypeInvalid, false, err
+	}
 	for _, policyType := range stork_api.GetValidSchedulePolicyTypes() {
 		var latestVolumeSnapshotTimestamp meta.Time
 		policyVolumeSnapshot, present := snapshotSchedule.Status.Items[policyType]
 		if present {
 			for _, snapshot := range policyVolumeSnapshot {
-				if latestVolumeSnapshotTimestamp.Before(&snapshot.CreationTimestamp) {
-					latestVolumeSnapshotTimestamp = snapshot.CreationTimestamp
+				lastTrigger := snapshot.CreationTimestamp
+				// Triggers that were due while a snapshot ran for longer
+				// than the max runtime are skipped
+				if snapshot.MaxRuntimeExceeded && maxRuntimeAction == stork_api.MaxRuntimeActionSkip &&
+					!snapshot.FinishTimestamp.IsZero() {
+					lastTrigger = snapshot.FinishTimestamp
+				}
+				if latestVolumeSnapshotTimestamp.Before(&lastTrigger) {
+					latestVolumeSnapshotTimestamp = lastTrigger
 				}
 			}
 		}
@@ -226,23 +351,164 @@ func (s *SnapshotScheduleController) shouldStartVolumeSnapshot(snapshotSchedule
 			snapshotSchedule.Spec.SchedulePolicyName,
 			policyType,
 			latestVolumeSnapshotTimestamp,
+			delay,
 		)
 		if err != nil {
 			return stork_api.SchedulePolicyTypeInvalid, false, err
 		}
+		trigger, pending, err := schedule.CatchUpRequired(
+			snapshotSchedule.Spec.SchedulePolicyName,
+			policyType,
+			latestVolumeSnapshotTimestamp,
+			snapshotSchedule.Spec.CatchUpPolicy,
+			trigger,
+			snapshotSchedule.Status.PendingCatchUpTriggers[policyType],
+			delay,
+		)
+		if err != nil {
+			return stork_api.SchedulePolicyTypeInvalid, false, err
+		}
+		if pending != snapshotSchedule.Status.PendingCatchUpTriggers[policyType] {
+			if snapshotSchedule.Status.PendingCatchUpTriggers == nil {
+				snapshotSchedule.Status.PendingCatchUpTriggers = make(map[stork_api.SchedulePolicyType]int)
+			}
+			snapshotSchedule.Status.PendingCatchUpTriggers[policyType] = pending
+		}
 		if trigger {
+			reason, err := schedule.GetSkipReason(snapshotSchedule.Spec.SchedulePolicyName)
+			if err != nil {
+				return stork_api.SchedulePolicyTypeInvalid, false, err
+			}
+			if reason == "" {
+				reason, err = schedule.GetDependencySkipReason(snapshotSchedule.Namespace, snapshotSchedule.Spec.DependsOn, latestVolumeSnapshotTimestamp)
+				if err != nil {
+					return stork_api.SchedulePolicyTypeInvalid, false, err
+				}
+			}
+			if reason != "" {
+				return stork_api.SchedulePolicyTypeInvalid, false, s.skipVolumeSnapshot(snapshotSchedule, policyType, latestVolumeSnapshotTimestamp, reason)
+			}
+			if concurrencyPolicy == stork_api.ConcurrencyPolicyReplace {
+				for _, snapshot := range running {
+					if err := s.cancelVolumeSnapshot(snapshotSchedule, snapshot); err != nil {
+						return stork_api.SchedulePolicyTypeInvalid, false, err
+					}
+					s.Recorder.Event(snapshotSchedule,
+						v1.EventTypeNormal,
+						"Cancelled",
+						fmt.Sprintf("Scheduled snapshot (%v) cancelled to start a new snapshot", snapshot.Name))
+				}
+			}
 			return policyType, true, nil
 		}
 	}
 	return stork_api.SchedulePolicyTypeInvalid, false, nil
 }
 
+// maxRuntimeExceeded handles a snapshot that has been running for longer than
+// the max runtime of the schedule policy. The snapshot is cancelled if the
+// policy says so, otherwise it is left running and an event is raised once.
+func (s *SnapshotScheduleController) maxRuntimeExceeded(
+	snapshotSchedule *stork_api.VolumeSnapshotSchedule,
+	snapshot *stork_api.ScheduledVolumeSnapshotStatus,
+	maxRuntime time.Duration,
+	action stork_api.MaxRuntimeActionType,
+) error {
+	if snapshot.MaxRuntimeExceeded && action != stork_api.MaxRuntimeActionCancel {
+		return nil
+	}
+	snapshot.MaxRuntimeExceeded = true
+	var msg string
+	if action == stork_api.MaxRuntimeActionCancel {
+		if err := s.cancelVolumeSnapshot(snapshotSchedule, snapshot); err != nil {
+			return err
+		}
+		msg = fmt.Sprintf("Scheduled snapshot (%v) cancelled since it ran for longer than %v", snapshot.Name, maxRuntime)
+		s.Recorder.Event(snapshotSchedule,
+			v1.EventTypeWarning,
+			"Cancelled",
+			msg)
+	} else {
+		msg = fmt.Sprintf("Scheduled snapshot (%v) has run for longer than %v, triggers will be skipped until it completes", snapshot.Name, maxRuntime)
+		s.Recorder.Event(snapshotSchedule,
+			v1.EventTypeWarning,
+			"MaxRuntimeExceeded",
+			msg)
+	}
+	log.VolumeSnapshotScheduleLog(snapshotSchedule).Warn(msg)
+	return sdk.Update(snapshotSchedule)
+}
+
+// cancelVolumeSnapshot cancels a snapshot that is in progress by deleting it
+// and marks it as failed
+func (s *SnapshotScheduleController) cancelVolumeSnapshot(
+	snapshotSchedule *stork_api.VolumeSnapshotSchedule,
+	snapshot *stork_api.ScheduledVolumeSnapshotStatus,
+) error {
+	err := s.deleteVolumeSnapshot(snapshotSchedule, snapshot.Name)
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("error cancelling snapshot %v: %v", snapshot.Name, err)
+	}
+	snapshot.Status = snapv1.VolumeSnapshotConditionError
+	snapshot.FinishTimestamp = meta.NewTime(schedule.GetCurrentTime())
+	log.VolumeSnapshotScheduleLog(snapshotSchedule).Infof("Cancelled snapshot %v", snapshot.Name)
+	return nil
+}
+
+func (s *SnapshotScheduleController) skipVolumeSnapshot(
+	snapshotSchedule *stork_api.VolumeSnapshotSchedule,
+	policyType stork_api.SchedulePolicyType,
+	lastTrigger meta.Time,
+	reason string,
+) error {
+	skippedTriggers, updated := schedule.RecordSkippedTrigger(snapshotSchedule.Status.SkippedTriggers, policyType, lastTrigger, reason)
+	if !updated {
+		return nil
+	}
+	snapshotSchedule.Status.SkippedTriggers = skippedTriggers
+	msg := skippedTriggers[len(skippedTriggers)-1].Reason
+	s.Recorder.Event(snapshotSchedule,
+		v1.EventTypeNormal,
+		"Skipped",
+		fmt.Sprintf("%v: %v", policyType, msg))
+	log.VolumeSnapshotScheduleLog(snapshotSchedule).Infof("%v: %v", policyType, msg)
+	return sdk.Update(snapshotSchedule)
+}
+
 func (s *SnapshotScheduleController) formatVolumeSnapshotName(snapshotSchedule *stork_api.VolumeSnapshotSchedule, policyType stork_api.SchedulePolicyType) string {
 	return strings.Join([]string{snapshotSchedule.Name, strings.ToLower(string(policyType)), time.Now().Format(nameTimeSuffixFormat)}, "-")
 }
 
+// shouldStartCloudSnapshot checks if a cloud snapshot should also be created
+// for the scheduled snapshot that is being triggered
+func (s *SnapshotScheduleController) shouldStartCloudSnapshot(
+	snapshotSchedule *stork_api.VolumeSnapshotSchedule,
+	policyType stork_api.SchedulePolicyType,
+) bool {
+	cloudSnapshot := snapshotSchedule.Spec.CloudSnapshot
+	if cloudSnapshot == nil || snapshotSchedule.Spec.SnapshotType != stork_api.VolumeSnapshotTypeStork {
+		return false
+	}
+	if snapshotSchedule.Status.NumTriggered[policyType]%cloudSnapshot.Frequency != 0 {
+		return false
+	}
+	// Don't start another cloud snapshot if the previous one is still
+	// being uploaded
+	for _, snapshot := range snapshotSchedule.Status.CloudItems[policyType] {
+		if !s.isVolumeSnapshotComplete(snapshot.Status) {
+			log.VolumeSnapshotScheduleLog(snapshotSchedule).Infof("Skipping cloud snapshot since %v is still in progress", snapshot.Name)
+			return false
+		}
+	}
+	return true
+}
+
 func (s *SnapshotScheduleController) startVolumeSnapshot(snapshotSchedule *stork_api.VolumeSnapshotSchedule, policyType stork_api.SchedulePolicyType) error {
 	snapshotName := s.formatVolumeSnapshotName(snapshotSchedule, policyType)
+	delay, err := schedule.GetTriggerDelay(snapshotSchedule.Spec.SchedulePolicyName, snapshotSchedule.Namespace, snapshotSchedule.Name)
+	if err != nil {
+		return err
+	}
 	if snapshotSchedule.Status.Items == nil {
 		snapshotSchedule.Status.Items = make(map[stork_api.SchedulePolicyType][]*stork_api.ScheduledVolumeSnapshotStatus)
 	}
@@ -251,29 +517,98 @@ func (s *SnapshotScheduleController) startVolumeSnapshot(snapshotSchedule *stork
 	}
 	snapshotSchedule.Status.Items[policyType] = append(snapshotSchedule.Status.Items[policyType],
 		&stork_api.ScheduledVolumeSnapshotStatus{
-			Name:              snapshotName,
-			CreationTimestamp: meta.NewTime(schedule.GetCurrentTime()),
-			Status:            snapv1.VolumeSnapshotConditionPending,
+			Name:                snapshotName,
+			CreationTimestamp:   meta.NewTime(schedule.GetCurrentTime()),
+			Status:              snapv1.VolumeSnapshotConditionPending,
+			TriggerDelaySeconds: int64(delay / time.Second),
 		})
-	err := sdk.Update(snapshotSchedule)
+	if snapshotSchedule.Status.NumTriggered == nil {
+		snapshotSchedule.Status.NumTriggered = make(map[stork_api.SchedulePolicyType]int)
+	}
+	snapshotSchedule.Status.NumTriggered[policyType]++
+
+	cloudSnapshotName := ""
+	if s.shouldStartCloudSnapshot(snapshotSchedule, policyType) {
+		cloudSnapshotName = snapshotName + "-cloud"
+		if snapshotSchedule.Status.CloudItems == nil {
+			snapshotSchedule.Status.CloudItems = make(map[stork_api.SchedulePolicyType][]*stork_api.ScheduledVolumeSnapshotStatus)
+		}
+		snapshotSchedule.Status.CloudItems[policyType] = append(snapshotSchedule.Status.CloudItems[policyType],
+			&stork_api.ScheduledVolumeSnapshotStatus{
+				Name:              cloudSnapshotName,
+				CreationTimestamp: meta.NewTime(schedule.GetCurrentTime()),
+				Status:            snapv1.VolumeSnapshotConditionPending,
+			})
+	}
+	cloudAnnotations := make(map[string]string)
+	if cloudSnapshotName != "" {
+		for k, v := range snapshotSchedule.Spec.CloudSnapshot.Annotations {
+			cloudAnnotations[k] = v
+		}
+		if s.updateChainLength(snapshotSchedule, policyType) {
+			cloudAnnotations[SnapshotFullAnnotation] = "true"
+		}
+	}
+	err = sdk.Update(snapshotSchedule)
 	if err != nil {
 		return err
 	}
 
+	if err := s.createVolumeSnapshot(snapshotSchedule, policyType, snapshotName, nil); err != nil {
+		return err
+	}
+	if cloudSnapshotName != "" {
+		return s.createVolumeSnapshot(snapshotSchedule, policyType, cloudSnapshotName, cloudAnnotations)
+	}
+	return nil
+}
+
+// updateChainLength updates the length of the cloud snapshot chain for the
+// policy. Returns true if a full snapshot should be taken.
+func (s *SnapshotScheduleController) updateChainLength(
+	snapshotSchedule *stork_api.VolumeSnapshotSchedule,
+	policyType stork_api.SchedulePolicyType,
+) bool {
+	if snapshotSchedule.Status.ChainLength == nil {
+		snapshotSchedule.Status.ChainLength = make(map[stork_api.SchedulePolicyType]int)
+	}
+	chainLength := snapshotSchedule.Status.ChainLength[policyType]
+	frequency := snapshotSchedule.Spec.CloudSnapshot.FullSnapshotFrequency
+	if chainLength == 0 || (frequency > 0 && chainLength >= frequency) {
+		snapshotSchedule.Status.ChainLength[policyType] = 1
+		return frequency > 0
+	}
+	snapshotSchedule.Status.ChainLength[policyType] = chainLength + 1
+	return false
+}
+
+func (s *SnapshotScheduleController) createVolumeSnapshot(
+	snapshotSchedule *stork_api.VolumeSnapshotSchedule,
+	policyType stork_api.SchedulePolicyType,
+	snapshotName string,
+	annotations map[string]string,
+) error {
 	snapshot := &snapv1.VolumeSnapshot{
 		Metadata: meta.ObjectMeta{
 			Name:        snapshotName,
 			Namespace:   snapshotSchedule.Namespace,
-			Annotations: snapshotSchedule.Annotations,
-			Labels:      snapshotSchedule.Labels,
+			Annotations: make(map[string]string),
+			Labels:      make(map[string]string),
 		},
 		Spec: snapshotSchedule.Spec.Template.Spec,
 	}
-	if snapshot.Metadata.Labels == nil {
-		snapshot.Metadata.Labels = make(map[string]string)
+	for k, v := range snapshotSchedule.Annotations {
+		snapshot.Metadata.Annotations[k] = v
+	}
+	for k, v := range annotations {
+		snapshot.Metadata.Annotations[k] = v
+	}
+	for k, v := range snapshotSchedule.Labels {
+		snapshot.Metadata.Labels[k] = v
 	}
 	snapshot.Metadata.Labels[SnapshotScheduleNameLabel] = snapshotSchedule.Name
 	snapshot.Metadata.Labels[SnapshotSchedulePolicyTypeLabel] = string(policyType)
+	snapshot.Metadata.Labels[SnapshotScheduleReclaimPolicyLabel] = string(snapshotSchedule.Spec.ReclaimPolicy)
 
 	log.VolumeSnapshotScheduleLog(snapshotSchedule).Infof("Starting snapshot %v", snapshotName)
 	// If reclaim policy is set to Delete, this will delete the snapshots
@@ -288,52 +623,75 @@ func (s *SnapshotScheduleController) startVolumeSnapshot(snapshotSchedule *stork
 			},
 		}
 	}
-	_, err = k8s.Instance().CreateSnapshot(snapshot)
+	if snapshotSchedule.Spec.SnapshotType == stork_api.VolumeSnapshotTypeCSI {
+		return s.csiSnapshotter.createSnapshot(
+			snapshot.Metadata,
+			snapshot.Spec.PersistentVolumeClaimName,
+			snapshotSchedule.Spec.VolumeSnapshotClassName)
+	}
+	_, err := k8s.Instance().CreateSnapshot(snapshot)
 	return err
 }
 
 func (s *SnapshotScheduleController) pruneVolumeSnapshots(snapshotSchedule *stork_api.VolumeSnapshotSchedule) error {
 	for policyType, policyVolumeSnapshot := range snapshotSchedule.Status.Items {
-		numVolumeSnapshots := len(policyVolumeSnapshot)
-		deleteBefore := 0
 		retainNum, err := schedule.GetRetain(snapshotSchedule.Spec.SchedulePolicyName, policyType)
 		if err != nil {
 			return err
 		}
-		numReady := 0
-
-		// Keep up to retainNum successful snapshot statuses and all failed snapshots
-		// until there is a successful one
-		if numVolumeSnapshots > int(retainNum) {
-			// Start from the end and find the retainNum successful snapshots
-			for i := range policyVolumeSnapshot {
-				if policyVolumeSnapshot[(numVolumeSnapshots-1-i)].Status == snapv1.VolumeSnapshotConditionReady {
-					numReady++
-					if numReady > int(retainNum) {
-						deleteBefore = numVolumeSnapshots - i
-						break
-					}
-				}
-			}
-			failedDeletes := make([]*stork_api.ScheduledVolumeSnapshotStatus, 0)
+		snapshotSchedule.Status.Items[policyType] = s.pruneVolumeSnapshotList(snapshotSchedule, policyVolumeSnapshot, retainNum)
+	}
+	if snapshotSchedule.Spec.CloudSnapshot != nil {
+		for policyType, policyVolumeSnapshot := range snapshotSchedule.Status.CloudItems {
+			snapshotSchedule.Status.CloudItems[policyType] = s.pruneVolumeSnapshotList(
+				snapshotSchedule,
+				policyVolumeSnapshot,
+				snapshotSchedule.Spec.CloudSnapshot.Retain)
+		}
+	}
+	return sdk.Update(snapshotSchedule)
+}
+
+// pruneVolumeSnapshotList deletes old snapshots in the list and returns the
+// statuses of the snapshots that should still be tracked
+func (s *SnapshotScheduleController) pruneVolumeSnapshotList(
+	snapshotSchedule *stork_api.VolumeSnapshotSchedule,
+	policyVolumeSnapshot []*stork_api.ScheduledVolumeSnapshotStatus,
+	retainNum stork_api.Retain,
+) []*stork_api.ScheduledVolumeSnapshotStatus {
+	numVolumeSnapshots := len(policyVolumeSnapshot)
+	deleteBefore := 0
+	numReady := 0
+
+	// Keep up to retainNum successful snapshot statuses and all failed snapshots
+	// until there is a successful one
+	if numVolumeSnapshots <= int(retainNum) {
+		return policyVolumeSnapshot
+	}
+	// Start from the end and find the retainNum successful snapshots
+	for i := range policyVolumeSnapshot {
+		if policyVolumeSnapshot[(numVolumeSnapshots-1-i)].Status == snapv1.VolumeSnapshotConditionReady {
+			numReady++
 			if numReady > int(retainNum) {
-				for i := 0; i < deleteBefore; i++ {
-					err := k8s.Instance().DeleteSnapshot(policyVolumeSnapshot[i].Name, snapshotSchedule.Namespace)
-					if err != nil && !errors.IsNotFound(err) {
-						log.VolumeSnapshotScheduleLog(snapshotSchedule).Warnf("Error deleting %v: %v", policyVolumeSnapshot[i].Name, err)
-						// Keep a track of the failed deletes
-						failedDeletes = append(failedDeletes, policyVolumeSnapshot[i])
-					}
-				}
+				deleteBefore = numVolumeSnapshots - i
+				break
 			}
-			// Remove all the ones we tried to delete above
-			snapshotSchedule.Status.Items[policyType] = policyVolumeSnapshot[deleteBefore:]
-			// And re-add the ones that failed so that we don't lose track
-			// of them
-			snapshotSchedule.Status.Items[policyType] = append(failedDeletes, snapshotSchedule.Status.Items[policyType]...)
 		}
 	}
-	return sdk.Update(snapshotSchedule)
+	failedDeletes := make([]*stork_api.ScheduledVolumeSnapshotStatus, 0)
+	if numReady > int(retainNum) {
+		for i := 0; i < deleteBefore; i++ {
+			err := s.deleteVolumeSnapshot(snapshotSchedule, policyVolumeSnapshot[i].Name)
+			if err != nil && !errors.IsNotFound(err) {
+				log.VolumeSnapshotScheduleLog(snapshotSchedule).Warnf("Error deleting %v: %v", policyVolumeSnapshot[i].Name, err)
+				// Keep a track of the failed deletes
+				failedDeletes = append(failedDeletes, policyVolumeSnapshot[i])
+			}
+		}
+	}
+	// Remove all the ones we tried to delete above and re-add the ones that
+	// failed so that we don't lose track of them
+	return append(failedDeletes, policyVolumeSnapshot[deleteBefore:]...)
 }
 
 func (s *SnapshotScheduleController) createCRD() error {
@@ -0,0 +1,166 @@
+// +build unittest
+
+package controllers
+
+import (
+	"testing"
+
+	snapv1 "github.com/kubernetes-incubator/external-storage/snapshot/pkg/apis/crd/v1"
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newCloudSnapshotSchedule(cloudSnapshot *stork_api.CloudSnapshotSpec) *stork_api.VolumeSnapshotSchedule {
+	snapshotSchedule := &stork_api.VolumeSnapshotSchedule{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "schedule",
+			Namespace:   "default",
+			Annotations: map[string]string{"portworx/snapshot-type": "local"},
+		},
+		Spec: stork_api.VolumeSnapshotScheduleSpec{
+			Template: stork_api.VolumeSnapshotTemplateSpec{
+				Spec: snapv1.VolumeSnapshotSpec{PersistentVolumeClaimName: "pvc"},
+			},
+			CloudSnapshot: cloudSnapshot,
+		},
+	}
+	(&SnapshotScheduleController{}).setDefaults(snapshotSchedule)
+	return snapshotSchedule
+}
+
+func TestCloudSnapshotDefaults(t *testing.T) {
+	snapshotSchedule := newCloudSnapshotSchedule(nil)
+	require.Equal(t, stork_api.VolumeSnapshotTypeStork, snapshotSchedule.Spec.SnapshotType)
+	require.Nil(t, snapshotSchedule.Spec.CloudSnapshot)
+
+	snapshotSchedule = newCloudSnapshotSchedule(&stork_api.CloudSnapshotSpec{Frequency: -1})
+	require.Equal(t, 1, snapshotSchedule.Spec.CloudSnapshot.Frequency)
+	require.Equal(t, stork_api.DefaultCloudSnapshotRetain, snapshotSchedule.Spec.CloudSnapshot.Retain)
+
+	snapshotSchedule = newCloudSnapshotSchedule(&stork_api.CloudSnapshotSpec{Frequency: 3, Retain: 2})
+	require.Equal(t, 3, snapshotSchedule.Spec.CloudSnapshot.Frequency)
+	require.Equal(t, stork_api.Retain(2), snapshotSchedule.Spec.CloudSnapshot.Retain)
+}
+
+func TestShouldStartCloudSnapshot(t *testing.T) {
+	s := &SnapshotScheduleController{}
+	daily := stork_api.SchedulePolicyTypeDaily
+	require.False(t, s.shouldStartCloudSnapshot(newCloudSnapshotSchedule(nil), daily))
+
+	// Every third snapshot is uploaded
+	snapshotSchedule := newCloudSnapshotSchedule(&stork_api.CloudSnapshotSpec{Frequency: 3})
+	snapshotSchedule.Status.NumTriggered = map[stork_api.SchedulePolicyType]int{daily: 1}
+	require.False(t, s.shouldStartCloudSnapshot(snapshotSchedule, daily))
+	snapshotSchedule.Status.NumTriggered[daily] = 3
+	require.True(t, s.shouldStartCloudSnapshot(snapshotSchedule, daily))
+
+	// Not started while the previous cloud snapshot is in progress
+	snapshotSchedule.Status.CloudItems = map[stork_api.SchedulePolicyType][]*stork_api.ScheduledVolumeSnapshotStatus{
+		daily: {{Name: "previous", Status: snapv1.VolumeSnapshotConditionPending}},
+	}
+	require.False(t, s.shouldStartCloudSnapshot(snapshotSchedule, daily))
+	snapshotSchedule.Status.CloudItems[daily][0].Status = snapv1.VolumeSnapshotConditionReady
+	require.True(t, s.shouldStartCloudSnapshot(snapshotSchedule, daily))
+
+	// Only supported for stork snapshots
+	snapshotSchedule.Spec.SnapshotType = stork_api.VolumeSnapshotTypeCSI
+	require.False(t, s.shouldStartCloudSnapshot(snapshotSchedule, daily))
+}
+
+func TestCreateCloudVolumeSnapshot(t *testing.T) {
+	store := newFakeSnapshotStore(t, "volumesnapshot.external-storage.k8s.io/v1")
+	setupGCClients(t, store)
+	s := &SnapshotScheduleController{}
+	snapshotSchedule := newCloudSnapshotSchedule(&stork_api.CloudSnapshotSpec{
+		Annotations: map[string]string{"portworx/snapshot-type": "cloud"},
+	})
+
+	require.NoError(t, s.createVolumeSnapshot(snapshotSchedule, stork_api.SchedulePolicyTypeDaily, "snap", nil))
+	require.NoError(t, s.createVolumeSnapshot(snapshotSchedule, stork_api.SchedulePolicyTypeDaily, "snap-cloud",
+		snapshotSchedule.Spec.CloudSnapshot.Annotations))
+	require.Equal(t, "local", store.annotations("default", "snap")["portworx/snapshot-type"])
+	// The cloud annotations override the annotations from the schedule
+	require.Equal(t, "cloud", store.annotations("default", "snap-cloud")["portworx/snapshot-type"])
+	// The annotations of the schedule aren't modified
+	require.Equal(t, "local", snapshotSchedule.Annotations["portworx/snapshot-type"])
+
+	labels := store.get("default", "snap-cloud")["metadata"].(map[string]interface{})["labels"].(map[string]interface{})
+	require.Equal(t, "schedule", labels[SnapshotScheduleNameLabel])
+	require.Equal(t, string(stork_api.SchedulePolicyTypeDaily), labels[SnapshotSchedulePolicyTypeLabel])
+	require.Equal(t, string(stork_api.ReclaimPolicyDelete), labels[SnapshotScheduleReclaimPolicyLabel])
+}
+
+func TestPruneCloudSnapshots(t *testing.T) {
+	store := newFakeSnapshotStore(t, "volumesnapshot.external-storage.k8s.io/v1",
+		newScheduledSnapshot("cloud-1", "schedule", "pvc", ""),
+		newScheduledSnapshot("cloud-2", "schedule", "pvc", ""),
+		newScheduledSnapshot("cloud-3", "schedule", "pvc", ""),
+		newScheduledSnapshot("cloud-4", "schedule", "pvc", ""),
+	)
+	setupGCClients(t, store)
+	s := &SnapshotScheduleController{}
+	snapshotSchedule := newCloudSnapshotSchedule(&stork_api.CloudSnapshotSpec{Retain: 2})
+
+	items := []*stork_api.ScheduledVolumeSnapshotStatus{
+		{Name: "cloud-1", Status: snapv1.VolumeSnapshotConditionReady},
+		{Name: "cloud-2", Status: snapv1.VolumeSnapshotConditionError},
+		{Name: "cloud-3", Status: snapv1.VolumeSnapshotConditionReady},
+		{Name: "cloud-4", Status: snapv1.VolumeSnapshotConditionReady},
+	}
+	// Nothing is deleted until more than retain snapshots are ready
+	require.Equal(t, items[:2], s.pruneVolumeSnapshotList(snapshotSchedule, items[:2], 2))
+
+	// Failed snapshots newer than the deleted ones are kept
+	remaining := s.pruneVolumeSnapshotList(snapshotSchedule, items, snapshotSchedule.Spec.CloudSnapshot.Retain)
+	require.Equal(t, items[1:], remaining)
+	require.Nil(t, store.get("default", "cloud-1"))
+	require.NotNil(t, store.get("default", "cloud-2"))
+	require.NotNil(t, store.get("default", "cloud-3"))
+	require.NotNil(t, store.get("default", "cloud-4"))
+}
+
+func TestUpdateChainLength(t *testing.T) {
+	s := &SnapshotScheduleController{}
+	daily := stork_api.SchedulePolicyTypeDaily
+	weekly := stork_api.SchedulePolicyTypeWeekly
+
+	// The driver decides when to take full snapshots without a frequency
+	snapshotSchedule := newCloudSnapshotSchedule(&stork_api.CloudSnapshotSpec{})
+	for i := 1; i <= 5; i++ {
+		require.False(t, s.updateChainLength(snapshotSchedule, daily))
+		require.Equal(t, i, snapshotSchedule.Status.ChainLength[daily])
+	}
+
+	// Every third cloud snapshot is a full snapshot, starting with the first
+	snapshotSchedule = newCloudSnapshotSchedule(&stork_api.CloudSnapshotSpec{FullSnapshotFrequency: 3})
+	full := make([]bool, 0)
+	for i := 0; i < 7; i++ {
+		full = append(full, s.updateChainLength(snapshotSchedule, daily))
+	}
+	require.Equal(t, []bool{true, false, false, true, false, false, true}, full)
+	require.Equal(t, 1, snapshotSchedule.Status.ChainLength[daily])
+
+	// Chains are tracked separately for each policy
+	require.True(t, s.updateChainLength(snapshotSchedule, weekly))
+	require.False(t, s.updateChainLength(snapshotSchedule, daily))
+	require.Equal(t, 1, snapshotSchedule.Status.ChainLength[weekly])
+	require.Equal(t, 2, snapshotSchedule.Status.ChainLength[daily])
+}
+
+func TestCreateFullCloudVolumeSnapshot(t *testing.T) {
+	store := newFakeSnapshotStore(t, "volumesnapshot.external-storage.k8s.io/v1")
+	setupGCClients(t, store)
+	s := &SnapshotScheduleController{}
+	snapshotSchedule := newCloudSnapshotSchedule(&stork_api.CloudSnapshotSpec{FullSnapshotFrequency: 2})
+
+	for _, name := range []string{"cloud-1", "cloud-2"} {
+		annotations := make(map[string]string)
+		if s.updateChainLength(snapshotSchedule, stork_api.SchedulePolicyTypeDaily) {
+			annotations[SnapshotFullAnnotation] = "true"
+		}
+		require.NoError(t, s.createVolumeSnapshot(snapshotSchedule, stork_api.SchedulePolicyTypeDaily, name, annotations))
+	}
+	require.Equal(t, "true", store.annotations("default", "cloud-1")[SnapshotFullAnnotation])
+	require.NotContains(t, store.annotations("default", "cloud-2"), SnapshotFullAnnotation)
+}
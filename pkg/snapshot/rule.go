@@ -1,7 +1,12 @@
 package snapshot
 
 import (
+	"encoding/json"
+	"strings"
+
 	crdv1 "github.com/kubernetes-incubator/external-storage/snapshot/pkg/apis/crd/v1"
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/log"
 	"github.com/libopenstorage/stork/pkg/rule"
 	"github.com/portworx/sched-ops/k8s"
 	"github.com/sirupsen/logrus"
@@ -15,6 +20,13 @@ const (
 	postSnapRuleAnnotationKey           = storkRuleAnnotationPrefix + "/post-snapshot-rule"
 	preSnapRuleAnnotationKeyDeprecated  = storkRuleAnnotationPrefixDeprecated + "/pre-snapshot"
 	postSnapRuleAnnotationKeyDeprecated = storkRuleAnnotationPrefixDeprecated + "/post-snapshot"
+	// RuleResultsAnnotationKey is the annotation used to record the results
+	// of the rules executed for a snapshot
+	RuleResultsAnnotationKey = storkRuleAnnotationPrefix + "/rule-results"
+	// RuleParameterAnnotationPrefix is the prefix for annotations used to
+	// pass parameters to built-in rule templates, for eg
+	// stork.libopenstorage.org/rule-param-user: root
+	RuleParameterAnnotationPrefix = storkRuleAnnotationPrefix + "/rule-param-"
 )
 
 var ruleAnnotationKeyTypes = map[string]rule.Type{
@@ -24,6 +36,18 @@ var ruleAnnotationKeyTypes = map[string]rule.Type{
 	postSnapRuleAnnotationKeyDeprecated: rule.PostExecRule,
 }
 
+// GetRuleNames returns the names of the rules set in the annotations of a
+// snapshot
+func GetRuleNames(annotations map[string]string) []string {
+	ruleNames := make([]string, 0)
+	for annotation := range ruleAnnotationKeyTypes {
+		if ruleName := annotations[annotation]; ruleName != "" {
+			ruleNames = append(ruleNames, ruleName)
+		}
+	}
+	return ruleNames
+}
+
 // validateSnapRules validates the rules if they are present in the given snapshot's annotations
 func validateSnapRules(snap *crdv1.VolumeSnapshot) error {
 	if snap.Metadata.Annotations != nil {
@@ -36,7 +60,7 @@ func validateSnapRules(snap *crdv1.VolumeSnapshot) error {
 		for _, annotation := range ruleAnnotations {
 			ruleName, present := snap.Metadata.Annotations[annotation]
 			if present && len(ruleName) > 0 {
-				r, err := k8s.Instance().GetRule(ruleName, snap.Metadata.Namespace)
+				r, err := rule.GetRule(ruleName, snap.Metadata.Namespace, ruleAnnotationKeyTypes[annotation], getRuleParameters(snap))
 				if err != nil {
 					return err
 				}
@@ -51,6 +75,31 @@ func validateSnapRules(snap *crdv1.VolumeSnapshot) error {
 	return nil
 }
 
+// getRuleParameters returns the parameters for rule templates from the
+// annotations of the snapshot
+func getRuleParameters(snap *crdv1.VolumeSnapshot) map[string]string {
+	parameters := make(map[string]string)
+	for k, v := range snap.Metadata.Annotations {
+		if strings.HasPrefix(k, RuleParameterAnnotationPrefix) {
+			parameters[strings.TrimPrefix(k, RuleParameterAnnotationPrefix)] = v
+		}
+	}
+	return parameters
+}
+
+// getExecuteParameters returns the parameters passed to the rules for the
+// snapshot
+func getExecuteParameters(snap *crdv1.VolumeSnapshot, pvcs []v1.PersistentVolumeClaim) *rule.Parameters {
+	parameters := &rule.Parameters{
+		PVCs:   make([]string, 0, len(pvcs)),
+		Values: getRuleParameters(snap),
+	}
+	for _, pvc := range pvcs {
+		parameters.PVCs = append(parameters.PVCs, pvc.Name)
+	}
+	return parameters
+}
+
 func setKind(snap *crdv1.VolumeSnapshot) {
 	snap.Kind = "VolumeSnapshot"
 	snap.APIVersion = crdv1.SchemeGroupVersion.String()
@@ -71,11 +120,14 @@ func ExecutePreSnapRule(snap *crdv1.VolumeSnapshot, pvcs []v1.PersistentVolumeCl
 				return nil, nil
 			}
 		}
-		r, err := k8s.Instance().GetRule(ruleName, snap.Metadata.Namespace)
+		r, err := rule.GetRule(ruleName, snap.Metadata.Namespace, rule.PreExecRule, getRuleParameters(snap))
 		if err != nil {
 			return nil, err
 		}
-		return rule.ExecuteRule(r, rule.PreExecRule, snap, snap.Metadata.Namespace)
+		backgroundCommandTermChan, results, err := rule.ExecuteRule(r, rule.PreExecRule, snap, snap.Metadata.Namespace,
+			getExecuteParameters(snap, pvcs))
+		recordRuleResults(snap, results, rule.PreExecRule)
+		return backgroundCommandTermChan, err
 	}
 	return nil, nil
 }
@@ -95,16 +147,51 @@ func ExecutePostSnapRule(pvcs []v1.PersistentVolumeClaim, snap *crdv1.VolumeSnap
 				return nil
 			}
 		}
-		r, err := k8s.Instance().GetRule(ruleName, snap.Metadata.Namespace)
+		r, err := rule.GetRule(ruleName, snap.Metadata.Namespace, rule.PostExecRule, getRuleParameters(snap))
 		if err != nil {
 			return err
 		}
-		_, err = rule.ExecuteRule(r, rule.PostExecRule, snap, snap.Metadata.Namespace)
+		_, results, err := rule.ExecuteRule(r, rule.PostExecRule, snap, snap.Metadata.Namespace,
+			getExecuteParameters(snap, pvcs))
+		recordRuleResults(snap, results, rule.PostExecRule)
 		return err
 	}
 	return nil
 }
 
+// recordRuleResults adds the results of the rule to the annotations of the
+// snapshot since the status of the snapshot can't be extended
+func recordRuleResults(snap *crdv1.VolumeSnapshot, results []*stork_api.RuleExecutionResult, rType rule.Type) {
+	if len(results) == 0 {
+		return
+	}
+	// Get the latest snapshot as ExecuteRule might have updated it
+	latest, err := k8s.Instance().GetSnapshot(snap.Metadata.Name, snap.Metadata.Namespace)
+	if err != nil {
+		log.SnapshotLog(snap).Warnf("Failed to get snapshot to update rule results: %v", err)
+		return
+	}
+	existing := make([]*stork_api.RuleExecutionResult, 0)
+	if latest.Metadata.Annotations != nil {
+		if value, present := latest.Metadata.Annotations[RuleResultsAnnotationKey]; present {
+			if err := json.Unmarshal([]byte(value), &existing); err != nil {
+				log.SnapshotLog(snap).Warnf("Failed to parse existing rule results: %v", err)
+			}
+		}
+	} else {
+		latest.Metadata.Annotations = make(map[string]string)
+	}
+	resultBytes, err := json.Marshal(rule.MergeRuleResults(existing, results, rType))
+	if err != nil {
+		log.SnapshotLog(snap).Warnf("Failed to marshal rule results: %v", err)
+		return
+	}
+	latest.Metadata.Annotations[RuleResultsAnnotationKey] = string(resultBytes)
+	if _, err := k8s.Instance().UpdateSnapshot(latest); err != nil {
+		log.SnapshotLog(snap).Warnf("Failed to update rule results: %v", err)
+	}
+}
+
 // performRuleRecovery terminates potential background commands running pods for
 // the given snapshot
 func performRuleRecovery() error {
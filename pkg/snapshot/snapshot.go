@@ -3,10 +3,10 @@ package snapshot
 import (
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/kubernetes-incubator/external-storage/lib/controller"
 	"github.com/kubernetes-incubator/external-storage/snapshot/pkg/client"
-	snapshotvolume "github.com/kubernetes-incubator/external-storage/snapshot/pkg/volume"
 	"github.com/libopenstorage/stork/drivers/volume"
 	"github.com/libopenstorage/stork/pkg/snapshot/controllers"
 	"github.com/portworx/sched-ops/k8s"
@@ -28,9 +28,16 @@ type Snapshot struct {
 	started                    bool
 	snapshotController         *controllers.Snapshotter
 	snapshotScheduleController *controllers.SnapshotScheduleController
+	snapshotRestoreController  *controllers.SnapshotRestoreController
+	groupRestoreController     *controllers.GroupSnapshotRestoreController
+	snapshotGarbageCollector   *controllers.SnapshotGarbageCollector
 	provisioner                *controller.ProvisionController
 	Driver                     volume.Driver
 	Recorder                   record.EventRecorder
+	// GCGracePeriod is the time after which snapshots created by schedules
+	// are deleted once their schedule or PVC has been deleted. Orphaned
+	// snapshots aren't deleted if this is 0.
+	GCGracePeriod time.Duration
 }
 
 // GetProvisionerName Gets the name of the provisioner
@@ -86,10 +93,9 @@ func (s *Snapshot) Start() error {
 		return err
 	}
 
-	plugins := make(map[string]snapshotvolume.Plugin)
-	plugins[s.Driver.String()] = s.Driver.GetSnapshotPlugin()
+	plugins := volume.GetSnapshotPlugins(s.Driver)
 
-	snapProvisioner := controllers.NewSnapshotProvisioner(clientset, snapshotClient, plugins, snapshotProvisionerID)
+	snapProvisioner := controllers.NewSnapshotProvisioner(clientset, snapshotClient, plugins, s.Driver, snapshotProvisionerID)
 
 	s.provisioner = controller.NewProvisionController(
 		clientset,
@@ -114,6 +120,34 @@ func (s *Snapshot) Start() error {
 		return fmt.Errorf("error initializing snapshot schedule controller: %v", err)
 	}
 
+	// Start the snapshot restore controller
+	s.snapshotRestoreController = &controllers.SnapshotRestoreController{
+		Driver:     s.Driver,
+		Recorder:   s.Recorder,
+		KubeClient: clientset,
+	}
+	err = s.snapshotRestoreController.Init()
+	if err != nil {
+		return fmt.Errorf("error initializing snapshot restore controller: %v", err)
+	}
+
+	// Start the group snapshot restore controller
+	s.groupRestoreController = &controllers.GroupSnapshotRestoreController{
+		Recorder: s.Recorder,
+	}
+	err = s.groupRestoreController.Init()
+	if err != nil {
+		return fmt.Errorf("error initializing group snapshot restore controller: %v", err)
+	}
+
+	// Start the garbage collector for orphaned scheduled snapshots
+	if s.GCGracePeriod > 0 {
+		s.snapshotGarbageCollector = &controllers.SnapshotGarbageCollector{
+			GracePeriod: s.GCGracePeriod,
+		}
+		s.snapshotGarbageCollector.Start(s.stopChannel)
+	}
+
 	s.started = true
 	return nil
 }
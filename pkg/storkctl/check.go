@@ -0,0 +1,318 @@
+package storkctl
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/portworx/sched-ops/k8s"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/kubernetes/pkg/kubectl/cmd/util"
+	"k8s.io/kubernetes/pkg/kubectl/genericclioptions"
+)
+
+const drCheckSubcommand = "dr"
+
+// checkResult is the result of one of the pre-flight checks
+type checkResult struct {
+	name    string
+	passed  bool
+	details string
+}
+
+type drCheckOptions struct {
+	clusterPair    string
+	namespaces     []string
+	storkNamespace string
+}
+
+func newCheckCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	checkCommands := &cobra.Command{
+		Use:   "check",
+		Short: "Run pre-flight checks",
+	}
+
+	checkCommands.AddCommand(
+		newCheckDRCommand(cmdFactory, ioStreams),
+	)
+
+	return checkCommands
+}
+
+func newCheckDRCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	options := &drCheckOptions{}
+	checkDRCommand := &cobra.Command{
+		Use:   drCheckSubcommand,
+		Short: "Check that namespaces can be migrated to the cluster in a cluster pair",
+		Run: func(c *cobra.Command, args []string) {
+			if options.clusterPair == "" {
+				util.CheckErr(fmt.Errorf("ClusterPair name needs to be provided"))
+				return
+			}
+			if len(options.namespaces) == 0 {
+				util.CheckErr(fmt.Errorf("need to provide atleast one namespace to check"))
+				return
+			}
+			clusterPair, err := k8s.Instance().GetClusterPair(options.clusterPair, cmdFactory.GetNamespace())
+			if err != nil {
+				util.CheckErr(err)
+				return
+			}
+
+			results := runDRChecks(clusterPair, options)
+			if err := printCheckResults(results, ioStreams.Out); err != nil {
+				util.CheckErr(err)
+				return
+			}
+			failed := 0
+			for _, result := range results {
+				if !result.passed {
+					failed++
+				}
+			}
+			printMsg(fmt.Sprintf("%v/%v checks passed", len(results)-failed, len(results)), ioStreams.Out)
+			if failed != 0 {
+				util.CheckErr(fmt.Errorf("%v checks failed", failed))
+				return
+			}
+		},
+	}
+	checkDRCommand.Flags().StringVarP(&options.clusterPair, "clusterpair", "c", "", "ClusterPair to the destination cluster")
+	checkDRCommand.Flags().StringSliceVar(&options.namespaces, "namespaces", nil, "Comma separated list of namespaces to migrate")
+	checkDRCommand.Flags().StringVar(&options.storkNamespace, "stork-namespace", defaultStorkNamespace, "Namespace where stork is running on both clusters")
+
+	return checkDRCommand
+}
+
+// runDRChecks runs the checks for migrating the namespaces using the cluster
+// pair. The checks that need the destination cluster fail if it can't be
+// reached.
+func runDRChecks(clusterPair *storkv1.ClusterPair, options *drCheckOptions) []checkResult {
+	results := make([]checkResult, 0)
+	results = append(results, checkClusterPairStatus("ClusterPair", clusterPair.Status.SchedulerStatus,
+		fmt.Sprintf("scheduler status for %v is %v", clusterPair.Name, clusterPair.Status.SchedulerStatus)))
+
+	destOps, err := getClusterOps(clusterPair.Spec.Config)
+	var serverVersion *version.Info
+	if err == nil {
+		serverVersion, err = destOps.GetVersion()
+	}
+	reachable := err == nil
+	if !reachable {
+		results = append(results, checkResult{
+			name:    "Connectivity",
+			details: fmt.Sprintf("error connecting to destination cluster: %v", err),
+		})
+		for _, name := range []string{"CRDs", "RBAC"} {
+			results = append(results, skippedCheck(name))
+		}
+	} else {
+		results = append(results,
+			checkResult{
+				name:    "Connectivity",
+				passed:  true,
+				details: fmt.Sprintf("destination cluster is running Kubernetes %v", serverVersion.GitVersion),
+			},
+			checkDestinationCRDs(destOps),
+			checkDestinationRBAC(destOps, options.namespaces),
+		)
+	}
+
+	results = append(results, checkSourceNamespaces(options.namespaces))
+
+	storageDetails := fmt.Sprintf("storage status for %v is %v", clusterPair.Name, clusterPair.Status.StorageStatus)
+	if clusterPair.Status.RemoteStorageID != "" {
+		storageDetails += fmt.Sprintf(", paired with %v", clusterPair.Status.RemoteStorageID)
+	}
+	results = append(results, checkClusterPairStatus("Storage pairing", clusterPair.Status.StorageStatus, storageDetails))
+
+	if !reachable {
+		results = append(results, skippedCheck("Stork versions"))
+	} else {
+		results = append(results, checkStorkVersions(destOps, options.storkNamespace))
+	}
+	return results
+}
+
+func skippedCheck(name string) checkResult {
+	return checkResult{
+		name:    name,
+		details: "skipped since the destination cluster can't be reached",
+	}
+}
+
+func checkClusterPairStatus(name string, status storkv1.ClusterPairStatusType, details string) checkResult {
+	return checkResult{
+		name:    name,
+		passed:  status == storkv1.ClusterPairStatusReady,
+		details: details,
+	}
+}
+
+// checkDestinationCRDs checks that the stork resources needed to activate the
+// migrated applications and to fail back are available on the destination
+// cluster
+func checkDestinationCRDs(destOps k8s.Ops) checkResult {
+	missing := make([]string, 0)
+	for _, crd := range []struct {
+		name string
+		list func() error
+	}{
+		{"migrations", func() error { _, err := destOps.ListMigrations(""); return err }},
+		{"migrationschedules", func() error { _, err := destOps.ListMigrationSchedules(""); return err }},
+		{"clusterpairs", func() error { _, err := destOps.ListClusterPairs(""); return err }},
+		{"schedulepolicies", func() error { _, err := destOps.ListSchedulePolicies(); return err }},
+	} {
+		if err := crd.list(); err != nil {
+			if errors.IsNotFound(err) {
+				missing = append(missing, crd.name)
+				continue
+			}
+			return checkResult{
+				name:    "CRDs",
+				details: fmt.Sprintf("error listing %v on destination cluster: %v", crd.name, err),
+			}
+		}
+	}
+	if len(missing) != 0 {
+		return checkResult{
+			name:    "CRDs",
+			details: fmt.Sprintf("%v not found on destination cluster, is stork installed?", strings.Join(missing, ",")),
+		}
+	}
+	return checkResult{
+		name:    "CRDs",
+		passed:  true,
+		details: "stork CRDs are installed on destination cluster",
+	}
+}
+
+// checkDestinationRBAC checks that the credentials in the cluster pair can be
+// used to access the namespaces and applications on the destination cluster
+func checkDestinationRBAC(destOps k8s.Ops, namespaces []string) checkResult {
+	if _, err := destOps.ListNamespaces(); err != nil {
+		return checkResult{
+			name:    "RBAC",
+			details: fmt.Sprintf("error listing namespaces on destination cluster: %v", err),
+		}
+	}
+	for _, namespace := range namespaces {
+		if _, err := destOps.ListDeployments(namespace); err != nil && !errors.IsNotFound(err) {
+			return checkResult{
+				name:    "RBAC",
+				details: fmt.Sprintf("error listing deployments in %v on destination cluster: %v", namespace, err),
+			}
+		}
+	}
+	return checkResult{
+		name:    "RBAC",
+		passed:  true,
+		details: "cluster pair credentials can access destination cluster",
+	}
+}
+
+func checkSourceNamespaces(namespaces []string) checkResult {
+	missing := make([]string, 0)
+	for _, namespace := range namespaces {
+		if _, err := k8s.Instance().GetNamespace(namespace); err != nil {
+			if errors.IsNotFound(err) {
+				missing = append(missing, namespace)
+				continue
+			}
+			return checkResult{
+				name:    "Namespaces",
+				details: fmt.Sprintf("error getting namespace %v: %v", namespace, err),
+			}
+		}
+	}
+	if len(missing) != 0 {
+		return checkResult{
+			name:    "Namespaces",
+			details: fmt.Sprintf("namespaces %v not found", strings.Join(missing, ",")),
+		}
+	}
+	return checkResult{
+		name:    "Namespaces",
+		passed:  true,
+		details: fmt.Sprintf("namespaces %v found", strings.Join(namespaces, ",")),
+	}
+}
+
+// checkStorkVersions checks that the same version of stork, which also serves
+// the admission webhook, is running on both clusters
+func checkStorkVersions(destOps k8s.Ops, storkNamespace string) checkResult {
+	sourceImages, err := getStorkImages(k8s.Instance(), storkNamespace)
+	if err != nil {
+		return checkResult{
+			name:    "Stork versions",
+			details: fmt.Sprintf("error getting stork pods: %v", err),
+		}
+	}
+	destImages, err := getStorkImages(destOps, storkNamespace)
+	if err != nil {
+		return checkResult{
+			name:    "Stork versions",
+			details: fmt.Sprintf("error getting stork pods on destination cluster: %v", err),
+		}
+	}
+	if len(sourceImages) == 0 || len(destImages) == 0 {
+		return checkResult{
+			name:    "Stork versions",
+			details: fmt.Sprintf("stork pods not found in %v on both clusters", storkNamespace),
+		}
+	}
+	if strings.Join(sourceImages, ",") != strings.Join(destImages, ",") {
+		return checkResult{
+			name: "Stork versions",
+			details: fmt.Sprintf("source cluster is running %v, destination cluster is running %v",
+				strings.Join(sourceImages, ","), strings.Join(destImages, ",")),
+		}
+	}
+	return checkResult{
+		name:    "Stork versions",
+		passed:  true,
+		details: fmt.Sprintf("both clusters are running %v", strings.Join(sourceImages, ",")),
+	}
+}
+
+// getStorkImages returns the sorted images for the stork pods
+func getStorkImages(ops k8s.Ops, storkNamespace string) ([]string, error) {
+	pods, err := ops.GetPods(storkNamespace, map[string]string{"name": "stork"})
+	if err != nil {
+		return nil, err
+	}
+	images := make(map[string]bool)
+	for _, pod := range pods.Items {
+		for _, container := range pod.Spec.Containers {
+			images[container.Image] = true
+		}
+	}
+	sortedImages := make([]string, 0, len(images))
+	for image := range images {
+		sortedImages = append(sortedImages, image)
+	}
+	sort.Strings(sortedImages)
+	return sortedImages, nil
+}
+
+func printCheckResults(results []checkResult, out io.Writer) error {
+	writer := tabwriter.NewWriter(out, 0, 8, 3, ' ', 0)
+	if _, err := fmt.Fprintln(writer, "CHECK\tRESULT\tDETAILS"); err != nil {
+		return err
+	}
+	for _, result := range results {
+		status := "FAIL"
+		if result.passed {
+			status = "PASS"
+		}
+		if _, err := fmt.Fprintf(writer, "%v\t%v\t%v\n", result.name, status, result.details); err != nil {
+			return err
+		}
+	}
+	return writer.Flush()
+}
@@ -0,0 +1,90 @@
+// +build unittest
+
+package storkctl
+
+import (
+	"fmt"
+	"testing"
+
+	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/portworx/sched-ops/k8s"
+	"github.com/stretchr/testify/require"
+	"k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+func TestCheckDR(t *testing.T) {
+	defer resetTest()
+	// The source and destination clusters are both served by the fake clients
+	getClusterOps = func(_ clientcmdapi.Config) (k8s.Ops, error) {
+		return k8s.Instance(), nil
+	}
+	defer func() { getClusterOps = newClusterOps }()
+	createClusterPairAndVerify(t, "pair1", "test")
+	serverVersion, err := k8s.Instance().GetVersion()
+	require.NoError(t, err, "Error getting version")
+	connectivity := "Connectivity      PASS     destination cluster is running Kubernetes " + serverVersion.GitVersion + "\n"
+
+	cmdArgs := []string{"check", "dr", "-n", "test", "--namespaces", "app"}
+	expected := "error: ClusterPair name needs to be provided"
+	testCommon(t, cmdArgs, nil, expected, true)
+
+	cmdArgs = []string{"check", "dr", "-n", "test", "-c", "pair1"}
+	expected = "error: need to provide atleast one namespace to check"
+	testCommon(t, cmdArgs, nil, expected, true)
+
+	cmdArgs = []string{"check", "dr", "-n", "test", "-c", "pair1", "--namespaces", "app"}
+	expected = "CHECK             RESULT   DETAILS\n" +
+		"ClusterPair       FAIL     scheduler status for pair1 is \n" +
+		connectivity +
+		"CRDs              PASS     stork CRDs are installed on destination cluster\n" +
+		"RBAC              PASS     cluster pair credentials can access destination cluster\n" +
+		"Namespaces        FAIL     namespaces app not found\n" +
+		"Storage pairing   FAIL     storage status for pair1 is \n" +
+		"Stork versions    FAIL     stork pods not found in kube-system on both clusters\n" +
+		"3/7 checks passed\n"
+	require.Equal(t, expected, runFailingCommand(t, cmdArgs, "error: 4 checks failed"))
+
+	clusterPair, err := k8s.Instance().GetClusterPair("pair1", "test")
+	require.NoError(t, err, "Error getting cluster pair")
+	clusterPair.Status = storkv1.ClusterPairStatus{
+		SchedulerStatus: storkv1.ClusterPairStatusReady,
+		StorageStatus:   storkv1.ClusterPairStatusReady,
+		RemoteStorageID: "remote-cluster",
+	}
+	_, err = k8s.Instance().UpdateClusterPair(clusterPair)
+	require.NoError(t, err, "Error updating cluster pair")
+	_, err = k8s.Instance().CreateNamespace("app", nil)
+	require.NoError(t, err, "Error creating namespace")
+	_, err = k8s.Instance().CreatePod(&v1.Pod{
+		ObjectMeta: meta.ObjectMeta{Name: "stork-1", Namespace: "kube-system", Labels: map[string]string{"name": "stork"}},
+		Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "stork", Image: "openstorage/stork:2.3.0"}}},
+	})
+	require.NoError(t, err, "Error creating pod")
+
+	expected = "CHECK             RESULT   DETAILS\n" +
+		"ClusterPair       PASS     scheduler status for pair1 is Ready\n" +
+		connectivity +
+		"CRDs              PASS     stork CRDs are installed on destination cluster\n" +
+		"RBAC              PASS     cluster pair credentials can access destination cluster\n" +
+		"Namespaces        PASS     namespaces app found\n" +
+		"Storage pairing   PASS     storage status for pair1 is Ready, paired with remote-cluster\n" +
+		"Stork versions    PASS     both clusters are running openstorage/stork:2.3.0\n" +
+		"7/7 checks passed\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+
+	getClusterOps = func(_ clientcmdapi.Config) (k8s.Ops, error) {
+		return nil, fmt.Errorf("connection refused")
+	}
+	expected = "CHECK             RESULT   DETAILS\n" +
+		"ClusterPair       PASS     scheduler status for pair1 is Ready\n" +
+		"Connectivity      FAIL     error connecting to destination cluster: connection refused\n" +
+		"CRDs              FAIL     skipped since the destination cluster can't be reached\n" +
+		"RBAC              FAIL     skipped since the destination cluster can't be reached\n" +
+		"Namespaces        PASS     namespaces app found\n" +
+		"Storage pairing   PASS     storage status for pair1 is Ready, paired with remote-cluster\n" +
+		"Stork versions    FAIL     skipped since the destination cluster can't be reached\n" +
+		"3/7 checks passed\n"
+	require.Equal(t, expected, runFailingCommand(t, cmdArgs, "error: 4 checks failed"))
+}
@@ -15,6 +15,7 @@ import (
 var clusterDomainsStatusColumns = []string{"NAME", "ACTIVE", "INACTIVE", "CREATED"}
 var clusterDomainsStatusSubcommand = "clusterdomainsstatus"
 var clusterDomainsStatusAliases = []string{"cds"}
+var clusterDomainColumns = []string{"NAME", "STATUS"}
 
 func newGetClusterDomainsStatusCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
 	getClusterDomainsStatusCommand := &cobra.Command{
@@ -41,11 +42,7 @@ func newGetClusterDomainsStatusCommand(cmdFactory Factory, ioStreams genericclio
 				}
 			}
 
-			if len(cdStatuses.Items) == 0 {
-				handleEmptyList(ioStreams.Out)
-				return
-			}
-			if err := printObjects(c, cdStatuses, cmdFactory, clusterDomainsStatusColumns, clusterDomainsStatusPrinter, ioStreams.Out); err != nil {
+			if err := printObjects(c, cdStatuses, cmdFactory, clusterDomainsStatusColumns, nil, clusterDomainsStatusPrinter, ioStreams.Out); err != nil {
 				util.CheckErr(err)
 				return
 			}
@@ -55,6 +52,87 @@ func newGetClusterDomainsStatusCommand(cmdFactory Factory, ioStreams genericclio
 	return getClusterDomainsStatusCommand
 }
 
+func newGetClusterDomainCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	getClusterDomainCommand := &cobra.Command{
+		Use:     clusterDomainSubcommand,
+		Aliases: append([]string{clusterDomainSubcommand + "s"}, clusterDomainAliases...),
+		Short:   "Get cluster domains and whether they are active",
+		Run: func(c *cobra.Command, args []string) {
+			cdStatuses, err := k8s.Instance().ListClusterDomainStatuses()
+			if err != nil {
+				util.CheckErr(err)
+				return
+			}
+			if len(args) > 0 {
+				cdStatuses, err = filterClusterDomains(cdStatuses, args)
+				if err != nil {
+					util.CheckErr(err)
+					return
+				}
+			}
+
+			if err := printObjects(c, cdStatuses, cmdFactory, clusterDomainColumns, nil, clusterDomainPrinter, ioStreams.Out); err != nil {
+				util.CheckErr(err)
+				return
+			}
+		},
+	}
+	return getClusterDomainCommand
+}
+
+// filterClusterDomains returns the statuses with only the cluster domains
+// that are in the list of names
+func filterClusterDomains(cdStatuses *storkv1.ClusterDomainsStatusList, names []string) (*storkv1.ClusterDomainsStatusList, error) {
+	filter := func(clusterDomains []string, found map[string]bool) []string {
+		filtered := make([]string, 0)
+		for _, clusterDomain := range clusterDomains {
+			for _, name := range names {
+				if clusterDomain == name {
+					filtered = append(filtered, clusterDomain)
+					found[name] = true
+				}
+			}
+		}
+		return filtered
+	}
+	found := make(map[string]bool)
+	filteredStatuses := cdStatuses.DeepCopy()
+	for i := range filteredStatuses.Items {
+		filteredStatuses.Items[i].Status.Active = filter(filteredStatuses.Items[i].Status.Active, found)
+		filteredStatuses.Items[i].Status.Inactive = filter(filteredStatuses.Items[i].Status.Inactive, found)
+	}
+	for _, name := range names {
+		if !found[name] {
+			return nil, fmt.Errorf("cluster domain %v not found", name)
+		}
+	}
+	return filteredStatuses, nil
+}
+
+func clusterDomainPrinter(cdsList *storkv1.ClusterDomainsStatusList, writer io.Writer, options printers.PrintOptions) error {
+	if cdsList == nil {
+		return nil
+	}
+
+	for _, cds := range cdsList.Items {
+		for _, clusterDomains := range []struct {
+			names  []string
+			status string
+		}{
+			{cds.Status.Active, "Active"},
+			{cds.Status.Inactive, "Inactive"},
+		} {
+			for _, clusterDomain := range clusterDomains.names {
+				name := printers.FormatResourceName(options.Kind, clusterDomain, options.WithKind)
+				if _, err := fmt.Fprintf(writer, "%v\t%v\n", name, clusterDomains.status); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
 func clusterDomainsStatusPrinter(cdsList *storkv1.ClusterDomainsStatusList, writer io.Writer, options printers.PrintOptions) error {
 	if cdsList == nil {
 		return nil
@@ -93,3 +93,28 @@ func TestGetClusterDomainsStatusNotFound(t *testing.T) {
 	expected := `Error from server (NotFound): clusterdomainsstatuses.stork.libopenstorage.org "test2" not found`
 	testCommon(t, cmdArgs, nil, expected, true)
 }
+
+func TestGetClusterDomains(t *testing.T) {
+	defer resetTest()
+	cmdArgs := []string{"get", "clusterdomains"}
+	expected := "No resources found.\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+
+	createClusterDomainsStatus(t, "test1")
+	expected = "NAME      STATUS\n" +
+		"zone1     Active\n" +
+		"zone2     Active\n" +
+		"zone3     Inactive\n" +
+		"zone4     Inactive\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+
+	cmdArgs = []string{"get", "cd", "zone4", "zone2"}
+	expected = "NAME      STATUS\n" +
+		"zone2     Active\n" +
+		"zone4     Inactive\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+
+	cmdArgs = []string{"get", "clusterdomain", "zone5"}
+	expected = "error: cluster domain zone5 not found"
+	testCommon(t, cmdArgs, nil, expected, true)
+}
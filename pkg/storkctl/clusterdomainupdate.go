@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"io"
 	"strconv"
+	"strings"
+	"time"
 
 	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
 	"github.com/pborman/uuid"
@@ -21,9 +23,23 @@ var clusterDomainUpdateAliases = []string{"cdu"}
 var clusterDomainSubcommand = "clusterdomain"
 var clusterDomainAliases = []string{"cd"}
 
+type clusterDomainUpdateOptions struct {
+	name             string
+	skipConfirmation bool
+	wait             bool
+	timeout          time.Duration
+}
+
+func (o *clusterDomainUpdateOptions) addFlags(c *cobra.Command, action string) {
+	c.Flags().StringVar(&o.name, "name", "", fmt.Sprintf("Name for the %v cluster domain action", action))
+	c.Flags().BoolVarP(&o.skipConfirmation, "yes", "y", false, "Don't prompt for confirmation")
+	c.Flags().BoolVar(&o.wait, "wait", false, fmt.Sprintf("Wait for the cluster domains to be %vd", action))
+	c.Flags().DurationVar(&o.timeout, "timeout", defaultWaitTimeout, "Time to wait for each cluster domain if --wait is set")
+}
+
 func newActivateClusterDomainCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
 	var allClusterDomains bool
-	var nameClusterDomainUpdate string
+	options := &clusterDomainUpdateOptions{}
 	activateClusterDomainCommand := &cobra.Command{
 		Use:     clusterDomainSubcommand,
 		Aliases: clusterDomainAliases,
@@ -46,86 +62,129 @@ func newActivateClusterDomainCommand(cmdFactory Factory, ioStreams genericcliopt
 				util.CheckErr(fmt.Errorf("exactly one cluster domain name needs to be provided to the activate command"))
 				return
 			}
-			updateUUID := uuid.New()
-			for i, clusterDomainName := range activationList {
-				var name string
-				if len(nameClusterDomainUpdate) > 0 {
-					if len(activationList) > 1 {
-						name = nameClusterDomainUpdate + "-" + strconv.FormatInt(int64(i), 10)
-					} else {
-						name = nameClusterDomainUpdate
-					}
-				} else {
-					if len(activationList) > 1 {
-						name = updateUUID + "-" + strconv.FormatInt(int64(i), 10)
-					} else {
-						name = updateUUID
-					}
-				}
-				clusterDomainUpdate := &storkv1.ClusterDomainUpdate{
-					ObjectMeta: meta.ObjectMeta{
-						Name: name,
-					},
-					Spec: storkv1.ClusterDomainUpdateSpec{
-						ClusterDomain: clusterDomainName,
-						Active:        true,
-					},
-				}
-				_, err := k8s.Instance().CreateClusterDomainUpdate(clusterDomainUpdate)
-				if err != nil {
-					util.CheckErr(fmt.Errorf("failed to activate cluster domain %v: %v", clusterDomainName, err))
-					return
-				}
-				msg := fmt.Sprintf("Cluster Domain %v activated successfully", clusterDomainName)
-				printMsg(msg, ioStreams.Out)
+			if err := updateClusterDomains(activationList, true, options, ioStreams); err != nil {
+				util.CheckErr(err)
+				return
 			}
 		},
 	}
 	activateClusterDomainCommand.Flags().BoolVarP(&allClusterDomains, "all", "a", false, "Activate all inactive cluster domains")
-	activateClusterDomainCommand.Flags().StringVar(&nameClusterDomainUpdate, "name", "", "Name for the activate cluster domain action")
+	options.addFlags(activateClusterDomainCommand, "activate")
 
 	return activateClusterDomainCommand
 }
 
 func newDeactivateClusterDomainCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
-	var nameClusterDomainUpdate string
+	options := &clusterDomainUpdateOptions{}
 	deactivateClusterDomainCommand := &cobra.Command{
 		Use:     clusterDomainSubcommand,
 		Aliases: clusterDomainAliases,
 		Short:   "Deactivate a cluster domain",
 		Run: func(c *cobra.Command, args []string) {
-			if len(args) == 1 {
-				name := uuid.New()
-				if len(nameClusterDomainUpdate) > 0 {
-					name = nameClusterDomainUpdate
-				}
-				clusterDomainName := args[0]
-				clusterDomainUpdate := &storkv1.ClusterDomainUpdate{
-					ObjectMeta: meta.ObjectMeta{
-						Name: name,
-					},
-					Spec: storkv1.ClusterDomainUpdateSpec{
-						ClusterDomain: clusterDomainName,
-						Active:        false,
-					},
-				}
-				_, err := k8s.Instance().CreateClusterDomainUpdate(clusterDomainUpdate)
-				if err != nil {
-					util.CheckErr(fmt.Errorf("failed to deactivate cluster domain %v: %v", clusterDomainName, err))
-					return
-				}
-				msg := fmt.Sprintf("Cluster Domain %v deactivated successfully", clusterDomainName)
-				printMsg(msg, ioStreams.Out)
-			} else {
+			if len(args) != 1 {
 				util.CheckErr(fmt.Errorf("exactly one cluster domain name needs to be provided to the deactivate command"))
 				return
 			}
+			if err := updateClusterDomains(args, false, options, ioStreams); err != nil {
+				util.CheckErr(err)
+				return
+			}
 		},
 	}
-	deactivateClusterDomainCommand.Flags().StringVar(&nameClusterDomainUpdate, "name", "", "Name for the deactivate cluster domain action")
+	options.addFlags(deactivateClusterDomainCommand, "deactivate")
 	return deactivateClusterDomainCommand
 }
 
+// updateClusterDomains creates the ClusterDomainUpdates to activate or
+// deactivate the cluster domains after confirming with the user, and
+// optionally waits for them to complete
+func updateClusterDomains(
+	clusterDomains []string,
+	active bool,
+	options *clusterDomainUpdateOptions,
+	ioStreams genericclioptions.IOStreams,
+) error {
+	action := "deactivate"
+	if active {
+		action = "activate"
+	}
+	if len(clusterDomains) == 0 {
+		printMsg(fmt.Sprintf("No cluster domains to %v", action), ioStreams.Out)
+		return nil
+	}
+	if !options.skipConfirmation {
+		confirmed, err := confirm(fmt.Sprintf("%v cluster domains %v?", strings.Title(action), clusterDomains), ioStreams)
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			return fmt.Errorf("aborted, no cluster domains were updated")
+		}
+	}
+
+	updateUUID := uuid.New()
+	updateNames := make([]string, 0)
+	for i, clusterDomainName := range clusterDomains {
+		var name string
+		if len(options.name) > 0 {
+			if len(clusterDomains) > 1 {
+				name = options.name + "-" + strconv.FormatInt(int64(i), 10)
+			} else {
+				name = options.name
+			}
+		} else {
+			if len(clusterDomains) > 1 {
+				name = updateUUID + "-" + strconv.FormatInt(int64(i), 10)
+			} else {
+				name = updateUUID
+			}
+		}
+		clusterDomainUpdate := &storkv1.ClusterDomainUpdate{
+			ObjectMeta: meta.ObjectMeta{
+				Name: name,
+			},
+			Spec: storkv1.ClusterDomainUpdateSpec{
+				ClusterDomain: clusterDomainName,
+				Active:        active,
+			},
+		}
+		_, err := k8s.Instance().CreateClusterDomainUpdate(clusterDomainUpdate)
+		if err != nil {
+			return fmt.Errorf("failed to %v cluster domain %v: %v", action, clusterDomainName, err)
+		}
+		msg := fmt.Sprintf("Cluster Domain %v %vd successfully", clusterDomainName, action)
+		printMsg(msg, ioStreams.Out)
+		updateNames = append(updateNames, name)
+	}
+
+	if !options.wait {
+		return nil
+	}
+	waitOptions := &waitOptions{
+		forStatus: string(storkv1.ClusterDomainUpdateStatusSuccessful),
+		timeout:   options.timeout,
+	}
+	for _, name := range updateNames {
+		updateName := name
+		err := waitForOperation("ClusterDomainUpdate", updateName, "", waitOptions, func() (*operationStatus, error) {
+			clusterDomainUpdate, err := k8s.Instance().GetClusterDomainUpdate(updateName)
+			if err != nil {
+				return nil, err
+			}
+			return &operationStatus{
+				status: string(clusterDomainUpdate.Status.Status),
+				final: clusterDomainUpdate.Status.Status == storkv1.ClusterDomainUpdateStatusSuccessful ||
+					clusterDomainUpdate.Status.Status == storkv1.ClusterDomainUpdateStatusFailed,
+				summary: clusterDomainUpdate.Status.Reason,
+			}, nil
+		}, ioStreams)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func newGetClusterDomainUpdateCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
 	getClusterDomainUpdateCommand := &cobra.Command{
 		Use:     clusterDomainUpdateSubcommand,
@@ -151,11 +210,7 @@ func newGetClusterDomainUpdateCommand(cmdFactory Factory, ioStreams genericcliop
 				}
 			}
 
-			if len(cdStatuses.Items) == 0 {
-				handleEmptyList(ioStreams.Out)
-				return
-			}
-			if err := printObjects(c, cdStatuses, cmdFactory, clusterDomainUpdateColumns, clusterDomainUpdatePrinter, ioStreams.Out); err != nil {
+			if err := printObjects(c, cdStatuses, cmdFactory, clusterDomainUpdateColumns, nil, clusterDomainUpdatePrinter, ioStreams.Out); err != nil {
 				util.CheckErr(err)
 				return
 			}
@@ -5,10 +5,12 @@ package storkctl
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
 	"github.com/portworx/sched-ops/k8s"
 	"github.com/stretchr/testify/require"
+	"k8s.io/kubernetes/pkg/kubectl/genericclioptions"
 )
 
 func createClusterDomainUpdate(t *testing.T, name string, clusterDomain string, active bool) *storkv1.ClusterDomainUpdate {
@@ -16,10 +18,10 @@ func createClusterDomainUpdate(t *testing.T, name string, clusterDomain string,
 	var cmdArgs []string
 	var expected string
 	if active {
-		cmdArgs = []string{"activate", "clusterdomain", "--name", name, clusterDomain}
+		cmdArgs = []string{"activate", "clusterdomain", "-y", "--name", name, clusterDomain}
 		expected = fmt.Sprintf("Cluster Domain %v activated successfully\n", clusterDomain)
 	} else {
-		cmdArgs = []string{"deactivate", "clusterdomain", "--name", name, clusterDomain}
+		cmdArgs = []string{"deactivate", "clusterdomain", "-y", "--name", name, clusterDomain}
 		expected = fmt.Sprintf("Cluster Domain %v deactivated successfully\n", clusterDomain)
 	}
 
@@ -99,7 +101,7 @@ func TestGetClusterDomainUpdateNotFound(t *testing.T) {
 func TestActivateClusterDomain(t *testing.T) {
 	defer resetTest()
 	name := "zone2"
-	cmdArgs := []string{"activate", "clusterdomain", name}
+	cmdArgs := []string{"activate", "clusterdomain", "-y", name}
 
 	expected := "Cluster Domain zone2 activated successfully\n"
 	testCommon(t, cmdArgs, nil, expected, false)
@@ -115,7 +117,7 @@ func TestActivateClusterDomain(t *testing.T) {
 func TestActivateClusterDomainWithName(t *testing.T) {
 	defer resetTest()
 	name := "zone2"
-	cmdArgs := []string{"activate", "clusterdomain", name, "--name", "testupdate1"}
+	cmdArgs := []string{"activate", "clusterdomain", "-y", name, "--name", "testupdate1"}
 
 	expected := "Cluster Domain zone2 activated successfully\n"
 	testCommon(t, cmdArgs, nil, expected, false)
@@ -139,7 +141,7 @@ func TestActivateAllClusterDomain(t *testing.T) {
 	defer resetTest()
 	createClusterDomainsStatus(t, "test1")
 
-	cmdArgs := []string{"activate", "clusterdomain", "--all"}
+	cmdArgs := []string{"activate", "clusterdomain", "-y", "--all"}
 
 	expected := "Cluster Domain zone3 activated successfully\nCluster Domain zone4 activated successfully\n"
 	testCommon(t, cmdArgs, nil, expected, false)
@@ -163,7 +165,7 @@ func TestActivateAllClusterDomainWithName(t *testing.T) {
 	defer resetTest()
 	createClusterDomainsStatus(t, "test1")
 
-	cmdArgs := []string{"activate", "clusterdomain", "--all", "--name", "testupdate1"}
+	cmdArgs := []string{"activate", "clusterdomain", "-y", "--all", "--name", "testupdate1"}
 
 	expected := "Cluster Domain zone3 activated successfully\nCluster Domain zone4 activated successfully\n"
 	testCommon(t, cmdArgs, nil, expected, false)
@@ -185,7 +187,7 @@ func TestActivateAllClusterDomainWithName(t *testing.T) {
 func TestDeactivateClusterDomain(t *testing.T) {
 	defer resetTest()
 	name := "zone2"
-	cmdArgs := []string{"deactivate", "clusterdomain", name}
+	cmdArgs := []string{"deactivate", "clusterdomain", "-y", name}
 
 	expected := "Cluster Domain zone2 deactivated successfully\n"
 	testCommon(t, cmdArgs, nil, expected, false)
@@ -201,7 +203,7 @@ func TestDeactivateClusterDomain(t *testing.T) {
 func TestDeactivateClusterDomainWithName(t *testing.T) {
 	defer resetTest()
 	name := "zone2"
-	cmdArgs := []string{"deactivate", "clusterdomain", name, "--name", "testupdate1"}
+	cmdArgs := []string{"deactivate", "clusterdomain", "-y", name, "--name", "testupdate1"}
 
 	expected := "Cluster Domain zone2 deactivated successfully\n"
 	testCommon(t, cmdArgs, nil, expected, false)
@@ -220,3 +222,35 @@ func TestDeactivateClusterDomainNoDomainSpecified(t *testing.T) {
 	expected := "error: exactly one cluster domain name needs to be provided to the deactivate command"
 	testCommon(t, cmdArgs, nil, expected, true)
 }
+
+func TestActivateClusterDomainConfirmation(t *testing.T) {
+	defer resetTest()
+	cmdArgs := []string{"activate", "clusterdomain", "zone2"}
+	expected := "error: error reading confirmation: EOF"
+	testCommon(t, cmdArgs, nil, expected, true)
+
+	options := &clusterDomainUpdateOptions{name: "testupdate1"}
+	streams, in, out, _ := genericclioptions.NewTestIOStreams()
+	in.WriteString("n\n")
+	err := updateClusterDomains([]string{"zone2"}, true, options, streams)
+	require.EqualError(t, err, "aborted, no cluster domains were updated")
+	require.Equal(t, "Activate cluster domains [zone2]? (y/n): ", out.String())
+	cdus, err := k8s.Instance().ListClusterDomainUpdates()
+	require.NoError(t, err, "Error listing ClusterDomainUpdate")
+	require.Empty(t, cdus.Items, "ClusterDomainUpdate shouldn't be created when aborted")
+
+	streams, in, out, _ = genericclioptions.NewTestIOStreams()
+	in.WriteString("y\n")
+	require.NoError(t, updateClusterDomains([]string{"zone2"}, true, options, streams))
+	require.Equal(t, "Activate cluster domains [zone2]? (y/n): Cluster Domain zone2 activated successfully\n", out.String())
+	_, err = k8s.Instance().GetClusterDomainUpdate("testupdate1")
+	require.NoError(t, err, "Error getting ClusterDomainUpdate")
+}
+
+func TestDeactivateClusterDomainWait(t *testing.T) {
+	defer resetTest()
+	waitPollInterval = time.Millisecond
+	cmdArgs := []string{"deactivate", "clusterdomain", "-y", "zone2", "--name", "testupdate1", "--wait", "--timeout", "5ms"}
+	expected := "timed out waiting for ClusterDomainUpdate testupdate1, stage: , status: "
+	testWaitExitCode(t, cmdArgs, expected, waitExitCodeTimeout)
+}
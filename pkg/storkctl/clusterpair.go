@@ -8,11 +8,19 @@ import (
 	"os"
 	"reflect"
 	"strings"
+	"time"
 
 	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
 	"github.com/portworx/sched-ops/k8s"
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 	"k8s.io/kubernetes/pkg/kubectl/cmd/util"
 	"k8s.io/kubernetes/pkg/kubectl/genericclioptions"
 	"k8s.io/kubernetes/pkg/printers"
@@ -23,9 +31,20 @@ const (
 	cmdPathKey            = "cmd-path"
 	gcloudPath            = "./google-cloud-sdk/bin/gcloud"
 	gcloudBinaryName      = "gcloud"
+
+	defaultClusterPairServiceAccount = "stork-clusterpair"
+	clusterPairRoleName              = "cluster-admin"
+	pxctlPath                        = "/opt/pwx/bin/pxctl"
+	pxTokenOption                    = "token"
+	pxIPOption                       = "ip"
+	pxPortOption                     = "port"
+	defaultPxPort                    = "9001"
+	tokenRetryInterval               = 2 * time.Second
+	tokenTimeout                     = time.Minute
 )
 
 var clusterPairColumns = []string{"NAME", "STORAGE-STATUS", "SCHEDULER-STATUS", "CREATED"}
+var clusterPairWideColumns = []string{"REMOTE-STORAGE-ID"}
 
 func newGetClusterPairCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
 	getClusterPairCommand := &cobra.Command{
@@ -66,12 +85,7 @@ func newGetClusterPairCommand(cmdFactory Factory, ioStreams genericclioptions.IO
 				clusterPairs = &tempClusterPairs
 			}
 
-			if len(clusterPairs.Items) == 0 {
-				handleEmptyList(ioStreams.Out)
-				return
-			}
-
-			if err := printObjects(c, clusterPairs, cmdFactory, clusterPairColumns, clusterPairPrinter, ioStreams.Out); err != nil {
+			if err := printObjects(c, clusterPairs, cmdFactory, clusterPairColumns, clusterPairWideColumns, clusterPairPrinter, ioStreams.Out); err != nil {
 				util.CheckErr(err)
 				return
 			}
@@ -96,13 +110,21 @@ func clusterPairPrinter(clusterPairList *storkv1.ClusterPairList, writer io.Writ
 		name := printers.FormatResourceName(options.Kind, clusterPair.Name, options.WithKind)
 
 		creationTime := toTimeString(clusterPair.CreationTimestamp.Time)
-		if _, err := fmt.Fprintf(writer, "%v\t%v\t%v\t%v\n",
+		if _, err := fmt.Fprintf(writer, "%v\t%v\t%v\t%v",
 			name,
 			clusterPair.Status.StorageStatus,
 			clusterPair.Status.SchedulerStatus,
 			creationTime); err != nil {
 			return err
 		}
+		if options.Wide {
+			if _, err := fmt.Fprintf(writer, "\t%v", clusterPair.Status.RemoteStorageID); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintln(writer); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -128,6 +150,80 @@ func getByteData(fileName string) ([]byte, error) {
 	return []byte(data), nil
 }
 
+// getClusterPairConfig returns a copy of the config with only the current
+// context and the cluster and user for it, with all the files used by them
+// inlined so that the config can be used from another cluster
+func getClusterPairConfig(config clientcmdapi.Config) (clientcmdapi.Config, error) {
+	var err error
+	currentContext := config.CurrentContext
+	if config.Contexts[currentContext] == nil {
+		return config, fmt.Errorf("context %v not found in config", currentContext)
+	}
+	for context := range config.Contexts {
+		if context != currentContext {
+			delete(config.Contexts, context)
+		}
+	}
+	currentCluster := config.Contexts[currentContext].Cluster
+	for cluster := range config.Clusters {
+		if cluster != currentCluster {
+			delete(config.Clusters, cluster)
+		}
+	}
+	currentAuthInfo := config.Contexts[currentContext].AuthInfo
+	for authInfo := range config.AuthInfos {
+		if authInfo != currentAuthInfo {
+			delete(config.AuthInfos, authInfo)
+		}
+	}
+
+	if config.AuthInfos[currentAuthInfo] != nil {
+		// Replace gcloud paths in the config
+		if config.AuthInfos[currentAuthInfo].AuthProvider != nil &&
+			config.AuthInfos[currentAuthInfo].AuthProvider.Config != nil {
+			if cmdPath, present := config.AuthInfos[currentAuthInfo].AuthProvider.Config[cmdPathKey]; present {
+				if strings.HasSuffix(cmdPath, gcloudBinaryName) {
+					config.AuthInfos[currentAuthInfo].AuthProvider.Config[cmdPathKey] = gcloudPath
+				}
+			}
+		}
+
+		// Replace file paths with inline data
+		if config.AuthInfos[currentAuthInfo].ClientCertificate != "" && len(config.AuthInfos[currentAuthInfo].ClientCertificateData) == 0 {
+			config.AuthInfos[currentAuthInfo].ClientCertificateData, err = getByteData(config.AuthInfos[currentAuthInfo].ClientCertificate)
+			if err != nil {
+				return config, err
+			}
+			config.AuthInfos[currentAuthInfo].ClientCertificate = ""
+		}
+		if config.AuthInfos[currentAuthInfo].ClientKey != "" && len(config.AuthInfos[currentAuthInfo].ClientKeyData) == 0 {
+			config.AuthInfos[currentAuthInfo].ClientKeyData, err = getByteData(config.AuthInfos[currentAuthInfo].ClientKey)
+			if err != nil {
+				return config, err
+			}
+			config.AuthInfos[currentAuthInfo].ClientKey = ""
+		}
+		if config.AuthInfos[currentAuthInfo].TokenFile != "" && len(config.AuthInfos[currentAuthInfo].Token) == 0 {
+			config.AuthInfos[currentAuthInfo].Token, err = getStringData(config.AuthInfos[currentAuthInfo].TokenFile)
+			if err != nil {
+				return config, err
+			}
+			config.AuthInfos[currentAuthInfo].TokenFile = ""
+		}
+	}
+	if config.Clusters[currentCluster] != nil &&
+		config.Clusters[currentCluster].CertificateAuthority != "" &&
+		len(config.Clusters[currentCluster].CertificateAuthorityData) == 0 {
+
+		config.Clusters[currentCluster].CertificateAuthorityData, err = getByteData(config.Clusters[currentCluster].CertificateAuthority)
+		if err != nil {
+			return config, err
+		}
+		config.Clusters[currentCluster].CertificateAuthority = ""
+	}
+	return config, nil
+}
+
 func newGenerateClusterPairCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
 	generateClusterPairCommand := &cobra.Command{
 		Use:   clusterPairSubcommand,
@@ -137,107 +233,398 @@ func newGenerateClusterPairCommand(cmdFactory Factory, ioStreams genericclioptio
 				util.CheckErr(fmt.Errorf("exactly one name needs to be provided for clusterpair name"))
 				return
 			}
-			config, err := cmdFactory.RawConfig()
+			rawConfig, err := cmdFactory.RawConfig()
+			if err != nil {
+				util.CheckErr(err)
+				return
+			}
+			config, err := getClusterPairConfig(rawConfig)
 			if err != nil {
 				util.CheckErr(err)
 				return
 			}
 
-			// Prune out all but the current-context and related
-			// info
-			currentContext := config.CurrentContext
-			for context := range config.Contexts {
-				if context != currentContext {
-					delete(config.Contexts, context)
-				}
+			clusterPair := &storkv1.ClusterPair{
+				TypeMeta: meta.TypeMeta{
+					Kind:       reflect.TypeOf(storkv1.ClusterPair{}).Name(),
+					APIVersion: storkv1.SchemeGroupVersion.String(),
+				},
+				ObjectMeta: meta.ObjectMeta{
+					Name:      args[0],
+					Namespace: cmdFactory.GetNamespace(),
+				},
+
+				Spec: storkv1.ClusterPairSpec{
+					Config: config,
+					Options: map[string]string{
+						"<insert_storage_options_here>": "",
+					},
+				},
 			}
-			if config.Contexts[currentContext] != nil {
-				currentCluster := config.Contexts[currentContext].Cluster
-				for cluster := range config.Clusters {
-					if cluster != currentCluster {
-						delete(config.Clusters, cluster)
-					}
-				}
-				currentAuthInfo := config.Contexts[currentContext].AuthInfo
-				for authInfo := range config.AuthInfos {
-					if authInfo != currentAuthInfo {
-						delete(config.AuthInfos, authInfo)
-					}
-				}
+			if err = printEncoded(c, clusterPair, "yaml", ioStreams.Out); err != nil {
+				util.CheckErr(err)
+				return
+			}
+		},
+	}
 
-				if config.AuthInfos[currentAuthInfo] != nil {
-					// Replace gcloud paths in the config
-					if config.AuthInfos[currentAuthInfo].AuthProvider != nil &&
-						config.AuthInfos[currentAuthInfo].AuthProvider.Config != nil {
-						if cmdPath, present := config.AuthInfos[currentAuthInfo].AuthProvider.Config[cmdPathKey]; present {
-							if strings.HasSuffix(cmdPath, gcloudBinaryName) {
-								config.AuthInfos[currentAuthInfo].AuthProvider.Config[cmdPathKey] = gcloudPath
-							}
-						}
-					}
+	return generateClusterPairCommand
+}
 
-					// Replace file paths with inline data
-					if config.AuthInfos[currentAuthInfo].ClientCertificate != "" && len(config.AuthInfos[currentAuthInfo].ClientCertificateData) == 0 {
-						config.AuthInfos[currentAuthInfo].ClientCertificateData, err = getByteData(config.AuthInfos[currentAuthInfo].ClientCertificate)
-						if err != nil {
-							util.CheckErr(err)
-							return
-						}
-						config.AuthInfos[currentAuthInfo].ClientCertificate = ""
-					}
-					if config.AuthInfos[currentAuthInfo].ClientKey != "" && len(config.AuthInfos[currentAuthInfo].ClientKeyData) == 0 {
-						config.AuthInfos[currentAuthInfo].ClientKeyData, err = getByteData(config.AuthInfos[currentAuthInfo].ClientKey)
-						if err != nil {
-							util.CheckErr(err)
-							return
-						}
-						config.AuthInfos[currentAuthInfo].ClientKey = ""
-					}
-					if config.AuthInfos[currentAuthInfo].TokenFile != "" && len(config.AuthInfos[currentAuthInfo].Token) == 0 {
-						config.AuthInfos[currentAuthInfo].Token, err = getStringData(config.AuthInfos[currentAuthInfo].TokenFile)
-						if err != nil {
-							util.CheckErr(err)
-							return
-						}
-						config.AuthInfos[currentAuthInfo].TokenFile = ""
-					}
-				}
-				if config.Clusters[currentCluster] != nil &&
-					config.Clusters[currentCluster].CertificateAuthority != "" &&
-					len(config.Clusters[currentCluster].CertificateAuthorityData) == 0 {
+// getClusterOps returns the ops to be used to talk to the cluster for the
+// config. Replaced in tests since the fake clients can't be created from a
+// config
+var getClusterOps = newClusterOps
 
-					config.Clusters[currentCluster].CertificateAuthorityData, err = getByteData(config.Clusters[currentCluster].CertificateAuthority)
-					if err != nil {
-						util.CheckErr(err)
-						return
-					}
-					config.Clusters[currentCluster].CertificateAuthority = ""
-				}
+func newClusterOps(config clientcmdapi.Config) (k8s.Ops, error) {
+	configFile, err := ioutil.TempFile("", "storkctl-clusterpair")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := os.Remove(configFile.Name()); err != nil {
+			logrus.Warnf("Error removing temporary kubeconfig %v: %v", configFile.Name(), err)
+		}
+	}()
+	if err := configFile.Close(); err != nil {
+		return nil, err
+	}
+	if err := clientcmd.WriteToFile(config, configFile.Name()); err != nil {
+		return nil, err
+	}
+	return k8s.NewInstance(configFile.Name())
+}
 
-				clusterPair := &storkv1.ClusterPair{
-					TypeMeta: meta.TypeMeta{
-						Kind:       reflect.TypeOf(storkv1.ClusterPair{}).Name(),
-						APIVersion: storkv1.SchemeGroupVersion.String(),
-					},
-					ObjectMeta: meta.ObjectMeta{
-						Name:      args[0],
-						Namespace: cmdFactory.GetNamespace(),
-					},
+// getDestConfig returns the config for the destination context. The
+// kubeconfig for the source cluster is used if a kubeconfig isn't specified
+// for the destination, and the current context from the kubeconfig is used if
+// a context isn't specified.
+func getDestConfig(cmdFactory Factory, destKubeconfig string, destContext string) (clientcmdapi.Config, error) {
+	var destConfig clientcmdapi.Config
+	var err error
+	if destKubeconfig != "" {
+		var config *clientcmdapi.Config
+		config, err = clientcmd.LoadFromFile(destKubeconfig)
+		if config != nil {
+			destConfig = *config
+		}
+	} else {
+		destConfig, err = cmdFactory.RawConfig()
+	}
+	if err != nil {
+		return destConfig, err
+	}
+	if destContext != "" {
+		destConfig.CurrentContext = destContext
+	}
+	return destConfig, nil
+}
 
-					Spec: storkv1.ClusterPairSpec{
-						Config: config,
-						Options: map[string]string{
-							"<insert_storage_options_here>": "",
-						},
-					},
+// destClusterOptions are the options to address the destination cluster
+// directly from the kubeconfig instead of through the config in a cluster
+// pair, for example when the credentials in the cluster pair don't have
+// access to the applications
+type destClusterOptions struct {
+	context    string
+	kubeconfig string
+}
+
+func (o *destClusterOptions) addFlags(c *cobra.Command) {
+	c.Flags().StringVar(&o.context, "dest-context", "", "Context for the destination cluster. "+
+		"The config from the clusterpair is used if neither the destination context nor kubeconfig are specified")
+	c.Flags().StringVar(&o.kubeconfig, "dest-kubeconfig", "", "Path to the kubeconfig file with the destination context. "+
+		"Defaults to the kubeconfig used for the source cluster")
+}
+
+// getConfig returns the config to use for the destination cluster of the
+// cluster pair
+func (o *destClusterOptions) getConfig(cmdFactory Factory, clusterPair *storkv1.ClusterPair) (clientcmdapi.Config, error) {
+	if o.context == "" && o.kubeconfig == "" {
+		return clusterPair.Spec.Config, nil
+	}
+	destConfig, err := getDestConfig(cmdFactory, o.kubeconfig, o.context)
+	if err != nil {
+		return destConfig, err
+	}
+	if destConfig.Contexts[destConfig.CurrentContext] == nil {
+		return destConfig, fmt.Errorf("context %v not found in config", destConfig.CurrentContext)
+	}
+	return destConfig, nil
+}
+
+// getServiceAccountToken creates a service account bound to the cluster-admin
+// role in the cluster and returns its token
+func getServiceAccountToken(ops k8s.Ops, name string, namespace string) (string, error) {
+	_, err := ops.CreateServiceAccount(&v1.ServiceAccount{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+	})
+	if err != nil && !errors.IsAlreadyExists(err) {
+		return "", fmt.Errorf("error creating service account %v/%v: %v", namespace, name, err)
+	}
+	_, err = ops.CreateClusterRoleBinding(&rbacv1.ClusterRoleBinding{
+		ObjectMeta: meta.ObjectMeta{
+			Name: name,
+		},
+		Subjects: []rbacv1.Subject{
+			{
+				Kind:      rbacv1.ServiceAccountKind,
+				Name:      name,
+				Namespace: namespace,
+			},
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "ClusterRole",
+			Name:     clusterPairRoleName,
+		},
+	})
+	if err != nil && !errors.IsAlreadyExists(err) {
+		return "", fmt.Errorf("error creating cluster role binding %v: %v", name, err)
+	}
+
+	// Create the token secret for the service account so that it can be
+	// looked up by name, it is populated by the token controller
+	secretName := name + "-token"
+	_, err = ops.CreateSecret(&v1.Secret{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      secretName,
+			Namespace: namespace,
+			Annotations: map[string]string{
+				v1.ServiceAccountNameKey: name,
+			},
+		},
+		Type: v1.SecretTypeServiceAccountToken,
+	})
+	if err != nil && !errors.IsAlreadyExists(err) {
+		return "", fmt.Errorf("error creating token secret for service account %v/%v: %v", namespace, name, err)
+	}
+
+	var token string
+	err = wait.PollImmediate(tokenRetryInterval, tokenTimeout, func() (bool, error) {
+		secret, err := ops.GetSecret(secretName, namespace)
+		if err != nil {
+			return false, err
+		}
+		token = string(secret.Data[v1.ServiceAccountTokenKey])
+		return token != "", nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("error getting token for service account %v/%v: %v", namespace, name, err)
+	}
+	return token, nil
+}
+
+// getStoragePairingOptions fills in the options required to pair with
+// Portworx in the cluster that weren't specified. The token is looked up
+// using pxctl on one of the Portworx pods and the IP of its node is used.
+func getStoragePairingOptions(ops k8s.Ops, options map[string]string, pxNamespace string) (map[string]string, error) {
+	if options[pxTokenOption] != "" && options[pxIPOption] != "" {
+		if options[pxPortOption] == "" {
+			options[pxPortOption] = defaultPxPort
+		}
+		return options, nil
+	}
+	pods, err := ops.GetPods(pxNamespace, map[string]string{"name": "portworx"})
+	if err != nil {
+		return nil, fmt.Errorf("error getting Portworx pods: %v", err)
+	}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != v1.PodRunning {
+			continue
+		}
+		if options[pxTokenOption] == "" {
+			output, err := ops.RunCommandInPod([]string{pxctlPath, "cluster", "token", "show"}, pod.Name, "portworx", pod.Namespace)
+			if err != nil {
+				return nil, fmt.Errorf("error getting cluster token from pod %v: %v", pod.Name, err)
+			}
+			fields := strings.Fields(output)
+			if len(fields) == 0 {
+				return nil, fmt.Errorf("cluster token not found in output from pod %v: %v", pod.Name, output)
+			}
+			options[pxTokenOption] = fields[len(fields)-1]
+		}
+		if options[pxIPOption] == "" {
+			options[pxIPOption] = pod.Status.HostIP
+		}
+		if options[pxPortOption] == "" {
+			options[pxPortOption] = defaultPxPort
+		}
+		return options, nil
+	}
+	return nil, fmt.Errorf("no running Portworx pods found in namespace %v, storage options need to be specified", pxNamespace)
+}
+
+// clusterPairSettings are the settings used to create a cluster pair
+// pointing to a remote cluster
+type clusterPairSettings struct {
+	serviceAccount          string
+	serviceAccountNamespace string
+	storageOptions          map[string]string
+	pxNamespace             string
+}
+
+// newClusterPair returns the cluster pair pointing to the cluster for the
+// config, generating the service account token and storage options for it
+func newClusterPair(
+	name string,
+	namespace string,
+	rawConfig clientcmdapi.Config,
+	settings *clusterPairSettings,
+) (*storkv1.ClusterPair, error) {
+	config, err := getClusterPairConfig(rawConfig)
+	if err != nil {
+		return nil, err
+	}
+	ops, err := getClusterOps(config)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to cluster for context %v: %v", config.CurrentContext, err)
+	}
+	if settings.serviceAccount != "" {
+		token, err := getServiceAccountToken(ops, settings.serviceAccount, settings.serviceAccountNamespace)
+		if err != nil {
+			return nil, err
+		}
+		authInfo := config.Contexts[config.CurrentContext].AuthInfo
+		config.AuthInfos[authInfo] = &clientcmdapi.AuthInfo{Token: token}
+	}
+
+	options := make(map[string]string)
+	for k, v := range settings.storageOptions {
+		options[k] = v
+	}
+	options, err = getStoragePairingOptions(ops, options, settings.pxNamespace)
+	if err != nil {
+		return nil, err
+	}
+
+	return &storkv1.ClusterPair{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: storkv1.ClusterPairSpec{
+			Config:  config,
+			Options: options,
+		},
+	}, nil
+}
+
+func newCreateClusterPairCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	dryRun := &dryRunOptions{}
+	var destContext string
+	var destKubeconfig string
+	var storageOptions []string
+	var sourceStorageOptions []string
+	var bidirectional bool
+	settings := &clusterPairSettings{}
+
+	createClusterPairCommand := &cobra.Command{
+		Use:     clusterPairSubcommand,
+		Aliases: []string{"cp"},
+		Short:   "Create a cluster pair to a destination cluster",
+		Long: "Create a cluster pair to the cluster for the destination context. A service account is " +
+			"created in the destination cluster for the pair and the storage options required for pairing are " +
+			"looked up from Portworx in the destination cluster if they aren't specified. The service account " +
+			"is also created for a dry run since its token is needed for the spec.",
+		Run: func(c *cobra.Command, args []string) {
+			if len(args) != 1 {
+				util.CheckErr(fmt.Errorf("exactly one name needs to be provided for clusterpair name"))
+				return
+			}
+			if destContext == "" {
+				util.CheckErr(fmt.Errorf("destination context needs to be provided"))
+				return
+			}
+			// The reverse cluster pair would need to be applied to the
+			// destination cluster, so only one spec can be generated
+			if dryRun.enabled() && bidirectional {
+				util.CheckErr(fmt.Errorf("dry run can't be used for bidirectional clusterpairs"))
+				return
+			}
+			name := args[0]
+			namespace := cmdFactory.GetNamespace()
+
+			destConfig, err := getDestConfig(cmdFactory, destKubeconfig, destContext)
+			if err != nil {
+				util.CheckErr(err)
+				return
+			}
+
+			settings.storageOptions, err = parseKeyValueList(storageOptions)
+			if err != nil {
+				util.CheckErr(err)
+				return
+			}
+			clusterPair, err := newClusterPair(name, namespace, destConfig, settings)
+			if err != nil {
+				util.CheckErr(err)
+				return
+			}
+
+			if dryRun.enabled() {
+				if err := dryRun.printObject(c, cmdFactory, clusterPair, clusterPair.Name, ioStreams.Out); err != nil {
+					util.CheckErr(err)
+				}
+				return
+			}
+
+			var reverseClusterPair *storkv1.ClusterPair
+			var destOps k8s.Ops
+			if bidirectional {
+				sourceConfig, err := cmdFactory.RawConfig()
+				if err != nil {
+					util.CheckErr(err)
+					return
+				}
+				reverseSettings := *settings
+				reverseSettings.storageOptions, err = parseKeyValueList(sourceStorageOptions)
+				if err != nil {
+					util.CheckErr(err)
+					return
 				}
-				if err = printEncoded(c, clusterPair, "yaml", ioStreams.Out); err != nil {
+				reverseClusterPair, err = newClusterPair(name, namespace, sourceConfig, &reverseSettings)
+				if err != nil {
 					util.CheckErr(err)
 					return
 				}
+				destOps, err = getClusterOps(clusterPair.Spec.Config)
+				if err != nil {
+					util.CheckErr(err)
+					return
+				}
+			}
+
+			if _, err := k8s.Instance().CreateClusterPair(clusterPair); err != nil {
+				util.CheckErr(err)
+				return
+			}
+			printMsg(fmt.Sprintf("ClusterPair %v created successfully", name), ioStreams.Out)
+			if reverseClusterPair != nil {
+				if _, err := destOps.CreateClusterPair(reverseClusterPair); err != nil {
+					util.CheckErr(fmt.Errorf("error creating ClusterPair in destination cluster: %v", err))
+					return
+				}
+				printMsg(fmt.Sprintf("ClusterPair %v created successfully in destination cluster", name), ioStreams.Out)
 			}
 		},
 	}
 
-	return generateClusterPairCommand
+	createClusterPairCommand.Flags().StringVar(&destContext, "dest-context", "", "Context for the destination cluster")
+	createClusterPairCommand.Flags().StringVar(&destKubeconfig, "dest-kubeconfig", "", "Path to the kubeconfig file with the destination context. Defaults to the kubeconfig used for the source cluster")
+	createClusterPairCommand.Flags().StringSliceVarP(&storageOptions, "storage-options", "", nil,
+		"Comma-separated list of options to pair with the storage in the destination cluster in the format key1=value1,key2=value2. "+
+			"The Portworx ip, port and token are looked up if not specified")
+	createClusterPairCommand.Flags().StringSliceVarP(&sourceStorageOptions, "source-storage-options", "", nil,
+		"Comma-separated list of options to pair with the storage in the source cluster for bidirectional pairs")
+	createClusterPairCommand.Flags().BoolVar(&bidirectional, "bidirectional", false, "Also create a cluster pair in the destination cluster pointing to the source cluster")
+	createClusterPairCommand.Flags().StringVar(&settings.serviceAccount, "service-account", defaultClusterPairServiceAccount,
+		"Service account created for the cluster pair. The credentials from the kubeconfig are used if empty")
+	createClusterPairCommand.Flags().StringVar(&settings.serviceAccountNamespace, "service-account-namespace", defaultStorkNamespace, "Namespace for the service account")
+	createClusterPairCommand.Flags().StringVar(&settings.pxNamespace, "px-namespace", defaultStorkNamespace, "Namespace where Portworx is running")
+	dryRun.addFlags(createClusterPairCommand)
+
+	return createClusterPairCommand
 }
@@ -3,13 +3,19 @@
 package storkctl
 
 import (
+	"io/ioutil"
+	"os"
 	"testing"
 
+	"github.com/ghodss/yaml"
 	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
 	"github.com/portworx/sched-ops/k8s"
 	"github.com/stretchr/testify/require"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 )
 
 func createClusterPairAndVerify(t *testing.T, name string, namespace string) {
@@ -111,6 +117,134 @@ func TestGetClusterPairsWithStatus(t *testing.T) {
 	testCommon(t, cmdArgs, nil, expected, false)
 }
 
+const testKubeconfig = `apiVersion: v1
+kind: Config
+current-context: source
+clusters:
+- name: source
+  cluster:
+    server: https://source:6443
+- name: dest
+  cluster:
+    server: https://dest:6443
+contexts:
+- name: source
+  context:
+    cluster: source
+    user: source
+- name: dest
+  context:
+    cluster: dest
+    user: dest
+users:
+- name: source
+  user:
+    token: sourcetoken
+- name: dest
+  user:
+    token: desttoken
+`
+
+func setupClusterPairKubeconfig(t *testing.T) string {
+	kubeconfig, err := ioutil.TempFile("", "kubeconfig")
+	require.NoError(t, err, "Error creating kubeconfig")
+	_, err = kubeconfig.WriteString(testKubeconfig)
+	require.NoError(t, err, "Error writing kubeconfig")
+	require.NoError(t, kubeconfig.Close(), "Error closing kubeconfig")
+
+	// Both clusters are served by the fake clients
+	getClusterOps = func(_ clientcmdapi.Config) (k8s.Ops, error) {
+		return k8s.Instance(), nil
+	}
+	_, err = k8s.Instance().CreateSecret(&v1.Secret{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      defaultClusterPairServiceAccount + "-token",
+			Namespace: defaultStorkNamespace,
+		},
+		Data: map[string][]byte{
+			v1.ServiceAccountTokenKey: []byte("satoken"),
+		},
+	})
+	require.NoError(t, err, "Error creating token secret")
+	return kubeconfig.Name()
+}
+
+func TestCreateClusterPair(t *testing.T) {
+	defer resetTest()
+	kubeconfig := setupClusterPairKubeconfig(t)
+	defer func() {
+		getClusterOps = newClusterOps
+		require.NoError(t, os.Remove(kubeconfig), "Error removing kubeconfig")
+	}()
+
+	cmdArgs := []string{"create", "clusterpair", "pair1", "--kubeconfig", kubeconfig}
+	expected := "error: destination context needs to be provided"
+	testCommon(t, cmdArgs, nil, expected, true)
+
+	cmdArgs = []string{"create", "clusterpair", "pair1", "--kubeconfig", kubeconfig, "--dest-context", "missing"}
+	expected = "error: context missing not found in config"
+	testCommon(t, cmdArgs, nil, expected, true)
+
+	cmdArgs = []string{"create", "clusterpair", "pair1", "--kubeconfig", kubeconfig, "--dest-context", "dest", "--storage-options", "token=pxtoken"}
+	expected = "error: no running Portworx pods found in namespace kube-system, storage options need to be specified"
+	testCommon(t, cmdArgs, nil, expected, true)
+
+	_, err := k8s.Instance().CreatePod(&v1.Pod{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      "portworx-1",
+			Namespace: defaultStorkNamespace,
+			Labels:    map[string]string{"name": "portworx"},
+		},
+		Status: v1.PodStatus{
+			Phase:  v1.PodRunning,
+			HostIP: "10.0.0.1",
+		},
+	})
+	require.NoError(t, err, "Error creating pod")
+
+	cmdArgs = []string{"create", "clusterpair", "pair1", "-n", "test", "--kubeconfig", kubeconfig, "--dest-context", "dest", "--storage-options", "token=pxtoken"}
+	expected = "ClusterPair pair1 created successfully\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+
+	clusterPair, err := k8s.Instance().GetClusterPair("pair1", "test")
+	require.NoError(t, err, "Error getting clusterpair")
+	require.Equal(t, map[string]string{"token": "pxtoken", "ip": "10.0.0.1", "port": "9001"}, clusterPair.Spec.Options)
+	config := clusterPair.Spec.Config
+	require.Equal(t, "dest", config.CurrentContext)
+	require.Len(t, config.Contexts, 1)
+	require.Len(t, config.Clusters, 1)
+	require.Equal(t, "https://dest:6443", config.Clusters["dest"].Server)
+	require.Equal(t, "satoken", config.AuthInfos["dest"].Token, "Service account token should be used")
+
+	_, err = k8s.Instance().GetClusterRoleBinding(defaultClusterPairServiceAccount)
+	require.NoError(t, err, "Error getting cluster role binding for service account")
+
+	// Credentials from the kubeconfig are used without a service account
+	cmdArgs = []string{"create", "clusterpair", "pair2", "-n", "test", "--kubeconfig", kubeconfig, "--dest-context", "dest",
+		"--storage-options", "token=pxtoken,ip=10.0.0.2,port=9020", "--service-account", ""}
+	expected = "ClusterPair pair2 created successfully\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+	clusterPair, err = k8s.Instance().GetClusterPair("pair2", "test")
+	require.NoError(t, err, "Error getting clusterpair")
+	require.Equal(t, map[string]string{"token": "pxtoken", "ip": "10.0.0.2", "port": "9020"}, clusterPair.Spec.Options)
+	require.Equal(t, "desttoken", clusterPair.Spec.Config.AuthInfos["dest"].Token)
+
+	// The spec is only printed for a dry run
+	cmdArgs = []string{"create", "clusterpair", "pair3", "-n", "test", "--kubeconfig", kubeconfig, "--dest-context", "dest",
+		"--storage-options", "token=pxtoken,ip=10.0.0.3", "--dry-run", "-o", "yaml"}
+	clusterPair = &storkv1.ClusterPair{}
+	require.NoError(t, yaml.Unmarshal([]byte(runCommand(t, cmdArgs)), clusterPair), "Error decoding dry run output")
+	require.Equal(t, "ClusterPair", clusterPair.Kind)
+	require.Equal(t, "pair3", clusterPair.Name)
+	require.Equal(t, "satoken", clusterPair.Spec.Config.AuthInfos["dest"].Token)
+	_, err = k8s.Instance().GetClusterPair("pair3", "test")
+	require.True(t, errors.IsNotFound(err), "ClusterPair shouldn't be created for a dry run")
+
+	cmdArgs = append(cmdArgs, "--bidirectional")
+	expected = "error: dry run can't be used for bidirectional clusterpairs"
+	testCommon(t, cmdArgs, nil, expected, true)
+}
+
 /*
 func TestGenerateClusterPair(t *testing.T) {
 	cmdArgs := []string{"clusterpair", "pair1"}
@@ -1,9 +1,13 @@
 package storkctl
 
 import (
+	"bufio"
 	"fmt"
 	"io"
+	"strings"
 	"time"
+
+	"k8s.io/kubernetes/pkg/kubectl/genericclioptions"
 )
 
 func toTimeString(t time.Time) string {
@@ -23,3 +27,17 @@ func printMsg(msg string, out io.Writer) {
 		fmt.Println(msg)
 	}
 }
+
+// confirm prompts for a yes or no answer and returns true if the answer was
+// yes
+func confirm(prompt string, ioStreams genericclioptions.IOStreams) (bool, error) {
+	if _, err := fmt.Fprintf(ioStreams.Out, "%v (y/n): ", prompt); err != nil {
+		return false, err
+	}
+	answer, err := bufio.NewReader(ioStreams.In).ReadString('\n')
+	if err != nil && answer == "" {
+		return false, fmt.Errorf("error reading confirmation: %v", err)
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes", nil
+}
@@ -104,3 +104,28 @@ func testCommon(t *testing.T, cmdArgs []string, obj runtime.Object, expected str
 		require.Equal(t, expected, buf.String())
 	}
 }
+
+// runCommand runs a command and returns its output for commands whose output
+// can't be compared exactly
+func runCommand(t *testing.T, cmdArgs []string) string {
+	streams, _, buf, _ := genericclioptions.NewTestIOStreams()
+	cmd := NewCommand(testFactory, streams.In, streams.Out, streams.ErrOut)
+	cmd.SetOutput(buf)
+	cmd.SetArgs(cmdArgs)
+	require.NoError(t, cmd.Execute(), "Error executing command: %v", cmdArgs)
+	return buf.String()
+}
+
+// runFailingCommand runs a command that prints its output before failing and
+// returns the output
+func runFailingCommand(t *testing.T, cmdArgs []string, expectedErr string) string {
+	calledFatal := false
+	defer cmdutil.DefaultBehaviorOnFatal()
+	cmdutil.BehaviorOnFatal(func(e string, code int) {
+		calledFatal = true
+		require.Equal(t, expectedErr, e)
+	})
+	output := runCommand(t, cmdArgs)
+	require.True(t, calledFatal)
+	return output
+}
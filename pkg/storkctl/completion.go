@@ -0,0 +1,465 @@
+package storkctl
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/portworx/sched-ops/k8s"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/kubernetes/pkg/kubectl/cmd/util"
+	"k8s.io/kubernetes/pkg/kubectl/genericclioptions"
+)
+
+const (
+	completionSubcommand = "completion"
+	namespacesCompletion = "namespaces"
+)
+
+// nameCompletionCommands are the commands whose subcommands take the names
+// of resources as arguments
+var nameCompletionCommands = []string{"get", "delete", "wait", "suspend", "resume", "diff", "describe"}
+
+const bashCompletionFunc = `
+__storkctl_override_flags()
+{
+    local word two_word_flag
+    for word in "${words[@]}"; do
+        if [ -n "${two_word_flag}" ]; then
+            echo "${two_word_flag}=${word}"
+            two_word_flag=""
+            continue
+        fi
+        case "${word}" in
+            --namespace=*|--kubeconfig=*|--context=*)
+                echo "${word}"
+                ;;
+            --namespace|-n)
+                two_word_flag="--namespace"
+                ;;
+            --kubeconfig|--context)
+                two_word_flag="${word}"
+                ;;
+        esac
+    done
+}
+
+__storkctl_get_names()
+{
+    local storkctl_out
+    if storkctl_out=$(storkctl completion names "$1" $(__storkctl_override_flags) 2>/dev/null); then
+        COMPREPLY=( $( compgen -W "${storkctl_out[*]}" -- "$cur" ) )
+    fi
+}
+
+__storkctl_get_namespaces()
+{
+    __storkctl_get_names namespaces
+}
+
+__custom_func() {
+    case ${last_command} in
+        storkctl_get_*|storkctl_delete_*|storkctl_wait_*|storkctl_suspend_*|storkctl_resume_*|storkctl_diff_*|storkctl_describe_*)
+            __storkctl_get_names "${last_command##*_}"
+            return
+            ;;
+        *)
+            ;;
+    esac
+}
+`
+
+// zshCompletionHead has the functions needed to run the bash completion
+// script with bashcompinit in zsh
+const zshCompletionHead = `#compdef storkctl
+
+__storkctl_bash_source() {
+	alias shopt=':'
+	emulate -L sh
+	setopt kshglob noshglob braceexpand
+
+	source "$@"
+}
+
+__storkctl_get_comp_words_by_ref() {
+	cur="${COMP_WORDS[COMP_CWORD]}"
+	prev="${COMP_WORDS[${COMP_CWORD}-1]}"
+	words=("${COMP_WORDS[@]}")
+	cword=("${COMP_CWORD[@]}")
+}
+
+__storkctl_compgen() {
+	local completions w
+	completions=( $(compgen "$@") ) || return $?
+
+	# filter by given word as prefix
+	while [[ "$1" = -* && "$1" != -- ]]; do
+		shift
+		shift
+	done
+	if [[ "$1" == -- ]]; then
+		shift
+	fi
+	for w in "${completions[@]}"; do
+		if [[ "${w}" = "$1"* ]]; then
+			echo "${w}"
+		fi
+	done
+}
+
+__storkctl_filedir() {
+	local RET
+	RET=( $(compgen -f -- "${cur}") )
+	COMPREPLY=( "${RET[@]}" )
+}
+
+autoload -U +X bashcompinit && bashcompinit
+
+__storkctl_bash_source <(cat <<'BASH_COMPLETION_EOF'
+`
+
+const zshCompletionTail = `BASH_COMPLETION_EOF
+)
+`
+
+// zshReplacements convert the parts of the bash completion script that
+// behave differently in zsh
+var zshReplacements = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`\bdeclare -F\b`), "whence -w"},
+	{regexp.MustCompile(`\b_get_comp_words_by_ref\b`), "__storkctl_get_comp_words_by_ref"},
+	{regexp.MustCompile(`\bcompgen\b`), "__storkctl_compgen"},
+	{regexp.MustCompile(`\b_filedir\b`), "__storkctl_filedir"},
+	{regexp.MustCompile(`\$\(type -t compopt\)`), `""`},
+	{regexp.MustCompile(`-z "\$\{BASH_VERSION\}" \|\|`), `-n "$${BASH_VERSION}" &&`},
+	{regexp.MustCompile(`local ([a-zA-Z0-9_]*)=`), "local $1; $1="},
+}
+
+// fishCompletionHead has the functions used by the generated completions
+// to find the subcommand being completed and to get the names of resources
+const fishCompletionHead = `# fish completion for storkctl
+
+function __storkctl_override_flags
+    set -l next
+    for token in (commandline -opc)
+        if test -n "$next"
+            echo "$next=$token"
+            set next
+            continue
+        end
+        switch $token
+            case '--namespace=*' '--kubeconfig=*' '--context=*'
+                echo $token
+            case '--namespace' '-n'
+                set next --namespace
+            case '--kubeconfig' '--context'
+                set next $token
+        end
+    end
+end
+
+function __storkctl_names
+    storkctl completion names $argv (__storkctl_override_flags) 2>/dev/null
+end
+
+# Checks if the subcommands on the command line match the arguments, where
+# each argument has the name and aliases of a subcommand separated by
+# commas. With exact, no other arguments are allowed after the subcommands.
+function __storkctl_using_command
+    set -l mode $argv[1]
+    set -e argv[1]
+    set -l tokens (commandline -opc)
+    set -e tokens[1]
+    set -l subcommands
+    set -l skip
+    for token in $tokens
+        if test -n "$skip"
+            set skip
+            continue
+        end
+        switch $token
+            case '--*=*'
+                continue
+            case '-*'
+                if contains -- $token $__storkctl_value_flags
+                    set skip true
+                end
+                continue
+        end
+        set subcommands $subcommands $token
+    end
+    if test (count $subcommands) -lt (count $argv)
+        return 1
+    end
+    if test "$mode" = exact; and test (count $subcommands) -ne (count $argv)
+        return 1
+    end
+    for i in (seq (count $argv))
+        if not contains -- $subcommands[$i] (string split , -- $argv[$i])
+            return 1
+        end
+    end
+    return 0
+end
+
+`
+
+func newCompletionCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	completionCommands := &cobra.Command{
+		Use:   completionSubcommand,
+		Short: "Output shell completion code for the specified shell",
+		Long: "Output shell completion code for bash, zsh or fish. The completions include the names of " +
+			"resources and namespaces from the cluster.\n\n" +
+			"To load the completions in the current shell:\n" +
+			"  bash: source <(storkctl completion bash)\n" +
+			"  zsh:  source <(storkctl completion zsh)\n" +
+			"  fish: storkctl completion fish | source",
+		// The completion code doesn't need access to the cluster
+		PersistentPreRun: func(c *cobra.Command, args []string) {},
+	}
+
+	completionCommands.AddCommand(
+		&cobra.Command{
+			Use:   "bash",
+			Short: "Output bash completion code",
+			Run: func(c *cobra.Command, args []string) {
+				util.CheckErr(genBashCompletion(c.Root(), ioStreams.Out))
+			},
+		},
+		&cobra.Command{
+			Use:   "zsh",
+			Short: "Output zsh completion code",
+			Run: func(c *cobra.Command, args []string) {
+				util.CheckErr(genZshCompletion(c.Root(), ioStreams.Out))
+			},
+		},
+		&cobra.Command{
+			Use:   "fish",
+			Short: "Output fish completion code",
+			Run: func(c *cobra.Command, args []string) {
+				util.CheckErr(genFishCompletion(c.Root(), ioStreams.Out))
+			},
+		},
+		newCompletionNamesCommand(cmdFactory, ioStreams),
+	)
+
+	return completionCommands
+}
+
+// newCompletionNamesCommand returns the command used by the completion code
+// to get the names of resources
+func newCompletionNamesCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	return &cobra.Command{
+		Use:    "names [resource]",
+		Short:  "Print the names of the resources of a type",
+		Hidden: true,
+		PersistentPreRun: func(c *cobra.Command, args []string) {
+			util.CheckErr(cmdFactory.UpdateConfig())
+		},
+		Run: func(c *cobra.Command, args []string) {
+			if len(args) != 1 {
+				util.CheckErr(fmt.Errorf("exactly one resource type needs to be provided"))
+				return
+			}
+			names, err := getCompletionNames(c.Root(), cmdFactory, args[0])
+			if err != nil {
+				util.CheckErr(err)
+				return
+			}
+			for _, name := range names {
+				printMsg(name, ioStreams.Out)
+			}
+		},
+	}
+}
+
+// getCompletionNames returns the names of the resources of a type in the
+// namespace. The type can be the name or alias of any subcommand that takes
+// resource names.
+func getCompletionNames(root *cobra.Command, cmdFactory Factory, resource string) ([]string, error) {
+	for _, parent := range nameCompletionCommands {
+		if command, _, err := root.Find([]string{parent, resource}); err == nil && command.Name() != parent {
+			resource = command.Name()
+			break
+		}
+	}
+	namespace := cmdFactory.GetNamespace()
+
+	var object runtime.Object
+	var err error
+	switch resource {
+	case namespacesCompletion, "namespace", "ns":
+		object, err = k8s.Instance().ListNamespaces()
+	case migrationSubcommand:
+		object, err = k8s.Instance().ListMigrations(namespace)
+	case migrationScheduleSubcommand:
+		object, err = k8s.Instance().ListMigrationSchedules(namespace)
+	case clusterPairSubcommand:
+		object, err = k8s.Instance().ListClusterPairs(namespace)
+	case schedulePolicySubcommand:
+		object, err = k8s.Instance().ListSchedulePolicies()
+	case snapSubcommand:
+		object, err = k8s.Instance().ListSnapshots(namespace)
+	case snapshotScheduleSubcommand:
+		object, err = k8s.Instance().ListSnapshotSchedules(namespace)
+	case groupSnapshotSubcommand:
+		object, err = k8s.Instance().ListGroupSnapshots(namespace)
+	case clusterDomainsStatusSubcommand:
+		object, err = k8s.Instance().ListClusterDomainStatuses()
+	case clusterDomainUpdateSubcommand:
+		object, err = k8s.Instance().ListClusterDomainUpdates()
+	case volumeSnapshotRestoreSubcommand:
+		storkClient, clientErr := getStorkClient(cmdFactory)
+		if clientErr != nil {
+			return nil, clientErr
+		}
+		object, err = storkClient.StorkV1alpha1().VolumeSnapshotRestores(namespace).List(metav1.ListOptions{})
+	default:
+		return nil, fmt.Errorf("names can't be completed for resource type %v", resource)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := meta.ExtractList(object)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(items))
+	for _, item := range items {
+		accessor, err := meta.Accessor(item)
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, accessor.GetName())
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func genBashCompletion(root *cobra.Command, out io.Writer) error {
+	root.BashCompletionFunction = bashCompletionFunc
+	if err := cobra.MarkFlagCustom(root.PersistentFlags(), "namespace", "__storkctl_get_namespaces"); err != nil {
+		return err
+	}
+	return root.GenBashCompletion(out)
+}
+
+func genZshCompletion(root *cobra.Command, out io.Writer) error {
+	var bashCompletion bytes.Buffer
+	if err := genBashCompletion(root, &bashCompletion); err != nil {
+		return err
+	}
+	zshCompletion := bashCompletion.String()
+	for _, r := range zshReplacements {
+		zshCompletion = r.pattern.ReplaceAllString(zshCompletion, r.replacement)
+	}
+	_, err := io.WriteString(out, zshCompletionHead+zshCompletion+zshCompletionTail)
+	return err
+}
+
+func genFishCompletion(root *cobra.Command, out io.Writer) error {
+	var buf bytes.Buffer
+	buf.WriteString(fishCompletionHead)
+
+	// Flags that take a value are needed to find the subcommands on the
+	// command line
+	valueFlags := make(map[string]bool)
+	visitCommands(root, func(c *cobra.Command) {
+		c.LocalFlags().VisitAll(func(flag *pflag.Flag) {
+			if flag.NoOptDefVal == "" {
+				valueFlags["--"+flag.Name] = true
+				if flag.Shorthand != "" {
+					valueFlags["-"+flag.Shorthand] = true
+				}
+			}
+		})
+	})
+	flagNames := make([]string, 0, len(valueFlags))
+	for name := range valueFlags {
+		flagNames = append(flagNames, name)
+	}
+	sort.Strings(flagNames)
+	fmt.Fprintf(&buf, "set -g __storkctl_value_flags %v\n\n", strings.Join(flagNames, " "))
+
+	writeFishCommand(&buf, root, nil)
+	_, err := buf.WriteTo(out)
+	return err
+}
+
+func visitCommands(c *cobra.Command, visit func(*cobra.Command)) {
+	visit(c)
+	for _, child := range c.Commands() {
+		if child.IsAvailableCommand() {
+			visitCommands(child, visit)
+		}
+	}
+}
+
+// writeFishCommand writes the completions for the subcommands, flags and
+// arguments of a command. path has the names and aliases of the subcommands
+// leading to the command.
+func writeFishCommand(buf *bytes.Buffer, c *cobra.Command, path []string) {
+	pathArgs := strings.Join(path, " ")
+	condition := fmt.Sprintf("-n '__storkctl_using_command prefix %v'", pathArgs)
+	if len(path) == 0 {
+		// Flags for the root command apply everywhere
+		condition = ""
+	}
+
+	for _, child := range c.Commands() {
+		if !child.IsAvailableCommand() {
+			continue
+		}
+		fmt.Fprintf(buf, "complete -c storkctl -f -n '__storkctl_using_command exact %v' -a %v -d %v\n",
+			pathArgs, child.Name(), fishQuote(child.Short))
+	}
+
+	c.LocalFlags().VisitAll(func(flag *pflag.Flag) {
+		if flag.Hidden || flag.Deprecated != "" || flag.Name == "help" {
+			return
+		}
+		line := fmt.Sprintf("complete -c storkctl %v -l %v", condition, flag.Name)
+		if flag.Shorthand != "" {
+			line += " -s " + flag.Shorthand
+		}
+		if flag.NoOptDefVal == "" {
+			line += " -r"
+		}
+		if flag.Name == "namespace" {
+			line += " -f -a '(__storkctl_names " + namespacesCompletion + ")'"
+		}
+		fmt.Fprintf(buf, "%v -d %v\n", strings.Join(strings.Fields(line), " "), fishQuote(flag.Usage))
+	})
+
+	if len(path) == 2 && !c.HasAvailableSubCommands() {
+		for _, parent := range nameCompletionCommands {
+			if c.Parent().Name() == parent {
+				fmt.Fprintf(buf, "complete -c storkctl -f %v -a '(__storkctl_names %v)'\n", condition, c.Name())
+			}
+		}
+	}
+
+	for _, child := range c.Commands() {
+		if child.IsAvailableCommand() {
+			names := append([]string{child.Name()}, child.Aliases...)
+			writeFishCommand(buf, child, append(append([]string{}, path...), strings.Join(names, ",")))
+		}
+	}
+}
+
+// fishQuote returns the first line of the description quoted for fish
+func fishQuote(description string) string {
+	description = strings.SplitN(description, "\n", 2)[0]
+	description = strings.Replace(description, `\`, `\\`, -1)
+	return "'" + strings.Replace(description, "'", `\'`, -1) + "'"
+}
@@ -0,0 +1,58 @@
+// +build unittest
+
+package storkctl
+
+import (
+	"testing"
+
+	"github.com/portworx/sched-ops/k8s"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompletionNames(t *testing.T) {
+	defer resetTest()
+	createMigrationAndVerify(t, "migration2", "test", "clusterpair1", []string{"namespace1"}, "", "")
+	createMigrationAndVerify(t, "migration1", "test", "clusterpair1", []string{"namespace1"}, "", "")
+	createMigrationAndVerify(t, "othermigration", "other", "clusterpair1", []string{"namespace1"}, "", "")
+	for _, name := range []string{"test", "other"} {
+		_, err := k8s.Instance().CreateNamespace(name, nil)
+		require.NoError(t, err, "Error creating namespace")
+	}
+
+	cmdArgs := []string{"completion", "names", "migrations", "-n", "test"}
+	testCommon(t, cmdArgs, nil, "migration1\nmigration2\n", false)
+
+	// Aliases for the resources can be used
+	cmdArgs = []string{"completion", "names", "migration", "-n", "other"}
+	testCommon(t, cmdArgs, nil, "othermigration\n", false)
+
+	cmdArgs = []string{"completion", "names", "namespaces"}
+	testCommon(t, cmdArgs, nil, "other\ntest\n", false)
+
+	cmdArgs = []string{"completion", "names", "pods"}
+	testCommon(t, cmdArgs, nil, "error: names can't be completed for resource type pods", true)
+
+	cmdArgs = []string{"completion", "names"}
+	testCommon(t, cmdArgs, nil, "error: exactly one resource type needs to be provided", true)
+}
+
+func TestCompletionScripts(t *testing.T) {
+	defer resetTest()
+
+	output := runCommand(t, []string{"completion", "bash"})
+	require.Contains(t, output, "__start_storkctl")
+	require.Contains(t, output, `flags_completion+=("__storkctl_get_namespaces")`)
+	require.Contains(t, output, "storkctl_get_*|storkctl_delete_*|storkctl_wait_*|storkctl_suspend_*|storkctl_resume_*|storkctl_diff_*|storkctl_describe_*)")
+	require.NotContains(t, output, "storkctl_completion_names", "Hidden commands shouldn't be completed")
+
+	output = runCommand(t, []string{"completion", "zsh"})
+	require.Contains(t, output, "#compdef storkctl")
+	require.Contains(t, output, "__storkctl_get_comp_words_by_ref")
+	require.NotContains(t, output, "declare -F")
+
+	output = runCommand(t, []string{"completion", "fish"})
+	require.Contains(t, output, "complete -c storkctl -f -n '__storkctl_using_command exact get' -a migrations -d 'Get migration resources'")
+	require.Contains(t, output, "complete -c storkctl -f -n '__storkctl_using_command prefix get migrations,migration' -a '(__storkctl_names migrations)'")
+	require.Contains(t, output, "complete -c storkctl -l namespace -s n -r -f -a '(__storkctl_names namespaces)'")
+	require.NotContains(t, output, "-a names", "Hidden commands shouldn't be completed")
+}
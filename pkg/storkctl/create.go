@@ -1,10 +1,19 @@
 package storkctl
 
 import (
+	"fmt"
+	"io"
+
 	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/kubernetes/pkg/kubectl/genericclioptions"
 )
 
+const (
+	dryRunNone   = "none"
+	dryRunClient = "client"
+)
+
 func newCreateCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
 	createCommands := &cobra.Command{
 		Use:   "create",
@@ -18,7 +27,66 @@ func newCreateCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams)
 		newCreatePVCCommand(cmdFactory, ioStreams),
 		newCreateSnapshotScheduleCommand(cmdFactory, ioStreams),
 		newCreateGroupSnapshotCommand(cmdFactory, ioStreams),
+		newCreateClusterPairCommand(cmdFactory, ioStreams),
 	)
 
 	return createCommands
 }
+
+// dryRunStrategy is the value for the dry-run flag, it is validated when the
+// flag is parsed
+type dryRunStrategy string
+
+func (s *dryRunStrategy) String() string {
+	return string(*s)
+}
+
+func (s *dryRunStrategy) Set(value string) error {
+	switch value {
+	case dryRunNone, dryRunClient:
+		*s = dryRunStrategy(value)
+		return nil
+	default:
+		return fmt.Errorf("must be %q or %q", dryRunNone, dryRunClient)
+	}
+}
+
+func (s *dryRunStrategy) Type() string {
+	return "string"
+}
+
+// dryRunOptions are the options for the create commands to print the objects
+// that would be created instead of creating them, so that the specs can be
+// generated and applied separately
+type dryRunOptions struct {
+	strategy dryRunStrategy
+}
+
+func (o *dryRunOptions) addFlags(c *cobra.Command) {
+	o.strategy = dryRunNone
+	c.Flags().Var(&o.strategy, "dry-run", fmt.Sprintf("Must be %q or %q. If client, only print the object that would be "+
+		"created without creating it. Use with -o yaml or -o json to print the spec", dryRunNone, dryRunClient))
+	c.Flags().Lookup("dry-run").NoOptDefVal = dryRunClient
+}
+
+// enabled returns true if the object should only be printed
+func (o *dryRunOptions) enabled() bool {
+	return o.strategy == dryRunClient
+}
+
+// printObject prints the object that would have been created in the output
+// format for the command
+func (o *dryRunOptions) printObject(c *cobra.Command, cmdFactory Factory, object runtime.Object, name string, out io.Writer) error {
+	outputFormat, err := cmdFactory.GetOutputFormat()
+	if err != nil {
+		return err
+	}
+	if outputFormat == outputFormatYaml || outputFormat == outputFormatJSON {
+		return printEncoded(c, object, outputFormat, out)
+	}
+	if err := setObjectKind(object); err != nil {
+		return err
+	}
+	printMsg(fmt.Sprintf("%v %v created (dry run)", object.GetObjectKind().GroupVersionKind().Kind, name), out)
+	return nil
+}
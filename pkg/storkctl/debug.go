@@ -0,0 +1,308 @@
+package storkctl
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/version"
+	"github.com/portworx/sched-ops/k8s"
+	"github.com/spf13/cobra"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/kubernetes/pkg/kubectl/cmd/util"
+	"k8s.io/kubernetes/pkg/kubectl/genericclioptions"
+)
+
+const (
+	debugSubcommand     = "debug"
+	redactedValue       = "REDACTED"
+	debugNameTimeFormat = "2006-01-02-150405"
+)
+
+// storkPodSelectors are the labels for the stork and stork scheduler pods
+// whose logs are collected
+var storkPodSelectors = []map[string]string{
+	{"name": "stork"},
+	{"component": "scheduler", "tier": "control-plane"},
+}
+
+// getPodLogs returns the logs for a container in a pod. Replaced in tests
+// since the fake clients can't return logs
+var getPodLogs = getPodLogsFromAPIServer
+
+// getExtenderHealth returns the response from the health endpoints of the
+// extender. Replaced in tests since it can't be served by the fake clients
+var getExtenderHealth = getExtenderHealthThroughProxy
+
+// debugBundle collects files in memory that are written to a tarball
+type debugBundle struct {
+	files  map[string][]byte
+	names  []string
+	errors []string
+}
+
+func (b *debugBundle) add(name string, data []byte) {
+	if _, present := b.files[name]; !present {
+		b.names = append(b.names, name)
+	}
+	b.files[name] = data
+}
+
+func (b *debugBundle) addError(format string, args ...interface{}) {
+	b.errors = append(b.errors, fmt.Sprintf(format, args...))
+}
+
+func (b *debugBundle) write(fileName string) error {
+	if len(b.errors) > 0 {
+		b.add("errors.txt", []byte(strings.Join(b.errors, "\n")+"\n"))
+	}
+	file, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	gzipWriter := gzip.NewWriter(file)
+	tarWriter := tar.NewWriter(gzipWriter)
+	for _, name := range b.names {
+		header := &tar.Header{
+			Name:    path.Join("stork-debug", name),
+			Mode:    0644,
+			Size:    int64(len(b.files[name])),
+			ModTime: time.Now(),
+		}
+		if err = tarWriter.WriteHeader(header); err != nil {
+			break
+		}
+		if _, err = tarWriter.Write(b.files[name]); err != nil {
+			break
+		}
+	}
+	for _, closer := range []interface{ Close() error }{tarWriter, gzipWriter, file} {
+		if closeErr := closer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+func newDebugCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	debugCommands := &cobra.Command{
+		Use:   debugSubcommand,
+		Short: "Debug stork",
+	}
+
+	debugCommands.AddCommand(
+		newDebugCollectCommand(cmdFactory, ioStreams),
+	)
+
+	return debugCommands
+}
+
+func newDebugCollectCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	var fileName string
+	var storkNamespace string
+	var storkService string
+	debugCollectCommand := &cobra.Command{
+		Use:   "collect",
+		Short: "Collect logs, resources and health information from stork into a tarball",
+		Long: "Collect the logs from the stork pods, the stork resources from all namespaces, the health of the " +
+			"extender and version information into a tarball that can be attached to support cases. " +
+			"Credentials in cluster pairs are redacted.",
+		Run: func(c *cobra.Command, args []string) {
+			if fileName == "" {
+				fileName = fmt.Sprintf("stork-debug-%v.tar.gz", time.Now().Format(debugNameTimeFormat))
+			}
+			config, err := cmdFactory.GetConfig()
+			if err != nil {
+				util.CheckErr(err)
+				return
+			}
+			bundle := &debugBundle{files: make(map[string][]byte)}
+			collectVersions(bundle, storkNamespace)
+			collectLogs(bundle, config, storkNamespace)
+			collectResources(c, bundle)
+			health, err := getExtenderHealth(config, storkNamespace, storkService)
+			if err != nil {
+				bundle.addError("Error getting extender health: %v", err)
+			} else {
+				bundle.add("health.txt", []byte(health))
+			}
+			if err := bundle.write(fileName); err != nil {
+				util.CheckErr(fmt.Errorf("error writing debug bundle %v: %v", fileName, err))
+				return
+			}
+			if len(bundle.errors) > 0 {
+				printMsg("Some information couldn't be collected, see errors.txt in the bundle", ioStreams.ErrOut)
+			}
+			printMsg(fmt.Sprintf("Debug information collected in %v", fileName), ioStreams.Out)
+		},
+	}
+	debugCollectCommand.Flags().StringVarP(&fileName, "file", "f", "", "File to write the tarball to. Defaults to stork-debug-<time>.tar.gz")
+	debugCollectCommand.Flags().StringVar(&storkNamespace, "stork-namespace", defaultStorkNamespace, "Namespace where stork is running")
+	debugCollectCommand.Flags().StringVar(&storkService, "stork-service", defaultStorkService, "Name of the service for stork")
+
+	return debugCollectCommand
+}
+
+// getStorkPods returns the stork and stork scheduler pods
+func getStorkPods(namespace string) ([]v1.Pod, error) {
+	pods := make([]v1.Pod, 0)
+	for _, selector := range storkPodSelectors {
+		podList, err := k8s.Instance().GetPods(namespace, selector)
+		if err != nil {
+			return nil, err
+		}
+		pods = append(pods, podList.Items...)
+	}
+	return pods, nil
+}
+
+func collectVersions(bundle *debugBundle, storkNamespace string) {
+	var versions bytes.Buffer
+	fmt.Fprintf(&versions, "storkctl: %v\n", version.Version)
+	if serverVersion, err := k8s.Instance().GetVersion(); err != nil {
+		bundle.addError("Error getting Kubernetes version: %v", err)
+	} else {
+		fmt.Fprintf(&versions, "kubernetes: %v\n", serverVersion.GitVersion)
+	}
+	pods, err := getStorkPods(storkNamespace)
+	if err != nil {
+		bundle.addError("Error getting stork pods: %v", err)
+	}
+	for _, pod := range pods {
+		for _, container := range pod.Spec.Containers {
+			fmt.Fprintf(&versions, "%v/%v: %v\n", pod.Name, container.Name, container.Image)
+		}
+	}
+	bundle.add("version.txt", versions.Bytes())
+}
+
+func collectLogs(bundle *debugBundle, config *rest.Config, storkNamespace string) {
+	pods, err := getStorkPods(storkNamespace)
+	if err != nil {
+		bundle.addError("Error getting stork pods: %v", err)
+		return
+	}
+	for _, pod := range pods {
+		for _, container := range pod.Spec.Containers {
+			logs, err := getPodLogs(config, &pod, container.Name)
+			if err != nil {
+				bundle.addError("Error getting logs for %v/%v: %v", pod.Name, container.Name, err)
+				continue
+			}
+			bundle.add(path.Join("logs", pod.Name+"-"+container.Name+".log"), logs)
+		}
+	}
+}
+
+func collectResources(c *cobra.Command, bundle *debugBundle) {
+	resources := []struct {
+		name string
+		list func() (runtime.Object, error)
+	}{
+		{"migrations", func() (runtime.Object, error) { return k8s.Instance().ListMigrations("") }},
+		{"migrationschedules", func() (runtime.Object, error) { return k8s.Instance().ListMigrationSchedules("") }},
+		{"clusterpairs", func() (runtime.Object, error) {
+			clusterPairs, err := k8s.Instance().ListClusterPairs("")
+			if err != nil {
+				return nil, err
+			}
+			for i := range clusterPairs.Items {
+				redactClusterPair(&clusterPairs.Items[i])
+			}
+			return clusterPairs, nil
+		}},
+		{"schedulepolicies", func() (runtime.Object, error) { return k8s.Instance().ListSchedulePolicies() }},
+		{"volumesnapshotschedules", func() (runtime.Object, error) { return k8s.Instance().ListSnapshotSchedules("") }},
+		{"groupvolumesnapshots", func() (runtime.Object, error) { return k8s.Instance().ListGroupSnapshots("") }},
+		{"clusterdomainsstatuses", func() (runtime.Object, error) { return k8s.Instance().ListClusterDomainStatuses() }},
+		{"clusterdomainupdates", func() (runtime.Object, error) { return k8s.Instance().ListClusterDomainUpdates() }},
+	}
+	for _, resource := range resources {
+		object, err := resource.list()
+		if err != nil {
+			bundle.addError("Error getting %v: %v", resource.name, err)
+			continue
+		}
+		var encoded bytes.Buffer
+		if err := printEncoded(c, object, outputFormatYaml, &encoded); err != nil {
+			bundle.addError("Error encoding %v: %v", resource.name, err)
+			continue
+		}
+		bundle.add(path.Join("resources", resource.name+".yaml"), encoded.Bytes())
+	}
+}
+
+// redactClusterPair removes the credentials for the remote cluster and
+// storage from the cluster pair
+func redactClusterPair(clusterPair *storkv1.ClusterPair) {
+	for _, authInfo := range clusterPair.Spec.Config.AuthInfos {
+		if authInfo == nil {
+			continue
+		}
+		if authInfo.Token != "" {
+			authInfo.Token = redactedValue
+		}
+		if authInfo.Password != "" {
+			authInfo.Password = redactedValue
+		}
+		if len(authInfo.ClientKeyData) != 0 {
+			authInfo.ClientKeyData = []byte(redactedValue)
+		}
+		if authInfo.AuthProvider != nil {
+			for key := range authInfo.AuthProvider.Config {
+				authInfo.AuthProvider.Config[key] = redactedValue
+			}
+		}
+	}
+	for key := range clusterPair.Spec.Options {
+		lowerKey := strings.ToLower(key)
+		for _, secret := range []string{"token", "key", "secret", "password"} {
+			if strings.Contains(lowerKey, secret) {
+				clusterPair.Spec.Options[key] = redactedValue
+				break
+			}
+		}
+	}
+}
+
+func getPodLogsFromAPIServer(config *rest.Config, pod *v1.Pod, container string) ([]byte, error) {
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return client.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &v1.PodLogOptions{Container: container}).DoRaw()
+}
+
+// getExtenderHealthThroughProxy checks the health endpoints of the extender
+// through the API server proxy for the stork service
+func getExtenderHealthThroughProxy(config *rest.Config, storkNamespace string, storkService string) (string, error) {
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return "", err
+	}
+	var health bytes.Buffer
+	for _, endpoint := range []string{"healthz", "readyz"} {
+		response, err := client.CoreV1().RESTClient().Get().
+			Namespace(storkNamespace).
+			Resource("services").
+			Name(storkService + ":" + extenderPort).
+			SubResource("proxy").
+			Suffix(endpoint).
+			DoRaw()
+		if err != nil {
+			fmt.Fprintf(&health, "%v: %v\n", endpoint, err)
+			continue
+		}
+		fmt.Fprintf(&health, "%v: ok %v\n", endpoint, string(response))
+	}
+	return health.String(), nil
+}
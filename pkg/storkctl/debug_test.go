@@ -0,0 +1,121 @@
+// +build unittest
+
+package storkctl
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/portworx/sched-ops/k8s"
+	"github.com/stretchr/testify/require"
+	"k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+func readDebugBundle(t *testing.T, fileName string) map[string]string {
+	file, err := os.Open(fileName)
+	require.NoError(t, err, "Error opening debug bundle")
+	defer func() {
+		require.NoError(t, file.Close(), "Error closing debug bundle")
+	}()
+	gzipReader, err := gzip.NewReader(file)
+	require.NoError(t, err, "Error reading debug bundle")
+	tarReader := tar.NewReader(gzipReader)
+	files := make(map[string]string)
+	for {
+		header, err := tarReader.Next()
+		if err != nil {
+			break
+		}
+		data, err := ioutil.ReadAll(tarReader)
+		require.NoError(t, err, "Error reading %v from debug bundle", header.Name)
+		files[header.Name] = string(data)
+	}
+	return files
+}
+
+func TestDebugCollect(t *testing.T) {
+	defer resetTest()
+	defer func() {
+		getPodLogs = getPodLogsFromAPIServer
+		getExtenderHealth = getExtenderHealthThroughProxy
+	}()
+	getPodLogs = func(_ *rest.Config, pod *v1.Pod, container string) ([]byte, error) {
+		if container == "failing" {
+			return nil, fmt.Errorf("container not running")
+		}
+		return []byte("logs for " + pod.Name + "\n"), nil
+	}
+	getExtenderHealth = func(_ *rest.Config, storkNamespace string, storkService string) (string, error) {
+		require.Equal(t, defaultStorkNamespace, storkNamespace)
+		require.Equal(t, defaultStorkService, storkService)
+		return "healthz: ok\n", nil
+	}
+
+	_, err := k8s.Instance().CreatePod(&v1.Pod{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      "stork-1",
+			Namespace: defaultStorkNamespace,
+			Labels:    map[string]string{"name": "stork"},
+		},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{Name: "stork", Image: "openstorage/stork:test"},
+				{Name: "failing", Image: "failing:test"},
+			},
+		},
+	})
+	require.NoError(t, err, "Error creating pod")
+
+	_, err = k8s.Instance().CreateClusterPair(&storkv1.ClusterPair{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      "debugpair",
+			Namespace: "test",
+		},
+		Spec: storkv1.ClusterPairSpec{
+			Config: clientcmdapi.Config{
+				AuthInfos: map[string]*clientcmdapi.AuthInfo{
+					"user": {Token: "remotetoken"},
+				},
+			},
+			Options: map[string]string{
+				"ip":    "10.0.0.1",
+				"token": "storagetoken",
+			},
+		},
+	})
+	require.NoError(t, err, "Error creating clusterpair")
+
+	dir, err := ioutil.TempDir("", "storkctl-debug")
+	require.NoError(t, err, "Error creating temp dir")
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir), "Error removing temp dir")
+	}()
+	fileName := path.Join(dir, "bundle.tar.gz")
+
+	cmdArgs := []string{"debug", "collect", "-f", fileName}
+	expected := "Debug information collected in " + fileName + "\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+
+	files := readDebugBundle(t, fileName)
+	require.Contains(t, files["stork-debug/version.txt"], "stork-1/stork: openstorage/stork:test")
+	require.Equal(t, "logs for stork-1\n", files["stork-debug/logs/stork-1-stork.log"])
+	require.Contains(t, files["stork-debug/errors.txt"], "Error getting logs for stork-1/failing: container not running")
+	require.Equal(t, "healthz: ok\n", files["stork-debug/health.txt"])
+	require.Contains(t, files, "stork-debug/resources/migrations.yaml")
+
+	clusterPairs := files["stork-debug/resources/clusterpairs.yaml"]
+	require.Contains(t, clusterPairs, "debugpair")
+	require.Contains(t, clusterPairs, "10.0.0.1")
+	require.Contains(t, clusterPairs, redactedValue)
+	require.NotContains(t, clusterPairs, "remotetoken")
+	require.NotContains(t, clusterPairs, "storagetoken")
+}
@@ -0,0 +1,254 @@
+package storkctl
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/portworx/sched-ops/k8s"
+	"github.com/spf13/cobra"
+	"k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/kubernetes/pkg/kubectl/cmd/util"
+	"k8s.io/kubernetes/pkg/kubectl/genericclioptions"
+)
+
+// failureCause is a likely cause for a failure that is reported if any of the
+// patterns are found in the failure messages
+type failureCause struct {
+	patterns []string
+	cause    string
+}
+
+// failureCauses are matched in order against the lowercased reasons and
+// warning events for a failed operation
+var failureCauses = []failureCause{
+	{
+		patterns: []string{"exceeded quota"},
+		cause:    "A resource quota in the destination namespace was exceeded",
+	},
+	{
+		patterns: []string{"forbidden: user", "unauthorized"},
+		cause:    "The credentials in the clusterpair don't have permissions for the resources on the destination cluster",
+	},
+	{
+		patterns: []string{"x509:"},
+		cause:    "The certificate for the destination cluster in the clusterpair isn't valid",
+	},
+	{
+		patterns: []string{"connection refused", "i/o timeout", "no such host", "no route to host"},
+		cause:    "The destination cluster couldn't be reached from the source cluster",
+	},
+	{
+		patterns: []string{"error getting namespace"},
+		cause:    "A namespace being migrated doesn't exist on the source cluster",
+	},
+	{
+		patterns: []string{"preexecrule", "postexecrule"},
+		cause:    "A rule for the migration is missing or failed to run on the application pods",
+	},
+	{
+		patterns: []string{"clusterpair", "cluster pair"},
+		cause:    "The clusterpair is missing or isn't ready, check its status with storkctl get clusterpair",
+	},
+	{
+		patterns: []string{"already exists"},
+		cause:    "Resources with the same name already exist on the destination cluster",
+	},
+	{
+		patterns: []string{"storageclass", "storage class"},
+		cause:    "A storage class used by the PVCs is missing on the destination cluster",
+	},
+}
+
+func newDescribeCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	describeCommands := &cobra.Command{
+		Use:   "describe",
+		Short: "Show details for stork resources",
+	}
+
+	describeCommands.AddCommand(
+		newDescribeMigrationCommand(cmdFactory, ioStreams),
+	)
+
+	return describeCommands
+}
+
+func newDescribeMigrationCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	describeMigrationCommand := &cobra.Command{
+		Use:     migrationSubcommand,
+		Aliases: migrationAliases,
+		Short:   "Show the status, events and likely cause of failures for a migration",
+		Run: func(c *cobra.Command, args []string) {
+			if len(args) != 1 {
+				util.CheckErr(fmt.Errorf("exactly one name needs to be provided for migration name"))
+				return
+			}
+			migration, err := k8s.Instance().GetMigration(args[0], cmdFactory.GetNamespace())
+			if err != nil {
+				util.CheckErr(err)
+				return
+			}
+			events, err := getObjectEvents(migration.Namespace, reflect.TypeOf(storkv1.Migration{}).Name(), migration.Name)
+			if err != nil {
+				util.CheckErr(err)
+				return
+			}
+			if err := describeMigration(migration, events, ioStreams.Out); err != nil {
+				util.CheckErr(err)
+				return
+			}
+		},
+	}
+
+	return describeMigrationCommand
+}
+
+// getObjectEvents returns the events for an object sorted by the time they
+// were last seen
+func getObjectEvents(namespace string, kind string, name string) ([]v1.Event, error) {
+	selector := fields.Set{
+		"involvedObject.kind": kind,
+		"involvedObject.name": name,
+	}.AsSelector().String()
+	eventList, err := k8s.Instance().ListEvents(namespace, meta.ListOptions{FieldSelector: selector})
+	if err != nil {
+		return nil, err
+	}
+	// The field selector isn't supported by all clients, so the events are
+	// filtered here too
+	events := make([]v1.Event, 0)
+	for _, event := range eventList.Items {
+		if event.InvolvedObject.Kind == kind && event.InvolvedObject.Name == name {
+			events = append(events, event)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].LastTimestamp.Before(&events[j].LastTimestamp)
+	})
+	return events, nil
+}
+
+func describeMigration(migration *storkv1.Migration, events []v1.Event, out io.Writer) error {
+	doneVolumes := 0
+	failures := make([]string, 0)
+	for _, volume := range migration.Status.Volumes {
+		if volume.Status == storkv1.MigrationStatusSuccessful {
+			doneVolumes++
+		} else if volume.Status == storkv1.MigrationStatusFailed {
+			failures = append(failures, fmt.Sprintf("Volume %v: %v",
+				getObjectName(volume.Namespace, volume.PersistentVolumeClaim), volume.Reason))
+		}
+	}
+	doneResources := 0
+	for _, resource := range migration.Status.Resources {
+		if resource.Status == storkv1.MigrationStatusSuccessful {
+			doneResources++
+		} else if resource.Status == storkv1.MigrationStatusFailed {
+			failures = append(failures, fmt.Sprintf("%v %v: %v",
+				resource.Kind, getObjectName(resource.Namespace, resource.Name), resource.Reason))
+		}
+	}
+
+	writer := tabwriter.NewWriter(out, 0, 8, 1, ' ', 0)
+	for _, field := range [][2]interface{}{
+		{"Name", migration.Name},
+		{"Namespace", migration.Namespace},
+		{"ClusterPair", migration.Spec.ClusterPair},
+		{"Namespaces", strings.Join(migration.Spec.Namespaces, ",")},
+		{"MigrationSchedule", getMigrationScheduleName(migration)},
+		{"Stage", migration.Status.Stage},
+		{"Status", migration.Status.Status},
+		{"Volumes", fmt.Sprintf("%v/%v", doneVolumes, len(migration.Status.Volumes))},
+		{"Resources", fmt.Sprintf("%v/%v", doneResources, len(migration.Status.Resources))},
+		{"Created", toTimeString(migration.CreationTimestamp.Time)},
+		{"Finished", toTimeString(migration.Status.FinishTimestamp.Time)},
+	} {
+		if _, err := fmt.Fprintf(writer, "%v:\t%v\n", field[0], field[1]); err != nil {
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	if len(failures) != 0 {
+		printMsg("\nFailures:", out)
+		for _, failure := range failures {
+			printMsg("  "+failure, out)
+		}
+	}
+
+	messages := make([]string, 0, len(failures)+len(events))
+	messages = append(messages, failures...)
+	printMsg("\nEvents:", out)
+	if len(events) == 0 {
+		printMsg("  <none>", out)
+	} else {
+		writer = tabwriter.NewWriter(out, 0, 8, 3, ' ', 0)
+		if _, err := fmt.Fprintln(writer, "  TYPE\tREASON\tLAST-SEEN\tCOUNT\tMESSAGE"); err != nil {
+			return err
+		}
+		for _, event := range events {
+			if _, err := fmt.Fprintf(writer, "  %v\t%v\t%v\t%v\t%v\n",
+				event.Type,
+				event.Reason,
+				toTimeString(event.LastTimestamp.Time),
+				event.Count,
+				event.Message); err != nil {
+				return err
+			}
+			if event.Type == v1.EventTypeWarning {
+				messages = append(messages, event.Message)
+			}
+		}
+		if err := writer.Flush(); err != nil {
+			return err
+		}
+	}
+
+	if migration.Status.Status == storkv1.MigrationStatusFailed ||
+		migration.Status.Status == storkv1.MigrationStatusPartialSuccess ||
+		len(messages) != 0 {
+		printMsg("\nLikely causes:", out)
+		causes := getLikelyCauses(messages)
+		if len(causes) == 0 {
+			printMsg("  Unknown, collect the stork logs with storkctl debug collect for more details", out)
+		}
+		for _, cause := range causes {
+			printMsg("  - "+cause, out)
+		}
+	}
+	return nil
+}
+
+// getLikelyCauses returns the causes whose patterns are found in the
+// messages. The messages are usually wrapped API errors, so they are matched
+// without looking at their structure.
+func getLikelyCauses(messages []string) []string {
+	causes := make([]string, 0)
+	for _, failureCause := range failureCauses {
+		found := false
+		for _, message := range messages {
+			message = strings.ToLower(message)
+			for _, pattern := range failureCause.patterns {
+				if strings.Contains(message, pattern) {
+					found = true
+					break
+				}
+			}
+			if found {
+				break
+			}
+		}
+		if found {
+			causes = append(causes, failureCause.cause)
+		}
+	}
+	return causes
+}
@@ -0,0 +1,119 @@
+// +build unittest
+
+package storkctl
+
+import (
+	"testing"
+
+	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/portworx/sched-ops/k8s"
+	"github.com/stretchr/testify/require"
+	"k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func createMigrationEvent(t *testing.T, name string, migrationName string, eventType string, message string) {
+	_, err := k8s.Instance().CreateEvent(&v1.Event{
+		ObjectMeta: meta.ObjectMeta{Name: name, Namespace: "test"},
+		InvolvedObject: v1.ObjectReference{
+			Kind:      "Migration",
+			Name:      migrationName,
+			Namespace: "test",
+		},
+		Type:    eventType,
+		Reason:  string(storkv1.MigrationStatusFailed),
+		Count:   1,
+		Message: message,
+	})
+	require.NoError(t, err, "Error creating event")
+}
+
+func TestDescribeMigration(t *testing.T) {
+	defer resetTest()
+	createMigrationAndVerify(t, "describemigration", "test", "clusterpair1", []string{"ns1"}, "", "")
+
+	cmdArgs := []string{"describe", "migrations", "describemigration", "-n", "test"}
+	expected := "Name:              describemigration\n" +
+		"Namespace:         test\n" +
+		"ClusterPair:       clusterpair1\n" +
+		"Namespaces:        ns1\n" +
+		"MigrationSchedule: \n" +
+		"Stage:             \n" +
+		"Status:            \n" +
+		"Volumes:           0/0\n" +
+		"Resources:         0/0\n" +
+		"Created:           \n" +
+		"Finished:          \n" +
+		"\nEvents:\n" +
+		"  <none>\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+
+	migration, err := k8s.Instance().GetMigration("describemigration", "test")
+	require.NoError(t, err, "Error getting migration")
+	migration.Status.Stage = storkv1.MigrationStageFinal
+	migration.Status.Status = storkv1.MigrationStatusPartialSuccess
+	migration.Status.Volumes = []*storkv1.VolumeInfo{
+		{PersistentVolumeClaim: "pvc1", Namespace: "ns1", Status: storkv1.MigrationStatusSuccessful},
+	}
+	migration.Status.Resources = []*storkv1.ResourceInfo{
+		{
+			Name:             "mysql",
+			Namespace:        "ns1",
+			GroupVersionKind: meta.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+			Status:           storkv1.MigrationStatusSuccessful,
+		},
+		{
+			Name:             "pvc1",
+			Namespace:        "ns1",
+			GroupVersionKind: meta.GroupVersionKind{Version: "v1", Kind: "PersistentVolumeClaim"},
+			Status:           storkv1.MigrationStatusFailed,
+			Reason: "Error applying resource: persistentvolumeclaims \"pvc1\" is forbidden: " +
+				"exceeded quota: storage, requested: requests.storage=10Gi",
+		},
+	}
+	_, err = k8s.Instance().UpdateMigration(migration)
+	require.NoError(t, err, "Error updating migration")
+	createMigrationEvent(t, "event1", "describemigration", v1.EventTypeWarning,
+		"Error migrating resources: dial tcp 10.0.0.1:6443: connect: connection refused")
+	// Events for other migrations shouldn't be shown
+	createMigrationEvent(t, "event2", "othermigration", v1.EventTypeWarning, "Error getting namespace ns2")
+
+	expected = "Name:              describemigration\n" +
+		"Namespace:         test\n" +
+		"ClusterPair:       clusterpair1\n" +
+		"Namespaces:        ns1\n" +
+		"MigrationSchedule: \n" +
+		"Stage:             Final\n" +
+		"Status:            PartialSuccess\n" +
+		"Volumes:           1/1\n" +
+		"Resources:         1/2\n" +
+		"Created:           \n" +
+		"Finished:          \n" +
+		"\nFailures:\n" +
+		"  PersistentVolumeClaim ns1/pvc1: Error applying resource: persistentvolumeclaims \"pvc1\" is forbidden: " +
+		"exceeded quota: storage, requested: requests.storage=10Gi\n" +
+		"\nEvents:\n" +
+		"  TYPE      REASON   LAST-SEEN   COUNT   MESSAGE\n" +
+		"  Warning   Failed               1       Error migrating resources: dial tcp 10.0.0.1:6443: connect: connection refused\n" +
+		"\nLikely causes:\n" +
+		"  - A resource quota in the destination namespace was exceeded\n" +
+		"  - The destination cluster couldn't be reached from the source cluster\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+
+	cmdArgs = []string{"describe", "migrations", "-n", "test"}
+	expected = "error: exactly one name needs to be provided for migration name"
+	testCommon(t, cmdArgs, nil, expected, true)
+}
+
+func TestGetLikelyCauses(t *testing.T) {
+	require.Empty(t, getLikelyCauses([]string{"Error migrating volumes: timed out"}))
+	require.Equal(t,
+		[]string{"A rule for the migration is missing or failed to run on the application pods"},
+		getLikelyCauses([]string{"Error getting PreExecRule rule1: rules.stork.libopenstorage.org \"rule1\" not found"}))
+	require.Equal(t,
+		[]string{"The certificate for the destination cluster in the clusterpair isn't valid"},
+		getLikelyCauses([]string{"Get https://dest:6443/api: x509: certificate signed by unknown authority"}))
+	require.Equal(t,
+		[]string{"The credentials in the clusterpair don't have permissions for the resources on the destination cluster"},
+		getLikelyCauses([]string{"deployments.apps is forbidden: User \"system:serviceaccount:kube-system:stork\" cannot create deployments"}))
+}
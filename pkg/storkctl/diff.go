@@ -0,0 +1,299 @@
+package storkctl
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	migration "github.com/libopenstorage/stork/pkg/migration/controllers"
+	"github.com/portworx/sched-ops/k8s"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/kubernetes/pkg/kubectl/cmd/util"
+	"k8s.io/kubernetes/pkg/kubectl/genericclioptions"
+)
+
+const diffMissingValue = "<none>"
+
+// getClusterDynamicClient returns the dynamic client for the cluster in the
+// config. Replaced in tests since the fake clients can't be created from a
+// config
+var getClusterDynamicClient = newClusterDynamicClient
+
+func newClusterDynamicClient(config clientcmdapi.Config) (dynamic.Interface, error) {
+	restConfig, err := clientcmd.NewDefaultClientConfig(config, &clientcmd.ConfigOverrides{}).ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	return dynamic.NewForConfig(restConfig)
+}
+
+// Metadata annotations that are set by the API server or the controllers and
+// are expected to be different between the clusters
+var ignoredDiffAnnotations = []string{
+	"kubectl.kubernetes.io/last-applied-configuration",
+	"deployment.kubernetes.io/revision",
+	"pv.kubernetes.io/",
+	"volume.beta.kubernetes.io/storage-provisioner",
+}
+
+func newDiffCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	diffCommands := &cobra.Command{
+		Use:   "diff",
+		Short: "Compare resources between the source and destination clusters",
+	}
+
+	diffCommands.AddCommand(
+		newDiffMigrationCommand(cmdFactory, ioStreams),
+	)
+
+	return diffCommands
+}
+
+func newDiffMigrationCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	destOptions := &destClusterOptions{}
+	diffMigrationCommand := &cobra.Command{
+		Use:     migrationSubcommand,
+		Aliases: migrationAliases,
+		Short:   "Compare the resources collected by a migration with the ones on the destination cluster",
+		Run: func(c *cobra.Command, args []string) {
+			if len(args) != 1 {
+				util.CheckErr(fmt.Errorf("exactly one name needs to be provided for migration name"))
+				return
+			}
+			namespace := cmdFactory.GetNamespace()
+			migrationObj, err := k8s.Instance().GetMigration(args[0], namespace)
+			if err != nil {
+				util.CheckErr(err)
+				return
+			}
+			if len(migrationObj.Status.Resources) == 0 {
+				handleEmptyList(ioStreams.Out)
+				return
+			}
+			clusterPair, err := k8s.Instance().GetClusterPair(migrationObj.Spec.ClusterPair, namespace)
+			if err != nil {
+				util.CheckErr(err)
+				return
+			}
+			sourceClient, err := getDynamicClient(cmdFactory)
+			if err != nil {
+				util.CheckErr(err)
+				return
+			}
+			destConfig, err := destOptions.getConfig(cmdFactory, clusterPair)
+			if err != nil {
+				util.CheckErr(err)
+				return
+			}
+			destClient, err := getClusterDynamicClient(destConfig)
+			if err != nil {
+				util.CheckErr(fmt.Errorf("error connecting to destination cluster: %v", err))
+				return
+			}
+			if err := diffMigration(migrationObj, sourceClient, destClient, ioStreams); err != nil {
+				util.CheckErr(err)
+				return
+			}
+		},
+	}
+	destOptions.addFlags(diffMigrationCommand)
+
+	return diffMigrationCommand
+}
+
+// diffMigration compares the resources in the status of the migration with
+// the ones on the destination cluster and prints the fields that differ for
+// each of them. Returns an error if any of the resources aren't in sync.
+func diffMigration(
+	migrationObj *storkv1.Migration,
+	sourceClient dynamic.Interface,
+	destClient dynamic.Interface,
+	ioStreams genericclioptions.IOStreams,
+) error {
+	inSync := 0
+	for _, resource := range migrationObj.Status.Resources {
+		name := getObjectName(resource.Namespace, resource.Name)
+		source, err := getDiffResource(sourceClient, resource)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				printMsg(fmt.Sprintf("%v %v: deleted on source", resource.Kind, name), ioStreams.Out)
+				continue
+			}
+			printMsg(fmt.Sprintf("%v %v: error getting from source: %v", resource.Kind, name, err), ioStreams.Out)
+			continue
+		}
+		dest, err := getDiffResource(destClient, resource)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				printMsg(fmt.Sprintf("%v %v: missing on destination", resource.Kind, name), ioStreams.Out)
+				continue
+			}
+			printMsg(fmt.Sprintf("%v %v: error getting from destination: %v", resource.Kind, name, err), ioStreams.Out)
+			continue
+		}
+
+		prepareDiffResource(source, migrationObj)
+		prepareDiffResource(dest, migrationObj)
+		diffs := diffValues("", source.Object, dest.Object)
+		if len(diffs) == 0 {
+			printMsg(fmt.Sprintf("%v %v: in sync", resource.Kind, name), ioStreams.Out)
+			inSync++
+			continue
+		}
+		printMsg(fmt.Sprintf("%v %v: differs", resource.Kind, name), ioStreams.Out)
+		for _, diff := range diffs {
+			printMsg("  "+diff, ioStreams.Out)
+		}
+	}
+
+	total := len(migrationObj.Status.Resources)
+	printMsg(fmt.Sprintf("%v/%v resources in sync", inSync, total), ioStreams.Out)
+	if inSync != total {
+		return fmt.Errorf("%v resources are out of sync with the destination cluster", total-inSync)
+	}
+	return nil
+}
+
+// getDiffResource gets a resource from a migration status. The resource name
+// is built from the kind the same way as when applying migrated resources.
+func getDiffResource(client dynamic.Interface, resource *storkv1.ResourceInfo) (*unstructured.Unstructured, error) {
+	group := resource.Group
+	// core Group doesn't have a name in the API
+	if group == "core" {
+		group = ""
+	}
+	gvr := schema.GroupVersionResource{
+		Group:    group,
+		Version:  resource.Version,
+		Resource: strings.ToLower(resource.Kind) + "s",
+	}
+	if resource.Namespace == "" {
+		return client.Resource(gvr).Get(resource.Name, metav1.GetOptions{})
+	}
+	return client.Resource(gvr).Namespace(resource.Namespace).Get(resource.Name, metav1.GetOptions{})
+}
+
+// prepareDiffResource removes the fields that are expected to be different
+// between the clusters, either because they are set by the API server or
+// because they are updated by the migration
+func prepareDiffResource(object *unstructured.Unstructured, migrationObj *storkv1.Migration) {
+	delete(object.Object, "status")
+	metadata := map[string]interface{}{
+		"name": object.GetName(),
+	}
+	if object.GetNamespace() != "" {
+		metadata["namespace"] = object.GetNamespace()
+	}
+	if labels := object.GetLabels(); len(labels) != 0 {
+		metadata["labels"] = labels
+	}
+	annotations := object.GetAnnotations()
+	for key := range annotations {
+		for _, ignored := range ignoredDiffAnnotations {
+			if strings.HasPrefix(key, ignored) {
+				delete(annotations, key)
+			}
+		}
+	}
+	object.Object["metadata"] = metadata
+
+	switch object.GetKind() {
+	case "PersistentVolume":
+		// The volume source is updated by the driver for the destination
+		// cluster, so only the existence of the PV is compared
+		delete(object.Object, "spec")
+	case "Service":
+		// Cluster IPs are allocated on each cluster, except for headless
+		// services
+		if clusterIP, _, _ := unstructured.NestedString(object.Object, "spec", "clusterIP"); clusterIP != "None" {
+			unstructured.RemoveNestedField(object.Object, "spec", "clusterIP")
+		}
+	case "Deployment", "StatefulSet", "DeploymentConfig":
+		// Applications are scaled down on the destination unless the
+		// migration started them
+		if migrationObj.Spec.StartApplications == nil || !*migrationObj.Spec.StartApplications {
+			unstructured.RemoveNestedField(object.Object, "spec", "replicas")
+			delete(annotations, migration.StorkMigrationReplicasAnnotation)
+		}
+	}
+	if len(annotations) != 0 {
+		metadata["annotations"] = annotations
+	}
+}
+
+// diffValues returns the paths of the fields that are different between the
+// source and destination values along with both the values
+func diffValues(path string, source interface{}, dest interface{}) []string {
+	sourceMap, sourceIsMap := source.(map[string]interface{})
+	destMap, destIsMap := dest.(map[string]interface{})
+	if sourceIsMap && destIsMap {
+		keys := make(map[string]bool)
+		for key := range sourceMap {
+			keys[key] = true
+		}
+		for key := range destMap {
+			keys[key] = true
+		}
+		sortedKeys := make([]string, 0, len(keys))
+		for key := range keys {
+			sortedKeys = append(sortedKeys, key)
+		}
+		sort.Strings(sortedKeys)
+
+		diffs := make([]string, 0)
+		for _, key := range sortedKeys {
+			keyPath := key
+			if path != "" {
+				keyPath = path + "." + key
+			}
+			sourceValue, sourcePresent := sourceMap[key]
+			destValue, destPresent := destMap[key]
+			if !sourcePresent || !destPresent {
+				diffs = append(diffs, formatDiff(keyPath, sourceValue, sourcePresent, destValue, destPresent))
+				continue
+			}
+			diffs = append(diffs, diffValues(keyPath, sourceValue, destValue)...)
+		}
+		return diffs
+	}
+
+	sourceSlice, sourceIsSlice := source.([]interface{})
+	destSlice, destIsSlice := dest.([]interface{})
+	if sourceIsSlice && destIsSlice && len(sourceSlice) == len(destSlice) {
+		diffs := make([]string, 0)
+		for i := range sourceSlice {
+			diffs = append(diffs, diffValues(fmt.Sprintf("%v[%v]", path, i), sourceSlice[i], destSlice[i])...)
+		}
+		return diffs
+	}
+
+	if reflect.DeepEqual(source, dest) {
+		return nil
+	}
+	return []string{formatDiff(path, source, true, dest, true)}
+}
+
+func formatDiff(path string, source interface{}, sourcePresent bool, dest interface{}, destPresent bool) string {
+	return fmt.Sprintf("%v: %v -> %v", path, formatDiffValue(source, sourcePresent), formatDiffValue(dest, destPresent))
+}
+
+func formatDiffValue(value interface{}, present bool) string {
+	if !present {
+		return diffMissingValue
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(data)
+}
@@ -0,0 +1,173 @@
+// +build unittest
+
+package storkctl
+
+import (
+	"os"
+	"testing"
+
+	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	migration "github.com/libopenstorage/stork/pkg/migration/controllers"
+	"github.com/portworx/sched-ops/k8s"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// testDynamicClient returns objects that are kept in memory. Only getting
+// objects is supported.
+type testDynamicClient struct {
+	objects map[string]*unstructured.Unstructured
+}
+
+type testResourceClient struct {
+	dynamic.ResourceInterface
+	client    *testDynamicClient
+	resource  schema.GroupVersionResource
+	namespace string
+}
+
+func (c *testDynamicClient) add(resource string, object map[string]interface{}) {
+	obj := &unstructured.Unstructured{Object: object}
+	c.objects[resource+"/"+obj.GetNamespace()+"/"+obj.GetName()] = obj
+}
+
+func (c *testDynamicClient) Resource(resource schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	return &testResourceClient{client: c, resource: resource}
+}
+
+func (c *testResourceClient) Namespace(namespace string) dynamic.ResourceInterface {
+	return &testResourceClient{client: c.client, resource: c.resource, namespace: namespace}
+}
+
+func (c *testResourceClient) Get(name string, options meta.GetOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	obj, present := c.client.objects[c.resource.Resource+"/"+c.namespace+"/"+name]
+	if !present {
+		return nil, errors.NewNotFound(c.resource.GroupResource(), name)
+	}
+	return obj.DeepCopy(), nil
+}
+
+func newDiffDeployment(replicas int64, image string, annotations map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]interface{}{
+			"name":            "mysql",
+			"namespace":       "ns1",
+			"uid":             "1234",
+			"resourceVersion": "10",
+			"annotations":     annotations,
+		},
+		"spec": map[string]interface{}{
+			"replicas": replicas,
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{"name": "mysql", "image": image},
+					},
+				},
+			},
+		},
+		"status": map[string]interface{}{
+			"readyReplicas": replicas,
+		},
+	}
+}
+
+func TestDiffMigration(t *testing.T) {
+	defer resetTest()
+	createClusterPairAndVerify(t, "clusterpair1", "test")
+	createMigrationAndVerify(t, "diffmigration", "test", "clusterpair1", []string{"ns1"}, "", "")
+
+	source := &testDynamicClient{objects: make(map[string]*unstructured.Unstructured)}
+	dest := &testDynamicClient{objects: make(map[string]*unstructured.Unstructured)}
+	getDynamicClient = func(_ Factory) (dynamic.Interface, error) {
+		return source, nil
+	}
+	getClusterDynamicClient = func(_ clientcmdapi.Config) (dynamic.Interface, error) {
+		return dest, nil
+	}
+	defer func() {
+		getDynamicClient = newDynamicClient
+		getClusterDynamicClient = newClusterDynamicClient
+	}()
+
+	cmdArgs := []string{"diff", "migrations", "diffmigration", "-n", "test"}
+	expected := "No resources found.\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+
+	migrationObj, err := k8s.Instance().GetMigration("diffmigration", "test")
+	require.NoError(t, err, "Error getting migration")
+	startApplications := false
+	migrationObj.Spec.StartApplications = &startApplications
+	migrationObj.Status.Resources = []*storkv1.ResourceInfo{
+		{
+			Name:             "mysql",
+			Namespace:        "ns1",
+			GroupVersionKind: meta.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+		},
+		{
+			Name:             "config",
+			Namespace:        "ns1",
+			GroupVersionKind: meta.GroupVersionKind{Group: "core", Version: "v1", Kind: "ConfigMap"},
+		},
+	}
+	_, err = k8s.Instance().UpdateMigration(migrationObj)
+	require.NoError(t, err, "Error updating migration")
+
+	source.add("deployments", newDiffDeployment(3, "mysql:5.7", nil))
+	source.add("configmaps", map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]interface{}{"name": "config", "namespace": "ns1"},
+		"data":       map[string]interface{}{"key": "value"},
+	})
+	// The replicas are expected to be different since the applications
+	// aren't started on the destination
+	dest.add("deployments", newDiffDeployment(0, "mysql:5.7",
+		map[string]interface{}{migration.StorkMigrationReplicasAnnotation: "3"}))
+
+	expected = "Deployment ns1/mysql: in sync\n" +
+		"ConfigMap ns1/config: missing on destination\n" +
+		"1/2 resources in sync\n"
+	require.Equal(t, expected, runFailingCommand(t, cmdArgs, "error: 1 resources are out of sync with the destination cluster"))
+
+	dest.add("deployments", newDiffDeployment(0, "mysql:5.6", nil))
+	dest.add("configmaps", map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]interface{}{"name": "config", "namespace": "ns1"},
+		"data":       map[string]interface{}{"key": "value", "other": "value"},
+	})
+	expected = "Deployment ns1/mysql: differs\n" +
+		"  spec.template.spec.containers[0].image: \"mysql:5.7\" -> \"mysql:5.6\"\n" +
+		"ConfigMap ns1/config: differs\n" +
+		"  data.other: <none> -> \"value\"\n" +
+		"0/2 resources in sync\n"
+	require.Equal(t, expected, runFailingCommand(t, cmdArgs, "error: 2 resources are out of sync with the destination cluster"))
+
+	// The destination context from the kubeconfig is used instead of the
+	// config in the cluster pair
+	kubeconfig := setupClusterPairKubeconfig(t)
+	defer func() {
+		getClusterOps = newClusterOps
+		require.NoError(t, os.Remove(kubeconfig), "Error removing kubeconfig")
+	}()
+	var destConfig clientcmdapi.Config
+	getClusterDynamicClient = func(config clientcmdapi.Config) (dynamic.Interface, error) {
+		destConfig = config
+		return dest, nil
+	}
+	runFailingCommand(t, append(cmdArgs, "--kubeconfig", kubeconfig, "--dest-context", "dest"),
+		"error: 2 resources are out of sync with the destination cluster")
+	require.Equal(t, "dest", destConfig.CurrentContext)
+
+	cmdArgs = []string{"diff", "migrations", "-n", "test"}
+	expected = "error: exactly one name needs to be provided for migration name"
+	testCommon(t, cmdArgs, nil, expected, true)
+}
@@ -12,6 +12,7 @@ import (
 
 const (
 	outputFormatTable = "table"
+	outputFormatWide  = "wide"
 	outputFormatYaml  = "yaml"
 	outputFormatJSON  = "json"
 )
@@ -22,6 +23,7 @@ type factory struct {
 	kubeconfig    string
 	context       string
 	outputFormat  string
+	noHeaders     bool
 }
 
 // Factory to be used for command line
@@ -33,6 +35,8 @@ type Factory interface {
 
 	// AllNamespaces Retruns true if the all-namespaces flag was used
 	AllNamespaces() bool
+	// NoHeaders Returns true if the headers shouldn't be printed for tables
+	NoHeaders() bool
 	// GetNamespace Gets the namespace used for the command
 	GetNamespace() string
 	// GetAllNamespaces Get all the namespaces that should be used for a command
@@ -60,7 +64,8 @@ func (f *factory) BindFlags(flags *pflag.FlagSet) {
 	flags.StringVarP(&f.namespace, "namespace", "n", "default", "If present, the namespace scope for this CLI request")
 	flags.StringVar(&f.kubeconfig, "kubeconfig", "", "Path to the kubeconfig file to use for CLI requests")
 	flags.StringVar(&f.context, "context", "", "The name of the kubeconfig context to use")
-	flags.StringVarP(&f.outputFormat, "output", "o", outputFormatTable, "Output format. One of: table|json|yaml")
+	flags.StringVarP(&f.outputFormat, "output", "o", outputFormatTable, "Output format. One of: table|wide|json|yaml")
+	flags.BoolVar(&f.noHeaders, "no-headers", false, "When using the table or wide output format, don't print headers")
 }
 
 func (f *factory) BindGetFlags(flags *pflag.FlagSet) {
@@ -72,6 +77,10 @@ func (f *factory) AllNamespaces() bool {
 	return f.allNamespaces
 }
 
+func (f *factory) NoHeaders() bool {
+	return f.noHeaders
+}
+
 func (f *factory) GetNamespace() string {
 	return f.namespace
 }
@@ -129,7 +138,7 @@ func (f *factory) RawConfig() (clientcmdapi.Config, error) {
 
 func (f *factory) GetOutputFormat() (string, error) {
 	switch f.outputFormat {
-	case outputFormatTable, outputFormatYaml, outputFormatJSON:
+	case outputFormatTable, outputFormatWide, outputFormatYaml, outputFormatJSON:
 		return f.outputFormat, nil
 	default:
 		return "", fmt.Errorf("unsupported output type %v", f.outputFormat)
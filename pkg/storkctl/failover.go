@@ -0,0 +1,327 @@
+package storkctl
+
+import (
+	"fmt"
+	"time"
+
+	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/portworx/sched-ops/k8s"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/errors"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/kubernetes/pkg/kubectl/cmd/util"
+	"k8s.io/kubernetes/pkg/kubectl/genericclioptions"
+)
+
+const (
+	failoverSubcommand       = "failover"
+	failbackSubcommand       = "failback"
+	failoverNameTimeFormat   = "2006-01-02-150405"
+	defaultMigrationWaitTime = 30 * time.Minute
+)
+
+// planStep is a single step in a failover or failback plan
+type planStep struct {
+	description string
+	run         func() error
+}
+
+// planOptions are the options common to the failover and failback commands
+type planOptions struct {
+	migrationScheduleName string
+	dryRun                bool
+	skipConfirmation      bool
+	waitTimeout           time.Duration
+	dest                  destClusterOptions
+}
+
+func (o *planOptions) addFlags(c *cobra.Command) {
+	c.Flags().StringVarP(&o.migrationScheduleName, "migrationschedule", "m", "", "Name of the migration schedule used to migrate the applications")
+	c.Flags().BoolVar(&o.dryRun, "dry-run", false, "Only print the steps that would be performed")
+	c.Flags().BoolVarP(&o.skipConfirmation, "yes", "y", false, "Perform the steps without asking for confirmation")
+	c.Flags().DurationVar(&o.waitTimeout, "wait-timeout", defaultMigrationWaitTime, "Time to wait for migrations to complete")
+	o.dest.addFlags(c)
+}
+
+// getMigrationSchedule returns the migration schedule for the plan along with
+// the ops for the destination cluster of its cluster pair
+func (o *planOptions) getMigrationSchedule(cmdFactory Factory, namespace string) (*storkv1.MigrationSchedule, k8s.Ops, error) {
+	if o.migrationScheduleName == "" {
+		return nil, nil, fmt.Errorf("migration schedule name needs to be provided")
+	}
+	migrationSchedule, err := k8s.Instance().GetMigrationSchedule(o.migrationScheduleName, namespace)
+	if err != nil {
+		return nil, nil, err
+	}
+	clusterPair, err := k8s.Instance().GetClusterPair(migrationSchedule.Spec.Template.Spec.ClusterPair, namespace)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error getting clusterpair for migration schedule: %v", err)
+	}
+	destConfig, err := o.dest.getConfig(cmdFactory, clusterPair)
+	if err != nil {
+		return nil, nil, err
+	}
+	destOps, err := getClusterOps(destConfig)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error getting client for destination cluster: %v", err)
+	}
+	return migrationSchedule, destOps, nil
+}
+
+func newPerformFailoverCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	options := &planOptions{}
+	performFailoverCommand := &cobra.Command{
+		Use:   failoverSubcommand,
+		Short: "Fail over applications migrated by a migration schedule to the destination cluster",
+		Long: "Fail over applications migrated by a migration schedule to the destination cluster. The migration " +
+			"schedule is suspended, migrations it has already started are allowed to complete, the applications in " +
+			"the source cluster are scaled down, a final migration is run and the applications are then activated " +
+			"in the destination cluster.",
+		Run: func(c *cobra.Command, args []string) {
+			namespace := cmdFactory.GetNamespace()
+			migrationSchedule, destOps, err := options.getMigrationSchedule(cmdFactory, namespace)
+			if err != nil {
+				util.CheckErr(err)
+				return
+			}
+			namespaces := migrationSchedule.Spec.Template.Spec.Namespaces
+			steps := []planStep{
+				{
+					description: fmt.Sprintf("Suspend migration schedule %v/%v", namespace, migrationSchedule.Name),
+					run: func() error {
+						return setMigrationScheduleSuspend(k8s.Instance(), migrationSchedule.Name, namespace, true)
+					},
+				},
+				{
+					description: fmt.Sprintf("Wait for migrations started by migration schedule %v/%v to complete",
+						namespace, migrationSchedule.Name),
+					run: func() error {
+						return waitForScheduledMigrations(k8s.Instance(), migrationSchedule.Name, namespace,
+							options.waitTimeout, ioStreams)
+					},
+				},
+				{
+					description: fmt.Sprintf("Scale down applications in the source cluster in namespaces %v", namespaces),
+					run: func() error {
+						for _, ns := range namespaces {
+							if err := scaleDownApplications(getScaleHandlers(k8s.Instance()), ns, ioStreams); err != nil {
+								return err
+							}
+						}
+						return nil
+					},
+				},
+				{
+					description: fmt.Sprintf("Migrate the applications to the destination cluster using clusterpair %v",
+						migrationSchedule.Spec.Template.Spec.ClusterPair),
+					run: func() error {
+						return runMigration(k8s.Instance(), migrationSchedule.Name+"-"+failoverSubcommand, namespace,
+							migrationSchedule.Spec.Template.Spec, options.waitTimeout, ioStreams)
+					},
+				},
+				{
+					description: fmt.Sprintf("Activate applications in the destination cluster in namespaces %v", namespaces),
+					run: func() error {
+						return activateApplications(destOps, namespaces, true, ioStreams)
+					},
+				},
+			}
+			if err := runPlan(steps, options, ioStreams); err != nil {
+				util.CheckErr(err)
+				return
+			}
+		},
+	}
+	options.addFlags(performFailoverCommand)
+
+	return performFailoverCommand
+}
+
+func newPerformFailbackCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	options := &planOptions{}
+	var reverseClusterPair string
+	performFailbackCommand := &cobra.Command{
+		Use:   failbackSubcommand,
+		Short: "Fail back applications to the source cluster of a migration schedule",
+		Long: "Fail back applications to the source cluster of a migration schedule after a failover. The " +
+			"applications are deactivated in the destination cluster, optionally migrated back using a clusterpair " +
+			"in the destination cluster, activated in the source cluster and the migration schedule is resumed.",
+		Run: func(c *cobra.Command, args []string) {
+			namespace := cmdFactory.GetNamespace()
+			migrationSchedule, destOps, err := options.getMigrationSchedule(cmdFactory, namespace)
+			if err != nil {
+				util.CheckErr(err)
+				return
+			}
+			namespaces := migrationSchedule.Spec.Template.Spec.Namespaces
+			steps := []planStep{
+				{
+					description: fmt.Sprintf("Deactivate applications in the destination cluster in namespaces %v", namespaces),
+					run: func() error {
+						return activateApplications(destOps, namespaces, false, ioStreams)
+					},
+				},
+			}
+			if reverseClusterPair != "" {
+				if _, err := destOps.GetClusterPair(reverseClusterPair, namespace); err != nil {
+					util.CheckErr(fmt.Errorf("error getting clusterpair in destination cluster: %v", err))
+					return
+				}
+				reverseSpec := *migrationSchedule.Spec.Template.Spec.DeepCopy()
+				reverseSpec.ClusterPair = reverseClusterPair
+				steps = append(steps, planStep{
+					description: fmt.Sprintf("Migrate the applications back to the source cluster using clusterpair %v "+
+						"in the destination cluster", reverseClusterPair),
+					run: func() error {
+						return runMigration(destOps, migrationSchedule.Name+"-"+failbackSubcommand, namespace,
+							reverseSpec, options.waitTimeout, ioStreams)
+					},
+				})
+			}
+			steps = append(steps,
+				planStep{
+					description: fmt.Sprintf("Activate applications in the source cluster in namespaces %v", namespaces),
+					run: func() error {
+						return activateApplications(k8s.Instance(), namespaces, true, ioStreams)
+					},
+				},
+				planStep{
+					description: fmt.Sprintf("Resume migration schedule %v/%v", namespace, migrationSchedule.Name),
+					run: func() error {
+						return setMigrationScheduleSuspend(k8s.Instance(), migrationSchedule.Name, namespace, false)
+					},
+				},
+			)
+			if err := runPlan(steps, options, ioStreams); err != nil {
+				util.CheckErr(err)
+				return
+			}
+		},
+	}
+	options.addFlags(performFailbackCommand)
+	performFailbackCommand.Flags().StringVarP(&reverseClusterPair, "clusterPair", "c", "",
+		"ClusterPair in the destination cluster to migrate the applications back to the source cluster. "+
+			"The applications aren't migrated back if not specified")
+
+	return performFailbackCommand
+}
+
+// runPlan prints the steps in the plan and runs them in order after they have
+// been confirmed. Stops at the first step that fails.
+func runPlan(steps []planStep, options *planOptions, ioStreams genericclioptions.IOStreams) error {
+	printMsg("The following steps will be performed:", ioStreams.Out)
+	for i, step := range steps {
+		printMsg(fmt.Sprintf("  %v. %v", i+1, step.description), ioStreams.Out)
+	}
+	if options.dryRun {
+		return nil
+	}
+	if !options.skipConfirmation {
+		confirmed, err := confirm("Continue?", ioStreams)
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			return fmt.Errorf("aborted, no steps were performed")
+		}
+	}
+	for i, step := range steps {
+		printMsg(fmt.Sprintf("Step %v: %v", i+1, step.description), ioStreams.Out)
+		if err := step.run(); err != nil {
+			return fmt.Errorf("step %v failed: %v", i+1, err)
+		}
+	}
+	return nil
+}
+
+func setMigrationScheduleSuspend(ops k8s.Ops, name string, namespace string, suspend bool) error {
+	migrationSchedule, err := ops.GetMigrationSchedule(name, namespace)
+	if err != nil {
+		return err
+	}
+	migrationSchedule.Spec.Suspend = &suspend
+	_, err = ops.UpdateMigrationSchedule(migrationSchedule)
+	return err
+}
+
+// waitForScheduledMigrations waits for the migrations started by the
+// migration schedule that are still in progress. The schedule should be
+// suspended first so that no new migrations are started. The migrations only
+// need to complete, a failed migration doesn't stop the failover since the
+// final migration is run after the applications have been scaled down.
+func waitForScheduledMigrations(
+	ops k8s.Ops,
+	name string,
+	namespace string,
+	timeout time.Duration,
+	ioStreams genericclioptions.IOStreams,
+) error {
+	migrationSchedule, err := ops.GetMigrationSchedule(name, namespace)
+	if err != nil {
+		return err
+	}
+	deadline := time.Now().Add(timeout)
+	for _, policyType := range storkv1.GetValidSchedulePolicyTypes() {
+		for _, scheduledMigration := range migrationSchedule.Status.Items[policyType] {
+			for {
+				status, err := getMigrationStatus(ops, scheduledMigration.Name, namespace)
+				if err != nil {
+					if errors.IsNotFound(err) {
+						break
+					}
+					return err
+				}
+				if status.final {
+					printMsg(fmt.Sprintf("Migration %v is %v", getObjectName(namespace, scheduledMigration.Name),
+						status.status), ioStreams.Out)
+					break
+				}
+				if time.Now().After(deadline) {
+					return fmt.Errorf("timed out waiting for Migration %v, stage: %v, status: %v",
+						getObjectName(namespace, scheduledMigration.Name), status.stage, status.status)
+				}
+				time.Sleep(waitPollInterval)
+			}
+		}
+	}
+	return nil
+}
+
+func activateApplications(ops k8s.Ops, namespaces []string, activate bool, ioStreams genericclioptions.IOStreams) error {
+	errs := make([]error, 0)
+	for _, ns := range namespaces {
+		if err := updateApplications(getScaleHandlers(ops), ns, activate, ioStreams); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// runMigration creates a migration with the spec without starting the
+// applications and waits for it to complete
+func runMigration(
+	ops k8s.Ops,
+	namePrefix string,
+	namespace string,
+	spec storkv1.MigrationSpec,
+	timeout time.Duration,
+	ioStreams genericclioptions.IOStreams,
+) error {
+	startApplications := false
+	spec.StartApplications = &startApplications
+	migration := &storkv1.Migration{Spec: spec}
+	migration.Name = namePrefix + "-" + time.Now().Format(failoverNameTimeFormat)
+	migration.Namespace = namespace
+	if _, err := ops.CreateMigration(migration); err != nil {
+		return err
+	}
+	printMsg(fmt.Sprintf("Migration %v started", migration.Name), ioStreams.Out)
+
+	options := &waitOptions{
+		forStatus: string(storkv1.MigrationStatusSuccessful),
+		timeout:   timeout,
+	}
+	return waitForOperation("Migration", migration.Name, namespace, options, func() (*operationStatus, error) {
+		return getMigrationStatus(ops, migration.Name, namespace)
+	}, ioStreams)
+}
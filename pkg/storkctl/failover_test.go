@@ -0,0 +1,266 @@
+// +build unittest
+
+package storkctl
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	migration "github.com/libopenstorage/stork/pkg/migration/controllers"
+	"github.com/portworx/sched-ops/k8s"
+	"github.com/stretchr/testify/require"
+	appv1 "k8s.io/api/apps/v1beta2"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	cmdutil "k8s.io/kubernetes/pkg/kubectl/cmd/util"
+)
+
+func setupFailoverTest(t *testing.T) {
+	// The source and destination clusters are both served by the fake clients
+	getClusterOps = func(_ clientcmdapi.Config) (k8s.Ops, error) {
+		return k8s.Instance(), nil
+	}
+	waitPollInterval = 10 * time.Millisecond
+	createClusterPairAndVerify(t, "pair1", "test")
+	createMigrationScheduleAndVerify(t, "schedule1", "policy1", "test", "pair1", []string{"app"}, "", "", false)
+
+	replicas := int32(3)
+	_, err := k8s.Instance().CreateDeployment(&appv1.Deployment{
+		ObjectMeta: meta.ObjectMeta{Name: "deployment1", Namespace: "app"},
+		Spec:       appv1.DeploymentSpec{Replicas: &replicas},
+	})
+	require.NoError(t, err, "Error creating deployment")
+}
+
+// completeMigrations marks the migrations created in the namespace with the
+// status till the returned function is called
+func completeMigrations(t *testing.T, namespace string, status storkv1.MigrationStatusType) func() {
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-stop:
+				return
+			case <-time.After(5 * time.Millisecond):
+			}
+			migrations, err := k8s.Instance().ListMigrations(namespace)
+			if err != nil {
+				continue
+			}
+			for _, m := range migrations.Items {
+				if m.Status.Stage == storkv1.MigrationStageFinal {
+					continue
+				}
+				m.Status.Stage = storkv1.MigrationStageFinal
+				m.Status.Status = status
+				if _, err := k8s.Instance().UpdateMigration(&m); err != nil {
+					t.Logf("Error updating migration: %v", err)
+				}
+			}
+		}
+	}()
+	// Wait for the updates to stop so that they don't affect other tests
+	return func() {
+		close(stop)
+		<-done
+	}
+}
+
+func runFailoverCommandWithError(t *testing.T, cmdArgs []string, check func(string)) {
+	calledFatal := false
+	defer cmdutil.DefaultBehaviorOnFatal()
+	cmdutil.BehaviorOnFatal(func(e string, code int) {
+		if calledFatal {
+			return
+		}
+		calledFatal = true
+		require.Equal(t, 1, code, "Unexpected error code")
+		check(e)
+	})
+	runCommand(t, cmdArgs)
+	require.True(t, calledFatal)
+}
+
+func getDeployment(t *testing.T) *appv1.Deployment {
+	deployment, err := k8s.Instance().GetDeployment("deployment1", "app")
+	require.NoError(t, err, "Error getting deployment")
+	return deployment
+}
+
+func TestPerformFailoverDryRun(t *testing.T) {
+	defer resetTest()
+	defer func() { getClusterOps = newClusterOps }()
+	setupFailoverTest(t)
+
+	cmdArgs := []string{"perform", "failover", "-n", "test"}
+	expected := "error: migration schedule name needs to be provided"
+	testCommon(t, cmdArgs, nil, expected, true)
+
+	cmdArgs = []string{"perform", "failover", "-n", "test", "--migrationschedule", "missing"}
+	expected = "Error from server (NotFound): migrationschedules.stork.libopenstorage.org \"missing\" not found"
+	testCommon(t, cmdArgs, nil, expected, true)
+
+	cmdArgs = []string{"perform", "failover", "-n", "test", "--migrationschedule", "schedule1", "--dry-run"}
+	expected = "The following steps will be performed:\n" +
+		"  1. Suspend migration schedule test/schedule1\n" +
+		"  2. Wait for migrations started by migration schedule test/schedule1 to complete\n" +
+		"  3. Scale down applications in the source cluster in namespaces [app]\n" +
+		"  4. Migrate the applications to the destination cluster using clusterpair pair1\n" +
+		"  5. Activate applications in the destination cluster in namespaces [app]\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+
+	// Nothing should be performed without confirmation
+	cmdArgs = []string{"perform", "failover", "-n", "test", "--migrationschedule", "schedule1"}
+	expected = "error: error reading confirmation: EOF"
+	testCommon(t, cmdArgs, nil, expected, true)
+
+	migrationSchedule, err := k8s.Instance().GetMigrationSchedule("schedule1", "test")
+	require.NoError(t, err, "Error getting migration schedule")
+	require.False(t, *migrationSchedule.Spec.Suspend)
+	require.Equal(t, int32(3), *getDeployment(t).Spec.Replicas)
+}
+
+func TestPerformFailoverAndFailback(t *testing.T) {
+	defer resetTest()
+	defer func() { getClusterOps = newClusterOps }()
+	setupFailoverTest(t)
+	defer completeMigrations(t, "test", storkv1.MigrationStatusSuccessful)()
+
+	cmdArgs := []string{"perform", "failover", "-n", "test", "--migrationschedule", "schedule1", "-y"}
+	runCommand(t, cmdArgs)
+
+	migrationSchedule, err := k8s.Instance().GetMigrationSchedule("schedule1", "test")
+	require.NoError(t, err, "Error getting migration schedule")
+	require.True(t, *migrationSchedule.Spec.Suspend, "Migration schedule should be suspended")
+	migrations, err := k8s.Instance().ListMigrations("test")
+	require.NoError(t, err, "Error listing migrations")
+	require.Len(t, migrations.Items, 1)
+	require.False(t, *migrations.Items[0].Spec.StartApplications)
+	require.Equal(t, "pair1", migrations.Items[0].Spec.ClusterPair)
+	// The applications are activated with the replicas they had before they
+	// were scaled down
+	deployment := getDeployment(t)
+	require.Equal(t, int32(3), *deployment.Spec.Replicas)
+	require.Equal(t, "3", deployment.Annotations[migration.StorkMigrationReplicasAnnotation])
+
+	cmdArgs = []string{"perform", "failback", "-n", "test", "--migrationschedule", "schedule1", "--dry-run", "-c", "pair1"}
+	expected := "The following steps will be performed:\n" +
+		"  1. Deactivate applications in the destination cluster in namespaces [app]\n" +
+		"  2. Migrate the applications back to the source cluster using clusterpair pair1 in the destination cluster\n" +
+		"  3. Activate applications in the source cluster in namespaces [app]\n" +
+		"  4. Resume migration schedule test/schedule1\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+
+	cmdArgs = []string{"perform", "failback", "-n", "test", "--migrationschedule", "schedule1", "-y", "-c", "pair1"}
+	runCommand(t, cmdArgs)
+	migrationSchedule, err = k8s.Instance().GetMigrationSchedule("schedule1", "test")
+	require.NoError(t, err, "Error getting migration schedule")
+	require.False(t, *migrationSchedule.Spec.Suspend, "Migration schedule should be resumed")
+	migrations, err = k8s.Instance().ListMigrations("test")
+	require.NoError(t, err, "Error listing migrations")
+	require.Len(t, migrations.Items, 2)
+	require.Equal(t, int32(3), *getDeployment(t).Spec.Replicas)
+}
+
+func TestPerformFailoverMigrationFailed(t *testing.T) {
+	defer resetTest()
+	defer func() { getClusterOps = newClusterOps }()
+	setupFailoverTest(t)
+	defer completeMigrations(t, "test", storkv1.MigrationStatusFailed)()
+
+	cmdArgs := []string{"perform", "failover", "-n", "test", "--migrationschedule", "schedule1", "-y"}
+	expectedPrefix := "error: step 4 failed: Migration test/schedule1-failover-"
+	runFailoverCommandWithError(t, cmdArgs, func(e string) {
+		require.Contains(t, e, expectedPrefix)
+		require.Contains(t, e, "completed with status Failed")
+	})
+	// The applications shouldn't be activated if the migration failed
+	require.Equal(t, int32(0), *getDeployment(t).Spec.Replicas)
+}
+
+func TestPerformFailoverWaitsForScheduledMigration(t *testing.T) {
+	defer resetTest()
+	defer func() { getClusterOps = newClusterOps }()
+	setupFailoverTest(t)
+
+	// A migration started by the schedule is still in progress
+	scheduled := &storkv1.Migration{
+		ObjectMeta: meta.ObjectMeta{Name: "schedule1-interval-1", Namespace: "test"},
+		Spec:       storkv1.MigrationSpec{ClusterPair: "pair1", Namespaces: []string{"app"}},
+		Status: storkv1.MigrationStatus{
+			Stage:  storkv1.MigrationStageApplications,
+			Status: storkv1.MigrationStatusInProgress,
+		},
+	}
+	_, err := k8s.Instance().CreateMigration(scheduled)
+	require.NoError(t, err, "Error creating migration")
+	migrationSchedule, err := k8s.Instance().GetMigrationSchedule("schedule1", "test")
+	require.NoError(t, err, "Error getting migration schedule")
+	migrationSchedule.Status.Items = map[storkv1.SchedulePolicyType][]*storkv1.ScheduledMigrationStatus{
+		storkv1.SchedulePolicyTypeInterval: {
+			{Name: "schedule1-interval-1", Status: storkv1.MigrationStatusInProgress},
+			// Migrations that have been deleted are ignored
+			{Name: "schedule1-interval-0", Status: storkv1.MigrationStatusInProgress},
+		},
+	}
+	_, err = k8s.Instance().UpdateMigrationSchedule(migrationSchedule)
+	require.NoError(t, err, "Error updating migration schedule")
+
+	// The applications shouldn't be scaled down till the scheduled migration
+	// completes
+	cmdArgs := []string{"perform", "failover", "-n", "test", "--migrationschedule", "schedule1", "-y",
+		"--wait-timeout", "50ms"}
+	runFailoverCommandWithError(t, cmdArgs, func(e string) {
+		require.Equal(t, "error: step 2 failed: timed out waiting for Migration test/schedule1-interval-1, "+
+			"stage: Applications, status: InProgress", e)
+	})
+	require.Equal(t, int32(3), *getDeployment(t).Spec.Replicas)
+
+	// Failed scheduled migrations don't stop the failover
+	defer completeMigrations(t, "test", storkv1.MigrationStatusFailed)()
+	cmdArgs = []string{"perform", "failover", "-n", "test", "--migrationschedule", "schedule1", "-y"}
+	runFailoverCommandWithError(t, cmdArgs, func(e string) {
+		require.Contains(t, e, "error: step 4 failed: Migration test/schedule1-failover-")
+	})
+	require.Equal(t, int32(0), *getDeployment(t).Spec.Replicas)
+}
+
+func TestPerformFailoverDestContext(t *testing.T) {
+	defer resetTest()
+	defer func() { getClusterOps = newClusterOps }()
+	setupFailoverTest(t)
+	kubeconfig := setupClusterPairKubeconfig(t)
+	defer func() {
+		require.NoError(t, os.Remove(kubeconfig), "Error removing kubeconfig")
+	}()
+
+	var destConfig clientcmdapi.Config
+	getClusterOps = func(config clientcmdapi.Config) (k8s.Ops, error) {
+		destConfig = config
+		return k8s.Instance(), nil
+	}
+
+	cmdArgs := []string{"perform", "failover", "-n", "test", "--migrationschedule", "schedule1", "--dry-run",
+		"--kubeconfig", kubeconfig, "--dest-context", "missing"}
+	expected := "error: context missing not found in config"
+	testCommon(t, cmdArgs, nil, expected, true)
+
+	// The destination context is used instead of the config in the cluster
+	// pair
+	cmdArgs = []string{"perform", "failover", "-n", "test", "--migrationschedule", "schedule1", "--dry-run",
+		"--kubeconfig", kubeconfig, "--dest-context", "dest"}
+	runCommand(t, cmdArgs)
+	require.Equal(t, "dest", destConfig.CurrentContext)
+	require.Equal(t, "https://dest:6443", destConfig.Clusters["dest"].Server)
+
+	// The current context of the destination kubeconfig is used if a context
+	// isn't specified
+	cmdArgs = []string{"perform", "failback", "-n", "test", "--migrationschedule", "schedule1", "--dry-run",
+		"--dest-kubeconfig", kubeconfig}
+	runCommand(t, cmdArgs)
+	require.Equal(t, "source", destConfig.CurrentContext)
+}
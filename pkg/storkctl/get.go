@@ -1,9 +1,14 @@
 package storkctl
 
 import (
+	"fmt"
 	"io"
+	"os"
 
+	snapv1 "github.com/kubernetes-incubator/external-storage/snapshot/pkg/apis/crd/v1"
+	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
 	"github.com/spf13/cobra"
+	"k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -11,6 +16,24 @@ import (
 	"k8s.io/kubernetes/pkg/printers"
 )
 
+// outputScheme is used to look up the kinds of the objects that are printed
+var outputScheme = runtime.NewScheme()
+
+func init() {
+	if err := storkv1.AddToScheme(outputScheme); err != nil {
+		fmt.Printf("Error updating scheme: %v", err)
+		os.Exit(1)
+	}
+	if err := snapv1.AddToScheme(outputScheme); err != nil {
+		fmt.Printf("Error updating scheme: %v", err)
+		os.Exit(1)
+	}
+	if err := v1.AddToScheme(outputScheme); err != nil {
+		fmt.Printf("Error updating scheme: %v", err)
+		os.Exit(1)
+	}
+}
+
 func newGetCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
 	getCommands := &cobra.Command{
 		Use:   "get",
@@ -26,6 +49,8 @@ func newGetCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *c
 		newGetGroupVolumeSnapshotCommand(cmdFactory, ioStreams),
 		newGetClusterDomainsStatusCommand(cmdFactory, ioStreams),
 		newGetClusterDomainUpdateCommand(cmdFactory, ioStreams),
+		newGetClusterDomainCommand(cmdFactory, ioStreams),
+		newGetVolumesCommand(cmdFactory, ioStreams),
 	)
 
 	return getCommands
@@ -35,14 +60,13 @@ func printTable(
 	cmd *cobra.Command,
 	object runtime.Object,
 	columns []string,
-	withNamespace bool,
+	wideColumns []string,
+	options printers.PrintOptions,
 	printerFunc interface{},
 	out io.Writer,
 ) error {
-	printer := printers.NewHumanReadablePrinter(nil, printers.PrintOptions{
-		WithNamespace: withNamespace,
-	})
-	if err := printer.Handler(columns, nil, printerFunc); err != nil {
+	printer := printers.NewHumanReadablePrinter(nil, options)
+	if err := printer.Handler(columns, wideColumns, printerFunc); err != nil {
 		return err
 	}
 	return printer.PrintObj(object, out)
@@ -50,10 +74,11 @@ func printTable(
 
 func printEncoded(cmd *cobra.Command, object runtime.Object, outputFormat string, out io.Writer) error {
 	if meta.IsListType(object) {
-		object.GetObjectKind().SetGroupVersionKind(schema.GroupVersionKind{
-			Version: "v1",
-			Kind:    "List",
-		})
+		if err := setListKinds(object); err != nil {
+			return err
+		}
+	} else if err := setObjectKind(object); err != nil {
+		return err
 	}
 	printer, err := (&genericclioptions.JSONYamlPrintFlags{}).ToPrinter(outputFormat)
 	if err != nil {
@@ -62,13 +87,72 @@ func printEncoded(cmd *cobra.Command, object runtime.Object, outputFormat string
 	return printer.PrintObj(object, out)
 }
 
-func printObjects(cmd *cobra.Command, object runtime.Object, cmdFactory Factory, columns []string, printerFunc interface{}, out io.Writer) error {
+// setListKinds sets the kind for the list and the items in it since they
+// aren't set for objects returned by the typed clients. Lists without any
+// items are encoded with an empty list of items so that the output has the
+// same schema.
+func setListKinds(object runtime.Object) error {
+	object.GetObjectKind().SetGroupVersionKind(schema.GroupVersionKind{
+		Version: "v1",
+		Kind:    "List",
+	})
+	items, err := meta.ExtractList(object)
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		return meta.SetList(object, []runtime.Object{})
+	}
+	for _, item := range items {
+		if err := setObjectKind(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func setObjectKind(object runtime.Object) error {
+	if !object.GetObjectKind().GroupVersionKind().Empty() {
+		return nil
+	}
+	kinds, _, err := outputScheme.ObjectKinds(object)
+	if err != nil {
+		return err
+	}
+	object.GetObjectKind().SetGroupVersionKind(kinds[0])
+	return nil
+}
+
+func printObjects(
+	cmd *cobra.Command,
+	object runtime.Object,
+	cmdFactory Factory,
+	columns []string,
+	wideColumns []string,
+	printerFunc interface{},
+	out io.Writer,
+) error {
 	outputFormat, err := cmdFactory.GetOutputFormat()
 	if err != nil {
 		return err
 	}
-	if outputFormat == outputFormatTable {
-		return printTable(cmd, object, columns, cmdFactory.AllNamespaces(), printerFunc, out)
+	if outputFormat != outputFormatTable && outputFormat != outputFormatWide {
+		return printEncoded(cmd, object, outputFormat, out)
+	}
+	if meta.IsListType(object) {
+		items, err := meta.ExtractList(object)
+		if err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			handleEmptyList(out)
+			return nil
+		}
+	}
+	options := printers.PrintOptions{
+		WithNamespace: cmdFactory.AllNamespaces(),
+		Wide:          outputFormat == outputFormatWide,
+		NoHeaders:     cmdFactory.NoHeaders(),
 	}
-	return printEncoded(cmd, object, outputFormat, out)
+	return printTable(cmd, object, columns, wideColumns, options, printerFunc, out)
 }
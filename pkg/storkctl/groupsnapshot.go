@@ -15,10 +15,12 @@ import (
 )
 
 var groupSnapshotColumns = []string{"NAME", "STATUS", "STAGE", "SNAPSHOTS", "CREATED"}
+var groupSnapshotWideColumns = []string{"PRE-EXEC-RULE", "POST-EXEC-RULE"}
 var groupSnapshotSubcommand = "groupsnapshots"
 var groupSnapshotAliases = []string{"groupsnapshot"}
 
 func newCreateGroupSnapshotCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	dryRun := &dryRunOptions{}
 	var groupSnapshotName string
 	var restoreNamespaces []string
 	var opts []string
@@ -75,6 +77,12 @@ func newCreateGroupSnapshotCommand(cmdFactory Factory, ioStreams genericclioptio
 			}
 			groupSnapshot.Name = groupSnapshotName
 			groupSnapshot.Namespace = cmdFactory.GetNamespace()
+			if dryRun.enabled() {
+				if err := dryRun.printObject(c, cmdFactory, groupSnapshot, groupSnapshot.Name, ioStreams.Out); err != nil {
+					util.CheckErr(err)
+				}
+				return
+			}
 			_, err = k8s.Instance().CreateGroupSnapshot(groupSnapshot)
 			if err != nil {
 				util.CheckErr(err)
@@ -107,6 +115,8 @@ func newCreateGroupSnapshotCommand(cmdFactory Factory, ioStreams genericclioptio
 		"Comma-separated list of options to provide to the storage driver. These "+
 			"are in the format key1=value1,key2=value2. e.g portworx/snapshot-type=cloud")
 
+	dryRun.addFlags(createGroupVolumeSnapshotCommand)
+
 	return createGroupVolumeSnapshotCommand
 }
 
@@ -150,12 +160,7 @@ func newGetGroupVolumeSnapshotCommand(cmdFactory Factory, ioStreams genericcliop
 				}
 			}
 
-			if len(groupSnapshots.Items) == 0 {
-				handleEmptyList(ioStreams.Out)
-				return
-			}
-
-			if err := printObjects(c, groupSnapshots, cmdFactory, groupSnapshotColumns, groupSnapshotPrinter, ioStreams.Out); err != nil {
+			if err := printObjects(c, groupSnapshots, cmdFactory, groupSnapshotColumns, groupSnapshotWideColumns, groupSnapshotPrinter, ioStreams.Out); err != nil {
 				util.CheckErr(err)
 				return
 			}
@@ -211,7 +216,7 @@ func groupSnapshotPrinter(groupSnapshotList *storkv1.GroupVolumeSnapshotList, wr
 		}
 
 		creationTime := toTimeString(groupSnapshot.CreationTimestamp.Time)
-		if _, err := fmt.Fprintf(writer, "%v\t%v\t%v\t%d\t%v\n",
+		if _, err := fmt.Fprintf(writer, "%v\t%v\t%v\t%d\t%v",
 			name,
 			groupSnapshot.Status.Status,
 			groupSnapshot.Status.Stage,
@@ -220,6 +225,14 @@ func groupSnapshotPrinter(groupSnapshotList *storkv1.GroupVolumeSnapshotList, wr
 		); err != nil {
 			return err
 		}
+		if options.Wide {
+			if _, err := fmt.Fprintf(writer, "\t%v\t%v", groupSnapshot.Spec.PreExecRule, groupSnapshot.Spec.PostExecRule); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintln(writer); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -3,23 +3,26 @@ package storkctl
 import (
 	"fmt"
 	"io"
-	"strconv"
+	"reflect"
+	"strings"
 	"time"
 
 	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
-	migration "github.com/libopenstorage/stork/pkg/migration/controllers"
 	"github.com/portworx/sched-ops/k8s"
 	"github.com/spf13/cobra"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/kubernetes/pkg/kubectl/cmd/util"
 	"k8s.io/kubernetes/pkg/kubectl/genericclioptions"
 	"k8s.io/kubernetes/pkg/printers"
 )
 
-var migrationColumns = []string{"NAME", "CLUSTERPAIR", "STAGE", "STATUS", "VOLUMES", "RESOURCES", "CREATED", "ELAPSED"}
+var migrationColumns = []string{"NAME", "CLUSTERPAIR", "STAGE", "STATUS", "VOLUMES", "RESOURCES", "PROGRESS", "CREATED", "ELAPSED"}
+var migrationWideColumns = []string{"SCHEDULE", "NAMESPACES", "PRE-EXEC-RULE", "POST-EXEC-RULE"}
 var migrationSubcommand = "migrations"
 var migrationAliases = []string{"migration"}
 
 func newCreateMigrationCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	dryRun := &dryRunOptions{}
 	var migrationName string
 	var clusterPair string
 	var namespaceList []string
@@ -61,6 +64,12 @@ func newCreateMigrationCommand(cmdFactory Factory, ioStreams genericclioptions.I
 			}
 			migration.Name = migrationName
 			migration.Namespace = cmdFactory.GetNamespace()
+			if dryRun.enabled() {
+				if err := dryRun.printObject(c, cmdFactory, migration, migration.Name, ioStreams.Out); err != nil {
+					util.CheckErr(err)
+				}
+				return
+			}
 			_, err := k8s.Instance().CreateMigration(migration)
 			if err != nil {
 				util.CheckErr(err)
@@ -77,225 +86,180 @@ func newCreateMigrationCommand(cmdFactory Factory, ioStreams genericclioptions.I
 	createMigrationCommand.Flags().BoolVarP(&startApplications, "startApplications", "a", true, "Start applications on the destination cluster after migration")
 	createMigrationCommand.Flags().StringVarP(&preExecRule, "preExecRule", "", "", "Rule to run before executing migration")
 	createMigrationCommand.Flags().StringVarP(&postExecRule, "postExecRule", "", "", "Rule to run after executing migration")
+	dryRun.addFlags(createMigrationCommand)
 
 	return createMigrationCommand
 }
 
 func newActivateMigrationsCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
-	var allNamespaces bool
-
+	options := &updateApplicationsOptions{}
 	activateMigrationCommand := &cobra.Command{
 		Use:     migrationSubcommand,
 		Aliases: migrationAliases,
 		Short:   "Activate apps that were created from a migration",
+		Long: "Activate apps that were created from a migration by restoring the replicas saved by stork " +
+			"when they were migrated or deactivated.",
 		Run: func(c *cobra.Command, args []string) {
-			activationNamespaces := make([]string, 0)
-			if allNamespaces {
-				namespaces, err := k8s.Instance().ListNamespaces()
-				if err != nil {
-					util.CheckErr(err)
-					return
-				}
-				for _, ns := range namespaces.Items {
-					activationNamespaces = append(activationNamespaces, ns.Name)
-				}
-
-			} else {
-				activationNamespaces = append(activationNamespaces, cmdFactory.GetNamespace())
-			}
-
-			for _, ns := range activationNamespaces {
-				updateStatefulSets(ns, true, ioStreams)
-				updateDeployments(ns, true, ioStreams)
+			if err := options.updateApplications(cmdFactory, true, ioStreams); err != nil {
+				util.CheckErr(err)
+				return
 			}
-
 		},
 	}
-	activateMigrationCommand.Flags().BoolVarP(&allNamespaces, "all-namespaces", "a", false, "Activate applications in all namespaces")
+	options.addFlags(activateMigrationCommand, "Activate applications in all namespaces")
 
 	return activateMigrationCommand
 }
 
 func newDeactivateMigrationsCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
-	var allNamespaces bool
-
+	options := &updateApplicationsOptions{}
 	deactivateMigrationCommand := &cobra.Command{
 		Use:     migrationSubcommand,
 		Aliases: migrationAliases,
 		Short:   "Deactivate apps that were created from a migration",
+		Long: "Deactivate apps that were created from a migration by scaling them down. The current replicas " +
+			"are saved so that they are restored when the apps are activated again.",
 		Run: func(c *cobra.Command, args []string) {
-			deactivationNamespaces := make([]string, 0)
-			if allNamespaces {
-				namespaces, err := k8s.Instance().ListNamespaces()
-				if err != nil {
-					util.CheckErr(err)
-					return
-				}
-				for _, ns := range namespaces.Items {
-					deactivationNamespaces = append(deactivationNamespaces, ns.Name)
-				}
-
-			} else {
-				deactivationNamespaces = append(deactivationNamespaces, cmdFactory.GetNamespace())
-			}
-
-			for _, ns := range deactivationNamespaces {
-				updateStatefulSets(ns, false, ioStreams)
-				updateDeployments(ns, false, ioStreams)
-				updateDeploymentConfigs(ns, false, ioStreams)
+			if err := options.updateApplications(cmdFactory, false, ioStreams); err != nil {
+				util.CheckErr(err)
+				return
 			}
-
 		},
 	}
-	deactivateMigrationCommand.Flags().BoolVarP(&allNamespaces, "all-namespaces", "a", false, "Deactivate applications in all namespaces")
+	options.addFlags(deactivateMigrationCommand, "Deactivate applications in all namespaces")
 
 	return deactivateMigrationCommand
 }
 
-func updateStatefulSets(namespace string, activate bool, ioStreams genericclioptions.IOStreams) {
-	statefulSets, err := k8s.Instance().ListStatefulSets(namespace)
-	if err != nil {
-		util.CheckErr(err)
-		return
-	}
-	for _, statefulSet := range statefulSets.Items {
-		if replicas, update := getUpdatedReplicaCount(statefulSet.Annotations, activate, ioStreams); update {
-			statefulSet.Spec.Replicas = &replicas
-			_, err := k8s.Instance().UpdateStatefulSet(&statefulSet)
-			if err != nil {
-				printMsg(fmt.Sprintf("Error updating replicas for statefulset %v/%v : %v", statefulSet.Namespace, statefulSet.Name, err), ioStreams.ErrOut)
-				continue
-			}
-			printMsg(fmt.Sprintf("Updated replicas for statefulset %v/%v to %v", statefulSet.Namespace, statefulSet.Name, replicas), ioStreams.Out)
-		}
+type updateApplicationsOptions struct {
+	allNamespaces   bool
+	customResources []string
+}
 
-	}
+func (o *updateApplicationsOptions) addFlags(c *cobra.Command, allNamespacesUsage string) {
+	c.Flags().BoolVarP(&o.allNamespaces, "all-namespaces", "a", false, allNamespacesUsage)
+	c.Flags().StringSliceVar(&o.customResources, "custom-resources", nil,
+		"Comma-separated list of custom resources to also scale, in the format resource.version.group[:path.to.replicas]. "+
+			"The replicas are expected at "+defaultReplicasPath+" if the path isn't specified")
 }
 
-func updateDeployments(namespace string, activate bool, ioStreams genericclioptions.IOStreams) {
-	deployments, err := k8s.Instance().ListDeployments(namespace)
-	if err != nil {
-		util.CheckErr(err)
-		return
-	}
-	for _, deployment := range deployments.Items {
-		if replicas, update := getUpdatedReplicaCount(deployment.Annotations, activate, ioStreams); update {
-			deployment.Spec.Replicas = &replicas
-			_, err := k8s.Instance().UpdateDeployment(&deployment)
+func (o *updateApplicationsOptions) updateApplications(cmdFactory Factory, activate bool, ioStreams genericclioptions.IOStreams) error {
+	handlers := getScaleHandlers(k8s.Instance())
+	if len(o.customResources) != 0 {
+		client, err := getDynamicClient(cmdFactory)
+		if err != nil {
+			return err
+		}
+		for _, customResource := range o.customResources {
+			handler, err := newCustomResourceScaleHandler(client, customResource)
 			if err != nil {
-				printMsg(fmt.Sprintf("Error updating replicas for deployment %v/%v : %v", deployment.Namespace, deployment.Name, err), ioStreams.ErrOut)
-				continue
+				return err
 			}
-			printMsg(fmt.Sprintf("Updated replicas for deployment %v/%v to %v", deployment.Namespace, deployment.Name, replicas), ioStreams.Out)
+			handlers = append(handlers, handler)
 		}
 	}
-}
 
-func updateDeploymentConfigs(namespace string, activate bool, ioStreams genericclioptions.IOStreams) {
-	deployments, err := k8s.Instance().ListDeploymentConfigs(namespace)
-	if err != nil {
-		util.CheckErr(err)
-		return
-	}
-	for _, deployment := range deployments.Items {
-		if replicas, update := getUpdatedReplicaCount(deployment.Annotations, activate, ioStreams); update {
-			deployment.Spec.Replicas = replicas
-			_, err := k8s.Instance().UpdateDeploymentConfig(&deployment)
-			if err != nil {
-				printMsg(fmt.Sprintf("Error updating replicas for deploymentconfig %v/%v : %v", deployment.Namespace, deployment.Name, err), ioStreams.ErrOut)
-				continue
-			}
-			printMsg(fmt.Sprintf("Updated replicas for deploymentconfig %v/%v to %v", deployment.Namespace, deployment.Name, replicas), ioStreams.Out)
+	namespaces := make([]string, 0)
+	if o.allNamespaces {
+		namespaceList, err := k8s.Instance().ListNamespaces()
+		if err != nil {
+			return err
 		}
+		for _, ns := range namespaceList.Items {
+			namespaces = append(namespaces, ns.Name)
+		}
+	} else {
+		namespaces = append(namespaces, cmdFactory.GetNamespace())
 	}
-}
 
-func getUpdatedReplicaCount(annotations map[string]string, activate bool, ioStreams genericclioptions.IOStreams) (int32, bool) {
-	if replicas, present := annotations[migration.StorkMigrationReplicasAnnotation]; present {
-		var updatedReplicas int32
-		if activate {
-			parsedReplicas, err := strconv.Atoi(replicas)
-			if err != nil {
-				printMsg(fmt.Sprintf("Error parsing replicas for app : %v", err), ioStreams.ErrOut)
-				return 0, false
-			}
-			updatedReplicas = int32(parsedReplicas)
-		} else {
-			updatedReplicas = 0
+	errs := make([]error, 0)
+	for _, ns := range namespaces {
+		if err := updateApplications(handlers, ns, activate, ioStreams); err != nil {
+			errs = append(errs, err)
 		}
-		return updatedReplicas, true
 	}
-
-	return 0, false
+	return utilerrors.NewAggregate(errs)
 }
 
 func newGetMigrationCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
 	var clusterPair string
+	var watch bool
 	getMigrationCommand := &cobra.Command{
 		Use:     migrationSubcommand,
 		Aliases: migrationAliases,
 		Short:   "Get migration resources",
 		Run: func(c *cobra.Command, args []string) {
-			var migrations *storkv1.MigrationList
-			var err error
-
-			namespaces, err := cmdFactory.GetAllNamespaces()
-			if err != nil {
-				util.CheckErr(err)
-				return
-			}
-			if len(args) > 0 {
-				migrations = new(storkv1.MigrationList)
-				for _, migrationName := range args {
-					for _, ns := range namespaces {
-						migration, err := k8s.Instance().GetMigration(migrationName, ns)
-						if err != nil {
-							util.CheckErr(err)
-							return
-						}
-						migrations.Items = append(migrations.Items, *migration)
-					}
+			for {
+				migrations, err := getMigrations(cmdFactory, args, clusterPair)
+				if err != nil {
+					util.CheckErr(err)
+					return
 				}
-			} else {
-				var tempMigrations storkv1.MigrationList
-				for _, ns := range namespaces {
-					migrations, err = k8s.Instance().ListMigrations(ns)
-					if err != nil {
-						util.CheckErr(err)
-						return
-					}
-					tempMigrations.Items = append(tempMigrations.Items, migrations.Items...)
+				if err := printObjects(c, migrations, cmdFactory, migrationColumns, migrationWideColumns, migrationPrinter, ioStreams.Out); err != nil {
+					util.CheckErr(err)
+					return
 				}
-				migrations = &tempMigrations
+				if !watch || migrationsComplete(migrations) {
+					return
+				}
+				time.Sleep(waitPollInterval)
 			}
+		},
+	}
+	getMigrationCommand.Flags().StringVarP(&clusterPair, "clusterpair", "c", "", "Name of the cluster pair for which to list migrations")
+	getMigrationCommand.Flags().BoolVarP(&watch, "watch", "w", false, "Keep printing the migrations till all of them are complete")
+	cmdFactory.BindGetFlags(getMigrationCommand.Flags())
 
-			if len(clusterPair) != 0 {
-				var tempMigrations storkv1.MigrationList
+	return getMigrationCommand
+}
 
-				for _, migration := range migrations.Items {
-					if migration.Spec.ClusterPair == clusterPair {
-						tempMigrations.Items = append(tempMigrations.Items, migration)
-						continue
-					}
+func getMigrations(cmdFactory Factory, names []string, clusterPair string) (*storkv1.MigrationList, error) {
+	namespaces, err := cmdFactory.GetAllNamespaces()
+	if err != nil {
+		return nil, err
+	}
+	migrations := new(storkv1.MigrationList)
+	if len(names) > 0 {
+		for _, migrationName := range names {
+			for _, ns := range namespaces {
+				migration, err := k8s.Instance().GetMigration(migrationName, ns)
+				if err != nil {
+					return nil, err
 				}
-				migrations = &tempMigrations
+				migrations.Items = append(migrations.Items, *migration)
 			}
-
-			if len(migrations.Items) == 0 {
-				handleEmptyList(ioStreams.Out)
-				return
+		}
+	} else {
+		for _, ns := range namespaces {
+			nsMigrations, err := k8s.Instance().ListMigrations(ns)
+			if err != nil {
+				return nil, err
 			}
+			migrations.Items = append(migrations.Items, nsMigrations.Items...)
+		}
+	}
 
-			if err := printObjects(c, migrations, cmdFactory, migrationColumns, migrationPrinter, ioStreams.Out); err != nil {
-				util.CheckErr(err)
-				return
+	if len(clusterPair) != 0 {
+		var tempMigrations storkv1.MigrationList
+
+		for _, migration := range migrations.Items {
+			if migration.Spec.ClusterPair == clusterPair {
+				tempMigrations.Items = append(tempMigrations.Items, migration)
+				continue
 			}
-		},
+		}
+		migrations = &tempMigrations
 	}
-	getMigrationCommand.Flags().StringVarP(&clusterPair, "clusterpair", "c", "", "Name of the cluster pair for which to list migrations")
-	cmdFactory.BindGetFlags(getMigrationCommand.Flags())
+	return migrations, nil
+}
 
-	return getMigrationCommand
+func migrationsComplete(migrations *storkv1.MigrationList) bool {
+	for _, migration := range migrations.Items {
+		if migration.Status.Stage != storkv1.MigrationStageFinal {
+			return false
+		}
+	}
+	return true
 }
 
 func newDeleteMigrationCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
@@ -390,7 +354,7 @@ func migrationPrinter(migrationList *storkv1.MigrationList, writer io.Writer, op
 		}
 
 		creationTime := toTimeString(migration.CreationTimestamp.Time)
-		if _, err := fmt.Fprintf(writer, "%v\t%v\t%v\t%v\t%v/%v\t%v/%v\t%v\t%v\n",
+		if _, err := fmt.Fprintf(writer, "%v\t%v\t%v\t%v\t%v/%v\t%v/%v\t%v%%\t%v\t%v",
 			name,
 			migration.Spec.ClusterPair,
 			migration.Status.Stage,
@@ -399,10 +363,67 @@ func migrationPrinter(migrationList *storkv1.MigrationList, writer io.Writer, op
 			totalVolumes,
 			doneResources,
 			totalResources,
+			getMigrationProgress(&migration, doneVolumes, doneResources),
 			creationTime,
 			elapsed); err != nil {
 			return err
 		}
+		if options.Wide {
+			if _, err := fmt.Fprintf(writer, "\t%v\t%v\t%v\t%v",
+				getMigrationScheduleName(&migration),
+				strings.Join(migration.Spec.Namespaces, ","),
+				migration.Spec.PreExecRule,
+				migration.Spec.PostExecRule); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintln(writer); err != nil {
+			return err
+		}
 	}
 	return nil
 }
+
+// getMigrationProgress returns the percentage of the migration that is
+// complete. Volumes and resources are each counted as half of the migration
+// when both are being migrated.
+func getMigrationProgress(migration *storkv1.Migration, doneVolumes int, doneResources int) int {
+	if migration.Status.Stage == storkv1.MigrationStageFinal {
+		if migration.Status.Status == storkv1.MigrationStatusSuccessful {
+			return 100
+		}
+	}
+	includeVolumes := migration.Spec.IncludeVolumes == nil || *migration.Spec.IncludeVolumes
+	includeResources := migration.Spec.IncludeResources == nil || *migration.Spec.IncludeResources
+	volumesWeight, resourcesWeight := 0, 0
+	if includeVolumes && includeResources {
+		volumesWeight, resourcesWeight = 50, 50
+	} else if includeVolumes {
+		volumesWeight = 100
+	} else if includeResources {
+		resourcesWeight = 100
+	}
+
+	progress := 0
+	if len(migration.Status.Volumes) != 0 {
+		progress += volumesWeight * doneVolumes / len(migration.Status.Volumes)
+	} else if migration.Status.Stage == storkv1.MigrationStageApplications {
+		// There were no volumes to migrate
+		progress += volumesWeight
+	}
+	if len(migration.Status.Resources) != 0 {
+		progress += resourcesWeight * doneResources / len(migration.Status.Resources)
+	}
+	return progress
+}
+
+// getMigrationScheduleName returns the name of the migration schedule that
+// created the migration, if any
+func getMigrationScheduleName(migration *storkv1.Migration) string {
+	for _, owner := range migration.OwnerReferences {
+		if owner.Kind == reflect.TypeOf(storkv1.MigrationSchedule{}).Name() {
+			return owner.Name
+		}
+	}
+	return ""
+}
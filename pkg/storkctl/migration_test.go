@@ -3,10 +3,13 @@
 package storkctl
 
 import (
+	"encoding/json"
+	"io/ioutil"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/ghodss/yaml"
 	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
 	migration "github.com/libopenstorage/stork/pkg/migration/controllers"
 	"github.com/portworx/sched-ops/k8s"
@@ -58,13 +61,46 @@ func TestGetMigrationsOneMigration(t *testing.T) {
 	defer resetTest()
 	createMigrationAndVerify(t, "getmigrationtest", "test", "clusterpair1", []string{"namespace1"}, "preExec", "postExec")
 
-	expected := "NAME               CLUSTERPAIR    STAGE     STATUS    VOLUMES   RESOURCES   CREATED   ELAPSED\n" +
-		"getmigrationtest   clusterpair1                       0/0       0/0                   \n"
+	expected := "NAME               CLUSTERPAIR    STAGE     STATUS    VOLUMES   RESOURCES   PROGRESS   CREATED   ELAPSED\n" +
+		"getmigrationtest   clusterpair1                       0/0       0/0         0%                   \n"
 
 	cmdArgs := []string{"get", "migrations", "-n", "test"}
 	testCommon(t, cmdArgs, nil, expected, false)
 }
 
+func TestGetMigrationsOutputFormats(t *testing.T) {
+	defer resetTest()
+	cmdArgs := []string{"get", "migrations", "-n", "test", "-o", "json"}
+	migrations := &storkv1.MigrationList{}
+	require.NoError(t, json.Unmarshal([]byte(runCommand(t, cmdArgs)), migrations), "Error decoding empty list")
+	require.Equal(t, "List", migrations.Kind)
+	require.NotNil(t, migrations.Items, "Empty lists should have empty items")
+	require.Len(t, migrations.Items, 0)
+
+	createMigrationAndVerify(t, "getmigrationtest", "test", "clusterpair1", []string{"namespace1", "namespace2"}, "preExec", "postExec")
+	for _, format := range []string{"json", "yaml"} {
+		cmdArgs = []string{"get", "migrations", "-n", "test", "-o", format}
+		migrations = &storkv1.MigrationList{}
+		require.NoError(t, yaml.Unmarshal([]byte(runCommand(t, cmdArgs)), migrations), "Error decoding %v output", format)
+		require.Len(t, migrations.Items, 1)
+		require.Equal(t, "Migration", migrations.Items[0].Kind)
+		require.Equal(t, "stork.libopenstorage.org/v1alpha1", migrations.Items[0].APIVersion)
+		require.Equal(t, "getmigrationtest", migrations.Items[0].Name)
+	}
+
+	expected := "NAME               CLUSTERPAIR    STAGE     STATUS    VOLUMES   RESOURCES   PROGRESS   CREATED   ELAPSED   SCHEDULE   NAMESPACES              PRE-EXEC-RULE   POST-EXEC-RULE\n" +
+		"getmigrationtest   clusterpair1                       0/0       0/0         0%                                        namespace1,namespace2   preExec         postExec\n"
+	cmdArgs = []string{"get", "migrations", "-n", "test", "-o", "wide"}
+	testCommon(t, cmdArgs, nil, expected, false)
+
+	expected = "getmigrationtest   clusterpair1                       0/0       0/0       0%                  \n"
+	cmdArgs = []string{"get", "migrations", "-n", "test", "--no-headers"}
+	testCommon(t, cmdArgs, nil, expected, false)
+
+	cmdArgs = []string{"get", "migrations", "-n", "test", "-o", "invalid"}
+	testCommon(t, cmdArgs, nil, "error: unsupported output type invalid", true)
+}
+
 func TestGetMigrationsMultiple(t *testing.T) {
 	defer resetTest()
 	_, err := k8s.Instance().CreateNamespace("default", nil)
@@ -73,9 +109,9 @@ func TestGetMigrationsMultiple(t *testing.T) {
 	createMigrationAndVerify(t, "getmigrationtest1", "default", "clusterpair1", []string{"namespace1"}, "", "")
 	createMigrationAndVerify(t, "getmigrationtest2", "default", "clusterpair2", []string{"namespace1"}, "", "")
 
-	expected := "NAME                CLUSTERPAIR    STAGE     STATUS    VOLUMES   RESOURCES   CREATED   ELAPSED\n" +
-		"getmigrationtest1   clusterpair1                       0/0       0/0                   \n" +
-		"getmigrationtest2   clusterpair2                       0/0       0/0                   \n"
+	expected := "NAME                CLUSTERPAIR    STAGE     STATUS    VOLUMES   RESOURCES   PROGRESS   CREATED   ELAPSED\n" +
+		"getmigrationtest1   clusterpair1                       0/0       0/0         0%                   \n" +
+		"getmigrationtest2   clusterpair2                       0/0       0/0         0%                   \n"
 
 	cmdArgs := []string{"get", "migrations", "getmigrationtest1", "getmigrationtest2"}
 	testCommon(t, cmdArgs, nil, expected, false)
@@ -84,8 +120,8 @@ func TestGetMigrationsMultiple(t *testing.T) {
 	cmdArgs = []string{"get", "migrations"}
 	testCommon(t, cmdArgs, nil, expected, false)
 
-	expected = "NAME                CLUSTERPAIR    STAGE     STATUS    VOLUMES   RESOURCES   CREATED   ELAPSED\n" +
-		"getmigrationtest1   clusterpair1                       0/0       0/0                   \n"
+	expected = "NAME                CLUSTERPAIR    STAGE     STATUS    VOLUMES   RESOURCES   PROGRESS   CREATED   ELAPSED\n" +
+		"getmigrationtest1   clusterpair1                       0/0       0/0         0%                   \n"
 	// Should get only one migration if name given
 	cmdArgs = []string{"get", "migrations", "getmigrationtest1"}
 	testCommon(t, cmdArgs, nil, expected, false)
@@ -94,10 +130,10 @@ func TestGetMigrationsMultiple(t *testing.T) {
 	require.NoError(t, err, "Error creating ns1 namespace")
 	createMigrationAndVerify(t, "getmigrationtest21", "ns1", "clusterpair2", []string{"namespace1"}, "", "")
 	cmdArgs = []string{"get", "migrations", "--all-namespaces"}
-	expected = "NAMESPACE   NAME                 CLUSTERPAIR    STAGE     STATUS    VOLUMES   RESOURCES   CREATED   ELAPSED\n" +
-		"default     getmigrationtest1    clusterpair1                       0/0       0/0                   \n" +
-		"default     getmigrationtest2    clusterpair2                       0/0       0/0                   \n" +
-		"ns1         getmigrationtest21   clusterpair2                       0/0       0/0                   \n"
+	expected = "NAMESPACE   NAME                 CLUSTERPAIR    STAGE     STATUS    VOLUMES   RESOURCES   PROGRESS   CREATED   ELAPSED\n" +
+		"default     getmigrationtest1    clusterpair1                       0/0       0/0         0%                   \n" +
+		"default     getmigrationtest2    clusterpair2                       0/0       0/0         0%                   \n" +
+		"ns1         getmigrationtest21   clusterpair2                       0/0       0/0         0%                   \n"
 	testCommon(t, cmdArgs, nil, expected, false)
 }
 
@@ -106,8 +142,8 @@ func TestGetMigrationsWithClusterPair(t *testing.T) {
 	createMigrationAndVerify(t, "getmigrationtest1", "default", "clusterpair1", []string{"namespace1"}, "", "")
 	createMigrationAndVerify(t, "getmigrationtest2", "default", "clusterpair2", []string{"namespace1"}, "", "")
 
-	expected := "NAME                CLUSTERPAIR    STAGE     STATUS    VOLUMES   RESOURCES   CREATED   ELAPSED\n" +
-		"getmigrationtest1   clusterpair1                       0/0       0/0                   \n"
+	expected := "NAME                CLUSTERPAIR    STAGE     STATUS    VOLUMES   RESOURCES   PROGRESS   CREATED   ELAPSED\n" +
+		"getmigrationtest1   clusterpair1                       0/0       0/0         0%                   \n"
 
 	cmdArgs := []string{"get", "migrations", "-c", "clusterpair1"}
 	testCommon(t, cmdArgs, nil, expected, false)
@@ -128,12 +164,70 @@ func TestGetMigrationsWithStatusAndProgress(t *testing.T) {
 	_, err = k8s.Instance().UpdateMigration(migration)
 	require.NoError(t, err, "Error updating migration")
 
-	expected := "NAME                     CLUSTERPAIR    STAGE     STATUS       VOLUMES   RESOURCES   CREATED               ELAPSED\n" +
-		"getmigrationstatustest   clusterpair1   Final     Successful   0/0       0/0         " + toTimeString(migration.CreationTimestamp.Time) + "   5m0s\n"
+	expected := "NAME                     CLUSTERPAIR    STAGE     STATUS       VOLUMES   RESOURCES   PROGRESS   CREATED               ELAPSED\n" +
+		"getmigrationstatustest   clusterpair1   Final     Successful   0/0       0/0         100%       " + toTimeString(migration.CreationTimestamp.Time) + "   5m0s\n"
 	cmdArgs := []string{"get", "migrations", "getmigrationstatustest"}
 	testCommon(t, cmdArgs, nil, expected, false)
 }
 
+func TestGetMigrationsProgressAndSchedule(t *testing.T) {
+	defer resetTest()
+	waitPollInterval = time.Millisecond
+	createMigrationAndVerify(t, "getmigrationprogresstest", "test", "clusterpair1", []string{"namespace1"}, "", "")
+	migration, err := k8s.Instance().GetMigration("getmigrationprogresstest", "test")
+	require.NoError(t, err, "Error getting migration")
+
+	migration.OwnerReferences = []metav1.OwnerReference{{Kind: "MigrationSchedule", Name: "schedule1"}}
+	migration.Status.Stage = storkv1.MigrationStageVolumes
+	migration.Status.Status = storkv1.MigrationStatusInProgress
+	migration.Status.Volumes = []*storkv1.VolumeInfo{
+		{PersistentVolumeClaim: "pvc1", Status: storkv1.MigrationStatusSuccessful},
+		{PersistentVolumeClaim: "pvc2", Status: storkv1.MigrationStatusInProgress},
+	}
+	migration, err = k8s.Instance().UpdateMigration(migration)
+	require.NoError(t, err, "Error updating migration")
+	require.Equal(t, 25, getMigrationProgress(migration, 1, 0))
+	require.Equal(t, "schedule1", getMigrationScheduleName(migration))
+
+	migration.Status.Stage = storkv1.MigrationStageApplications
+	migration.Status.Volumes[1].Status = storkv1.MigrationStatusSuccessful
+	migration.Status.Resources = []*storkv1.ResourceInfo{
+		{Name: "resource1", Status: storkv1.MigrationStatusSuccessful},
+		{Name: "resource2", Status: storkv1.MigrationStatusSuccessful},
+		{Name: "resource3", Status: storkv1.MigrationStatusSuccessful},
+		{Name: "resource4", Status: storkv1.MigrationStatusInProgress},
+	}
+	migration, err = k8s.Instance().UpdateMigration(migration)
+	require.NoError(t, err, "Error updating migration")
+	require.Equal(t, 87, getMigrationProgress(migration, 2, 3))
+
+	expected := "NAME                       CLUSTERPAIR    STAGE          STATUS       VOLUMES   RESOURCES   PROGRESS   CREATED   ELAPSED   SCHEDULE    NAMESPACES   PRE-EXEC-RULE   POST-EXEC-RULE\n" +
+		"getmigrationprogresstest   clusterpair1   Applications   InProgress   2/2       3/4         87%                            schedule1   namespace1                   \n"
+	cmdArgs := []string{"get", "migrations", "-n", "test", "-o", "wide"}
+	testCommon(t, cmdArgs, nil, expected, false)
+
+	// Watching should stop once the migration is complete
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		runCommand(t, []string{"get", "migrations", "-n", "test", "--watch"})
+	}()
+	select {
+	case <-done:
+		t.Fatalf("Watch shouldn't stop till the migration is complete")
+	case <-time.After(20 * time.Millisecond):
+	}
+	migration.Status.Stage = storkv1.MigrationStageFinal
+	migration.Status.Status = storkv1.MigrationStatusSuccessful
+	_, err = k8s.Instance().UpdateMigration(migration)
+	require.NoError(t, err, "Error updating migration")
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for watch to stop")
+	}
+}
+
 func TestCreateMigrationsNoNamespace(t *testing.T) {
 	cmdArgs := []string{"create", "migrations", "-c", "clusterPair1", "migration1"}
 
@@ -169,6 +263,36 @@ func TestCreateDuplicateMigrations(t *testing.T) {
 	testCommon(t, cmdArgs, nil, expected, true)
 }
 
+func TestCreateMigrationsDryRun(t *testing.T) {
+	defer resetTest()
+	cmdArgs := []string{"create", "migrations", "-n", "test", "-c", "clusterpair1", "--namespaces", "namespace1",
+		"--dry-run=client", "dryrunmigration"}
+	expected := "Migration dryrunmigration created (dry run)\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+
+	for _, format := range []string{"json", "yaml"} {
+		migration := &storkv1.Migration{}
+		require.NoError(t, yaml.Unmarshal([]byte(runCommand(t, append(cmdArgs, "-o", format))), migration),
+			"Error decoding %v output", format)
+		require.Equal(t, "Migration", migration.Kind)
+		require.Equal(t, "stork.libopenstorage.org/v1alpha1", migration.APIVersion)
+		require.Equal(t, "dryrunmigration", migration.Name)
+		require.Equal(t, "test", migration.Namespace)
+		require.Equal(t, "clusterpair1", migration.Spec.ClusterPair)
+	}
+
+	migrations, err := k8s.Instance().ListMigrations("test")
+	require.NoError(t, err, "Error listing migrations")
+	require.Len(t, migrations.Items, 0, "Migration shouldn't be created for a dry run")
+
+	cmdArgs = []string{"create", "migrations", "-n", "test", "-c", "clusterpair1", "--namespaces", "namespace1",
+		"--dry-run=server", "dryrunmigration"}
+	cmd := NewCommand(testFactory, nil, ioutil.Discard, ioutil.Discard)
+	cmd.SetOutput(ioutil.Discard)
+	cmd.SetArgs(cmdArgs)
+	require.EqualError(t, cmd.Execute(), "invalid argument \"server\" for \"--dry-run\" flag: must be \"none\" or \"client\"")
+}
+
 func TestDeleteMigrationsNoMigrationName(t *testing.T) {
 	cmdArgs := []string{"delete", "migrations"}
 
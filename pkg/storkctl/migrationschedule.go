@@ -3,6 +3,7 @@ package storkctl
 import (
 	"fmt"
 	"io"
+	"strings"
 	"time"
 
 	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
@@ -13,11 +14,13 @@ import (
 	"k8s.io/kubernetes/pkg/printers"
 )
 
-var migrationScheduleColumns = []string{"NAME", "POLICYNAME", "CLUSTERPAIR", "SUSPEND", "LAST-SUCCESS-TIME", "LAST-SUCCESS-DURATION"}
+var migrationScheduleColumns = []string{"NAME", "POLICYNAME", "CLUSTERPAIR", "SUSPEND", "LAST-SUCCESS-TIME", "LAST-SUCCESS-DURATION", "RPO"}
+var migrationScheduleWideColumns = []string{"NAMESPACES", "PRE-EXEC-RULE", "POST-EXEC-RULE"}
 var migrationScheduleSubcommand = "migrationschedules"
 var migrationScheduleAliases = []string{"migrationschedule"}
 
 func newCreateMigrationScheduleCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	dryRun := &dryRunOptions{}
 	var migrationScheduleName string
 	var clusterPair string
 	var namespaceList []string
@@ -71,6 +74,12 @@ func newCreateMigrationScheduleCommand(cmdFactory Factory, ioStreams genericclio
 			}
 			migrationSchedule.Name = migrationScheduleName
 			migrationSchedule.Namespace = cmdFactory.GetNamespace()
+			if dryRun.enabled() {
+				if err := dryRun.printObject(c, cmdFactory, migrationSchedule, migrationSchedule.Name, ioStreams.Out); err != nil {
+					util.CheckErr(err)
+				}
+				return
+			}
 			_, err := k8s.Instance().CreateMigrationSchedule(migrationSchedule)
 			if err != nil {
 				util.CheckErr(err)
@@ -89,77 +98,81 @@ func newCreateMigrationScheduleCommand(cmdFactory Factory, ioStreams genericclio
 	createMigrationScheduleCommand.Flags().StringVarP(&postExecRule, "postExecRule", "", "", "Rule to run after executing migration")
 	createMigrationScheduleCommand.Flags().StringVarP(&schedulePolicyName, "schedulePolicyName", "s", "", "Name of the schedule policy to use")
 	createMigrationScheduleCommand.Flags().BoolVar(&suspend, "suspend", false, "Flag to denote whether schedule should be suspended on creation")
+	dryRun.addFlags(createMigrationScheduleCommand)
 
 	return createMigrationScheduleCommand
 }
 
 func newGetMigrationScheduleCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
 	var clusterPair string
+	var watch bool
 	getMigrationScheduleCommand := &cobra.Command{
 		Use:     migrationScheduleSubcommand,
 		Aliases: migrationScheduleAliases,
 		Short:   "Get migration schedules",
 		Run: func(c *cobra.Command, args []string) {
-			var migrationSchedules *storkv1.MigrationScheduleList
-			var err error
-
-			namespaces, err := cmdFactory.GetAllNamespaces()
-			if err != nil {
-				util.CheckErr(err)
-				return
-			}
-			if len(args) > 0 {
-				migrationSchedules = new(storkv1.MigrationScheduleList)
-				for _, migrationScheduleName := range args {
-					for _, ns := range namespaces {
-						migrationSchedule, err := k8s.Instance().GetMigrationSchedule(migrationScheduleName, ns)
-						if err != nil {
-							util.CheckErr(err)
-							return
-						}
-						migrationSchedules.Items = append(migrationSchedules.Items, *migrationSchedule)
-					}
+			for {
+				migrationSchedules, err := getMigrationSchedules(cmdFactory, args, clusterPair)
+				if err != nil {
+					util.CheckErr(err)
+					return
 				}
-			} else {
-				var tempMigrationSchedules storkv1.MigrationScheduleList
-				for _, ns := range namespaces {
-					migrationSchedules, err = k8s.Instance().ListMigrationSchedules(ns)
-					if err != nil {
-						util.CheckErr(err)
-						return
-					}
-					tempMigrationSchedules.Items = append(tempMigrationSchedules.Items, migrationSchedules.Items...)
+				if err := printObjects(c, migrationSchedules, cmdFactory, migrationScheduleColumns, migrationScheduleWideColumns, migrationSchedulePrinter, ioStreams.Out); err != nil {
+					util.CheckErr(err)
+					return
 				}
-				migrationSchedules = &tempMigrationSchedules
+				if !watch {
+					return
+				}
+				time.Sleep(waitPollInterval)
 			}
+		},
+	}
+	getMigrationScheduleCommand.Flags().StringVarP(&clusterPair, "clusterpair", "c", "", "Name of the cluster pair for which to list migration schedules")
+	getMigrationScheduleCommand.Flags().BoolVarP(&watch, "watch", "w", false, "Keep printing the migration schedules till interrupted")
+	cmdFactory.BindGetFlags(getMigrationScheduleCommand.Flags())
 
-			if len(clusterPair) != 0 {
-				var tempMigrationSchedules storkv1.MigrationScheduleList
+	return getMigrationScheduleCommand
+}
 
-				for _, migrationSchedule := range migrationSchedules.Items {
-					if migrationSchedule.Spec.Template.Spec.ClusterPair == clusterPair {
-						tempMigrationSchedules.Items = append(tempMigrationSchedules.Items, migrationSchedule)
-						continue
-					}
+func getMigrationSchedules(cmdFactory Factory, names []string, clusterPair string) (*storkv1.MigrationScheduleList, error) {
+	namespaces, err := cmdFactory.GetAllNamespaces()
+	if err != nil {
+		return nil, err
+	}
+	migrationSchedules := new(storkv1.MigrationScheduleList)
+	if len(names) > 0 {
+		for _, migrationScheduleName := range names {
+			for _, ns := range namespaces {
+				migrationSchedule, err := k8s.Instance().GetMigrationSchedule(migrationScheduleName, ns)
+				if err != nil {
+					return nil, err
 				}
-				migrationSchedules = &tempMigrationSchedules
+				migrationSchedules.Items = append(migrationSchedules.Items, *migrationSchedule)
 			}
-
-			if len(migrationSchedules.Items) == 0 {
-				handleEmptyList(ioStreams.Out)
-				return
+		}
+	} else {
+		for _, ns := range namespaces {
+			nsMigrationSchedules, err := k8s.Instance().ListMigrationSchedules(ns)
+			if err != nil {
+				return nil, err
 			}
+			migrationSchedules.Items = append(migrationSchedules.Items, nsMigrationSchedules.Items...)
+		}
+	}
 
-			if err := printObjects(c, migrationSchedules, cmdFactory, migrationScheduleColumns, migrationSchedulePrinter, ioStreams.Out); err != nil {
-				util.CheckErr(err)
-				return
+	if len(clusterPair) != 0 {
+		var tempMigrationSchedules storkv1.MigrationScheduleList
+
+		for _, migrationSchedule := range migrationSchedules.Items {
+			if migrationSchedule.Spec.Template.Spec.ClusterPair == clusterPair {
+				tempMigrationSchedules.Items = append(tempMigrationSchedules.Items, migrationSchedule)
+				continue
 			}
-		},
+		}
+		migrationSchedules = &tempMigrationSchedules
 	}
-	getMigrationScheduleCommand.Flags().StringVarP(&clusterPair, "clusterpair", "c", "", "Name of the cluster pair for which to list migration schedules")
-	cmdFactory.BindGetFlags(getMigrationScheduleCommand.Flags())
-
-	return getMigrationScheduleCommand
+	return migrationSchedules, nil
 }
 
 func newDeleteMigrationScheduleCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
@@ -230,6 +243,9 @@ func migrationSchedulePrinter(migrationScheduleList *storkv1.MigrationScheduleLi
 
 		lastSuccessTime := time.Time{}
 		lastSuccessDuration := ""
+		// The RPO is the time since the start of the last successful
+		// migration since changes after that might not have been migrated
+		rpo := ""
 		for _, policyType := range storkv1.GetValidSchedulePolicyTypes() {
 			if len(migrationSchedule.Status.Items[policyType]) == 0 {
 				continue
@@ -238,6 +254,7 @@ func migrationSchedulePrinter(migrationScheduleList *storkv1.MigrationScheduleLi
 				if migrationStatus.Status == storkv1.MigrationStatusSuccessful && migrationStatus.FinishTimestamp.Time.After(lastSuccessTime) {
 					lastSuccessTime = migrationStatus.FinishTimestamp.Time
 					lastSuccessDuration = migrationStatus.FinishTimestamp.Time.Sub(migrationStatus.CreationTimestamp.Time).String()
+					rpo = time.Since(migrationStatus.CreationTimestamp.Time).Round(time.Minute).String()
 				}
 			}
 		}
@@ -249,16 +266,28 @@ func migrationSchedulePrinter(migrationScheduleList *storkv1.MigrationScheduleLi
 			suspend = *migrationSchedule.Spec.Suspend
 		}
 
-		if _, err := fmt.Fprintf(writer, "%v\t%v\t%v\t%v\t%v\t%v\n",
+		if _, err := fmt.Fprintf(writer, "%v\t%v\t%v\t%v\t%v\t%v\t%v",
 			name,
 			migrationSchedule.Spec.SchedulePolicyName,
 			migrationSchedule.Spec.Template.Spec.ClusterPair,
 			suspend,
 			toTimeString(lastSuccessTime),
 			lastSuccessDuration,
+			rpo,
 		); err != nil {
 			return err
 		}
+		if options.Wide {
+			if _, err := fmt.Fprintf(writer, "\t%v\t%v\t%v",
+				strings.Join(migrationSchedule.Spec.Template.Spec.Namespaces, ","),
+				migrationSchedule.Spec.Template.Spec.PreExecRule,
+				migrationSchedule.Spec.Template.Spec.PostExecRule); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintln(writer); err != nil {
+			return err
+		}
 	}
 	return nil
 }
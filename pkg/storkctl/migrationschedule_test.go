@@ -58,8 +58,8 @@ func TestGetMigrationSchedulesOneMigrationSchedule(t *testing.T) {
 	defer resetTest()
 	createMigrationScheduleAndVerify(t, "getmigrationscheduletest", "testpolicy", "test", "clusterpair1", []string{"namespace1"}, "preExec", "postExec", true)
 
-	expected := "NAME                       POLICYNAME   CLUSTERPAIR    SUSPEND   LAST-SUCCESS-TIME   LAST-SUCCESS-DURATION\n" +
-		"getmigrationscheduletest   testpolicy   clusterpair1   true                          \n"
+	expected := "NAME                       POLICYNAME   CLUSTERPAIR    SUSPEND   LAST-SUCCESS-TIME   LAST-SUCCESS-DURATION   RPO\n" +
+		"getmigrationscheduletest   testpolicy   clusterpair1   true                                                  \n"
 
 	cmdArgs := []string{"get", "migrationschedules", "-n", "test"}
 	testCommon(t, cmdArgs, nil, expected, false)
@@ -70,9 +70,9 @@ func TestGetMigrationSchedulesMultiple(t *testing.T) {
 	createMigrationScheduleAndVerify(t, "getmigrationscheduletest1", "testpolicy", "default", "clusterpair1", []string{"namespace1"}, "", "", true)
 	createMigrationScheduleAndVerify(t, "getmigrationscheduletest2", "testpolicy", "default", "clusterpair2", []string{"namespace1"}, "", "", true)
 
-	expected := "NAME                        POLICYNAME   CLUSTERPAIR    SUSPEND   LAST-SUCCESS-TIME   LAST-SUCCESS-DURATION\n" +
-		"getmigrationscheduletest1   testpolicy   clusterpair1   true                          \n" +
-		"getmigrationscheduletest2   testpolicy   clusterpair2   true                          \n"
+	expected := "NAME                        POLICYNAME   CLUSTERPAIR    SUSPEND   LAST-SUCCESS-TIME   LAST-SUCCESS-DURATION   RPO\n" +
+		"getmigrationscheduletest1   testpolicy   clusterpair1   true                                                  \n" +
+		"getmigrationscheduletest2   testpolicy   clusterpair2   true                                                  \n"
 
 	cmdArgs := []string{"get", "migrationschedules", "getmigrationscheduletest1", "getmigrationscheduletest2"}
 	testCommon(t, cmdArgs, nil, expected, false)
@@ -81,8 +81,8 @@ func TestGetMigrationSchedulesMultiple(t *testing.T) {
 	cmdArgs = []string{"get", "migrationschedules"}
 	testCommon(t, cmdArgs, nil, expected, false)
 
-	expected = "NAME                        POLICYNAME   CLUSTERPAIR    SUSPEND   LAST-SUCCESS-TIME   LAST-SUCCESS-DURATION\n" +
-		"getmigrationscheduletest1   testpolicy   clusterpair1   true                          \n"
+	expected = "NAME                        POLICYNAME   CLUSTERPAIR    SUSPEND   LAST-SUCCESS-TIME   LAST-SUCCESS-DURATION   RPO\n" +
+		"getmigrationscheduletest1   testpolicy   clusterpair1   true                                                  \n"
 	// Should get only one migration if name given
 	cmdArgs = []string{"get", "migrationschedules", "getmigrationscheduletest1"}
 	testCommon(t, cmdArgs, nil, expected, false)
@@ -98,17 +98,17 @@ func TestGetMigrationSchedulesMultipleNamespaces(t *testing.T) {
 	createMigrationScheduleAndVerify(t, "getmigrationscheduletest1", "testpolicy", "test1", "clusterpair1", []string{"namespace1"}, "", "", true)
 	createMigrationScheduleAndVerify(t, "getmigrationscheduletest2", "testpolicy", "test2", "clusterpair2", []string{"namespace1"}, "", "", true)
 
-	expected := "NAME                        POLICYNAME   CLUSTERPAIR    SUSPEND   LAST-SUCCESS-TIME   LAST-SUCCESS-DURATION\n" +
-		"getmigrationscheduletest1   testpolicy   clusterpair1   true                          \n"
+	expected := "NAME                        POLICYNAME   CLUSTERPAIR    SUSPEND   LAST-SUCCESS-TIME   LAST-SUCCESS-DURATION   RPO\n" +
+		"getmigrationscheduletest1   testpolicy   clusterpair1   true                                                  \n"
 
 	cmdArgs := []string{"get", "migrationschedules", "-n", "test1"}
 	testCommon(t, cmdArgs, nil, expected, false)
 
 	// Should get all migrationschedules
 	cmdArgs = []string{"get", "migrationschedules", "--all-namespaces"}
-	expected = "NAMESPACE   NAME                        POLICYNAME   CLUSTERPAIR    SUSPEND   LAST-SUCCESS-TIME   LAST-SUCCESS-DURATION\n" +
-		"test1       getmigrationscheduletest1   testpolicy   clusterpair1   true                          \n" +
-		"test2       getmigrationscheduletest2   testpolicy   clusterpair2   true                          \n"
+	expected = "NAMESPACE   NAME                        POLICYNAME   CLUSTERPAIR    SUSPEND   LAST-SUCCESS-TIME   LAST-SUCCESS-DURATION   RPO\n" +
+		"test1       getmigrationscheduletest1   testpolicy   clusterpair1   true                                                  \n" +
+		"test2       getmigrationscheduletest2   testpolicy   clusterpair2   true                                                  \n"
 	testCommon(t, cmdArgs, nil, expected, false)
 }
 
@@ -117,8 +117,8 @@ func TestGetMigrationSchedulesWithClusterPair(t *testing.T) {
 	createMigrationScheduleAndVerify(t, "getmigrationscheduletest1", "testpolicy", "default", "clusterpair1", []string{"namespace1"}, "", "", true)
 	createMigrationScheduleAndVerify(t, "getmigrationscheduletest2", "testpolicy", "default", "clusterpair2", []string{"namespace1"}, "", "", true)
 
-	expected := "NAME                        POLICYNAME   CLUSTERPAIR    SUSPEND   LAST-SUCCESS-TIME   LAST-SUCCESS-DURATION\n" +
-		"getmigrationscheduletest1   testpolicy   clusterpair1   true                          \n"
+	expected := "NAME                        POLICYNAME   CLUSTERPAIR    SUSPEND   LAST-SUCCESS-TIME   LAST-SUCCESS-DURATION   RPO\n" +
+		"getmigrationscheduletest1   testpolicy   clusterpair1   true                                                  \n"
 
 	cmdArgs := []string{"get", "migrationschedules", "-c", "clusterpair1"}
 	testCommon(t, cmdArgs, nil, expected, false)
@@ -146,8 +146,8 @@ func TestGetMigrationSchedulesWithStatus(t *testing.T) {
 	migrationSchedule, err = k8s.Instance().UpdateMigrationSchedule(migrationSchedule)
 	require.NoError(t, err, "Error updating migration schedule")
 
-	expected := "NAME                             POLICYNAME   CLUSTERPAIR    SUSPEND   LAST-SUCCESS-TIME     LAST-SUCCESS-DURATION\n" +
-		"getmigrationschedulestatustest   testpolicy   clusterpair1   true      " + toTimeString(finishTimestamp.Time) + "   5m0s\n"
+	expected := "NAME                             POLICYNAME   CLUSTERPAIR    SUSPEND   LAST-SUCCESS-TIME     LAST-SUCCESS-DURATION   RPO\n" +
+		"getmigrationschedulestatustest   testpolicy   clusterpair1   true      " + toTimeString(finishTimestamp.Time) + "   5m0s                    0s\n"
 	cmdArgs := []string{"get", "migrationschedules", "getmigrationschedulestatustest"}
 	testCommon(t, cmdArgs, nil, expected, false)
 
@@ -164,8 +164,8 @@ func TestGetMigrationSchedulesWithStatus(t *testing.T) {
 	migrationSchedule, err = k8s.Instance().UpdateMigrationSchedule(migrationSchedule)
 	require.NoError(t, err, "Error updating migration schedule")
 
-	expected = "NAME                             POLICYNAME   CLUSTERPAIR    SUSPEND   LAST-SUCCESS-TIME     LAST-SUCCESS-DURATION\n" +
-		"getmigrationschedulestatustest   testpolicy   clusterpair1   true      " + toTimeString(finishTimestamp.Time) + "   5m0s\n"
+	expected = "NAME                             POLICYNAME   CLUSTERPAIR    SUSPEND   LAST-SUCCESS-TIME     LAST-SUCCESS-DURATION   RPO\n" +
+		"getmigrationschedulestatustest   testpolicy   clusterpair1   true      " + toTimeString(finishTimestamp.Time) + "   5m0s                    0s\n"
 	cmdArgs = []string{"get", "migrationschedules", "getmigrationschedulestatustest"}
 	testCommon(t, cmdArgs, nil, expected, false)
 
@@ -182,8 +182,8 @@ func TestGetMigrationSchedulesWithStatus(t *testing.T) {
 	_, err = k8s.Instance().UpdateMigrationSchedule(migrationSchedule)
 	require.NoError(t, err, "Error updating migration schedule")
 
-	expected = "NAME                             POLICYNAME   CLUSTERPAIR    SUSPEND   LAST-SUCCESS-TIME     LAST-SUCCESS-DURATION\n" +
-		"getmigrationschedulestatustest   testpolicy   clusterpair1   true      " + toTimeString(finishTimestamp.Time) + "   5m0s\n"
+	expected = "NAME                             POLICYNAME   CLUSTERPAIR    SUSPEND   LAST-SUCCESS-TIME     LAST-SUCCESS-DURATION   RPO\n" +
+		"getmigrationschedulestatustest   testpolicy   clusterpair1   true      " + toTimeString(finishTimestamp.Time) + "   5m0s                    0s\n"
 	cmdArgs = []string{"get", "migrationschedules", "getmigrationschedulestatustest"}
 	testCommon(t, cmdArgs, nil, expected, false)
 }
@@ -266,3 +266,37 @@ func TestDeleteMigrationSchedules(t *testing.T) {
 	expected += "MigrationSchedule deletemigration2 deleted successfully\n"
 	testCommon(t, cmdArgs, nil, expected, false)
 }
+
+func TestGetMigrationSchedulesRPO(t *testing.T) {
+	defer resetTest()
+	createMigrationScheduleAndVerify(t, "getmigrationschedulerpotest", "testpolicy", "default", "clusterpair1", []string{"namespace1"}, "", "", false)
+	migrationSchedule, err := k8s.Instance().GetMigrationSchedule("getmigrationschedulerpotest", "default")
+	require.NoError(t, err, "Error getting migration schedule")
+
+	// The RPO is from the start of the last successful migration
+	creationTimestamp := metav1.NewTime(time.Now().Add(-2 * time.Hour))
+	finishTimestamp := metav1.NewTime(creationTimestamp.Add(30 * time.Minute))
+	migrationSchedule.Status.Items = map[storkv1.SchedulePolicyType][]*storkv1.ScheduledMigrationStatus{
+		storkv1.SchedulePolicyTypeInterval: {
+			{
+				Name:              "successfulmigration",
+				CreationTimestamp: creationTimestamp,
+				FinishTimestamp:   finishTimestamp,
+				Status:            storkv1.MigrationStatusSuccessful,
+			},
+			{
+				Name:              "failedmigration",
+				CreationTimestamp: metav1.NewTime(time.Now().Add(-time.Hour)),
+				FinishTimestamp:   metav1.NewTime(time.Now().Add(-30 * time.Minute)),
+				Status:            storkv1.MigrationStatusFailed,
+			},
+		},
+	}
+	_, err = k8s.Instance().UpdateMigrationSchedule(migrationSchedule)
+	require.NoError(t, err, "Error updating migration schedule")
+
+	expected := "NAME                          POLICYNAME   CLUSTERPAIR    SUSPEND   LAST-SUCCESS-TIME     LAST-SUCCESS-DURATION   RPO\n" +
+		"getmigrationschedulerpotest   testpolicy   clusterpair1   false     " + toTimeString(finishTimestamp.Time) + "   30m0s                   2h0m0s\n"
+	cmdArgs := []string{"get", "migrationschedules", "getmigrationschedulerpotest"}
+	testCommon(t, cmdArgs, nil, expected, false)
+}
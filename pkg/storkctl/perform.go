@@ -0,0 +1,20 @@
+package storkctl
+
+import (
+	"github.com/spf13/cobra"
+	"k8s.io/kubernetes/pkg/kubectl/genericclioptions"
+)
+
+func newPerformCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	performCommands := &cobra.Command{
+		Use:   "perform",
+		Short: "Perform operations across clusters",
+	}
+
+	performCommands.AddCommand(
+		newPerformFailoverCommand(cmdFactory, ioStreams),
+		newPerformFailbackCommand(cmdFactory, ioStreams),
+	)
+
+	return performCommands
+}
@@ -0,0 +1,174 @@
+package storkctl
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/kubernetes/pkg/kubectl/cmd/util"
+	"k8s.io/kubernetes/pkg/kubectl/genericclioptions"
+)
+
+// pluginPrefix is the prefix for the names of the plugin binaries. A plugin
+// named storkctl-foo-bar is run for "storkctl foo bar".
+const pluginPrefix = "storkctl-"
+
+func newPluginCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	pluginCommands := &cobra.Command{
+		Use:   "plugin",
+		Short: "Manage storkctl plugins",
+		Long: "Plugins are executables named " + pluginPrefix + "<name> in the PATH. They are run with the " +
+			"remaining arguments when storkctl is called with a command that isn't built in, with dashes in " +
+			"the command names replaced by underscores in the executable name.",
+	}
+
+	pluginCommands.AddCommand(
+		newPluginListCommand(cmdFactory, ioStreams),
+	)
+
+	return pluginCommands
+}
+
+func newPluginListCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	pluginListCommand := &cobra.Command{
+		Use:   "list",
+		Short: "List the plugins in the PATH",
+		Run: func(c *cobra.Command, args []string) {
+			plugins, warnings := findPlugins(c.Root(), filepath.SplitList(os.Getenv("PATH")))
+			if len(plugins) == 0 {
+				handleEmptyList(ioStreams.Out)
+			} else if err := printPlugins(plugins, ioStreams.Out); err != nil {
+				util.CheckErr(err)
+				return
+			}
+			for _, warning := range warnings {
+				printMsg("warning: "+warning, ioStreams.ErrOut)
+			}
+		},
+	}
+
+	return pluginListCommand
+}
+
+// pluginInfo is a plugin binary found in the PATH
+type pluginInfo struct {
+	command string
+	path    string
+}
+
+// findPlugins returns the plugins in the directories along with warnings for
+// the ones that can't be run because they are shadowed by builtin commands
+// or by plugins earlier in the directories
+func findPlugins(root *cobra.Command, dirs []string) ([]pluginInfo, []string) {
+	plugins := make([]pluginInfo, 0)
+	warnings := make([]string, 0)
+	found := make(map[string]string)
+	for _, dir := range dirs {
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, file := range files {
+			if file.IsDir() || !strings.HasPrefix(file.Name(), pluginPrefix) || file.Mode()&0111 == 0 {
+				continue
+			}
+			path := filepath.Join(dir, file.Name())
+			command := getPluginCommand(file.Name())
+			if shadowedBy, present := found[command]; present {
+				warnings = append(warnings, fmt.Sprintf("%v is shadowed by %v", path, shadowedBy))
+				continue
+			}
+			found[command] = path
+			if isBuiltinCommand(root, strings.Fields(command)[0]) {
+				warnings = append(warnings, fmt.Sprintf("%v is shadowed by the builtin %v command", path, strings.Fields(command)[0]))
+				continue
+			}
+			plugins = append(plugins, pluginInfo{command: command, path: path})
+		}
+	}
+	return plugins, warnings
+}
+
+// getPluginCommand returns the command that runs the plugin binary
+func getPluginCommand(name string) string {
+	names := strings.Split(strings.TrimPrefix(name, pluginPrefix), "-")
+	for i := range names {
+		names[i] = strings.Replace(names[i], "_", "-", -1)
+	}
+	return strings.Join(names, " ")
+}
+
+func isBuiltinCommand(root *cobra.Command, name string) bool {
+	for _, c := range root.Commands() {
+		if c.Name() == name || c.HasAlias(name) {
+			return true
+		}
+	}
+	return name == "help"
+}
+
+func printPlugins(plugins []pluginInfo, out io.Writer) error {
+	writer := tabwriter.NewWriter(out, 0, 8, 3, ' ', 0)
+	if _, err := fmt.Fprintln(writer, "COMMAND\tPATH"); err != nil {
+		return err
+	}
+	for _, plugin := range plugins {
+		if _, err := fmt.Fprintf(writer, "%v\t%v\n", plugin.command, plugin.path); err != nil {
+			return err
+		}
+	}
+	return writer.Flush()
+}
+
+// RunPlugin runs the plugin for the args if the first arg isn't a builtin
+// command. The plugin for the longest list of command names in the args is
+// used and the rest of the args are passed to it. Returns false if there is
+// no plugin for the args, and the error from running the plugin otherwise.
+func RunPlugin(root *cobra.Command, args []string, in io.Reader, out io.Writer, errOut io.Writer) (bool, error) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") || isBuiltinCommand(root, args[0]) {
+		return false, nil
+	}
+	names := make([]string, 0)
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			break
+		}
+		names = append(names, strings.Replace(arg, "-", "_", -1))
+	}
+	for i := len(names); i > 0; i-- {
+		path, err := exec.LookPath(pluginPrefix + strings.Join(names[:i], "-"))
+		if err != nil {
+			continue
+		}
+		pluginCmd := exec.Command(path, args[i:]...)
+		pluginCmd.Stdin = in
+		pluginCmd.Stdout = out
+		pluginCmd.Stderr = errOut
+		pluginCmd.Env = os.Environ()
+		return true, pluginCmd.Run()
+	}
+	return false, nil
+}
+
+// PrintObjects prints the objects in the output format set in the factory,
+// either as a table using the printer function or encoded as json or yaml.
+// Plugins written in Go can use it along with the factory to print objects
+// the same way as the builtin commands.
+func PrintObjects(
+	cmd *cobra.Command,
+	object runtime.Object,
+	cmdFactory Factory,
+	columns []string,
+	wideColumns []string,
+	printerFunc interface{},
+	out io.Writer,
+) error {
+	return printObjects(cmd, object, cmdFactory, columns, wideColumns, printerFunc, out)
+}
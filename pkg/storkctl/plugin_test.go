@@ -0,0 +1,78 @@
+// +build unittest
+
+package storkctl
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/kubernetes/pkg/kubectl/genericclioptions"
+)
+
+func createTestPlugin(t *testing.T, dir string, name string, script string) string {
+	path := filepath.Join(dir, name)
+	require.NoError(t, ioutil.WriteFile(path, []byte("#!/bin/sh\n"+script+"\n"), 0755), "Error writing plugin")
+	return path
+}
+
+func TestPlugins(t *testing.T) {
+	dir1, err := ioutil.TempDir("", "storkctl-plugins")
+	require.NoError(t, err, "Error creating plugin dir")
+	defer os.RemoveAll(dir1)
+	dir2, err := ioutil.TempDir("", "storkctl-plugins")
+	require.NoError(t, err, "Error creating plugin dir")
+	defer os.RemoveAll(dir2)
+
+	hello := createTestPlugin(t, dir1, "storkctl-hello", `echo "hello $@"`)
+	helloWorld := createTestPlugin(t, dir1, "storkctl-hello-big_world", `echo "hello big world $@"; exit 3`)
+	shadowedHello := createTestPlugin(t, dir2, "storkctl-hello", `echo "shadowed"`)
+	shadowedGet := createTestPlugin(t, dir2, "storkctl-get-report", `echo "report"`)
+	// Files that aren't executable aren't plugins
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir2, "storkctl-readme"), []byte("readme"), 0644))
+
+	path := os.Getenv("PATH")
+	require.NoError(t, os.Setenv("PATH", dir1+string(filepath.ListSeparator)+dir2))
+	defer func() {
+		require.NoError(t, os.Setenv("PATH", path))
+	}()
+
+	streams, _, out, errOut := genericclioptions.NewTestIOStreams()
+	cmd := NewCommand(testFactory, streams.In, streams.Out, streams.ErrOut)
+	cmd.SetOutput(out)
+	cmd.SetArgs([]string{"plugin", "list"})
+	require.NoError(t, cmd.Execute(), "Error listing plugins")
+	require.Equal(t, "COMMAND           PATH\n"+
+		"hello             "+hello+"\n"+
+		"hello big-world   "+helloWorld+"\n", out.String())
+	require.Equal(t, "warning: "+shadowedGet+" is shadowed by the builtin get command\n"+
+		"warning: "+shadowedHello+" is shadowed by "+hello+"\n", errOut.String())
+
+	for _, test := range []struct {
+		args     []string
+		found    bool
+		output   string
+		exitCode int
+	}{
+		{args: []string{"hello", "-n", "test"}, found: true, output: "hello -n test\n"},
+		{args: []string{"hello", "world"}, found: true, output: "hello world\n"},
+		{args: []string{"hello", "big-world", "arg"}, found: true, output: "hello big world arg\n", exitCode: 3},
+		{args: []string{"get", "report"}},
+		{args: []string{"-n", "test", "hello"}},
+		{args: []string{"missing"}},
+		{},
+	} {
+		output := &bytes.Buffer{}
+		found, err := RunPlugin(NewCommand(testFactory, nil, output, output), test.args, nil, output, output)
+		require.Equal(t, test.found, found, "Unexpected result for %v", test.args)
+		require.Equal(t, test.output, output.String())
+		if test.exitCode != 0 {
+			require.EqualError(t, err, "exit status 3")
+		} else {
+			require.NoError(t, err)
+		}
+	}
+}
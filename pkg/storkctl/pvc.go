@@ -19,6 +19,7 @@ var pvcSubcommand = "persistentvolumeclaims"
 var pvcAliases = []string{"persistentvolumeclaim", "volume", "pvc"}
 
 func newCreatePVCCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	dryRun := &dryRunOptions{}
 	var snapName string
 	var pvcName string
 	var sourceNamespace string
@@ -71,6 +72,12 @@ func newCreatePVCCommand(cmdFactory Factory, ioStreams genericclioptions.IOStrea
 			if len(sourceNamespace) != 0 {
 				pvc.Annotations[snapshotcontrollers.StorkSnapshotSourceNamespaceAnnotation] = sourceNamespace
 			}
+			if dryRun.enabled() {
+				if err := dryRun.printObject(c, cmdFactory, pvc, pvc.Name, ioStreams.Out); err != nil {
+					util.CheckErr(err)
+				}
+				return
+			}
 			_, err = k8s.Instance().CreatePersistentVolumeClaim(pvc)
 			if err != nil {
 				util.CheckErr(err)
@@ -84,6 +91,7 @@ func newCreatePVCCommand(cmdFactory Factory, ioStreams genericclioptions.IOStrea
 	createPVCCommand.Flags().StringVar(&sourceNamespace, "source-ns", "", "The source namespace if the snapshot was created in a different namespace")
 	createPVCCommand.Flags().StringVarP(&accessMode, "acccess-mode", "a", string(v1.ReadWriteOnce), "Access mode for the new PVC")
 	createPVCCommand.Flags().StringVar(&size, "size", "", "Size for the new PVC (example 2Gi)")
+	dryRun.addFlags(createPVCCommand)
 
 	return createPVCCommand
 }
@@ -4,6 +4,10 @@ package storkctl
 
 import (
 	"testing"
+
+	"github.com/portworx/sched-ops/k8s"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/errors"
 )
 
 func TestCreatePVCNoName(t *testing.T) {
@@ -40,3 +44,14 @@ func TestCreatePVCSourceNamespace(t *testing.T) {
 	expected := "PersistentVolumeClaim pvc2 created successfully\n"
 	testCommon(t, cmdArgs, nil, expected, false)
 }
+
+func TestCreatePVCDryRun(t *testing.T) {
+	defer resetTest()
+	cmdArgs := []string{"create", "pvc", "--snapshot", "snap1", "--size", "1", "--dry-run", "pvc3"}
+
+	expected := "PersistentVolumeClaim pvc3 created (dry run)\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+
+	_, err := k8s.Instance().GetPersistentVolumeClaim("pvc3", "default")
+	require.True(t, errors.IsNotFound(err), "PVC shouldn't be created for a dry run")
+}
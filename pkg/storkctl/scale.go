@@ -0,0 +1,293 @@
+package storkctl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	migration "github.com/libopenstorage/stork/pkg/migration/controllers"
+	"github.com/portworx/sched-ops/k8s"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/kubernetes/pkg/kubectl/genericclioptions"
+)
+
+const defaultReplicasPath = "spec.replicas"
+
+// getDynamicClient returns the client used to scale custom resources.
+// Replaced in tests to use the fake client.
+var getDynamicClient = newDynamicClient
+
+func newDynamicClient(cmdFactory Factory) (dynamic.Interface, error) {
+	config, err := cmdFactory.GetConfig()
+	if err != nil {
+		return nil, err
+	}
+	return dynamic.NewForConfig(config)
+}
+
+// scalableApp is an application with a replica count
+type scalableApp struct {
+	kind        string
+	name        string
+	namespace   string
+	annotations map[string]string
+	replicas    int32
+	// update saves the replicas and annotations for the application
+	update func(replicas int32, annotations map[string]string) error
+}
+
+// scaleHandler lists the applications of a kind that can be scaled. New
+// handlers can be added for applications, like custom resources, that have
+// a replica count.
+type scaleHandler interface {
+	list(namespace string) ([]scalableApp, error)
+}
+
+// getScaleHandlers returns the handlers for the applications that are
+// migrated by stork
+func getScaleHandlers(ops k8s.Ops) []scaleHandler {
+	return []scaleHandler{
+		&statefulSetScaleHandler{ops: ops},
+		&deploymentScaleHandler{ops: ops},
+		&deploymentConfigScaleHandler{ops: ops},
+	}
+}
+
+type statefulSetScaleHandler struct {
+	ops k8s.Ops
+}
+
+func (h *statefulSetScaleHandler) list(namespace string) ([]scalableApp, error) {
+	statefulSets, err := h.ops.ListStatefulSets(namespace)
+	if err != nil {
+		return nil, err
+	}
+	apps := make([]scalableApp, 0)
+	for i := range statefulSets.Items {
+		statefulSet := statefulSets.Items[i]
+		apps = append(apps, scalableApp{
+			kind:        "statefulset",
+			name:        statefulSet.Name,
+			namespace:   statefulSet.Namespace,
+			annotations: statefulSet.Annotations,
+			replicas:    getReplicas(statefulSet.Spec.Replicas),
+			update: func(replicas int32, annotations map[string]string) error {
+				statefulSet.Spec.Replicas = &replicas
+				statefulSet.Annotations = annotations
+				_, err := h.ops.UpdateStatefulSet(&statefulSet)
+				return err
+			},
+		})
+	}
+	return apps, nil
+}
+
+type deploymentScaleHandler struct {
+	ops k8s.Ops
+}
+
+func (h *deploymentScaleHandler) list(namespace string) ([]scalableApp, error) {
+	deployments, err := h.ops.ListDeployments(namespace)
+	if err != nil {
+		return nil, err
+	}
+	apps := make([]scalableApp, 0)
+	for i := range deployments.Items {
+		deployment := deployments.Items[i]
+		apps = append(apps, scalableApp{
+			kind:        "deployment",
+			name:        deployment.Name,
+			namespace:   deployment.Namespace,
+			annotations: deployment.Annotations,
+			replicas:    getReplicas(deployment.Spec.Replicas),
+			update: func(replicas int32, annotations map[string]string) error {
+				deployment.Spec.Replicas = &replicas
+				deployment.Annotations = annotations
+				_, err := h.ops.UpdateDeployment(&deployment)
+				return err
+			},
+		})
+	}
+	return apps, nil
+}
+
+type deploymentConfigScaleHandler struct {
+	ops k8s.Ops
+}
+
+func (h *deploymentConfigScaleHandler) list(namespace string) ([]scalableApp, error) {
+	deploymentConfigs, err := h.ops.ListDeploymentConfigs(namespace)
+	if err != nil {
+		// DeploymentConfigs are only available on OpenShift
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	apps := make([]scalableApp, 0)
+	for i := range deploymentConfigs.Items {
+		deploymentConfig := deploymentConfigs.Items[i]
+		apps = append(apps, scalableApp{
+			kind:        "deploymentconfig",
+			name:        deploymentConfig.Name,
+			namespace:   deploymentConfig.Namespace,
+			annotations: deploymentConfig.Annotations,
+			replicas:    deploymentConfig.Spec.Replicas,
+			update: func(replicas int32, annotations map[string]string) error {
+				deploymentConfig.Spec.Replicas = replicas
+				deploymentConfig.Annotations = annotations
+				_, err := h.ops.UpdateDeploymentConfig(&deploymentConfig)
+				return err
+			},
+		})
+	}
+	return apps, nil
+}
+
+// customResourceScaleHandler scales custom resources that have a replica
+// count in their spec
+type customResourceScaleHandler struct {
+	client       dynamic.Interface
+	resource     schema.GroupVersionResource
+	replicasPath []string
+}
+
+// newCustomResourceScaleHandler parses a custom resource in the format
+// resource.version.group[:path.to.replicas]. The replicas are expected at
+// spec.replicas if the path isn't specified.
+func newCustomResourceScaleHandler(client dynamic.Interface, customResource string) (scaleHandler, error) {
+	replicasPath := defaultReplicasPath
+	if i := strings.Index(customResource, ":"); i >= 0 {
+		replicasPath = customResource[i+1:]
+		customResource = customResource[:i]
+	}
+	resource, _ := schema.ParseResourceArg(customResource)
+	if resource == nil || replicasPath == "" {
+		return nil, fmt.Errorf("invalid custom resource %v, should be in the format resource.version.group[:path.to.replicas]", customResource)
+	}
+	return &customResourceScaleHandler{
+		client:       client,
+		resource:     *resource,
+		replicasPath: strings.Split(replicasPath, "."),
+	}, nil
+}
+
+func (h *customResourceScaleHandler) list(namespace string) ([]scalableApp, error) {
+	objects, err := h.client.Resource(h.resource).Namespace(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	apps := make([]scalableApp, 0)
+	for i := range objects.Items {
+		object := objects.Items[i]
+		replicas, found, err := unstructured.NestedInt64(object.Object, h.replicasPath...)
+		if err != nil {
+			return nil, fmt.Errorf("error getting replicas for %v %v/%v: %v", h.resource.Resource, object.GetNamespace(), object.GetName(), err)
+		}
+		if !found {
+			continue
+		}
+		apps = append(apps, scalableApp{
+			kind:        strings.ToLower(object.GetKind()),
+			name:        object.GetName(),
+			namespace:   object.GetNamespace(),
+			annotations: object.GetAnnotations(),
+			replicas:    int32(replicas),
+			update: func(replicas int32, annotations map[string]string) error {
+				if err := unstructured.SetNestedField(object.Object, int64(replicas), h.replicasPath...); err != nil {
+					return err
+				}
+				object.SetAnnotations(annotations)
+				_, err := h.client.Resource(h.resource).Namespace(object.GetNamespace()).Update(&object)
+				return err
+			},
+		})
+	}
+	return apps, nil
+}
+
+func getReplicas(replicas *int32) int32 {
+	if replicas == nil {
+		return 1
+	}
+	return *replicas
+}
+
+// updateApplications activates or deactivates the applications that have
+// the replicas saved in the migration replicas annotation. Deactivating
+// saves the current replicas in the annotation so that activating restores
+// them. Errors for an application don't stop the rest of the applications
+// from being updated, they are returned together once all the applications
+// have been processed.
+func updateApplications(handlers []scaleHandler, namespace string, activate bool, ioStreams genericclioptions.IOStreams) error {
+	errs := make([]error, 0)
+	for _, handler := range handlers {
+		apps, err := handler.list(namespace)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, app := range apps {
+			savedReplicas, present := app.annotations[migration.StorkMigrationReplicasAnnotation]
+			if !present {
+				continue
+			}
+			replicas := int32(0)
+			annotations := app.annotations
+			if activate {
+				parsedReplicas, err := strconv.Atoi(savedReplicas)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("error parsing replicas for %v %v/%v: %v", app.kind, app.namespace, app.name, err))
+					continue
+				}
+				replicas = int32(parsedReplicas)
+			} else if app.replicas != 0 {
+				annotations = getScaledDownAnnotations(annotations, app.replicas)
+			}
+			if err := app.update(replicas, annotations); err != nil {
+				errs = append(errs, fmt.Errorf("error updating replicas for %v %v/%v: %v", app.kind, app.namespace, app.name, err))
+				continue
+			}
+			printMsg(fmt.Sprintf("Updated replicas for %v %v/%v to %v", app.kind, app.namespace, app.name, replicas), ioStreams.Out)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// scaleDownApplications sets the replicas for all the applications in the
+// namespace to 0. The replicas they had are saved in the same annotation used
+// for migrated applications so that they can be activated again.
+func scaleDownApplications(handlers []scaleHandler, namespace string, ioStreams genericclioptions.IOStreams) error {
+	for _, handler := range handlers {
+		apps, err := handler.list(namespace)
+		if err != nil {
+			return err
+		}
+		for _, app := range apps {
+			if app.replicas == 0 {
+				continue
+			}
+			if err := app.update(0, getScaledDownAnnotations(app.annotations, app.replicas)); err != nil {
+				return fmt.Errorf("error scaling down %v %v/%v: %v", app.kind, app.namespace, app.name, err)
+			}
+			printMsg(fmt.Sprintf("Scaled down %v %v/%v", app.kind, app.namespace, app.name), ioStreams.Out)
+		}
+	}
+	return nil
+}
+
+func getScaledDownAnnotations(annotations map[string]string, replicas int32) map[string]string {
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[migration.StorkMigrationReplicasAnnotation] = strconv.Itoa(int(replicas))
+	return annotations
+}
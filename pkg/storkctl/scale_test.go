@@ -0,0 +1,133 @@
+// +build unittest
+
+package storkctl
+
+import (
+	"fmt"
+	"testing"
+
+	migration "github.com/libopenstorage/stork/pkg/migration/controllers"
+	"github.com/portworx/sched-ops/k8s"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/kubernetes/pkg/kubectl/genericclioptions"
+)
+
+// testScaleHandler returns apps that are kept in memory
+type testScaleHandler struct {
+	apps map[string]*scalableApp
+}
+
+func (h *testScaleHandler) add(name string, replicas int32, annotations map[string]string) {
+	app := &scalableApp{
+		kind:        "testapp",
+		name:        name,
+		namespace:   "test",
+		annotations: annotations,
+		replicas:    replicas,
+	}
+	app.update = func(replicas int32, annotations map[string]string) error {
+		if replicas < 0 {
+			return fmt.Errorf("invalid replicas")
+		}
+		app.replicas = replicas
+		app.annotations = annotations
+		return nil
+	}
+	h.apps[name] = app
+}
+
+func (h *testScaleHandler) list(namespace string) ([]scalableApp, error) {
+	apps := make([]scalableApp, 0)
+	for _, name := range []string{"app1", "app2", "app3"} {
+		if app, present := h.apps[name]; present && app.namespace == namespace {
+			apps = append(apps, *app)
+		}
+	}
+	return apps, nil
+}
+
+func TestUpdateApplications(t *testing.T) {
+	handler := &testScaleHandler{apps: make(map[string]*scalableApp)}
+	handler.add("app1", 0, map[string]string{migration.StorkMigrationReplicasAnnotation: "3"})
+	// Apps that weren't migrated shouldn't be updated
+	handler.add("app2", 2, nil)
+	handler.add("app3", 0, map[string]string{migration.StorkMigrationReplicasAnnotation: "invalid"})
+	handlers := []scaleHandler{handler}
+
+	// Errors are returned after the rest of the apps have been updated
+	streams, _, out, _ := genericclioptions.NewTestIOStreams()
+	err := updateApplications(handlers, "test", true, streams)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "error parsing replicas for testapp test/app3")
+	require.Equal(t, "Updated replicas for testapp test/app1 to 3\n", out.String())
+	require.Equal(t, int32(3), handler.apps["app1"].replicas)
+	require.Equal(t, int32(2), handler.apps["app2"].replicas)
+
+	// All the errors are returned
+	handler.apps["app1"].annotations[migration.StorkMigrationReplicasAnnotation] = "-1"
+	err = updateApplications(handlers, "test", true, streams)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "error updating replicas for testapp test/app1: invalid replicas")
+	require.Contains(t, err.Error(), "error parsing replicas for testapp test/app3")
+	delete(handler.apps, "app3")
+
+	// The replicas are saved when deactivating so that they can be restored
+	handler.apps["app1"].replicas = 5
+	require.NoError(t, updateApplications(handlers, "test", false, streams))
+	require.Equal(t, int32(0), handler.apps["app1"].replicas)
+	require.Equal(t, "5", handler.apps["app1"].annotations[migration.StorkMigrationReplicasAnnotation])
+	require.NoError(t, updateApplications(handlers, "test", true, streams))
+	require.Equal(t, int32(5), handler.apps["app1"].replicas)
+
+	require.NoError(t, scaleDownApplications(handlers, "test", streams))
+	require.Equal(t, int32(0), handler.apps["app2"].replicas)
+	require.Equal(t, "2", handler.apps["app2"].annotations[migration.StorkMigrationReplicasAnnotation])
+}
+
+func TestCustomResourceScaleHandler(t *testing.T) {
+	handler, err := newCustomResourceScaleHandler(nil, "cassandras.v1.db.example.com")
+	require.NoError(t, err, "Error parsing custom resource")
+	customResourceHandler := handler.(*customResourceScaleHandler)
+	require.Equal(t, schema.GroupVersionResource{Group: "db.example.com", Version: "v1", Resource: "cassandras"},
+		customResourceHandler.resource)
+	require.Equal(t, []string{"spec", "replicas"}, customResourceHandler.replicasPath)
+
+	handler, err = newCustomResourceScaleHandler(nil, "cassandras.v1.db.example.com:spec.cluster.size")
+	require.NoError(t, err, "Error parsing custom resource")
+	require.Equal(t, []string{"spec", "cluster", "size"}, handler.(*customResourceScaleHandler).replicasPath)
+
+	_, err = newCustomResourceScaleHandler(nil, "cassandras")
+	require.Error(t, err)
+	_, err = newCustomResourceScaleHandler(nil, "cassandras.v1.db.example.com:")
+	require.Error(t, err)
+}
+
+func TestActivateMigrationsRestoresReplicas(t *testing.T) {
+	defer resetTest()
+	createMigratedDeployment(t)
+
+	cmdArgs := []string{"activate", "migrations", "-n", "dep"}
+	expected := "Updated replicas for deployment dep/migratedDeployment to 1\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+
+	// Scale up the deployment after it was activated
+	deployment, err := k8s.Instance().GetDeployment("migratedDeployment", "dep")
+	require.NoError(t, err, "Error getting deployment")
+	replicas := int32(4)
+	deployment.Spec.Replicas = &replicas
+	_, err = k8s.Instance().UpdateDeployment(deployment)
+	require.NoError(t, err, "Error updating deployment")
+
+	cmdArgs = []string{"deactivate", "migrations", "-n", "dep"}
+	expected = "Updated replicas for deployment dep/migratedDeployment to 0\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+
+	cmdArgs = []string{"activate", "migrations", "-n", "dep"}
+	expected = "Updated replicas for deployment dep/migratedDeployment to 4\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+
+	cmdArgs = []string{"activate", "migrations", "-n", "dep", "--custom-resources", "cassandras"}
+	expected = "error: invalid custom resource cassandras, should be in the format resource.version.group[:path.to.replicas]"
+	testCommon(t, cmdArgs, nil, expected, true)
+}
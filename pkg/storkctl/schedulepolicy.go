@@ -45,12 +45,7 @@ func newGetSchedulePolicyCommand(cmdFactory Factory, ioStreams genericclioptions
 				}
 			}
 
-			if len(schedulePolicies.Items) == 0 {
-				handleEmptyList(ioStreams.Out)
-				return
-			}
-
-			if err := printObjects(c, schedulePolicies, cmdFactory, schedulePolicyColumns, schedulePolicyPrinter, ioStreams.Out); err != nil {
+			if err := printObjects(c, schedulePolicies, cmdFactory, schedulePolicyColumns, nil, schedulePolicyPrinter, ioStreams.Out); err != nil {
 				util.CheckErr(err)
 				return
 			}
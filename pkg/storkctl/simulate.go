@@ -0,0 +1,186 @@
+package storkctl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/ghodss/yaml"
+	"github.com/libopenstorage/stork/pkg/extender"
+	"github.com/portworx/sched-ops/k8s"
+	"github.com/spf13/cobra"
+	"k8s.io/api/core/v1"
+	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/kubernetes/pkg/kubectl/cmd/util"
+	"k8s.io/kubernetes/pkg/kubectl/genericclioptions"
+	schedulerapi "k8s.io/kubernetes/pkg/scheduler/api"
+)
+
+const (
+	simulateSubcommand    = "simulate"
+	defaultStorkNamespace = "kube-system"
+	defaultStorkService   = "stork-service"
+	extenderPort          = "8099"
+)
+
+// simulateScheduling sends the simulate request for the pod to the extender.
+// Replaced in tests since it can't be served by the fake clients
+var simulateScheduling = simulateSchedulingThroughProxy
+
+func newSimulateCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	var specFile string
+	var storkNamespace string
+	var storkService string
+	simulateCommand := &cobra.Command{
+		Use:   simulateSubcommand + " [pod-name]",
+		Short: "Show the nodes and scores stork would return when scheduling a pod",
+		Run: func(c *cobra.Command, args []string) {
+			var pod *v1.Pod
+			var err error
+			if specFile != "" {
+				if len(args) != 0 {
+					util.CheckErr(fmt.Errorf("pod name can't be specified with a spec file"))
+					return
+				}
+				pod, err = readPodSpec(specFile)
+				if err != nil {
+					util.CheckErr(err)
+					return
+				}
+				if pod.Namespace == "" {
+					pod.Namespace = cmdFactory.GetNamespace()
+				}
+			} else {
+				if len(args) != 1 {
+					util.CheckErr(fmt.Errorf("exactly one pod name or a spec file needs to be provided"))
+					return
+				}
+				pod, err = k8s.Instance().GetPodByName(args[0], cmdFactory.GetNamespace())
+				if err != nil {
+					util.CheckErr(err)
+					return
+				}
+			}
+
+			config, err := cmdFactory.GetConfig()
+			if err != nil {
+				util.CheckErr(err)
+				return
+			}
+			result, err := simulateScheduling(config, storkNamespace, storkService, pod)
+			if err != nil {
+				util.CheckErr(fmt.Errorf("error simulating scheduling for pod %v: %v", pod.Name, err))
+				return
+			}
+			outputFormat, err := cmdFactory.GetOutputFormat()
+			if err != nil {
+				util.CheckErr(err)
+				return
+			}
+			if err := printSimulationResult(result, outputFormat, ioStreams.Out); err != nil {
+				util.CheckErr(err)
+				return
+			}
+		},
+	}
+	simulateCommand.Flags().StringVarP(&specFile, "filename", "f", "", "File with the spec of the pod to simulate scheduling for")
+	simulateCommand.Flags().StringVar(&storkNamespace, "stork-namespace", defaultStorkNamespace, "Namespace where stork is running")
+	simulateCommand.Flags().StringVar(&storkService, "stork-service", defaultStorkService, "Name of the service for stork")
+
+	return simulateCommand
+}
+
+func readPodSpec(specFile string) (*v1.Pod, error) {
+	spec, err := ioutil.ReadFile(specFile)
+	if err != nil {
+		return nil, err
+	}
+
+	pod := &v1.Pod{}
+	if err := k8syaml.NewYAMLOrJSONDecoder(bytes.NewReader(spec), len(spec)).Decode(pod); err != nil {
+		return nil, fmt.Errorf("error parsing pod spec from %v: %v", specFile, err)
+	}
+	if pod.Kind != "" && pod.Kind != "Pod" {
+		return nil, fmt.Errorf("spec in %v is for a %v, only pods are supported", specFile, pod.Kind)
+	}
+	return pod, nil
+}
+
+// simulateSchedulingThroughProxy sends the simulate request to the extender
+// through the API server proxy for the stork service
+func simulateSchedulingThroughProxy(
+	config *rest.Config,
+	storkNamespace string,
+	storkService string,
+	pod *v1.Pod,
+) (*extender.SimulationResult, error) {
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(&schedulerapi.ExtenderArgs{Pod: pod})
+	if err != nil {
+		return nil, err
+	}
+	response, err := client.CoreV1().RESTClient().Post().
+		Namespace(storkNamespace).
+		Resource("services").
+		Name(storkService + ":" + extenderPort).
+		SubResource("proxy").
+		Suffix(simulateSubcommand).
+		Body(body).
+		DoRaw()
+	if err != nil {
+		return nil, err
+	}
+	result := &extender.SimulationResult{}
+	if err := json.Unmarshal(response, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func printSimulationResult(result *extender.SimulationResult, outputFormat string, out io.Writer) error {
+	switch outputFormat {
+	case outputFormatJSON:
+		encoded, err := json.MarshalIndent(result, "", "    ")
+		if err != nil {
+			return err
+		}
+		printMsg(string(encoded), out)
+		return nil
+	case outputFormatYaml:
+		encoded, err := yaml.Marshal(result)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprint(out, string(encoded))
+		return err
+	}
+
+	if result.FilterError != "" {
+		printMsg(fmt.Sprintf("Pod can't be scheduled: %v", result.FilterError), out)
+		return nil
+	}
+	scores := make(schedulerapi.HostPriorityList, len(result.Scores))
+	copy(scores, result.Scores)
+	sort.SliceStable(scores, func(i, j int) bool {
+		return scores[i].Score > scores[j].Score
+	})
+	writer := tabwriter.NewWriter(out, 0, 8, 3, ' ', 0)
+	if _, err := fmt.Fprintln(writer, "NODE\tSCORE"); err != nil {
+		return err
+	}
+	for _, score := range scores {
+		if _, err := fmt.Fprintf(writer, "%v\t%v\n", score.Host, score.Score); err != nil {
+			return err
+		}
+	}
+	return writer.Flush()
+}
@@ -0,0 +1,121 @@
+// +build unittest
+
+package storkctl
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/libopenstorage/stork/pkg/extender"
+	"github.com/portworx/sched-ops/k8s"
+	"github.com/stretchr/testify/require"
+	"k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	schedulerapi "k8s.io/kubernetes/pkg/scheduler/api"
+)
+
+func setSimulationResult(t *testing.T, result *extender.SimulationResult, err error) {
+	simulateScheduling = func(_ *rest.Config, storkNamespace string, storkService string, pod *v1.Pod) (*extender.SimulationResult, error) {
+		require.Equal(t, defaultStorkNamespace, storkNamespace)
+		require.Equal(t, defaultStorkService, storkService)
+		return result, err
+	}
+}
+
+func TestSimulateNoPod(t *testing.T) {
+	defer resetTest()
+	cmdArgs := []string{"simulate"}
+	expected := "error: exactly one pod name or a spec file needs to be provided"
+	testCommon(t, cmdArgs, nil, expected, true)
+}
+
+func TestSimulatePodNotFound(t *testing.T) {
+	defer resetTest()
+	cmdArgs := []string{"simulate", "missingpod"}
+	expected := "error: Pod(s) not found"
+	testCommon(t, cmdArgs, nil, expected, true)
+}
+
+func TestSimulate(t *testing.T) {
+	defer resetTest()
+	defer func() {
+		simulateScheduling = simulateSchedulingThroughProxy
+	}()
+	_, err := k8s.Instance().CreatePod(&v1.Pod{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      "simulatepod",
+			Namespace: "default",
+		},
+	})
+	require.NoError(t, err, "Error creating pod")
+
+	setSimulationResult(t, &extender.SimulationResult{
+		FilteredNodes: []string{"node1", "node2"},
+		Scores: schedulerapi.HostPriorityList{
+			{Host: "node1", Score: 10},
+			{Host: "node2", Score: 100},
+		},
+	}, nil)
+	cmdArgs := []string{"simulate", "simulatepod"}
+	expected := "NODE    SCORE\n" +
+		"node2   100\n" +
+		"node1   10\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+
+	setSimulationResult(t, &extender.SimulationResult{
+		FilteredNodes: []string{},
+		FilterError:   "No node found with storage driver",
+		Scores:        schedulerapi.HostPriorityList{},
+	}, nil)
+	expected = "Pod can't be scheduled: No node found with storage driver\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+
+	setSimulationResult(t, nil, fmt.Errorf("extender not found"))
+	expected = "error: error simulating scheduling for pod simulatepod: extender not found"
+	testCommon(t, cmdArgs, nil, expected, true)
+}
+
+func TestSimulateSpecFile(t *testing.T) {
+	defer resetTest()
+	defer func() {
+		simulateScheduling = simulateSchedulingThroughProxy
+	}()
+	specFile, err := ioutil.TempFile("", "simulate")
+	require.NoError(t, err, "Error creating spec file")
+	defer func() {
+		require.NoError(t, os.Remove(specFile.Name()), "Error removing spec file")
+	}()
+	_, err = specFile.WriteString("apiVersion: v1\nkind: Pod\nmetadata:\n  name: specpod\n")
+	require.NoError(t, err, "Error writing spec file")
+	require.NoError(t, specFile.Close(), "Error closing spec file")
+
+	simulateScheduling = func(_ *rest.Config, _ string, _ string, pod *v1.Pod) (*extender.SimulationResult, error) {
+		require.Equal(t, "specpod", pod.Name)
+		require.Equal(t, "default", pod.Namespace)
+		return &extender.SimulationResult{
+			FilteredNodes: []string{"node1"},
+			Scores:        schedulerapi.HostPriorityList{{Host: "node1", Score: 5}},
+		}, nil
+	}
+	cmdArgs := []string{"simulate", "-f", specFile.Name(), "-o", "json"}
+	expected := `{
+    "filteredNodes": [
+        "node1"
+    ],
+    "scores": [
+        {
+            "Host": "node1",
+            "Score": 5
+        }
+    ]
+}
+`
+	testCommon(t, cmdArgs, nil, expected, false)
+
+	cmdArgs = []string{"simulate", "-f", specFile.Name(), "specpod"}
+	expected = "error: pod name can't be specified with a spec file"
+	testCommon(t, cmdArgs, nil, expected, true)
+}
@@ -17,10 +17,12 @@ import (
 )
 
 var snapshotColumns = []string{"NAME", "PVC", "STATUS", "CREATED", "COMPLETED", "TYPE"}
+var snapshotWideColumns = []string{"SNAPSHOT-DATA"}
 var snapSubcommand = "volumesnapshots"
 var snapAliases = []string{"volumesnapshot", "snapshots", "snapshot", "snap"}
 
 func newCreateSnapshotCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	dryRun := &dryRunOptions{}
 	var snapName string
 	var pvcName string
 	createSnapshotCommand := &cobra.Command{
@@ -49,6 +51,12 @@ func newCreateSnapshotCommand(cmdFactory Factory, ioStreams genericclioptions.IO
 					PersistentVolumeClaimName: pvcName,
 				},
 			}
+			if dryRun.enabled() {
+				if err := dryRun.printObject(c, cmdFactory, snapshot, snapshot.Metadata.Name, ioStreams.Out); err != nil {
+					util.CheckErr(err)
+				}
+				return
+			}
 			_, err := k8s.Instance().CreateSnapshot(snapshot)
 			if err != nil {
 				util.CheckErr(err)
@@ -59,6 +67,7 @@ func newCreateSnapshotCommand(cmdFactory Factory, ioStreams genericclioptions.IO
 		},
 	}
 	createSnapshotCommand.Flags().StringVarP(&pvcName, "pvc", "p", "", "Name of the PVC which should be used to create a snapshot")
+	dryRun.addFlags(createSnapshotCommand)
 
 	return createSnapshotCommand
 }
@@ -115,12 +124,7 @@ func newGetSnapshotCommand(cmdFactory Factory, ioStreams genericclioptions.IOStr
 				snapshots = &tempSnapshots
 			}
 
-			if len(snapshots.Items) == 0 {
-				handleEmptyList(ioStreams.Out)
-				return
-			}
-
-			if err := printObjects(c, snapshots, cmdFactory, snapshotColumns, snapshotPrinter, ioStreams.Out); err != nil {
+			if err := printObjects(c, snapshots, cmdFactory, snapshotColumns, snapshotWideColumns, snapshotPrinter, ioStreams.Out); err != nil {
 				util.CheckErr(err)
 				return
 			}
@@ -202,7 +206,15 @@ func snapshotPrinter(snapList *snapv1.VolumeSnapshotList, writer io.Writer, opti
 		status, completedTime := getSnapshotStatusAndTime(&snap)
 		snapType := volume.GetSnapshotType(&snap)
 		creationTime := toTimeString(snap.Metadata.CreationTimestamp.Time)
-		if _, err := fmt.Fprintf(writer, "%v\t%v\t%v\t%v\t%v\t%v\n", name, snap.Spec.PersistentVolumeClaimName, status, creationTime, completedTime, snapType); err != nil {
+		if _, err := fmt.Fprintf(writer, "%v\t%v\t%v\t%v\t%v\t%v", name, snap.Spec.PersistentVolumeClaimName, status, creationTime, completedTime, snapType); err != nil {
+			return err
+		}
+		if options.Wide {
+			if _, err := fmt.Fprintf(writer, "\t%v", snap.Spec.SnapshotDataName); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintln(writer); err != nil {
 			return err
 		}
 	}
@@ -19,6 +19,7 @@ var snapshotScheduleSubcommand = "volumesnapshotschedules"
 var snapshotScheduleAliases = []string{"volumesnapshotschedule", "snapshotschedule", "snapshotschedules"}
 
 func newCreateSnapshotScheduleCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	dryRun := &dryRunOptions{}
 	var snapshotScheduleName string
 	var preExecRule string
 	var postExecRule string
@@ -26,6 +27,8 @@ func newCreateSnapshotScheduleCommand(cmdFactory Factory, ioStreams genericcliop
 	var reclaimPolicy string
 	var suspend bool
 	var pvc string
+	var snapshotType string
+	var snapshotClassName string
 
 	createSnapshotScheduleCommand := &cobra.Command{
 		Use:     snapshotScheduleSubcommand,
@@ -41,6 +44,12 @@ func newCreateSnapshotScheduleCommand(cmdFactory Factory, ioStreams genericcliop
 				util.CheckErr(fmt.Errorf("need to provide schedulePolicyName"))
 				return
 			}
+			switch storkv1.VolumeSnapshotType(snapshotType) {
+			case storkv1.VolumeSnapshotTypeStork, storkv1.VolumeSnapshotTypeCSI:
+			default:
+				util.CheckErr(fmt.Errorf("invalid snapshotType %v, should be one of stork|csi", snapshotType))
+				return
+			}
 
 			snapshotSchedule := &storkv1.VolumeSnapshotSchedule{
 				Spec: storkv1.VolumeSnapshotScheduleSpec{
@@ -54,10 +63,18 @@ func newCreateSnapshotScheduleCommand(cmdFactory Factory, ioStreams genericcliop
 					SchedulePolicyName: schedulePolicyName,
 					Suspend:            &suspend,
 					ReclaimPolicy:      storkv1.ReclaimPolicyType(reclaimPolicy),
+					SnapshotType:       storkv1.VolumeSnapshotType(snapshotType),
 				},
 			}
+			snapshotSchedule.Spec.VolumeSnapshotClassName = snapshotClassName
 			snapshotSchedule.Name = snapshotScheduleName
 			snapshotSchedule.Namespace = cmdFactory.GetNamespace()
+			if dryRun.enabled() {
+				if err := dryRun.printObject(c, cmdFactory, snapshotSchedule, snapshotSchedule.Name, ioStreams.Out); err != nil {
+					util.CheckErr(err)
+				}
+				return
+			}
 			_, err := k8s.Instance().CreateSnapshotSchedule(snapshotSchedule)
 			if err != nil {
 				util.CheckErr(err)
@@ -73,6 +90,9 @@ func newCreateSnapshotScheduleCommand(cmdFactory Factory, ioStreams genericcliop
 	createSnapshotScheduleCommand.Flags().StringVarP(&schedulePolicyName, "schedulePolicyName", "s", "", "Name of the schedule policy to use")
 	createSnapshotScheduleCommand.Flags().StringVarP(&reclaimPolicy, "reclaimPolicy", "", "Retain", "Reclaim policy for the created snapshots (Retain or Delete)")
 	createSnapshotScheduleCommand.Flags().BoolVar(&suspend, "suspend", false, "Flag to denote whether schedule should be suspended on creation")
+	createSnapshotScheduleCommand.Flags().StringVarP(&snapshotType, "snapshotType", "", string(storkv1.VolumeSnapshotTypeStork), "Type of snapshots to create (stork or csi)")
+	createSnapshotScheduleCommand.Flags().StringVarP(&snapshotClassName, "volumeSnapshotClass", "", "", "Name of the VolumeSnapshotClass to use for csi snapshots")
+	dryRun.addFlags(createSnapshotScheduleCommand)
 
 	return createSnapshotScheduleCommand
 }
@@ -129,12 +149,7 @@ func newGetSnapshotScheduleCommand(cmdFactory Factory, ioStreams genericclioptio
 				snapshotSchedules = &tempVolumeSnapshotSchedules
 			}
 
-			if len(snapshotSchedules.Items) == 0 {
-				handleEmptyList(ioStreams.Out)
-				return
-			}
-
-			if err := printObjects(c, snapshotSchedules, cmdFactory, snapshotScheduleColumns, snapshotSchedulePrinter, ioStreams.Out); err != nil {
+			if err := printObjects(c, snapshotSchedules, cmdFactory, snapshotScheduleColumns, nil, snapshotSchedulePrinter, ioStreams.Out); err != nil {
 				util.CheckErr(err)
 				return
 			}
@@ -202,6 +202,26 @@ func TestCreateDuplicateSnapshotSchedules(t *testing.T) {
 	testCommon(t, cmdArgs, nil, expected, true)
 }
 
+func TestCreateCSISnapshotSchedules(t *testing.T) {
+	defer resetTest()
+	cmdArgs := []string{"create", "snapshotschedules", "createcsisnapshotschedule", "-p", "pvcname1", "-s", "testpolicy", "-n", "test", "--snapshotType", "csi", "--volumeSnapshotClass", "csi-class"}
+
+	expected := "VolumeSnapshotSchedule createcsisnapshotschedule created successfully\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+
+	snapshotSchedule, err := k8s.Instance().GetSnapshotSchedule("createcsisnapshotschedule", "test")
+	require.NoError(t, err, "Error getting snapshot schedule")
+	require.Equal(t, storkv1.VolumeSnapshotTypeCSI, snapshotSchedule.Spec.SnapshotType, "SnapshotSchedule snapshotType mismatch")
+	require.Equal(t, "csi-class", snapshotSchedule.Spec.VolumeSnapshotClassName, "SnapshotSchedule volumeSnapshotClassName mismatch")
+}
+
+func TestCreateSnapshotSchedulesInvalidSnapshotType(t *testing.T) {
+	cmdArgs := []string{"create", "snapshotschedules", "createsnapshotschedule", "-p", "pvcname1", "-s", "testpolicy", "-n", "test", "--snapshotType", "invalid"}
+
+	expected := "error: invalid snapshotType invalid, should be one of stork|csi"
+	testCommon(t, cmdArgs, nil, expected, true)
+}
+
 func TestDeleteSnapshotSchedulesNoSnapshotName(t *testing.T) {
 	cmdArgs := []string{"delete", "snapshotschedules"}
 
@@ -33,7 +33,18 @@ func NewCommand(cmdFactory Factory, in io.Reader, out io.Writer, errOut io.Write
 		newGetCommand(cmdFactory, ioStreams),
 		newActivateCommand(cmdFactory, ioStreams),
 		newDeactivateCommand(cmdFactory, ioStreams),
+		newSuspendCommand(cmdFactory, ioStreams),
+		newResumeCommand(cmdFactory, ioStreams),
+		newPerformCommand(cmdFactory, ioStreams),
+		newWaitCommand(cmdFactory, ioStreams),
+		newDiffCommand(cmdFactory, ioStreams),
+		newCheckCommand(cmdFactory, ioStreams),
+		newDescribeCommand(cmdFactory, ioStreams),
+		newDebugCommand(cmdFactory, ioStreams),
+		newCompletionCommand(cmdFactory, ioStreams),
+		newPluginCommand(cmdFactory, ioStreams),
 		newGenerateCommand(cmdFactory, ioStreams),
+		newSimulateCommand(cmdFactory, ioStreams),
 		newVersionCommand(cmdFactory, ioStreams),
 	)
 
@@ -0,0 +1,244 @@
+package storkctl
+
+import (
+	"fmt"
+
+	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/portworx/sched-ops/k8s"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/kubernetes/pkg/kubectl/cmd/util"
+	"k8s.io/kubernetes/pkg/kubectl/genericclioptions"
+)
+
+// suspendableSchedule is a schedule that can be suspended and resumed
+type suspendableSchedule struct {
+	name      string
+	namespace string
+	labels    map[string]string
+	suspended bool
+	// update saves the schedule with the suspend flag set
+	update func(suspend bool) error
+}
+
+// scheduleType has the functions to get the schedules of a type
+type scheduleType struct {
+	kind    string
+	command string
+	aliases []string
+	get     func(name string, namespace string) (*suspendableSchedule, error)
+	list    func(namespace string) ([]*suspendableSchedule, error)
+}
+
+func getScheduleTypes() []scheduleType {
+	return []scheduleType{
+		{
+			kind:    "MigrationSchedule",
+			command: migrationScheduleSubcommand,
+			aliases: migrationScheduleAliases,
+			get: func(name string, namespace string) (*suspendableSchedule, error) {
+				migrationSchedule, err := k8s.Instance().GetMigrationSchedule(name, namespace)
+				if err != nil {
+					return nil, err
+				}
+				return newSuspendableMigrationSchedule(migrationSchedule), nil
+			},
+			list: func(namespace string) ([]*suspendableSchedule, error) {
+				migrationSchedules, err := k8s.Instance().ListMigrationSchedules(namespace)
+				if err != nil {
+					return nil, err
+				}
+				schedules := make([]*suspendableSchedule, 0)
+				for i := range migrationSchedules.Items {
+					schedules = append(schedules, newSuspendableMigrationSchedule(&migrationSchedules.Items[i]))
+				}
+				return schedules, nil
+			},
+		},
+		{
+			kind:    "VolumeSnapshotSchedule",
+			command: snapshotScheduleSubcommand,
+			aliases: snapshotScheduleAliases,
+			get: func(name string, namespace string) (*suspendableSchedule, error) {
+				snapshotSchedule, err := k8s.Instance().GetSnapshotSchedule(name, namespace)
+				if err != nil {
+					return nil, err
+				}
+				return newSuspendableSnapshotSchedule(snapshotSchedule), nil
+			},
+			list: func(namespace string) ([]*suspendableSchedule, error) {
+				snapshotSchedules, err := k8s.Instance().ListSnapshotSchedules(namespace)
+				if err != nil {
+					return nil, err
+				}
+				schedules := make([]*suspendableSchedule, 0)
+				for i := range snapshotSchedules.Items {
+					schedules = append(schedules, newSuspendableSnapshotSchedule(&snapshotSchedules.Items[i]))
+				}
+				return schedules, nil
+			},
+		},
+	}
+}
+
+func newSuspendableMigrationSchedule(migrationSchedule *storkv1.MigrationSchedule) *suspendableSchedule {
+	return &suspendableSchedule{
+		name:      migrationSchedule.Name,
+		namespace: migrationSchedule.Namespace,
+		labels:    migrationSchedule.Labels,
+		suspended: migrationSchedule.Spec.Suspend != nil && *migrationSchedule.Spec.Suspend,
+		update: func(suspend bool) error {
+			migrationSchedule.Spec.Suspend = &suspend
+			_, err := k8s.Instance().UpdateMigrationSchedule(migrationSchedule)
+			return err
+		},
+	}
+}
+
+func newSuspendableSnapshotSchedule(snapshotSchedule *storkv1.VolumeSnapshotSchedule) *suspendableSchedule {
+	return &suspendableSchedule{
+		name:      snapshotSchedule.Name,
+		namespace: snapshotSchedule.Namespace,
+		labels:    snapshotSchedule.Labels,
+		suspended: snapshotSchedule.Spec.Suspend != nil && *snapshotSchedule.Spec.Suspend,
+		update: func(suspend bool) error {
+			snapshotSchedule.Spec.Suspend = &suspend
+			_, err := k8s.Instance().UpdateSnapshotSchedule(snapshotSchedule)
+			return err
+		},
+	}
+}
+
+func newSuspendCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	suspendCommands := &cobra.Command{
+		Use:   "suspend",
+		Short: "Suspend schedules",
+	}
+
+	for _, scheduleType := range getScheduleTypes() {
+		suspendCommands.AddCommand(newSuspendScheduleCommand(cmdFactory, scheduleType, true, ioStreams))
+	}
+
+	return suspendCommands
+}
+
+func newResumeCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	resumeCommands := &cobra.Command{
+		Use:   "resume",
+		Short: "Resume suspended schedules",
+	}
+
+	for _, scheduleType := range getScheduleTypes() {
+		resumeCommands.AddCommand(newSuspendScheduleCommand(cmdFactory, scheduleType, false, ioStreams))
+	}
+
+	return resumeCommands
+}
+
+func newSuspendScheduleCommand(
+	cmdFactory Factory,
+	scheduleType scheduleType,
+	suspend bool,
+	ioStreams genericclioptions.IOStreams,
+) *cobra.Command {
+	var all bool
+	var selector string
+	action := "Resume"
+	if suspend {
+		action = "Suspend"
+	}
+	suspendScheduleCommand := &cobra.Command{
+		Use:     scheduleType.command,
+		Aliases: scheduleType.aliases,
+		Short:   fmt.Sprintf("%v %vs by name, by label selector or all of them", action, scheduleType.kind),
+		Run: func(c *cobra.Command, args []string) {
+			if len(args) == 0 && selector == "" && !all {
+				util.CheckErr(fmt.Errorf("schedule names, a selector or --all needs to be provided"))
+				return
+			}
+			if len(args) != 0 && (selector != "" || all) {
+				util.CheckErr(fmt.Errorf("schedule names can't be provided with a selector or --all"))
+				return
+			}
+			labelSelector, err := labels.Parse(selector)
+			if err != nil {
+				util.CheckErr(err)
+				return
+			}
+			namespaces, err := cmdFactory.GetAllNamespaces()
+			if err != nil {
+				util.CheckErr(err)
+				return
+			}
+
+			schedules := make([]*suspendableSchedule, 0)
+			for _, ns := range namespaces {
+				if len(args) != 0 {
+					for _, name := range args {
+						schedule, err := scheduleType.get(name, ns)
+						if err != nil {
+							util.CheckErr(err)
+							return
+						}
+						schedules = append(schedules, schedule)
+					}
+					continue
+				}
+				nsSchedules, err := scheduleType.list(ns)
+				if err != nil {
+					util.CheckErr(err)
+					return
+				}
+				for _, schedule := range nsSchedules {
+					if labelSelector.Matches(labels.Set(schedule.labels)) {
+						schedules = append(schedules, schedule)
+					}
+				}
+			}
+			if len(schedules) == 0 {
+				handleEmptyList(ioStreams.Out)
+				return
+			}
+
+			if err := setSchedulesSuspend(scheduleType.kind, schedules, suspend, ioStreams); err != nil {
+				util.CheckErr(err)
+				return
+			}
+		},
+	}
+	suspendScheduleCommand.Flags().BoolVar(&all, "all", false, fmt.Sprintf("%v all %vs in the namespace", action, scheduleType.kind))
+	suspendScheduleCommand.Flags().StringVarP(&selector, "selector", "l", "", "Label selector for the schedules, e.g. app=mysql")
+	cmdFactory.BindGetFlags(suspendScheduleCommand.Flags())
+
+	return suspendScheduleCommand
+}
+
+// setSchedulesSuspend updates the schedules that aren't in the requested
+// state and prints a summary of the changes
+func setSchedulesSuspend(kind string, schedules []*suspendableSchedule, suspend bool, ioStreams genericclioptions.IOStreams) error {
+	state := "resumed"
+	unchangedState := "active"
+	if suspend {
+		state = "suspended"
+		unchangedState = "suspended"
+	}
+	updated := 0
+	failed := 0
+	for _, schedule := range schedules {
+		if schedule.suspended == suspend {
+			continue
+		}
+		if err := schedule.update(suspend); err != nil {
+			printMsg(fmt.Sprintf("Error updating %v %v/%v: %v", kind, schedule.namespace, schedule.name, err), ioStreams.ErrOut)
+			failed++
+			continue
+		}
+		printMsg(fmt.Sprintf("%v %v/%v %v", kind, schedule.namespace, schedule.name, state), ioStreams.Out)
+		updated++
+	}
+	printMsg(fmt.Sprintf("%v %v(s) %v, %v already %v", updated, kind, state, len(schedules)-updated-failed, unchangedState), ioStreams.Out)
+	if failed != 0 {
+		return fmt.Errorf("failed to update %v %v(s)", failed, kind)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+// +build unittest
+
+package storkctl
+
+import (
+	"testing"
+
+	"github.com/portworx/sched-ops/k8s"
+	"github.com/stretchr/testify/require"
+)
+
+func setMigrationScheduleLabels(t *testing.T, name string, namespace string, labels map[string]string) {
+	migrationSchedule, err := k8s.Instance().GetMigrationSchedule(name, namespace)
+	require.NoError(t, err, "Error getting migration schedule")
+	migrationSchedule.Labels = labels
+	_, err = k8s.Instance().UpdateMigrationSchedule(migrationSchedule)
+	require.NoError(t, err, "Error updating migration schedule")
+}
+
+func requireMigrationScheduleSuspended(t *testing.T, name string, namespace string, suspended bool) {
+	migrationSchedule, err := k8s.Instance().GetMigrationSchedule(name, namespace)
+	require.NoError(t, err, "Error getting migration schedule")
+	require.Equal(t, suspended, *migrationSchedule.Spec.Suspend, "Unexpected suspend for %v", name)
+}
+
+func TestSuspendResumeMigrationSchedules(t *testing.T) {
+	defer resetTest()
+	createMigrationScheduleAndVerify(t, "schedule1", "policy", "test", "pair", []string{"ns1"}, "", "", false)
+	createMigrationScheduleAndVerify(t, "schedule2", "policy", "test", "pair", []string{"ns1"}, "", "", false)
+	createMigrationScheduleAndVerify(t, "schedule3", "policy", "test", "pair", []string{"ns1"}, "", "", true)
+	setMigrationScheduleLabels(t, "schedule1", "test", map[string]string{"app": "mysql"})
+	setMigrationScheduleLabels(t, "schedule3", "test", map[string]string{"app": "mysql"})
+
+	cmdArgs := []string{"suspend", "migrationschedules", "-n", "test"}
+	expected := "error: schedule names, a selector or --all needs to be provided"
+	testCommon(t, cmdArgs, nil, expected, true)
+
+	cmdArgs = []string{"suspend", "migrationschedules", "-n", "test", "schedule1", "--all"}
+	expected = "error: schedule names can't be provided with a selector or --all"
+	testCommon(t, cmdArgs, nil, expected, true)
+
+	cmdArgs = []string{"suspend", "migrationschedules", "-n", "test", "-l", "app=postgres"}
+	expected = "No resources found.\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+
+	cmdArgs = []string{"suspend", "migrationschedules", "-n", "test", "-l", "app=mysql"}
+	expected = "MigrationSchedule test/schedule1 suspended\n" +
+		"1 MigrationSchedule(s) suspended, 1 already suspended\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+	requireMigrationScheduleSuspended(t, "schedule1", "test", true)
+	requireMigrationScheduleSuspended(t, "schedule2", "test", false)
+
+	cmdArgs = []string{"resume", "migrationschedules", "-n", "test", "--all"}
+	expected = "MigrationSchedule test/schedule1 resumed\n" +
+		"MigrationSchedule test/schedule3 resumed\n" +
+		"2 MigrationSchedule(s) resumed, 1 already active\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+	requireMigrationScheduleSuspended(t, "schedule1", "test", false)
+	requireMigrationScheduleSuspended(t, "schedule3", "test", false)
+
+	cmdArgs = []string{"suspend", "migrationschedules", "-n", "test", "schedule2"}
+	expected = "MigrationSchedule test/schedule2 suspended\n" +
+		"1 MigrationSchedule(s) suspended, 0 already suspended\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+	requireMigrationScheduleSuspended(t, "schedule2", "test", true)
+}
+
+func TestSuspendResumeSnapshotSchedules(t *testing.T) {
+	defer resetTest()
+	createSnapshotScheduleAndVerify(t, "schedule1", "pvc1", "policy", "test", "", "", false)
+	createSnapshotScheduleAndVerify(t, "schedule2", "pvc1", "policy", "default", "", "", false)
+	for _, name := range []string{"default", "test"} {
+		_, err := k8s.Instance().CreateNamespace(name, nil)
+		require.NoError(t, err, "Error creating namespace")
+	}
+
+	cmdArgs := []string{"suspend", "volumesnapshotschedules", "--all-namespaces", "--all"}
+	expected := "VolumeSnapshotSchedule default/schedule2 suspended\n" +
+		"VolumeSnapshotSchedule test/schedule1 suspended\n" +
+		"2 VolumeSnapshotSchedule(s) suspended, 0 already suspended\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+
+	cmdArgs = []string{"resume", "snapshotschedule", "-n", "test", "schedule1"}
+	expected = "VolumeSnapshotSchedule test/schedule1 resumed\n" +
+		"1 VolumeSnapshotSchedule(s) resumed, 0 already active\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+	snapshotSchedule, err := k8s.Instance().GetSnapshotSchedule("schedule1", "test")
+	require.NoError(t, err, "Error getting snapshot schedule")
+	require.False(t, *snapshotSchedule.Spec.Suspend)
+}
@@ -0,0 +1,171 @@
+package storkctl
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ghodss/yaml"
+	"github.com/libopenstorage/stork/pkg/extender"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/kubernetes/pkg/kubectl/cmd/util"
+	"k8s.io/kubernetes/pkg/kubectl/genericclioptions"
+)
+
+const (
+	volumesSubcommand = "volumes"
+	volumesEndpoint   = "volumes"
+)
+
+var volumesAliases = []string{"volume"}
+
+// getVolumePlacement gets the placement of the volumes in the namespace from
+// the extender. Replaced in tests since it can't be served by the fake
+// clients
+var getVolumePlacement = getVolumePlacementThroughProxy
+
+func newGetVolumesCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	var storkNamespace string
+	var storkService string
+	getVolumesCommand := &cobra.Command{
+		Use:     volumesSubcommand,
+		Aliases: volumesAliases,
+		Short:   "Get the nodes with the data for the PVCs and the nodes where they are used",
+		Run: func(c *cobra.Command, args []string) {
+			config, err := cmdFactory.GetConfig()
+			if err != nil {
+				util.CheckErr(err)
+				return
+			}
+			namespace := cmdFactory.GetNamespace()
+			// The extender returns the volumes from all namespaces if one
+			// isn't specified
+			if cmdFactory.AllNamespaces() {
+				namespace = ""
+			}
+			placements, err := getVolumePlacement(config, storkNamespace, storkService, namespace)
+			if err != nil {
+				util.CheckErr(fmt.Errorf("error getting volumes from stork: %v", err))
+				return
+			}
+			if len(placements) == 0 {
+				handleEmptyList(ioStreams.Out)
+				return
+			}
+			outputFormat, err := cmdFactory.GetOutputFormat()
+			if err != nil {
+				util.CheckErr(err)
+				return
+			}
+			if err := printVolumePlacements(placements, outputFormat, cmdFactory.AllNamespaces(), ioStreams.Out); err != nil {
+				util.CheckErr(err)
+				return
+			}
+		},
+	}
+	getVolumesCommand.Flags().StringVar(&storkNamespace, "stork-namespace", defaultStorkNamespace, "Namespace where stork is running")
+	getVolumesCommand.Flags().StringVar(&storkService, "stork-service", defaultStorkService, "Name of the service for stork")
+	cmdFactory.BindGetFlags(getVolumesCommand.Flags())
+
+	return getVolumesCommand
+}
+
+// getVolumePlacementThroughProxy sends the volumes request to the extender
+// through the API server proxy for the stork service
+func getVolumePlacementThroughProxy(
+	config *rest.Config,
+	storkNamespace string,
+	storkService string,
+	namespace string,
+) ([]*extender.VolumePlacement, error) {
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	response, err := client.CoreV1().RESTClient().Get().
+		Namespace(storkNamespace).
+		Resource("services").
+		Name(storkService+":"+extenderPort).
+		SubResource("proxy").
+		Suffix(volumesEndpoint).
+		Param("namespace", namespace).
+		DoRaw()
+	if err != nil {
+		return nil, err
+	}
+	placements := make([]*extender.VolumePlacement, 0)
+	if err := json.Unmarshal(response, &placements); err != nil {
+		return nil, err
+	}
+	return placements, nil
+}
+
+func printVolumePlacements(
+	placements []*extender.VolumePlacement,
+	outputFormat string,
+	allNamespaces bool,
+	out io.Writer,
+) error {
+	switch outputFormat {
+	case outputFormatJSON:
+		encoded, err := json.MarshalIndent(placements, "", "    ")
+		if err != nil {
+			return err
+		}
+		printMsg(string(encoded), out)
+		return nil
+	case outputFormatYaml:
+		encoded, err := yaml.Marshal(placements)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprint(out, string(encoded))
+		return err
+	}
+
+	writer := tabwriter.NewWriter(out, 0, 8, 3, ' ', 0)
+	header := "NAME\tDRIVER\tREPLICA-NODES\tATTACHED-NODE\tPODS\tHYPERCONVERGED"
+	if allNamespaces {
+		header = "NAMESPACE\t" + header
+	}
+	if _, err := fmt.Fprintln(writer, header); err != nil {
+		return err
+	}
+	for _, placement := range placements {
+		pods := make([]string, 0)
+		hyperconverged := 0
+		for _, pod := range placement.Pods {
+			node := pod.Node
+			if node == "" {
+				node = "unscheduled"
+			}
+			pods = append(pods, fmt.Sprintf("%v(%v)", pod.Name, node))
+			if pod.Hyperconverged {
+				hyperconverged++
+			}
+		}
+		hyperconvergedPods := ""
+		if len(placement.Pods) > 0 {
+			hyperconvergedPods = fmt.Sprintf("%v/%v", hyperconverged, len(placement.Pods))
+		}
+		if allNamespaces {
+			if _, err := fmt.Fprintf(writer, "%v\t", placement.Namespace); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(writer, "%v\t%v\t%v\t%v\t%v\t%v\n",
+			placement.PVC,
+			placement.Driver,
+			strings.Join(placement.ReplicaNodes, ","),
+			placement.AttachedNode,
+			strings.Join(pods, ","),
+			hyperconvergedPods); err != nil {
+			return err
+		}
+	}
+	return writer.Flush()
+}
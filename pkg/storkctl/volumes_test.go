@@ -0,0 +1,70 @@
+// +build unittest
+
+package storkctl
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/libopenstorage/stork/pkg/extender"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/rest"
+)
+
+func setVolumePlacement(t *testing.T, expectedNamespace string, placements []*extender.VolumePlacement, err error) {
+	getVolumePlacement = func(_ *rest.Config, storkNamespace string, storkService string, namespace string) ([]*extender.VolumePlacement, error) {
+		require.Equal(t, defaultStorkNamespace, storkNamespace)
+		require.Equal(t, defaultStorkService, storkService)
+		require.Equal(t, expectedNamespace, namespace)
+		return placements, err
+	}
+}
+
+func TestGetVolumes(t *testing.T) {
+	defer resetTest()
+	defer func() {
+		getVolumePlacement = getVolumePlacementThroughProxy
+	}()
+	placements := []*extender.VolumePlacement{
+		{
+			PVC:          "mysql-data",
+			Namespace:    "test",
+			Driver:       "pxd",
+			VolumeID:     "123",
+			ReplicaNodes: []string{"node1", "node2"},
+			AttachedNode: "node1",
+			Pods: []extender.PodPlacement{
+				{Name: "mysql-0", Node: "node1", Hyperconverged: true},
+				{Name: "mysql-1", Node: "node3"},
+			},
+		},
+		{
+			PVC:          "pending",
+			Namespace:    "test",
+			ReplicaNodes: []string{},
+			Pods:         []extender.PodPlacement{{Name: "web"}},
+		},
+	}
+
+	setVolumePlacement(t, "test", nil, nil)
+	cmdArgs := []string{"get", "volumes", "-n", "test"}
+	expected := "No resources found.\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+
+	setVolumePlacement(t, "test", placements, nil)
+	expected = "NAME         DRIVER   REPLICA-NODES   ATTACHED-NODE   PODS                            HYPERCONVERGED\n" +
+		"mysql-data   pxd      node1,node2     node1           mysql-0(node1),mysql-1(node3)   1/2\n" +
+		"pending                                               web(unscheduled)                0/1\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+
+	setVolumePlacement(t, "", placements[:1], nil)
+	cmdArgs = []string{"get", "volume", "--all-namespaces"}
+	expected = "NAMESPACE   NAME         DRIVER   REPLICA-NODES   ATTACHED-NODE   PODS                            HYPERCONVERGED\n" +
+		"test        mysql-data   pxd      node1,node2     node1           mysql-0(node1),mysql-1(node3)   1/2\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+
+	setVolumePlacement(t, "test", nil, fmt.Errorf("service unavailable"))
+	cmdArgs = []string{"get", "volumes", "-n", "test"}
+	expected = "error: error getting volumes from stork: service unavailable"
+	testCommon(t, cmdArgs, nil, expected, true)
+}
@@ -0,0 +1,265 @@
+package storkctl
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	storkclientset "github.com/libopenstorage/stork/pkg/client/clientset/versioned"
+	"github.com/portworx/sched-ops/k8s"
+	"github.com/spf13/cobra"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubernetes/pkg/kubectl/cmd/util"
+	"k8s.io/kubernetes/pkg/kubectl/genericclioptions"
+	utilexec "k8s.io/utils/exec"
+)
+
+const (
+	// waitExitCodeFailed is the exit code when the operation completes
+	// without reaching the expected status
+	waitExitCodeFailed = 2
+	// waitExitCodeTimeout is the exit code when the operation doesn't
+	// complete before the timeout
+	waitExitCodeTimeout = 3
+
+	volumeSnapshotRestoreSubcommand = "volumesnapshotrestores"
+
+	defaultWaitStatus  = "Successful"
+	defaultWaitTimeout = 30 * time.Minute
+)
+
+// waitPollInterval is the interval at which the status of the resources is
+// checked while waiting for or watching them
+var waitPollInterval = 10 * time.Second
+
+// getStorkClient returns the client for stork resources that can't be
+// fetched through the k8s ops. Replaced in tests to use the fake client.
+var getStorkClient = newStorkClient
+
+func newStorkClient(cmdFactory Factory) (storkclientset.Interface, error) {
+	config, err := cmdFactory.GetConfig()
+	if err != nil {
+		return nil, err
+	}
+	return storkclientset.NewForConfig(config)
+}
+
+// operationStatus is the status of an asynchronous operation
+type operationStatus struct {
+	stage  string
+	status string
+	// final is set once the operation has completed
+	final bool
+	// summary of the errors for the operation
+	summary string
+}
+
+type waitOptions struct {
+	forStatus string
+	timeout   time.Duration
+}
+
+func (o *waitOptions) addFlags(c *cobra.Command) {
+	c.Flags().StringVar(&o.forStatus, "for", defaultWaitStatus, "Status to wait for")
+	c.Flags().DurationVar(&o.timeout, "timeout", defaultWaitTimeout, "Time to wait before giving up")
+}
+
+func newWaitCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	waitCommands := &cobra.Command{
+		Use:   "wait",
+		Short: "Wait for operations on stork resources to complete",
+		Long: "Wait for operations on stork resources to reach a status. Exits with code 0 once the status is " +
+			"reached, 2 if the operation completed with a different status and 3 if it timed out.",
+	}
+
+	waitCommands.AddCommand(
+		newWaitMigrationCommand(cmdFactory, ioStreams),
+		newWaitGroupVolumeSnapshotCommand(cmdFactory, ioStreams),
+		newWaitVolumeSnapshotRestoreCommand(cmdFactory, ioStreams),
+	)
+
+	return waitCommands
+}
+
+func newWaitMigrationCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	options := &waitOptions{}
+	waitMigrationCommand := &cobra.Command{
+		Use:     migrationSubcommand,
+		Aliases: migrationAliases,
+		Short:   "Wait for a migration",
+		Run: func(c *cobra.Command, args []string) {
+			if len(args) != 1 {
+				util.CheckErr(fmt.Errorf("exactly one name needs to be provided for migration name"))
+				return
+			}
+			namespace := cmdFactory.GetNamespace()
+			err := waitForOperation("Migration", args[0], namespace, options, func() (*operationStatus, error) {
+				return getMigrationStatus(k8s.Instance(), args[0], namespace)
+			}, ioStreams)
+			if err != nil {
+				util.CheckErr(err)
+				return
+			}
+		},
+	}
+	options.addFlags(waitMigrationCommand)
+
+	return waitMigrationCommand
+}
+
+func newWaitGroupVolumeSnapshotCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	options := &waitOptions{}
+	waitGroupVolumeSnapshotCommand := &cobra.Command{
+		Use:     groupSnapshotSubcommand,
+		Aliases: groupSnapshotAliases,
+		Short:   "Wait for a group volume snapshot",
+		Run: func(c *cobra.Command, args []string) {
+			if len(args) != 1 {
+				util.CheckErr(fmt.Errorf("exactly one name needs to be provided for group volume snapshot name"))
+				return
+			}
+			namespace := cmdFactory.GetNamespace()
+			err := waitForOperation("GroupVolumeSnapshot", args[0], namespace, options, func() (*operationStatus, error) {
+				groupSnapshot, err := k8s.Instance().GetGroupSnapshot(args[0], namespace)
+				if err != nil {
+					return nil, err
+				}
+				return &operationStatus{
+					stage:  string(groupSnapshot.Status.Stage),
+					status: string(groupSnapshot.Status.Status),
+					final: groupSnapshot.Status.Stage == storkv1.GroupSnapshotStageFinal ||
+						groupSnapshot.Status.Status == storkv1.GroupSnapshotFailed,
+				}, nil
+			}, ioStreams)
+			if err != nil {
+				util.CheckErr(err)
+				return
+			}
+		},
+	}
+	options.addFlags(waitGroupVolumeSnapshotCommand)
+
+	return waitGroupVolumeSnapshotCommand
+}
+
+func newWaitVolumeSnapshotRestoreCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	options := &waitOptions{}
+	waitVolumeSnapshotRestoreCommand := &cobra.Command{
+		Use:     volumeSnapshotRestoreSubcommand,
+		Aliases: []string{"volumesnapshotrestore", "restore", "restores"},
+		Short:   "Wait for a volume snapshot restore",
+		Run: func(c *cobra.Command, args []string) {
+			if len(args) != 1 {
+				util.CheckErr(fmt.Errorf("exactly one name needs to be provided for restore name"))
+				return
+			}
+			storkClient, err := getStorkClient(cmdFactory)
+			if err != nil {
+				util.CheckErr(err)
+				return
+			}
+			namespace := cmdFactory.GetNamespace()
+			err = waitForOperation("VolumeSnapshotRestore", args[0], namespace, options, func() (*operationStatus, error) {
+				restore, err := storkClient.StorkV1alpha1().VolumeSnapshotRestores(namespace).Get(args[0], meta.GetOptions{})
+				if err != nil {
+					return nil, err
+				}
+				failed := make([]string, 0)
+				if restore.Status.Reason != "" {
+					failed = append(failed, restore.Status.Reason)
+				}
+				for _, volume := range restore.Status.Volumes {
+					if volume.Status == storkv1.VolumeSnapshotRestoreStatusFailed {
+						failed = append(failed, fmt.Sprintf("volume %v/%v: %v", volume.Namespace, volume.PersistentVolumeClaim, volume.Reason))
+					}
+				}
+				return &operationStatus{
+					stage:   string(restore.Status.Stage),
+					status:  string(restore.Status.Status),
+					final:   restore.Status.Stage == storkv1.VolumeSnapshotRestoreStageFinal,
+					summary: strings.Join(failed, "; "),
+				}, nil
+			}, ioStreams)
+			if err != nil {
+				util.CheckErr(err)
+				return
+			}
+		},
+	}
+	options.addFlags(waitVolumeSnapshotRestoreCommand)
+
+	return waitVolumeSnapshotRestoreCommand
+}
+
+func getMigrationStatus(ops k8s.Ops, name string, namespace string) (*operationStatus, error) {
+	migration, err := ops.GetMigration(name, namespace)
+	if err != nil {
+		return nil, err
+	}
+	failed := make([]string, 0)
+	for _, volume := range migration.Status.Volumes {
+		if volume.Status == storkv1.MigrationStatusFailed {
+			failed = append(failed, fmt.Sprintf("volume %v/%v: %v", volume.Namespace, volume.PersistentVolumeClaim, volume.Reason))
+		}
+	}
+	for _, resource := range migration.Status.Resources {
+		if resource.Status == storkv1.MigrationStatusFailed {
+			failed = append(failed, fmt.Sprintf("%v %v/%v: %v", resource.Kind, resource.Namespace, resource.Name, resource.Reason))
+		}
+	}
+	return &operationStatus{
+		stage:   string(migration.Status.Stage),
+		status:  string(migration.Status.Status),
+		final:   migration.Status.Stage == storkv1.MigrationStageFinal,
+		summary: strings.Join(failed, "; "),
+	}, nil
+}
+
+// waitForOperation waits till the operation reaches the status in the
+// options. The error returned has the exit code to be used if the operation
+// completed with a different status or didn't complete before the timeout.
+func waitForOperation(
+	kind string,
+	name string,
+	namespace string,
+	options *waitOptions,
+	getStatus func() (*operationStatus, error),
+	ioStreams genericclioptions.IOStreams,
+) error {
+	deadline := time.Now().Add(options.timeout)
+	for {
+		status, err := getStatus()
+		if err != nil {
+			return err
+		}
+		if status.status == options.forStatus {
+			printMsg(fmt.Sprintf("%v %v is %v", kind, getObjectName(namespace, name), status.status), ioStreams.Out)
+			return nil
+		}
+		if status.final {
+			err := fmt.Errorf("%v %v completed with status %v", kind, getObjectName(namespace, name), status.status)
+			if status.summary != "" {
+				err = fmt.Errorf("%v: %v", err, status.summary)
+			}
+			return utilexec.CodeExitError{Err: err, Code: waitExitCodeFailed}
+		}
+		if time.Now().After(deadline) {
+			return utilexec.CodeExitError{
+				Err: fmt.Errorf("timed out waiting for %v %v, stage: %v, status: %v",
+					kind, getObjectName(namespace, name), status.stage, status.status),
+				Code: waitExitCodeTimeout,
+			}
+		}
+		time.Sleep(waitPollInterval)
+	}
+}
+
+// getObjectName returns the name of an object prefixed with its namespace if
+// it is namespaced
+func getObjectName(namespace string, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "/" + name
+}
@@ -0,0 +1,120 @@
+// +build unittest
+
+package storkctl
+
+import (
+	"testing"
+	"time"
+
+	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	storkclientset "github.com/libopenstorage/stork/pkg/client/clientset/versioned"
+	"github.com/portworx/sched-ops/k8s"
+	"github.com/stretchr/testify/require"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	cmdutil "k8s.io/kubernetes/pkg/kubectl/cmd/util"
+)
+
+func testWaitExitCode(t *testing.T, cmdArgs []string, expected string, expectedCode int) {
+	calledFatal := false
+	defer cmdutil.DefaultBehaviorOnFatal()
+	cmdutil.BehaviorOnFatal(func(e string, code int) {
+		if calledFatal {
+			return
+		}
+		calledFatal = true
+		require.Equal(t, expectedCode, code, "Unexpected error code")
+		require.Equal(t, expected, e)
+	})
+	runCommand(t, cmdArgs)
+	require.True(t, calledFatal)
+}
+
+func TestWaitMigration(t *testing.T) {
+	defer resetTest()
+	waitPollInterval = time.Millisecond
+	createMigrationAndVerify(t, "waitmigration", "test", "clusterpair1", []string{"namespace1"}, "", "")
+
+	cmdArgs := []string{"wait", "migrations", "-n", "test"}
+	expected := "error: exactly one name needs to be provided for migration name"
+	testCommon(t, cmdArgs, nil, expected, true)
+
+	cmdArgs = []string{"wait", "migrations", "missing", "-n", "test"}
+	expected = "Error from server (NotFound): migrations.stork.libopenstorage.org \"missing\" not found"
+	testCommon(t, cmdArgs, nil, expected, true)
+
+	cmdArgs = []string{"wait", "migrations", "waitmigration", "-n", "test", "--timeout", "5ms"}
+	expected = "timed out waiting for Migration test/waitmigration, stage: , status: "
+	testWaitExitCode(t, cmdArgs, expected, waitExitCodeTimeout)
+
+	migration, err := k8s.Instance().GetMigration("waitmigration", "test")
+	require.NoError(t, err, "Error getting migration")
+	migration.Status.Stage = storkv1.MigrationStageVolumes
+	migration.Status.Status = storkv1.MigrationStatusInProgress
+	migration, err = k8s.Instance().UpdateMigration(migration)
+	require.NoError(t, err, "Error updating migration")
+
+	cmdArgs = []string{"wait", "migrations", "waitmigration", "-n", "test", "--for", "InProgress"}
+	expected = "Migration test/waitmigration is InProgress\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+
+	migration.Status.Stage = storkv1.MigrationStageFinal
+	migration.Status.Status = storkv1.MigrationStatusFailed
+	migration.Status.Volumes = []*storkv1.VolumeInfo{
+		{
+			PersistentVolumeClaim: "pvc1",
+			Namespace:             "namespace1",
+			Status:                storkv1.MigrationStatusFailed,
+			Reason:                "volume error",
+		},
+	}
+	migration.Status.Resources = []*storkv1.ResourceInfo{
+		{
+			Name:             "deployment1",
+			Namespace:        "namespace1",
+			GroupVersionKind: meta.GroupVersionKind{Kind: "Deployment"},
+			Status:           storkv1.MigrationStatusSuccessful,
+		},
+	}
+	_, err = k8s.Instance().UpdateMigration(migration)
+	require.NoError(t, err, "Error updating migration")
+
+	cmdArgs = []string{"wait", "migrations", "waitmigration", "-n", "test"}
+	expected = "Migration test/waitmigration completed with status Failed: volume namespace1/pvc1: volume error"
+	testWaitExitCode(t, cmdArgs, expected, waitExitCodeFailed)
+}
+
+func TestWaitVolumeSnapshotRestore(t *testing.T) {
+	defer resetTest()
+	waitPollInterval = time.Millisecond
+	getStorkClient = func(_ Factory) (storkclientset.Interface, error) {
+		return fakeStorkClient, nil
+	}
+	defer func() { getStorkClient = newStorkClient }()
+
+	restore := &storkv1.VolumeSnapshotRestore{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      "restore1",
+			Namespace: "test",
+		},
+		Status: storkv1.VolumeSnapshotRestoreStatus{
+			Stage:  storkv1.VolumeSnapshotRestoreStageFinal,
+			Status: storkv1.VolumeSnapshotRestoreStatusSuccessful,
+		},
+	}
+	_, err := fakeStorkClient.StorkV1alpha1().VolumeSnapshotRestores("test").Create(restore)
+	require.NoError(t, err, "Error creating restore")
+
+	cmdArgs := []string{"wait", "restore", "restore1", "-n", "test"}
+	expected := "VolumeSnapshotRestore test/restore1 is Successful\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+
+	restore.Name = "restore2"
+	restore.Status.Status = storkv1.VolumeSnapshotRestoreStatusFailed
+	restore.Status.Reason = "snapshot not found"
+	_, err = fakeStorkClient.StorkV1alpha1().VolumeSnapshotRestores("test").Create(restore)
+	require.NoError(t, err, "Error creating restore")
+
+	cmdArgs = []string{"wait", "restore", "restore2", "-n", "test"}
+	expected = "VolumeSnapshotRestore test/restore2 completed with status Failed: snapshot not found"
+	testWaitExitCode(t, cmdArgs, expected, waitExitCodeFailed)
+}
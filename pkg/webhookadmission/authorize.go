@@ -0,0 +1,288 @@
+package webhookadmission
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/libopenstorage/stork/pkg/apis/stork"
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/rule"
+	"github.com/libopenstorage/stork/pkg/snapshot"
+	"github.com/portworx/sched-ops/k8s"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ruleReference is a rule referenced by an object along with the namespace in
+// which the rule is run
+type ruleReference struct {
+	name      string
+	namespace string
+}
+
+// ruleReferences returns the rules referenced by the object of the given kind
+func ruleReferences(kind string, raw []byte, namespace string) ([]ruleReference, error) {
+	var ruleNames []string
+	switch kind {
+	case "Migration":
+		migration := &stork_api.Migration{}
+		if err := json.Unmarshal(raw, migration); err != nil {
+			return nil, fmt.Errorf("error decoding Migration: %v", err)
+		}
+		ruleNames = []string{migration.Spec.PreExecRule, migration.Spec.PostExecRule}
+	case "MigrationSchedule":
+		migrationSchedule := &stork_api.MigrationSchedule{}
+		if err := json.Unmarshal(raw, migrationSchedule); err != nil {
+			return nil, fmt.Errorf("error decoding MigrationSchedule: %v", err)
+		}
+		spec := migrationSchedule.Spec.Template.Spec
+		ruleNames = []string{spec.PreExecRule, spec.PostExecRule}
+	case "VolumeSnapshotSchedule":
+		snapshotSchedule := &stork_api.VolumeSnapshotSchedule{}
+		if err := json.Unmarshal(raw, snapshotSchedule); err != nil {
+			return nil, fmt.Errorf("error decoding VolumeSnapshotSchedule: %v", err)
+		}
+		ruleNames = []string{snapshotSchedule.Spec.PreExecRule, snapshotSchedule.Spec.PostExecRule}
+	case "GroupVolumeSnapshot":
+		groupSnapshot := &stork_api.GroupVolumeSnapshot{}
+		if err := json.Unmarshal(raw, groupSnapshot); err != nil {
+			return nil, fmt.Errorf("error decoding GroupVolumeSnapshot: %v", err)
+		}
+		ruleNames = []string{groupSnapshot.Spec.PreExecRule, groupSnapshot.Spec.PostExecRule}
+	case "VolumeSnapshot":
+		// Only the annotations are needed from the snapshot
+		snap := &struct {
+			Metadata meta.ObjectMeta `json:"metadata"`
+		}{}
+		if err := json.Unmarshal(raw, snap); err != nil {
+			return nil, fmt.Errorf("error decoding VolumeSnapshot: %v", err)
+		}
+		ruleNames = snapshot.GetRuleNames(snap.Metadata.Annotations)
+	case "ClusterDomainUpdate":
+		// Cluster domain updates aren't namespaced, the rule is run in the
+		// namespace from the spec
+		clusterDomainUpdate := &stork_api.ClusterDomainUpdate{}
+		if err := json.Unmarshal(raw, clusterDomainUpdate); err != nil {
+			return nil, fmt.Errorf("error decoding ClusterDomainUpdate: %v", err)
+		}
+		ruleNames = []string{clusterDomainUpdate.Spec.PostExecRule}
+		namespace = clusterDomainUpdate.Spec.RuleNamespace
+	}
+	references := make([]ruleReference, 0)
+	for _, ruleName := range ruleNames {
+		if ruleName != "" {
+			references = append(references, ruleReference{name: ruleName, namespace: namespace})
+		}
+	}
+	return references, nil
+}
+
+// changedRuleReferences returns the rules referenced by the object in the
+// admission request. For updates only the rules that weren't referenced by
+// the old object are returned, so that objects can still be updated by users
+// that have lost access to the rules.
+func changedRuleReferences(req *admissionv1beta1.AdmissionRequest) ([]ruleReference, error) {
+	references, err := ruleReferences(req.Kind.Kind, req.Object.Raw, req.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	if req.Operation != admissionv1beta1.Update || len(req.OldObject.Raw) == 0 {
+		return references, nil
+	}
+	oldReferences, err := ruleReferences(req.Kind.Kind, req.OldObject.Raw, req.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[ruleReference]bool)
+	for _, reference := range oldReferences {
+		existing[reference] = true
+	}
+	changed := make([]ruleReference, 0)
+	for _, reference := range references {
+		if !existing[reference] {
+			changed = append(changed, reference)
+		}
+	}
+	return changed, nil
+}
+
+// isStorkUser returns true if the request was made by stork's service
+// account. The migrations and snapshots created by stork get their rules from
+// the schedules and other objects that were authorized when they were created.
+func (c *Controller) isStorkUser(req *admissionv1beta1.AdmissionRequest) bool {
+	if c.ServiceAccount == "" {
+		return false
+	}
+	return req.UserInfo.Username == fmt.Sprintf("system:serviceaccount:%v:%v", c.ServiceNamespace, c.ServiceAccount)
+}
+
+// authorizeClusterRules checks that the user making the request has been
+// granted the "use" verb on the cluster rules referenced by the object, so
+// that only the users allowed by RBAC can run the rules from the library in
+// their namespaces
+func (c *Controller) authorizeClusterRules(req *admissionv1beta1.AdmissionRequest) error {
+	if c.isStorkUser(req) {
+		return nil
+	}
+	references, err := changedRuleReferences(req)
+	if err != nil {
+		return err
+	}
+	for _, reference := range references {
+		if !rule.IsClusterRule(reference.name) {
+			continue
+		}
+		clusterRuleName := rule.GetClusterRuleName(reference.name)
+		allowed, err := c.checkAccess(req, &authorizationv1.ResourceAttributes{
+			Namespace: reference.namespace,
+			Verb:      rule.ClusterRuleUseVerb,
+			Group:     stork.GroupName,
+			Resource:  stork_api.ClusterRuleResourcePlural,
+			Name:      clusterRuleName,
+		})
+		if err != nil {
+			return fmt.Errorf("error checking access to cluster rule %v: %v", clusterRuleName, err)
+		}
+		if !allowed {
+			return fmt.Errorf("user %v isn't allowed to use cluster rule %v", req.UserInfo.Username, clusterRuleName)
+		}
+	}
+	return nil
+}
+
+// authorizeGroupSnapshotNamespaces checks that the user making the request is
+// allowed to create group snapshots in the other namespaces that a group
+// snapshot selects PVCs from. For updates only the namespaces that weren't
+// selected before are authorized.
+func (c *Controller) authorizeGroupSnapshotNamespaces(req *admissionv1beta1.AdmissionRequest) error {
+	if req.Kind.Kind != "GroupVolumeSnapshot" || c.isStorkUser(req) {
+		return nil
+	}
+	groupSnapshot, oldGroupSnapshot := &stork_api.GroupVolumeSnapshot{}, &stork_api.GroupVolumeSnapshot{}
+	update, err := decodeRequest(req, groupSnapshot, oldGroupSnapshot)
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]bool)
+	if update {
+		for _, ns := range oldGroupSnapshot.Spec.Namespaces {
+			existing[ns] = true
+		}
+	}
+	for _, ns := range groupSnapshot.Spec.Namespaces {
+		if ns == req.Namespace || existing[ns] {
+			continue
+		}
+		allowed, err := c.checkAccess(req, &authorizationv1.ResourceAttributes{
+			Namespace: ns,
+			Verb:      "create",
+			Group:     stork.GroupName,
+			Resource:  stork_api.GroupVolumeSnapshotResourcePlural,
+		})
+		if err != nil {
+			return fmt.Errorf("error checking access to namespace %v: %v", ns, err)
+		}
+		if !allowed {
+			return fmt.Errorf("user %v isn't allowed to create group snapshots in namespace %v", req.UserInfo.Username, ns)
+		}
+	}
+	return nil
+}
+
+// authorizeRestoreNamespaces checks that the user creating a group snapshot
+// restore is allowed to create the PVCs in the other namespaces of the group
+// snapshot, or update them for in-place restores. Updates are only authorized
+// if they change the source or type of the restore.
+func (c *Controller) authorizeRestoreNamespaces(req *admissionv1beta1.AdmissionRequest) error {
+	if c.isStorkUser(req) {
+		return nil
+	}
+	var sourceName string
+	var inPlace bool
+	switch req.Kind.Kind {
+	case "GroupVolumeSnapshotRestore":
+		groupRestore, oldGroupRestore := &stork_api.GroupVolumeSnapshotRestore{}, &stork_api.GroupVolumeSnapshotRestore{}
+		update, err := decodeRequest(req, groupRestore, oldGroupRestore)
+		if err != nil {
+			return err
+		}
+		if update && groupRestore.Spec.SourceName == oldGroupRestore.Spec.SourceName &&
+			groupRestore.Spec.Type == oldGroupRestore.Spec.Type {
+			return nil
+		}
+		sourceName = groupRestore.Spec.SourceName
+		inPlace = groupRestore.Spec.Type == stork_api.GroupVolumeSnapshotRestoreTypeInPlace
+	case "VolumeSnapshotRestore":
+		snapRestore, oldSnapRestore := &stork_api.VolumeSnapshotRestore{}, &stork_api.VolumeSnapshotRestore{}
+		update, err := decodeRequest(req, snapRestore, oldSnapRestore)
+		if err != nil {
+			return err
+		}
+		if !snapRestore.Spec.GroupSnapshot || (update && oldSnapRestore.Spec.GroupSnapshot &&
+			snapRestore.Spec.SourceName == oldSnapRestore.Spec.SourceName) {
+			return nil
+		}
+		sourceName = snapRestore.Spec.SourceName
+		inPlace = true
+	default:
+		return nil
+	}
+
+	groupSnap, err := k8s.Instance().GetGroupSnapshot(sourceName, req.Namespace)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			// The controller fails the restore
+			return nil
+		}
+		return fmt.Errorf("error getting group snapshot %v: %v", sourceName, err)
+	}
+	verb := "create"
+	if inPlace {
+		verb = "update"
+	}
+	for _, ns := range groupSnap.Spec.Namespaces {
+		if ns == req.Namespace {
+			continue
+		}
+		allowed, err := c.checkAccess(req, &authorizationv1.ResourceAttributes{
+			Namespace: ns,
+			Verb:      verb,
+			Resource:  "persistentvolumeclaims",
+		})
+		if err != nil {
+			return fmt.Errorf("error checking access to namespace %v: %v", ns, err)
+		}
+		if !allowed {
+			return fmt.Errorf("user %v isn't allowed to %v PVCs in namespace %v", req.UserInfo.Username, verb, ns)
+		}
+	}
+	return nil
+}
+
+// checkAccess creates a subject access review to check if the user making the
+// request is allowed to perform the action with the attributes
+func (c *Controller) checkAccess(
+	req *admissionv1beta1.AdmissionRequest,
+	attributes *authorizationv1.ResourceAttributes,
+) (bool, error) {
+	extra := make(map[string]authorizationv1.ExtraValue)
+	for k, v := range req.UserInfo.Extra {
+		extra[k] = authorizationv1.ExtraValue(v)
+	}
+	review := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:               req.UserInfo.Username,
+			UID:                req.UserInfo.UID,
+			Groups:             req.UserInfo.Groups,
+			Extra:              extra,
+			ResourceAttributes: attributes,
+		},
+	}
+	review, err := c.KubeClient.AuthorizationV1().SubjectAccessReviews().Create(review)
+	if err != nil {
+		return false, err
+	}
+	return review.Status.Allowed, nil
+}
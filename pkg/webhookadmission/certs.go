@@ -0,0 +1,140 @@
+package webhookadmission
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+const (
+	certValidity = 365 * 24 * time.Hour
+	// certRenewBefore is how long before the serving certificate expires that
+	// new certificates are generated
+	certRenewBefore = 30 * 24 * time.Hour
+	keySize         = 2048
+)
+
+var serialNumberLimit = new(big.Int).Lsh(big.NewInt(1), 128)
+
+// generateCertificates generates a self-signed CA and a serving certificate
+// for the DNS name signed by it. Returns the PEM encoded CA certificate,
+// serving certificate and serving key.
+func generateCertificates(dnsName string) ([]byte, []byte, []byte, error) {
+	notBefore := time.Now().Add(-time.Hour)
+	notAfter := notBefore.Add(certValidity)
+
+	caKey, err := rsa.GenerateKey(rand.Reader, keySize)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	caSerial, err := rand.Int(rand.Reader, serialNumberLimit)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          caSerial,
+		Subject:               pkix.Name{CommonName: "stork-webhook-ca"},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	caCert, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, keySize)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	serial, err := rand.Int(rand.Reader, serialNumberLimit)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: dnsName},
+		DNSNames:     []string{dnsName},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}),
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}),
+		pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}),
+		nil
+}
+
+// parseCertificates returns the certificates from the PEM encoded data
+func parseCertificates(data []byte) ([]*x509.Certificate, error) {
+	certs := make([]*x509.Certificate, 0)
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no certificates found")
+	}
+	return certs, nil
+}
+
+// needsRotation returns true if the PEM encoded serving certificate can't be
+// parsed or expires within certRenewBefore
+func needsRotation(certPEM []byte, now time.Time) bool {
+	certs, err := parseCertificates(certPEM)
+	if err != nil {
+		return true
+	}
+	return now.Add(certRenewBefore).After(certs[0].NotAfter)
+}
+
+// mergeCABundle returns the bundle with the new CA followed by the CAs from
+// the old bundle that haven't expired. The API server then trusts the serving
+// certificates signed by the old and new CAs while the replicas switch to the
+// new certificate.
+func mergeCABundle(newCA []byte, oldBundle []byte, now time.Time) []byte {
+	bundle := append([]byte{}, newCA...)
+	oldCerts, err := parseCertificates(oldBundle)
+	if err != nil {
+		return bundle
+	}
+	for _, cert := range oldCerts {
+		if now.After(cert.NotAfter) {
+			continue
+		}
+		certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+		if !bytes.Contains(bundle, certPEM) {
+			bundle = append(bundle, certPEM...)
+		}
+	}
+	return bundle
+}
@@ -0,0 +1,83 @@
+package webhookadmission
+
+import (
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	admissionregistration "k8s.io/api/admissionregistration/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// getFailurePolicy returns the failure policy that should be used for the
+// webhook. Requests are only rejected when the webhook can't be reached if
+// the service has ready endpoints, so that a stork deployment that is down or
+// being upgraded doesn't block updates to stork resources.
+func (c *Controller) getFailurePolicy() admissionregistration.FailurePolicyType {
+	if c.HealthCheckIntervalSec == 0 {
+		return admissionregistration.Fail
+	}
+	endpoints, err := c.KubeClient.CoreV1().Endpoints(c.ServiceNamespace).Get(c.ServiceName, meta.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			log.Warnf("Error getting endpoints for webhook service %v/%v: %v", c.ServiceNamespace, c.ServiceName, err)
+		}
+		return admissionregistration.Ignore
+	}
+	for _, subset := range endpoints.Subsets {
+		if len(subset.Addresses) > 0 {
+			return admissionregistration.Fail
+		}
+	}
+	return admissionregistration.Ignore
+}
+
+// setFailurePolicy updates the failure policy in the webhook configuration if
+// it is different
+func (c *Controller) setFailurePolicy(failurePolicy admissionregistration.FailurePolicyType) error {
+	client := c.KubeClient.AdmissionregistrationV1beta1().ValidatingWebhookConfigurations()
+	webhookConfig, err := client.Get(ValidatingWebhookConfigName, meta.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("error getting webhook configuration %v: %v", ValidatingWebhookConfigName, err)
+	}
+	updated := false
+	for i := range webhookConfig.Webhooks {
+		webhook := &webhookConfig.Webhooks[i]
+		if webhook.FailurePolicy == nil || *webhook.FailurePolicy != failurePolicy {
+			policy := failurePolicy
+			webhook.FailurePolicy = &policy
+			updated = true
+		}
+	}
+	if !updated {
+		return nil
+	}
+	log.Infof("Setting failure policy for webhook configuration %v to %v", ValidatingWebhookConfigName, failurePolicy)
+	if _, err := client.Update(webhookConfig); err != nil {
+		return fmt.Errorf("error updating webhook configuration %v: %v", ValidatingWebhookConfigName, err)
+	}
+	return nil
+}
+
+// startHealthCheck keeps updating the failure policy of the webhook based on
+// the health of the service till the stop channel is closed
+func (c *Controller) startHealthCheck(stopChannel chan struct{}) {
+	if c.HealthCheckIntervalSec == 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(time.Duration(c.HealthCheckIntervalSec) * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := c.setFailurePolicy(c.getFailurePolicy()); err != nil {
+					log.Errorf("Error updating webhook failure policy: %v", err)
+				}
+			case <-stopChannel:
+				return
+			}
+		}
+	}()
+}
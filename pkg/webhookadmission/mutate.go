@@ -0,0 +1,167 @@
+package webhookadmission
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	log "github.com/sirupsen/logrus"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	admissionregistration "k8s.io/api/admissionregistration/v1beta1"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// MutatingWebhookConfigName is the name of the mutating webhook
+	// configuration registered by stork
+	MutatingWebhookConfigName = "stork-webhooks-cfg"
+	mutatingWebhookName       = "mutate.stork.libopenstorage.org"
+	mutatePath                = "/mutate"
+	templateSpecPath          = "/spec/template/spec"
+)
+
+// appObject has the fields that are common to the deployments and
+// statefulsets from all the API versions
+type appObject struct {
+	meta.ObjectMeta `json:"metadata,omitempty"`
+	Spec            struct {
+		Template             v1.PodTemplateSpec         `json:"template"`
+		VolumeClaimTemplates []v1.PersistentVolumeClaim `json:"volumeClaimTemplates,omitempty"`
+	} `json:"spec"`
+}
+
+type patchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// mutateRequest returns the JSON patch to update the pod template of the
+// application in the admission request, or nil if it doesn't need to be
+// updated
+func (c *Controller) mutateRequest(req *admissionv1beta1.AdmissionRequest) ([]byte, error) {
+	app := &appObject{}
+	if err := json.Unmarshal(req.Object.Raw, app); err != nil {
+		return nil, fmt.Errorf("error decoding %v: %v", req.Kind.Kind, err)
+	}
+	// The namespace isn't always set in the object when it is created
+	if app.Namespace == "" {
+		app.Namespace = req.Namespace
+	}
+
+	template := app.Spec.Template.DeepCopy()
+	switch req.Kind.Kind {
+	case "Deployment":
+		if err := c.AppInitializer.UpdateDeploymentTemplate(&app.ObjectMeta, template); err != nil {
+			return nil, fmt.Errorf("error getting volumes for deployment: %v", err)
+		}
+	case "StatefulSet":
+		if err := c.AppInitializer.UpdateStatefulSetTemplate(&app.ObjectMeta, template, app.Spec.VolumeClaimTemplates); err != nil {
+			return nil, fmt.Errorf("error getting volume templates for statefulset: %v", err)
+		}
+	default:
+		return nil, nil
+	}
+
+	patch := make([]patchOperation, 0)
+	if template.Spec.SchedulerName != app.Spec.Template.Spec.SchedulerName {
+		patch = append(patch, patchOperation{
+			Op:    "add",
+			Path:  templateSpecPath + "/schedulerName",
+			Value: template.Spec.SchedulerName,
+		})
+	}
+	if !reflect.DeepEqual(template.Spec.Affinity, app.Spec.Template.Spec.Affinity) {
+		patch = append(patch, patchOperation{
+			Op:    "add",
+			Path:  templateSpecPath + "/affinity",
+			Value: template.Spec.Affinity,
+		})
+	}
+	if len(patch) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(patch)
+}
+
+func (c *Controller) serveMutate(w http.ResponseWriter, req *http.Request) {
+	serveAdmissionReview(w, req, func(request *admissionv1beta1.AdmissionRequest) *admissionv1beta1.AdmissionResponse {
+		// Applications are always admitted, they just won't be scheduled by
+		// stork if they can't be updated
+		response := &admissionv1beta1.AdmissionResponse{Allowed: true}
+		if c.AppInitializer == nil {
+			return response
+		}
+		patch, err := c.mutateRequest(request)
+		if err != nil {
+			log.Errorf("Error updating %v %v/%v: %v", request.Kind.Kind, request.Namespace, request.Name, err)
+			return response
+		}
+		if patch != nil {
+			patchType := admissionv1beta1.PatchTypeJSONPatch
+			response.Patch = patch
+			response.PatchType = &patchType
+		}
+		return response
+	})
+}
+
+func (c *Controller) registerMutatingWebhook(caCert []byte) error {
+	path := mutatePath
+	// Failing to update the scheduler name shouldn't block applications from
+	// being created
+	failurePolicy := admissionregistration.Ignore
+	webhookConfig := &admissionregistration.MutatingWebhookConfiguration{
+		ObjectMeta: meta.ObjectMeta{
+			Name: MutatingWebhookConfigName,
+		},
+		Webhooks: []admissionregistration.Webhook{
+			{
+				Name: mutatingWebhookName,
+				ClientConfig: admissionregistration.WebhookClientConfig{
+					Service: &admissionregistration.ServiceReference{
+						Name:      c.ServiceName,
+						Namespace: c.ServiceNamespace,
+						Path:      &path,
+					},
+					CABundle: caCert,
+				},
+				Rules: []admissionregistration.RuleWithOperations{
+					{
+						Operations: []admissionregistration.OperationType{admissionregistration.Create},
+						Rule: admissionregistration.Rule{
+							APIGroups:   []string{"apps"},
+							APIVersions: []string{"v1", "v1beta1", "v1beta2"},
+							Resources:   []string{"deployments", "statefulsets"},
+						},
+					},
+					{
+						Operations: []admissionregistration.OperationType{admissionregistration.Create},
+						Rule: admissionregistration.Rule{
+							APIGroups:   []string{"extensions"},
+							APIVersions: []string{"v1beta1"},
+							Resources:   []string{"deployments"},
+						},
+					},
+				},
+				FailurePolicy: &failurePolicy,
+			},
+		},
+	}
+
+	client := c.KubeClient.AdmissionregistrationV1beta1().MutatingWebhookConfigurations()
+	existing, err := client.Get(MutatingWebhookConfigName, meta.GetOptions{})
+	if errors.IsNotFound(err) {
+		_, err = client.Create(webhookConfig)
+	} else if err == nil {
+		existing.Webhooks = webhookConfig.Webhooks
+		_, err = client.Update(existing)
+	}
+	if err != nil {
+		return fmt.Errorf("error registering webhook configuration %v: %v", MutatingWebhookConfigName, err)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+// +build unittest
+
+package webhookadmission
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/libopenstorage/stork/drivers/volume"
+	"github.com/libopenstorage/stork/drivers/volume/mock"
+	storkerrors "github.com/libopenstorage/stork/pkg/errors"
+	"github.com/libopenstorage/stork/pkg/initializer"
+	"github.com/portworx/sched-ops/k8s"
+	"github.com/stretchr/testify/require"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	appv1 "k8s.io/api/apps/v1"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakekube "k8s.io/client-go/kubernetes/fake"
+)
+
+func newMutateRequest(t *testing.T, kind string, object interface{}) *admissionv1beta1.AdmissionRequest {
+	raw, err := json.Marshal(object)
+	require.NoError(t, err, "Error encoding object")
+	return &admissionv1beta1.AdmissionRequest{
+		Kind:      metav1.GroupVersionKind{Group: "apps", Version: "v1", Kind: kind},
+		Namespace: "app",
+		Object:    runtime.RawExtension{Raw: raw},
+	}
+}
+
+func getPatch(t *testing.T, c *Controller, kind string, object interface{}) []patchOperation {
+	patch, err := c.mutateRequest(newMutateRequest(t, kind, object))
+	require.NoError(t, err, "Error mutating %v", kind)
+	if patch == nil {
+		return nil
+	}
+	operations := make([]patchOperation, 0)
+	require.NoError(t, json.Unmarshal(patch, &operations), "Error decoding patch")
+	return operations
+}
+
+func sendMutateReview(t *testing.T, c *Controller, statefulSet *appv1.StatefulSet) *admissionv1beta1.AdmissionResponse {
+	body, err := json.Marshal(&admissionv1beta1.AdmissionReview{
+		Request: newMutateRequest(t, "StatefulSet", statefulSet),
+	})
+	require.NoError(t, err, "Error encoding admission review")
+	recorder := httptest.NewRecorder()
+	c.serveMutate(recorder, httptest.NewRequest(http.MethodPost, mutatePath, bytes.NewReader(body)))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	response := &admissionv1beta1.AdmissionReview{}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), response), "Error decoding response")
+	require.NotNil(t, response.Response)
+	return response.Response
+}
+
+func TestMutateTemplates(t *testing.T) {
+	k8s.Instance().SetClient(fakekube.NewSimpleClientset(), nil, nil, nil, nil, nil)
+	storkDriver, err := volume.Get("MockDriver")
+	require.NoError(t, err, "Error getting mock volume driver")
+	driver, ok := storkDriver.(*mock.Driver)
+	require.True(t, ok, "Error casting mock driver")
+	require.NoError(t, driver.CreateCluster(1, &v1.NodeList{}), "Error creating cluster")
+	require.NoError(t, driver.ProvisionVolume("mutateVolume", []int{0}, 1), "Error provisioning volume")
+	driver.NewPVC("mutateVolume")
+
+	c := &Controller{AppInitializer: &initializer.Initializer{Driver: driver}}
+
+	deployment := &appv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "deployment"},
+	}
+	deployment.Spec.Template.Spec.SchedulerName = "default-scheduler"
+	require.Nil(t, getPatch(t, c, "Deployment", deployment), "Deployments without volumes shouldn't be updated")
+
+	deployment.Spec.Template.Spec.Volumes = []v1.Volume{
+		{
+			Name: "data",
+			VolumeSource: v1.VolumeSource{
+				PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: "mutateVolume"},
+			},
+		},
+	}
+	operations := getPatch(t, c, "Deployment", deployment)
+	require.Len(t, operations, 1)
+	require.Equal(t, "/spec/template/spec/schedulerName", operations[0].Path)
+	require.Equal(t, "stork", operations[0].Value)
+
+	deployment.Spec.Template.Annotations = map[string]string{initializer.SkipAnnotation: "true"}
+	require.Nil(t, getPatch(t, c, "Deployment", deployment), "Opted out deployments shouldn't be updated")
+
+	storageClassName := driver.GetStorageClassName()
+	statefulSet := &appv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "statefulset"},
+	}
+	statefulSet.Spec.Template.Spec.SchedulerName = "default-scheduler"
+	require.Nil(t, getPatch(t, c, "StatefulSet", statefulSet), "Statefulsets without volume templates shouldn't be updated")
+	statefulSet.Spec.VolumeClaimTemplates = []v1.PersistentVolumeClaim{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "data"},
+			Spec:       v1.PersistentVolumeClaimSpec{StorageClassName: &storageClassName},
+		},
+	}
+	operations = getPatch(t, c, "StatefulSet", statefulSet)
+	require.Len(t, operations, 1)
+	require.Equal(t, "stork", operations[0].Value)
+	response := sendMutateReview(t, c, statefulSet)
+	require.True(t, response.Allowed)
+	require.NotNil(t, response.PatchType)
+	require.Equal(t, admissionv1beta1.PatchTypeJSONPatch, *response.PatchType)
+
+	// Applications are admitted even if they can't be updated
+	driver.SetInterfaceError(&storkerrors.ErrNotSupported{})
+	defer driver.SetInterfaceError(nil)
+	_, err = c.mutateRequest(newMutateRequest(t, "StatefulSet", statefulSet))
+	require.Error(t, err)
+	response = sendMutateReview(t, c, statefulSet)
+	require.True(t, response.Allowed)
+	require.Nil(t, response.Patch)
+}
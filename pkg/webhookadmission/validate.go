@@ -0,0 +1,292 @@
+package webhookadmission
+
+import (
+	"encoding/json"
+	"fmt"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/rule"
+	"github.com/libopenstorage/stork/pkg/schedule"
+	"github.com/portworx/sched-ops/k8s"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+)
+
+// validatedResources returns the stork resources that are sent to the webhook
+// for validation, or to authorize the cluster rules and namespaces referenced
+// by them
+func validatedResources() []string {
+	return []string{
+		stork_api.SchedulePolicyResourcePlural,
+		stork_api.MigrationResourcePlural,
+		stork_api.MigrationScheduleResourcePlural,
+		stork_api.VolumeSnapshotScheduleResourcePlural,
+		stork_api.GroupVolumeSnapshotResourcePlural,
+		stork_api.ClusterDomainUpdatePlural,
+		stork_api.GroupVolumeSnapshotRestoreResourcePlural,
+		stork_api.VolumeSnapshotRestoreResourcePlural,
+	}
+}
+
+// validateRequest decodes the object in the admission request and returns an
+// error describing why it is invalid, if it is
+func validateRequest(req *admissionv1beta1.AdmissionRequest) error {
+	switch req.Kind.Kind {
+	case "SchedulePolicy":
+		policy := &stork_api.SchedulePolicy{}
+		if _, err := decodeRequest(req, policy, nil); err != nil {
+			return err
+		}
+		return validateSchedulePolicy(policy)
+	case "Migration":
+		migration, oldMigration := &stork_api.Migration{}, &stork_api.Migration{}
+		update, err := decodeRequest(req, migration, oldMigration)
+		if err != nil {
+			return err
+		}
+		var oldSpec *stork_api.MigrationSpec
+		if update {
+			oldSpec = &oldMigration.Spec
+		}
+		return validateMigrationSpec(&migration.Spec, oldSpec, req.Namespace)
+	case "MigrationSchedule":
+		migrationSchedule, oldMigrationSchedule := &stork_api.MigrationSchedule{}, &stork_api.MigrationSchedule{}
+		update, err := decodeRequest(req, migrationSchedule, oldMigrationSchedule)
+		if err != nil {
+			return err
+		}
+		if !update {
+			oldMigrationSchedule = nil
+		}
+		return validateMigrationSchedule(migrationSchedule, oldMigrationSchedule, req.Namespace)
+	case "VolumeSnapshotSchedule":
+		snapshotSchedule, oldSnapshotSchedule := &stork_api.VolumeSnapshotSchedule{}, &stork_api.VolumeSnapshotSchedule{}
+		update, err := decodeRequest(req, snapshotSchedule, oldSnapshotSchedule)
+		if err != nil {
+			return err
+		}
+		if !update {
+			oldSnapshotSchedule = nil
+		}
+		return validateVolumeSnapshotSchedule(snapshotSchedule, oldSnapshotSchedule, req.Namespace)
+	}
+	return nil
+}
+
+// decodeRequest decodes the object in the admission request, and for updates
+// the old object if oldObject is set. Returns true if the old object was
+// decoded.
+func decodeRequest(req *admissionv1beta1.AdmissionRequest, object interface{}, oldObject interface{}) (bool, error) {
+	if err := json.Unmarshal(req.Object.Raw, object); err != nil {
+		return false, fmt.Errorf("error decoding %v: %v", req.Kind.Kind, err)
+	}
+	if oldObject == nil || req.Operation != admissionv1beta1.Update || len(req.OldObject.Raw) == 0 {
+		return false, nil
+	}
+	if err := json.Unmarshal(req.OldObject.Raw, oldObject); err != nil {
+		return false, fmt.Errorf("error decoding old %v: %v", req.Kind.Kind, err)
+	}
+	return true, nil
+}
+
+func validateSchedulePolicy(policy *stork_api.SchedulePolicy) error {
+	if policy.Policy.Interval == nil && policy.Policy.Daily == nil &&
+		policy.Policy.Weekly == nil && policy.Policy.Monthly == nil && policy.Policy.Cron == nil {
+		return fmt.Errorf("at least one of interval, daily, weekly, monthly or cron policies needs to be specified")
+	}
+	return schedule.ValidateSchedulePolicy(policy)
+}
+
+// validateMigrationSpec validates the spec of a migration. oldSpec is set for
+// updates so that the references that haven't changed aren't validated again.
+func validateMigrationSpec(spec *stork_api.MigrationSpec, oldSpec *stork_api.MigrationSpec, namespace string) error {
+	if spec.ClusterPair == "" {
+		return fmt.Errorf("clusterPair needs to be specified")
+	}
+	if len(spec.Namespaces) == 0 {
+		return fmt.Errorf("at least one namespace needs to be specified")
+	}
+	namespaces := make(map[string]bool)
+	for _, ns := range spec.Namespaces {
+		if ns == "" {
+			return fmt.Errorf("namespaces can't be empty")
+		}
+		if namespaces[ns] {
+			return fmt.Errorf("namespace %v is specified more than once", ns)
+		}
+		namespaces[ns] = true
+	}
+	var oldRules map[string]string
+	if oldSpec != nil {
+		oldRules = execRules(oldSpec.PreExecRule, oldSpec.PostExecRule)
+	}
+	return validateRules(execRules(spec.PreExecRule, spec.PostExecRule), oldRules, namespace)
+}
+
+func validateMigrationSchedule(
+	migrationSchedule *stork_api.MigrationSchedule,
+	oldMigrationSchedule *stork_api.MigrationSchedule,
+	namespace string,
+) error {
+	var oldSpec *stork_api.MigrationSpec
+	oldPolicyName := ""
+	if oldMigrationSchedule != nil {
+		oldSpec = &oldMigrationSchedule.Spec.Template.Spec
+		oldPolicyName = oldMigrationSchedule.Spec.SchedulePolicyName
+	}
+	if err := validateMigrationSpec(&migrationSchedule.Spec.Template.Spec, oldSpec, namespace); err != nil {
+		return fmt.Errorf("invalid migration template: %v", err)
+	}
+	if err := validateCatchUpPolicy(migrationSchedule.Spec.CatchUpPolicy); err != nil {
+		return err
+	}
+	if err := validateConcurrencyPolicy(migrationSchedule.Spec.ConcurrencyPolicy); err != nil {
+		return err
+	}
+	if err := validateScheduleDependency(migrationSchedule.Spec.DependsOn, "MigrationSchedule", migrationSchedule.Name); err != nil {
+		return err
+	}
+	return validateSchedulePolicyName(migrationSchedule.Spec.SchedulePolicyName, oldPolicyName, namespace)
+}
+
+func validateVolumeSnapshotSchedule(
+	snapshotSchedule *stork_api.VolumeSnapshotSchedule,
+	oldSnapshotSchedule *stork_api.VolumeSnapshotSchedule,
+	namespace string,
+) error {
+	switch snapshotSchedule.Spec.SnapshotType {
+	case "", stork_api.VolumeSnapshotTypeStork, stork_api.VolumeSnapshotTypeCSI:
+	default:
+		return fmt.Errorf("invalid snapshotType %v", snapshotSchedule.Spec.SnapshotType)
+	}
+	switch snapshotSchedule.Spec.ReclaimPolicy {
+	case "", stork_api.ReclaimPolicyDelete, stork_api.ReclaimPolicyRetain:
+	default:
+		return fmt.Errorf("invalid reclaimPolicy %v", snapshotSchedule.Spec.ReclaimPolicy)
+	}
+	if err := validateCatchUpPolicy(snapshotSchedule.Spec.CatchUpPolicy); err != nil {
+		return err
+	}
+	if err := validateConcurrencyPolicy(snapshotSchedule.Spec.ConcurrencyPolicy); err != nil {
+		return err
+	}
+	var oldRules map[string]string
+	oldPolicyName := ""
+	if oldSnapshotSchedule != nil {
+		oldRules = execRules(oldSnapshotSchedule.Spec.PreExecRule, oldSnapshotSchedule.Spec.PostExecRule)
+		oldPolicyName = oldSnapshotSchedule.Spec.SchedulePolicyName
+	}
+	rules := execRules(snapshotSchedule.Spec.PreExecRule, snapshotSchedule.Spec.PostExecRule)
+	if err := validateRules(rules, oldRules, namespace); err != nil {
+		return err
+	}
+	if err := validateScheduleDependency(snapshotSchedule.Spec.DependsOn, "VolumeSnapshotSchedule", snapshotSchedule.Name); err != nil {
+		return err
+	}
+	return validateSchedulePolicyName(snapshotSchedule.Spec.SchedulePolicyName, oldPolicyName, namespace)
+}
+
+func validateConcurrencyPolicy(concurrencyPolicy stork_api.ConcurrencyPolicyType) error {
+	switch concurrencyPolicy {
+	case "", stork_api.ConcurrencyPolicyForbid, stork_api.ConcurrencyPolicyReplace, stork_api.ConcurrencyPolicyAllow:
+		return nil
+	}
+	return fmt.Errorf("invalid concurrencyPolicy %v", concurrencyPolicy)
+}
+
+func validateCatchUpPolicy(catchUpPolicy stork_api.CatchUpPolicyType) error {
+	switch catchUpPolicy {
+	case "", stork_api.CatchUpPolicySkip, stork_api.CatchUpPolicyRunOnce, stork_api.CatchUpPolicyRunAllMissed:
+		return nil
+	}
+	return fmt.Errorf("invalid catchUpPolicy %v", catchUpPolicy)
+}
+
+// validateSchedulePolicyName checks that the schedule policy exists and that
+// schedules in the namespace are allowed to use it. The policy isn't checked
+// again if an update doesn't change it, so that schedules can still be
+// updated after the policy has been deleted.
+func validateSchedulePolicyName(policyName string, oldPolicyName string, namespace string) error {
+	if policyName == "" {
+		return fmt.Errorf("schedulePolicyName needs to be specified")
+	}
+	if policyName == oldPolicyName {
+		return nil
+	}
+	policy, err := k8s.Instance().GetSchedulePolicy(policyName)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return fmt.Errorf("schedule policy %v not found", policyName)
+		}
+		return fmt.Errorf("error getting schedule policy %v: %v", policyName, err)
+	}
+	allowed, err := schedule.NamespaceAllowed(policy, namespace)
+	if err != nil {
+		return fmt.Errorf("error checking if namespace %v can use schedule policy %v: %v", namespace, policyName, err)
+	}
+	if !allowed {
+		return fmt.Errorf("schedule policy %v can't be used in namespace %v", policyName, namespace)
+	}
+	return nil
+}
+
+// validateScheduleDependency checks that the dependency of a schedule refers
+// to another schedule. The schedule doesn't need to exist yet so that the
+// schedules can be created in any order.
+func validateScheduleDependency(dependency *stork_api.ScheduleDependency, kind string, name string) error {
+	if dependency == nil {
+		return nil
+	}
+	switch dependency.Kind {
+	case "MigrationSchedule", "VolumeSnapshotSchedule":
+	default:
+		return fmt.Errorf("invalid kind %v in dependsOn, expected MigrationSchedule or VolumeSnapshotSchedule", dependency.Kind)
+	}
+	if dependency.Name == "" {
+		return fmt.Errorf("name needs to be specified in dependsOn")
+	}
+	if dependency.Kind == kind && dependency.Name == name {
+		return fmt.Errorf("schedule can't depend on itself")
+	}
+	return nil
+}
+
+// execRules returns the pre and post exec rules keyed by the field they are
+// set in
+func execRules(preExecRule string, postExecRule string) map[string]string {
+	return map[string]string{"preExecRule": preExecRule, "postExecRule": postExecRule}
+}
+
+// validateRules checks that the rules exist and can be used in the namespace.
+// For updates the rules that were already set in oldRules aren't checked
+// again, so that objects can still be updated, for eg to remove finalizers,
+// after the rules they reference have been deleted.
+func validateRules(rules map[string]string, oldRules map[string]string, namespace string) error {
+	for field, ruleName := range rules {
+		if ruleName == "" || ruleName == oldRules[field] {
+			continue
+		}
+		if rule.IsTemplate(ruleName) {
+			if _, err := rule.RuleFromTemplate(ruleName, namespace, rule.PreExecRule, nil); err != nil {
+				return fmt.Errorf("invalid %v %v: %v", field, ruleName, err)
+			}
+			continue
+		}
+		if rule.IsClusterRule(ruleName) {
+			if _, err := rule.GetRule(ruleName, namespace, rule.PreExecRule, nil); err != nil {
+				if errors.IsNotFound(err) {
+					return fmt.Errorf("%v %v not found", field, ruleName)
+				}
+				return fmt.Errorf("invalid %v %v: %v", field, ruleName, err)
+			}
+			continue
+		}
+		if _, err := k8s.Instance().GetRule(ruleName, namespace); err != nil {
+			if errors.IsNotFound(err) {
+				return fmt.Errorf("%v %v not found in namespace %v", field, ruleName, namespace)
+			}
+			return fmt.Errorf("error getting %v %v: %v", field, ruleName, err)
+		}
+	}
+	return nil
+}
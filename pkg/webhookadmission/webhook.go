@@ -0,0 +1,444 @@
+package webhookadmission
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	snapv1 "github.com/kubernetes-incubator/external-storage/snapshot/pkg/apis/crd/v1"
+	stork "github.com/libopenstorage/stork/pkg/apis/stork"
+	"github.com/libopenstorage/stork/pkg/initializer"
+	log "github.com/sirupsen/logrus"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	admissionregistration "k8s.io/api/admissionregistration/v1beta1"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// ValidatingWebhookConfigName is the name of the validating webhook
+	// configuration registered by stork
+	ValidatingWebhookConfigName = "stork-webhooks-cfg"
+	validatingWebhookName       = "validate.stork.libopenstorage.org"
+	validatePath                = "/validate"
+	certSecretName              = "stork-webhook-certs"
+	certSecretCAKey             = "ca.crt"
+	certSecretCertKey           = "tls.crt"
+	certSecretKeyKey            = "tls.key"
+
+	// DefaultPort is the port on which the webhook server listens by default
+	DefaultPort = 8443
+
+	// certCheckInterval is the interval at which the certificates are
+	// checked for rotation
+	certCheckInterval = time.Hour
+)
+
+// Controller serves the validating admission webhook for stork's CRDs so that
+// malformed specs are rejected when they are created instead of failing later
+// in the controllers
+type Controller struct {
+	// KubeClient is used to manage the serving certificate and the webhook
+	// configuration
+	KubeClient kubernetes.Interface
+	// ServiceName is the name of the service through which the API server
+	// reaches the webhook
+	ServiceName string
+	// ServiceNamespace is the namespace of the service, which is also where
+	// the serving certificate is stored
+	ServiceNamespace string
+	// ServiceAccount is the name of the service account stork runs as in the
+	// service namespace. Requests from it aren't checked for access to
+	// cluster rules
+	ServiceAccount string
+	// Port on which the webhook server listens
+	Port int
+	// HealthCheckIntervalSec is the interval at which the endpoints of the
+	// service are checked. The webhook only fails requests it can't serve
+	// while the service has ready endpoints. Set to 0 to always fail them
+	HealthCheckIntervalSec int64
+	// AppInitializer if set, the pod templates of deployments and
+	// statefulsets using volumes from the driver are updated to use stork as
+	// the scheduler when they are created
+	AppInitializer *initializer.Initializer
+
+	server      *http.Server
+	serverCert  *tls.Certificate
+	certLock    sync.Mutex
+	stopChannel chan struct{}
+	lock        sync.Mutex
+	started     bool
+}
+
+// Start Starts the webhook server and registers the webhook configuration
+func (c *Controller) Start() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.started {
+		return fmt.Errorf("webhook controller has already been started")
+	}
+	if c.Port == 0 {
+		c.Port = DefaultPort
+	}
+
+	caCert, serverCert, err := c.getCertificates()
+	if err != nil {
+		return err
+	}
+	c.serverCert = serverCert
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(validatePath, c.serveValidate)
+	mux.HandleFunc(mutatePath, c.serveMutate)
+	c.server = &http.Server{
+		Addr:      fmt.Sprintf(":%v", c.Port),
+		Handler:   mux,
+		TLSConfig: &tls.Config{GetCertificate: c.getServingCertificate},
+	}
+	// Listen before registering the webhook so that requests can be served
+	// as soon as the API server starts sending them
+	listener, err := net.Listen("tcp", c.server.Addr)
+	if err != nil {
+		return fmt.Errorf("error starting webhook server: %v", err)
+	}
+	go func() {
+		if err := c.server.ServeTLS(listener, "", ""); err != http.ErrServerClosed {
+			log.Panicf("Error starting webhook server: %v", err)
+		}
+	}()
+
+	if err := c.registerWebhook(caCert, c.getFailurePolicy()); err != nil {
+		return err
+	}
+	if c.AppInitializer != nil {
+		if err := c.registerMutatingWebhook(caCert); err != nil {
+			return err
+		}
+	}
+	c.stopChannel = make(chan struct{})
+	c.startHealthCheck(c.stopChannel)
+	c.startCertRotation(c.stopChannel)
+	c.started = true
+	return nil
+}
+
+// Stop Stops the webhook server
+func (c *Controller) Stop() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if !c.started {
+		return fmt.Errorf("webhook controller has not been started")
+	}
+
+	close(c.stopChannel)
+	// Stop failing requests while stork is going down, for eg during an
+	// upgrade. Replicas that are still running restore the policy on their
+	// next health check
+	if c.HealthCheckIntervalSec != 0 {
+		if err := c.setFailurePolicy(admissionregistration.Ignore); err != nil {
+			log.Warnf("Error updating webhook failure policy: %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := c.server.Shutdown(ctx); err != nil {
+		return err
+	}
+	c.started = false
+	return nil
+}
+
+// getCertificates returns the CA bundle and the serving certificate for the
+// webhook. They are stored in a secret so that all the replicas of stork
+// serve with the same certificate, since only one CA bundle can be
+// registered. New certificates are generated when the serving certificate is
+// about to expire.
+func (c *Controller) getCertificates() ([]byte, *tls.Certificate, error) {
+	secrets := c.KubeClient.CoreV1().Secrets(c.ServiceNamespace)
+	secret, err := secrets.Get(certSecretName, meta.GetOptions{})
+	if errors.IsNotFound(err) {
+		data, genErr := c.generateCertificateData(nil)
+		if genErr != nil {
+			return nil, nil, genErr
+		}
+		secret, err = secrets.Create(&v1.Secret{
+			ObjectMeta: meta.ObjectMeta{
+				Name:      certSecretName,
+				Namespace: c.ServiceNamespace,
+			},
+			Data: data,
+		})
+		// Another replica could have created the secret in the meantime
+		if errors.IsAlreadyExists(err) {
+			secret, err = secrets.Get(certSecretName, meta.GetOptions{})
+		}
+	} else if err == nil && needsRotation(secret.Data[certSecretCertKey], time.Now()) {
+		log.Infof("Rotating webhook certificates in secret %v/%v", c.ServiceNamespace, certSecretName)
+		data, genErr := c.generateCertificateData(secret.Data[certSecretCAKey])
+		if genErr != nil {
+			return nil, nil, genErr
+		}
+		secret.Data = data
+		secret, err = secrets.Update(secret)
+		// Another replica could have rotated the certificates in the meantime
+		if errors.IsConflict(err) {
+			secret, err = secrets.Get(certSecretName, meta.GetOptions{})
+		}
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("error getting webhook certificates: %v", err)
+	}
+
+	serverCert, err := tls.X509KeyPair(secret.Data[certSecretCertKey], secret.Data[certSecretKeyKey])
+	if err != nil {
+		return nil, nil, fmt.Errorf("error parsing webhook certificate from secret %v: %v", certSecretName, err)
+	}
+	return secret.Data[certSecretCAKey], &serverCert, nil
+}
+
+// generateCertificateData generates a new CA and serving certificate for the
+// secret. The CAs from the old bundle are kept in the bundle till they expire.
+func (c *Controller) generateCertificateData(oldCABundle []byte) (map[string][]byte, error) {
+	dnsName := fmt.Sprintf("%v.%v.svc", c.ServiceName, c.ServiceNamespace)
+	caCert, cert, key, err := generateCertificates(dnsName)
+	if err != nil {
+		return nil, fmt.Errorf("error generating webhook certificates: %v", err)
+	}
+	return map[string][]byte{
+		certSecretCAKey:   mergeCABundle(caCert, oldCABundle, time.Now()),
+		certSecretCertKey: cert,
+		certSecretKeyKey:  key,
+	}, nil
+}
+
+// getServingCertificate returns the current serving certificate for TLS
+// handshakes, so that rotated certificates are used without restarting the
+// server
+func (c *Controller) getServingCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.certLock.Lock()
+	defer c.certLock.Unlock()
+	return c.serverCert, nil
+}
+
+// rotateCertificates reloads the certificates from the secret, rotating them
+// if they are about to expire, and updates the CA bundle in the webhook
+// configurations
+func (c *Controller) rotateCertificates() error {
+	caBundle, serverCert, err := c.getCertificates()
+	if err != nil {
+		return err
+	}
+	c.certLock.Lock()
+	c.serverCert = serverCert
+	c.certLock.Unlock()
+	return c.updateCABundle(caBundle)
+}
+
+// startCertRotation keeps checking the certificates till the stop channel is
+// closed
+func (c *Controller) startCertRotation(stopChannel chan struct{}) {
+	go func() {
+		ticker := time.NewTicker(certCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := c.rotateCertificates(); err != nil {
+					log.Errorf("Error rotating webhook certificates: %v", err)
+				}
+			case <-stopChannel:
+				return
+			}
+		}
+	}()
+}
+
+// setCABundle sets the CA bundle for the webhooks and returns true if any of
+// them were updated
+func setCABundle(webhooks []admissionregistration.Webhook, caBundle []byte) bool {
+	updated := false
+	for i := range webhooks {
+		if !bytes.Equal(webhooks[i].ClientConfig.CABundle, caBundle) {
+			webhooks[i].ClientConfig.CABundle = caBundle
+			updated = true
+		}
+	}
+	return updated
+}
+
+// updateCABundle updates the CA bundle in the webhook configurations if it is
+// different
+func (c *Controller) updateCABundle(caBundle []byte) error {
+	validatingClient := c.KubeClient.AdmissionregistrationV1beta1().ValidatingWebhookConfigurations()
+	validatingConfig, err := validatingClient.Get(ValidatingWebhookConfigName, meta.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("error getting webhook configuration %v: %v", ValidatingWebhookConfigName, err)
+	}
+	if setCABundle(validatingConfig.Webhooks, caBundle) {
+		log.Infof("Updating CA bundle for webhook configuration %v", ValidatingWebhookConfigName)
+		if _, err := validatingClient.Update(validatingConfig); err != nil {
+			return fmt.Errorf("error updating webhook configuration %v: %v", ValidatingWebhookConfigName, err)
+		}
+	}
+	if c.AppInitializer == nil {
+		return nil
+	}
+
+	mutatingClient := c.KubeClient.AdmissionregistrationV1beta1().MutatingWebhookConfigurations()
+	mutatingConfig, err := mutatingClient.Get(MutatingWebhookConfigName, meta.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("error getting webhook configuration %v: %v", MutatingWebhookConfigName, err)
+	}
+	if setCABundle(mutatingConfig.Webhooks, caBundle) {
+		log.Infof("Updating CA bundle for webhook configuration %v", MutatingWebhookConfigName)
+		if _, err := mutatingClient.Update(mutatingConfig); err != nil {
+			return fmt.Errorf("error updating webhook configuration %v: %v", MutatingWebhookConfigName, err)
+		}
+	}
+	return nil
+}
+
+func (c *Controller) registerWebhook(caCert []byte, failurePolicy admissionregistration.FailurePolicyType) error {
+	path := validatePath
+	webhookConfig := &admissionregistration.ValidatingWebhookConfiguration{
+		ObjectMeta: meta.ObjectMeta{
+			Name: ValidatingWebhookConfigName,
+		},
+		Webhooks: []admissionregistration.Webhook{
+			{
+				Name: validatingWebhookName,
+				ClientConfig: admissionregistration.WebhookClientConfig{
+					Service: &admissionregistration.ServiceReference{
+						Name:      c.ServiceName,
+						Namespace: c.ServiceNamespace,
+						Path:      &path,
+					},
+					CABundle: caCert,
+				},
+				Rules: []admissionregistration.RuleWithOperations{
+					{
+						Operations: []admissionregistration.OperationType{
+							admissionregistration.Create,
+							admissionregistration.Update,
+						},
+						Rule: admissionregistration.Rule{
+							APIGroups:   []string{stork.GroupName},
+							APIVersions: []string{"v1alpha1"},
+							Resources:   validatedResources(),
+						},
+					},
+					{
+						Operations: []admissionregistration.OperationType{
+							admissionregistration.Create,
+							admissionregistration.Update,
+						},
+						Rule: admissionregistration.Rule{
+							APIGroups:   []string{snapv1.GroupName},
+							APIVersions: []string{"v1"},
+							Resources:   []string{snapv1.VolumeSnapshotResourcePlural},
+						},
+					},
+				},
+				FailurePolicy: &failurePolicy,
+			},
+		},
+	}
+
+	client := c.KubeClient.AdmissionregistrationV1beta1().ValidatingWebhookConfigurations()
+	existing, err := client.Get(ValidatingWebhookConfigName, meta.GetOptions{})
+	if errors.IsNotFound(err) {
+		_, err = client.Create(webhookConfig)
+	} else if err == nil {
+		existing.Webhooks = webhookConfig.Webhooks
+		_, err = client.Update(existing)
+	}
+	if err != nil {
+		return fmt.Errorf("error registering webhook configuration %v: %v", ValidatingWebhookConfigName, err)
+	}
+	return nil
+}
+
+func (c *Controller) serveValidate(w http.ResponseWriter, req *http.Request) {
+	serveAdmissionReview(w, req, func(request *admissionv1beta1.AdmissionRequest) *admissionv1beta1.AdmissionResponse {
+		response := &admissionv1beta1.AdmissionResponse{Allowed: true}
+		reason, code := meta.StatusReasonInvalid, int32(http.StatusUnprocessableEntity)
+		err := validateRequest(request)
+		if err == nil {
+			err = c.authorizeClusterRules(request)
+			if err == nil {
+				err = c.authorizeGroupSnapshotNamespaces(request)
+			}
+			if err == nil {
+				err = c.authorizeRestoreNamespaces(request)
+			}
+			if err != nil {
+				reason, code = meta.StatusReasonForbidden, http.StatusForbidden
+			}
+		}
+		if err != nil {
+			log.Infof("Rejecting %v %v/%v: %v", request.Kind.Kind, request.Namespace, request.Name, err)
+			response.Allowed = false
+			response.Result = &meta.Status{
+				Status:  meta.StatusFailure,
+				Reason:  reason,
+				Message: err.Error(),
+				Code:    code,
+			}
+		}
+		return response
+	})
+}
+
+// serveAdmissionReview decodes the admission review from the request and
+// responds with the response returned by the admit function
+func serveAdmissionReview(
+	w http.ResponseWriter,
+	req *http.Request,
+	admit func(*admissionv1beta1.AdmissionRequest) *admissionv1beta1.AdmissionResponse,
+) {
+	body, err := ioutil.ReadAll(req.Body)
+	defer func() {
+		if err := req.Body.Close(); err != nil {
+			log.Warnf("Error closing webhook request body: %v", err)
+		}
+	}()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	review := &admissionv1beta1.AdmissionReview{}
+	if err := json.Unmarshal(body, review); err != nil || review.Request == nil {
+		log.Errorf("Error decoding admission review: %v", err)
+		http.Error(w, "Decode error", http.StatusBadRequest)
+		return
+	}
+
+	response := admit(review.Request)
+	response.UID = review.Request.UID
+	review.Response = response
+	review.Request = nil
+
+	encoded, err := json.Marshal(review)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(encoded); err != nil {
+		log.Errorf("Error writing admission review response: %v", err)
+	}
+}
@@ -0,0 +1,557 @@
+// +build unittest
+
+package webhookadmission
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	fakeclient "github.com/libopenstorage/stork/pkg/client/clientset/versioned/fake"
+	"github.com/libopenstorage/stork/pkg/rule"
+	"github.com/portworx/sched-ops/k8s"
+	"github.com/stretchr/testify/require"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	admissionregistration "k8s.io/api/admissionregistration/v1beta1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakekube "k8s.io/client-go/kubernetes/fake"
+	core "k8s.io/client-go/testing"
+)
+
+func setup(t *testing.T) {
+	fakeStorkClient := fakeclient.NewSimpleClientset()
+	k8s.Instance().SetClient(fakekube.NewSimpleClientset(), nil, fakeStorkClient, nil, nil, nil)
+	rule.SetStorkClient(fakeStorkClient)
+	_, err := k8s.Instance().CreateSchedulePolicy(&stork_api.SchedulePolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "daily"},
+		Policy: stork_api.SchedulePolicyItem{
+			Daily: &stork_api.DailyPolicy{Time: "10:00PM"},
+		},
+	})
+	require.NoError(t, err, "Error creating schedule policy")
+	_, err = k8s.Instance().CreateSchedulePolicy(&stork_api.SchedulePolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "gold"},
+		Policy: stork_api.SchedulePolicyItem{
+			Interval: &stork_api.IntervalPolicy{IntervalMinutes: 15},
+		},
+		NamespaceSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{"tier": "gold"},
+		},
+	})
+	require.NoError(t, err, "Error creating schedule policy")
+	for name, tier := range map[string]string{"app": "silver", "db": "gold"} {
+		_, err = k8s.Instance().CreateNamespace(name, map[string]string{"tier": tier})
+		require.NoError(t, err, "Error creating namespace")
+	}
+	_, err = k8s.Instance().CreateRule(&stork_api.Rule{
+		ObjectMeta: metav1.ObjectMeta{Name: "prerule", Namespace: "app"},
+	})
+	require.NoError(t, err, "Error creating rule")
+	_, err = fakeStorkClient.StorkV1alpha1().ClusterRules().Create(&stork_api.ClusterRule{
+		ObjectMeta: metav1.ObjectMeta{Name: "fsfreeze"},
+		NamespaceSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{"tier": "silver"},
+		},
+	})
+	require.NoError(t, err, "Error creating cluster rule")
+}
+
+func TestValidateSchedulePolicy(t *testing.T) {
+	policy := &stork_api.SchedulePolicy{}
+	require.EqualError(t, validateSchedulePolicy(policy), "at least one of interval, daily, weekly, monthly or cron policies needs to be specified")
+
+	policy.Policy.Interval = &stork_api.IntervalPolicy{IntervalMinutes: 0}
+	require.EqualError(t, validateSchedulePolicy(policy), "Invalid intervalMinutes (0) in Interval policy")
+	policy.Policy.Interval = &stork_api.IntervalPolicy{IntervalMinutes: 10, Retain: -1}
+	require.EqualError(t, validateSchedulePolicy(policy), "Invalid retain (-1) in Interval policy")
+	policy.Policy.Interval.Retain = 5
+	require.NoError(t, validateSchedulePolicy(policy))
+
+	policy.NamespaceSelector = &metav1.LabelSelector{
+		MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "tier", Operator: "Matches"}},
+	}
+	require.EqualError(t, validateSchedulePolicy(policy), `Invalid namespaceSelector: "Matches" is not a valid pod selector operator`)
+	policy.NamespaceSelector = nil
+
+	policy.Policy.Weekly = &stork_api.WeeklyPolicy{Day: "Someday", Time: "10:00PM"}
+	require.EqualError(t, validateSchedulePolicy(policy), "Invalid day of the week (Someday) in Weekly policy")
+}
+
+func TestValidateMigration(t *testing.T) {
+	setup(t)
+	spec := &stork_api.MigrationSpec{}
+	require.EqualError(t, validateMigrationSpec(spec, nil, "app"), "clusterPair needs to be specified")
+	spec.ClusterPair = "remote"
+	require.EqualError(t, validateMigrationSpec(spec, nil, "app"), "at least one namespace needs to be specified")
+	spec.Namespaces = []string{"app", "db", "app"}
+	require.EqualError(t, validateMigrationSpec(spec, nil, "app"), "namespace app is specified more than once")
+	spec.Namespaces = []string{"app", "db"}
+	require.NoError(t, validateMigrationSpec(spec, nil, "app"))
+
+	spec.PreExecRule = "prerule"
+	require.NoError(t, validateMigrationSpec(spec, nil, "app"))
+	require.EqualError(t, validateMigrationSpec(spec, nil, "other"), "preExecRule prerule not found in namespace other")
+	spec.PostExecRule = "missing"
+	require.EqualError(t, validateMigrationSpec(spec, nil, "app"), "postExecRule missing not found in namespace app")
+
+	// Built-in templates don't need a rule in the namespace
+	spec.PostExecRule = "template/mongodb"
+	require.NoError(t, validateMigrationSpec(spec, nil, "app"))
+	spec.PostExecRule = "template/oracle"
+	require.Error(t, validateMigrationSpec(spec, nil, "app"))
+
+	// Cluster rules can only be used in the namespaces selected by them
+	spec.PreExecRule = ""
+	spec.PostExecRule = "clusterrule/fsfreeze"
+	require.NoError(t, validateMigrationSpec(spec, nil, "app"))
+	require.EqualError(t, validateMigrationSpec(spec, nil, "db"), "invalid postExecRule clusterrule/fsfreeze: cluster rule fsfreeze can't be used in namespace db")
+	spec.PostExecRule = "clusterrule/missing"
+	require.EqualError(t, validateMigrationSpec(spec, nil, "app"), "postExecRule clusterrule/missing not found")
+}
+
+func TestValidateSchedules(t *testing.T) {
+	setup(t)
+	migrationSchedule := &stork_api.MigrationSchedule{}
+	migrationSchedule.Spec.Template.Spec = stork_api.MigrationSpec{
+		ClusterPair: "remote",
+		Namespaces:  []string{"app"},
+	}
+	require.EqualError(t, validateMigrationSchedule(migrationSchedule, nil, "app"), "schedulePolicyName needs to be specified")
+	migrationSchedule.Spec.SchedulePolicyName = "missing"
+	require.EqualError(t, validateMigrationSchedule(migrationSchedule, nil, "app"), "schedule policy missing not found")
+	migrationSchedule.Spec.SchedulePolicyName = "daily"
+	require.NoError(t, validateMigrationSchedule(migrationSchedule, nil, "app"))
+	migrationSchedule.Name = "migrate"
+	migrationSchedule.Spec.DependsOn = &stork_api.ScheduleDependency{Kind: "Job", Name: "snapshots"}
+	require.EqualError(t, validateMigrationSchedule(migrationSchedule, nil, "app"), "invalid kind Job in dependsOn, expected MigrationSchedule or VolumeSnapshotSchedule")
+	migrationSchedule.Spec.DependsOn = &stork_api.ScheduleDependency{Kind: "MigrationSchedule", Name: "migrate"}
+	require.EqualError(t, validateMigrationSchedule(migrationSchedule, nil, "app"), "schedule can't depend on itself")
+	migrationSchedule.Spec.DependsOn = &stork_api.ScheduleDependency{Kind: "VolumeSnapshotSchedule", Name: "snapshots"}
+	require.NoError(t, validateMigrationSchedule(migrationSchedule, nil, "app"))
+	migrationSchedule.Spec.CatchUpPolicy = "Sometimes"
+	require.EqualError(t, validateMigrationSchedule(migrationSchedule, nil, "app"), "invalid catchUpPolicy Sometimes")
+	migrationSchedule.Spec.CatchUpPolicy = ""
+	migrationSchedule.Spec.ConcurrencyPolicy = "Sometimes"
+	require.EqualError(t, validateMigrationSchedule(migrationSchedule, nil, "app"), "invalid concurrencyPolicy Sometimes")
+	migrationSchedule.Spec.ConcurrencyPolicy = stork_api.ConcurrencyPolicyReplace
+	require.NoError(t, validateMigrationSchedule(migrationSchedule, nil, "app"))
+	migrationSchedule.Spec.Template.Spec.ClusterPair = ""
+	require.EqualError(t, validateMigrationSchedule(migrationSchedule, nil, "app"), "invalid migration template: clusterPair needs to be specified")
+
+	snapshotSchedule := &stork_api.VolumeSnapshotSchedule{}
+	snapshotSchedule.Spec.SchedulePolicyName = "daily"
+	require.NoError(t, validateVolumeSnapshotSchedule(snapshotSchedule, nil, "app"))
+	// Only namespaces matching the selector of the policy can use it
+	snapshotSchedule.Spec.SchedulePolicyName = "gold"
+	require.NoError(t, validateVolumeSnapshotSchedule(snapshotSchedule, nil, "db"))
+	require.EqualError(t, validateVolumeSnapshotSchedule(snapshotSchedule, nil, "app"), "schedule policy gold can't be used in namespace app")
+	snapshotSchedule.Spec.SchedulePolicyName = "daily"
+	snapshotSchedule.Spec.SnapshotType = "other"
+	require.EqualError(t, validateVolumeSnapshotSchedule(snapshotSchedule, nil, "app"), "invalid snapshotType other")
+	snapshotSchedule.Spec.SnapshotType = stork_api.VolumeSnapshotTypeCSI
+	snapshotSchedule.Spec.ReclaimPolicy = stork_api.ReclaimPolicyInvalid
+	require.EqualError(t, validateVolumeSnapshotSchedule(snapshotSchedule, nil, "app"), "invalid reclaimPolicy Invalid")
+	snapshotSchedule.Spec.ReclaimPolicy = stork_api.ReclaimPolicyRetain
+	snapshotSchedule.Spec.PostExecRule = "missing"
+	require.EqualError(t, validateVolumeSnapshotSchedule(snapshotSchedule, nil, "app"), "postExecRule missing not found in namespace app")
+
+	// Updates don't validate the references that haven't changed, so that
+	// schedules can be updated after the policy or rules were deleted
+	oldSnapshotSchedule := snapshotSchedule.DeepCopy()
+	snapshotSchedule.Spec.Suspend = &[]bool{true}[0]
+	require.NoError(t, validateVolumeSnapshotSchedule(snapshotSchedule, oldSnapshotSchedule, "app"))
+	snapshotSchedule.Spec.PreExecRule = "missing"
+	require.EqualError(t, validateVolumeSnapshotSchedule(snapshotSchedule, oldSnapshotSchedule, "app"), "preExecRule missing not found in namespace app")
+	snapshotSchedule.Spec.PreExecRule = ""
+	snapshotSchedule.Spec.SchedulePolicyName = "gold"
+	require.EqualError(t, validateVolumeSnapshotSchedule(snapshotSchedule, oldSnapshotSchedule, "app"), "schedule policy gold can't be used in namespace app")
+
+	oldMigrationSchedule := migrationSchedule.DeepCopy()
+	oldMigrationSchedule.Spec.Template.Spec.ClusterPair = "remote"
+	oldMigrationSchedule.Spec.SchedulePolicyName = "missing"
+	oldMigrationSchedule.Spec.Template.Spec.PostExecRule = "missing"
+	migrationSchedule = oldMigrationSchedule.DeepCopy()
+	require.NoError(t, validateMigrationSchedule(migrationSchedule, oldMigrationSchedule, "app"))
+	require.EqualError(t, validateMigrationSchedule(migrationSchedule, nil, "app"), "invalid migration template: postExecRule missing not found in namespace app")
+}
+
+func sendReview(t *testing.T, c *Controller, kind string, object interface{}) *admissionv1beta1.AdmissionResponse {
+	raw, err := json.Marshal(object)
+	require.NoError(t, err, "Error encoding object")
+	review := &admissionv1beta1.AdmissionReview{
+		Request: &admissionv1beta1.AdmissionRequest{
+			UID:       "uid",
+			Kind:      metav1.GroupVersionKind{Group: "stork.libopenstorage.org", Version: "v1alpha1", Kind: kind},
+			Namespace: "app",
+			Object:    runtime.RawExtension{Raw: raw},
+		},
+	}
+	body, err := json.Marshal(review)
+	require.NoError(t, err, "Error encoding admission review")
+
+	recorder := httptest.NewRecorder()
+	c.serveValidate(recorder, httptest.NewRequest(http.MethodPost, validatePath, bytes.NewReader(body)))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	response := &admissionv1beta1.AdmissionReview{}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), response), "Error decoding response")
+	require.NotNil(t, response.Response)
+	require.Equal(t, "uid", string(response.Response.UID))
+	return response.Response
+}
+
+func TestServeValidate(t *testing.T) {
+	setup(t)
+	c := &Controller{}
+
+	response := sendReview(t, c, "Migration", &stork_api.Migration{
+		Spec: stork_api.MigrationSpec{ClusterPair: "remote", Namespaces: []string{"app"}},
+	})
+	require.True(t, response.Allowed)
+
+	response = sendReview(t, c, "Migration", &stork_api.Migration{
+		Spec: stork_api.MigrationSpec{ClusterPair: "remote", Namespaces: []string{"app", "app"}},
+	})
+	require.False(t, response.Allowed)
+	require.Equal(t, "namespace app is specified more than once", response.Result.Message)
+
+	response = sendReview(t, c, "SchedulePolicy", &stork_api.SchedulePolicy{
+		Policy: stork_api.SchedulePolicyItem{
+			Daily: &stork_api.DailyPolicy{Time: "25:00"},
+		},
+	})
+	require.False(t, response.Allowed)
+
+	// Kinds that aren't validated are always allowed
+	response = sendReview(t, c, "ClusterPair", &stork_api.ClusterPair{})
+	require.True(t, response.Allowed)
+
+	// Updates that don't change the schedule policy are allowed after the
+	// policy has been deleted
+	raw, err := json.Marshal(&stork_api.VolumeSnapshotSchedule{
+		Spec: stork_api.VolumeSnapshotScheduleSpec{SchedulePolicyName: "deleted"},
+	})
+	require.NoError(t, err, "Error encoding schedule")
+	req := &admissionv1beta1.AdmissionRequest{
+		Kind:      metav1.GroupVersionKind{Kind: "VolumeSnapshotSchedule"},
+		Namespace: "app",
+		Operation: admissionv1beta1.Update,
+		Object:    runtime.RawExtension{Raw: raw},
+		OldObject: runtime.RawExtension{Raw: raw},
+	}
+	require.NoError(t, validateRequest(req))
+	req.Operation = admissionv1beta1.Create
+	require.EqualError(t, validateRequest(req), "schedule policy deleted not found")
+
+	recorder := httptest.NewRecorder()
+	c.serveValidate(recorder, httptest.NewRequest(http.MethodPost, validatePath, bytes.NewReader([]byte("bad"))))
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestAuthorizeClusterRules(t *testing.T) {
+	setup(t)
+	kubeClient := fakekube.NewSimpleClientset()
+	// Only the admin user is allowed to use the cluster rule
+	kubeClient.PrependReactor("create", "subjectaccessreviews", func(action core.Action) (bool, runtime.Object, error) {
+		review := action.(core.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		attributes := review.Spec.ResourceAttributes
+		review.Status.Allowed = review.Spec.User == "admin" && attributes.Verb == "use" &&
+			attributes.Resource == "clusterrules" && attributes.Name == "fsfreeze" && attributes.Namespace == "app"
+		return true, review, nil
+	})
+	c := &Controller{KubeClient: kubeClient}
+
+	migration := &stork_api.Migration{
+		Spec: stork_api.MigrationSpec{ClusterPair: "remote", Namespaces: []string{"app"}, PreExecRule: "clusterrule/fsfreeze"},
+	}
+	raw, err := json.Marshal(migration)
+	require.NoError(t, err, "Error encoding migration")
+	req := &admissionv1beta1.AdmissionRequest{
+		Kind:      metav1.GroupVersionKind{Kind: "Migration"},
+		Namespace: "app",
+		Object:    runtime.RawExtension{Raw: raw},
+		UserInfo:  authenticationv1.UserInfo{Username: "admin"},
+	}
+	require.NoError(t, c.authorizeClusterRules(req))
+	req.UserInfo.Username = "dev"
+	require.EqualError(t, c.authorizeClusterRules(req), "user dev isn't allowed to use cluster rule fsfreeze")
+
+	response := sendReview(t, c, "Migration", migration)
+	require.False(t, response.Allowed)
+	require.Equal(t, int32(http.StatusForbidden), response.Result.Code)
+
+	// Updates only authorize the rules that weren't referenced before
+	req.Operation = admissionv1beta1.Update
+	req.OldObject = runtime.RawExtension{Raw: raw}
+	require.NoError(t, c.authorizeClusterRules(req))
+	req.OldObject = runtime.RawExtension{Raw: []byte(`{"spec": {"preExecRule": "clusterrule/other"}}`)}
+	require.EqualError(t, c.authorizeClusterRules(req), "user dev isn't allowed to use cluster rule fsfreeze")
+
+	// Stork's service account is allowed to create objects using the rules
+	// from the objects it was created from
+	c.ServiceNamespace = "kube-system"
+	c.ServiceAccount = "stork-account"
+	req.UserInfo.Username = "system:serviceaccount:kube-system:stork-account"
+	require.NoError(t, c.authorizeClusterRules(req))
+	req.UserInfo.Username = "system:serviceaccount:app:stork-account"
+	require.Error(t, c.authorizeClusterRules(req))
+
+	// Rules in the namespace don't need to be authorized
+	migration.Spec.PreExecRule = "prerule"
+	response = sendReview(t, c, "Migration", migration)
+	require.True(t, response.Allowed)
+
+	// Group snapshots, rule annotations on snapshots and cluster domain
+	// updates are authorized too
+	response = sendReview(t, c, "GroupVolumeSnapshot", &stork_api.GroupVolumeSnapshot{
+		Spec: stork_api.GroupVolumeSnapshotSpec{PostExecRule: "clusterrule/fsfreeze"},
+	})
+	require.False(t, response.Allowed)
+	response = sendReview(t, c, "VolumeSnapshot", map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{"stork.libopenstorage.org/pre-snapshot-rule": "clusterrule/fsfreeze"},
+		},
+	})
+	require.False(t, response.Allowed)
+	require.Equal(t, "user  isn't allowed to use cluster rule fsfreeze", response.Result.Message)
+	references, err := ruleReferences("ClusterDomainUpdate", []byte(`{"spec": {"postExecRule": "clusterrule/fsfreeze", "ruleNamespace": "db"}}`), "")
+	require.NoError(t, err, "Error getting rule references")
+	require.Equal(t, []ruleReference{{name: "clusterrule/fsfreeze", namespace: "db"}}, references)
+}
+
+func TestAuthorizeGroupSnapshotNamespaces(t *testing.T) {
+	setup(t)
+	kubeClient := fakekube.NewSimpleClientset()
+	// The admin user is only allowed to create group snapshots in the db
+	// namespace
+	kubeClient.PrependReactor("create", "subjectaccessreviews", func(action core.Action) (bool, runtime.Object, error) {
+		review := action.(core.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		attributes := review.Spec.ResourceAttributes
+		review.Status.Allowed = review.Spec.User == "admin" && attributes.Verb == "create" &&
+			attributes.Resource == "groupvolumesnapshots" && attributes.Namespace == "db"
+		return true, review, nil
+	})
+	c := &Controller{KubeClient: kubeClient}
+
+	groupSnapshot := &stork_api.GroupVolumeSnapshot{
+		Spec: stork_api.GroupVolumeSnapshotSpec{Namespaces: []string{"app", "db"}},
+	}
+	raw, err := json.Marshal(groupSnapshot)
+	require.NoError(t, err, "Error encoding group snapshot")
+	req := &admissionv1beta1.AdmissionRequest{
+		Kind:      metav1.GroupVersionKind{Kind: "GroupVolumeSnapshot"},
+		Namespace: "app",
+		Object:    runtime.RawExtension{Raw: raw},
+		UserInfo:  authenticationv1.UserInfo{Username: "admin"},
+	}
+	require.NoError(t, c.authorizeGroupSnapshotNamespaces(req))
+	req.UserInfo.Username = "dev"
+	require.EqualError(t, c.authorizeGroupSnapshotNamespaces(req),
+		"user dev isn't allowed to create group snapshots in namespace db")
+
+	response := sendReview(t, c, "GroupVolumeSnapshot", groupSnapshot)
+	require.False(t, response.Allowed)
+	require.Equal(t, int32(http.StatusForbidden), response.Result.Code)
+
+	// Updates only authorize the namespaces that weren't selected before
+	req.Operation = admissionv1beta1.Update
+	req.OldObject = runtime.RawExtension{Raw: raw}
+	require.NoError(t, c.authorizeGroupSnapshotNamespaces(req))
+	req.OldObject = runtime.RawExtension{Raw: []byte(`{"spec": {"namespaces": ["app"]}}`)}
+	require.Error(t, c.authorizeGroupSnapshotNamespaces(req))
+
+	// Stork's service account isn't checked
+	c.ServiceNamespace = "kube-system"
+	c.ServiceAccount = "stork-account"
+	req.UserInfo.Username = "system:serviceaccount:kube-system:stork-account"
+	require.NoError(t, c.authorizeGroupSnapshotNamespaces(req))
+
+	// The namespace of the group snapshot doesn't need to be authorized
+	groupSnapshot.Spec.Namespaces = []string{"app"}
+	response = sendReview(t, c, "GroupVolumeSnapshot", groupSnapshot)
+	require.True(t, response.Allowed)
+}
+
+func TestAuthorizeRestoreNamespaces(t *testing.T) {
+	setup(t)
+	_, err := k8s.Instance().CreateGroupSnapshot(&stork_api.GroupVolumeSnapshot{
+		ObjectMeta: metav1.ObjectMeta{Name: "group", Namespace: "app"},
+		Spec:       stork_api.GroupVolumeSnapshotSpec{Namespaces: []string{"app", "db"}},
+	})
+	require.NoError(t, err, "Error creating group snapshot")
+	kubeClient := fakekube.NewSimpleClientset()
+	// The admin user is only allowed to create PVCs in the db namespace
+	kubeClient.PrependReactor("create", "subjectaccessreviews", func(action core.Action) (bool, runtime.Object, error) {
+		review := action.(core.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		attributes := review.Spec.ResourceAttributes
+		review.Status.Allowed = review.Spec.User == "admin" && attributes.Verb == "create" &&
+			attributes.Resource == "persistentvolumeclaims" && attributes.Namespace == "db"
+		return true, review, nil
+	})
+	c := &Controller{KubeClient: kubeClient}
+
+	groupRestore := &stork_api.GroupVolumeSnapshotRestore{
+		Spec: stork_api.GroupVolumeSnapshotRestoreSpec{SourceName: "group"},
+	}
+	raw, err := json.Marshal(groupRestore)
+	require.NoError(t, err, "Error encoding group restore")
+	req := &admissionv1beta1.AdmissionRequest{
+		Kind:      metav1.GroupVersionKind{Kind: "GroupVolumeSnapshotRestore"},
+		Namespace: "app",
+		Object:    runtime.RawExtension{Raw: raw},
+		UserInfo:  authenticationv1.UserInfo{Username: "admin"},
+	}
+	require.NoError(t, c.authorizeRestoreNamespaces(req))
+	req.UserInfo.Username = "dev"
+	require.EqualError(t, c.authorizeRestoreNamespaces(req), "user dev isn't allowed to create PVCs in namespace db")
+
+	// Updates that don't change the source aren't authorized again
+	req.Operation = admissionv1beta1.Update
+	req.OldObject = runtime.RawExtension{Raw: raw}
+	require.NoError(t, c.authorizeRestoreNamespaces(req))
+
+	// In-place restores need to update the PVCs
+	groupRestore.Spec.Type = stork_api.GroupVolumeSnapshotRestoreTypeInPlace
+	response := sendReview(t, c, "GroupVolumeSnapshotRestore", groupRestore)
+	require.False(t, response.Allowed)
+	require.Equal(t, "user  isn't allowed to update PVCs in namespace db", response.Result.Message)
+	response = sendReview(t, c, "VolumeSnapshotRestore", &stork_api.VolumeSnapshotRestore{
+		Spec: stork_api.VolumeSnapshotRestoreSpec{SourceName: "group", GroupSnapshot: true},
+	})
+	require.False(t, response.Allowed)
+	require.Equal(t, int32(http.StatusForbidden), response.Result.Code)
+
+	// Restores of a single snapshot and of group snapshots that don't exist
+	// are left to the controller
+	response = sendReview(t, c, "VolumeSnapshotRestore", &stork_api.VolumeSnapshotRestore{
+		Spec: stork_api.VolumeSnapshotRestoreSpec{SourceName: "group"},
+	})
+	require.True(t, response.Allowed)
+	response = sendReview(t, c, "GroupVolumeSnapshotRestore", &stork_api.GroupVolumeSnapshotRestore{
+		Spec: stork_api.GroupVolumeSnapshotRestoreSpec{SourceName: "missing"},
+	})
+	require.True(t, response.Allowed)
+}
+
+func TestRegistration(t *testing.T) {
+	kubeClient := fakekube.NewSimpleClientset()
+	c := &Controller{
+		KubeClient:       kubeClient,
+		ServiceName:      "stork-service",
+		ServiceNamespace: "kube-system",
+	}
+	caCert, _, err := c.getCertificates()
+	require.NoError(t, err, "Error getting certificates")
+	require.NotEmpty(t, caCert)
+
+	// Certificates should be reused from the secret
+	secondCACert, _, err := c.getCertificates()
+	require.NoError(t, err, "Error getting certificates")
+	require.Equal(t, caCert, secondCACert)
+
+	require.NoError(t, c.registerWebhook(caCert, admissionregistration.Fail), "Error registering webhook")
+	require.NoError(t, c.registerWebhook(caCert, admissionregistration.Fail), "Error updating webhook")
+	webhookConfig, err := kubeClient.AdmissionregistrationV1beta1().ValidatingWebhookConfigurations().Get(ValidatingWebhookConfigName, metav1.GetOptions{})
+	require.NoError(t, err, "Error getting webhook configuration")
+	require.Len(t, webhookConfig.Webhooks, 1)
+	require.Equal(t, caCert, webhookConfig.Webhooks[0].ClientConfig.CABundle)
+	require.Equal(t, "stork-service", webhookConfig.Webhooks[0].ClientConfig.Service.Name)
+	require.Equal(t, validatedResources(), webhookConfig.Webhooks[0].Rules[0].Resources)
+	require.Equal(t, []string{"volumesnapshots"}, webhookConfig.Webhooks[0].Rules[1].Resources)
+}
+
+func TestCertificateRotation(t *testing.T) {
+	kubeClient := fakekube.NewSimpleClientset()
+	c := &Controller{
+		KubeClient:       kubeClient,
+		ServiceName:      "stork-service",
+		ServiceNamespace: "kube-system",
+	}
+	caCert, serverCert, err := c.getCertificates()
+	require.NoError(t, err, "Error getting certificates")
+	c.serverCert = serverCert
+	require.NoError(t, c.registerWebhook(caCert, admissionregistration.Fail), "Error registering webhook")
+
+	secret, err := kubeClient.CoreV1().Secrets("kube-system").Get(certSecretName, metav1.GetOptions{})
+	require.NoError(t, err, "Error getting secret")
+	require.False(t, needsRotation(secret.Data[certSecretCertKey], time.Now()))
+	require.True(t, needsRotation(secret.Data[certSecretCertKey], time.Now().Add(certValidity-certRenewBefore)))
+
+	// Replace the serving certificate with one that is about to expire so
+	// that it gets rotated
+	oldCACert, oldCert, oldKey, err := generateCertificates("stork-service.kube-system.svc")
+	require.NoError(t, err, "Error generating certificates")
+	oldCerts, err := parseCertificates(oldCert)
+	require.NoError(t, err, "Error parsing certificate")
+	secret.Data = map[string][]byte{certSecretCAKey: oldCACert, certSecretCertKey: []byte("invalid"), certSecretKeyKey: oldKey}
+	_, err = kubeClient.CoreV1().Secrets("kube-system").Update(secret)
+	require.NoError(t, err, "Error updating secret")
+
+	require.NoError(t, c.rotateCertificates(), "Error rotating certificates")
+	secret, err = kubeClient.CoreV1().Secrets("kube-system").Get(certSecretName, metav1.GetOptions{})
+	require.NoError(t, err, "Error getting secret")
+	require.False(t, needsRotation(secret.Data[certSecretCertKey], time.Now()))
+	currentCert, err := c.getServingCertificate(nil)
+	require.NoError(t, err, "Error getting serving certificate")
+	require.NotEqual(t, serverCert, currentCert)
+
+	// The old CA is kept in the bundle so that the replicas still serving
+	// with the old certificate are trusted
+	bundle, err := parseCertificates(secret.Data[certSecretCAKey])
+	require.NoError(t, err, "Error parsing CA bundle")
+	require.Len(t, bundle, 2)
+	require.NoError(t, oldCerts[0].CheckSignatureFrom(bundle[1]))
+	newCerts, err := parseCertificates(secret.Data[certSecretCertKey])
+	require.NoError(t, err, "Error parsing certificate")
+	require.NoError(t, newCerts[0].CheckSignatureFrom(bundle[0]))
+	// Expired CAs are dropped from the bundle
+	require.Len(t, mergeCABundle(oldCACert, secret.Data[certSecretCAKey], time.Now().Add(2*certValidity)), len(oldCACert))
+
+	webhookConfig, err := kubeClient.AdmissionregistrationV1beta1().ValidatingWebhookConfigurations().Get(ValidatingWebhookConfigName, metav1.GetOptions{})
+	require.NoError(t, err, "Error getting webhook configuration")
+	require.Equal(t, secret.Data[certSecretCAKey], webhookConfig.Webhooks[0].ClientConfig.CABundle)
+}
+
+func TestFailurePolicyGating(t *testing.T) {
+	kubeClient := fakekube.NewSimpleClientset()
+	c := &Controller{
+		KubeClient:       kubeClient,
+		ServiceName:      "stork-service",
+		ServiceNamespace: "kube-system",
+	}
+	// Always fail requests when gating is disabled
+	require.Equal(t, admissionregistration.Fail, c.getFailurePolicy())
+
+	c.HealthCheckIntervalSec = 30
+	require.Equal(t, admissionregistration.Ignore, c.getFailurePolicy(), "Requests shouldn't fail without endpoints")
+	endpoints, err := kubeClient.CoreV1().Endpoints("kube-system").Create(&v1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{Name: "stork-service", Namespace: "kube-system"},
+		Subsets: []v1.EndpointSubset{
+			{NotReadyAddresses: []v1.EndpointAddress{{IP: "10.0.0.1"}}},
+		},
+	})
+	require.NoError(t, err, "Error creating endpoints")
+	require.Equal(t, admissionregistration.Ignore, c.getFailurePolicy(), "Requests shouldn't fail without ready endpoints")
+
+	endpoints.Subsets = append(endpoints.Subsets, v1.EndpointSubset{Addresses: []v1.EndpointAddress{{IP: "10.0.0.2"}}})
+	_, err = kubeClient.CoreV1().Endpoints("kube-system").Update(endpoints)
+	require.NoError(t, err, "Error updating endpoints")
+	require.Equal(t, admissionregistration.Fail, c.getFailurePolicy())
+
+	require.NoError(t, c.registerWebhook([]byte("ca"), admissionregistration.Fail), "Error registering webhook")
+	require.NoError(t, c.setFailurePolicy(admissionregistration.Ignore), "Error setting failure policy")
+	webhookConfig, err := kubeClient.AdmissionregistrationV1beta1().ValidatingWebhookConfigurations().Get(ValidatingWebhookConfigName, metav1.GetOptions{})
+	require.NoError(t, err, "Error getting webhook configuration")
+	require.Equal(t, admissionregistration.Ignore, *webhookConfig.Webhooks[0].FailurePolicy)
+	require.NoError(t, c.setFailurePolicy(admissionregistration.Fail), "Error setting failure policy")
+	webhookConfig, err = kubeClient.AdmissionregistrationV1beta1().ValidatingWebhookConfigurations().Get(ValidatingWebhookConfigName, metav1.GetOptions{})
+	require.NoError(t, err, "Error getting webhook configuration")
+	require.Equal(t, admissionregistration.Fail, *webhookConfig.Webhooks[0].FailurePolicy)
+}